@@ -1,22 +1,104 @@
 package bot
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ping is the request sent by the chat platform to verify that the endpoint belongs to the bot
+type Ping struct {
+}
 
 type ListSubscriptions struct {
 }
 
+// ListApps lists applications of the project, so users might discover what they can subscribe to
+type ListApps struct {
+	Project string
+}
+
+// WhoAmI returns the chat user and channel identity as seen by the bot
+type WhoAmI struct {
+}
+
 type UpdateSubscription struct {
 	App     string
 	Project string
 	Trigger string
 }
 
+// Acknowledge marks the firing trigger of the application as acknowledged
+type Acknowledge struct {
+	App     string
+	Trigger string
+}
+
+// Mute temporarily suppresses notifications of the application or project
+type Mute struct {
+	App      string
+	Project  string
+	Duration time.Duration
+}
+
+// ParseMuteDuration parses Go duration with the additional support of days, e.g. 2d
+func ParseMuteDuration(val string) (time.Duration, error) {
+	if strings.HasSuffix(val, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(val, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s'", val)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	duration, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s'", val)
+	}
+	return duration, nil
+}
+
+// OptOut stops notifications of the application trigger to the command recipient, including notifications of inherited
+// subscriptions. The command is not authorized using RBAC, so adapters must verify that the user owns the recipient
+type OptOut struct {
+	App     string
+	Trigger string
+}
+
+const (
+	AppActionSync     = "sync"
+	AppActionRefresh  = "refresh"
+	AppActionRollback = "rollback"
+)
+
+// AppAction is the operation on the application requested using the interactive message
+type AppAction struct {
+	App    string
+	Action string
+}
+
 type Command struct {
-	Service           string
-	Recipient         string
+	Service   string
+	Recipient string
+	// User is the name of the chat user who has sent the command
+	User string
+	// UserID is the immutable ID of the chat user who has sent the command. Unlike the name it cannot be changed by the
+	// user, so it is used to authorize the command if the adapter provides it
+	UserID            string
 	ListSubscriptions *ListSubscriptions
+	ListApps          *ListApps
+	WhoAmI            *WhoAmI
 	Subscribe         *UpdateSubscription
 	Unsubscribe       *UpdateSubscription
+	Acknowledge       *Acknowledge
+	Mute              *Mute
+	Unmute            *Mute
+	AppAction         *AppAction
+	OptOut            *OptOut
+	Ping              *Ping
+	// ResponseURL is the URL that accepts the response if the response cannot be sent in the reply, e.g. to the interactive message
+	ResponseURL string
 }
 
 // Adapter encapsulates integration with the notification service
@@ -26,3 +108,30 @@ type Adapter interface {
 	// Sends formatted response
 	SendResponse(content string, w http.ResponseWriter)
 }
+
+// RequestError is returned by adapters if the request must be rejected with the specific HTTP status, e.g. if the
+// request signature is invalid
+type RequestError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// CommandResponder is implemented by adapters that need the parsed command to send the response. The command might be
+// partially populated if the parsing has failed
+type CommandResponder interface {
+	SendCommandResponse(cmd Command, content string, w http.ResponseWriter)
+}
+
+// RequestHandler is implemented by adapters that serve some requests without executing the command, e.g. the page that
+// asks the user to confirm the command. ServeRequest returns false if the request should be parsed as the command
+type RequestHandler interface {
+	ServeRequest(w http.ResponseWriter, r *http.Request) bool
+}
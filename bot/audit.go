@@ -0,0 +1,84 @@
+package bot
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// Name returns the command name used in metrics and audit log
+func (cmd Command) Name() string {
+	switch {
+	case cmd.ListSubscriptions != nil:
+		return "list-subscriptions"
+	case cmd.ListApps != nil:
+		return "list-apps"
+	case cmd.WhoAmI != nil:
+		return "whoami"
+	case cmd.Subscribe != nil:
+		return "subscribe"
+	case cmd.Unsubscribe != nil:
+		return "unsubscribe"
+	case cmd.Acknowledge != nil:
+		return "ack"
+	case cmd.Mute != nil:
+		return "mute"
+	case cmd.Unmute != nil:
+		return "unmute"
+	case cmd.AppAction != nil:
+		return cmd.AppAction.Action
+	case cmd.OptOut != nil:
+		return "opt-out"
+	case cmd.Ping != nil:
+		return "ping"
+	default:
+		return "unknown"
+	}
+}
+
+// auditFields returns fields that describe the change made by the command or nil if the command is read-only
+func auditFields(cmd Command) log.Fields {
+	fields := log.Fields{}
+	switch {
+	case cmd.Subscribe != nil || cmd.Unsubscribe != nil:
+		opts := cmd.Subscribe
+		if opts == nil {
+			opts = cmd.Unsubscribe
+		}
+		fields["app"], fields["project"], fields["trigger"] = opts.App, opts.Project, opts.Trigger
+	case cmd.Acknowledge != nil:
+		fields["app"], fields["trigger"] = cmd.Acknowledge.App, cmd.Acknowledge.Trigger
+	case cmd.Mute != nil:
+		fields["app"], fields["project"], fields["duration"] = cmd.Mute.App, cmd.Mute.Project, cmd.Mute.Duration.String()
+	case cmd.Unmute != nil:
+		fields["app"], fields["project"] = cmd.Unmute.App, cmd.Unmute.Project
+	case cmd.AppAction != nil:
+		fields["app"] = cmd.AppAction.App
+	case cmd.OptOut != nil:
+		fields["app"], fields["trigger"] = cmd.OptOut.App, cmd.OptOut.Trigger
+	default:
+		return nil
+	}
+	for k, v := range fields {
+		if v == "" {
+			delete(fields, k)
+		}
+	}
+	fields["service"] = cmd.Service
+	fields["recipient"] = cmd.Recipient
+	fields["user"] = cmd.User
+	fields["command"] = cmd.Name()
+	return fields
+}
+
+// audit records who has requested the change of subscriptions, acknowledgments, mutes or applications and the result
+func (s *server) audit(cmd Command, err error) {
+	fields := auditFields(cmd)
+	if fields == nil {
+		return
+	}
+	entry := s.auditLogger.WithFields(fields).WithField("audit", true)
+	if err != nil {
+		entry.WithError(err).Warn("bot command failed")
+	} else {
+		entry.Info("bot command succeeded")
+	}
+}
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestCommandName(t *testing.T) {
+	assert.Equal(t, "subscribe", Command{Subscribe: &UpdateSubscription{}}.Name())
+	assert.Equal(t, "list-apps", Command{ListApps: &ListApps{}}.Name())
+	assert.Equal(t, "rollback", Command{AppAction: &AppAction{Action: AppActionRollback}}.Name())
+	assert.Equal(t, "unknown", Command{}.Name())
+}
+
+func TestAuditFields(t *testing.T) {
+	assert.Nil(t, auditFields(Command{ListSubscriptions: &ListSubscriptions{}}))
+
+	fields := auditFields(Command{Service: "slack", Recipient: "general", User: "alice", Mute: &Mute{Project: "prod", Duration: time.Hour}})
+	assert.Equal(t, log.Fields{
+		"service":   "slack",
+		"recipient": "general",
+		"user":      "alice",
+		"command":   "mute",
+		"project":   "prod",
+		"duration":  "1h0m0s",
+	}, fields)
+}
+
+func TestAudit(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	s := NewServer(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace, WithAuditLogger(logger))
+
+	s.audit(Command{User: "alice", WhoAmI: &WhoAmI{}}, nil)
+	assert.Empty(t, hook.AllEntries())
+
+	s.audit(Command{Service: "slack", User: "alice", Unsubscribe: &UpdateSubscription{App: "foo"}}, errors.New("denied"))
+	entry := hook.LastEntry()
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, log.WarnLevel, entry.Level)
+		assert.Equal(t, "alice", entry.Data["user"])
+		assert.Equal(t, "unsubscribe", entry.Data["command"])
+		assert.Equal(t, "foo", entry.Data["app"])
+		assert.Equal(t, true, entry.Data["audit"])
+	}
+}
+
+type fakeAdapter struct {
+	cmd Command
+	err error
+}
+
+func (a *fakeAdapter) Parse(_ *http.Request) (Command, error) {
+	return a.cmd, a.err
+}
+
+func (a *fakeAdapter) SendResponse(content string, w http.ResponseWriter) {
+	_, _ = w.Write([]byte(content))
+}
+
+type fakeMetrics struct {
+	commands        map[string]int
+	durations       int
+	invalidRequests int
+}
+
+func (m *fakeMetrics) IncCommandsCounter(service string, command string, succeeded bool) {
+	if succeeded {
+		m.commands[service+" "+command]++
+	}
+}
+
+func (m *fakeMetrics) ObserveCommandDuration(string, string, time.Duration) {
+	m.durations++
+}
+
+func (m *fakeMetrics) IncInvalidRequestsCounter(string) {
+	m.invalidRequests++
+}
+
+func TestHandler_RecordsMetrics(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	metrics := &fakeMetrics{commands: map[string]int{}}
+	s := NewServer(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace, WithMetrics(metrics), WithAuditLogger(logger))
+
+	w := httptest.NewRecorder()
+	s.handler(&fakeAdapter{cmd: Command{Service: "slack", Ping: &Ping{}}})(w, httptest.NewRequest(http.MethodPost, "/slack", nil))
+	assert.Equal(t, "pong", w.Body.String())
+
+	w = httptest.NewRecorder()
+	s.handler(&fakeAdapter{err: &RequestError{StatusCode: http.StatusUnauthorized, Err: errors.New("invalid signature")}})(w, httptest.NewRequest(http.MethodPost, "/slack", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	assert.Equal(t, map[string]int{"slack ping": 1}, metrics.commands)
+	assert.Equal(t, 1, metrics.durations)
+	assert.Equal(t, 1, metrics.invalidRequests)
+	assert.Empty(t, hook.AllEntries())
+}
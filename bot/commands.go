@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UsageError is returned by ParseCommandArgs if the command is unknown or has invalid arguments. Adapters format the
+// usage instructions of the platform using the error
+type UsageError struct {
+	// Command is the name of the command with invalid arguments. Empty if the command is missing or unknown
+	Command string
+	// Err explains what is wrong with the arguments. Nil if the command is missing or unknown
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	if e.Err == nil {
+		return "unknown command"
+	}
+	return e.Err.Error()
+}
+
+// ParseCommandArgs populates the command using the text command split into words, e.g. [subscribe guestbook on-sync-failed].
+// Adapters set the service, recipient and user of the command and map platform specific command names, e.g. Telegram's
+// list_apps, to the names used by this parser. The returned error is always *UsageError
+func ParseCommandArgs(cmd *Command, parts []string) error {
+	if len(parts) < 1 {
+		return &UsageError{}
+	}
+	// 'list subscriptions' and 'list apps' are aliases of 'list-subscriptions' and 'list-apps'
+	if parts[0] == "list" && len(parts) > 1 {
+		parts = append([]string{"list-" + parts[1]}, parts[2:]...)
+	}
+	command := parts[0]
+	usageErr := func(format string, args ...interface{}) error {
+		return &UsageError{Command: command, Err: fmt.Errorf(format, args...)}
+	}
+	switch command {
+	case "list-subscriptions":
+		cmd.ListSubscriptions = &ListSubscriptions{}
+	case "list-apps":
+		if len(parts) < 2 {
+			return usageErr("project name expected")
+		}
+		cmd.ListApps = &ListApps{Project: parts[1]}
+	case "whoami":
+		cmd.WhoAmI = &WhoAmI{}
+	case "subscribe", "unsubscribe":
+		if len(parts) < 2 {
+			return usageErr("at least one argument expected")
+		}
+		app, project, err := parseTarget(parts[1])
+		if err != nil {
+			return &UsageError{Command: command, Err: err}
+		}
+		update := &UpdateSubscription{App: app, Project: project}
+		if len(parts) > 2 {
+			update.Trigger = parts[2]
+		}
+		if command == "subscribe" {
+			cmd.Subscribe = update
+		} else {
+			cmd.Unsubscribe = update
+		}
+	case "ack":
+		if len(parts) < 3 {
+			return usageErr("application and trigger names expected")
+		}
+		cmd.Acknowledge = &Acknowledge{App: parts[1], Trigger: parts[2]}
+	case "mute", "unmute":
+		if len(parts) < 2 || command == "mute" && len(parts) < 3 {
+			return usageErr("not enough arguments")
+		}
+		app, project, err := parseTarget(parts[1])
+		if err != nil {
+			return &UsageError{Command: command, Err: err}
+		}
+		muteOpts := &Mute{App: app, Project: project}
+		if command == "unmute" {
+			cmd.Unmute = muteOpts
+			break
+		}
+		if muteOpts.Duration, err = ParseMuteDuration(parts[2]); err != nil {
+			return &UsageError{Command: command, Err: err}
+		}
+		cmd.Mute = muteOpts
+	default:
+		return &UsageError{}
+	}
+	return nil
+}
+
+// parseTarget parses the application name, optionally prefixed with 'app:', or the project name prefixed with 'proj:'
+func parseTarget(arg string) (string, string, error) {
+	nameParts := strings.SplitN(arg, ":", 2)
+	if len(nameParts) == 1 {
+		return arg, "", nil
+	}
+	switch {
+	case nameParts[1] == "":
+		return "", "", fmt.Errorf("name expected after '%s:'", nameParts[0])
+	case nameParts[0] == "app":
+		return nameParts[1], "", nil
+	case nameParts[0] == "proj":
+		return "", nameParts[1], nil
+	}
+	return "", "", fmt.Errorf("incorrect name argument: %s", arg)
+}
@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCommandArgs(t *testing.T) {
+	testCases := map[string]Command{
+		"list subscriptions":                  {ListSubscriptions: &ListSubscriptions{}},
+		"list-apps default":                   {ListApps: &ListApps{Project: "default"}},
+		"whoami":                              {WhoAmI: &WhoAmI{}},
+		"subscribe guestbook on-sync-failed":  {Subscribe: &UpdateSubscription{App: "guestbook", Trigger: "on-sync-failed"}},
+		"unsubscribe proj:default":            {Unsubscribe: &UpdateSubscription{Project: "default"}},
+		"ack app:guestbook on-sync-failed":    {Acknowledge: &Acknowledge{App: "guestbook", Trigger: "on-sync-failed"}},
+		"mute proj:default 2d":                {Mute: &Mute{Project: "default", Duration: 48 * time.Hour}},
+		"unmute guestbook":                    {Unmute: &Mute{App: "guestbook"}},
+		"subscribe app:guestbook on-deployed": {Subscribe: &UpdateSubscription{App: "guestbook", Trigger: "on-deployed"}},
+	}
+	for text, expected := range testCases {
+		t.Run(text, func(t *testing.T) {
+			cmd := Command{}
+			err := ParseCommandArgs(&cmd, strings.Fields(text))
+			if assert.NoError(t, err) {
+				assert.Equal(t, expected, cmd)
+			}
+		})
+	}
+}
+
+func TestParseCommandArgs_UsageError(t *testing.T) {
+	testCases := map[string]UsageError{
+		"":                       {},
+		"hello":                  {},
+		"list-apps":              {Command: "list-apps"},
+		"subscribe":              {Command: "subscribe"},
+		"subscribe cluster:prod": {Command: "subscribe"},
+		"mute guestbook":         {Command: "mute"},
+		"mute guestbook forever": {Command: "mute"},
+		"ack guestbook":          {Command: "ack"},
+	}
+	for text, expected := range testCases {
+		t.Run(text, func(t *testing.T) {
+			err := ParseCommandArgs(&Command{}, strings.Fields(text))
+			usageErr, ok := err.(*UsageError)
+			if !assert.True(t, ok, "expected usage error, got %v", err) {
+				return
+			}
+			assert.Equal(t, expected.Command, usageErr.Command)
+			assert.Equal(t, expected.Command != "", usageErr.Err != nil)
+		})
+	}
+}
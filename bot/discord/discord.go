@@ -0,0 +1,165 @@
+package discord
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+)
+
+const (
+	interactionTypePing               = 1
+	interactionTypeApplicationCommand = 2
+
+	responseTypePong                     = 1
+	responseTypeChannelMessageWithSource = 4
+)
+
+// NewDiscordAdapter returns adapter that handles application commands received using the interactions endpoint
+func NewDiscordAdapter(verifier RequestVerifier) *discord {
+	return &discord{verifier: verifier}
+}
+
+type discord struct {
+	verifier RequestVerifier
+}
+
+type commandOption struct {
+	Name    string          `json:"name"`
+	Value   interface{}     `json:"value"`
+	Options []commandOption `json:"options"`
+}
+
+type interaction struct {
+	Type      int    `json:"type"`
+	ChannelID string `json:"channel_id"`
+	Member    *struct {
+		User discordUser `json:"user"`
+	} `json:"member"`
+	User *discordUser `json:"user"`
+	Data struct {
+		Name    string          `json:"name"`
+		Options []commandOption `json:"options"`
+	} `json:"data"`
+}
+
+type discordUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// user returns the user who has sent the command. Discord sets the member in guild channels and the user in DMs
+func (i interaction) user() discordUser {
+	switch {
+	case i.Member != nil:
+		return i.Member.User
+	case i.User != nil:
+		return *i.User
+	}
+	return discordUser{}
+}
+
+// optionValues returns values of the subcommand options by name
+func optionValues(options []commandOption) map[string]string {
+	res := map[string]string{}
+	for _, opt := range options {
+		if opt.Value != nil {
+			res[opt.Name] = fmt.Sprintf("%v", opt.Value)
+		}
+	}
+	return res
+}
+
+func (d *discord) Parse(r *http.Request) (bot.Command, error) {
+	cmd := bot.Command{}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return cmd, err
+	}
+	service, err := d.verifier(data, r.Header)
+	if err != nil {
+		// Discord periodically checks that the endpoint rejects requests with invalid signatures
+		return cmd, &bot.RequestError{StatusCode: http.StatusUnauthorized, Err: fmt.Errorf("failed to verify request signature: %v", err)}
+	}
+	var req interaction
+	if err := json.Unmarshal(data, &req); err != nil {
+		return cmd, &bot.RequestError{StatusCode: http.StatusBadRequest, Err: err}
+	}
+	if req.Type == interactionTypePing {
+		cmd.Ping = &bot.Ping{}
+		return cmd, nil
+	}
+	if req.Type != interactionTypeApplicationCommand || len(req.Data.Options) == 0 {
+		return cmd, errors.New("unsupported interaction")
+	}
+	cmd.Service = service
+	cmd.Recipient = req.ChannelID
+	user := req.user()
+	cmd.User = user.Username
+	cmd.UserID = user.ID
+
+	subcommand := req.Data.Options[0]
+	values := optionValues(subcommand.Options)
+	switch subcommand.Name {
+	case "list-subscriptions":
+		cmd.ListSubscriptions = &bot.ListSubscriptions{}
+	case "list-apps":
+		cmd.ListApps = &bot.ListApps{Project: values["project"]}
+	case "whoami":
+		cmd.WhoAmI = &bot.WhoAmI{}
+	case "subscribe", "unsubscribe":
+		subscription := &bot.UpdateSubscription{App: values["app"], Project: values["project"], Trigger: values["trigger"]}
+		if subscription.App == "" && subscription.Project == "" {
+			return cmd, errors.New("either app or project option must be specified")
+		}
+		if subcommand.Name == "subscribe" {
+			cmd.Subscribe = subscription
+		} else {
+			cmd.Unsubscribe = subscription
+		}
+	case "ack":
+		cmd.Acknowledge = &bot.Acknowledge{App: values["app"], Trigger: values["trigger"]}
+	case "mute":
+		duration, err := bot.ParseMuteDuration(values["duration"])
+		if err != nil {
+			return cmd, err
+		}
+		cmd.Mute = &bot.Mute{App: values["app"], Project: values["project"], Duration: duration}
+	case "unmute":
+		cmd.Unmute = &bot.Mute{App: values["app"], Project: values["project"]}
+	case bot.AppActionSync, bot.AppActionRefresh, bot.AppActionRollback:
+		cmd.AppAction = &bot.AppAction{App: values["app"], Action: subcommand.Name}
+	default:
+		return cmd, fmt.Errorf("unknown command '%s'", subcommand.Name)
+	}
+	return cmd, nil
+}
+
+func (d *discord) SendResponse(content string, w http.ResponseWriter) {
+	d.writeResponse(map[string]interface{}{
+		"type": responseTypeChannelMessageWithSource,
+		"data": map[string]string{"content": content},
+	}, w)
+}
+
+// SendCommandResponse responds to the ping with pong and to commands with the channel message
+func (d *discord) SendCommandResponse(cmd bot.Command, content string, w http.ResponseWriter) {
+	if cmd.Ping != nil {
+		d.writeResponse(map[string]interface{}{"type": responseTypePong}, w)
+		return
+	}
+	d.SendResponse(content, w)
+}
+
+func (d *discord) writeResponse(res interface{}, w http.ResponseWriter) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,90 @@
+package discord
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+)
+
+var noopVerifier = func(data []byte, header http.Header) (string, error) {
+	return "discord", nil
+}
+
+func newInteractionRequest(body string) *http.Request {
+	return httptest.NewRequest("POST", "http://localhost/discord", bytes.NewBufferString(body))
+}
+
+func TestParse_Ping(t *testing.T) {
+	d := NewDiscordAdapter(noopVerifier)
+
+	cmd, err := d.Parse(newInteractionRequest(`{"type": 1}`))
+	assert.NoError(t, err)
+	assert.NotNil(t, cmd.Ping)
+}
+
+func TestParse_Subscribe(t *testing.T) {
+	d := NewDiscordAdapter(noopVerifier)
+
+	cmd, err := d.Parse(newInteractionRequest(`{
+		"type": 2,
+		"channel_id": "1234",
+		"member": {"user": {"id": "80351110224678912", "username": "alice"}},
+		"data": {"name": "argocd", "options": [{"name": "subscribe", "type": 1, "options": [
+			{"name": "app", "value": "guestbook"}, {"name": "trigger", "value": "on-sync-failed"}
+		]}]}
+	}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &bot.UpdateSubscription{App: "guestbook", Trigger: "on-sync-failed"}, cmd.Subscribe)
+	assert.Equal(t, "1234", cmd.Recipient)
+	assert.Equal(t, "discord", cmd.Service)
+	assert.Equal(t, "alice", cmd.User)
+	assert.Equal(t, "80351110224678912", cmd.UserID)
+}
+
+func TestParse_Sync(t *testing.T) {
+	d := NewDiscordAdapter(noopVerifier)
+
+	cmd, err := d.Parse(newInteractionRequest(`{
+		"type": 2,
+		"channel_id": "1234",
+		"data": {"name": "argocd", "options": [{"name": "sync", "type": 1, "options": [{"name": "app", "value": "guestbook"}]}]}
+	}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &bot.AppAction{App: "guestbook", Action: bot.AppActionSync}, cmd.AppAction)
+}
+
+func TestParse_InvalidSignature(t *testing.T) {
+	d := NewDiscordAdapter(func(data []byte, header http.Header) (string, error) {
+		return "", errors.New("invalid request signature")
+	})
+
+	_, err := d.Parse(newInteractionRequest(`{"type": 1}`))
+	var requestErr *bot.RequestError
+	if assert.True(t, errors.As(err, &requestErr)) {
+		assert.Equal(t, http.StatusUnauthorized, requestErr.StatusCode)
+	}
+}
+
+func TestSendCommandResponse(t *testing.T) {
+	d := NewDiscordAdapter(noopVerifier)
+
+	w := httptest.NewRecorder()
+	d.SendCommandResponse(bot.Command{Ping: &bot.Ping{}}, "pong", w)
+	assert.JSONEq(t, `{"type": 1}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	d.SendCommandResponse(bot.Command{}, "subscription updated", w)
+	assert.JSONEq(t, `{"type": 4, "data": {"content": "subscription updated"}}`, w.Body.String())
+}
@@ -0,0 +1,110 @@
+package discord
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/argoproj-labs/argocd-notifications/bot/slack"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// maxRequestAge is the maximum difference between the signed request timestamp and the current time, so captured
+// interactions cannot be replayed later
+const maxRequestAge = 5 * time.Minute
+
+type HasPublicKey interface {
+	GetPublicKey() string
+}
+
+// RequestVerifier checks the interaction signature and returns the name of the discord service
+type RequestVerifier func(data []byte, header http.Header) (string, error)
+
+type VerifierOpts func(v *verifier)
+
+// WithReplayCache rejects requests with the signature that has been already seen within the allowed request age
+func WithReplayCache(cache slack.ReplayCache) VerifierOpts {
+	return func(v *verifier) {
+		v.replayCache = cache
+	}
+}
+
+type verifier struct {
+	now         func() time.Time
+	replayCache slack.ReplayCache
+}
+
+// NewVerifier returns the verifier that checks the signature using public keys of all configured discord services and
+// returns the name of the service which key verifies the signature
+func NewVerifier(cfg settings.Config, opts ...VerifierOpts) RequestVerifier {
+	v := &verifier{now: time.Now}
+	for i := range opts {
+		opts[i](v)
+	}
+	return func(data []byte, header http.Header) (string, error) {
+		keys := map[string]ed25519.PublicKey{}
+		var names []string
+		configured := false
+		for name, service := range cfg.API.GetNotificationServices() {
+			hasKey, ok := services.Unwrap(service).(HasPublicKey)
+			if !ok {
+				continue
+			}
+			configured = true
+			publicKey, err := hex.DecodeString(hasKey.GetPublicKey())
+			if hasKey.GetPublicKey() == "" || err != nil || len(publicKey) != ed25519.PublicKeySize {
+				continue
+			}
+			keys[name] = publicKey
+			names = append(names, name)
+		}
+		switch {
+		case !configured:
+			return "", errors.New("discord is not configured")
+		case len(names) == 0:
+			return "", errors.New("discord public key is not configured")
+		}
+		sort.Strings(names)
+		return v.verify(names, keys, data, header)
+	}
+}
+
+// verify checks the signature of the timestamp and body as described in https://discord.com/developers/docs/interactions/receiving-and-responding#security-and-authorization
+func (v *verifier) verify(names []string, keys map[string]ed25519.PublicKey, data []byte, header http.Header) (string, error) {
+	signatureHex := header.Get("X-Signature-Ed25519")
+	signature, err := hex.DecodeString(signatureHex)
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return "", errors.New("request does not have valid signature")
+	}
+	timestamp := header.Get("X-Signature-Timestamp")
+	unixTime, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", errors.New("request does not have valid timestamp")
+	}
+	age := v.now().Sub(time.Unix(unixTime, 0))
+	if age > maxRequestAge || age < -maxRequestAge {
+		return "", fmt.Errorf("request timestamp is outside of the allowed %s window", maxRequestAge)
+	}
+	for _, name := range names {
+		if !ed25519.Verify(keys[name], append([]byte(timestamp), data...), signature) {
+			continue
+		}
+		if v.replayCache != nil {
+			added, err := v.replayCache.Add(signatureHex, time.Unix(unixTime, 0).Add(maxRequestAge))
+			if err != nil {
+				return "", fmt.Errorf("failed to check if request has been already processed: %v", err)
+			}
+			if !added {
+				return "", errors.New("request has been already processed")
+			}
+		}
+		return name, nil
+	}
+	return "", errors.New("invalid request signature")
+}
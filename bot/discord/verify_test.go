@@ -0,0 +1,125 @@
+package discord
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/argoproj-labs/argocd-notifications/bot/slack"
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestVerifier(t *testing.T, opts services.DiscordOptions, verifierOpts ...VerifierOpts) RequestVerifier {
+	api, err := pkg.NewAPI(pkg.Config{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	api.AddNotificationService("discord", services.NewDiscordService(opts))
+	return NewVerifier(settings.Config{API: api}, verifierOpts...)
+}
+
+func signedHeader(privateKey ed25519.PrivateKey, timestamp time.Time, data []byte) http.Header {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return http.Header{
+		"X-Signature-Ed25519":   {hex.EncodeToString(ed25519.Sign(privateKey, append([]byte(ts), data...)))},
+		"X-Signature-Timestamp": {ts},
+	}
+}
+
+func TestNewVerifier_ValidSignature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+	verifier := newTestVerifier(t, services.DiscordOptions{PublicKey: hex.EncodeToString(publicKey)})
+	data := []byte(`{"type": 1}`)
+
+	service, err := verifier(data, signedHeader(privateKey, time.Now(), data))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "discord", service)
+}
+
+func TestNewVerifier_ExpiredTimestamp(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+	verifier := newTestVerifier(t, services.DiscordOptions{PublicKey: hex.EncodeToString(publicKey)})
+	data := []byte(`{"type": 1}`)
+
+	_, err = verifier(data, signedHeader(privateKey, time.Now().Add(-10*time.Minute), data))
+
+	assert.EqualError(t, err, "request timestamp is outside of the allowed 5m0s window")
+}
+
+func TestNewVerifier_Replay(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+	verifier := newTestVerifier(t, services.DiscordOptions{PublicKey: hex.EncodeToString(publicKey)}, WithReplayCache(slack.NewReplayCache()))
+	data := []byte(`{"type": 1}`)
+	header := signedHeader(privateKey, time.Now(), data)
+
+	_, err = verifier(data, header)
+	assert.NoError(t, err)
+
+	_, err = verifier(data, header)
+	assert.EqualError(t, err, "request has been already processed")
+}
+
+func TestNewVerifier_MultipleServices(t *testing.T) {
+	api, err := pkg.NewAPI(pkg.Config{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	privateKeys := map[string]ed25519.PrivateKey{}
+	for _, name := range []string{"discord", "discord-other", "discord-third"} {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if !assert.NoError(t, err) {
+			return
+		}
+		privateKeys[name] = privateKey
+		api.AddNotificationService(name, services.NewDiscordService(services.DiscordOptions{PublicKey: hex.EncodeToString(publicKey)}))
+	}
+	verifier := NewVerifier(settings.Config{API: api})
+	data := []byte(`{"type": 1}`)
+
+	for name, privateKey := range privateKeys {
+		service, err := verifier(data, signedHeader(privateKey, time.Now(), data))
+		assert.NoError(t, err)
+		assert.Equal(t, name, service)
+	}
+}
+
+func TestNewVerifier_IncorrectSignature(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+	verifier := newTestVerifier(t, services.DiscordOptions{PublicKey: hex.EncodeToString(publicKey)})
+
+	_, err = verifier([]byte(`{"type": 1}`), http.Header{
+		"X-Signature-Ed25519":   {hex.EncodeToString(make([]byte, ed25519.SignatureSize))},
+		"X-Signature-Timestamp": {strconv.FormatInt(time.Now().Unix(), 10)},
+	})
+
+	assert.EqualError(t, err, "invalid request signature")
+}
+
+func TestNewVerifier_NoPublicKey(t *testing.T) {
+	verifier := newTestVerifier(t, services.DiscordOptions{})
+
+	_, err := verifier(nil, http.Header{})
+
+	assert.EqualError(t, err, "discord public key is not configured")
+}
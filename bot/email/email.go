@@ -0,0 +1,88 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/unsubscribe"
+)
+
+// LinkVerifier returns the unsubscribe link with the valid signature from the request query
+type LinkVerifier func(query url.Values) (unsubscribe.Link, error)
+
+func NewVerifier(cfg settings.Config) LinkVerifier {
+	return func(query url.Values) (unsubscribe.Link, error) {
+		if cfg.EmailUnsubscribe == nil {
+			return unsubscribe.Link{}, errors.New("email unsubscribe links are not configured")
+		}
+		return unsubscribe.Parse(query, []byte(cfg.EmailUnsubscribe.Key))
+	}
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Argo CD Notifications</title></head>
+<body>
+{{if .Link}}<form method="POST">
+<p>Stop sending the <b>{{.Link.Trigger}}</b> notifications of the <b>{{.Link.App}}</b> application to {{.Link.Recipient}}?</p>
+<button type="submit">Unsubscribe</button>
+</form>{{else}}<p>{{.Message}}</p>{{end}}
+</body>
+</html>
+`))
+
+// NewEmailAdapter returns adapter that handles signed unsubscribe links added to notification emails. Opening the
+// link shows the confirmation page, so link scanners of mail servers don't unsubscribe the recipient
+func NewEmailAdapter(verifier LinkVerifier) *email {
+	return &email{verifier: verifier}
+}
+
+type email struct {
+	verifier LinkVerifier
+}
+
+func (e *email) ServeRequest(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	link, err := e.verifier(r.URL.Query())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to verify unsubscribe link: %v", err), http.StatusUnauthorized)
+		return true
+	}
+	e.render(w, map[string]interface{}{"Link": link})
+	return true
+}
+
+// Parse handles the confirmation form as well as one-click unsubscribe requests described in RFC 8058
+func (e *email) Parse(r *http.Request) (bot.Command, error) {
+	if r.Method != http.MethodPost {
+		return bot.Command{}, &bot.RequestError{StatusCode: http.StatusMethodNotAllowed, Err: errors.New("method not allowed")}
+	}
+	link, err := e.verifier(r.URL.Query())
+	if err != nil {
+		return bot.Command{}, &bot.RequestError{StatusCode: http.StatusUnauthorized, Err: fmt.Errorf("failed to verify unsubscribe link: %v", err)}
+	}
+	return bot.Command{
+		Service:   link.Service,
+		Recipient: link.Recipient,
+		User:      link.Recipient,
+		OptOut:    &bot.OptOut{App: link.App, Trigger: link.Trigger},
+	}, nil
+}
+
+func (e *email) SendResponse(content string, w http.ResponseWriter) {
+	e.render(w, map[string]interface{}{"Message": content})
+}
+
+func (e *email) render(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pageTemplate.Execute(w, data); err != nil {
+		_, _ = w.Write([]byte(err.Error()))
+	}
+}
@@ -0,0 +1,74 @@
+package email
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/unsubscribe"
+)
+
+var (
+	testConfig = settings.Config{EmailUnsubscribe: &settings.EmailUnsubscribe{URL: "http://localhost/email/unsubscribe", Key: "my-key"}}
+	testLink   = unsubscribe.Link{App: "guestbook", Trigger: "on-sync-failed", Service: "email", Recipient: "alice@example.com", Expires: 4102444800}
+)
+
+func TestServeRequest_Confirmation(t *testing.T) {
+	e := NewEmailAdapter(NewVerifier(testConfig))
+	w := httptest.NewRecorder()
+
+	served := e.ServeRequest(w, httptest.NewRequest("GET", testLink.URL(testConfig.EmailUnsubscribe.URL, []byte("my-key")), nil))
+
+	assert.True(t, served)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<form method="POST">`)
+	assert.Contains(t, w.Body.String(), "alice@example.com")
+}
+
+func TestServeRequest_InvalidLink(t *testing.T) {
+	e := NewEmailAdapter(NewVerifier(testConfig))
+	w := httptest.NewRecorder()
+
+	served := e.ServeRequest(w, httptest.NewRequest("GET", testLink.URL(testConfig.EmailUnsubscribe.URL, []byte("other-key")), nil))
+
+	assert.True(t, served)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestParse_OptOut(t *testing.T) {
+	e := NewEmailAdapter(NewVerifier(testConfig))
+
+	assert.False(t, e.ServeRequest(httptest.NewRecorder(), httptest.NewRequest("POST", "http://localhost/email/unsubscribe", nil)))
+	cmd, err := e.Parse(httptest.NewRequest("POST", testLink.URL(testConfig.EmailUnsubscribe.URL, []byte("my-key")), nil))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, bot.Command{
+		Service:   "email",
+		Recipient: "alice@example.com",
+		User:      "alice@example.com",
+		OptOut:    &bot.OptOut{App: "guestbook", Trigger: "on-sync-failed"},
+	}, cmd)
+}
+
+func TestParse_NotConfigured(t *testing.T) {
+	e := NewEmailAdapter(NewVerifier(settings.Config{}))
+
+	_, err := e.Parse(httptest.NewRequest("POST", testLink.URL("http://localhost/email/unsubscribe", []byte("my-key")), nil))
+
+	assert.EqualError(t, err, "failed to verify unsubscribe link: email unsubscribe links are not configured")
+}
+
+func TestSendResponse_EscapesContent(t *testing.T) {
+	e := NewEmailAdapter(NewVerifier(testConfig))
+	w := httptest.NewRecorder()
+
+	e.SendResponse("<script>", w)
+
+	assert.Contains(t, w.Body.String(), "&lt;script&gt;")
+}
@@ -0,0 +1,49 @@
+package mattermost
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+	"github.com/argoproj-labs/argocd-notifications/bot/slack"
+)
+
+const (
+	// ResponseTypeEphemeral responses are visible only to the user who has sent the command
+	ResponseTypeEphemeral = "ephemeral"
+	// ResponseTypeInChannel responses are posted to the channel and visible to all channel members
+	ResponseTypeInChannel = "in_channel"
+)
+
+// NewMattermostAdapter returns adapter that handles Mattermost slash commands. Commands use the same syntax as the
+// Slack bot commands
+func NewMattermostAdapter(verifier RequestVerifier, responseType string) *mattermost {
+	return &mattermost{verifier: verifier, responseType: responseType}
+}
+
+type mattermost struct {
+	verifier     RequestVerifier
+	responseType string
+}
+
+func (m *mattermost) Parse(r *http.Request) (bot.Command, error) {
+	if err := r.ParseForm(); err != nil {
+		return bot.Command{}, err
+	}
+	service, err := m.verifier(r.PostForm.Get("token"))
+	if err != nil {
+		return bot.Command{}, &bot.RequestError{StatusCode: http.StatusUnauthorized, Err: fmt.Errorf("failed to verify request token: %v", err)}
+	}
+	return slack.ParseCommand(service, r.PostForm)
+}
+
+func (m *mattermost) SendResponse(content string, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	data, err := json.Marshal(map[string]string{"response_type": m.responseType, "text": content})
+	if err != nil {
+		_, _ = w.Write([]byte(err.Error()))
+	} else {
+		_, _ = w.Write(data)
+	}
+}
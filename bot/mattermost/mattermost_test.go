@@ -0,0 +1,70 @@
+package mattermost
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+)
+
+var noopVerifier = func(token string) (string, error) {
+	return "mattermost", nil
+}
+
+func newCommandRequest(body string) *http.Request {
+	req := httptest.NewRequest("POST", "http://localhost/mattermost", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestParse_Subscribe(t *testing.T) {
+	m := NewMattermostAdapter(noopVerifier, ResponseTypeEphemeral)
+
+	cmd, err := m.Parse(newCommandRequest("token=abc&command=%2Fargocd&text=subscribe%20guestbook%20on-sync-failed&channel_name=town-square&user_name=alice&user_id=rrxzbqrpoby6bdj9giwfpthbqc"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &bot.UpdateSubscription{App: "guestbook", Trigger: "on-sync-failed"}, cmd.Subscribe)
+	assert.Equal(t, "town-square", cmd.Recipient)
+	assert.Equal(t, "mattermost", cmd.Service)
+	assert.Equal(t, "alice", cmd.User)
+	assert.Equal(t, "rrxzbqrpoby6bdj9giwfpthbqc", cmd.UserID)
+}
+
+func TestParse_InvalidToken(t *testing.T) {
+	m := NewMattermostAdapter(func(token string) (string, error) {
+		return "", errors.New("invalid command token")
+	}, ResponseTypeEphemeral)
+
+	_, err := m.Parse(newCommandRequest("token=abc&text=list-subscriptions&channel_name=town-square"))
+
+	var reqErr *bot.RequestError
+	if assert.True(t, errors.As(err, &reqErr)) {
+		assert.Equal(t, http.StatusUnauthorized, reqErr.StatusCode)
+	}
+}
+
+func TestParse_Usage(t *testing.T) {
+	m := NewMattermostAdapter(noopVerifier, ResponseTypeEphemeral)
+
+	_, err := m.Parse(newCommandRequest("token=abc&command=%2Fnotifications&text=&channel_name=town-square"))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "/notifications subscribe <my-app>")
+}
+
+func TestSendResponse(t *testing.T) {
+	m := NewMattermostAdapter(noopVerifier, ResponseTypeInChannel)
+	w := httptest.NewRecorder()
+
+	m.SendResponse("hello", w)
+
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"response_type": "in_channel", "text": "hello"}`, w.Body.String())
+}
@@ -0,0 +1,39 @@
+package mattermost
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// HasCommandToken is implemented by services that might be configured as the Mattermost server
+type HasCommandToken interface {
+	GetCommandToken() string
+}
+
+// RequestVerifier checks the token of the slash command and returns the name of the service configured with the token
+type RequestVerifier func(token string) (string, error)
+
+// NewVerifier returns verifier that compares the request token with the commandToken of all configured services, so
+// the bot might serve several Mattermost servers or teams
+func NewVerifier(cfg settings.Config) RequestVerifier {
+	return func(token string) (string, error) {
+		configured := false
+		for name, service := range cfg.API.GetNotificationServices() {
+			hasToken, ok := services.Unwrap(service).(HasCommandToken)
+			if !ok || hasToken.GetCommandToken() == "" {
+				continue
+			}
+			configured = true
+			if subtle.ConstantTimeCompare([]byte(token), []byte(hasToken.GetCommandToken())) == 1 {
+				return name, nil
+			}
+		}
+		if !configured {
+			return "", errors.New("mattermost is not configured")
+		}
+		return "", errors.New("invalid command token")
+	}
+}
@@ -0,0 +1,46 @@
+package mattermost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+func newTestConfig(t *testing.T, svcs map[string]services.NotificationService) settings.Config {
+	api, err := pkg.NewAPI(pkg.Config{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for name, service := range svcs {
+		api.AddNotificationService(name, service)
+	}
+	return settings.Config{API: api}
+}
+
+func TestVerifier_NotConfigured(t *testing.T) {
+	verifier := NewVerifier(newTestConfig(t, map[string]services.NotificationService{
+		"slack": services.NewSlackService(services.SlackOptions{SigningSecret: "secret"}),
+	}))
+
+	_, err := verifier("abc")
+
+	assert.EqualError(t, err, "mattermost is not configured")
+}
+
+func TestVerifier_SelectsServiceByToken(t *testing.T) {
+	verifier := NewVerifier(newTestConfig(t, map[string]services.NotificationService{
+		"mattermost-dev":  services.NewSlackService(services.SlackOptions{CommandToken: "dev-token"}),
+		"mattermost-prod": services.NewSlackService(services.SlackOptions{CommandToken: "prod-token"}),
+	}))
+
+	service, err := verifier("prod-token")
+	assert.NoError(t, err)
+	assert.Equal(t, "mattermost-prod", service)
+
+	_, err = verifier("other-token")
+	assert.EqualError(t, err, "invalid command token")
+}
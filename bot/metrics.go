@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	commandsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_bot_commands_total",
+			Help: "Number of processed bot commands.",
+		},
+		[]string{"service", "command", "succeeded"},
+	)
+
+	commandDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "argocd_notifications_bot_command_duration_seconds",
+			Help:    "Duration of bot command processing.",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		},
+		[]string{"service", "command"},
+	)
+
+	invalidRequestsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_bot_invalid_requests_total",
+			Help: "Number of bot requests that could not be parsed or verified.",
+		},
+		[]string{"path"},
+	)
+)
+
+// MetricsRegistry records bot metrics
+type MetricsRegistry interface {
+	IncCommandsCounter(service string, command string, succeeded bool)
+	ObserveCommandDuration(service string, command string, duration time.Duration)
+	IncInvalidRequestsCounter(path string)
+}
+
+func NewMetricsRegistry() *botRegistry {
+	registry := &botRegistry{
+		Registry:                 prometheus.NewRegistry(),
+		commandsCounter:          commandsCounter,
+		commandDurationHistogram: commandDurationHistogram,
+		invalidRequestsCounter:   invalidRequestsCounter,
+	}
+	registry.MustRegister(commandsCounter)
+	registry.MustRegister(commandDurationHistogram)
+	registry.MustRegister(invalidRequestsCounter)
+	return registry
+}
+
+type botRegistry struct {
+	*prometheus.Registry
+	commandsCounter          *prometheus.CounterVec
+	commandDurationHistogram *prometheus.HistogramVec
+	invalidRequestsCounter   *prometheus.CounterVec
+}
+
+func (r *botRegistry) IncCommandsCounter(service string, command string, succeeded bool) {
+	r.commandsCounter.WithLabelValues(service, command, strconv.FormatBool(succeeded)).Inc()
+}
+
+func (r *botRegistry) ObserveCommandDuration(service string, command string, duration time.Duration) {
+	r.commandDurationHistogram.WithLabelValues(service, command).Observe(duration.Seconds())
+}
+
+func (r *botRegistry) IncInvalidRequestsCounter(path string) {
+	r.invalidRequestsCounter.WithLabelValues(path).Inc()
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) IncCommandsCounter(string, string, bool) {}
+
+func (noopMetrics) ObserveCommandDuration(string, string, time.Duration) {}
+
+func (noopMetrics) IncInvalidRequestsCounter(string) {}
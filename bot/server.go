@@ -2,17 +2,24 @@ package bot
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/argoproj-labs/argocd-notifications/pkg"
 	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/shared/ack"
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
 	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/mute"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
 
+	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/dynamic"
 )
 
@@ -21,39 +28,102 @@ type Server interface {
 	AddAdapter(path string, adapter Adapter)
 }
 
-func NewServer(dynamicClient dynamic.Interface, namespace string) *server {
-	return &server{
+// Opts configures optional bot features
+type Opts func(s *server)
+
+// WithConfig provides the notifications config used to resolve default subscriptions and triggers
+func WithConfig(getConfig func() settings.Config) Opts {
+	return func(s *server) {
+		s.getConfig = getConfig
+	}
+}
+
+// WithArgoCDClient enables application actions performed using the Argo CD API. Actions require RBAC enforcement
+func WithArgoCDClient(client argocd.APIClient) Opts {
+	return func(s *server) {
+		s.argocdClient = client
+	}
+}
+
+// WithRBAC enables the check that the user who has sent the command has the corresponding Argo CD permissions. Chat users
+// are mapped to Argo CD RBAC subjects using the bot identities configuration
+func WithRBAC(enforcer argocd.Enforcer) Opts {
+	return func(s *server) {
+		s.enforcer = enforcer
+	}
+}
+
+// WithMetrics enables recording of the bot metrics
+func WithMetrics(metrics MetricsRegistry) Opts {
+	return func(s *server) {
+		s.metrics = metrics
+	}
+}
+
+// WithAuditLogger overrides the logger that records commands which change subscriptions, acknowledgments, mutes and
+// applications. The standard logger is used by default
+func WithAuditLogger(logger log.FieldLogger) Opts {
+	return func(s *server) {
+		s.auditLogger = logger
+	}
+}
+
+func NewServer(dynamicClient dynamic.Interface, namespace string, opts ...Opts) *server {
+	s := &server{
 		mux:           http.NewServeMux(),
 		appClient:     k8s.NewAppClient(dynamicClient, namespace),
 		appProjClient: k8s.NewAppProjClient(dynamicClient, namespace),
+		metrics:       noopMetrics{},
+		auditLogger:   log.StandardLogger(),
+	}
+	for i := range opts {
+		opts[i](s)
 	}
+	return s
 }
 
 type server struct {
 	appClient     dynamic.ResourceInterface
 	appProjClient dynamic.ResourceInterface
 	mux           *http.ServeMux
-}
-
-func copyStringMap(in map[string]string) map[string]string {
-	out := make(map[string]string)
-	for k, v := range in {
-		out[k] = v
-	}
-	return out
+	argocdClient  argocd.APIClient
+	enforcer      argocd.Enforcer
+	getConfig     func() settings.Config
+	metrics       MetricsRegistry
+	auditLogger   log.FieldLogger
 }
 
 func (s *server) handler(adapter Adapter) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if handler, ok := adapter.(RequestHandler); ok && handler.ServeRequest(w, r) {
+			return
+		}
 		cmd, err := adapter.Parse(r)
-		if err != nil {
-			adapter.SendResponse(err.Error(), w)
+		sendResponse := adapter.SendResponse
+		if responder, ok := adapter.(CommandResponder); ok {
+			sendResponse = func(content string, w http.ResponseWriter) {
+				responder.SendCommandResponse(cmd, content, w)
+			}
+		}
+		var requestErr *RequestError
+		if errors.As(err, &requestErr) {
+			s.metrics.IncInvalidRequestsCounter(r.URL.Path)
+			http.Error(w, requestErr.Error(), requestErr.StatusCode)
+			return
+		} else if err != nil {
+			s.metrics.IncInvalidRequestsCounter(r.URL.Path)
+			sendResponse(err.Error(), w)
 			return
 		}
-		if res, err := s.execute(cmd); err != nil {
-			adapter.SendResponse(fmt.Sprintf("cannot execute command: %v", err), w)
+		start := time.Now()
+		res, err := s.execute(cmd)
+		s.metrics.ObserveCommandDuration(cmd.Service, cmd.Name(), time.Since(start))
+		s.metrics.IncCommandsCounter(cmd.Service, cmd.Name(), err == nil)
+		s.audit(cmd, err)
+		if err != nil {
+			sendResponse(fmt.Sprintf("cannot execute command: %v", err), w)
 		} else {
-			adapter.SendResponse(res, w)
+			sendResponse(res, w)
 		}
 	}
 }
@@ -62,29 +132,44 @@ func (s *server) execute(cmd Command) (string, error) {
 	switch {
 	case cmd.ListSubscriptions != nil:
 		return s.listSubscriptions(cmd.Service, cmd.Recipient)
+	case cmd.ListApps != nil:
+		return s.listApps(cmd.Service, cmd.Recipient, *cmd.ListApps)
+	case cmd.WhoAmI != nil:
+		return whoAmI(cmd), nil
 	case cmd.Subscribe != nil:
+		if err := s.authorizeUpdate(cmd, cmd.Subscribe.App, cmd.Subscribe.Project); err != nil {
+			return "", err
+		}
 		return s.updateSubscription(cmd.Service, cmd.Recipient, true, *cmd.Subscribe)
 	case cmd.Unsubscribe != nil:
+		if err := s.authorizeUpdate(cmd, cmd.Unsubscribe.App, cmd.Unsubscribe.Project); err != nil {
+			return "", err
+		}
 		return s.updateSubscription(cmd.Service, cmd.Recipient, false, *cmd.Unsubscribe)
-	default:
-		return "", errors.New("unknown command")
-	}
-}
-
-func annotationsPatch(old map[string]string, new map[string]string) map[string]*string {
-	patch := map[string]*string{}
-	for k := range new {
-		val := new[k]
-		if val != old[k] {
-			patch[k] = &val
+	case cmd.Acknowledge != nil:
+		if err := s.authorizeUpdate(cmd, cmd.Acknowledge.App, ""); err != nil {
+			return "", err
 		}
-	}
-	for k := range old {
-		if _, ok := new[k]; !ok {
-			patch[k] = nil
+		return s.acknowledge(cmd.User, *cmd.Acknowledge)
+	case cmd.Mute != nil:
+		if err := s.authorizeUpdate(cmd, cmd.Mute.App, cmd.Mute.Project); err != nil {
+			return "", err
+		}
+		return s.mute(cmd.User, true, *cmd.Mute)
+	case cmd.Unmute != nil:
+		if err := s.authorizeUpdate(cmd, cmd.Unmute.App, cmd.Unmute.Project); err != nil {
+			return "", err
 		}
+		return s.mute(cmd.User, false, *cmd.Unmute)
+	case cmd.AppAction != nil:
+		return s.runAppAction(cmd, *cmd.AppAction)
+	case cmd.OptOut != nil:
+		return s.optOut(cmd.Service, cmd.Recipient, *cmd.OptOut)
+	case cmd.Ping != nil:
+		return "pong", nil
+	default:
+		return "", errors.New("unknown command")
 	}
-	return patch
 }
 
 func (s *server) updateSubscription(service string, recipient string, subscribe bool, opts UpdateSubscription) (string, error) {
@@ -100,35 +185,173 @@ func (s *server) updateSubscription(service string, recipient string, subscribe
 	default:
 		return "", errors.New("either application or project name must be specified")
 	}
-	obj, err := client.Get(context.Background(), name, v1.GetOptions{})
+	// subscribing is idempotent and concurrent updates made by other bot replicas are retried, so the bot might be scaled
+	err := k8s.UpdateAnnotations(context.Background(), client, name, func(annotations map[string]string) {
+		if subscribe {
+			subscriptions.Annotations(annotations).Subscribe(opts.Trigger, service, recipient)
+		} else {
+			subscriptions.Annotations(annotations).Unsubscribe(opts.Trigger, service, recipient)
+		}
+	})
 	if err != nil {
 		return "", err
 	}
-	oldAnnotations := copyStringMap(obj.GetAnnotations())
-	annotations := subscriptions.Annotations(obj.GetAnnotations())
-	if subscribe {
-		annotations.Subscribe(opts.Trigger, service, recipient)
-	} else {
-		annotations.Unsubscribe(opts.Trigger, service, recipient)
-	}
-	annotationsPatch := annotationsPatch(oldAnnotations, annotations)
-	if len(annotationsPatch) > 0 {
-		patch := map[string]map[string]interface{}{
-			"metadata": {
-				"annotations": annotationsPatch,
-			},
-		}
-		patchData, err := json.Marshal(patch)
-		if err != nil {
+	return "subscription updated", nil
+}
+
+// optOut removes the recipient from the application subscriptions and opts it out from inherited subscriptions of the trigger
+func (s *server) optOut(service string, recipient string, opts OptOut) (string, error) {
+	err := k8s.UpdateAnnotations(context.Background(), s.appClient, opts.App, func(annotations map[string]string) {
+		subscriptions.Annotations(annotations).Unsubscribe(opts.Trigger, service, recipient)
+		subscriptions.Annotations(annotations).OptOut(opts.Trigger, service, recipient)
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s is unsubscribed from the %s notifications of the %s application", recipient, opts.Trigger, opts.App), nil
+}
+
+func (s *server) acknowledge(user string, opts Acknowledge) (string, error) {
+	if err := ack.Acknowledge(context.Background(), s.appClient, opts.App, opts.Trigger, user); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("trigger %s of application %s acknowledged", opts.Trigger, opts.App), nil
+}
+
+func (s *server) mute(user string, enabled bool, opts Mute) (string, error) {
+	var name, kind string
+	var client dynamic.ResourceInterface
+	switch {
+	case opts.App != "":
+		name, kind, client = opts.App, "application", s.appClient
+	case opts.Project != "":
+		name, kind, client = opts.Project, "project", s.appProjClient
+	default:
+		return "", errors.New("either application or project name must be specified")
+	}
+	ctx := context.Background()
+	if !enabled {
+		if err := mute.Unmute(ctx, client, name); err != nil {
 			return "", err
 		}
-		_, err = client.Patch(context.Background(), name, types.MergePatchType, patchData, v1.PatchOptions{})
+		return fmt.Sprintf("notifications of %s %s unmuted", kind, name), nil
+	}
+	if opts.Duration <= 0 {
+		return "", errors.New("mute duration must be positive")
+	}
+	if err := mute.Mute(ctx, client, name, opts.Duration, user); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("notifications of %s %s muted for %s", kind, name, opts.Duration), nil
+}
+
+// appActionPermissions holds Argo CD RBAC actions required to perform application actions
+var appActionPermissions = map[string]string{
+	AppActionSync:     argocd.ActionSync,
+	AppActionRollback: argocd.ActionSync,
+	AppActionRefresh:  argocd.ActionGet,
+}
+
+// authorize returns an error if none of the Argo CD RBAC subjects of the command user is allowed to perform the action
+func (s *server) authorize(cmd Command, resource string, action string, object string) error {
+	var identities settings.BotIdentities
+	if s.getConfig != nil {
+		identities = s.getConfig().BotIdentities
+	}
+	if cmd.UserID == "" {
+		return fmt.Errorf("user %s is not allowed to %s %s %s: the %s service does not provide the user ID", cmd.User, action, resource, object, cmd.Service)
+	}
+	subjects := identities.Resolve(cmd.Service, cmd.UserID)
+	if len(subjects) == 0 {
+		return fmt.Errorf("user %s is not allowed to %s %s %s: user ID %s is not mapped to Argo CD RBAC subjects", cmd.User, action, resource, object, cmd.UserID)
+	}
+	ctx := context.Background()
+	for _, subject := range subjects {
+		allowed, err := s.enforcer.Enforce(ctx, subject, resource, action, object)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("user %s is not allowed to %s %s %s", cmd.User, action, resource, object)
+}
+
+// authorizeUpdate checks that the user is allowed to update the application or project if RBAC is enabled
+func (s *server) authorizeUpdate(cmd Command, app string, project string) error {
+	if s.enforcer == nil {
+		return nil
+	}
+	switch {
+	case app != "":
+		obj, err := s.appClient.Get(context.Background(), app, v1.GetOptions{})
 		if err != nil {
-			return "", err
+			return err
 		}
+		appProject, _, _ := unstructured.NestedString(obj.Object, "spec", "project")
+		return s.authorize(cmd, argocd.ResourceApplications, argocd.ActionUpdate, appProject+"/"+app)
+	case project != "":
+		return s.authorize(cmd, argocd.ResourceProjects, argocd.ActionUpdate, project)
 	}
+	return nil
+}
 
-	return "subscription updated", nil
+func (s *server) runAppAction(cmd Command, action AppAction) (string, error) {
+	if s.argocdClient == nil {
+		return "", errors.New("Argo CD API is not configured")
+	}
+	if s.enforcer == nil {
+		return "", errors.New("application actions require Argo CD RBAC enforcement")
+	}
+	permission, ok := appActionPermissions[action.Action]
+	if !ok {
+		return "", fmt.Errorf("unknown action '%s'", action.Action)
+	}
+	ctx := context.Background()
+	app, err := s.appClient.Get(ctx, action.App, v1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+	if err := s.authorize(cmd, argocd.ResourceApplications, permission, project+"/"+action.App); err != nil {
+		return "", err
+	}
+	switch action.Action {
+	case AppActionSync:
+		err = s.argocdClient.Sync(ctx, action.App)
+	case AppActionRefresh:
+		err = s.argocdClient.Refresh(ctx, action.App)
+	case AppActionRollback:
+		err = s.argocdClient.Rollback(ctx, action.App)
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s of application %s requested by %s", action.Action, action.App, cmd.User), nil
+}
+
+// subscribedTriggers returns triggers of the subscriptions that notify the recipient
+func subscribedTriggers(subs pkg.Subscriptions, service string, recipient string) []string {
+	var res []string
+	for trigger, destinations := range subs {
+		for _, dest := range destinations {
+			if dest.Service == service && dest.Recipient == recipient {
+				res = append(res, trigger)
+				break
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func formatSubscription(obj unstructured.Unstructured, triggers []string) string {
+	res := fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())
+	if len(triggers) > 0 {
+		res = fmt.Sprintf("%s (%s)", res, strings.Join(triggers, ", "))
+	}
+	return res
 }
 
 func (s *server) listSubscriptions(service string, recipient string) (string, error) {
@@ -136,20 +359,31 @@ func (s *server) listSubscriptions(service string, recipient string) (string, er
 	if err != nil {
 		return "", err
 	}
-	var apps []string
-	for _, app := range appList.Items {
-		if subscriptions.Annotations(app.GetAnnotations()).Has(service, recipient) {
-			apps = append(apps, fmt.Sprintf("%s/%s", app.GetNamespace(), app.GetName()))
-		}
+	var cfg settings.Config
+	if s.getConfig != nil {
+		cfg = s.getConfig()
 	}
 	appProjList, err := s.appProjClient.List(context.Background(), v1.ListOptions{})
 	if err != nil {
 		return "", err
 	}
+	projects := map[string]*unstructured.Unstructured{}
 	var appProjs []string
-	for _, appProj := range appProjList.Items {
-		if subscriptions.Annotations(appProj.GetAnnotations()).Has(service, recipient) {
-			appProjs = append(appProjs, fmt.Sprintf("%s/%s", appProj.GetNamespace(), appProj.GetName()))
+	for i := range appProjList.Items {
+		appProj := appProjList.Items[i]
+		projects[appProj.GetName()] = &appProj
+		annotations := subscriptions.Annotations(appProj.GetAnnotations())
+		if annotations.Has(service, recipient) {
+			appProjs = append(appProjs, formatSubscription(appProj, subscribedTriggers(annotations.GetAll(cfg.DefaultTriggers...), service, recipient)))
+		}
+	}
+	var apps []string
+	for i := range appList.Items {
+		app := appList.Items[i]
+		projName, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+		triggers := subscribedTriggers(cfg.ResolveSubscriptions(&app, settings.SubscriptionSources{Project: projects[projName]}), service, recipient)
+		if len(triggers) > 0 || subscriptions.Annotations(app.GetAnnotations()).Has(service, recipient) {
+			apps = append(apps, formatSubscription(app, triggers))
 		}
 	}
 	response := fmt.Sprintf("The %s has no subscriptions.", recipient)
@@ -166,6 +400,43 @@ func (s *server) listSubscriptions(service string, recipient string) (string, er
 	return response, nil
 }
 
+func (s *server) listApps(service string, recipient string, opts ListApps) (string, error) {
+	if opts.Project == "" {
+		return "", errors.New("project name must be specified")
+	}
+	appList, err := s.appClient.List(context.Background(), v1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	var apps []string
+	for _, app := range appList.Items {
+		if project, _, _ := unstructured.NestedString(app.Object, "spec", "project"); project != opts.Project {
+			continue
+		}
+		name := app.GetName()
+		if subscriptions.Annotations(app.GetAnnotations()).Has(service, recipient) {
+			name = name + " (subscribed)"
+		}
+		apps = append(apps, name)
+	}
+	if len(apps) == 0 {
+		return fmt.Sprintf("The project %s has no applications.", opts.Project), nil
+	}
+	sort.Strings(apps)
+	return fmt.Sprintf("The project %s has %d applications: %s.", opts.Project, len(apps), strings.Join(apps, ", ")), nil
+}
+
+func whoAmI(cmd Command) string {
+	user := cmd.User
+	if user == "" {
+		user = "unknown user"
+	}
+	if cmd.UserID != "" {
+		user = fmt.Sprintf("%s (user ID %s)", user, cmd.UserID)
+	}
+	return fmt.Sprintf("You are %s in the %s of the %s service.", user, cmd.Recipient, cmd.Service)
+}
+
 func (s *server) AddAdapter(pattern string, adapter Adapter) {
 	s.mux.HandleFunc(pattern, s.handler(adapter))
 }
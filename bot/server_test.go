@@ -1,16 +1,20 @@
 package bot
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
 	. "github.com/argoproj-labs/argocd-notifications/testing"
 
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic/fake"
-	"k8s.io/utils/pointer"
 )
 
 func TestListRecipients_NoSubscriptions(t *testing.T) {
@@ -50,23 +54,82 @@ func TestListSubscriptions_HasAppProjSubscription(t *testing.T) {
 	assert.Contains(t, response, "Projects: default/bar")
 }
 
+func TestListSubscriptions_AppInheritsProjSubscription(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		NewApp("foo", WithProject("bar")),
+		NewProject("bar", WithAnnotations(map[string]string{subscriptions.SubscribeAnnotationKey("my-trigger", "slack"): "general"})))
+	s := NewServer(client, TestNamespace)
+
+	response, err := s.listSubscriptions("slack", "general")
+
+	assert.NoError(t, err)
+
+	assert.Contains(t, response, "Applications: default/foo (my-trigger)")
+	assert.Contains(t, response, "Projects: default/bar")
+}
+
+func TestListSubscriptions_DefaultSubscriptionsAndTriggers(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		NewApp("foo", WithProject("prod")),
+		NewApp("bar", WithAnnotations(map[string]string{subscriptions.SubscribeAnnotationKey("my-trigger", "slack"): "general"})),
+		NewApp("baz", WithProject("prod"), WithAnnotations(map[string]string{subscriptions.UnsubscribeAnnotationKey("", "slack"): "general"})))
+	s := NewServer(client, TestNamespace, WithConfig(func() settings.Config {
+		return settings.Config{Subscriptions: settings.DefaultSubscriptions{{
+			Recipients: []string{"slack:general"},
+			Triggers:   []string{"on-deployed"},
+			Projects:   []string{"prod"},
+		}}}
+	}))
+
+	response, err := s.listSubscriptions("slack", "general")
+
+	assert.NoError(t, err)
+	assert.Contains(t, response, "default/bar (my-trigger)")
+	assert.Contains(t, response, "default/foo (on-deployed)")
+	assert.NotContains(t, response, "baz")
+}
+
+func TestListApps(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		NewApp("foo", WithProject("prod")),
+		NewApp("bar", WithProject("prod"), WithAnnotations(map[string]string{subscriptions.SubscribeAnnotationKey("my-trigger", "slack"): "general"})),
+		NewApp("baz", WithProject("dev")))
+	s := NewServer(client, TestNamespace)
+
+	response, err := s.execute(Command{Service: "slack", Recipient: "general", ListApps: &ListApps{Project: "prod"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "The project prod has 2 applications: bar (subscribed), foo.", response)
+}
+
+func TestWhoAmI(t *testing.T) {
+	s := NewServer(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace)
+
+	response, err := s.execute(Command{Service: "slack", Recipient: "general", User: "alice", WhoAmI: &WhoAmI{}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "You are alice in the general of the slack service.", response)
+
+	response, err = s.execute(Command{Service: "slack", Recipient: "general", User: "alice", UserID: "U1234", WhoAmI: &WhoAmI{}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "You are alice (user ID U1234) in the general of the slack service.", response)
+}
+
 func TestUpdateSubscription_SubscribeToApp(t *testing.T) {
 	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithAnnotations(map[string]string{
 		subscriptions.SubscribeAnnotationKey("my-trigger", "slack"): "channel1",
 	})))
 
-	var patches []map[string]interface{}
-	AddPatchCollectorReactor(client, &patches)
-
 	s := NewServer(client, TestNamespace)
 
 	resp, err := s.updateSubscription("slack", "channel2", true, UpdateSubscription{App: "foo", Trigger: "my-trigger"})
 	assert.NoError(t, err)
 	assert.Equal(t, "subscription updated", resp)
-	assert.Len(t, patches, 1)
 
-	val, _, _ := unstructured.NestedString(patches[0], "metadata", "annotations", subscriptions.SubscribeAnnotationKey("my-trigger", "slack"))
-	assert.Equal(t, val, "channel1;channel2")
+	app, err := s.appClient.Get(context.Background(), "foo", v1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "channel1;channel2", app.GetAnnotations()[subscriptions.SubscribeAnnotationKey("my-trigger", "slack")])
 }
 
 func TestUpdateSubscription_SubscribeToAppTrigger(t *testing.T) {
@@ -74,35 +137,217 @@ func TestUpdateSubscription_SubscribeToAppTrigger(t *testing.T) {
 		subscriptions.SubscribeAnnotationKey("my-trigger", "slack"): "channel1",
 	})))
 
+	s := NewServer(client, TestNamespace)
+
+	resp, err := s.updateSubscription("slack", "channel2", true, UpdateSubscription{App: "foo", Trigger: "on-sync-failed"})
+	assert.NoError(t, err)
+	assert.Equal(t, "subscription updated", resp)
+
+	app, err := s.appClient.Get(context.Background(), "foo", v1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "channel2", app.GetAnnotations()[subscriptions.SubscribeAnnotationKey("on-sync-failed", "slack")])
+}
+
+func TestUpdateSubscription_AlreadySubscribed(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "slack"): "channel1",
+	})))
 	var patches []map[string]interface{}
 	AddPatchCollectorReactor(client, &patches)
-
 	s := NewServer(client, TestNamespace)
 
-	resp, err := s.updateSubscription("slack", "channel2", true, UpdateSubscription{App: "foo", Trigger: "on-sync-failed"})
+	resp, err := s.updateSubscription("slack", "channel1", true, UpdateSubscription{App: "foo", Trigger: "my-trigger"})
 	assert.NoError(t, err)
 	assert.Equal(t, "subscription updated", resp)
+	assert.Empty(t, patches)
+}
+
+func TestOptOut(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithProject("my-proj"), WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("on-sync-failed", "email"): "alice@example.com",
+	})))
+	// opt-out is authorized by the adapter, so RBAC denies are ignored
+	s := NewServer(client, TestNamespace, WithRBAC(fakeEnforcer{}))
+
+	resp, err := s.execute(Command{Service: "email", Recipient: "alice@example.com", User: "alice@example.com", OptOut: &OptOut{App: "foo", Trigger: "on-sync-failed"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@example.com is unsubscribed from the on-sync-failed notifications of the foo application", resp)
+
+	app, err := s.appClient.Get(context.Background(), "foo", v1.GetOptions{})
+	assert.NoError(t, err)
+	_, subscribed := app.GetAnnotations()[subscriptions.SubscribeAnnotationKey("on-sync-failed", "email")]
+	assert.False(t, subscribed)
+	assert.Equal(t, "alice@example.com", app.GetAnnotations()[subscriptions.UnsubscribeAnnotationKey("on-sync-failed", "email")])
+}
+
+func TestAcknowledge(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo"))
+
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+
+	s := NewServer(client, TestNamespace)
+
+	resp, err := s.execute(Command{User: "alice", Acknowledge: &Acknowledge{App: "foo", Trigger: "on-sync-failed"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "trigger on-sync-failed of application foo acknowledged", resp)
 	assert.Len(t, patches, 1)
 
-	patch := patches[0]
-	val, _, _ := unstructured.NestedString(patch, "metadata", "annotations", subscriptions.SubscribeAnnotationKey("on-sync-failed", "slack"))
-	assert.Equal(t, "channel2", val)
+	val, _, _ := unstructured.NestedString(patches[0], "metadata", "annotations", subscriptions.AcknowledgedAnnotationKey)
+	assert.Equal(t, "alice", triggers.NewAcknowledgments(val)["on-sync-failed"].User)
+}
+
+func TestMute(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewProject("foo"))
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+	s := NewServer(client, TestNamespace)
+
+	resp, err := s.execute(Command{User: "alice", Mute: &Mute{Project: "foo", Duration: 2 * time.Hour}})
+	assert.NoError(t, err)
+	assert.Equal(t, "notifications of project foo muted for 2h0m0s", resp)
+	assert.Len(t, patches, 1)
+
+	val, _, _ := unstructured.NestedString(patches[0], "metadata", "annotations", subscriptions.MutedAnnotationKey)
+	mute := triggers.ParseMute(val)
+	assert.Equal(t, "alice", mute.User)
+	assert.True(t, mute.IsActive(time.Now().Add(time.Hour)))
+}
+
+func TestUnmute(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo"))
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+	s := NewServer(client, TestNamespace)
+
+	resp, err := s.execute(Command{User: "alice", Unmute: &Mute{App: "foo"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "notifications of application foo unmuted", resp)
+	assert.Len(t, patches, 1)
+}
+
+func TestParseMuteDuration(t *testing.T) {
+	duration, err := ParseMuteDuration("2d")
+	assert.NoError(t, err)
+	assert.Equal(t, 48*time.Hour, duration)
+
+	duration, err = ParseMuteDuration("30m")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, duration)
+
+	_, err = ParseMuteDuration("forever")
+	assert.EqualError(t, err, "invalid duration 'forever'")
+}
+
+type fakeArgoCDClient struct {
+	calls []string
+}
+
+func (c *fakeArgoCDClient) Sync(_ context.Context, app string) error {
+	c.calls = append(c.calls, "sync "+app)
+	return nil
+}
+
+func (c *fakeArgoCDClient) Refresh(_ context.Context, app string) error {
+	c.calls = append(c.calls, "refresh "+app)
+	return nil
+}
+
+func (c *fakeArgoCDClient) Rollback(_ context.Context, app string) error {
+	c.calls = append(c.calls, "rollback "+app)
+	return nil
 }
 
-func TestCopyStringMap(t *testing.T) {
-	in := map[string]string{"key": "val"}
-	out := copyStringMap(in)
-	assert.Equal(t, in, out)
-	assert.False(t, &in == &out)
+type fakeEnforcer map[string]bool
+
+func (e fakeEnforcer) Enforce(_ context.Context, subject string, _ string, action string, object string) (bool, error) {
+	return e[subject+" "+action+" "+object], nil
+}
+
+// withIdentity maps the U1234 slack user to the alice RBAC subject
+var withIdentity = WithConfig(func() settings.Config {
+	return settings.Config{BotIdentities: settings.BotIdentities{{Service: "slack", UserID: "U1234", Subjects: []string{"alice"}}}}
+})
+
+func TestRunAppAction(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithProject("my-proj")))
+	argocdClient := &fakeArgoCDClient{}
+	s := NewServer(client, TestNamespace, WithArgoCDClient(argocdClient), WithRBAC(fakeEnforcer{"alice sync my-proj/foo": true}), withIdentity)
+
+	resp, err := s.execute(Command{Service: "slack", User: "alice", UserID: "U1234", AppAction: &AppAction{App: "foo", Action: AppActionRollback}})
+	assert.NoError(t, err)
+	assert.Equal(t, "rollback of application foo requested by alice", resp)
+	assert.Equal(t, []string{"rollback foo"}, argocdClient.calls)
+}
+
+func TestRunAppAction_Denied(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithProject("my-proj")))
+	argocdClient := &fakeArgoCDClient{}
+	s := NewServer(client, TestNamespace, WithArgoCDClient(argocdClient), WithRBAC(fakeEnforcer{"alice get my-proj/foo": true}), withIdentity)
+
+	_, err := s.execute(Command{Service: "slack", User: "alice", UserID: "U1234", AppAction: &AppAction{App: "foo", Action: AppActionSync}})
+	assert.EqualError(t, err, "user alice is not allowed to sync applications my-proj/foo")
+	assert.Empty(t, argocdClient.calls)
 }
 
-func TestAnnotationsPatch(t *testing.T) {
-	oldAnnotations := map[string]string{"key1": "val1", "key2": "val2", "key3": "val3"}
-	newAnnotations := map[string]string{"key2": "val2-updated", "key3": "val3", "key4": "val4"}
-	patch := annotationsPatch(oldAnnotations, newAnnotations)
-	assert.Equal(t, map[string]*string{
-		"key1": nil,
-		"key2": pointer.StringPtr("val2-updated"),
-		"key4": pointer.StringPtr("val4"),
-	}, patch)
+func TestRunAppAction_IdentitySubjects(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithProject("my-proj")))
+	argocdClient := &fakeArgoCDClient{}
+	s := NewServer(client, TestNamespace, WithArgoCDClient(argocdClient), WithRBAC(fakeEnforcer{"ops sync my-proj/foo": true}), WithConfig(func() settings.Config {
+		return settings.Config{BotIdentities: settings.BotIdentities{{Service: "slack", UserID: "U1234", Subjects: []string{"alice@example.com", "ops"}}}}
+	}))
+
+	_, err := s.execute(Command{Service: "slack", User: "alice", UserID: "U1234", AppAction: &AppAction{App: "foo", Action: AppActionSync}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sync foo"}, argocdClient.calls)
+}
+
+func TestRunAppAction_UnmappedUser(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithProject("my-proj")))
+	argocdClient := &fakeArgoCDClient{}
+	s := NewServer(client, TestNamespace, WithArgoCDClient(argocdClient), WithRBAC(fakeEnforcer{"alice sync my-proj/foo": true}))
+
+	_, err := s.execute(Command{Service: "slack", User: "alice", UserID: "U1234", AppAction: &AppAction{App: "foo", Action: AppActionSync}})
+	assert.EqualError(t, err, "user alice is not allowed to sync applications my-proj/foo: user ID U1234 is not mapped to Argo CD RBAC subjects")
+	assert.Empty(t, argocdClient.calls)
+}
+
+func TestRunAppAction_NoUserID(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithProject("my-proj")))
+	argocdClient := &fakeArgoCDClient{}
+	s := NewServer(client, TestNamespace, WithArgoCDClient(argocdClient), WithRBAC(fakeEnforcer{"alice sync my-proj/foo": true}), withIdentity)
+
+	_, err := s.execute(Command{Service: "slack", User: "alice", AppAction: &AppAction{App: "foo", Action: AppActionSync}})
+	assert.EqualError(t, err, "user alice is not allowed to sync applications my-proj/foo: the slack service does not provide the user ID")
+	assert.Empty(t, argocdClient.calls)
+}
+
+func TestSubscribe_Denied(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithProject("my-proj")))
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+	s := NewServer(client, TestNamespace, WithRBAC(fakeEnforcer{"alice get my-proj/foo": true}), withIdentity)
+
+	_, err := s.execute(Command{Service: "slack", Recipient: "general", User: "alice", UserID: "U1234", Subscribe: &UpdateSubscription{App: "foo"}})
+	assert.EqualError(t, err, "user alice is not allowed to update applications my-proj/foo")
+	assert.Empty(t, patches)
+}
+
+func TestMuteProject_Allowed(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewProject("my-proj"))
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+	s := NewServer(client, TestNamespace, WithRBAC(fakeEnforcer{"alice update my-proj": true}), withIdentity)
+
+	_, err := s.execute(Command{Service: "slack", User: "alice", UserID: "U1234", Mute: &Mute{Project: "my-proj", Duration: time.Hour}})
+	assert.NoError(t, err)
+	assert.Len(t, patches, 1)
+}
+
+func TestRunAppAction_NotConfigured(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo"))
+	s := NewServer(client, TestNamespace)
+
+	_, err := s.execute(Command{User: "alice", AppAction: &AppAction{App: "foo", Action: AppActionSync}})
+	assert.Error(t, err)
 }
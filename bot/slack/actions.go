@@ -0,0 +1,99 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+)
+
+const (
+	// actionIDPrefix is the prefix of the button action_id that requests the Argo CD application action, e.g. argocd-sync
+	actionIDPrefix = "argocd-"
+)
+
+// NewSlackActionsAdapter returns adapter that handles clicks on the interactive message buttons
+func NewSlackActionsAdapter(verifier RequestVerifier) *slackActions {
+	return &slackActions{slack: slack{verifier: verifier}, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+type slackActions struct {
+	slack
+	client *http.Client
+}
+
+type actionsPayload struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Channel struct {
+		Name string `json:"name"`
+	} `json:"channel"`
+	ResponseURL string `json:"response_url"`
+	Actions     []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+func (s *slackActions) Parse(r *http.Request) (bot.Command, error) {
+	cmd := bot.Command{}
+	service, query, err := s.parseQuery(r)
+	if err != nil {
+		return cmd, err
+	}
+	cmd.Service = service
+	var payload actionsPayload
+	if err := json.Unmarshal([]byte(query.Get("payload")), &payload); err != nil {
+		return cmd, fmt.Errorf("failed to parse interaction payload: %v", err)
+	}
+	if payload.Type != "block_actions" || len(payload.Actions) == 0 {
+		return cmd, fmt.Errorf("unsupported interaction '%s'", payload.Type)
+	}
+	action := payload.Actions[0]
+	if !strings.HasPrefix(action.ActionID, actionIDPrefix) {
+		return cmd, fmt.Errorf("unsupported action '%s'", action.ActionID)
+	}
+	if action.Value == "" {
+		return cmd, errors.New("action value must be the application name")
+	}
+	cmd.Recipient = payload.Channel.Name
+	cmd.User = payload.User.Username
+	cmd.UserID = payload.User.ID
+	cmd.ResponseURL = payload.ResponseURL
+	cmd.AppAction = &bot.AppAction{App: action.Value, Action: strings.TrimPrefix(action.ActionID, actionIDPrefix)}
+	return cmd, nil
+}
+
+// SendCommandResponse posts the ephemeral message visible only to the user who has clicked the button. Slack ignores
+// the body of the interaction response, so the message is sent using the response URL
+func (s *slackActions) SendCommandResponse(cmd bot.Command, content string, w http.ResponseWriter) {
+	w.WriteHeader(http.StatusOK)
+	if cmd.ResponseURL == "" {
+		return
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"response_type":    "ephemeral",
+		"replace_original": false,
+		"text":             content,
+	})
+	if err != nil {
+		log.Errorf("Failed to marshal Slack action response: %v", err)
+		return
+	}
+	resp, err := s.client.Post(cmd.ResponseURL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.Errorf("Failed to send Slack action response: %v", err)
+		return
+	}
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,76 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+)
+
+func newActionRequest(payload string) *http.Request {
+	return httptest.NewRequest("POST", "http://localhost/slack/actions",
+		bytes.NewBufferString(url.Values{"payload": []string{payload}}.Encode()))
+}
+
+func TestParseAction_Sync(t *testing.T) {
+	s := NewSlackActionsAdapter(noopVerifier)
+
+	cmd, err := s.Parse(newActionRequest(`{
+		"type": "block_actions",
+		"user": {"id": "U1234", "username": "alice"},
+		"channel": {"name": "test"},
+		"response_url": "https://hooks.slack.com/actions/1",
+		"actions": [{"action_id": "argocd-sync", "value": "guestbook"}]
+	}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &bot.AppAction{App: "guestbook", Action: bot.AppActionSync}, cmd.AppAction)
+	assert.Equal(t, "alice", cmd.User)
+	assert.Equal(t, "U1234", cmd.UserID)
+	assert.Equal(t, "test", cmd.Recipient)
+	assert.Equal(t, "slack", cmd.Service)
+	assert.Equal(t, "https://hooks.slack.com/actions/1", cmd.ResponseURL)
+}
+
+func TestParseAction_UnsupportedAction(t *testing.T) {
+	s := NewSlackActionsAdapter(noopVerifier)
+
+	_, err := s.Parse(newActionRequest(`{"type": "block_actions", "actions": [{"action_id": "other", "value": "guestbook"}]}`))
+	assert.EqualError(t, err, "unsupported action 'other'")
+}
+
+func TestParseAction_InvalidPayload(t *testing.T) {
+	s := NewSlackActionsAdapter(noopVerifier)
+
+	_, err := s.Parse(newActionRequest("not a json"))
+	assert.Error(t, err)
+}
+
+func TestSendCommandResponse(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &received)
+	}))
+	defer server.Close()
+	s := NewSlackActionsAdapter(noopVerifier)
+	w := httptest.NewRecorder()
+
+	s.SendCommandResponse(bot.Command{ResponseURL: server.URL}, "sync requested", w)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, map[string]interface{}{
+		"response_type":    "ephemeral",
+		"replace_original": false,
+		"text":             "sync requested",
+	}, received)
+}
@@ -30,9 +30,19 @@ func mustTemplate(text string) *texttemplate.Template {
 
 var commandsHelp = map[string]*texttemplate.Template{
 	"list-subscriptions": mustTemplate("*List your subscriptions*:\n" + "```{{.cmd}} list-subscriptions```"),
+	"list-apps":          mustTemplate("*List project applications*:\n" + "```{{.cmd}} list-apps <my-proj>```"),
+	"whoami":             mustTemplate("*Show how the bot identifies you*:\n" + "```{{.cmd}} whoami```"),
 	"subscribe": mustTemplate("*Subscribe current channel*:\n" +
 		"```{{.cmd}} subscribe <my-app> <optional-trigger>\n" +
 		"{{.cmd}} subscribe proj:<my-proj> <optional-trigger>```"),
+	"ack": mustTemplate("*Acknowledge firing trigger and stop its notifications until it is resolved*:\n" +
+		"```{{.cmd}} ack <my-app> <trigger>```"),
+	"mute": mustTemplate("*Mute notifications for the specified duration, e.g. 30m, 2h or 1d*:\n" +
+		"```{{.cmd}} mute <my-app> <duration>\n" +
+		"{{.cmd}} mute proj:<my-proj> <duration>```"),
+	"unmute": mustTemplate("*Unmute notifications*:\n" +
+		"```{{.cmd}} unmute <my-app>\n" +
+		"{{.cmd}} unmute proj:<my-proj>```"),
 	"unsubscribe": mustTemplate("*Unsubscribe current channel*:\n" +
 		"```{{.cmd}} unsubscribe <my-app> <optional-trigger>\n" +
 		"{{.cmd}} unsubscribe proj:<my-proj> <optional-trigger>```"),
@@ -72,7 +82,7 @@ func (s *slack) parseQuery(r *http.Request) (string, url.Values, error) {
 	}
 	service, err := s.verifier(data, r.Header)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to verify request signature: %v", err)
+		return "", nil, &bot.RequestError{StatusCode: http.StatusUnauthorized, Err: fmt.Errorf("failed to verify request signature: %v", err)}
 	}
 	values, err := url.ParseQuery(string(data))
 	if err != nil {
@@ -82,54 +92,28 @@ func (s *slack) parseQuery(r *http.Request) (string, url.Values, error) {
 }
 
 func (s *slack) Parse(r *http.Request) (bot.Command, error) {
-	cmd := bot.Command{}
 	service, query, err := s.parseQuery(r)
 	if err != nil {
-		return cmd, err
+		return bot.Command{}, err
 	}
-	cmd.Service = service
+	return ParseCommand(service, query)
+}
+
+// ParseCommand parses the slash command payload. Mattermost sends slash commands in the same format, so the parser
+// is shared by both adapters
+func ParseCommand(service string, query url.Values) (bot.Command, error) {
+	cmd := bot.Command{Service: service}
 	channel := query.Get("channel_name")
 	if channel == "" {
 		return cmd, errors.New("request does not have channel")
 	}
-	parts := strings.Fields(query.Get("text"))
-	if len(parts) < 1 {
-		return cmd, errors.New(usageInstructions(query, "", nil))
-	}
-	command := parts[0]
-
 	cmd.Recipient = channel
+	cmd.User = query.Get("user_name")
+	cmd.UserID = query.Get("user_id")
 
-	switch command {
-	case "list-subscriptions":
-		cmd.ListSubscriptions = &bot.ListSubscriptions{}
-	case "subscribe", "unsubscribe":
-		if len(parts) < 2 {
-			return cmd, errors.New(usageInstructions(query, command, errors.New("at least one argument expected")))
-		}
-		update := &bot.UpdateSubscription{}
-		nameParts := strings.Split(parts[1], ":")
-		if len(nameParts) == 1 {
-			nameParts = append([]string{"app"}, nameParts...)
-		}
-		switch nameParts[0] {
-		case "app":
-			update.App = nameParts[1]
-		case "proj":
-			update.Project = nameParts[1]
-		default:
-			return cmd, errors.New(usageInstructions(query, command, fmt.Errorf("incorrect name argument: %s", parts[1])))
-		}
-		if len(parts) > 2 {
-			update.Trigger = parts[2]
-		}
-		if command == "subscribe" {
-			cmd.Subscribe = update
-		} else {
-			cmd.Unsubscribe = update
-		}
-	default:
-		return cmd, errors.New(usageInstructions(query, "", nil))
+	var usageErr *bot.UsageError
+	if err := bot.ParseCommandArgs(&cmd, strings.Fields(query.Get("text"))); errors.As(err, &usageErr) {
+		return cmd, errors.New(usageInstructions(query, usageErr.Command, usageErr.Err))
 	}
 	return cmd, nil
 }
@@ -6,8 +6,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
 )
 
 var noopVerifier = func(data []byte, header http.Header) (string, error) {
@@ -67,6 +70,50 @@ func TestParse_UnsubscribeApp(t *testing.T) {
 	assert.Equal(t, cmd.Recipient, "test")
 }
 
+func TestParse_Acknowledge(t *testing.T) {
+	s := NewSlackAdapter(noopVerifier)
+
+	cmd, err := s.Parse(httptest.NewRequest("GET", "http://localhost/slack",
+		bytes.NewBufferString("text=ack%20foo%20on-sync-failed&channel_name=test&user_name=alice&user_id=U1234")))
+	assert.NoError(t, err)
+
+	assert.NotNil(t, cmd.Acknowledge)
+	assert.Equal(t, "foo", cmd.Acknowledge.App)
+	assert.Equal(t, "on-sync-failed", cmd.Acknowledge.Trigger)
+	assert.Equal(t, "alice", cmd.User)
+	assert.Equal(t, "U1234", cmd.UserID)
+}
+
+func TestParse_ListApps(t *testing.T) {
+	s := NewSlackAdapter(noopVerifier)
+
+	cmd, err := s.Parse(httptest.NewRequest("GET", "http://localhost/slack",
+		bytes.NewBufferString("text=list%20apps%20prod&channel_name=test")))
+	assert.NoError(t, err)
+
+	assert.NotNil(t, cmd.ListApps)
+	assert.Equal(t, "prod", cmd.ListApps.Project)
+}
+
+func TestParse_Mute(t *testing.T) {
+	s := NewSlackAdapter(noopVerifier)
+
+	cmd, err := s.Parse(httptest.NewRequest("GET", "http://localhost/slack",
+		bytes.NewBufferString("text=mute%20proj%3Afoo%202h&channel_name=test")))
+	assert.NoError(t, err)
+
+	assert.Equal(t, &bot.Mute{Project: "foo", Duration: 2 * time.Hour}, cmd.Mute)
+}
+
+func TestParse_MuteInvalidDuration(t *testing.T) {
+	s := NewSlackAdapter(noopVerifier)
+
+	_, err := s.Parse(httptest.NewRequest("GET", "http://localhost/slack",
+		bytes.NewBufferString("text=mute%20foo%20forever&channel_name=test")))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid duration 'forever'")
+}
+
 func TestParse_WrongCommandHelpResponse(t *testing.T) {
 	s := NewSlackAdapter(noopVerifier)
 
@@ -1,46 +1,185 @@
 package slack
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/go-redis/redis/v8"
 
-	slackclient "github.com/slack-go/slack"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
 )
 
+// maxRequestAge is the maximum difference between the request timestamp and the current time. Slack recommends
+// rejecting older requests to protect from replay attacks
+const maxRequestAge = 5 * time.Minute
+
+const replayCacheKeyPrefix = "argocd-notifications-bot|slack|"
+
 type HasSigningSecret interface {
 	GetSigningSecret() string
 }
 
+// hasCommandToken is implemented by services that might be configured as the Mattermost server
+type hasCommandToken interface {
+	GetCommandToken() string
+}
+
 type RequestVerifier func(data []byte, header http.Header) (string, error)
 
-func NewVerifier(cfg settings.Config) RequestVerifier {
+type VerifierOpts func(v *verifier)
+
+// WithReplayCache rejects requests with the signature that has been already seen within the allowed request age
+func WithReplayCache(cache ReplayCache) VerifierOpts {
+	return func(v *verifier) {
+		v.replayCache = cache
+	}
+}
+
+// WithService verifies requests using the signing secret of the specified service. The service must be specified if
+// more than one Slack service is configured
+func WithService(name string) VerifierOpts {
+	return func(v *verifier) {
+		v.service = name
+	}
+}
+
+type verifier struct {
+	now         func() time.Time
+	replayCache ReplayCache
+	service     string
+}
+
+func NewVerifier(cfg settings.Config, opts ...VerifierOpts) RequestVerifier {
+	v := &verifier{now: time.Now}
+	for i := range opts {
+		opts[i](v)
+	}
 	return func(data []byte, header http.Header) (string, error) {
+		var candidates []string
 		signingSecret := ""
-		serviceName := ""
 		for name, service := range cfg.API.GetNotificationServices() {
-			if hasSecret, ok := service.(HasSigningSecret); ok {
-				signingSecret = hasSecret.GetSigningSecret()
-				serviceName = name
-				if signingSecret == "" {
-					return "", errors.New("slack signing secret is not configured")
-				}
+			if v.service != "" && name != v.service {
+				continue
+			}
+			service = services.Unwrap(service)
+			hasSecret, ok := service.(HasSigningSecret)
+			if !ok {
+				continue
 			}
+			// Mattermost servers are configured using the slack service and verified by the Mattermost adapter
+			if hasToken, ok := service.(hasCommandToken); ok && hasToken.GetCommandToken() != "" && hasSecret.GetSigningSecret() == "" {
+				continue
+			}
+			candidates = append(candidates, name)
+			signingSecret = hasSecret.GetSigningSecret()
 		}
 
-		if signingSecret == "" {
+		switch {
+		case len(candidates) == 0 && v.service != "":
+			return "", fmt.Errorf("slack service '%s' is not configured", v.service)
+		case len(candidates) == 0:
 			return "", errors.New("slack is not configured")
+		case len(candidates) > 1:
+			sort.Strings(candidates)
+			return "", fmt.Errorf("multiple slack services are configured (%s), the service that receives bot requests must be specified", strings.Join(candidates, ", "))
+		case signingSecret == "":
+			return "", errors.New("slack signing secret is not configured")
 		}
 
-		verifier, err := slackclient.NewSecretsVerifier(header, signingSecret)
-		if err != nil {
-			return "", err
-		}
-		_, err = verifier.Write(data)
-		if err != nil {
-			return "", err
+		return candidates[0], v.verify(signingSecret, data, header)
+	}
+}
+
+// verify checks the request signature as described in https://api.slack.com/authentication/verifying-requests-from-slack
+func (v *verifier) verify(signingSecret string, data []byte, header http.Header) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	unixTime, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("request does not have valid timestamp")
+	}
+	age := v.now().Sub(time.Unix(unixTime, 0))
+	if age > maxRequestAge || age < -maxRequestAge {
+		return fmt.Errorf("request timestamp is outside of the allowed %s window", maxRequestAge)
+	}
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	_, _ = mac.Write([]byte("v0:" + timestamp + ":"))
+	_, _ = mac.Write(data)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	signature := header.Get("X-Slack-Signature")
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("invalid request signature")
+	}
+	if v.replayCache == nil {
+		return nil
+	}
+	added, err := v.replayCache.Add(signature, time.Unix(unixTime, 0).Add(maxRequestAge))
+	if err != nil {
+		return fmt.Errorf("failed to check if request has been already processed: %v", err)
+	}
+	if !added {
+		return errors.New("request has been already processed")
+	}
+	return nil
+}
+
+// ReplayCache holds signatures of recently verified requests
+type ReplayCache interface {
+	// Add returns false if the signature is already in the cache. Signatures are kept until the request expires
+	Add(signature string, expiresAt time.Time) (bool, error)
+}
+
+type memoryReplayCache struct {
+	lock       sync.Mutex
+	signatures map[string]time.Time
+	now        func() time.Time
+}
+
+// NewReplayCache returns the cache that keeps signatures in memory. The cache is not shared by bot replicas, so the
+// Redis cache must be used if the bot has more than one replica
+func NewReplayCache() *memoryReplayCache {
+	return &memoryReplayCache{signatures: map[string]time.Time{}, now: time.Now}
+}
+
+func (c *memoryReplayCache) Add(signature string, expiresAt time.Time) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := c.now()
+	for k, exp := range c.signatures {
+		if now.After(exp) {
+			delete(c.signatures, k)
 		}
-		return serviceName, verifier.Ensure()
 	}
+	if _, ok := c.signatures[signature]; ok {
+		return false, nil
+	}
+	c.signatures[signature] = expiresAt
+	return true, nil
+}
+
+type redisReplayCache struct {
+	client *redis.Client
+}
+
+// NewRedisReplayCache returns the cache that keeps signatures in Redis, so the requests are verified once by all bot replicas
+func NewRedisReplayCache(client *redis.Client) *redisReplayCache {
+	return &redisReplayCache{client: client}
+}
+
+func (c *redisReplayCache) Add(signature string, expiresAt time.Time) (bool, error) {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return true, nil
+	}
+	return c.client.SetNX(context.Background(), replayCacheKeyPrefix+signature, 1, ttl).Result()
 }
@@ -1,6 +1,9 @@
 package slack
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"strconv"
 	"testing"
 	"time"
@@ -25,6 +28,17 @@ func TestNewVerifier_IncorrectConfig(t *testing.T) {
 			Services: map[string]services.NotificationService{"slack": services.NewSlackService(services.SlackOptions{})},
 			Error:    "slack signing secret is not configured",
 		},
+		"MattermostOnly": {
+			Services: map[string]services.NotificationService{"mattermost": services.NewSlackService(services.SlackOptions{CommandToken: "token"})},
+			Error:    "slack is not configured",
+		},
+		"MultipleSlackServices": {
+			Services: map[string]services.NotificationService{
+				"slack":       services.NewSlackService(services.SlackOptions{SigningSecret: "secret"}),
+				"slack-other": services.NewSlackService(services.SlackOptions{SigningSecret: "other-secret"}),
+			},
+			Error: "multiple slack services are configured (slack, slack-other)",
+		},
 	}
 
 	for k := range testCases {
@@ -49,21 +63,104 @@ func TestNewVerifier_IncorrectConfig(t *testing.T) {
 	}
 }
 
-func TestNewVerifier_IncorrectSignature(t *testing.T) {
+func newTestAPI(t *testing.T) pkg.API {
 	api, err := pkg.NewAPI(pkg.Config{})
 	if !assert.NoError(t, err) {
-		return
+		t.FailNow()
 	}
 	api.AddNotificationService("slack", services.NewSlackService(services.SlackOptions{SigningSecret: "hello world"}))
-	verifier := NewVerifier(settings.Config{API: api})
+	return api
+}
 
-	now := time.Now()
-	data := "hello world"
-	_, err = verifier([]byte(data), map[string][]string{
-		"X-Slack-Request-Timestamp": {strconv.Itoa(int(now.UnixNano()))},
+func sign(secret string, timestamp string, data string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte("v0:" + timestamp + ":" + data))
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestNewVerifier_IncorrectSignature(t *testing.T) {
+	verifier := NewVerifier(settings.Config{API: newTestAPI(t)})
+
+	_, err := verifier([]byte("hello world"), map[string][]string{
+		"X-Slack-Request-Timestamp": {strconv.FormatInt(time.Now().Unix(), 10)},
 		"X-Slack-Signature":         {"v0=9e3753bb47fd3495894ab133c423ec93eff1ff30dd905ce39dda065e21ed9255"},
 	})
 
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "Expected signing signature")
+	assert.EqualError(t, err, "invalid request signature")
+}
+
+func TestNewVerifier_ValidSignature(t *testing.T) {
+	verifier := NewVerifier(settings.Config{API: newTestAPI(t)})
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	service, err := verifier([]byte("hello world"), map[string][]string{
+		"X-Slack-Request-Timestamp": {timestamp},
+		"X-Slack-Signature":         {sign("hello world", timestamp, "hello world")},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "slack", service)
+}
+
+func TestNewVerifier_ExpiredTimestamp(t *testing.T) {
+	verifier := NewVerifier(settings.Config{API: newTestAPI(t)})
+	timestamp := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+
+	_, err := verifier([]byte("hello world"), map[string][]string{
+		"X-Slack-Request-Timestamp": {timestamp},
+		"X-Slack-Signature":         {sign("hello world", timestamp, "hello world")},
+	})
+
+	assert.EqualError(t, err, "request timestamp is outside of the allowed 5m0s window")
+}
+
+func TestNewVerifier_ReplayedRequest(t *testing.T) {
+	verifier := NewVerifier(settings.Config{API: newTestAPI(t)}, WithReplayCache(NewReplayCache()))
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	header := map[string][]string{
+		"X-Slack-Request-Timestamp": {timestamp},
+		"X-Slack-Signature":         {sign("hello world", timestamp, "hello world")},
+	}
+
+	_, err := verifier([]byte("hello world"), header)
+	assert.NoError(t, err)
+
+	_, err = verifier([]byte("hello world"), header)
+	assert.EqualError(t, err, "request has been already processed")
+}
+
+func TestNewVerifier_WithService(t *testing.T) {
+	api := newTestAPI(t)
+	api.AddNotificationService("slack-other", services.NewSlackService(services.SlackOptions{SigningSecret: "other-secret"}))
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	header := map[string][]string{
+		"X-Slack-Request-Timestamp": {timestamp},
+		"X-Slack-Signature":         {sign("other-secret", timestamp, "hello world")},
+	}
+
+	service, err := NewVerifier(settings.Config{API: api}, WithService("slack-other"))([]byte("hello world"), header)
+	assert.NoError(t, err)
+	assert.Equal(t, "slack-other", service)
+
+	_, err = NewVerifier(settings.Config{API: api}, WithService("slack"))([]byte("hello world"), header)
+	assert.EqualError(t, err, "invalid request signature")
+
+	_, err = NewVerifier(settings.Config{API: api}, WithService("missing"))([]byte("hello world"), header)
+	assert.EqualError(t, err, "slack service 'missing' is not configured")
+}
+
+func TestReplayCache_RemovesExpiredSignatures(t *testing.T) {
+	cache := NewReplayCache()
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	added, err := cache.Add("sig", now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.True(t, added)
+	added, _ = cache.Add("sig", now.Add(time.Minute))
+	assert.False(t, added)
+
+	now = now.Add(2 * time.Minute)
+	added, _ = cache.Add("sig", now.Add(time.Minute))
+	assert.True(t, added)
 }
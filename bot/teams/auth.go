@@ -0,0 +1,148 @@
+package teams
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc"
+)
+
+const (
+	botFrameworkOpenIDMetadataURL = "https://login.botframework.com/v1/.well-known/openidconfiguration"
+	botFrameworkIssuer            = "https://api.botframework.com"
+	botFrameworkTokenURL          = "https://login.microsoftonline.com/botframework.com/oauth2/v2.0/token"
+	botFrameworkScope             = "https://api.botframework.com/.default"
+
+	// clockSkew is the allowed difference between Bot Framework and the bot clocks
+	clockSkew = 5 * time.Minute
+)
+
+type jwtClaims struct {
+	Issuer     string `json:"iss"`
+	Audience   string `json:"aud"`
+	Expiry     int64  `json:"exp"`
+	NotBefore  int64  `json:"nbf"`
+	ServiceURL string `json:"serviceurl"`
+}
+
+// signingKeys verifies tokens that Bot Framework sends to bots using the public keys published in the OpenID metadata.
+// The key set caches the keys and re-fetches them if the token is signed by the unknown key
+type signingKeys struct {
+	metadataURL string
+	client      *http.Client
+
+	lock   sync.Mutex
+	keySet oidc.KeySet
+}
+
+func (k *signingKeys) getKeySet() (oidc.KeySet, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.keySet != nil {
+		return k.keySet, nil
+	}
+	// Bot Framework metadata issuer differs from the metadata URL, so oidc.NewProvider cannot be used
+	resp, err := k.client.Get(k.metadataURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Bot Framework OpenID metadata: %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get Bot Framework OpenID metadata: %s responded with %d", k.metadataURL, resp.StatusCode)
+	}
+	var metadata struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse Bot Framework OpenID metadata: %v", err)
+	}
+	k.keySet = oidc.NewRemoteKeySet(oidc.ClientContext(context.Background(), k.client), metadata.JWKSURI)
+	return k.keySet, nil
+}
+
+// verify checks that the request is sent by Bot Framework to the bot with the specified app id on behalf of the
+// channel identified by the service URL
+func (k *signingKeys) verify(header http.Header, appID string, serviceURL string) error {
+	token := strings.TrimPrefix(header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		return errors.New("request does not have bearer token")
+	}
+	keySet, err := k.getKeySet()
+	if err != nil {
+		return err
+	}
+	verifier := oidc.NewVerifier(botFrameworkIssuer, keySet, &oidc.Config{
+		ClientID:             appID,
+		SupportedSigningAlgs: []string{oidc.RS256},
+		// token expiry is checked against the time in the past to tolerate the clock skew
+		Now: func() time.Time { return time.Now().Add(-clockSkew) },
+	})
+	idToken, err := verifier.Verify(context.Background(), token)
+	if err != nil {
+		return fmt.Errorf("invalid token: %v", err)
+	}
+	var claims jwtClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return fmt.Errorf("invalid token: %v", err)
+	}
+	switch {
+	case claims.NotBefore != 0 && time.Now().Add(clockSkew).Before(time.Unix(claims.NotBefore, 0)):
+		return errors.New("token is not valid yet")
+	case claims.ServiceURL != "" && claims.ServiceURL != serviceURL:
+		return errors.New("token is issued for the different service URL")
+	}
+	return nil
+}
+
+// accessTokens obtains and caches tokens that the bot uses to send replies using the Bot Framework API
+type accessTokens struct {
+	tokenURL string
+	client   *http.Client
+
+	lock      sync.Mutex
+	appID     string
+	token     string
+	expiresAt time.Time
+}
+
+func (t *accessTokens) get(creds Credentials) (string, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.token != "" && t.appID == creds.AppID && time.Now().Add(clockSkew).Before(t.expiresAt) {
+		return t.token, nil
+	}
+	resp, err := t.client.PostForm(t.tokenURL, map[string][]string{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {creds.AppID},
+		"client_secret": {creds.AppPassword},
+		"scope":         {botFrameworkScope},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get Bot Framework access token: token endpoint responded with %d", resp.StatusCode)
+	}
+	var res struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", err
+	}
+	t.appID = creds.AppID
+	t.token = res.AccessToken
+	t.expiresAt = time.Now().Add(time.Duration(res.ExpiresIn) * time.Second)
+	return t.token, nil
+}
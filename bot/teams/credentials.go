@@ -0,0 +1,36 @@
+package teams
+
+import (
+	"errors"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+type HasBotCredentials interface {
+	GetBotCredentials() (string, string)
+}
+
+// Credentials holds Azure Bot registration credentials and the name of the teams service that provides them
+type Credentials struct {
+	Service     string
+	AppID       string
+	AppPassword string
+}
+
+type CredentialsSource func() (Credentials, error)
+
+func NewCredentialsSource(cfg settings.Config) CredentialsSource {
+	return func() (Credentials, error) {
+		for name, service := range cfg.API.GetNotificationServices() {
+			if hasCredentials, ok := services.Unwrap(service).(HasBotCredentials); ok {
+				appID, appPassword := hasCredentials.GetBotCredentials()
+				if appID == "" || appPassword == "" {
+					return Credentials{}, errors.New("teams bot appId and appPassword are not configured")
+				}
+				return Credentials{Service: name, AppID: appID, AppPassword: appPassword}, nil
+			}
+		}
+		return Credentials{}, errors.New("teams is not configured")
+	}
+}
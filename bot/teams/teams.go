@@ -0,0 +1,183 @@
+package teams
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+)
+
+// Teams posts messages to the General channel without the channel name, the General channel id is the team id
+const generalChannel = "General"
+
+var (
+	mentionRegexp = regexp.MustCompile(`<at>[^<]*</at>`)
+	tagRegexp     = regexp.MustCompile(`<[^>]+>`)
+)
+
+var usage = "Available commands:\n\n" +
+	"* `list-subscriptions` - list channel subscriptions\n" +
+	"* `list-apps <my-proj>` - list project applications\n" +
+	"* `whoami` - show how the bot identifies you\n" +
+	"* `subscribe <my-app> <optional-trigger>` or `subscribe proj:<my-proj> <optional-trigger>` - subscribe channel\n" +
+	"* `unsubscribe <my-app> <optional-trigger>` or `unsubscribe proj:<my-proj> <optional-trigger>` - unsubscribe channel\n" +
+	"* `mute <my-app> <duration>` or `mute proj:<my-proj> <duration>` - mute notifications, e.g. for 30m, 2h or 1d\n" +
+	"* `unmute <my-app>` or `unmute proj:<my-proj>` - unmute notifications\n" +
+	"* `ack <my-app> <trigger>` - acknowledge firing trigger and stop its notifications until it is resolved"
+
+// NewTeamsAdapter returns adapter that handles messages sent to the bot through Azure Bot Service
+func NewTeamsAdapter(credentials CredentialsSource) *teams {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &teams{
+		credentials: credentials,
+		keys:        &signingKeys{metadataURL: botFrameworkOpenIDMetadataURL, client: client},
+		tokens:      &accessTokens{tokenURL: botFrameworkTokenURL, client: client},
+		client:      client,
+	}
+}
+
+type teams struct {
+	credentials CredentialsSource
+	keys        *signingKeys
+	tokens      *accessTokens
+	client      *http.Client
+}
+
+type activity struct {
+	Type       string `json:"type"`
+	ID         string `json:"id"`
+	Text       string `json:"text"`
+	ServiceURL string `json:"serviceUrl"`
+	From       struct {
+		Name        string `json:"name"`
+		AADObjectID string `json:"aadObjectId"`
+	} `json:"from"`
+	Conversation struct {
+		ID string `json:"id"`
+	} `json:"conversation"`
+	ChannelData struct {
+		Team struct {
+			ID string `json:"id"`
+		} `json:"team"`
+		Channel struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"channel"`
+	} `json:"channelData"`
+}
+
+// channelName returns the name of the team channel that the subscription is created for
+func (a activity) channelName() string {
+	channel := a.ChannelData.Channel
+	if channel.Name == "" && channel.ID != "" && channel.ID == a.ChannelData.Team.ID {
+		return generalChannel
+	}
+	return channel.Name
+}
+
+// commandText returns message text without the bot mention and formatting
+func (a activity) commandText() string {
+	text := mentionRegexp.ReplaceAllString(a.Text, "")
+	text = tagRegexp.ReplaceAllString(text, " ")
+	return strings.TrimSpace(html.UnescapeString(text))
+}
+
+func (t *teams) Parse(r *http.Request) (bot.Command, error) {
+	cmd := bot.Command{}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return cmd, err
+	}
+	var msg activity
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return cmd, fmt.Errorf("failed to parse activity: %v", err)
+	}
+	creds, err := t.credentials()
+	if err != nil {
+		return cmd, err
+	}
+	if err := t.keys.verify(r.Header, creds.AppID, msg.ServiceURL); err != nil {
+		return cmd, fmt.Errorf("failed to verify request: %v", err)
+	}
+	if msg.Type != "message" {
+		return cmd, nil
+	}
+	cmd.Service = creds.Service
+	cmd.User = msg.From.Name
+	cmd.UserID = msg.From.AADObjectID
+	cmd.ResponseURL = fmt.Sprintf("%s/v3/conversations/%s/activities/%s",
+		strings.TrimSuffix(msg.ServiceURL, "/"), url.PathEscape(msg.Conversation.ID), url.PathEscape(msg.ID))
+
+	channel := msg.channelName()
+	if channel == "" {
+		return cmd, errors.New("subscriptions can be managed only in team channels")
+	}
+	cmd.Recipient = channel
+
+	var usageErr *bot.UsageError
+	if err := bot.ParseCommandArgs(&cmd, strings.Fields(msg.commandText())); errors.As(err, &usageErr) {
+		if usageErr.Err == nil {
+			return cmd, errors.New(usage)
+		}
+		return cmd, fmt.Errorf("%v\n\n%s", usageErr.Err, usage)
+	}
+	return cmd, nil
+}
+
+// SendResponse acknowledges the request. Bot Framework ignores the response body, so the messages are sent using
+// SendCommandResponse
+func (t *teams) SendResponse(_ string, w http.ResponseWriter) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// SendCommandResponse replies to the message that contains the command
+func (t *teams) SendCommandResponse(cmd bot.Command, content string, w http.ResponseWriter) {
+	w.WriteHeader(http.StatusOK)
+	if cmd.ResponseURL == "" {
+		return
+	}
+	if err := t.reply(cmd.ResponseURL, content); err != nil {
+		log.Errorf("Failed to send Teams bot response: %v", err)
+	}
+}
+
+func (t *teams) reply(replyURL string, content string) error {
+	creds, err := t.credentials()
+	if err != nil {
+		return err
+	}
+	token, err := t.tokens.get(creds)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(map[string]string{"type": "message", "textFormat": "markdown", "text": content})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, replyURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Bot Framework API responded with %d", resp.StatusCode)
+	}
+	return nil
+}
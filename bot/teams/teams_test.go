@@ -0,0 +1,219 @@
+package teams
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+)
+
+var testCredentials = func() (Credentials, error) {
+	return Credentials{Service: "teams", AppID: "my-app-id", AppPassword: "my-password"}, nil
+}
+
+type botFramework struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	replies []map[string]string
+}
+
+// newBotFramework starts fake Bot Framework server that publishes signing keys, issues access tokens and accepts replies
+func newBotFramework(t *testing.T) *botFramework {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	f := &botFramework{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metadata", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"jwks_uri": f.server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "test-key", Algorithm: "RS256", Use: "sig"}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "my-app-id", r.FormValue("client_id"))
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "my-access-token", "expires_in": 3600})
+	})
+	mux.HandleFunc("/v3/conversations/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer my-access-token", r.Header.Get("Authorization"))
+		data, _ := ioutil.ReadAll(r.Body)
+		var reply map[string]string
+		_ = json.Unmarshal(data, &reply)
+		f.replies = append(f.replies, reply)
+	})
+	f.server = httptest.NewServer(mux)
+	return f
+}
+
+func (f *botFramework) newAdapter() *teams {
+	adapter := NewTeamsAdapter(testCredentials)
+	adapter.keys.metadataURL = f.server.URL + "/metadata"
+	adapter.tokens.tokenURL = f.server.URL + "/token"
+	return adapter
+}
+
+func (f *botFramework) token(t *testing.T, claims jwtClaims) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: f.key, KeyID: "test-key"}}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	payload, _ := json.Marshal(claims)
+	signed, err := signer.Sign(payload)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	token, err := signed.CompactSerialize()
+	assert.NoError(t, err)
+	return token
+}
+
+func (f *botFramework) newRequest(t *testing.T, text string, claims jwtClaims) *http.Request {
+	data, _ := json.Marshal(map[string]interface{}{
+		"type":         "message",
+		"id":           "1",
+		"text":         text,
+		"serviceUrl":   f.server.URL,
+		"from":         map[string]string{"name": "alice", "aadObjectId": "6ef4a3e1-08b2-4bd8-b0d5-1b8c2d0a9f36"},
+		"conversation": map[string]string{"id": "19:abc"},
+		"channelData": map[string]interface{}{
+			"team":    map[string]string{"id": "team-1"},
+			"channel": map[string]string{"id": "channel-1", "name": "deployments"},
+		},
+	})
+	r := httptest.NewRequest("POST", "http://localhost/teams", bytes.NewReader(data))
+	r.Header.Set("Authorization", "Bearer "+f.token(t, claims))
+	return r
+}
+
+func (f *botFramework) validClaims() jwtClaims {
+	return jwtClaims{
+		Issuer:     botFrameworkIssuer,
+		Audience:   "my-app-id",
+		Expiry:     time.Now().Add(time.Hour).Unix(),
+		ServiceURL: f.server.URL,
+	}
+}
+
+func TestParse_Subscribe(t *testing.T) {
+	f := newBotFramework(t)
+	defer f.server.Close()
+
+	cmd, err := f.newAdapter().Parse(f.newRequest(t, "<at>argocd</at> subscribe guestbook on-sync-failed", f.validClaims()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &bot.UpdateSubscription{App: "guestbook", Trigger: "on-sync-failed"}, cmd.Subscribe)
+	assert.Equal(t, "deployments", cmd.Recipient)
+	assert.Equal(t, "teams", cmd.Service)
+	assert.Equal(t, "alice", cmd.User)
+	assert.Equal(t, "6ef4a3e1-08b2-4bd8-b0d5-1b8c2d0a9f36", cmd.UserID)
+}
+
+func TestParse_SubscribeProject(t *testing.T) {
+	f := newBotFramework(t)
+	defer f.server.Close()
+
+	cmd, err := f.newAdapter().Parse(f.newRequest(t, "<at>argocd</at> unsubscribe proj:default", f.validClaims()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &bot.UpdateSubscription{Project: "default"}, cmd.Unsubscribe)
+}
+
+func TestParse_InvalidAudience(t *testing.T) {
+	f := newBotFramework(t)
+	defer f.server.Close()
+	claims := f.validClaims()
+	claims.Audience = "other-app"
+
+	cmd, err := f.newAdapter().Parse(f.newRequest(t, "list-subscriptions", claims))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "expected audience")
+	}
+	assert.Empty(t, cmd.ResponseURL)
+}
+
+func TestParse_ExpiredToken(t *testing.T) {
+	f := newBotFramework(t)
+	defer f.server.Close()
+	claims := f.validClaims()
+	claims.Expiry = time.Now().Add(-time.Hour).Unix()
+
+	_, err := f.newAdapter().Parse(f.newRequest(t, "list-subscriptions", claims))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "expired")
+	}
+}
+
+func TestParse_UnknownCommand(t *testing.T) {
+	f := newBotFramework(t)
+	defer f.server.Close()
+
+	_, err := f.newAdapter().Parse(f.newRequest(t, "<at>argocd</at> hello", f.validClaims()))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Available commands")
+	}
+}
+
+func TestParse_UnknownSigningKey(t *testing.T) {
+	f := newBotFramework(t)
+	defer f.server.Close()
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err) {
+		return
+	}
+	r := f.newRequest(t, "list-subscriptions", f.validClaims())
+	f.key = otherKey
+	r.Header.Set("Authorization", "Bearer "+f.token(t, f.validClaims()))
+
+	_, err = f.newAdapter().Parse(r)
+	assert.Error(t, err)
+}
+
+func TestParse_InvalidArgument(t *testing.T) {
+	f := newBotFramework(t)
+	defer f.server.Close()
+
+	_, err := f.newAdapter().Parse(f.newRequest(t, "<at>argocd</at> mute guestbook forever", f.validClaims()))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid duration 'forever'")
+		assert.Contains(t, err.Error(), "Available commands")
+	}
+}
+
+func TestSendCommandResponse(t *testing.T) {
+	f := newBotFramework(t)
+	defer f.server.Close()
+	adapter := f.newAdapter()
+	cmd, err := adapter.Parse(f.newRequest(t, "list-subscriptions", f.validClaims()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	w := httptest.NewRecorder()
+
+	adapter.SendCommandResponse(cmd, "no subscriptions", w)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []map[string]string{{"type": "message", "textFormat": "markdown", "text": "no subscriptions"}}, f.replies)
+}
+
+func TestChannelName_General(t *testing.T) {
+	msg := activity{}
+	msg.ChannelData.Team.ID = "team-1"
+	msg.ChannelData.Channel.ID = "team-1"
+
+	assert.Equal(t, generalChannel, msg.channelName())
+}
@@ -0,0 +1,113 @@
+package telegram
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+)
+
+var usage = "Available commands:\n" +
+	"/list - list chat subscriptions\n" +
+	"/list_apps <my-proj> - list project applications\n" +
+	"/whoami - show how the bot identifies you\n" +
+	"/subscribe <my-app> <optional-trigger> - subscribe chat to the app notifications\n" +
+	"/subscribe proj:<my-proj> <optional-trigger> - subscribe chat to the app project notifications\n" +
+	"/unsubscribe <my-app> <optional-trigger> - unsubscribe chat from the app notifications\n" +
+	"/unsubscribe proj:<my-proj> <optional-trigger> - unsubscribe chat from the app project notifications\n" +
+	"/mute <my-app> <duration> - mute app notifications, e.g. for 30m, 2h or 1d\n" +
+	"/mute proj:<my-proj> <duration> - mute app project notifications\n" +
+	"/unmute <my-app> - unmute app notifications\n" +
+	"/unmute proj:<my-proj> - unmute app project notifications\n" +
+	"/ack <my-app> <trigger> - acknowledge firing trigger and stop its notifications until it is resolved"
+
+// commandAliases maps Telegram command names, which cannot contain dashes, to the bot command names
+var commandAliases = map[string]string{
+	"list":               "list-subscriptions",
+	"list_subscriptions": "list-subscriptions",
+	"list_apps":          "list-apps",
+}
+
+// NewTelegramAdapter returns adapter that handles updates delivered to the Telegram bot webhook
+func NewTelegramAdapter(verifier RequestVerifier) *telegram {
+	return &telegram{verifier: verifier}
+}
+
+type telegram struct {
+	verifier RequestVerifier
+}
+
+type update struct {
+	Message *struct {
+		Text string `json:"text"`
+		From struct {
+			ID       int64  `json:"id"`
+			Username string `json:"username"`
+		} `json:"from"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+func (t *telegram) Parse(r *http.Request) (bot.Command, error) {
+	cmd := bot.Command{}
+	service, err := t.verifier(r.Header)
+	if err != nil {
+		return cmd, fmt.Errorf("failed to verify request: %v", err)
+	}
+	var upd update
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		return cmd, err
+	}
+	// bot receives other updates, e.g. when it is added to the group, which don't require a response
+	if upd.Message == nil || !strings.HasPrefix(upd.Message.Text, "/") {
+		return cmd, nil
+	}
+	cmd.Service = service
+	cmd.Recipient = strconv.FormatInt(upd.Message.Chat.ID, 10)
+	cmd.User = upd.Message.From.Username
+	if upd.Message.From.ID != 0 {
+		cmd.UserID = strconv.FormatInt(upd.Message.From.ID, 10)
+	}
+
+	parts := strings.Fields(upd.Message.Text)
+	// commands sent in groups might include the bot username, e.g. /subscribe@argocd_bot
+	parts[0] = strings.Split(strings.TrimPrefix(parts[0], "/"), "@")[0]
+	if command, ok := commandAliases[parts[0]]; ok {
+		parts[0] = command
+	}
+	var usageErr *bot.UsageError
+	if err := bot.ParseCommandArgs(&cmd, parts); errors.As(err, &usageErr) {
+		if usageErr.Err == nil {
+			return cmd, errors.New(usage)
+		}
+		return cmd, fmt.Errorf("%v\n%s", usageErr.Err, usage)
+	}
+	return cmd, nil
+}
+
+// SendResponse acknowledges the update without the reply since the chat is unknown
+func (t *telegram) SendResponse(_ string, w http.ResponseWriter) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// SendCommandResponse replies to the chat using the webhook response, so the bot doesn't need to call Telegram API
+func (t *telegram) SendCommandResponse(cmd bot.Command, content string, w http.ResponseWriter) {
+	if cmd.Recipient == "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	chatID, _ := strconv.ParseInt(cmd.Recipient, 10, 64)
+	data, err := json.Marshal(map[string]interface{}{"method": "sendMessage", "chat_id": chatID, "text": content})
+	if err != nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,85 @@
+package telegram
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/bot"
+)
+
+var noopVerifier = func(header http.Header) (string, error) {
+	return "telegram", nil
+}
+
+func newUpdateRequest(text string) *http.Request {
+	return httptest.NewRequest("POST", "http://localhost/telegram", bytes.NewBufferString(
+		`{"update_id": 1, "message": {"text": "`+text+`", "from": {"id": 123456789, "username": "alice"}, "chat": {"id": -1001234}}}`))
+}
+
+func TestParse_Subscribe(t *testing.T) {
+	s := NewTelegramAdapter(noopVerifier)
+
+	cmd, err := s.Parse(newUpdateRequest("/subscribe@argocd_bot guestbook on-sync-failed"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &bot.UpdateSubscription{App: "guestbook", Trigger: "on-sync-failed"}, cmd.Subscribe)
+	assert.Equal(t, "-1001234", cmd.Recipient)
+	assert.Equal(t, "telegram", cmd.Service)
+	assert.Equal(t, "alice", cmd.User)
+	assert.Equal(t, "123456789", cmd.UserID)
+}
+
+func TestParse_UnsubscribeProject(t *testing.T) {
+	s := NewTelegramAdapter(noopVerifier)
+
+	cmd, err := s.Parse(newUpdateRequest("/unsubscribe proj:default"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, &bot.UpdateSubscription{Project: "default"}, cmd.Unsubscribe)
+}
+
+func TestParse_List(t *testing.T) {
+	s := NewTelegramAdapter(noopVerifier)
+
+	cmd, err := s.Parse(newUpdateRequest("/list"))
+	assert.NoError(t, err)
+	assert.NotNil(t, cmd.ListSubscriptions)
+}
+
+func TestParse_UnknownCommand(t *testing.T) {
+	s := NewTelegramAdapter(noopVerifier)
+
+	cmd, err := s.Parse(newUpdateRequest("/hello"))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Available commands")
+	}
+	assert.Equal(t, "-1001234", cmd.Recipient)
+}
+
+func TestParse_InvalidSecret(t *testing.T) {
+	s := NewTelegramAdapter(func(header http.Header) (string, error) {
+		return "", errors.New("invalid secret token")
+	})
+
+	cmd, err := s.Parse(newUpdateRequest("/list"))
+	assert.Error(t, err)
+	assert.Empty(t, cmd.Recipient)
+}
+
+func TestSendCommandResponse(t *testing.T) {
+	s := NewTelegramAdapter(noopVerifier)
+	w := httptest.NewRecorder()
+
+	s.SendCommandResponse(bot.Command{Recipient: "-1001234"}, "subscription updated", w)
+
+	assert.JSONEq(t, `{"method": "sendMessage", "chat_id": -1001234, "text": "subscription updated"}`, w.Body.String())
+}
@@ -0,0 +1,38 @@
+package telegram
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// secretTokenHeader is the header with the secret token specified in the setWebhook request
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+type HasWebhookSecret interface {
+	GetWebhookSecret() string
+}
+
+// RequestVerifier checks that the update is sent by Telegram and returns the name of the telegram service
+type RequestVerifier func(header http.Header) (string, error)
+
+func NewVerifier(cfg settings.Config) RequestVerifier {
+	return func(header http.Header) (string, error) {
+		for name, service := range cfg.API.GetNotificationServices() {
+			if hasSecret, ok := services.Unwrap(service).(HasWebhookSecret); ok {
+				secret := hasSecret.GetWebhookSecret()
+				if secret == "" {
+					return "", errors.New("telegram webhook secret is not configured")
+				}
+				if subtle.ConstantTimeCompare([]byte(header.Get(secretTokenHeader)), []byte(secret)) != 1 {
+					return "", errors.New("invalid secret token")
+				}
+				return name, nil
+			}
+		}
+		return "", errors.New("telegram is not configured")
+	}
+}
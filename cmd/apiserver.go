@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/redact"
+	"github.com/argoproj-labs/argocd-notifications/shared/apiserver"
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+	"github.com/argoproj-labs/argocd-notifications/shared/deadletter"
+	"github.com/argoproj-labs/argocd-notifications/shared/httpserver"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/webui"
+)
+
+const defaultAPIServerMetricsPort = 9003
+
+func newAPIServerCommand() *cobra.Command {
+	var (
+		clientConfig     clientcmd.ClientConfig
+		namespace        string
+		port             int
+		repoServer       argocd.RepoServerOptions
+		serverOpts       httpserver.Options
+		alertmanager     bool
+		grpcPort         int
+		grpcOpts         httpserver.Options
+		alertmanagerOpts apiserver.AlertmanagerOptions
+		ui               bool
+		uiRBAC           bool
+		dexOpts          webui.DexOptions
+		sessionKeyFile   string
+		egressPolicyFile string
+		deadLettersSize  int
+		metricsPort      int
+		metricsAddress   string
+		metricsServer    httpserver.Options
+	)
+	var command = cobra.Command{
+		Use:   "apiserver",
+		Short: "Starts Argo CD Notifications API server that sends notifications requested using REST API",
+		RunE: func(c *cobra.Command, args []string) error {
+			if serverOpts.BearerTokenFile == "" && serverOpts.ClientCAFile == "" {
+				return errors.New("API server requires authentication: specify --api-bearer-token-file or --api-client-ca")
+			}
+			if grpcPort > 0 && (grpcOpts.TLSCertFile == "" || grpcOpts.TLSKeyFile == "" || grpcOpts.ClientCAFile == "") {
+				return errors.New("gRPC API requires mTLS: specify --grpc-tls-cert, --grpc-tls-key and --grpc-client-ca")
+			}
+			if egressPolicyFile != "" {
+				policies, err := httputil.LoadEgressPolicies(egressPolicyFile)
+				if err != nil {
+					return err
+				}
+				pkg.SetEgressPolicies(policies)
+			}
+			restConfig, err := clientConfig.ClientConfig()
+			if err != nil {
+				return err
+			}
+			dynamicClient, err := dynamic.NewForConfig(restConfig)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := kubernetes.NewForConfig(restConfig)
+			if err != nil {
+				return err
+			}
+			if namespace == "" {
+				namespace, _, err = clientConfig.Namespace()
+				if err != nil {
+					return err
+				}
+			}
+			argocdService, err := argocd.NewArgoCDService(k8sClient, namespace, repoServer)
+			if err != nil {
+				return err
+			}
+			defer argocdService.Close()
+
+			var currentCfg *settings.Config
+			var lock sync.RWMutex
+			resolver := settings.NewSecretResolver(k8sClient, namespace, nil)
+			if err = settings.WatchConfig(context.Background(), argocdService, k8sClient, nil, namespace, resolver, func(cfg settings.Config) error {
+				redact.SetValues(cfg.SecretValues)
+				if _, ok := cfg.API.GetNotificationServices()["console"]; !ok {
+					cfg.API.AddNotificationService("console", services.NewConsoleService(os.Stdout))
+				}
+				lock.Lock()
+				currentCfg = &cfg
+				lock.Unlock()
+				log.Info("Configuration loaded")
+				return nil
+			}, nil, legacy.ApplyLegacyConfig); err != nil {
+				return err
+			}
+
+			getConfig := func() *settings.Config {
+				lock.RLock()
+				defer lock.RUnlock()
+				return currentCfg
+			}
+			// inbound webhooks are authenticated using HMAC signature and the web UI users sign in using Dex
+			serverOpts.PublicPaths = []string{"/healthz", apiserver.InboundWebhookPathPrefix, webui.PathPrefix}
+			var opts []apiserver.Opts
+			if alertmanager {
+				opts = append(opts, apiserver.WithAlertmanagerReceiver(alertmanagerOpts))
+			}
+			if deadLettersSize > 0 {
+				opts = append(opts, apiserver.WithDeadLetterStore(deadletter.NewConfigMapStore(k8sClient, namespace, deadLettersSize)))
+			}
+			if metricsPort > 0 {
+				registry := apiserver.NewMetricsRegistry()
+				metricsMux := http.NewServeMux()
+				metricsMux.Handle("/metrics", promhttp.HandlerFor(prometheus.Gatherers{registry, prometheus.DefaultGatherer}, promhttp.HandlerOpts{}))
+				if err := metricsServer.Validate(); err != nil {
+					return err
+				}
+				go func() {
+					log.Fatal(metricsServer.ListenAndServe(net.JoinHostPort(metricsAddress, strconv.Itoa(metricsPort)), metricsMux))
+				}()
+				log.Infof("serving metrics on %s", net.JoinHostPort(metricsAddress, strconv.Itoa(metricsPort)))
+				opts = append(opts, apiserver.WithMetrics(registry))
+			}
+			handler := apiserver.NewServer(k8s.NewAppClient(dynamicClient, namespace), k8s.NewAppProjClient(dynamicClient, namespace), getConfig, opts...)
+			mux := http.NewServeMux()
+			mux.Handle("/", handler)
+			if ui {
+				if sessionKeyFile != "" {
+					key, err := ioutil.ReadFile(sessionKeyFile)
+					if err != nil {
+						return err
+					}
+					dexOpts.SessionKey = []byte(strings.TrimSpace(string(key)))
+				}
+				auth, err := webui.NewAuthenticator(dexOpts)
+				if err != nil {
+					return err
+				}
+				var uiOpts []webui.Opts
+				if uiRBAC {
+					uiOpts = append(uiOpts, webui.WithRBAC(argocd.NewRBACEnforcer(context.Background(), k8sClient, namespace)))
+				}
+				mux.Handle(webui.PathPrefix, webui.NewServer(dynamicClient, namespace, getConfig, auth, uiOpts...))
+				log.Infof("serving web UI on %s", webui.PathPrefix)
+			}
+			if grpcPort > 0 {
+				tlsConfig, err := grpcOpts.TLSConfig()
+				if err != nil {
+					return err
+				}
+				tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+				listener, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
+				if err != nil {
+					return err
+				}
+				grpcServer := handler.NewGRPCServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+				go func() {
+					log.Fatal(grpcServer.Serve(listener))
+				}()
+				log.Infof("serving gRPC API on port %d", grpcPort)
+			}
+			log.Infof("serving API on port %d", port)
+			return serverOpts.ListenAndServe(fmt.Sprintf(":%d", port), mux)
+		},
+	}
+	clientConfig = k8s.AddK8SFlagsToCmd(&command)
+	command.Flags().IntVar(&port, "port", 8080, "Port number.")
+	command.Flags().StringVar(&namespace, "namespace", "", "Namespace of the notifications configuration and applications. Current namespace if empty.")
+	argocd.AddRepoServerFlags(&command, &repoServer)
+	httpserver.AddFlags(&command, "api", &serverOpts)
+	command.Flags().IntVar(&grpcPort, "grpc-port", 0, "Port of the gRPC API. Zero disables the gRPC API.")
+	command.Flags().StringVar(&grpcOpts.TLSCertFile, "grpc-tls-cert", "", "Path to the TLS certificate of the gRPC API")
+	command.Flags().StringVar(&grpcOpts.TLSKeyFile, "grpc-tls-key", "", "Path to the TLS private key of the gRPC API")
+	command.Flags().StringVar(&grpcOpts.ClientCAFile, "grpc-client-ca", "", "Path to the CA bundle used to verify gRPC client certificates")
+	command.Flags().BoolVar(&alertmanager, "alertmanager-receiver", false, "Serve /api/v1/alertmanager endpoint that routes Alertmanager alerts to subscribers of the application referenced by the alert")
+	command.Flags().StringVar(&alertmanagerOpts.AppLabel, "alertmanager-app-label", "argocd_application", "Alert label that holds the application name")
+	command.Flags().StringVar(&alertmanagerOpts.Trigger, "alertmanager-trigger", "on-alert", "Trigger name used in subscriptions to the application alerts")
+	command.Flags().StringSliceVar(&alertmanagerOpts.Templates, "alertmanager-template", []string{"alertmanager-alert"}, "Templates used to generate alert notifications")
+	command.Flags().BoolVar(&ui, "ui", false, "Serve the web UI that manages subscriptions on /ui/. Users sign in using Argo CD's Dex")
+	command.Flags().StringVar(&dexOpts.IssuerURL, "ui-dex-issuer", "", "Dex issuer URL, e.g. https://argocd.example.com/api/dex")
+	command.Flags().StringVar(&dexOpts.ClientID, "ui-dex-client-id", "argocd-notifications", "Dex client ID of the web UI")
+	command.Flags().StringVar(&dexOpts.ClientSecretFile, "ui-dex-client-secret-file", "", "Path to the file with the Dex client secret of the web UI")
+	command.Flags().StringVar(&dexOpts.RedirectURL, "ui-redirect-url", "", "External URL of the web UI sign in callback, e.g. https://notifications.example.com/ui/auth/callback")
+	command.Flags().StringVar(&dexOpts.RootCAFile, "ui-dex-root-ca-file", "", "Path to PEM encoded certificates that verify the Dex certificate in addition to the system certificates")
+	command.Flags().StringVar(&sessionKeyFile, "ui-session-key-file", "", "Path to the file with the key that signs web UI sessions. Random key is used if not specified, so sessions don't survive restarts")
+	command.Flags().StringVar(&egressPolicyFile, "egress-policy-file", "", "Path to the YAML file with hosts and CIDRs each service type is allowed to connect to. Should be mounted from a source that users who manage notifications config cannot modify.")
+	command.Flags().IntVar(&deadLettersSize, "dead-letters-max-size", 100, "Max number of undelivered notifications about Alertmanager alerts kept in the dead letters ConfigMap. Zero disables dead letters.")
+	command.Flags().IntVar(&metricsPort, "metrics-port", defaultAPIServerMetricsPort, "Port of the HTTP server that serves metrics. Zero disables metrics.")
+	command.Flags().StringVar(&metricsAddress, "metrics-bind-address", "0.0.0.0", "Address the HTTP server that serves metrics binds to")
+	httpserver.AddFlags(&command, "metrics", &metricsServer)
+	command.Flags().BoolVar(&uiRBAC, "ui-rbac", true, "Check that the web UI user is allowed to update the application using Argo CD RBAC policies before changing subscriptions")
+	return &command
+}
@@ -2,32 +2,63 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
 	"sync"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/argoproj-labs/argocd-notifications/bot"
+	"github.com/argoproj-labs/argocd-notifications/bot/discord"
+	"github.com/argoproj-labs/argocd-notifications/bot/email"
+	"github.com/argoproj-labs/argocd-notifications/bot/mattermost"
 	"github.com/argoproj-labs/argocd-notifications/bot/slack"
+	"github.com/argoproj-labs/argocd-notifications/bot/teams"
+	"github.com/argoproj-labs/argocd-notifications/bot/telegram"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/redact"
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+	"github.com/argoproj-labs/argocd-notifications/shared/httpserver"
 	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
 	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
 	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/unsubscribe"
 )
 
+const defaultBotMetricsPort = 9002
+
 func newBotCommand() *cobra.Command {
 	var (
-		clientConfig clientcmd.ClientConfig
-		namespace    string
-		port         int
+		clientConfig   clientcmd.ClientConfig
+		namespace      string
+		port           int
+		argocdOpts     argocd.APIClientOptions
+		rbac           bool
+		metricsPort    int
+		metricsAddress string
+		metricsServer  httpserver.Options
+		auditLogFile   string
+		slackService   string
+		redisOpts      argocd.RedisOptions
+
+		mattermostResponseType string
 	)
 	var command = cobra.Command{
 		Use:   "bot",
 		Short: "Starts Argo CD Notifications bot",
 		RunE: func(c *cobra.Command, args []string) error {
+			if mattermostResponseType != mattermost.ResponseTypeEphemeral && mattermostResponseType != mattermost.ResponseTypeInChannel {
+				return fmt.Errorf("unsupported Mattermost response type: %s", mattermostResponseType)
+			}
 			restConfig, err := clientConfig.ClientConfig()
 			if err != nil {
 				return err
@@ -47,42 +78,124 @@ func newBotCommand() *cobra.Command {
 				}
 			}
 			cfgSrc := make(chan settings.Config)
-			if err = settings.WatchConfig(context.Background(), nil, clientset, namespace, func(config settings.Config) error {
+			if err = settings.WatchConfig(context.Background(), nil, clientset, nil, namespace, settings.NewSecretResolver(clientset, namespace, nil), func(config settings.Config) error {
+				redact.SetValues(config.SecretValues)
 				cfgSrc <- config
 				return nil
-			}, legacy.ApplyLegacyConfig); err != nil {
+			}, nil, legacy.ApplyLegacyConfig); err != nil {
 				log.Fatal(err)
 			}
-			server := bot.NewServer(dynamicClient, namespace)
-			server.AddAdapter("/slack", slack.NewSlackAdapter(getVerifier(cfgSrc)))
+			getConfig := watchConfig(cfgSrc)
+			opts := []bot.Opts{bot.WithConfig(getConfig)}
+			if metricsPort > 0 {
+				registry := bot.NewMetricsRegistry()
+				mux := http.NewServeMux()
+				mux.Handle("/metrics", promhttp.HandlerFor(prometheus.Gatherers{registry, prometheus.DefaultGatherer}, promhttp.HandlerOpts{}))
+				if err := metricsServer.Validate(); err != nil {
+					return err
+				}
+				go func() {
+					log.Fatal(metricsServer.ListenAndServe(net.JoinHostPort(metricsAddress, strconv.Itoa(metricsPort)), mux))
+				}()
+				log.Infof("serving metrics on %s", net.JoinHostPort(metricsAddress, strconv.Itoa(metricsPort)))
+				opts = append(opts, bot.WithMetrics(registry))
+			}
+			if auditLogFile != "" {
+				file, err := os.OpenFile(auditLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					_ = file.Close()
+				}()
+				auditLogger := log.New()
+				auditLogger.SetOutput(file)
+				auditLogger.SetFormatter(&log.JSONFormatter{})
+				auditLogger.AddHook(redact.NewHook(redact.Default))
+				opts = append(opts, bot.WithAuditLogger(auditLogger))
+			}
+			// application actions are always authorized, so RBAC enforcement is enabled together with the Argo CD API
+			if rbac || argocdOpts.ServerURL != "" {
+				opts = append(opts, bot.WithRBAC(argocd.NewRBACEnforcer(context.Background(), clientset, namespace)))
+			}
+			if argocdOpts.ServerURL != "" {
+				opts = append(opts, bot.WithArgoCDClient(argocd.NewAPIClient(argocdOpts)))
+			}
+			server := bot.NewServer(dynamicClient, namespace, opts...)
+			var replayCache slack.ReplayCache = slack.NewReplayCache()
+			if redisOpts.Address != "" {
+				redisOpts.Password = os.Getenv("REDIS_PASSWORD")
+				redisClient, err := argocd.NewRedisClient(redisOpts)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					_ = redisClient.Close()
+				}()
+				replayCache = slack.NewRedisReplayCache(redisClient)
+				log.Infof("keeping Slack and Discord request signatures in Redis %s", redisOpts.Address)
+			}
+			// the verifier is created for every request, so the rotated signing secret is used without restart
+			verifier := func(data []byte, header http.Header) (string, error) {
+				return slack.NewVerifier(getConfig(), slack.WithReplayCache(replayCache), slack.WithService(slackService))(data, header)
+			}
+			server.AddAdapter("/slack", slack.NewSlackAdapter(verifier))
+			server.AddAdapter("/slack/actions", slack.NewSlackActionsAdapter(verifier))
+			server.AddAdapter("/telegram", telegram.NewTelegramAdapter(func(header http.Header) (string, error) {
+				return telegram.NewVerifier(getConfig())(header)
+			}))
+			server.AddAdapter("/discord", discord.NewDiscordAdapter(func(data []byte, header http.Header) (string, error) {
+				return discord.NewVerifier(getConfig(), discord.WithReplayCache(replayCache))(data, header)
+			}))
+			server.AddAdapter("/mattermost", mattermost.NewMattermostAdapter(func(token string) (string, error) {
+				return mattermost.NewVerifier(getConfig())(token)
+			}, mattermostResponseType))
+			server.AddAdapter("/email/unsubscribe", email.NewEmailAdapter(func(query url.Values) (unsubscribe.Link, error) {
+				return email.NewVerifier(getConfig())(query)
+			}))
+			server.AddAdapter("/teams", teams.NewTeamsAdapter(func() (teams.Credentials, error) {
+				return teams.NewCredentialsSource(getConfig())()
+			}))
 			return server.Serve(port)
 		},
 	}
 	clientConfig = k8s.AddK8SFlagsToCmd(&command)
 	command.Flags().IntVar(&port, "port", 8080, "Port number.")
 	command.Flags().StringVar(&namespace, "namespace", "", "Namespace which bot handles. Current namespace if empty.")
+	command.Flags().StringVar(&argocdOpts.ServerURL, "argocd-server", "", "Argo CD API server address. Enables application actions in interactive messages.")
+	command.Flags().StringVar(&argocdOpts.AuthTokenFile, "argocd-auth-token-file", "", "Path to the file with the Argo CD account token used to perform application actions.")
+	command.Flags().BoolVar(&argocdOpts.Insecure, "argocd-insecure", false, "Skip Argo CD API server certificate verification.")
+	command.Flags().BoolVar(&rbac, "rbac", true, "Check permissions of the chat user who sends commands using Argo CD RBAC policies.")
+	command.Flags().StringVar(&mattermostResponseType, "mattermost-response-type", mattermost.ResponseTypeEphemeral, "Type of Mattermost command responses: ephemeral or in_channel.")
+	command.Flags().IntVar(&metricsPort, "metrics-port", defaultBotMetricsPort, "Port of the HTTP server that serves metrics. Zero disables metrics.")
+	command.Flags().StringVar(&metricsAddress, "metrics-bind-address", "0.0.0.0", "Address the HTTP server that serves metrics binds to")
+	httpserver.AddFlags(&command, "metrics", &metricsServer)
+	command.Flags().StringVar(&slackService, "slack-service", "", "Name of the Slack service which signing secret verifies Slack requests. Required if more than one Slack service is configured.")
+	command.Flags().StringVar(&redisOpts.Address, "redis", "", "Redis address, e.g. argocd-redis:6379. If specified, signatures of processed Slack requests are shared by all bot replicas, so replayed requests are rejected by every replica. The password is read from the REDIS_PASSWORD environment variable.")
+	command.Flags().BoolVar(&redisOpts.TLS, "redis-use-tls", false, "Use TLS when connecting to Redis")
+	command.Flags().BoolVar(&redisOpts.InsecureSkipVerify, "redis-insecure-skip-tls-verify", false, "Skip verification of the Redis server certificate")
+	command.Flags().StringVar(&redisOpts.CACertificate, "redis-ca-certificate", "", "Path to the PEM file with certificates used to verify the Redis server certificate")
+	command.Flags().StringVar(&auditLogFile, "audit-log-file", "", "Path to the file that receives the audit log in JSON format. The audit log is written to the standard log if not specified.")
 	return &command
 }
 
-func getVerifier(cfgSrc chan settings.Config) slack.RequestVerifier {
+// watchConfig returns function that returns the latest config received from the source
+func watchConfig(cfgSrc chan settings.Config) func() settings.Config {
 	cfg := <-cfgSrc
-	verifier := slack.NewVerifier(cfg)
 
 	var lock sync.Mutex
 
 	go func() {
 		for next := range cfgSrc {
 			lock.Lock()
-			verifier = slack.NewVerifier(next)
+			cfg = next
 			lock.Unlock()
 		}
 	}()
 
-	return func(data []byte, header http.Header) (string, error) {
-		var currentVerifier slack.RequestVerifier
+	return func() settings.Config {
 		lock.Lock()
-		currentVerifier = verifier
-		lock.Unlock()
-		return currentVerifier(data, header)
+		defer lock.Unlock()
+		return cfg
 	}
 }
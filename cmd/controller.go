@@ -2,47 +2,127 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/http/pprof"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/argoproj-labs/argocd-notifications/controller"
+	"github.com/argoproj-labs/argocd-notifications/pkg"
 	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/redact"
+	"github.com/argoproj-labs/argocd-notifications/shared/admission"
 	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+	"github.com/argoproj-labs/argocd-notifications/shared/aws"
+	"github.com/argoproj-labs/argocd-notifications/shared/deadletter"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/httpserver"
 	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
 	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+	"github.com/argoproj-labs/argocd-notifications/shared/quota"
+	"github.com/argoproj-labs/argocd-notifications/shared/selfmonitoring"
 	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/tracing"
+	"github.com/argoproj-labs/argocd-notifications/shared/vault"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 )
 
 const (
-	defaultMetricsPort = 9001
+	defaultMetricsPort     = 9001
+	historyCleanupInterval = 10 * time.Minute
 )
 
 func newControllerCommand() *cobra.Command {
 	var (
-		clientConfig     clientcmd.ClientConfig
-		processorsCount  int
-		namespace        string
-		appLabelSelector string
-		logLevel         string
-		logFormat        string
-		metricsPort      int
-		argocdRepoServer string
+		clientConfig        clientcmd.ClientConfig
+		processorsCount     int
+		deliveryParallelism int
+		namespace           string
+		appLabelSelectors   []string
+		appStripFields      []string
+		logLevel            string
+		logFormat           string
+		metricsPort         int
+		metricsAddress      string
+		repoServer          argocd.RepoServerOptions
+		repoCacheSize       int
+		repoCacheTTL        time.Duration
+		repoCacheRedis      argocd.RedisOptions
+		deadLettersSize     int
+		historyEnabled      bool
+		historyTTL          time.Duration
+		emitEvents          bool
+		enablePprof         bool
+		otlpAddress         string
+		metricsServer       httpserver.Options
+		webhookPort         int
+		webhookServer       httpserver.Options
+		queueBaseDelay      time.Duration
+		queueMaxDelay       time.Duration
+		queueQPS            float64
+		queueBurst          int
+		appMaxRetries       int
+		resyncPeriod        time.Duration
+		deliveryTimeout     time.Duration
+		shutdownGracePeriod time.Duration
+		maxEventAge         time.Duration
+		stateLimits         triggers.CompactOptions
+		appFilter           settings.AppFilter
+		dryRun              bool
+		filterUpdates       bool
+		argocdOpts          argocd.APIClientOptions
+		vaultOpts           vault.Options
+		awsSecrets          bool
+		awsRegion           string
+		configCRDs          bool
+		subscriptionCRDs    bool
+		nsSubscriptions     bool
+		rollouts            bool
+		workflows           bool
+		egressPolicyFile    string
 	)
 	var command = cobra.Command{
 		Use:   "controller",
 		Short: "Starts Argo CD Notifications controller",
 		RunE: func(c *cobra.Command, args []string) error {
+			for _, selector := range appLabelSelectors {
+				if _, err := labels.Parse(selector); err != nil {
+					return fmt.Errorf("invalid --app-label-selector '%s': %v", selector, err)
+				}
+			}
+			if egressPolicyFile != "" {
+				policies, err := httputil.LoadEgressPolicies(egressPolicyFile)
+				if err != nil {
+					return err
+				}
+				pkg.SetEgressPolicies(policies)
+			}
 			restConfig, err := clientConfig.ClientConfig()
 			if err != nil {
 				return err
@@ -61,6 +141,9 @@ func newControllerCommand() *cobra.Command {
 					return err
 				}
 			}
+			if err := appFilter.Validate(); err != nil {
+				return err
+			}
 			level, err := log.ParseLevel(logLevel)
 			if err != nil {
 				return err
@@ -78,37 +161,261 @@ func newControllerCommand() *cobra.Command {
 				return fmt.Errorf("Unknown log format '%s'", logFormat)
 			}
 
-			argocdService, err := argocd.NewArgoCDService(k8sClient, namespace, argocdRepoServer)
+			if otlpAddress != "" {
+				exporter := tracing.NewOTLPExporter(otlpAddress, "argocd-notifications-controller")
+				tracing.SetExporter(exporter)
+				defer exporter.Shutdown()
+				log.Infof("exporting traces to %s", otlpAddress)
+			}
+
+			argocdService, err := argocd.NewArgoCDService(k8sClient, namespace, repoServer)
 			if err != nil {
 				return err
 			}
 			defer argocdService.Close()
 
 			registry := controller.NewMetricsRegistry()
-			http.Handle("/metrics", promhttp.HandlerFor(prometheus.Gatherers{registry, prometheus.DefaultGatherer}, promhttp.HandlerOpts{}))
+			argocd.RegisterCacheMetrics(registry)
+			var repoService argocd.Service = argocd.NewCoalescingService(argocdService)
+			switch {
+			case repoCacheRedis.Address != "":
+				repoCacheRedis.Password = os.Getenv("REDIS_PASSWORD")
+				redisCache, err := argocd.NewRedisCache(repoCacheRedis, repoCacheTTL)
+				if err != nil {
+					return fmt.Errorf("failed to create Redis cache: %v", err)
+				}
+				defer func() {
+					_ = redisCache.Close()
+				}()
+				repoService = argocd.NewCachedService(repoService, redisCache)
+				log.Infof("caching repo server responses in Redis %s", repoCacheRedis.Address)
+			case repoCacheSize > 0:
+				repoService = argocd.NewCachedService(repoService, argocd.NewMemoryCache(repoCacheSize, repoCacheTTL))
+			}
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.HandlerFor(prometheus.Gatherers{registry, prometheus.DefaultGatherer}, promhttp.HandlerOpts{}))
 
-			go func() {
-				log.Fatal(http.ListenAndServe(fmt.Sprintf("0.0.0.0:%d", metricsPort), http.DefaultServeMux))
-			}()
-			log.Infof("serving metrics on port %d", metricsPort)
-			log.Infof("loading configuration %d", metricsPort)
+			var currentCtrl controller.NotificationController
+			var currentCtrlLock sync.RWMutex
+			setCurrentCtrl := func(ctrl controller.NotificationController) {
+				currentCtrlLock.Lock()
+				defer currentCtrlLock.Unlock()
+				currentCtrl = ctrl
+			}
+			mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+			})
+			mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+				currentCtrlLock.RLock()
+				defer currentCtrlLock.RUnlock()
+				if currentCtrl == nil || !currentCtrl.HasSynced() {
+					http.Error(w, "controller is not ready", http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = w.Write([]byte("ok"))
+			})
 
+			var debugConfig []byte
+			var debugConfigLock sync.RWMutex
+			var configMap *corev1.ConfigMap
+			var secret *corev1.Secret
+			if enablePprof {
+				addDebugHandlers(mux, func() []byte {
+					debugConfigLock.RLock()
+					defer debugConfigLock.RUnlock()
+					return debugConfig
+				})
+				mux.Handle(controller.StatePath, controller.NewStateHandler(func() controller.StateProvider {
+					currentCtrlLock.RLock()
+					defer currentCtrlLock.RUnlock()
+					if provider, ok := currentCtrl.(controller.StateProvider); ok && currentCtrl.HasSynced() {
+						return provider
+					}
+					return nil
+				}))
+			}
+
+			metricsServer.PublicPaths = []string{"/healthz", "/readyz"}
+			if err := metricsServer.Validate(); err != nil {
+				return err
+			}
+			if metricsPort > 0 {
+				go func() {
+					log.Fatal(metricsServer.ListenAndServe(net.JoinHostPort(metricsAddress, strconv.Itoa(metricsPort)), mux))
+				}()
+				log.Infof("serving metrics on %s", net.JoinHostPort(metricsAddress, strconv.Itoa(metricsPort)))
+			} else {
+				log.Info("HTTP server is disabled")
+			}
+			if webhookPort > 0 {
+				if webhookServer.TLSCertFile == "" || webhookServer.TLSKeyFile == "" {
+					return errors.New("admission webhook requires --webhook-tls-cert and --webhook-tls-key")
+				}
+				webhookMux := http.NewServeMux()
+				webhookMux.Handle("/validate", admission.NewConfigValidator(func() (*corev1.Secret, error) {
+					return k8sClient.CoreV1().Secrets(namespace).Get(context.Background(), k8s.SecretName, metav1.GetOptions{})
+				}))
+				go func() {
+					log.Fatal(webhookServer.ListenAndServe(net.JoinHostPort(metricsAddress, strconv.Itoa(webhookPort)), webhookMux))
+				}()
+				log.Infof("serving admission webhook on %s", net.JoinHostPort(metricsAddress, strconv.Itoa(webhookPort)))
+			}
+			log.Info("loading configuration")
+
+			var historyRecorder history.Recorder
+			if historyEnabled {
+				historyRecorder = history.NewRecorder(dynamicClient, namespace, historyTTL)
+				go history.Run(context.Background(), historyRecorder, historyCleanupInterval)
+			}
+
+			var eventRecorder record.EventRecorder
+			if emitEvents {
+				eventBroadcaster := record.NewBroadcaster()
+				eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: k8sClient.CoreV1().Events(namespace)})
+				defer eventBroadcaster.Shutdown()
+				eventRecorder = eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "argocd-notifications-controller"})
+			}
+
+			secretProviders := map[string]settings.SecretProvider{}
+			if vaultOpts.Address != "" {
+				vaultOpts.PathVars = map[string]string{"namespace": namespace}
+				vaultClient := vault.NewClient(vaultOpts)
+				go vaultClient.Run(context.Background())
+				secretProviders["vault"] = vaultClient
+			}
+			if awsSecrets {
+				awsSession, err := aws.NewSession(awsRegion)
+				if err != nil {
+					return fmt.Errorf("failed to create AWS session: %v", err)
+				}
+				secretProviders["aws-sm"] = aws.NewSecretsManagerProvider(awsSession)
+				secretProviders["aws-ssm"] = aws.NewParameterStoreProvider(awsSession)
+			}
+
+			monitor := selfmonitoring.NewMonitor()
+			// quota counters are kept across configuration reloads
+			quotaTracker := quota.NewTracker()
 			var cancelPrev context.CancelFunc
-			err = settings.WatchConfig(context.Background(), argocdService, k8sClient, namespace, func(cfg settings.Config) error {
+			// controllers are stopped once the shutdown signal is received; running tracks controllers that are
+			// draining in-flight deliveries
+			rootCtx, shutdown := context.WithCancel(context.Background())
+			var running sync.WaitGroup
+			resolver := settings.NewSecretResolver(k8sClient, namespace, secretProviders)
+			var configClient dynamic.Interface
+			if configCRDs {
+				configClient = dynamicClient
+			}
+			// resource controllers are started once and keep running across configuration reloads
+			var resourceTypes []controller.ResourceType
+			if rollouts {
+				resourceTypes = append(resourceTypes, controller.Rollouts)
+			}
+			if workflows {
+				resourceTypes = append(resourceTypes, controller.Workflows)
+			}
+			var resourceCtrls []controller.ResourceController
+			for _, resourceType := range resourceTypes {
+				if dryRun {
+					log.Warnf("Dry run is not supported for %s resources, skipping", resourceType.Name)
+					continue
+				}
+				resourceCtrl := controller.NewResourceController(dynamicClient, "", resourceType, resyncPeriod, maxEventAge)
+				resourceCtrls = append(resourceCtrls, resourceCtrl)
+				name := resourceType.Name
+				running.Add(1)
+				go func() {
+					defer running.Done()
+					if err := resourceCtrl.Init(rootCtx); err != nil {
+						log.Errorf("Failed to start %s controller: %v", name, err)
+						return
+					}
+					resourceCtrl.Run(rootCtx, processorsCount)
+				}()
+			}
+			err = settings.WatchConfig(rootCtx, repoService, k8sClient, configClient, namespace, resolver, func(cfg settings.Config) error {
 				if cancelPrev != nil {
 					log.Info("Settings had been updated. Restarting controller...")
 					cancelPrev()
 					cancelPrev = nil
+					setCurrentCtrl(nil)
+				}
+
+				redact.SetValues(cfg.SecretValues)
+				registry.SetConfigDeprecations(cfg.APIVersion, cfg.Deprecations)
+
+				// add console service that is useful for debugging unless it is configured using the service.console key
+				if _, ok := cfg.API.GetNotificationServices()["console"]; !ok {
+					cfg.API.AddNotificationService("console", services.NewConsoleService(os.Stdout))
+				}
+
+				if err := monitor.Configure(cfg.API, cfg.SelfMonitoring); err != nil {
+					return err
+				}
+				if err := quotaTracker.Configure(cfg.Quotas); err != nil {
+					return err
 				}
 
-				// add console service that is useful for debugging
-				cfg.API.AddNotificationService("console", services.NewConsoleService(os.Stdout))
+				if enablePprof {
+					if data, err := settings.RedactedConfig(cfg, configMap, secret); err != nil {
+						log.Warnf("Failed to serialize effective configuration: %v", err)
+					} else {
+						debugConfigLock.Lock()
+						debugConfig = data
+						debugConfigLock.Unlock()
+					}
+				}
 
-				ctrl, err := controller.NewController(dynamicClient, namespace, cfg, appLabelSelector, registry)
+				opts := []controller.Opts{
+					controller.WithQueueRateLimiter(workqueue.NewMaxOfRateLimiter(
+						workqueue.NewItemExponentialFailureRateLimiter(queueBaseDelay, queueMaxDelay),
+						&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(queueQPS), queueBurst)},
+					)),
+					controller.WithMaxRetries(appMaxRetries),
+					controller.WithResyncPeriod(resyncPeriod),
+					controller.WithAppFilter(appFilter),
+					controller.WithAppStripFields(appStripFields),
+					controller.WithDeliveryParallelism(deliveryParallelism),
+					controller.WithDeliveryTimeout(deliveryTimeout),
+					controller.WithShutdownGracePeriod(shutdownGracePeriod),
+					controller.WithMaxEventAge(maxEventAge),
+					controller.WithStateLimits(stateLimits),
+					controller.WithQuotaTracker(quotaTracker),
+				}
+				if subscriptionCRDs {
+					opts = append(opts, controller.WithSubscriptionResources())
+				}
+				if nsSubscriptions {
+					opts = append(opts, controller.WithNamespaceSubscriptions())
+				}
+				if filterUpdates {
+					opts = append(opts, controller.WithUpdateFilter())
+				}
+				if argocdOpts.ServerURL != "" {
+					opts = append(opts, controller.WithArgoCDAPI(argocd.NewAPIClient(argocdOpts)))
+				}
+				if dryRun {
+					// print notifications instead of sending and skip all side effects of the delivery
+					for name := range cfg.API.GetNotificationServices() {
+						cfg.API.AddNotificationService(name, services.NewDryRunService(os.Stdout))
+					}
+					opts = append(opts, controller.WithDryRun())
+				} else {
+					if deadLettersSize > 0 {
+						opts = append(opts, controller.WithDeadLetterStore(deadletter.NewConfigMapStore(k8sClient, namespace, deadLettersSize)))
+					}
+					if historyRecorder != nil {
+						opts = append(opts, controller.WithHistoryRecorder(historyRecorder))
+					}
+					if eventRecorder != nil {
+						opts = append(opts, controller.WithEventRecorder(eventRecorder))
+					}
+					opts = append(opts, controller.WithDeliveryObserver(monitor))
+				}
+				ctrl, err := controller.NewController(dynamicClient, namespace, cfg, appLabelSelectors, registry, opts...)
 				if err != nil {
 					return err
 				}
-				ctx, cancel := context.WithCancel(context.Background())
+				ctx, cancel := context.WithCancel(rootCtx)
 				cancelPrev = cancel
 
 				err = ctrl.Init(ctx)
@@ -116,23 +423,116 @@ func newControllerCommand() *cobra.Command {
 					return err
 				}
 
-				go ctrl.Run(ctx, processorsCount)
+				running.Add(1)
+				go func() {
+					defer running.Done()
+					ctrl.Run(ctx, processorsCount)
+				}()
+				setCurrentCtrl(ctrl)
+
+				for _, resourceCtrl := range resourceCtrls {
+					resourceCtrl.SetConfig(cfg)
+				}
 				return nil
-			}, legacy.ApplyLegacyConfig)
+			}, monitor.ReportConfigError, legacy.ApplyLegacyConfig, func(_ *settings.Config, cm *corev1.ConfigMap, s *corev1.Secret) error {
+				// keep raw settings to serve effective configuration at /debug/config
+				configMap, secret = cm, s
+				return nil
+			})
 			if err != nil {
 				log.Fatal(err)
 			}
-			<-context.Background().Done()
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+			sig := <-signals
+			log.Infof("Received %v, draining in-flight deliveries", sig)
+			shutdown()
+			running.Wait()
 			return nil
 		},
 	}
 	clientConfig = k8s.AddK8SFlagsToCmd(&command)
 	command.Flags().IntVar(&processorsCount, "processors-count", 1, "Processors count.")
-	command.Flags().StringVar(&appLabelSelector, "app-label-selector", "", "App label selector.")
+	command.Flags().IntVar(&deliveryParallelism, "delivery-parallelism", 5, "Max number of notifications about one application sent concurrently")
+	command.Flags().DurationVar(&shutdownGracePeriod, "shutdown-grace-period", 20*time.Second, "Max time the controller waits for in-flight deliveries after SIGTERM. Should be less than the pod termination grace period.")
+	command.Flags().DurationVar(&deliveryTimeout, "delivery-timeout", 5*time.Minute, "Max time of the notification delivery to one destination including retries. Zero value disables the timeout.")
+	command.Flags().StringArrayVar(&appLabelSelectors, "app-label-selector", nil, "App label selector, e.g. 'team in (payments,billing),!legacy'. The flag might be repeated to process apps that match any of the selectors.")
+	command.Flags().StringVar(&appFilter.FieldSelector, "app-field-selector", "", "App field selector in dot notation, e.g. spec.destination.namespace!=sandbox")
+	command.Flags().StringSliceVar(&appFilter.Projects, "app-projects", nil, "Glob patterns of processed app projects. All projects are processed if empty.")
+	command.Flags().StringSliceVar(&appFilter.ExcludeProjects, "app-exclude-projects", nil, "Glob patterns of ignored app projects")
+	command.Flags().StringSliceVar(&appFilter.Namespaces, "app-namespaces", nil, "Glob patterns of processed app destination namespaces. All namespaces are processed if empty.")
+	command.Flags().StringSliceVar(&appFilter.ExcludeNamespaces, "app-exclude-namespaces", nil, "Glob patterns of ignored app destination namespaces")
+	command.Flags().StringSliceVar(&appFilter.Names, "app-names", nil, "Glob patterns of processed app names. All apps are processed if empty.")
+	command.Flags().StringSliceVar(&appFilter.ExcludeNames, "app-exclude-names", nil, "Glob patterns of ignored app names")
+	command.Flags().StringSliceVar(&appStripFields, "app-strip-fields", controller.DefaultAppStripFields, "Dot separated app fields removed before apps are cached to reduce memory usage. Fields must not be referenced by triggers and templates. Managed fields and the last applied configuration are always removed.")
+	command.Flags().DurationVar(&resyncPeriod, "resync-period", 60*time.Second, "How often all applications are re-processed")
+	command.Flags().BoolVar(&filterUpdates, "filter-app-updates", false, "Skip app updates that don't change fields referenced by trigger conditions, e.g. status refreshes by Argo CD. Resyncs are not skipped.")
+	command.Flags().StringVar(&argocdOpts.ServerURL, "argocd-server", "", "Argo CD API server address. Exposes the application resource tree, events, sync windows and logs in templates.")
+	command.Flags().StringVar(&argocdOpts.AuthTokenFile, "argocd-auth-token-file", "", "Path to the file with the Argo CD account token used to read application data.")
+	command.Flags().BoolVar(&argocdOpts.Insecure, "argocd-insecure", false, "Skip Argo CD API server certificate verification.")
+	command.Flags().DurationVar(&maxEventAge, "max-event-age", 0, "Skip notifications about app, rollout and workflow state transitions older than the specified age when they are processed for the first time after the controller start, e.g. 30m. Zero value disables the check.")
+	command.Flags().IntVar(&stateLimits.MaxItems, "state-max-items", triggers.DefaultCompactOptions.MaxItems, "Max number of items in the notification state annotation of the app")
+	command.Flags().IntVar(&stateLimits.MaxItemsPerTrigger, "state-max-items-per-trigger", triggers.DefaultCompactOptions.MaxItemsPerTrigger, "Max number of oncePer items of one trigger in the notification state. Zero value means no limit.")
+	command.Flags().DurationVar(&stateLimits.TTL, "state-ttl", triggers.DefaultCompactOptions.TTL, "How long oncePer items are kept in the notification state, e.g. 720h. Zero value means forever.")
+	command.Flags().IntVar(&stateLimits.MaxBytes, "state-max-bytes", triggers.DefaultCompactOptions.MaxBytes, "Max size of the notification state annotation in bytes. Zero value means no limit.")
+	command.Flags().DurationVar(&queueBaseDelay, "queue-base-delay", 5*time.Millisecond, "Initial delay before the failed application processing is retried")
+	command.Flags().DurationVar(&queueMaxDelay, "queue-max-delay", 1000*time.Second, "Max delay before the failed application processing is retried")
+	command.Flags().Float64Var(&queueQPS, "queue-qps", 10, "Overall rate of retried applications processing per second")
+	command.Flags().IntVar(&queueBurst, "queue-burst", 100, "Burst of retried applications processing")
+	command.Flags().IntVar(&appMaxRetries, "app-max-retries", 5, "Max number of times the failed application processing is retried before waiting for the next resync")
 	command.Flags().StringVar(&namespace, "namespace", "", "Namespace which controller handles. Current namespace if empty.")
 	command.Flags().StringVar(&logLevel, "loglevel", "info", "Set the logging level. One of: debug|info|warn|error")
 	command.Flags().StringVar(&logFormat, "logformat", "text", "Set the logging format. One of: text|json")
-	command.Flags().IntVar(&metricsPort, "metrics-port", defaultMetricsPort, "Metrics port")
-	command.Flags().StringVar(&argocdRepoServer, "argocd-repo-server", "argocd-repo-server:8081", "Argo CD repo server address")
+	command.Flags().IntVar(&metricsPort, "metrics-port", defaultMetricsPort, "Port of the HTTP server that serves metrics and health checks. Zero disables the HTTP server.")
+	command.Flags().StringVar(&metricsAddress, "metrics-bind-address", "0.0.0.0", "Address the HTTP server that serves metrics and health checks binds to")
+	httpserver.AddFlags(&command, "metrics", &metricsServer)
+	command.Flags().IntVar(&webhookPort, "webhook-port", 0, "Port of the admission webhook that validates notifications config map. Zero disables the webhook.")
+	httpserver.AddFlags(&command, "webhook", &webhookServer)
+	argocd.AddRepoServerFlags(&command, &repoServer)
+	command.Flags().IntVar(&repoCacheSize, "repo-server-cache-size", 1000, "Max number of repo server responses kept in memory. Use 0 to disable the cache")
+	command.Flags().DurationVar(&repoCacheTTL, "repo-server-cache-ttl", 5*time.Minute, "How long repo server responses are cached")
+	command.Flags().StringVar(&repoCacheRedis.Address, "repo-server-cache-redis", "", "Redis address, e.g. argocd-redis:6379. If specified, repo server responses are cached in Redis and shared by all controller replicas. The password is read from the REDIS_PASSWORD environment variable")
+	command.Flags().BoolVar(&repoCacheRedis.TLS, "repo-server-cache-redis-use-tls", false, "Use TLS when connecting to Redis")
+	command.Flags().BoolVar(&repoCacheRedis.InsecureSkipVerify, "repo-server-cache-redis-insecure-skip-tls-verify", false, "Skip verification of the Redis server certificate")
+	command.Flags().StringVar(&repoCacheRedis.CACertificate, "repo-server-cache-redis-ca-certificate", "", "Path to the PEM file with certificates used to verify the Redis server certificate")
+	command.Flags().IntVar(&repoCacheRedis.PoolSize, "repo-server-cache-redis-pool-size", 0, "Max number of Redis connections. Defaults to 10 connections per CPU")
+	command.Flags().IntVar(&deadLettersSize, "dead-letters-max-size", 100, "Max number of undelivered notifications kept in the dead letters ConfigMap. Zero disables dead letters.")
+	command.Flags().StringVar(&otlpAddress, "otlp-address", "", "OpenTelemetry collector OTLP/HTTP address (e.g. otel-collector:4318). Tracing is disabled if empty.")
+	command.Flags().BoolVar(&dryRun, "dry-run", false, "Evaluate triggers and render templates but print notifications to stdout instead of sending them. Notifications state is not persisted in applications.")
+	command.Flags().BoolVar(&enablePprof, "enable-pprof", false, "Serve /debug/pprof, /debug/config and /api/state endpoints on the metrics port")
+	command.Flags().BoolVar(&emitEvents, "emit-events", true, "Emit Kubernetes events on the application when notification is sent or fails")
+	command.Flags().BoolVar(&historyEnabled, "history-enabled", false, "Record notification delivery attempts as NotificationHistory resources. Requires NotificationHistory CRD.")
+	command.Flags().DurationVar(&historyTTL, "history-ttl", 30*24*time.Hour, "Duration after which NotificationHistory resources are removed")
+	command.Flags().StringVar(&vaultOpts.Address, "vault-address", "", "Vault server address. Enables resolving $vault:<path>#<field> references in service configuration.")
+	command.Flags().StringVar(&vaultOpts.Role, "vault-role", "", "Vault role used to log in with the Kubernetes auth method")
+	command.Flags().StringVar(&vaultOpts.AuthPath, "vault-auth-path", "kubernetes", "Mount path of the Vault Kubernetes auth method")
+	command.Flags().StringVar(&vaultOpts.Namespace, "vault-namespace", "", "Vault Enterprise namespace")
+	command.Flags().StringVar(&vaultOpts.TLS.CABundleFile, "vault-ca-cert", "", "Path to the CA bundle used to verify Vault server certificate")
+	command.Flags().BoolVar(&awsSecrets, "aws-secrets-enabled", false, "Enables resolving $aws-sm:<secret-id>#<field> and $aws-ssm:<parameter> references in service configuration")
+	command.Flags().StringVar(&awsRegion, "aws-region", "", "AWS region of Secrets Manager and Parameter Store. Defaults to AWS_REGION environment variable")
+	command.Flags().BoolVar(&configCRDs, "config-crds", false, "Load triggers, templates and services from NotificationTrigger, NotificationTemplate and NotificationService resources in addition to the config map. Requires CRDs to be installed.")
+	command.Flags().BoolVar(&subscriptionCRDs, "subscription-crds", false, "Process NotificationSubscription resources from all namespaces. Requires CRD to be installed and permissions to watch the resources cluster-wide.")
+	command.Flags().BoolVar(&nsSubscriptions, "namespace-subscriptions", false, "Apply subscription annotations of the application destination namespace. Requires permissions to watch namespaces.")
+	command.Flags().BoolVar(&rollouts, "rollouts", false, "Send notifications about Argo Rollouts resources in all namespaces. Requires permissions to watch and patch rollouts cluster-wide.")
+	command.Flags().StringVar(&egressPolicyFile, "egress-policy-file", "", "Path to the YAML file with hosts and CIDRs each service type is allowed to connect to. Should be mounted from a source that users who manage notifications config cannot modify.")
+	command.Flags().BoolVar(&workflows, "workflows", false, "Send notifications about Argo Workflows resources in all namespaces. Requires permissions to watch and patch workflows and to get cron workflows cluster-wide.")
 	return &command
 }
+
+// addDebugHandlers registers pprof handlers and the endpoint that returns effective configuration with redacted secrets
+func addDebugHandlers(mux *http.ServeMux, getConfig func() []byte) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/config", func(w http.ResponseWriter, r *http.Request) {
+		config := getConfig()
+		if config == nil {
+			http.Error(w, "configuration is not loaded", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(config)
+	})
+}
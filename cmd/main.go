@@ -4,19 +4,24 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/argoproj-labs/argocd-notifications/cmd/tools"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/redact"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 func main() {
-	binaryName := filepath.Base(os.Args[0])
+	binaryName := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
 	if val := os.Getenv("ARGOCD_NOTIFICATIONS_BINARY"); val != "" {
 		binaryName = val
 	}
 	var command *cobra.Command
 	switch binaryName {
 	case "argocd-notifications-backend":
+		// secret values are added to the redactor once the configuration is loaded
+		log.AddHook(redact.NewHook(redact.Default))
 		command = &cobra.Command{
 			Use: "argocd-notifications-backend",
 			Run: func(c *cobra.Command, args []string) {
@@ -25,8 +30,12 @@ func main() {
 		}
 		command.AddCommand(newControllerCommand())
 		command.AddCommand(newBotCommand())
+		command.AddCommand(newAPIServerCommand())
+	case tools.KubectlPluginBinaryName:
+		// kubectl plugin, the namespace and context are detected from the kubeconfig as in kubectl
+		command = tools.NewToolsCommand(tools.KubectlPluginBinaryName, "kubectl argocd-notifications")
 	default:
-		command = tools.NewToolsCommand()
+		command = tools.NewToolsCommand(tools.DefaultCLIName, tools.DefaultCLIName)
 	}
 
 	if err := command.Execute(); err != nil {
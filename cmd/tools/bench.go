@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/expr"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/misc"
+	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+)
+
+// benchStatuses are combinations of application statuses used to generate synthetic applications, so both true and false
+// branches of trigger conditions are measured
+var benchStatuses = []struct {
+	phase  string
+	sync   string
+	health string
+}{
+	{"Succeeded", "Synced", "Healthy"},
+	{"Failed", "OutOfSync", "Degraded"},
+	{"Running", "OutOfSync", "Progressing"},
+	{"Succeeded", "Unknown", "Missing"},
+}
+
+type benchResult struct {
+	count    int
+	errors   int
+	duration time.Duration
+}
+
+func (r benchResult) avg() time.Duration {
+	if r.count == 0 {
+		return 0
+	}
+	return r.duration / time.Duration(r.count)
+}
+
+// newBenchApps returns the synthetic applications based on the application manifest or default application
+func newBenchApps(base *unstructured.Unstructured, count int) []*unstructured.Unstructured {
+	var apps []*unstructured.Unstructured
+	for i := 0; i < count; i++ {
+		var app *unstructured.Unstructured
+		if base != nil {
+			app = base.DeepCopy()
+		} else {
+			status := benchStatuses[i%len(benchStatuses)]
+			app = &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "argoproj.io/v1alpha1",
+				"kind":       "Application",
+				"spec": map[string]interface{}{
+					"project": "default",
+					"source":  map[string]interface{}{"repoURL": "https://github.com/argoproj/argocd-example-apps.git", "path": "guestbook"},
+					"destination": map[string]interface{}{
+						"server":    "https://kubernetes.default.svc",
+						"namespace": "default",
+					},
+				},
+				"status": map[string]interface{}{
+					"sync":   map[string]interface{}{"status": status.sync, "revision": "abc"},
+					"health": map[string]interface{}{"status": status.health},
+					"operationState": map[string]interface{}{
+						"phase":      status.phase,
+						"startedAt":  "2021-01-01T00:00:00Z",
+						"finishedAt": "2021-01-01T00:01:00Z",
+					},
+				},
+			}}
+		}
+		app.SetName(fmt.Sprintf("bench-app-%d", i))
+		apps = append(apps, app)
+	}
+	return apps
+}
+
+func newBenchCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		appsCount int
+	)
+	var command = cobra.Command{
+		Use:   "bench",
+		Short: "Measures trigger evaluation and template rendering performance of the config using synthetic applications",
+		Example: `
+# Measure performance of the config map against 5000 synthetic applications
+argocd-notifications bench --apps 5000 --config-map ./argocd-notifications-cm.yaml --secret :empty
+
+# Use the real application manifest as the base of synthetic applications
+argocd-notifications bench --apps 5000 --app-file ./guestbook.yaml --commit-metadata-file ./commit.yaml
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			if appsCount <= 0 {
+				return fmt.Errorf("--apps must be positive")
+			}
+			cfg, err := cmdContext.getConfig()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to parse config: %v\n", err)
+				return nil
+			}
+			var base *unstructured.Unstructured
+			if cmdContext.appFile != "" {
+				if base, err = cmdContext.loadApplicationFile(cmdContext.appFile); err != nil {
+					_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load application: %v\n", err)
+					return nil
+				}
+			}
+			apps := newBenchApps(base, appsCount)
+			dest := services.Destination{Service: "bench", Recipient: "bench"}
+
+			triggerResults := map[string]*benchResult{}
+			templateResults := map[string]*benchResult{}
+			var triggersTotal, templatesTotal time.Duration
+			for name := range cfg.Triggers {
+				triggerResults[name] = &benchResult{}
+			}
+			for name := range cfg.Templates {
+				templateResults[name] = &benchResult{}
+			}
+			for _, app := range apps {
+				vars := expr.Spawn(app, cfg.ArgoCDService, map[string]interface{}{
+					"app":     app.Object,
+					"context": legacy.InjectLegacyVar(cfg.Context, dest.Service),
+				})
+				for name, res := range triggerResults {
+					start := time.Now()
+					_, err := cfg.API.RunTrigger(name, vars)
+					elapsed := time.Since(start)
+					res.count++
+					res.duration += elapsed
+					triggersTotal += elapsed
+					if err != nil {
+						res.errors++
+					}
+				}
+				for name, res := range templateResults {
+					start := time.Now()
+					_, err := cfg.API.FormatNotification(vars, []string{name}, dest)
+					elapsed := time.Since(start)
+					res.count++
+					res.duration += elapsed
+					templatesTotal += elapsed
+					if err != nil {
+						res.errors++
+					}
+				}
+			}
+
+			w := tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintf(w, "TRIGGER\tEVALUATIONS\tERRORS\tTOTAL\tAVG\n")
+			misc.IterateStringKeyMap(triggerResults, func(name string) {
+				res := triggerResults[name]
+				_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%v\t%v\n", name, res.count, res.errors, res.duration, res.avg())
+			})
+			_, _ = fmt.Fprintf(w, "\nTEMPLATE\tRENDERS\tERRORS\tTOTAL\tAVG\n")
+			misc.IterateStringKeyMap(templateResults, func(name string) {
+				res := templateResults[name]
+				_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%v\t%v\n", name, res.count, res.errors, res.duration, res.avg())
+			})
+			_ = w.Flush()
+			perApp := (triggersTotal + templatesTotal) / time.Duration(len(apps))
+			_, _ = fmt.Fprintf(cmdContext.stdout, "\nApplications: %d\n", len(apps))
+			_, _ = fmt.Fprintf(cmdContext.stdout, "Triggers evaluation: %v total, %v per application\n", triggersTotal, triggersTotal/time.Duration(len(apps)))
+			_, _ = fmt.Fprintf(cmdContext.stdout, "Templates rendering: %v total, %v per application\n", templatesTotal, templatesTotal/time.Duration(len(apps)))
+			if perApp > 0 {
+				_, _ = fmt.Fprintf(cmdContext.stdout, "Estimated throughput: %.0f applications per second per processor\n", float64(time.Second)/float64(perApp))
+			}
+			return nil
+		},
+	}
+	command.Flags().IntVar(&appsCount, "apps", 1000, "Number of synthetic applications")
+	addOfflineFlags(&command, cmdContext)
+	return &command
+}
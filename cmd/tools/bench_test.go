@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBench(t *testing.T) {
+	cmData := map[string]string{
+		"trigger.on-deployed": `
+- when: app.status.operationState.phase == 'Succeeded'
+  send: [app-deployed]`,
+		"template.app-deployed": `{message: "{{.app.metadata.name}} is deployed"}`,
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newBenchCommand(ctx)
+	assert.NoError(t, command.Flags().Set("apps", "10"))
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Regexp(t, `on-deployed\s+10\s+0\s+`, stdout.String())
+	assert.Regexp(t, `app-deployed\s+10\s+0\s+`, stdout.String())
+	assert.Contains(t, stdout.String(), "Applications: 10")
+}
+
+func TestNewBenchApps(t *testing.T) {
+	apps := newBenchApps(nil, 5)
+	assert.Len(t, apps, 5)
+	assert.Equal(t, "bench-app-0", apps[0].GetName())
+	assert.Equal(t, "bench-app-4", apps[4].GetName())
+	assert.NotEqual(t, apps[0].Object["status"], apps[1].Object["status"])
+}
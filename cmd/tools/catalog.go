@@ -0,0 +1,245 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/misc"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// catalog describes configured triggers and templates, e.g. for internal developer portals
+type catalog struct {
+	Triggers  []catalogTrigger  `json:"triggers"`
+	Templates []catalogTemplate `json:"templates"`
+}
+
+type catalogTrigger struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Conditions  []catalogCondition `json:"conditions"`
+	// Subscribers are recipients of the default subscriptions of the trigger
+	Subscribers []string `json:"subscribers,omitempty"`
+}
+
+type catalogCondition struct {
+	Description string   `json:"description,omitempty"`
+	When        string   `json:"when"`
+	OncePer     string   `json:"oncePer,omitempty"`
+	Send        []string `json:"send"`
+}
+
+type catalogTemplate struct {
+	Name string `json:"name"`
+	// Services are services with the service specific template or empty if the template has the message only
+	Services   []string `json:"services,omitempty"`
+	UsedBy     []string `json:"usedBy,omitempty"`
+	Definition string   `json:"definition"`
+}
+
+// templateServices returns names of services that have the service specific fields in the template
+func templateServices(notification services.Notification) ([]string, error) {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	var res []string
+	for field := range fields {
+		if field != "message" {
+			res = append(res, field)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func newCatalog(cfg *settings.Config) (*catalog, error) {
+	res := &catalog{Triggers: []catalogTrigger{}, Templates: []catalogTemplate{}}
+	usedBy := map[string][]string{}
+	misc.IterateStringKeyMap(cfg.Triggers, func(name string) {
+		trigger := catalogTrigger{Name: name, Conditions: []catalogCondition{}}
+		for _, condition := range cfg.Triggers[name] {
+			if trigger.Description == "" {
+				trigger.Description = condition.Description
+			}
+			trigger.Conditions = append(trigger.Conditions, catalogCondition{
+				Description: condition.Description, When: condition.When, OncePer: condition.OncePer, Send: condition.Send,
+			})
+			for _, template := range condition.Send {
+				if n := len(usedBy[template]); n == 0 || usedBy[template][n-1] != name {
+					usedBy[template] = append(usedBy[template], name)
+				}
+			}
+		}
+		for _, sub := range cfg.Subscriptions {
+			triggers := sub.Triggers
+			if len(triggers) == 0 {
+				triggers = cfg.DefaultTriggers
+			}
+			for _, t := range triggers {
+				if t == name {
+					trigger.Subscribers = append(trigger.Subscribers, sub.Recipients...)
+				}
+			}
+		}
+		res.Triggers = append(res.Triggers, trigger)
+	})
+	var err error
+	misc.IterateStringKeyMap(cfg.Templates, func(name string) {
+		if err != nil {
+			return
+		}
+		notification := cfg.Templates[name]
+		var definition []byte
+		if definition, err = yaml.Marshal(notification); err != nil {
+			return
+		}
+		var templateServiceNames []string
+		if templateServiceNames, err = templateServices(notification); err != nil {
+			return
+		}
+		res.Templates = append(res.Templates, catalogTemplate{
+			Name: name, Services: templateServiceNames, UsedBy: usedBy[name], Definition: string(definition),
+		})
+	})
+	return res, err
+}
+
+func writeCatalogMarkdown(out io.Writer, c *catalog) error {
+	_, _ = fmt.Fprintln(out, "# Triggers and Templates Catalog")
+	_, _ = fmt.Fprintln(out, "## Triggers")
+
+	w := tablewriter.NewWriter(out)
+	w.SetHeader([]string{"NAME", "DESCRIPTION", "TEMPLATE", "SUBSCRIBERS"})
+	w.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	w.SetCenterSeparator("|")
+	w.SetAutoWrapText(false)
+	for _, trigger := range c.Triggers {
+		var templates []string
+		for _, condition := range trigger.Conditions {
+			for _, template := range condition.Send {
+				templates = append(templates, fmt.Sprintf("[%s](#%s)", template, template))
+			}
+		}
+		w.Append([]string{trigger.Name, trigger.Description, strings.Join(templates, ","), strings.Join(trigger.Subscribers, ",")})
+	}
+	w.Render()
+
+	for _, trigger := range c.Triggers {
+		conditions, err := yaml.Marshal(trigger.Conditions)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(out, "\n### %s\n**conditions**:\n```yaml\n%s```\n", trigger.Name, string(conditions))
+	}
+
+	_, _ = fmt.Fprintln(out, "")
+	_, _ = fmt.Fprintln(out, "## Templates")
+	for _, template := range c.Templates {
+		serviceNames := "all"
+		if len(template.Services) > 0 {
+			serviceNames = strings.Join(template.Services, ", ")
+		}
+		_, _ = fmt.Fprintf(out, "### %s\n**services**: %s\n\n", template.Name, serviceNames)
+		if len(template.UsedBy) > 0 {
+			_, _ = fmt.Fprintf(out, "**used by**: %s\n\n", strings.Join(template.UsedBy, ", "))
+		}
+		_, _ = fmt.Fprintf(out, "**definition**:\n```yaml\n%s```\n", template.Definition)
+	}
+	return nil
+}
+
+var catalogHTML = htmltemplate.Must(htmltemplate.New("catalog").Funcs(htmltemplate.FuncMap{"join": strings.Join}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Triggers and Templates Catalog</title>
+<style>
+body { font-family: sans-serif; color: #333; }
+table { border-collapse: collapse; }
+td, th { border-bottom: 1px solid #eee; padding: 4px; text-align: left; vertical-align: top; }
+pre { background: #f5f5f5; padding: 10px; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>Triggers and Templates Catalog</h1>
+<h2>Triggers</h2>
+<table>
+<thead><tr><th>Name</th><th>Description</th><th>Conditions</th><th>Subscribers</th></tr></thead>
+<tbody>
+{{- range .Triggers}}
+<tr id="trigger-{{.Name}}"><td>{{.Name}}</td><td>{{.Description}}</td><td>
+{{- range .Conditions}}<pre>{{.When}}</pre>sends {{range $i, $t := .Send}}{{if $i}}, {{end}}<a href="#template-{{$t}}">{{$t}}</a>{{end}}{{if .OncePer}} once per {{.OncePer}}{{end}}{{end -}}
+</td><td>{{join .Subscribers ", "}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+<h2>Templates</h2>
+{{- range .Templates}}
+<h3 id="template-{{.Name}}">{{.Name}}</h3>
+<p>Services: {{if .Services}}{{join .Services ", "}}{{else}}all{{end}}</p>
+{{- if .UsedBy}}
+<p>Used by: {{range $i, $t := .UsedBy}}{{if $i}}, {{end}}<a href="#trigger-{{$t}}">{{$t}}</a>{{end}}</p>
+{{- end}}
+<pre>{{.Definition}}</pre>
+{{- end}}
+</body>
+</html>
+`))
+
+func newCatalogCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		output string
+	)
+	var command = cobra.Command{
+		Use:   "catalog",
+		Short: "Generates documentation of configured triggers and templates",
+		Example: `
+# Generate the markdown documentation of triggers and templates configured in the cluster
+argocd-notifications catalog > notifications-catalog.md
+
+# Generate the HTML page for the internal developer portal from the local config map
+argocd-notifications catalog --config-map ./argocd-notifications-cm.yaml --secret :empty -o html > catalog.html
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			cfg, err := cmdContext.getConfig()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to parse config: %v\n", err)
+				return nil
+			}
+			res, err := newCatalog(cfg)
+			if err != nil {
+				return err
+			}
+			switch output {
+			case "markdown":
+				return writeCatalogMarkdown(cmdContext.stdout, res)
+			case "html":
+				var buf bytes.Buffer
+				if err := catalogHTML.Execute(&buf, res); err != nil {
+					return err
+				}
+				_, err = cmdContext.stdout.Write(buf.Bytes())
+				return err
+			default:
+				return misc.PrintFormatted(res, output, cmdContext.stdout)
+			}
+		},
+	}
+	command.Flags().StringVarP(&output, "output", "o", "markdown", "Output format. One of:markdown|html|json|yaml")
+	return &command
+}
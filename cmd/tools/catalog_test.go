@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var catalogConfig = map[string]string{
+	"trigger.on-deployed": `
+- description: Application is synced and healthy
+  when: app.status.operationState.phase == 'Succeeded'
+  oncePer: app.status.sync.revision
+  send: [app-deployed]`,
+	"template.app-deployed": `
+message: Application {{.app.metadata.name}} is deployed
+slack:
+  attachments: "[]"`,
+	"subscriptions": `
+- recipients: [slack:deployments]
+  triggers: [on-deployed]`,
+}
+
+func TestCatalog_JSON(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, catalogConfig)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newCatalogCommand(ctx)
+	assert.NoError(t, command.Flags().Set("output", "json"))
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+
+	var res catalog
+	if !assert.NoError(t, json.Unmarshal(stdout.Bytes(), &res)) {
+		return
+	}
+	if assert.Len(t, res.Triggers, 1) {
+		assert.Equal(t, "Application is synced and healthy", res.Triggers[0].Description)
+		assert.Equal(t, []string{"slack:deployments"}, res.Triggers[0].Subscribers)
+		assert.Equal(t, "app.status.sync.revision", res.Triggers[0].Conditions[0].OncePer)
+	}
+	if assert.Len(t, res.Templates, 1) {
+		assert.Equal(t, []string{"slack"}, res.Templates[0].Services)
+		assert.Equal(t, []string{"on-deployed"}, res.Templates[0].UsedBy)
+	}
+}
+
+func TestCatalog_Markdown(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, catalogConfig)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newCatalogCommand(ctx)
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "# Triggers and Templates Catalog")
+	assert.Regexp(t, `\| on-deployed \| Application is synced and healthy \| \[app-deployed\]\(#app-deployed\) \| slack:deployments \|`, stdout.String())
+	assert.Contains(t, stdout.String(), "### app-deployed\n**services**: slack\n\n**used by**: on-deployed")
+}
+
+func TestCatalog_HTML(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, catalogConfig)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newCatalogCommand(ctx)
+	assert.NoError(t, command.Flags().Set("output", "html"))
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), `<h3 id="template-app-deployed">app-deployed</h3>`)
+	assert.Contains(t, stdout.String(), `<a href="#template-app-deployed">app-deployed</a>`)
+	assert.Contains(t, stdout.String(), "app.status.operationState.phase == &#39;Succeeded&#39;")
+}
@@ -0,0 +1,312 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+)
+
+func newConfigCommand(cmdContext *commandContext) *cobra.Command {
+	var command = cobra.Command{
+		Use:   "config",
+		Short: "Notifications configuration related commands",
+		RunE: func(c *cobra.Command, args []string) error {
+			return errors.New("select child command")
+		},
+	}
+	command.AddCommand(newConfigDiffCommand(cmdContext))
+	command.AddCommand(newConfigMigrateCommand(cmdContext))
+
+	return &command
+}
+
+func newConfigDiffCommand(cmdContext *commandContext) *cobra.Command {
+	var command = cobra.Command{
+		Use:   "diff",
+		Short: "Compares triggers, templates, services and other settings of local files with the config in the cluster",
+		Example: `
+# Compare the local config map with the 'argocd-notifications-cm' ConfigMap in the cluster
+argocd-notifications config diff --config-map ./argocd-notifications-cm.yaml
+
+# Compare the local config map and secret with the cluster ones. Secret values are never printed
+argocd-notifications config diff --config-map ./argocd-notifications-cm.yaml --secret ./argocd-notifications-secret.yaml
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			if cmdContext.configMapPath == "" {
+				return errors.New("--config-map flag is required")
+			}
+			compareSecrets := cmdContext.secretPath != "" && cmdContext.secretPath != ":empty"
+			local := *cmdContext
+			cluster := *cmdContext
+			cluster.configMapPath = ""
+			cluster.secretPath = ""
+			if !compareSecrets {
+				local.secretPath = ":empty"
+				cluster.secretPath = ":empty"
+			}
+			localConfigMap, localSecret, _, err := local.loadConfigSources()
+			if err != nil {
+				return fmt.Errorf("failed to load local config: %v", err)
+			}
+			clusterConfigMap, clusterSecret, _, err := cluster.loadConfigSources()
+			if err != nil {
+				return fmt.Errorf("failed to load cluster config: %v", err)
+			}
+
+			changes := diffConfigData(clusterConfigMap.Data, localConfigMap.Data)
+			if compareSecrets {
+				changes = append(changes, diffSecretData(secretData(clusterSecret), secretData(localSecret))...)
+			}
+			if len(changes) == 0 {
+				_, _ = fmt.Fprintln(cmdContext.stdout, "No differences found")
+				return nil
+			}
+			for _, change := range changes {
+				_, _ = fmt.Fprintln(cmdContext.stdout, change)
+			}
+			return nil
+		},
+	}
+	return &command
+}
+
+func newConfigMigrateCommand(cmdContext *commandContext) *cobra.Command {
+	var command = cobra.Command{
+		Use:   "migrate",
+		Short: "Converts deprecated 'config.yaml' and 'notifiers.yaml' settings into the current config map format and prints the result",
+		Example: `
+# Migrate the config in the cluster and apply the result
+argocd-notifications config migrate | kubectl apply -n argocd -f -
+
+# Migrate local files
+argocd-notifications config migrate --config-map ./argocd-notifications-cm.yaml --secret ./argocd-notifications-secret.yaml
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			configMap, secret, err := cmdContext.loadRawConfig()
+			if err != nil {
+				return err
+			}
+			migratedConfigMap, migratedSecret, changes, err := legacy.MigrateConfig(configMap, secret)
+			if err != nil {
+				return err
+			}
+			if len(changes) == 0 {
+				_, _ = fmt.Fprintln(cmdContext.stderr, "Config has no deprecated settings")
+				return nil
+			}
+			for _, change := range changes {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "# %s\n", change)
+			}
+			objects := []interface{}{&v1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: cleanObjectMeta(migratedConfigMap.ObjectMeta, k8s.ConfigMapName),
+				Data:       migratedConfigMap.Data,
+			}}
+			if !reflect.DeepEqual(secretData(secret), secretData(migratedSecret)) {
+				objects = append(objects, &v1.Secret{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+					ObjectMeta: cleanObjectMeta(migratedSecret.ObjectMeta, k8s.SecretName),
+					Data:       migratedSecret.Data,
+				})
+			}
+			for i, obj := range objects {
+				data, err := yaml.Marshal(obj)
+				if err != nil {
+					return err
+				}
+				if i > 0 {
+					_, _ = fmt.Fprintln(cmdContext.stdout, "---")
+				}
+				_, _ = fmt.Fprint(cmdContext.stdout, string(data))
+			}
+			return nil
+		},
+	}
+	return &command
+}
+
+// cleanObjectMeta keeps only metadata fields that make sense in the manifest file
+func cleanObjectMeta(meta metav1.ObjectMeta, defaultName string) metav1.ObjectMeta {
+	name := meta.Name
+	if name == "" {
+		name = defaultName
+	}
+	return metav1.ObjectMeta{Name: name, Namespace: meta.Namespace, Labels: meta.Labels}
+}
+
+// loadRawConfig returns the config map and secret without merging config fragments, so the result might be applied as is
+func (c *commandContext) loadRawConfig() (*v1.ConfigMap, *v1.Secret, error) {
+	var configMap v1.ConfigMap
+	var secret v1.Secret
+	if c.configMapPath == "" || c.secretPath == "" {
+		k8sClient, _, ns, err := c.getK8SClients()
+		if err != nil {
+			return nil, nil, err
+		}
+		if c.configMapPath == "" {
+			cm, err := k8sClient.CoreV1().ConfigMaps(ns).Get(context.Background(), k8s.ConfigMapName, metav1.GetOptions{})
+			if err != nil {
+				return nil, nil, err
+			}
+			configMap = *cm
+		}
+		if c.secretPath == "" {
+			s, err := k8sClient.CoreV1().Secrets(ns).Get(context.Background(), k8s.SecretName, metav1.GetOptions{})
+			if err != nil {
+				return nil, nil, err
+			}
+			secret = *s
+		}
+	}
+	if c.configMapPath != "" {
+		if err := c.unmarshalFromFile(c.configMapPath, k8s.ConfigMapName, schema.GroupKind{Kind: "ConfigMap"}, &configMap); err != nil {
+			return nil, nil, err
+		}
+	}
+	if c.secretPath != "" && c.secretPath != ":empty" {
+		if err := c.unmarshalFromFile(c.secretPath, k8s.SecretName, schema.GroupKind{Kind: "Secret"}, &secret); err != nil {
+			return nil, nil, err
+		}
+	}
+	return &configMap, &secret, nil
+}
+
+// flattenValue converts the parsed YAML value into the map of field paths and JSON encoded values, so the changes
+// might be reported per field and formatting differences are ignored
+func flattenValue(path string, val interface{}, res map[string]string) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			itemPath := k
+			if path != "" {
+				itemPath = path + "." + k
+			}
+			flattenValue(itemPath, item, res)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenValue(fmt.Sprintf("%s[%d]", path, i), item, res)
+		}
+	default:
+		data, _ := json.Marshal(v)
+		res[path] = string(data)
+	}
+}
+
+func flattenConfigValue(val string) map[string]string {
+	res := map[string]string{}
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(val), &parsed); err != nil {
+		parsed = val
+	}
+	flattenValue("", parsed, res)
+	return res
+}
+
+func sortedKeys(items ...map[string]string) []string {
+	set := map[string]bool{}
+	for _, item := range items {
+		for k := range item {
+			set[k] = true
+		}
+	}
+	var keys []string
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// configEntryName returns the human readable name of the config map key, e.g. 'trigger on-deployed'
+func configEntryName(key string) string {
+	for _, prefix := range []string{"trigger.", "template.", "service."} {
+		if strings.HasPrefix(key, prefix) {
+			return strings.TrimSuffix(prefix, ".") + " " + strings.TrimPrefix(key, prefix)
+		}
+	}
+	return key
+}
+
+// diffConfigData returns the changes between the cluster and local config map data
+func diffConfigData(cluster map[string]string, local map[string]string) []string {
+	var changes []string
+	for _, key := range sortedKeys(cluster, local) {
+		clusterVal, inCluster := cluster[key]
+		localVal, inLocal := local[key]
+		name := configEntryName(key)
+		switch {
+		case !inCluster:
+			changes = append(changes, fmt.Sprintf("%s: added", name))
+		case !inLocal:
+			changes = append(changes, fmt.Sprintf("%s: removed", name))
+		default:
+			clusterFields := flattenConfigValue(clusterVal)
+			localFields := flattenConfigValue(localVal)
+			var fieldChanges []string
+			for _, field := range sortedKeys(clusterFields, localFields) {
+				before, inBefore := clusterFields[field]
+				after, inAfter := localFields[field]
+				label := field
+				if label == "" {
+					label = "value"
+				}
+				switch {
+				case !inBefore:
+					fieldChanges = append(fieldChanges, fmt.Sprintf("  %s: added %s", label, after))
+				case !inAfter:
+					fieldChanges = append(fieldChanges, fmt.Sprintf("  %s: removed %s", label, before))
+				case before != after:
+					fieldChanges = append(fieldChanges, fmt.Sprintf("  %s: %s -> %s", label, before, after))
+				}
+			}
+			if len(fieldChanges) > 0 {
+				changes = append(changes, fmt.Sprintf("%s: modified\n%s", name, strings.Join(fieldChanges, "\n")))
+			}
+		}
+	}
+	return changes
+}
+
+// secretData returns the secret data including the 'stringData' field that is usually used in local files
+func secretData(secret *v1.Secret) map[string]string {
+	res := map[string]string{}
+	for k, v := range secret.Data {
+		res[k] = string(v)
+	}
+	for k, v := range secret.StringData {
+		res[k] = v
+	}
+	return res
+}
+
+// diffSecretData returns the changed secret keys without values
+func diffSecretData(clusterKeys map[string]string, localKeys map[string]string) []string {
+	var changes []string
+	for _, key := range sortedKeys(clusterKeys, localKeys) {
+		before, inCluster := clusterKeys[key]
+		after, inLocal := localKeys[key]
+		switch {
+		case !inCluster:
+			changes = append(changes, fmt.Sprintf("secret %s: added", key))
+		case !inLocal:
+			changes = append(changes, fmt.Sprintf("secret %s: removed", key))
+		case before != after:
+			changes = append(changes, fmt.Sprintf("secret %s: value changed", key))
+		}
+	}
+	return changes
+}
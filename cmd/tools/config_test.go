@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+)
+
+func TestConfigDiff(t *testing.T) {
+	localData := map[string]string{
+		"trigger.on-deployed": `[{when: "app.status.health.status == 'Healthy'", send: [app-deployed]}]`,
+		"template.app-deployed": `
+message: deployed`,
+		"service.slack": `{token: $slack-token}`,
+	}
+	clusterConfigMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: k8s.ConfigMapName, Namespace: "default"},
+		Data: map[string]string{
+			"trigger.on-deployed": `
+- when: app.status.sync.status == 'Synced'
+  send: [app-deployed]`,
+			"template.app-deployed": `{message: deployed}`,
+			"template.app-created":  `{message: created}`,
+		},
+	}
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, localData)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+	ctx.getK8SClients = func() (kubernetes.Interface, dynamic.Interface, string, error) {
+		return fake.NewSimpleClientset(clusterConfigMap), dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), "default", nil
+	}
+
+	command := newConfigDiffCommand(ctx)
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `service slack: added
+template app-created: removed
+trigger on-deployed: modified
+  [0].when: "app.status.sync.status == 'Synced'" -> "app.status.health.status == 'Healthy'"
+`, stdout.String())
+}
+
+func TestDiffSecretData(t *testing.T) {
+	changes := diffSecretData(
+		map[string]string{"slack-token": "abc", "email-password": "123"},
+		map[string]string{"slack-token": "def", "webhook-token": "456"})
+	assert.Equal(t, []string{"secret email-password: removed", "secret slack-token: value changed", "secret webhook-token: added"}, changes)
+}
+
+func TestConfigMigrate(t *testing.T) {
+	cmData := map[string]string{
+		"config.yaml": `
+triggers:
+- name: my-trigger
+  condition: app.status.sync.status == 'Unknown'
+  template: my-template
+`,
+	}
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newConfigMigrateCommand(ctx)
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "# trigger 'my-trigger' is moved to 'trigger.my-trigger' key\n", stderr.String())
+	assert.Equal(t, `apiVersion: v1
+data:
+  trigger.my-trigger: |
+    - send:
+      - my-template
+      when: app.status.sync.status == 'Unknown'
+kind: ConfigMap
+metadata:
+  creationTimestamp: null
+  name: argocd-notifications-cm
+`, stdout.String())
+}
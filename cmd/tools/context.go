@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -13,6 +14,7 @@ import (
 	"github.com/argoproj/gitops-engine/pkg/utils/kube"
 	"github.com/ghodss/yaml"
 	v1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -22,6 +24,7 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/argoproj-labs/argocd-notifications/expr/shared"
+	"github.com/argoproj-labs/argocd-notifications/pkg"
 	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
 	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
 	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
@@ -40,6 +43,20 @@ type commandContext struct {
 	stderr        io.Writer
 	getK8SClients clientsSource
 	argocdService *lazyArgocdServiceInitializer
+	// appFile and commitMetadataFile allow rendering templates and running triggers without the cluster access
+	appFile            string
+	commitMetadataFile string
+}
+
+// commitMetadataService returns the commit metadata loaded from the file, so repo functions work without the Argo CD repo server
+type commitMetadataService struct {
+	argocd.Service
+	commitMetadata shared.CommitMetadata
+}
+
+func (svc *commitMetadataService) GetCommitMetadata(_ context.Context, _ string, _ string) (*shared.CommitMetadata, error) {
+	meta := svc.commitMetadata
+	return &meta, nil
 }
 
 type lazyArgocdServiceInitializer struct {
@@ -54,7 +71,7 @@ func (svc *lazyArgocdServiceInitializer) initArgoCDService() error {
 	if err != nil {
 		return err
 	}
-	argocdService, err := argocd.NewArgoCDService(k8sClient, ns, *svc.argocdRepoServer)
+	argocdService, err := argocd.NewArgoCDService(k8sClient, ns, argocd.RepoServerOptions{Address: *svc.argocdRepoServer, Plaintext: true})
 	if err != nil {
 		return err
 	}
@@ -110,7 +127,7 @@ func (c *commandContext) unmarshalFromFile(filePath string, name string, gk sche
 	if filePath == "-" {
 		data, err = ioutil.ReadAll(c.stdin)
 	} else {
-		data, err = ioutil.ReadFile(c.configMapPath)
+		data, err = ioutil.ReadFile(filePath)
 	}
 	if err != nil {
 		return err
@@ -129,53 +146,155 @@ func (c *commandContext) unmarshalFromFile(filePath string, name string, gk sche
 }
 
 func (c *commandContext) getConfig() (*settings.Config, error) {
+	configMap, secret, resolver, err := c.loadConfigSources()
+	if err != nil {
+		return nil, err
+	}
+	if configMap, err = settings.ResolveConfigRefs(context.Background(), configMap); err != nil {
+		return nil, err
+	}
+	argocdService, err := c.getArgoCDService()
+	if err != nil {
+		return nil, err
+	}
+	return settings.NewConfig(configMap, secret, resolver, argocdService, legacy.ApplyLegacyConfig)
+}
+
+func (c *commandContext) getArgoCDService() (argocd.Service, error) {
+	var argocdService argocd.Service = &lazyArgocdServiceInitializer{}
+	if c.argocdService != nil {
+		argocdService = c.argocdService
+	}
+	if c.commitMetadataFile == "" {
+		return argocdService, nil
+	}
+	data, err := ioutil.ReadFile(c.commitMetadataFile)
+	if err != nil {
+		return nil, err
+	}
+	svc := &commitMetadataService{Service: argocdService}
+	if err := yaml.Unmarshal(data, &svc.commitMetadata); err != nil {
+		return nil, fmt.Errorf("failed to parse commit metadata file '%s': %v", c.commitMetadataFile, err)
+	}
+	return svc, nil
+}
+
+// loadConfigSources returns the config map merged with config fragments and the secret from the files or the cluster
+func (c *commandContext) loadConfigSources() (*v1.ConfigMap, *v1.Secret, pkg.SecretResolver, error) {
 	var configMap v1.ConfigMap
 	if c.configMapPath == "" {
-		k8sClient, _, ns, err := c.getK8SClients()
+		k8sClient, dynamicClient, ns, err := c.getK8SClients()
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, err
 		}
 		cm, err := k8sClient.CoreV1().ConfigMaps(ns).Get(context.Background(), k8s.ConfigMapName, metav1.GetOptions{})
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, err
+		}
+		fragmentsList, err := k8sClient.CoreV1().ConfigMaps(ns).List(context.Background(), metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=true", k8s.ConfigFragmentLabel),
+		})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		var fragments []*v1.ConfigMap
+		for i := range fragmentsList.Items {
+			if settings.IsConfigFragment(&fragmentsList.Items[i]) {
+				fragments = append(fragments, &fragmentsList.Items[i])
+			}
 		}
-		configMap = *cm
+		for _, resource := range k8s.ConfigResources {
+			list, err := dynamicClient.Resource(resource).Namespace(ns).List(context.Background(), metav1.ListOptions{})
+			if apierr.IsNotFound(err) {
+				// CRDs are optional
+				continue
+			} else if err != nil {
+				return nil, nil, nil, err
+			}
+			for i := range list.Items {
+				fragment, err := settings.ResourceToConfigFragment(&list.Items[i])
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				fragments = append(fragments, fragment)
+			}
+		}
+		configMap = *settings.MergeConfigFragments(cm, fragments)
 	} else {
 		if err := c.unmarshalFromFile(c.configMapPath, k8s.ConfigMapName, schema.GroupKind{Kind: "ConfigMap"}, &configMap); err != nil {
-			return nil, err
+			return nil, nil, nil, err
 		}
 	}
 
 	var secret v1.Secret
+	var resolver pkg.SecretResolver
 	if c.secretPath == ":empty" {
 		secret = v1.Secret{}
 	} else if c.secretPath == "" {
 		k8sClient, _, ns, err := c.getK8SClients()
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, err
 		}
 		s, err := k8sClient.CoreV1().Secrets(ns).Get(context.Background(), k8s.SecretName, metav1.GetOptions{})
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, err
 		}
 		secret = *s
+		resolver = &lazySecretResolver{getK8SClients: c.getK8SClients}
 	} else {
 		if err := c.unmarshalFromFile(c.secretPath, k8s.SecretName, schema.GroupKind{Kind: "Secret"}, &secret); err != nil {
-			return nil, err
+			return nil, nil, nil, err
 		}
 	}
-	return settings.NewConfig(&configMap, &secret, &lazyArgocdServiceInitializer{}, legacy.ApplyLegacyConfig)
+	return &configMap, &secret, resolver, nil
+}
+
+// lazySecretResolver resolves references to the Secrets in the cluster; k8s client is created only if config has such references
+type lazySecretResolver struct {
+	getK8SClients clientsSource
+}
+
+func (r *lazySecretResolver) Resolve(source string, key string) (string, error) {
+	k8sClient, _, ns, err := r.getK8SClients()
+	if err != nil {
+		return "", err
+	}
+	return settings.NewSecretResolver(k8sClient, ns, nil).Resolve(source, key)
+}
+
+// loadApplicationArg loads the application from the --app-file flag file or using the APPLICATION argument
+func (c *commandContext) loadApplicationArg(args []string) (*unstructured.Unstructured, error) {
+	if c.appFile != "" {
+		if len(args) > 0 {
+			return nil, errors.New("APPLICATION argument and --app-file flag are mutually exclusive")
+		}
+		return c.loadApplicationFile(c.appFile)
+	}
+	if len(args) == 0 {
+		return nil, errors.New("APPLICATION argument or --app-file flag is required")
+	}
+	return c.loadApplication(args[0])
+}
+
+func (c *commandContext) loadApplicationFile(filePath string) (*unstructured.Unstructured, error) {
+	var err error
+	var data []byte
+	if filePath == "-" {
+		data, err = ioutil.ReadAll(c.stdin)
+	} else {
+		data, err = ioutil.ReadFile(filePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var app unstructured.Unstructured
+	err = yaml.Unmarshal(data, &app)
+	return &app, err
 }
 
 func (c *commandContext) loadApplication(application string) (*unstructured.Unstructured, error) {
 	if ext := filepath.Ext(application); ext != "" {
-		data, err := ioutil.ReadFile(application)
-		if err != nil {
-			return nil, err
-		}
-		var app unstructured.Unstructured
-		err = yaml.Unmarshal(data, &app)
-		return &app, err
+		return c.loadApplicationFile(application)
 	}
 	_, client, ns, err := c.getK8SClients()
 	if err != nil {
@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/misc"
+	"github.com/argoproj-labs/argocd-notifications/shared/deadletter"
+)
+
+func newDeadLetterCommand(cmdContext *commandContext) *cobra.Command {
+	var command = cobra.Command{
+		Use:   "deadletter",
+		Short: "Notifications that could not be delivered related commands",
+		RunE: func(c *cobra.Command, args []string) error {
+			return errors.New("select child command")
+		},
+	}
+	command.AddCommand(newDeadLetterListCommand(cmdContext))
+	command.AddCommand(newDeadLetterReplayCommand(cmdContext))
+
+	return &command
+}
+
+func (c *commandContext) getDeadLetterStore() (deadletter.Store, error) {
+	k8sClient, _, ns, err := c.getK8SClients()
+	if err != nil {
+		return nil, err
+	}
+	return deadletter.NewConfigMapStore(k8sClient, ns, 0), nil
+}
+
+func newDeadLetterListCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		output string
+	)
+	var command = cobra.Command{
+		Use: "list",
+		Example: `
+# prints all notifications that could not be delivered
+argocd-notifications deadletter list
+`,
+		Short: "Prints notifications that could not be delivered",
+		RunE: func(c *cobra.Command, args []string) error {
+			store, err := cmdContext.getDeadLetterStore()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to get dead letters: %v\n", err)
+				return nil
+			}
+			entries, err := store.List(context.Background())
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load dead letters: %v\n", err)
+				return nil
+			}
+			switch output {
+			case "", "wide":
+				w := tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
+				_, _ = fmt.Fprintf(w, "ID\tAPP\tTRIGGER\tDESTINATION\tFAILURES\tLAST FAILURE\tERROR\n")
+				for _, e := range entries {
+					_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s:%s\t%d\t%s\t%s\n",
+						e.ID, e.App, e.Trigger, e.Destination.Service, e.Destination.Recipient, e.Failures,
+						time.Unix(e.Timestamp, 0).UTC().Format(time.RFC3339), e.Error)
+				}
+				_ = w.Flush()
+			case "name":
+				for _, e := range entries {
+					_, _ = fmt.Fprintln(cmdContext.stdout, e.ID)
+				}
+			default:
+				return misc.PrintFormatted(entries, output, cmdContext.stdout)
+			}
+			return nil
+		},
+	}
+	addOutputFlags(&command, &output)
+	return &command
+}
+
+func newDeadLetterReplayCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		all bool
+	)
+	var command = cobra.Command{
+		Use: "replay [ID...]",
+		Example: `
+# re-send notification with the specified id
+argocd-notifications deadletter replay 3f2a8c1b9d0e
+
+# re-send all notifications that could not be delivered
+argocd-notifications deadletter replay --all
+`,
+		Short: "Re-sends notifications that could not be delivered and removes successfully sent notifications from the dead letters",
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) == 0 && !all {
+				return errors.New("at least one dead letter id or --all flag is expected")
+			}
+			store, err := cmdContext.getDeadLetterStore()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to get dead letters: %v\n", err)
+				return nil
+			}
+			entries, err := store.List(context.Background())
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load dead letters: %v\n", err)
+				return nil
+			}
+			ids := map[string]bool{}
+			for _, id := range args {
+				ids[id] = true
+			}
+			config, err := cmdContext.getConfig()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to parse config: %v\n", err)
+				return nil
+			}
+			for _, e := range entries {
+				if !all && !ids[e.ID] {
+					continue
+				}
+				delete(ids, e.ID)
+				if err := cmdContext.sendWithVars(config, e.App, e.Vars, e.Templates, e.Destination); err != nil {
+					_, _ = fmt.Fprintf(cmdContext.stderr, "failed to re-send notification %s: %v\n", e.ID, err)
+					continue
+				}
+				if err := store.Delete(context.Background(), e.ID); err != nil {
+					_, _ = fmt.Fprintf(cmdContext.stderr, "notification %s was sent but not removed from dead letters: %v\n", e.ID, err)
+					continue
+				}
+				_, _ = fmt.Fprintf(cmdContext.stdout, "notification %s was sent\n", e.ID)
+			}
+			for id := range ids {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "dead letter '%s' not found\n", id)
+			}
+			return nil
+		},
+	}
+	command.Flags().BoolVar(&all, "all", false, "Re-send all notifications")
+	return &command
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/shared/deadletter"
+)
+
+func TestDeadLetterList(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	dest := services.Destination{Service: "slack", Recipient: "my-channel"}
+	err := deadletter.NewConfigMapStore(clientset, "default", 10).Add(context.Background(), deadletter.Entry{
+		ID:          deadletter.EntryID("guestbook", "on-sync-failed", []string{"app-sync-failed"}, dest),
+		App:         "guestbook",
+		Trigger:     "on-sync-failed",
+		Templates:   []string{"app-sync-failed"},
+		Destination: dest,
+		Error:       "channel_not_found",
+		Failures:    1,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx := &commandContext{
+		stdout: &stdout,
+		stderr: &stderr,
+		stdin:  strings.NewReader(""),
+		getK8SClients: func() (kubernetes.Interface, dynamic.Interface, string, error) {
+			return clientset, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), "default", nil
+		},
+	}
+
+	command := newDeadLetterListCommand(ctx)
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "guestbook")
+	assert.Contains(t, stdout.String(), "slack:my-channel")
+	assert.Contains(t, stdout.String(), "channel_not_found")
+}
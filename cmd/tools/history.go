@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/misc"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+)
+
+const (
+	exportSourceState   = "state"
+	exportSourceHistory = "history"
+
+	// resultNotified is the result of the notification state entry which only says that the notification has been sent
+	resultNotified = "Notified"
+)
+
+// exportRecord is the notification state entry or the delivery history record
+type exportRecord struct {
+	Source    string   `json:"source"`
+	App       string   `json:"app"`
+	Trigger   string   `json:"trigger"`
+	Templates []string `json:"templates,omitempty"`
+	Service   string   `json:"service"`
+	Recipient string   `json:"recipient"`
+	OncePer   string   `json:"oncePer,omitempty"`
+	Result    string   `json:"result"`
+	Error     string   `json:"error,omitempty"`
+	Timestamp string   `json:"timestamp"`
+}
+
+type exportFilter struct {
+	apps     []string
+	triggers []string
+	since    time.Time
+	until    time.Time
+}
+
+func containsOrEmpty(items []string, item string) bool {
+	if len(items) == 0 {
+		return true
+	}
+	for i := range items {
+		if items[i] == item {
+			return true
+		}
+	}
+	return false
+}
+
+func (f exportFilter) matches(app string, trigger string, timestamp time.Time) bool {
+	if !containsOrEmpty(f.apps, app) || !containsOrEmpty(f.triggers, trigger) {
+		return false
+	}
+	if !f.since.IsZero() && timestamp.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && timestamp.After(f.until) {
+		return false
+	}
+	return true
+}
+
+// parseExportTime parses the RFC3339 timestamp or the duration relative to the current time, e.g. 24h
+func parseExportTime(val string, now time.Time) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' is neither RFC3339 timestamp nor duration", val)
+	}
+	return now.Add(-d), nil
+}
+
+func stateRecords(apps []unstructured.Unstructured, filter exportFilter) []exportRecord {
+	var records []exportRecord
+	for _, app := range apps {
+		state := triggers.NewState(app.GetAnnotations()[subscriptions.NotifiedAnnotationKey])
+		for key, notifiedAt := range state {
+			item, err := triggers.ParseStateItemKey(key)
+			if err != nil {
+				continue
+			}
+			timestamp := time.Unix(notifiedAt, 0).UTC()
+			if !filter.matches(app.GetName(), item.Trigger, timestamp) {
+				continue
+			}
+			records = append(records, exportRecord{
+				Source:    exportSourceState,
+				App:       app.GetName(),
+				Trigger:   item.Trigger,
+				Service:   item.Destination.Service,
+				Recipient: item.Destination.Recipient,
+				OncePer:   item.OncePer,
+				Result:    resultNotified,
+				Timestamp: timestamp.Format(time.RFC3339),
+			})
+		}
+	}
+	return records
+}
+
+func historyRecords(items []unstructured.Unstructured, filter exportFilter) []exportRecord {
+	var records []exportRecord
+	for _, item := range items {
+		record := exportRecord{Source: exportSourceHistory}
+		record.App, _, _ = unstructured.NestedString(item.Object, "spec", "app")
+		record.Trigger, _, _ = unstructured.NestedString(item.Object, "spec", "trigger")
+		record.Templates, _, _ = unstructured.NestedStringSlice(item.Object, "spec", "templates")
+		record.Service, _, _ = unstructured.NestedString(item.Object, "spec", "destination", "service")
+		record.Recipient, _, _ = unstructured.NestedString(item.Object, "spec", "destination", "recipient")
+		record.Result, _, _ = unstructured.NestedString(item.Object, "spec", "result")
+		record.Error, _, _ = unstructured.NestedString(item.Object, "spec", "error")
+		record.Timestamp, _, _ = unstructured.NestedString(item.Object, "spec", "timestamp")
+		timestamp, err := time.Parse(time.RFC3339, record.Timestamp)
+		if err != nil {
+			timestamp = item.GetCreationTimestamp().UTC()
+			record.Timestamp = timestamp.Format(time.RFC3339)
+		}
+		if filter.matches(record.App, record.Trigger, timestamp) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+func writeExportCSV(records []exportRecord, cmdContext *commandContext) error {
+	w := csv.NewWriter(cmdContext.stdout)
+	_ = w.Write([]string{"source", "app", "trigger", "templates", "service", "recipient", "oncePer", "result", "error", "timestamp"})
+	for _, r := range records {
+		_ = w.Write([]string{r.Source, r.App, r.Trigger, strings.Join(r.Templates, ","), r.Service, r.Recipient, r.OncePer, r.Result, r.Error, r.Timestamp})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func newHistoryCommand(cmdContext *commandContext) *cobra.Command {
+	var command = cobra.Command{
+		Use:   "history",
+		Short: "Notification delivery history related commands",
+		RunE: func(c *cobra.Command, args []string) error {
+			return errors.New("select child command")
+		},
+	}
+	command.AddCommand(newHistoryExportCommand(cmdContext))
+
+	return &command
+}
+
+func newHistoryExportCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		filter exportFilter
+		since  string
+		until  string
+		output string
+	)
+	var command = cobra.Command{
+		Use: "export",
+		Example: `
+# export notification state of all applications and recorded deliveries as CSV
+argocd-notifications history export > notifications.csv
+
+# export on-sync-failed notifications of the guestbook application sent during the last week as JSON
+argocd-notifications history export --app guestbook --trigger on-sync-failed --since 168h -o json
+
+# export notifications of the specified month
+argocd-notifications history export --since 2021-01-01T00:00:00Z --until 2021-02-01T00:00:00Z
+`,
+		Short: "Exports the notification state of applications and the notification history records as CSV or JSON",
+		RunE: func(c *cobra.Command, args []string) error {
+			now := time.Now()
+			var err error
+			if filter.since, err = parseExportTime(since, now); err != nil {
+				return fmt.Errorf("invalid --since value: %v", err)
+			}
+			if filter.until, err = parseExportTime(until, now); err != nil {
+				return fmt.Errorf("invalid --until value: %v", err)
+			}
+			_, client, ns, err := cmdContext.getK8SClients()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to create k8s client: %v\n", err)
+				return nil
+			}
+			apps, err := k8s.NewAppClient(client, ns).List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to list applications: %v\n", err)
+				return nil
+			}
+			records := stateRecords(apps.Items, filter)
+
+			// the history is available only if the NotificationHistory CRD is installed and the recorder is enabled
+			if list, err := k8s.NewNotificationHistoryClient(client, ns).List(context.Background(), metav1.ListOptions{}); err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "notification history is not available, exporting notification state only: %v\n", err)
+			} else {
+				records = append(records, historyRecords(list.Items, filter)...)
+			}
+			sort.Slice(records, func(i, j int) bool {
+				if records[i].Timestamp != records[j].Timestamp {
+					return records[i].Timestamp < records[j].Timestamp
+				}
+				if records[i].App != records[j].App {
+					return records[i].App < records[j].App
+				}
+				return records[i].Source < records[j].Source
+			})
+
+			switch output {
+			case "csv":
+				return writeExportCSV(records, cmdContext)
+			default:
+				if records == nil {
+					records = []exportRecord{}
+				}
+				return misc.PrintFormatted(records, output, cmdContext.stdout)
+			}
+		},
+	}
+	command.Flags().StringArrayVar(&filter.apps, "app", nil, "Export records of the specified application only")
+	command.Flags().StringArrayVar(&filter.triggers, "trigger", nil, "Export records of the specified trigger only")
+	command.Flags().StringVar(&since, "since", "", "Export records created after the RFC3339 timestamp or the duration ago, e.g. 24h")
+	command.Flags().StringVar(&until, "until", "", "Export records created before the RFC3339 timestamp or the duration ago, e.g. 1h")
+	command.Flags().StringVarP(&output, "output", "o", "csv", "Output format. One of:csv|json|yaml")
+	return &command
+}
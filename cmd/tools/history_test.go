@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	testingutil "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func newHistoryTestContext(t *testing.T, stdout *bytes.Buffer, stderr *bytes.Buffer) *commandContext {
+	app := testingutil.NewApp("guestbook", testingutil.WithAnnotations(map[string]string{
+		subscriptions.NotifiedAnnotationKey: `{"on-deployed:0:slack:dev":1609459200,"on-sync-failed:0:slack:ops":1612137600}`,
+	}))
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), app)
+	recorder := history.NewRecorder(dynamicClient, "default", time.Hour)
+	assert.NoError(t, recorder.Record(context.Background(), history.Record{
+		App: "guestbook", Trigger: "on-sync-failed", Templates: []string{"app-sync-failed"},
+		Destination: services.Destination{Service: "slack", Recipient: "ops"},
+		Error:       errors.New("channel not found"), Timestamp: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+	}))
+	return &commandContext{
+		stdout: stdout,
+		stderr: stderr,
+		stdin:  strings.NewReader(""),
+		getK8SClients: func() (kubernetes.Interface, dynamic.Interface, string, error) {
+			return fake.NewSimpleClientset(), dynamicClient, "default", nil
+		},
+	}
+}
+
+func TestHistoryExport_CSV(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx := newHistoryTestContext(t, &stdout, &stderr)
+
+	command := newHistoryExportCommand(ctx)
+	err := command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, `source,app,trigger,templates,service,recipient,oncePer,result,error,timestamp
+state,guestbook,on-deployed,,slack,dev,,Notified,,2021-01-01T00:00:00Z
+history,guestbook,on-sync-failed,app-sync-failed,slack,ops,,Failed,channel not found,2021-02-01T00:00:00Z
+state,guestbook,on-sync-failed,,slack,ops,,Notified,,2021-02-01T00:00:00Z
+`, stdout.String())
+}
+
+func TestHistoryExport_Filter(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx := newHistoryTestContext(t, &stdout, &stderr)
+
+	command := newHistoryExportCommand(ctx)
+	assert.NoError(t, command.Flags().Set("trigger", "on-sync-failed"))
+	assert.NoError(t, command.Flags().Set("since", "2021-01-15T00:00:00Z"))
+	assert.NoError(t, command.Flags().Set("output", "json"))
+	err := command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+
+	var records []exportRecord
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &records))
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, exportSourceHistory, records[0].Source)
+		assert.Equal(t, "channel not found", records[0].Error)
+		assert.Equal(t, exportSourceState, records[1].Source)
+	}
+}
+
+func TestParseExportTime(t *testing.T) {
+	now := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	ts, err := parseExportTime("24h", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 1, 31, 0, 0, 0, 0, time.UTC), ts)
+
+	ts, err = parseExportTime("2021-01-01T00:00:00Z", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), ts)
+
+	_, err = parseExportTime("yesterday", now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,377 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/expr"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/misc"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+)
+
+const (
+	colorReset = "\033[0m"
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+	// clearScreen moves the cursor to the top left corner and clears the terminal
+	clearScreen = "\033[H\033[2J"
+
+	defaultRefreshInterval = 5 * time.Second
+)
+
+const interactiveHelp = `Commands:
+  apps [FILTER]                  list applications
+  watch [FILTER]                 list applications with triggers that fire for them, refreshed until Enter is pressed
+  select NAME|NUMBER|FILE        select the application
+  triggers                       evaluate all triggers against the selected application
+  templates                      list templates
+  render TEMPLATE [RECIPIENT]    render the template for the selected application, e.g. 'render app-deployed slack:my-channel'
+  help                           print this help
+  quit                           exit
+`
+
+var yamlKeyPattern = regexp.MustCompile(`^(\s*(?:- )?)([^\s:][^:]*):(\s|$)`)
+
+// interactiveSession keeps the state of the interactive mode. The mode is a line based session rather than the full
+// screen terminal UI, so it works in any terminal and does not need the terminal library. The config and the selected
+// application are reloaded before every command, so changes of the config map or the application are visible
+// immediately
+type interactiveSession struct {
+	cmdContext      *commandContext
+	color           bool
+	refreshInterval time.Duration
+	apps            []string
+	selected        string
+}
+
+func newInteractiveCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		noColor         bool
+		refreshInterval time.Duration
+	)
+	var command = cobra.Command{
+		Use:   "interactive",
+		Short: "Starts the interactive session that evaluates triggers and renders templates against selected applications",
+		Example: `
+# Debug triggers and templates of the local config map against applications in the cluster
+argocd-notifications interactive --config-map ./argocd-notifications-cm.yaml
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			session := &interactiveSession{cmdContext: cmdContext, color: !noColor, refreshInterval: refreshInterval}
+			_, _ = fmt.Fprint(cmdContext.stdout, interactiveHelp)
+			return session.run()
+		},
+	}
+	command.Flags().BoolVar(&noColor, "no-color", false, "Disable colored output and screen clearing of the watch command")
+	command.Flags().DurationVar(&refreshInterval, "refresh-interval", defaultRefreshInterval, "Refresh interval of the watch command")
+	return &command
+}
+
+func (s *interactiveSession) colorize(text string, color string) string {
+	if !s.color {
+		return text
+	}
+	return color + text + colorReset
+}
+
+func (s *interactiveSession) printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(s.cmdContext.stdout, format, args...)
+}
+
+func (s *interactiveSession) run() error {
+	scanner := bufio.NewScanner(s.cmdContext.stdin)
+	for {
+		prompt := "> "
+		if s.selected != "" {
+			prompt = fmt.Sprintf("[%s]> ", s.selected)
+		}
+		s.printf("%s", prompt)
+		if !scanner.Scan() {
+			s.printf("\n")
+			return scanner.Err()
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		var err error
+		switch fields[0] {
+		case "apps":
+			filter := ""
+			if len(fields) > 1 {
+				filter = fields[1]
+			}
+			err = s.listApps(filter)
+		case "watch":
+			filter := ""
+			if len(fields) > 1 {
+				filter = fields[1]
+			}
+			err = s.watchApps(filter, scanner)
+		case "select":
+			if len(fields) < 2 {
+				err = fmt.Errorf("application is not specified")
+			} else {
+				err = s.selectApp(fields[1])
+			}
+		case "triggers":
+			err = s.evaluateTriggers()
+		case "templates":
+			err = s.listTemplates()
+		case "render":
+			if len(fields) < 2 {
+				err = fmt.Errorf("template is not specified")
+			} else {
+				recipient := "console:stdout"
+				if len(fields) > 2 {
+					recipient = fields[2]
+				}
+				err = s.render(fields[1], recipient)
+			}
+		case "help":
+			s.printf("%s", interactiveHelp)
+		case "quit", "exit":
+			return nil
+		default:
+			err = fmt.Errorf("unknown command '%s', type 'help' to list commands", fields[0])
+		}
+		if err != nil {
+			s.printf("%s\n", s.colorize(err.Error(), colorRed))
+		}
+	}
+}
+
+// loadApps returns applications which names contain the filter sorted by name and remembers their numbers for the
+// select command
+func (s *interactiveSession) loadApps(filter string) ([]unstructured.Unstructured, error) {
+	_, client, ns, err := s.cmdContext.getK8SClients()
+	if err != nil {
+		return nil, err
+	}
+	list, err := k8s.NewAppClient(client, ns).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].GetName() < list.Items[j].GetName()
+	})
+	var apps []unstructured.Unstructured
+	s.apps = nil
+	for i := range list.Items {
+		if strings.Contains(list.Items[i].GetName(), filter) {
+			apps = append(apps, list.Items[i])
+			s.apps = append(s.apps, list.Items[i].GetName())
+		}
+	}
+	return apps, nil
+}
+
+func (s *interactiveSession) listApps(filter string) error {
+	apps, err := s.loadApps(filter)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(s.cmdContext.stdout, 5, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "#\tNAME\tPROJECT\tSYNC\tHEALTH\n")
+	for i := range apps {
+		_, _ = fmt.Fprintf(w, "%d\t%s\n", i+1, formatAppStatus(apps[i]))
+	}
+	return w.Flush()
+}
+
+// formatAppStatus returns tab separated name, project, sync and health status of the application
+func formatAppStatus(app unstructured.Unstructured) string {
+	project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+	syncStatus, _, _ := unstructured.NestedString(app.Object, "status", "sync", "status")
+	health, _, _ := unstructured.NestedString(app.Object, "status", "health", "status")
+	return fmt.Sprintf("%s\t%s\t%s\t%s", app.GetName(), project, syncStatus, health)
+}
+
+// watchApps prints applications with the triggers that fire for them and reprints them every refresh interval until
+// the next line is read from the input
+func (s *interactiveSession) watchApps(filter string, scanner *bufio.Scanner) error {
+	if err := s.printTriggeredApps(filter); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		interval := s.refreshInterval
+		if interval <= 0 {
+			interval = defaultRefreshInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := s.printTriggeredApps(filter); err != nil {
+					s.printf("%s\n", s.colorize(err.Error(), colorRed))
+				}
+			}
+		}
+	}()
+	scanner.Scan()
+	close(done)
+	wg.Wait()
+	return scanner.Err()
+}
+
+func (s *interactiveSession) printTriggeredApps(filter string) error {
+	apps, err := s.loadApps(filter)
+	if err != nil {
+		return err
+	}
+	cfg, err := s.cmdContext.getConfig()
+	if err != nil {
+		return err
+	}
+	if s.color {
+		s.printf("%s", clearScreen)
+	}
+	s.printf("%s\n", time.Now().Format(time.RFC3339))
+	w := tabwriter.NewWriter(s.cmdContext.stdout, 5, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "#\tNAME\tPROJECT\tSYNC\tHEALTH\tTRIGGERED\n")
+	for i := range apps {
+		app := &apps[i]
+		var triggered []string
+		misc.IterateStringKeyMap(cfg.Triggers, func(name string) {
+			results, err := cfg.API.RunTrigger(name, expr.Spawn(app, cfg.ArgoCDService, map[string]interface{}{"app": app.Object}))
+			if err != nil {
+				triggered = append(triggered, s.colorize(name+" (error)", colorRed))
+				return
+			}
+			for _, res := range results {
+				if res.Triggered {
+					triggered = append(triggered, s.colorize(name, colorGreen))
+					return
+				}
+			}
+		})
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", i+1, formatAppStatus(*app), strings.Join(triggered, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	s.printf("Press Enter to stop\n")
+	return nil
+}
+
+func (s *interactiveSession) selectApp(name string) error {
+	if index, err := strconv.Atoi(name); err == nil {
+		if index < 1 || index > len(s.apps) {
+			return fmt.Errorf("application number %d is not listed, run 'apps' first", index)
+		}
+		name = s.apps[index-1]
+	}
+	if _, err := s.cmdContext.loadApplication(name); err != nil {
+		return err
+	}
+	s.selected = name
+	return nil
+}
+
+func (s *interactiveSession) loadSelectedApp() (*unstructured.Unstructured, error) {
+	if s.selected == "" {
+		return nil, fmt.Errorf("application is not selected, use 'select' command")
+	}
+	return s.cmdContext.loadApplication(s.selected)
+}
+
+func (s *interactiveSession) evaluateTriggers() error {
+	app, err := s.loadSelectedApp()
+	if err != nil {
+		return err
+	}
+	cfg, err := s.cmdContext.getConfig()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(s.cmdContext.stdout, 5, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "TRIGGER\tCONDITION\tRESULT\tTEMPLATES\n")
+	misc.IterateStringKeyMap(cfg.Triggers, func(name string) {
+		results, err := cfg.API.RunTrigger(name, expr.Spawn(app, cfg.ArgoCDService, map[string]interface{}{"app": app.Object}))
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "%s\t\t%s\t\n", name, s.colorize("error: "+err.Error(), colorRed))
+			return
+		}
+		for i, res := range results {
+			result := s.colorize("false", colorRed)
+			if res.Triggered {
+				result = s.colorize("true", colorGreen)
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, cfg.Triggers[name][i].When, result, strings.Join(res.Templates, ", "))
+		}
+	})
+	return w.Flush()
+}
+
+func (s *interactiveSession) listTemplates() error {
+	cfg, err := s.cmdContext.getConfig()
+	if err != nil {
+		return err
+	}
+	misc.IterateStringKeyMap(cfg.Templates, func(name string) {
+		s.printf("%s\n", name)
+	})
+	return nil
+}
+
+func (s *interactiveSession) render(template string, recipient string) error {
+	app, err := s.loadSelectedApp()
+	if err != nil {
+		return err
+	}
+	cfg, err := s.cmdContext.getConfig()
+	if err != nil {
+		return err
+	}
+	parts := strings.SplitN(recipient, ":", 2)
+	dest := services.Destination{Service: parts[0]}
+	if len(parts) > 1 {
+		dest.Recipient = parts[1]
+	}
+	vars := expr.Spawn(app, cfg.ArgoCDService, map[string]interface{}{"app": app.Object, "context": legacy.InjectLegacyVar(cfg.Context, dest.Service)})
+	notification, err := cfg.API.FormatNotification(vars, []string{template}, dest)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	s.printf("%s", s.highlightYAML(string(data)))
+	return nil
+}
+
+// highlightYAML colors keys of the YAML document
+func (s *interactiveSession) highlightYAML(data string) string {
+	if !s.color {
+		return data
+	}
+	lines := strings.Split(data, "\n")
+	for i, line := range lines {
+		lines[i] = yamlKeyPattern.ReplaceAllString(line, "${1}"+colorCyan+"${2}"+colorReset+":${3}")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	testingutil "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestInteractive(t *testing.T) {
+	cmData := map[string]string{
+		"trigger.on-healthy": `
+- when: app.status.health.status == 'Healthy'
+  send: [app-healthy]`,
+		"template.app-healthy": `
+message: '{{.app.metadata.name}} is healthy'`,
+	}
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData, testingutil.NewApp("guestbook", testingutil.WithHealthStatus("Healthy")))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+	ctx.stdin = strings.NewReader("apps\nselect 1\ntriggers\nrender app-healthy\nunknown\nquit\n")
+
+	command := newInteractiveCommand(ctx)
+	assert.NoError(t, command.Flags().Set("no-color", "true"))
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	out := stdout.String()
+	assert.Regexp(t, `1\s+guestbook`, out)
+	assert.Contains(t, out, "on-healthy  app.status.health.status == 'Healthy'  true    app-healthy")
+	assert.Contains(t, out, "message: guestbook is healthy")
+	assert.Contains(t, out, "unknown command 'unknown'")
+}
+
+func TestInteractive_Watch(t *testing.T) {
+	cmData := map[string]string{
+		"trigger.on-healthy": `
+- when: app.status.health.status == 'Healthy'
+  send: [app-healthy]`,
+		"trigger.on-degraded": `
+- when: app.status.health.status == 'Degraded'
+  send: [app-healthy]`,
+	}
+	var stdout bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &bytes.Buffer{}, cmData,
+		testingutil.NewApp("guestbook", testingutil.WithHealthStatus("Healthy")),
+		testingutil.NewApp("broken", testingutil.WithHealthStatus("Degraded")))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+	ctx.stdin = strings.NewReader("watch\n\nselect 2\nquit\n")
+
+	command := newInteractiveCommand(ctx)
+	assert.NoError(t, command.Flags().Set("no-color", "true"))
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	out := stdout.String()
+	assert.Regexp(t, `1\s+broken\s.*on-degraded\n`, out)
+	assert.Regexp(t, `2\s+guestbook\s.*on-healthy\n`, out)
+	assert.Contains(t, out, "Press Enter to stop")
+	assert.Contains(t, out, "[guestbook]> ")
+}
+
+func TestHighlightYAML(t *testing.T) {
+	s := &interactiveSession{color: true}
+	assert.Equal(t, "\033[36mmessage\033[0m: hello\n  - \033[36mtitle\033[0m: a:b", s.highlightYAML("message: hello\n  - title: a:b"))
+}
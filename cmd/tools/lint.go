@@ -0,0 +1,347 @@
+package tools
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/antonmedv/expr"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/templates"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+type lintProblem struct {
+	// key is the config map key that has the problem or empty if the problem is not specific to the key
+	key     string
+	message string
+}
+
+func newLintCommand(cmdContext *commandContext) *cobra.Command {
+	var command = cobra.Command{
+		Use: "lint",
+		Example: `
+# Validate the 'argocd-notifications-cm' ConfigMap and 'argocd-notifications-secret' Secret in the cluster
+argocd-notifications lint
+
+# Validate local files, e.g. in the CI pipeline
+argocd-notifications lint --config-map ./argocd-notifications-cm.yaml --secret ./argocd-notifications-secret.yaml
+`,
+		Short: "Validates triggers, templates, services and subscriptions and exits with non-zero code if the config has problems",
+		RunE: func(c *cobra.Command, args []string) error {
+			configMap, secret, resolver, err := cmdContext.loadConfigSources()
+			if err != nil {
+				return err
+			}
+			problems := lintConfig(configMap, secret, resolver)
+			if len(problems) == 0 {
+				_, _ = fmt.Fprintln(cmdContext.stdout, "No problems found")
+				return nil
+			}
+			source := k8s.ConfigMapName
+			lines := map[string]int{}
+			if cmdContext.configMapPath != "" && cmdContext.configMapPath != "-" {
+				source = cmdContext.configMapPath
+				if data, err := ioutil.ReadFile(cmdContext.configMapPath); err == nil {
+					lines = keyLines(string(data))
+				}
+			}
+			for _, problem := range problems {
+				location := source
+				if line, ok := lines[problem.key]; ok {
+					location = fmt.Sprintf("%s:%d", source, line)
+				}
+				if problem.key != "" {
+					location = fmt.Sprintf("%s: %s", location, problem.key)
+				}
+				_, _ = fmt.Fprintf(cmdContext.stdout, "%s: %s\n", location, problem.message)
+			}
+			return fmt.Errorf("found %d problems", len(problems))
+		},
+	}
+	return &command
+}
+
+// keyLines returns the line numbers of the config map data keys
+func keyLines(data string) map[string]int {
+	res := map[string]int{}
+	keyPattern := regexp.MustCompile(`^(\s+)([\w-_.]+):`)
+	inData := false
+	keyIndent := ""
+	for i, line := range strings.Split(data, "\n") {
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			inData = strings.HasPrefix(line, "data:")
+			keyIndent = ""
+			continue
+		}
+		match := keyPattern.FindStringSubmatch(line)
+		if !inData || match == nil {
+			continue
+		}
+		// keys have the indentation of the first key, deeper lines belong to values
+		if keyIndent == "" {
+			keyIndent = match[1]
+		}
+		if _, ok := res[match[2]]; !ok && match[1] == keyIndent {
+			res[match[2]] = i + 1
+		}
+	}
+	return res
+}
+
+type configLinter struct {
+	configMap *v1.ConfigMap
+	secret    *v1.Secret
+	resolver  pkg.SecretResolver
+	triggers  map[string]bool
+	templates map[string]bool
+	services  map[string]bool
+	groups    settings.DestinationGroups
+	routes    settings.ClusterRoutes
+	problems  []lintProblem
+}
+
+func (l *configLinter) addProblem(key string, format string, args ...interface{}) {
+	l.problems = append(l.problems, lintProblem{key: key, message: fmt.Sprintf(format, args...)})
+}
+
+// lintConfig validates every config map key separately, so all problems are reported at once, and then checks references
+// between triggers, templates, services and subscriptions
+func lintConfig(configMap *v1.ConfigMap, secret *v1.Secret, resolver pkg.SecretResolver) []lintProblem {
+	l := &configLinter{
+		configMap: configMap, secret: secret, resolver: resolver,
+		triggers: map[string]bool{}, templates: map[string]bool{}, services: map[string]bool{},
+	}
+	var keys []string
+	for k := range configMap.Data {
+		keys = append(keys, k)
+		if parts := strings.Split(k, "."); len(parts) > 1 {
+			switch parts[0] {
+			case "trigger":
+				l.triggers[strings.Join(parts[1:], ".")] = true
+			case "template":
+				l.templates[strings.Join(parts[1:], ".")] = true
+			case "service":
+				l.services[parts[len(parts)-1]] = true
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	// names defined using the legacy config are added if the whole config is valid
+	cfg, cfgErr := settings.NewConfig(configMap, secret, resolver, nil, legacy.ApplyLegacyConfig)
+	if cfgErr == nil {
+		for name := range cfg.Triggers {
+			l.triggers[name] = true
+		}
+		for name := range cfg.Templates {
+			l.templates[name] = true
+		}
+		for name := range cfg.Services {
+			l.services[name] = true
+		}
+	}
+	if data, ok := configMap.Data["destinationGroups"]; ok {
+		_ = yaml.Unmarshal([]byte(data), &l.groups)
+	}
+	if data, ok := configMap.Data["clusterRoutes"]; ok {
+		_ = yaml.Unmarshal([]byte(data), &l.routes)
+	}
+
+	for _, k := range keys {
+		v := configMap.Data[k]
+		switch {
+		case strings.HasPrefix(k, "template."):
+			l.lintTemplate(k, v)
+		case strings.HasPrefix(k, "trigger."):
+			l.lintTrigger(k, v)
+		case strings.HasPrefix(k, "service."):
+			l.lintService(k, v)
+		case k == "subscriptions" || k == "defaultSubscriptions":
+			l.lintSubscriptions(k, v)
+		case k == "defaultTriggers":
+			l.lintDefaultTriggers(k, v)
+		case k == "destinationGroups":
+			l.lintDestinationGroups(k, v)
+		case k == "clusterRoutes":
+			l.lintClusterRoutes(k, v)
+		}
+	}
+
+	if cfgErr != nil && len(l.problems) == 0 {
+		l.addProblem("", "%v", cfgErr)
+	}
+	return l.problems
+}
+
+func (l *configLinter) lintTemplate(key string, val string) {
+	name := strings.TrimPrefix(key, "template.")
+	var notification services.Notification
+	if err := yaml.Unmarshal([]byte(val), &notification); err != nil {
+		l.addProblem(key, "invalid YAML: %v", err)
+		return
+	}
+	if _, err := templates.NewService(map[string]services.Notification{name: notification}); err != nil {
+		l.addProblem(key, "%v", err)
+	}
+}
+
+func (l *configLinter) lintTrigger(key string, val string) {
+	var conditions []triggers.Condition
+	if err := yaml.Unmarshal([]byte(val), &conditions); err != nil {
+		l.addProblem(key, "invalid YAML: %v", err)
+		return
+	}
+	if len(conditions) == 0 {
+		l.addProblem(key, "trigger has no conditions")
+	}
+	for i, condition := range conditions {
+		if condition.When == "" {
+			l.addProblem(key, "condition %d: 'when' expression is empty", i)
+		} else if _, err := expr.Compile(condition.When); err != nil {
+			l.addProblem(key, "condition %d: invalid 'when' expression: %v", i, err)
+		}
+		if len(condition.Send) == 0 {
+			l.addProblem(key, "condition %d: no templates to send", i)
+		}
+		for _, template := range condition.Send {
+			if !l.templates[template] {
+				l.addProblem(key, "condition %d: template '%s' is not configured", i, template)
+			}
+		}
+	}
+}
+
+func (l *configLinter) lintService(key string, val string) {
+	data := map[string]string{key: val}
+	for _, k := range pkg.GlobalServiceKeys() {
+		if v, ok := l.configMap.Data[k]; ok {
+			data[k] = v
+		}
+	}
+	for _, match := range pkg.KeyPattern.FindAllStringSubmatch(val, -1) {
+		if _, ok := l.secret.Data[match[1]]; ok {
+			continue
+		}
+		if match[2] == "" {
+			l.addProblem(key, "secret key '%s' is not found", match[1])
+		} else if l.resolver != nil {
+			if _, err := l.resolver.Resolve(match[1], match[2][1:]); err != nil {
+				l.addProblem(key, "cannot resolve '%s': %v", match[0], err)
+			}
+		}
+	}
+	cfg, err := pkg.ParseConfig(&v1.ConfigMap{Data: data}, l.secret, l.resolver)
+	if err != nil {
+		l.addProblem(key, "%v", err)
+		return
+	}
+	for _, factory := range cfg.Services {
+		if _, err := factory(); err != nil {
+			l.addProblem(key, "%v", err)
+		}
+	}
+}
+
+func (l *configLinter) lintRecipient(key string, recipient string) {
+	parts := strings.SplitN(recipient, ":", 2)
+	switch {
+	case parts[0] == settings.GroupService && len(parts) == 2:
+		if _, ok := l.groups[parts[1]]; !ok {
+			l.addProblem(key, "destination group '%s' is not configured", parts[1])
+		}
+	case parts[0] == settings.ClusterRouteService && len(parts) == 2:
+		if _, ok := l.routes[parts[1]]; !ok {
+			l.addProblem(key, "cluster route '%s' is not configured", parts[1])
+		}
+	case !l.services[parts[0]]:
+		l.addProblem(key, "recipient '%s' references service '%s' that is not configured", recipient, parts[0])
+	}
+}
+
+func (l *configLinter) lintSubscriptions(key string, val string) {
+	var subscriptions settings.DefaultSubscriptions
+	if err := yaml.Unmarshal([]byte(val), &subscriptions); err != nil {
+		l.addProblem(key, "%v", err)
+		return
+	}
+	for i, subscription := range subscriptions {
+		for _, trigger := range subscription.Triggers {
+			if !l.triggers[trigger] {
+				l.addProblem(key, "subscription %d: trigger '%s' is not configured", i, trigger)
+			}
+		}
+		for _, recipient := range subscription.Recipients {
+			l.lintRecipient(key, recipient)
+		}
+	}
+}
+
+func (l *configLinter) lintDefaultTriggers(key string, val string) {
+	var defaultTriggers []string
+	if err := yaml.Unmarshal([]byte(val), &defaultTriggers); err != nil {
+		l.addProblem(key, "%v", err)
+		return
+	}
+	for _, trigger := range defaultTriggers {
+		if !l.triggers[trigger] {
+			l.addProblem(key, "trigger '%s' is not configured", trigger)
+		}
+	}
+}
+
+func (l *configLinter) lintDestinationGroups(key string, val string) {
+	var groups settings.DestinationGroups
+	if err := yaml.Unmarshal([]byte(val), &groups); err != nil {
+		l.addProblem(key, "%v", err)
+		return
+	}
+	if err := groups.Validate(); err != nil {
+		l.addProblem(key, "%v", err)
+		return
+	}
+	var names []string
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, dest := range groups[name] {
+			l.lintRecipient(key, dest)
+		}
+	}
+}
+
+func (l *configLinter) lintClusterRoutes(key string, val string) {
+	var routes settings.ClusterRoutes
+	if err := yaml.Unmarshal([]byte(val), &routes); err != nil {
+		l.addProblem(key, "%v", err)
+		return
+	}
+	if err := routes.Validate(); err != nil {
+		l.addProblem(key, "%v", err)
+		return
+	}
+	var names []string
+	for name := range routes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, rule := range routes[name] {
+			for _, dest := range rule.Destinations {
+				l.lintRecipient(key, dest)
+			}
+		}
+	}
+}
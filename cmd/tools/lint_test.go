@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint_NoProblems(t *testing.T) {
+	cmData := map[string]string{
+		"trigger.my-trigger": `
+- when: app.metadata.name == 'guestbook'
+  send: [my-template]`,
+		"template.my-template": `
+message: hello {{.app.metadata.name}}`,
+		"defaultTriggers": `[my-trigger]`,
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newLintCommand(ctx)
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "No problems found")
+}
+
+func TestLint_ReportsAllProblems(t *testing.T) {
+	cmData := map[string]string{
+		"trigger.my-trigger": `
+- when: app.metadata.name ==
+  send: [my-template, missing-template]`,
+		"template.my-template": `
+message: hello {{.app.metadata.name | unknownFunc}}`,
+		"service.slack":   `token: $slack-token`,
+		"service.unknown": `{}`,
+		"subscriptions": `
+- recipients: [email:team@example.com]
+  triggers: [on-missing]`,
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newLintCommand(ctx)
+	err = command.RunE(command, nil)
+	assert.EqualError(t, err, "found 7 problems")
+
+	out := stdout.String()
+	assert.Contains(t, out, "service.slack: secret key 'slack-token' is not found")
+	assert.Contains(t, out, "service.unknown: service type 'unknown' is not supported")
+	assert.Contains(t, out, "subscriptions: subscription 0: trigger 'on-missing' is not configured")
+	assert.Contains(t, out, "subscriptions: recipient 'email:team@example.com' references service 'email' that is not configured")
+	assert.Contains(t, out, `template.my-template: template: my-template:1: function "unknownFunc" not defined`)
+	assert.Contains(t, out, "trigger.my-trigger: condition 0: invalid 'when' expression")
+	assert.Contains(t, out, "trigger.my-trigger: condition 0: template 'missing-template' is not configured")
+	assert.Regexp(t, `-cm\.yaml:\d+: trigger\.my-trigger: `, out)
+}
+
+func TestKeyLines(t *testing.T) {
+	lines := keyLines(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: argocd-notifications-cm
+data:
+  trigger.on-sync-failed: |
+    - when: app.status.operationState.phase in ['Error', 'Failed']
+      send: [app-sync-failed]
+  template.app-sync-failed: |
+    message: failed
+`)
+
+	assert.Equal(t, map[string]int{"trigger.on-sync-failed": 6, "template.app-sync-failed": 9}, lines)
+}
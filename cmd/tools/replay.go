@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/argoproj-labs/argocd-notifications/expr"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+func newReplayCommand(cmdContext *commandContext) *cobra.Command {
+	var command = cobra.Command{
+		Use:   "replay",
+		Short: "Commands that re-send notifications after an outage of the notification service",
+		RunE: func(c *cobra.Command, args []string) error {
+			return errors.New("select child command")
+		},
+	}
+	command.AddCommand(newReplayAppCommand(cmdContext))
+	command.AddCommand(newReplayHistoryCommand(cmdContext))
+
+	return &command
+}
+
+// send renders templates for the specified application and sends them to the destination
+func (c *commandContext) send(config *settings.Config, appName string, templates []string, dest services.Destination) error {
+	return c.sendWithVars(config, appName, nil, templates, dest)
+}
+
+// sendWithVars sends the notification about the application with additional template variables
+func (c *commandContext) sendWithVars(config *settings.Config, appName string, extraVars map[string]interface{}, templates []string, dest services.Destination) error {
+	app, err := c.loadApplication(appName)
+	if err != nil {
+		return fmt.Errorf("failed to load application %s: %v", appName, err)
+	}
+	vars := map[string]interface{}{}
+	for k, v := range extraVars {
+		vars[k] = v
+	}
+	vars["app"] = app.Object
+	vars["context"] = legacy.InjectLegacyVar(config.Context, dest.Service)
+	return config.API.Send(expr.Spawn(app, config.ArgoCDService, vars), templates, dest)
+}
+
+func matchesReplayFilter(item *triggers.StateItem, triggerNames []string, recipients []string) bool {
+	if len(triggerNames) > 0 {
+		found := false
+		for _, name := range triggerNames {
+			if item.Trigger == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(recipients) > 0 {
+		found := false
+		for _, recipient := range recipients {
+			if fmt.Sprintf("%s:%s", item.Destination.Service, item.Destination.Recipient) == recipient {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func newReplayAppCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		triggerNames []string
+		recipients   []string
+	)
+	var command = cobra.Command{
+		Use: "app APPLICATION",
+		Example: `
+# forget all sent notifications of the application so the controller re-evaluates triggers and sends them again
+argocd-notifications replay app guestbook
+
+# re-send only on-sync-succeeded notifications to the specified slack channel
+argocd-notifications replay app guestbook --trigger on-sync-succeeded --recipient slack:my-channel
+`,
+		Short: "Resets the notifications state of the application so the controller re-sends notifications of the triggers that are still firing",
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected one argument, got %d", len(args))
+			}
+			_, client, ns, err := cmdContext.getK8SClients()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to create k8s client: %v\n", err)
+				return nil
+			}
+			appClient := k8s.NewAppClient(client, ns)
+			app, err := appClient.Get(context.Background(), args[0], metav1.GetOptions{})
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to get application: %v\n", err)
+				return nil
+			}
+			state := triggers.NewState(app.GetAnnotations()[subscriptions.NotifiedAnnotationKey])
+			removed := 0
+			for key := range state {
+				item, err := triggers.ParseStateItemKey(key)
+				if err != nil {
+					_, _ = fmt.Fprintf(cmdContext.stderr, "skipping state item: %v\n", err)
+					continue
+				}
+				if matchesReplayFilter(item, triggerNames, recipients) {
+					delete(state, key)
+					removed++
+				}
+			}
+			if removed == 0 {
+				_, _ = fmt.Fprintf(cmdContext.stdout, "no notifications of application %s match the filter\n", app.GetName())
+				return nil
+			}
+			patch, err := replayStatePatch(state)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to update notifications state: %v\n", err)
+				return nil
+			}
+			if _, err := appClient.Patch(context.Background(), app.GetName(), types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to update notifications state: %v\n", err)
+				return nil
+			}
+			_, _ = fmt.Fprintf(cmdContext.stdout, "%d notification(s) of application %s will be re-sent by the controller\n", removed, app.GetName())
+			return nil
+		},
+	}
+	command.Flags().StringArrayVar(&triggerNames, "trigger", nil, "Re-send notifications of the specified trigger only")
+	command.Flags().StringArrayVar(&recipients, "recipient", nil, "Re-send notifications to the specified recipient only, e.g. slack:my-channel")
+	return &command
+}
+
+func replayStatePatch(state triggers.State) ([]byte, error) {
+	var annotation interface{}
+	if len(state) > 0 {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return nil, err
+		}
+		annotation = string(data)
+	}
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				subscriptions.NotifiedAnnotationKey: annotation,
+			},
+		},
+	})
+}
+
+func newReplayHistoryCommand(cmdContext *commandContext) *cobra.Command {
+	var command = cobra.Command{
+		Use: "history NAME...",
+		Example: `
+# re-send notification recorded in the specified notification history resource
+argocd-notifications replay history guestbook-x7k2p
+`,
+		Short: "Re-sends notifications recorded in the notification history",
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("at least one notification history name is expected")
+			}
+			_, client, ns, err := cmdContext.getK8SClients()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to create k8s client: %v\n", err)
+				return nil
+			}
+			config, err := cmdContext.getConfig()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to parse config: %v\n", err)
+				return nil
+			}
+			historyClient := k8s.NewNotificationHistoryClient(client, ns)
+			for _, name := range args {
+				obj, err := historyClient.Get(context.Background(), name, metav1.GetOptions{})
+				if err != nil {
+					_, _ = fmt.Fprintf(cmdContext.stderr, "failed to get notification history %s: %v\n", name, err)
+					continue
+				}
+				appName, _, _ := unstructured.NestedString(obj.Object, "spec", "app")
+				templates, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "templates")
+				service, _, _ := unstructured.NestedString(obj.Object, "spec", "destination", "service")
+				recipient, _, _ := unstructured.NestedString(obj.Object, "spec", "destination", "recipient")
+				if err := cmdContext.send(config, appName, templates, services.Destination{Service: service, Recipient: recipient}); err != nil {
+					_, _ = fmt.Fprintf(cmdContext.stderr, "failed to re-send notification %s: %v\n", name, err)
+					continue
+				}
+				_, _ = fmt.Fprintf(cmdContext.stdout, "notification %s was sent\n", name)
+			}
+			return nil
+		},
+	}
+	return &command
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	testingutil "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestReplayApp(t *testing.T) {
+	app := testingutil.NewApp("guestbook", testingutil.WithAnnotations(map[string]string{
+		subscriptions.NotifiedAnnotationKey: `{"on-sync-succeeded:0:slack:my-channel":1,"on-sync-failed:0:slack:my-channel":1}`,
+	}))
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), app)
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx := &commandContext{
+		stdout: &stdout,
+		stderr: &stderr,
+		stdin:  strings.NewReader(""),
+		getK8SClients: func() (kubernetes.Interface, dynamic.Interface, string, error) {
+			return fake.NewSimpleClientset(), dynamicClient, "default", nil
+		},
+	}
+
+	command := newReplayAppCommand(ctx)
+	assert.NoError(t, command.Flags().Set("trigger", "on-sync-succeeded"))
+	err := command.RunE(command, []string{"guestbook"})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "1 notification(s) of application guestbook will be re-sent")
+
+	updated, err := k8s.NewAppClient(dynamicClient, "default").Get(context.Background(), "guestbook", metav1.GetOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	state := triggers.NewState(updated.GetAnnotations()[subscriptions.NotifiedAnnotationKey])
+	assert.Equal(t, triggers.State{"on-sync-failed:0:slack:my-channel": 1}, state)
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+)
+
+const testMessage = "This is a test notification sent by Argo CD Notifications at %s"
+
+func newServiceCommand(cmdContext *commandContext) *cobra.Command {
+	var command = cobra.Command{
+		Use:   "service",
+		Short: "Notification services related commands",
+		RunE: func(c *cobra.Command, args []string) error {
+			return errors.New("select child command")
+		},
+	}
+	command.AddCommand(newServiceTestCommand(cmdContext))
+
+	return &command
+}
+
+func newServiceTestCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		template string
+		app      string
+	)
+	var command = cobra.Command{
+		Use:   "test SERVICE RECIPIENT",
+		Short: "Sends the test notification using the live service configuration",
+		Example: `
+# Verify the Slack token after the rotation
+argocd-notifications service test slack my-channel
+
+# Send the notification rendered using the app-sync-succeeded template and guestbook application
+argocd-notifications service test slack my-channel --template app-sync-succeeded --app guestbook
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return fmt.Errorf("expected two arguments, got %d", len(args))
+			}
+			if template != "" && app == "" {
+				return errors.New("--app flag is required to render the template")
+			}
+			dest := services.Destination{Service: args[0], Recipient: args[1]}
+			config, err := cmdContext.getConfig()
+			if err != nil {
+				return fmt.Errorf("failed to parse config: %v", err)
+			}
+			service, ok := config.API.GetNotificationServices()[dest.Service]
+			if !ok {
+				return fmt.Errorf("service '%s' is not configured", dest.Service)
+			}
+			if template != "" {
+				err = cmdContext.send(config, app, []string{template}, dest)
+			} else {
+				message := fmt.Sprintf(testMessage, time.Now().UTC().Format(time.RFC3339))
+				err = service.Send(services.Notification{
+					Message: message,
+					Email:   &services.EmailNotification{Subject: "Argo CD Notifications test", Body: message},
+					Webhook: services.WebhookNotifications{dest.Service: {Method: http.MethodPost, Body: message}},
+				}, dest)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to send test notification to %s:%s: %v", dest.Service, dest.Recipient, err)
+			}
+			_, _ = fmt.Fprintf(cmdContext.stdout, "Test notification is sent to %s:%s\n", dest.Service, dest.Recipient)
+			return nil
+		},
+	}
+	command.Flags().StringVar(&template, "template", "", "Template used to render the notification instead of the canned test message")
+	command.Flags().StringVar(&app, "app", "", "Application name or path to the application manifest file used to render the template")
+	return &command
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	testingutil "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestServiceTest(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, r.Method+" "+string(data))
+	}))
+	defer server.Close()
+
+	cmData := map[string]string{
+		"service.webhook.my-hook": "url: " + server.URL,
+		"template.my-template": `
+webhook:
+  my-hook:
+    method: POST
+    body: hello {{.app.metadata.name}}`,
+	}
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData, testingutil.NewApp("guestbook"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	t.Run("CannedMessage", func(t *testing.T) {
+		bodies = nil
+		command := newServiceTestCommand(ctx)
+		err := command.RunE(command, []string{"my-hook", ""})
+		assert.NoError(t, err)
+		if assert.Len(t, bodies, 1) {
+			assert.Contains(t, bodies[0], "POST This is a test notification")
+		}
+	})
+
+	t.Run("Template", func(t *testing.T) {
+		bodies = nil
+		command := newServiceTestCommand(ctx)
+		assert.NoError(t, command.Flags().Set("template", "my-template"))
+		assert.NoError(t, command.Flags().Set("app", "guestbook"))
+		err := command.RunE(command, []string{"my-hook", ""})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"POST hello guestbook"}, bodies)
+	})
+
+	t.Run("UnknownService", func(t *testing.T) {
+		command := newServiceTestCommand(ctx)
+		err := command.RunE(command, []string{"slack", "my-channel"})
+		assert.EqualError(t, err, "service 'slack' is not configured")
+	})
+}
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/argoproj/gitops-engine/pkg/utils/kube"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/argoproj-labs/argocd-notifications/expr"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/misc"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// noSubscribers is the destination used to track the state of triggers without subscribers
+var noSubscribers = services.Destination{}
+
+func newSimulateCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		triggerNames []string
+	)
+	var command = cobra.Command{
+		Use:   "simulate FILE",
+		Short: "Replays recorded application snapshots through triggers and prints notifications that would have been sent",
+		Example: `
+# Record application changes and replay them
+kubectl get applications guestbook -n argocd -w -o yaml > guestbook-events.yaml
+argocd-notifications simulate ./guestbook-events.yaml
+
+# Replay snapshots from stdin using only the on-deployed trigger
+cat guestbook-events.yaml | argocd-notifications simulate - --trigger on-deployed
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected one argument, got %d", len(args))
+			}
+			var data []byte
+			var err error
+			if args[0] == "-" {
+				data, err = ioutil.ReadAll(cmdContext.stdin)
+			} else {
+				data, err = ioutil.ReadFile(args[0])
+			}
+			if err != nil {
+				return err
+			}
+			snapshots, err := splitSnapshots(data)
+			if err != nil {
+				return fmt.Errorf("failed to parse snapshots: %v", err)
+			}
+			cfg, err := cmdContext.getConfig()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to parse config: %v\n", err)
+				return nil
+			}
+			if len(triggerNames) == 0 {
+				misc.IterateStringKeyMap(cfg.Triggers, func(name string) {
+					triggerNames = append(triggerNames, name)
+				})
+			}
+			for _, name := range triggerNames {
+				if _, ok := cfg.Triggers[name]; !ok {
+					return fmt.Errorf("trigger with name '%s' does not exist", name)
+				}
+			}
+
+			sim := &simulation{cfg: cfg, triggers: triggerNames, states: map[string]triggers.State{}}
+			for i, app := range snapshots {
+				for _, line := range sim.next(app) {
+					_, _ = fmt.Fprintf(cmdContext.stdout, "#%d %s (resourceVersion %s): %s\n", i+1, app.GetName(), app.GetResourceVersion(), line)
+				}
+			}
+			_, _ = fmt.Fprintf(cmdContext.stdout, "%d snapshots replayed, %d notifications would have been sent\n", len(snapshots), sim.sent)
+			return nil
+		},
+	}
+	command.Flags().StringArrayVar(&triggerNames, "trigger", nil, "Replay only the specified triggers. All configured triggers are replayed by default")
+	return &command
+}
+
+// splitSnapshots returns applications of the multi document YAML. Lists are expanded, so output of 'kubectl get -o yaml'
+// is supported as well
+func splitSnapshots(data []byte) ([]*unstructured.Unstructured, error) {
+	objs, err := kube.SplitYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	var res []*unstructured.Unstructured
+	for _, obj := range objs {
+		if obj.IsList() {
+			if err := obj.EachListItem(func(item runtime.Object) error {
+				res = append(res, item.(*unstructured.Unstructured))
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		res = append(res, obj)
+	}
+	return res, nil
+}
+
+// simulation keeps the notification state of every application in memory the same way as the controller keeps it in annotations
+type simulation struct {
+	cfg      *settings.Config
+	triggers []string
+	states   map[string]triggers.State
+	// sent is the number of notifications that would have been sent
+	sent int
+}
+
+// next evaluates triggers for the application snapshot and returns descriptions of notifications that would have been sent
+func (s *simulation) next(app *unstructured.Unstructured) []string {
+	state, ok := s.states[app.GetName()]
+	if !ok {
+		state = triggers.State{}
+		s.states[app.GetName()] = state
+	}
+	destinations := map[string][]services.Destination{}
+	for _, sub := range getEffectiveSubscriptions(s.cfg, app, nil) {
+		parts := strings.SplitN(sub.Recipient, ":", 2)
+		destinations[sub.Trigger] = append(destinations[sub.Trigger], services.Destination{Service: parts[0], Recipient: parts[1]})
+	}
+	acks := triggers.NewAcknowledgments(app.GetAnnotations()[subscriptions.AcknowledgedAnnotationKey])
+
+	var res []string
+	for _, trigger := range s.triggers {
+		results, err := s.cfg.API.RunTrigger(trigger, expr.Spawn(app, s.cfg.ArgoCDService, map[string]interface{}{"app": app.Object}))
+		if err != nil {
+			res = append(res, fmt.Sprintf("%s: failed to evaluate trigger: %v", trigger, err))
+			continue
+		}
+		dests := destinations[trigger]
+		if len(dests) == 0 {
+			dests = []services.Destination{noSubscribers}
+		}
+		for _, cr := range results {
+			var notified []string
+			sent := 0
+			for _, dest := range dests {
+				if !state.SetAlreadyNotified(trigger, cr, dest, cr.Triggered) || !cr.Triggered {
+					continue
+				}
+				if dest == noSubscribers {
+					notified = append(notified, "no subscribers")
+				} else {
+					notified = append(notified, formatDestination(dest))
+					sent++
+				}
+			}
+			if len(notified) == 0 {
+				continue
+			}
+			line := fmt.Sprintf("%s sends %s to %s", trigger, strings.Join(cr.Templates, ", "), strings.Join(notified, ", "))
+			if acks.IsAcknowledged(trigger) {
+				line += " (suppressed, the trigger is acknowledged)"
+			} else {
+				s.sent += sent
+			}
+			res = append(res, line)
+		}
+	}
+	return res
+}
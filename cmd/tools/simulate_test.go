@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const guestbookSnapshots = `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+  resourceVersion: "1"
+  annotations:
+    notifications.argoproj.io/subscribe.on-deployed.slack: my-channel
+status:
+  operationState:
+    phase: Running
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+  resourceVersion: "2"
+  annotations:
+    notifications.argoproj.io/subscribe.on-deployed.slack: my-channel
+status:
+  operationState:
+    phase: Succeeded
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+  resourceVersion: "3"
+  annotations:
+    notifications.argoproj.io/subscribe.on-deployed.slack: my-channel
+status:
+  operationState:
+    phase: Succeeded
+`
+
+func TestSimulate(t *testing.T) {
+	cmData := map[string]string{
+		"trigger.on-deployed": `
+- when: app.status.operationState.phase == 'Succeeded'
+  send: [app-deployed]`,
+		"trigger.on-running": `
+- when: app.status.operationState.phase == 'Running'
+  send: [app-running]`,
+		"template.app-deployed": `{message: deployed}`,
+		"template.app-running":  `{message: running}`,
+	}
+	snapshotsFile := writeTempFile(t, "*-events.yaml", guestbookSnapshots)
+	defer func() {
+		_ = os.Remove(snapshotsFile)
+	}()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newSimulateCommand(ctx)
+	err = command.RunE(command, []string{snapshotsFile})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, `#1 guestbook (resourceVersion 1): on-running sends app-running to no subscribers
+#2 guestbook (resourceVersion 2): on-deployed sends app-deployed to slack:my-channel
+3 snapshots replayed, 1 notifications would have been sent
+`, stdout.String())
+}
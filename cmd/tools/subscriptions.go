@@ -0,0 +1,320 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/misc"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// effectiveSubscription is the destination notified about the application trigger
+type effectiveSubscription struct {
+	App       string `json:"app"`
+	Trigger   string `json:"trigger"`
+	Recipient string `json:"recipient"`
+	// Source is where the subscription is configured: the config map defaults, application or project annotations
+	Source string `json:"source"`
+}
+
+func newSubscriptionsCommand(cmdContext *commandContext) *cobra.Command {
+	var command = cobra.Command{
+		Use:   "subscriptions",
+		Short: "Subscriptions related commands",
+		RunE: func(c *cobra.Command, args []string) error {
+			return errors.New("select child command")
+		},
+	}
+	command.AddCommand(newSubscriptionsListCommand(cmdContext))
+	command.AddCommand(newSubscriptionsUpdateCommand(cmdContext, true))
+	command.AddCommand(newSubscriptionsUpdateCommand(cmdContext, false))
+
+	return &command
+}
+
+func newSubscriptionsListCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		app       string
+		recipient string
+		output    string
+	)
+	var command = cobra.Command{
+		Use:   "list",
+		Short: "Prints who gets notified about which triggers of every application",
+		Example: `
+# Print subscriptions of all applications
+argocd-notifications subscriptions list
+
+# Print subscriptions of the guestbook application
+argocd-notifications subscriptions list --app guestbook
+
+# Print applications and triggers that notify the Slack channel
+argocd-notifications subscriptions list --recipient slack:my-channel
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			cfg, err := cmdContext.getConfig()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to parse config: %v\n", err)
+				return nil
+			}
+			var apps []*unstructured.Unstructured
+			if app != "" {
+				item, err := cmdContext.loadApplication(app)
+				if err != nil {
+					_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load application: %v\n", err)
+					return nil
+				}
+				apps = append(apps, item)
+			} else {
+				_, client, ns, err := cmdContext.getK8SClients()
+				if err != nil {
+					return err
+				}
+				list, err := k8s.NewAppClient(client, ns).List(context.Background(), metav1.ListOptions{})
+				if err != nil {
+					_, _ = fmt.Fprintf(cmdContext.stderr, "failed to list applications: %v\n", err)
+					return nil
+				}
+				for i := range list.Items {
+					apps = append(apps, &list.Items[i])
+				}
+				sort.Slice(apps, func(i, j int) bool {
+					return apps[i].GetName() < apps[j].GetName()
+				})
+			}
+
+			projects := map[string]*unstructured.Unstructured{}
+			items := []effectiveSubscription{}
+			for _, item := range apps {
+				if !cfg.AppFilter.Matches(item) {
+					continue
+				}
+				projName, _, _ := unstructured.NestedString(item.Object, "spec", "project")
+				proj, ok := projects[projName]
+				if !ok {
+					proj = cmdContext.loadAppProject(item)
+					projects[projName] = proj
+				}
+				for _, sub := range getEffectiveSubscriptions(cfg, item, proj) {
+					if recipient == "" || sub.Recipient == recipient || strings.HasSuffix(sub.Recipient, ":"+recipient) {
+						items = append(items, sub)
+					}
+				}
+			}
+
+			switch output {
+			case "", "wide":
+				w := tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
+				_, _ = fmt.Fprintf(w, "APPLICATION\tTRIGGER\tRECIPIENT\tSOURCE\n")
+				for _, sub := range items {
+					_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", sub.App, sub.Trigger, sub.Recipient, sub.Source)
+				}
+				_ = w.Flush()
+			case "name":
+				for _, sub := range items {
+					_, _ = fmt.Fprintln(cmdContext.stdout, sub.App)
+				}
+			default:
+				return misc.PrintFormatted(items, output, cmdContext.stdout)
+			}
+			return nil
+		},
+	}
+	command.Flags().StringVar(&app, "app", "", "Application name or path to the application manifest file")
+	command.Flags().StringVar(&recipient, "recipient", "", "Print only subscriptions of the recipient, e.g. 'slack:my-channel' or 'my-channel'")
+	addOutputFlags(&command, &output)
+	return &command
+}
+
+// getEffectiveSubscriptions resolves default, application and project subscriptions the same way as the controller and
+// reports the first source that subscribes each destination
+func getEffectiveSubscriptions(cfg *settings.Config, app *unstructured.Unstructured, proj *unstructured.Unstructured) []effectiveSubscription {
+	annotations := subscriptions.Annotations(app.GetAnnotations())
+	seen := map[string]bool{}
+	var res []effectiveSubscription
+	for _, source := range cfg.CollectSubscriptions(app, settings.SubscriptionSources{Project: proj}) {
+		subs := annotations.RemoveUnsubscribed(source.Subscriptions)
+		misc.IterateStringKeyMap(subs, func(trigger string) {
+			for _, dest := range subs[trigger] {
+				key := trigger + "/" + dest.Service + ":" + dest.Recipient
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				res = append(res, effectiveSubscription{App: app.GetName(), Trigger: trigger, Recipient: formatDestination(dest), Source: source.Source})
+			}
+		})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Trigger != res[j].Trigger {
+			return res[i].Trigger < res[j].Trigger
+		}
+		return res[i].Recipient < res[j].Recipient
+	})
+	return res
+}
+
+func formatDestination(dest services.Destination) string {
+	return dest.Service + ":" + dest.Recipient
+}
+
+type subscriptionsSelector struct {
+	apps          []string
+	projects      []string
+	labelSelector string
+	all           bool
+}
+
+func (s subscriptionsSelector) empty() bool {
+	return len(s.apps) == 0 && len(s.projects) == 0 && s.labelSelector == ""
+}
+
+func (s subscriptionsSelector) matches(app unstructured.Unstructured) bool {
+	if !containsOrEmpty(s.apps, app.GetName()) {
+		return false
+	}
+	projName, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+	return containsOrEmpty(s.projects, projName)
+}
+
+func parseDestinations(values []string) ([]services.Destination, error) {
+	var res []services.Destination
+	for _, val := range values {
+		parts := strings.SplitN(val, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("destination '%s' must have the service:recipient format", val)
+		}
+		res = append(res, services.Destination{Service: parts[0], Recipient: parts[1]})
+	}
+	return res, nil
+}
+
+// newSubscriptionsUpdateCommand returns the command that adds or removes subscriptions of all matching applications
+func newSubscriptionsUpdateCommand(cmdContext *commandContext, subscribe bool) *cobra.Command {
+	var (
+		selector subscriptionsSelector
+		triggers []string
+		dests    []string
+		dryRun   bool
+	)
+	use, short, example := "add", "Subscribes destinations to triggers of all matching applications", `
+# Subscribe the Slack channel to the on-deployed trigger of all applications of the payments project
+argocd-notifications subscriptions add --project payments --trigger on-deployed --dest slack:payments
+
+# Print applications that would be updated without changing them
+argocd-notifications subscriptions add -l team=payments --trigger on-sync-failed --dest slack:payments --dry-run
+`
+	if !subscribe {
+		use, short, example = "remove", "Removes subscriptions of destinations to triggers from all matching applications", `
+# Unsubscribe the Slack channel from the on-deployed trigger of all applications of the payments project
+argocd-notifications subscriptions remove --project payments --trigger on-deployed --dest slack:payments
+
+# Unsubscribe the Slack channel from the on-deployed trigger of all applications
+argocd-notifications subscriptions remove --all --trigger on-deployed --dest slack:payments
+`
+	}
+	var command = cobra.Command{
+		Use:     use,
+		Short:   short,
+		Example: example,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(triggers) == 0 || len(dests) == 0 {
+				return errors.New("both --trigger and --dest flags are required")
+			}
+			if selector.empty() == !selector.all {
+				return errors.New("either --all or at least one of --app, --project and --selector flags is required")
+			}
+			destinations, err := parseDestinations(dests)
+			if err != nil {
+				return err
+			}
+			_, client, ns, err := cmdContext.getK8SClients()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to create k8s client: %v\n", err)
+				return nil
+			}
+			appClient := k8s.NewAppClient(client, ns)
+			list, err := appClient.List(context.Background(), metav1.ListOptions{LabelSelector: selector.labelSelector})
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to list applications: %v\n", err)
+				return nil
+			}
+			sort.Slice(list.Items, func(i, j int) bool {
+				return list.Items[i].GetName() < list.Items[j].GetName()
+			})
+
+			update := func(annotations map[string]string) {
+				for _, trigger := range triggers {
+					for _, dest := range destinations {
+						if subscribe {
+							subscriptions.Annotations(annotations).Subscribe(trigger, dest.Service, dest.Recipient)
+						} else {
+							subscriptions.Annotations(annotations).Unsubscribe(trigger, dest.Service, dest.Recipient)
+						}
+					}
+				}
+			}
+			suffix := ""
+			if dryRun {
+				suffix = " (dry run)"
+			}
+			updated, matched := 0, 0
+			for _, app := range list.Items {
+				if !selector.matches(app) {
+					continue
+				}
+				matched++
+				after := map[string]string{}
+				for k, v := range app.GetAnnotations() {
+					after[k] = v
+				}
+				update(after)
+				if annotationsUnchanged(app.GetAnnotations(), after) {
+					_, _ = fmt.Fprintf(cmdContext.stdout, "%s: unchanged\n", app.GetName())
+					continue
+				}
+				if !dryRun {
+					if err := k8s.UpdateAnnotations(context.Background(), appClient, app.GetName(), update); err != nil {
+						_, _ = fmt.Fprintf(cmdContext.stderr, "failed to update application %s: %v\n", app.GetName(), err)
+						continue
+					}
+				}
+				updated++
+				_, _ = fmt.Fprintf(cmdContext.stdout, "%s: updated%s\n", app.GetName(), suffix)
+			}
+			_, _ = fmt.Fprintf(cmdContext.stdout, "%d of %d matching applications updated%s\n", updated, matched, suffix)
+			return nil
+		},
+	}
+	command.Flags().StringArrayVar(&selector.apps, "app", nil, "Update the specified application only")
+	command.Flags().StringArrayVar(&selector.projects, "project", nil, "Update applications of the specified project only")
+	command.Flags().StringVarP(&selector.labelSelector, "selector", "l", "", "Update applications that match the label selector only, e.g. team=payments")
+	command.Flags().BoolVar(&selector.all, "all", false, "Update all applications")
+	command.Flags().StringArrayVar(&triggers, "trigger", nil, "Trigger name")
+	command.Flags().StringArrayVar(&dests, "dest", nil, "Destination in the service:recipient format, e.g. slack:my-channel")
+	command.Flags().BoolVar(&dryRun, "dry-run", false, "Print applications that would be updated without updating them")
+	return &command
+}
+
+func annotationsUnchanged(before map[string]string, after map[string]string) bool {
+	if len(before) != len(after) {
+		return false
+	}
+	for k, v := range before {
+		if w, ok := after[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	testingutil "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestSubscriptionsList(t *testing.T) {
+	cmData := map[string]string{
+		"subscriptions": `
+- recipients: [email:team@example.com]
+  triggers: [on-created]`,
+	}
+	guestbook := testingutil.NewApp("guestbook", testingutil.WithProject("default"), testingutil.WithAnnotations(map[string]string{
+		"notifications.argoproj.io/subscribe.on-deployed.slack": "my-channel",
+	}))
+	frontend := testingutil.NewApp("frontend", testingutil.WithProject("default"), testingutil.WithAnnotations(map[string]string{
+		"notifications.argoproj.io/unsubscribe.on-sync-failed.slack": "ops",
+	}))
+	proj := testingutil.NewProject("default", testingutil.WithAnnotations(map[string]string{
+		"notifications.argoproj.io/subscribe.on-sync-failed.slack": "ops",
+	}))
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData, guestbook, frontend, proj)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	t.Run("AllApps", func(t *testing.T) {
+		stdout.Reset()
+		command := newSubscriptionsListCommand(ctx)
+		err := command.RunE(command, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, stderr.String())
+		assert.Equal(t, `APPLICATION  TRIGGER         RECIPIENT               SOURCE
+frontend     on-created      email:team@example.com  default
+guestbook    on-created      email:team@example.com  default
+guestbook    on-deployed     slack:my-channel        application
+guestbook    on-sync-failed  slack:ops               project
+`, stdout.String())
+	})
+
+	t.Run("Recipient", func(t *testing.T) {
+		stdout.Reset()
+		command := newSubscriptionsListCommand(ctx)
+		assert.NoError(t, command.Flags().Set("recipient", "ops"))
+		assert.NoError(t, command.Flags().Set("output", "name"))
+		err := command.RunE(command, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "guestbook\n", stdout.String())
+	})
+}
+
+func TestGetEffectiveSubscriptions_NoProject(t *testing.T) {
+	ctx, closer, err := newTestContext(&bytes.Buffer{}, &bytes.Buffer{}, map[string]string{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+	cfg, err := ctx.getConfig()
+	if !assert.NoError(t, err) {
+		return
+	}
+	app := testingutil.NewApp("guestbook", testingutil.WithAnnotations(map[string]string{
+		"notifications.argoproj.io/subscribe.on-deployed.slack":   "my-channel;other-channel",
+		"notifications.argoproj.io/unsubscribe.on-deployed.slack": "other-channel",
+	}))
+	assert.Equal(t, []effectiveSubscription{
+		{App: "guestbook", Trigger: "on-deployed", Recipient: "slack:my-channel", Source: settings.SubscriptionSourceApp},
+	}, getEffectiveSubscriptions(cfg, app, nil))
+}
+
+func TestSubscriptionsAddRemove(t *testing.T) {
+	guestbook := testingutil.NewApp("guestbook", testingutil.WithProject("payments"))
+	frontend := testingutil.NewApp("frontend", testingutil.WithProject("payments"), testingutil.WithAnnotations(map[string]string{
+		"notifications.argoproj.io/subscribe.on-deployed.slack": "payments",
+	}))
+	other := testingutil.NewApp("other", testingutil.WithProject("default"))
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), guestbook, frontend, other)
+	ctx := &commandContext{
+		stdout: &stdout,
+		stderr: &stderr,
+		stdin:  strings.NewReader(""),
+		getK8SClients: func() (kubernetes.Interface, dynamic.Interface, string, error) {
+			return fake.NewSimpleClientset(), client, "default", nil
+		},
+	}
+	appClient := k8s.NewAppClient(client, "default")
+	getAnnotation := func(name string) string {
+		app, err := appClient.Get(context.Background(), name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		return app.GetAnnotations()["notifications.argoproj.io/subscribe.on-deployed.slack"]
+	}
+
+	t.Run("DryRun", func(t *testing.T) {
+		stdout.Reset()
+		command := newSubscriptionsUpdateCommand(ctx, true)
+		assert.NoError(t, command.Flags().Set("project", "payments"))
+		assert.NoError(t, command.Flags().Set("trigger", "on-deployed"))
+		assert.NoError(t, command.Flags().Set("dest", "slack:payments"))
+		assert.NoError(t, command.Flags().Set("dry-run", "true"))
+		err := command.RunE(command, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, stderr.String())
+		assert.Equal(t, `frontend: unchanged
+guestbook: updated (dry run)
+1 of 2 matching applications updated (dry run)
+`, stdout.String())
+		assert.Equal(t, "", getAnnotation("guestbook"))
+	})
+
+	t.Run("Add", func(t *testing.T) {
+		stdout.Reset()
+		command := newSubscriptionsUpdateCommand(ctx, true)
+		assert.NoError(t, command.Flags().Set("project", "payments"))
+		assert.NoError(t, command.Flags().Set("trigger", "on-deployed"))
+		assert.NoError(t, command.Flags().Set("dest", "slack:payments"))
+		err := command.RunE(command, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, stderr.String())
+		assert.Equal(t, "payments", getAnnotation("guestbook"))
+		assert.Equal(t, "", getAnnotation("other"))
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		stdout.Reset()
+		command := newSubscriptionsUpdateCommand(ctx, false)
+		assert.NoError(t, command.Flags().Set("all", "true"))
+		assert.NoError(t, command.Flags().Set("trigger", "on-deployed"))
+		assert.NoError(t, command.Flags().Set("dest", "slack:payments"))
+		err := command.RunE(command, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, stderr.String())
+		assert.Contains(t, stdout.String(), "2 of 3 matching applications updated")
+		assert.Equal(t, "", getAnnotation("guestbook"))
+		assert.Equal(t, "", getAnnotation("frontend"))
+	})
+
+	t.Run("NoSelector", func(t *testing.T) {
+		command := newSubscriptionsUpdateCommand(ctx, true)
+		assert.NoError(t, command.Flags().Set("trigger", "on-deployed"))
+		assert.NoError(t, command.Flags().Set("dest", "slack:payments"))
+		assert.Error(t, command.RunE(command, nil))
+	})
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/argoproj-labs/argocd-notifications/expr"
 	"github.com/argoproj-labs/argocd-notifications/pkg/services"
 	"github.com/argoproj-labs/argocd-notifications/pkg/util/misc"
 	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
@@ -32,23 +33,26 @@ func newTemplateNotifyCommand(cmdContext *commandContext) *cobra.Command {
 		recipients []string
 	)
 	var command = cobra.Command{
-		Use: "notify NAME APPLICATION",
+		Use: "notify NAME [APPLICATION]",
 		Example: `
 # Trigger notification using in-cluster config map and secret
 argocd-notifications template notify app-sync-succeeded guestbook --recipient slack:argocd-notifications
 
 # Render notification render generated notification in console
 argocd-notifications template notify app-sync-succeeded guestbook
+
+# Render notification without the cluster access using local config map, application and commit metadata files
+argocd-notifications template notify app-sync-succeeded --app-file ./sample-app.yaml --commit-metadata-file ./commit.yaml \
+    --config-map ./argocd-notifications-cm.yaml --secret :empty
 `,
 		Short: "Generates notification using the specified template and send it to specified recipients",
 		RunE: func(c *cobra.Command, args []string) error {
 			cancel := withDebugLogs()
 			defer cancel()
-			if len(args) < 2 {
-				return fmt.Errorf("expected two arguments, got %d", len(args))
+			if len(args) < 1 {
+				return fmt.Errorf("expected at least one argument, got %d", len(args))
 			}
 			name := args[0]
-			application := args[1]
 
 			config, err := cmdContext.getConfig()
 			if err != nil {
@@ -57,7 +61,7 @@ argocd-notifications template notify app-sync-succeeded guestbook
 			}
 			config.API.AddNotificationService("console", services.NewConsoleService(cmdContext.stdout))
 
-			app, err := cmdContext.loadApplication(application)
+			app, err := cmdContext.loadApplicationArg(args[1:])
 			if err != nil {
 				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load application: %v\n", err)
 				return nil
@@ -69,7 +73,7 @@ argocd-notifications template notify app-sync-succeeded guestbook
 				if len(parts) > 1 {
 					dest.Recipient = parts[1]
 				}
-				vars := map[string]interface{}{"app": app.Object, "context": legacy.InjectLegacyVar(config.Context, dest.Service)}
+				vars := expr.Spawn(app, config.ArgoCDService, map[string]interface{}{"app": app.Object, "context": legacy.InjectLegacyVar(config.Context, dest.Service)})
 				if err := config.API.Send(vars, []string{name}, dest); err != nil {
 					_, _ = fmt.Fprintf(cmdContext.stderr, "failed to notify '%s': %v\n", recipient, err)
 					return nil
@@ -80,6 +84,7 @@ argocd-notifications template notify app-sync-succeeded guestbook
 		},
 	}
 	command.Flags().StringArrayVar(&recipients, "recipient", []string{"console:stdout"}, "List of recipients")
+	addOfflineFlags(&command, cmdContext)
 
 	return &command
 }
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"bytes"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -30,6 +31,45 @@ message: hello {{.app.metadata.name}}`,
 	assert.Contains(t, stdout.String(), "hello guestbook")
 }
 
+func TestTemplateNotify_AppFile(t *testing.T) {
+	cmData := map[string]string{
+		"template.my-template": `
+message: '{{.app.metadata.name}}: {{(call .repo.GetCommitMetadata "abc").Message}}'`,
+	}
+	appFile := writeTempFile(t, "*-app.yaml", `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+spec:
+  source:
+    repoURL: https://github.com/argoproj/argocd-example-apps.git
+`)
+	defer func() {
+		_ = os.Remove(appFile)
+	}()
+	commitFile := writeTempFile(t, "*-commit.yaml", `message: fix guestbook`)
+	defer func() {
+		_ = os.Remove(commitFile)
+	}()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newTemplateNotifyCommand(ctx)
+	assert.NoError(t, command.Flags().Set("app-file", appFile))
+	assert.NoError(t, command.Flags().Set("commit-metadata-file", commitFile))
+	err = command.RunE(command, []string{"my-template"})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "guestbook: fix guestbook")
+}
+
 func TestTemplateGet(t *testing.T) {
 	cmData := map[string]string{
 		"template.my-template1": `{message: hello}`,
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"os"
+	"strings"
 
 	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
 
@@ -23,7 +24,31 @@ func addOutputFlags(cmd *cobra.Command, output *string) {
 	cmd.Flags().StringVarP(output, "output", "o", "wide", "Output format. One of:json|yaml|wide|name")
 }
 
-func NewToolsCommand() *cobra.Command {
+// addOfflineFlags adds flags that replace the application and the Argo CD repo server with local files
+func addOfflineFlags(cmd *cobra.Command, cmdContext *commandContext) {
+	cmd.Flags().StringVar(&cmdContext.appFile, "app-file", "", "Application manifest file path used instead of the APPLICATION argument. Use '-' to read the manifest from stdin")
+	cmd.Flags().StringVar(&cmdContext.commitMetadataFile, "commit-metadata-file", "", "File with the commit metadata returned by repo.GetCommitMetadata instead of the Argo CD repo server")
+}
+
+const (
+	// DefaultCLIName is the name of the standalone CLI binary
+	DefaultCLIName = "argocd-notifications"
+	// KubectlPluginBinaryName is the binary name of the kubectl plugin that is invoked as 'kubectl argocd-notifications'
+	KubectlPluginBinaryName = "kubectl-argocd_notifications"
+)
+
+// setCLIName replaces the default CLI name in examples of the command and all sub-commands, e.g. with
+// 'kubectl argocd-notifications' or 'argocd admin notifications'
+func setCLIName(cmd *cobra.Command, cliName string) {
+	cmd.Example = strings.Replace(cmd.Example, DefaultCLIName+" ", cliName+" ", -1)
+	for _, c := range cmd.Commands() {
+		setCLIName(c, cliName)
+	}
+}
+
+// NewToolsCommand returns the CLI root command. The name is the command name and the cliName is how the CLI is
+// invoked by users, so the command might be embedded into another CLI, e.g. NewToolsCommand("notifications", "argocd admin notifications")
+func NewToolsCommand(name string, cliName string) *cobra.Command {
 	var (
 		argocdRepoServer string
 		cmdContext       = commandContext{
@@ -34,7 +59,7 @@ func NewToolsCommand() *cobra.Command {
 		}
 	)
 	var command = cobra.Command{
-		Use:   "argocd-notifications",
+		Use:   name,
 		Short: "Set of CLI commands that helps to configure the controller",
 		Run: func(c *cobra.Command, args []string) {
 			c.HelpFunc()(c, args)
@@ -43,6 +68,17 @@ func NewToolsCommand() *cobra.Command {
 
 	command.AddCommand(newTriggerCommand(&cmdContext))
 	command.AddCommand(newTemplateCommand(&cmdContext))
+	command.AddCommand(newDeadLetterCommand(&cmdContext))
+	command.AddCommand(newReplayCommand(&cmdContext))
+	command.AddCommand(newHistoryCommand(&cmdContext))
+	command.AddCommand(newInteractiveCommand(&cmdContext))
+	command.AddCommand(newLintCommand(&cmdContext))
+	command.AddCommand(newSimulateCommand(&cmdContext))
+	command.AddCommand(newBenchCommand(&cmdContext))
+	command.AddCommand(newCatalogCommand(&cmdContext))
+	command.AddCommand(newConfigCommand(&cmdContext))
+	command.AddCommand(newServiceCommand(&cmdContext))
+	command.AddCommand(newSubscriptionsCommand(&cmdContext))
 
 	command.PersistentFlags().StringVar(&cmdContext.configMapPath,
 		"config-map", "", "argocd-notifications-cm.yaml file path")
@@ -55,5 +91,8 @@ func NewToolsCommand() *cobra.Command {
 		return getK8SClients(clientConfig)
 	}
 	cmdContext.argocdService.getK8SClients = cmdContext.getK8SClients
+	if cliName != DefaultCLIName {
+		setCLIName(&command, cliName)
+	}
 	return &command
 }
@@ -30,3 +30,14 @@ func TestPrintFormatterYaml(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, out.String(), `foo: bar`)
 }
+
+func TestNewToolsCommand_CLIName(t *testing.T) {
+	command := NewToolsCommand("notifications", "argocd admin notifications")
+	assert.Equal(t, "notifications", command.Name())
+	lint, _, err := command.Find([]string{"lint"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, lint.Example, "argocd admin notifications lint --config-map ./argocd-notifications-cm.yaml")
+	assert.NotContains(t, lint.Example, "argocd-notifications lint")
+}
@@ -7,6 +7,7 @@ import (
 	"text/tabwriter"
 
 	"github.com/argoproj-labs/argocd-notifications/expr"
+	"github.com/argoproj-labs/argocd-notifications/expr/syncwindows"
 	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
 	"github.com/argoproj-labs/argocd-notifications/pkg/util/misc"
 
@@ -23,13 +24,14 @@ func newTriggerCommand(cmdContext *commandContext) *cobra.Command {
 	}
 	command.AddCommand(newTriggerRunCommand(cmdContext))
 	command.AddCommand(newTriggerGetCommand(cmdContext))
+	command.AddCommand(newTriggerWhyNotCommand(cmdContext))
 
 	return &command
 }
 
 func newTriggerRunCommand(cmdContext *commandContext) *cobra.Command {
 	var command = cobra.Command{
-		Use:   "run NAME APPLICATION",
+		Use:   "run NAME [APPLICATION]",
 		Short: "Evaluates specified trigger condition and prints the result",
 		Example: `
 # Execute trigger configured in 'argocd-notification-cm' ConfigMap
@@ -37,13 +39,16 @@ argocd-notifications trigger run on-sync-status-unknown ./sample-app.yaml
 
 # Execute trigger using argocd-notifications-cm.yaml instead of 'argocd-notification-cm' ConfigMap
 argocd-notifications trigger run on-sync-status-unknown ./sample-app.yaml \
-    --config-map ./argocd-notifications-cm.yaml`,
+    --config-map ./argocd-notifications-cm.yaml
+
+# Execute trigger without the cluster access using local config map, application and commit metadata files
+argocd-notifications trigger run on-deployed --app-file ./sample-app.yaml --commit-metadata-file ./commit.yaml \
+    --config-map ./argocd-notifications-cm.yaml --secret :empty`,
 		RunE: func(c *cobra.Command, args []string) error {
-			if len(args) < 2 {
-				return fmt.Errorf("expected two arguments, got %d", len(args))
+			if len(args) < 1 {
+				return fmt.Errorf("expected at least one argument, got %d", len(args))
 			}
 			name := args[0]
-			application := args[1]
 			cfg, err := cmdContext.getConfig()
 			if err != nil {
 				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to parse config: %v\n", err)
@@ -59,12 +64,14 @@ argocd-notifications trigger run on-sync-status-unknown ./sample-app.yaml \
 					"trigger with name '%s' does not exist (found %s)\n", name, strings.Join(names, ", "))
 				return nil
 			}
-			app, err := cmdContext.loadApplication(application)
+			app, err := cmdContext.loadApplicationArg(args[1:])
 			if err != nil {
 				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load application: %v\n", err)
 				return nil
 			}
-			res, err := cfg.API.RunTrigger(name, expr.Spawn(app, cfg.ArgoCDService, map[string]interface{}{"app": app.Object}))
+			// the project is not loaded, so sync windows never block the sync
+			vars := map[string]interface{}{"app": app.Object, "syncwindows": syncwindows.NewExprs(app, nil)}
+			res, err := cfg.API.RunTrigger(name, expr.Spawn(app, cfg.ArgoCDService, vars))
 			if err != nil {
 				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to execute trigger %s: %v\n", name, err)
 				return nil
@@ -78,6 +85,7 @@ argocd-notifications trigger run on-sync-status-unknown ./sample-app.yaml \
 			return nil
 		},
 	}
+	addOfflineFlags(&command, cmdContext)
 
 	return &command
 }
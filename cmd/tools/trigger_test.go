@@ -86,6 +86,64 @@ message: hello {{.app.metadata.name}}`,
 	assert.Contains(t, stdout.String(), "true")
 }
 
+func writeTempFile(t *testing.T, pattern string, data string) string {
+	file, err := ioutil.TempFile("", pattern)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	_, err = file.WriteString(data)
+	assert.NoError(t, err)
+	return file.Name()
+}
+
+func TestTriggerRun_AppFile(t *testing.T) {
+	cmData := map[string]string{
+		"trigger.my-trigger": `
+- when: repo.GetCommitMetadata('abc').Author == 'alice'
+  send: [my-template]`,
+		"template.my-template": `
+message: hello {{.app.metadata.name}}`,
+	}
+	appFile := writeTempFile(t, "*-app.yaml", `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+spec:
+  source:
+    repoURL: https://github.com/argoproj/argocd-example-apps.git
+`)
+	defer func() {
+		_ = os.Remove(appFile)
+	}()
+	commitFile := writeTempFile(t, "*-commit.yaml", `
+author: alice
+message: fix guestbook
+`)
+	defer func() {
+		_ = os.Remove(commitFile)
+	}()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newTriggerRunCommand(ctx)
+	assert.NoError(t, command.Flags().Set("app-file", appFile))
+	assert.NoError(t, command.Flags().Set("commit-metadata-file", commitFile))
+	err = command.RunE(command, []string{"my-trigger"})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "true")
+}
+
 func TestTriggerGet(t *testing.T) {
 	cmData := map[string]string{
 		"trigger.my-trigger1": `
@@ -113,3 +171,39 @@ message: hello {{.app.metadata.name}}`,
 	assert.Contains(t, stdout.String(), "my-trigger1")
 	assert.Contains(t, stdout.String(), "my-trigger2")
 }
+
+func TestTriggerWhyNot(t *testing.T) {
+	cmData := map[string]string{
+		"trigger.my-trigger": `
+- when: app.metadata.name == 'guestbook' && app.status.health.status == 'Healthy'
+  send: [my-template]`,
+		"template.my-template": `
+message: hello {{.app.metadata.name}}`,
+		"service.slack": `
+token: abc`,
+	}
+	app := testingutil.NewApp("guestbook", testingutil.WithHealthStatus("Degraded"), testingutil.WithAnnotations(map[string]string{
+		"notifications.argoproj.io/subscribe.my-trigger.slack": "my-channel",
+	}))
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData, app)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newTriggerWhyNotCommand(ctx)
+	assert.NoError(t, command.Flags().Set("app", "guestbook"))
+	assert.NoError(t, command.Flags().Set("trigger", "my-trigger"))
+	err = command.RunE(command, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "Subscriptions: OK, subscribed destinations: slack:my-channel")
+	assert.Contains(t, stdout.String(), "Conditions: BLOCKED, 0 of 1 conditions are true")
+	assert.Contains(t, stdout.String(), `app.metadata.name == "guestbook": true`)
+	assert.Contains(t, stdout.String(), `app.status.health.status == "Healthy": false`)
+	assert.Contains(t, stdout.String(), "Maintenance: OK, notifications are not paused")
+	assert.Contains(t, stdout.String(), "The notification is blocked by the 'Conditions' step")
+}
@@ -0,0 +1,308 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	antonmedvexpr "github.com/antonmedv/expr"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/expr"
+	"github.com/argoproj-labs/argocd-notifications/expr/syncwindows"
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+	"github.com/argoproj-labs/argocd-notifications/shared/quota"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+func newTriggerWhyNotCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		application string
+		trigger     string
+		maxEventAge time.Duration
+	)
+	var command = cobra.Command{
+		Use:   "why-not",
+		Short: "Explains which step prevents the trigger notification of the application",
+		Example: `
+# Explain why the on-deployed notification of the guestbook application has not been sent
+argocd-notifications trigger why-not --app guestbook --trigger on-deployed
+
+# Use the application manifest instead of the application in the cluster
+argocd-notifications trigger why-not --app ./sample-app.yaml --trigger on-deployed
+`,
+		RunE: func(c *cobra.Command, args []string) error {
+			if application == "" || trigger == "" {
+				return fmt.Errorf("both --app and --trigger flags are required")
+			}
+			cfg, err := cmdContext.getConfig()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to parse config: %v\n", err)
+				return nil
+			}
+			conditions, ok := cfg.Triggers[trigger]
+			if !ok {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "trigger with name '%s' does not exist\n", trigger)
+				return nil
+			}
+			app, err := cmdContext.loadApplication(application)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load application: %v\n", err)
+				return nil
+			}
+			var proj *unstructured.Unstructured
+			// the project is available only in the cluster, so project subscriptions are skipped for the manifest file
+			if filepath.Ext(application) == "" {
+				proj = cmdContext.loadAppProject(app)
+			}
+			e := &explanation{out: cmdContext}
+			explainWhyNot(e, cfg, app, proj, trigger, conditions, maxEventAge)
+			return nil
+		},
+	}
+	command.Flags().StringVar(&application, "app", "", "Application name or path to the application manifest file")
+	command.Flags().StringVar(&trigger, "trigger", "", "Trigger name")
+	command.Flags().DurationVar(&maxEventAge, "max-event-age", 0, "The --max-event-age setting of the controller. Used to check if the notification is skipped as stale after the controller restart.")
+	return &command
+}
+
+func (c *commandContext) loadAppProject(app *unstructured.Unstructured) *unstructured.Unstructured {
+	projName, ok, _ := unstructured.NestedString(app.Object, "spec", "project")
+	if !ok {
+		return nil
+	}
+	_, client, ns, err := c.getK8SClients()
+	if err != nil {
+		return nil
+	}
+	proj, err := k8s.NewAppProjClient(client, ns).Get(context.Background(), projName, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	return proj
+}
+
+type explanation struct {
+	out     *commandContext
+	step    int
+	blocker string
+}
+
+func (e *explanation) report(name string, blocked bool, format string, args ...interface{}) {
+	e.step++
+	result := "OK"
+	if blocked {
+		result = "BLOCKED"
+		if e.blocker == "" {
+			e.blocker = name
+		}
+	}
+	_, _ = fmt.Fprintf(e.out.stdout, "%d. %s: %s, %s\n", e.step, name, result, fmt.Sprintf(format, args...))
+}
+
+func (e *explanation) detail(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(e.out.stdout, "   "+format+"\n", args...)
+}
+
+func formatDestinations(destinations []services.Destination) string {
+	var res []string
+	for _, dest := range destinations {
+		res = append(res, formatDestination(dest))
+	}
+	return strings.Join(res, ", ")
+}
+
+// explainWhyNot walks through the same steps as the controller and reports the first step that blocks the notification
+func explainWhyNot(e *explanation, cfg *settings.Config, app *unstructured.Unstructured, proj *unstructured.Unstructured, trigger string, conditions []triggers.Condition, maxEventAge time.Duration) {
+	if cfg.AppFilter.Matches(app) {
+		e.report("Application filter", false, "application matches the 'appFilter' settings")
+	} else {
+		e.report("Application filter", true, "application is excluded by the 'appFilter' settings")
+	}
+
+	subs := pkg.Subscriptions{}
+	for _, source := range cfg.CollectSubscriptions(app, settings.SubscriptionSources{Project: proj}) {
+		subs.Merge(source.Subscriptions)
+	}
+	subscribed := subs.Dedup()[trigger]
+	destinations := subscriptions.Annotations(app.GetAnnotations()).RemoveUnsubscribed(pkg.Subscriptions{trigger: subscribed})[trigger]
+	switch {
+	case len(subscribed) == 0:
+		e.report("Subscriptions", true, "no destinations are subscribed to the trigger")
+	case len(destinations) == 0:
+		e.report("Subscriptions", true, "all subscribed destinations (%s) are opted out using unsubscribe annotations", formatDestinations(subscribed))
+	default:
+		e.report("Subscriptions", false, "subscribed destinations: %s", formatDestinations(destinations))
+		if optedOut := len(subscribed) - len(destinations); optedOut > 0 {
+			e.detail("%d destinations are opted out using unsubscribe annotations", optedOut)
+		}
+	}
+	if proj == nil {
+		e.detail("project subscriptions are not checked because the project is not available")
+	}
+
+	now := time.Now()
+	mute := triggers.ParseMute(app.GetAnnotations()[subscriptions.MutedAnnotationKey])
+	if proj != nil && (mute == nil || !mute.IsActive(now)) {
+		mute = triggers.ParseMute(proj.GetAnnotations()[subscriptions.MutedAnnotationKey])
+	}
+	if mute != nil && mute.IsActive(now) {
+		e.report("Mute", true, "notifications are muted by '%s' until %s", mute.User, time.Unix(mute.Until, 0).UTC().Format(time.RFC3339))
+	} else {
+		e.report("Mute", false, "notifications are not muted")
+	}
+
+	acks := triggers.NewAcknowledgments(app.GetAnnotations()[subscriptions.AcknowledgedAnnotationKey])
+	if acks.IsAcknowledged(trigger) {
+		e.report("Acknowledgment", true, "trigger is acknowledged by '%s'", acks[trigger].User)
+	} else {
+		e.report("Acknowledgment", false, "trigger is not acknowledged")
+	}
+
+	if cfg.Maintenance.IsActive(now) {
+		if cfg.Maintenance.Until.IsZero() {
+			e.report("Maintenance", true, "notifications are paused until the '%s' annotation is removed", settings.MaintenanceAnnotationKey)
+		} else {
+			e.report("Maintenance", true, "notifications are paused until %s", cfg.Maintenance.Until.UTC().Format(time.RFC3339))
+		}
+	} else {
+		e.report("Maintenance", false, "notifications are not paused")
+	}
+
+	if maxEventAge > 0 {
+		transitionTime, ok := k8s.LastTransitionTime(app)
+		switch {
+		case !ok:
+			e.report("Backfill", false, "application has no known state transitions, so notifications are skipped if the controller processes the application for the first time after the start")
+		case transitionTime.Before(now.Add(-maxEventAge)):
+			e.report("Backfill", false, "the last state transition at %s is older than %v, so notifications are skipped if the controller processes the application for the first time after the start",
+				transitionTime.UTC().Format(time.RFC3339), maxEventAge)
+		default:
+			e.report("Backfill", false, "the last state transition at %s is newer than %v", transitionTime.UTC().Format(time.RFC3339), maxEventAge)
+		}
+	}
+
+	// the controller evaluates conditions and renders templates using the same variables
+	syncWindows := syncwindows.NewExprs(app, proj)
+	vars := expr.Spawn(app, cfg.ArgoCDService, map[string]interface{}{"app": app.Object, "syncwindows": syncWindows})
+	results, err := cfg.API.RunTrigger(trigger, vars)
+	if err != nil {
+		e.report("Conditions", true, "failed to evaluate trigger: %v", err)
+		return
+	}
+	var triggered []triggers.ConditionResult
+	for _, res := range results {
+		if res.Triggered {
+			triggered = append(triggered, res)
+		}
+	}
+	e.report("Conditions", len(triggered) == 0, "%d of %d conditions are true", len(triggered), len(results))
+	for i, condition := range conditions {
+		e.detail("[%d] %s: %v", i, condition.When, i < len(results) && results[i].Triggered)
+		operands, err := triggers.Operands(condition.When)
+		if err != nil || len(operands) < 2 {
+			continue
+		}
+		for _, operand := range operands {
+			val, err := antonmedvexpr.Eval(operand, vars)
+			if err != nil {
+				e.detail("    %s: error: %v", operand, err)
+			} else {
+				e.detail("    %s: %v", operand, val)
+			}
+		}
+	}
+
+	state := triggers.NewState(app.GetAnnotations()[subscriptions.NotifiedAnnotationKey])
+	var pending []services.Destination
+	for _, res := range triggered {
+		for _, dest := range destinations {
+			key := triggers.StateItemKey(trigger, res, dest)
+			if notifiedAt, ok := state[key]; ok {
+				oncePer := ""
+				if res.OncePer != "" {
+					oncePer = fmt.Sprintf(" for oncePer value '%s'", res.OncePer)
+				}
+				e.detail("%s:%s has been already notified%s at %s", dest.Service, dest.Recipient, oncePer, time.Unix(notifiedAt, 0).UTC().Format(time.RFC3339))
+			} else {
+				pending = append(pending, dest)
+			}
+		}
+	}
+	if len(triggered) > 0 && len(destinations) > 0 {
+		e.report("Notification state", len(pending) == 0, "%d destinations have not been notified yet", len(pending))
+	}
+
+	if len(pending) > 0 && len(cfg.Quotas) > 0 {
+		project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+		var quotas []string
+		for _, dest := range pending {
+			for _, rule := range cfg.Quotas.Matching(project, dest) {
+				per := rule.Per
+				if per == "" {
+					per = quota.PerProject
+				}
+				quotas = append(quotas, fmt.Sprintf("%s: %d per hour per %s", formatDestination(dest), rule.MaxPerHour, per))
+			}
+		}
+		if len(quotas) > 0 {
+			e.report("Quota", false, "notifications are limited by quotas: %s", strings.Join(quotas, ", "))
+			e.detail("the controller counts sent notifications in memory, so notifications that exceed the quota are reported in the controller logs")
+		} else {
+			e.report("Quota", false, "no quotas apply to the destinations")
+		}
+	}
+
+	var missing []string
+	var failed []string
+	for _, dest := range pending {
+		if _, ok := cfg.API.GetNotificationServices()[dest.Service]; !ok {
+			missing = append(missing, dest.Service+":"+dest.Recipient)
+		}
+	}
+	for _, res := range triggered {
+		for _, template := range res.Templates {
+			if _, ok := cfg.Templates[template]; !ok {
+				missing = append(missing, "template "+template)
+			}
+		}
+		if len(missing) > 0 {
+			continue
+		}
+		for _, dest := range pending {
+			templateVars := expr.Spawn(app, cfg.ArgoCDService, map[string]interface{}{
+				"app":         app.Object,
+				"context":     legacy.InjectLegacyVar(cfg.Context, dest.Service),
+				"syncwindows": syncWindows,
+			})
+			if _, err := cfg.API.FormatNotification(templateVars, res.Templates, dest); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", formatDestination(dest), err))
+			}
+		}
+	}
+	if len(pending) > 0 {
+		switch {
+		case len(missing) > 0:
+			e.report("Destinations", true, "services or templates are not configured: %s", strings.Join(missing, ", "))
+		case len(failed) > 0:
+			e.report("Destinations", true, "failed to render templates: %s", strings.Join(failed, ", "))
+		default:
+			e.report("Destinations", false, "all services and templates are configured")
+		}
+	}
+
+	if e.blocker != "" {
+		_, _ = fmt.Fprintf(e.out.stdout, "The notification is blocked by the '%s' step\n", e.blocker)
+	} else {
+		_, _ = fmt.Fprintf(e.out.stdout, "The notification would be sent to %s\n", formatDestinations(pending))
+	}
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+)
+
+// processedObjects tracks objects processed since the controller start
+type processedObjects struct {
+	lock sync.Mutex
+	keys map[string]struct{}
+}
+
+// add marks the object as processed and returns true if the object is processed for the first time
+func (p *processedObjects) add(obj *unstructured.Unstructured) bool {
+	key := obj.GetNamespace() + "/" + obj.GetName()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.keys == nil {
+		p.keys = map[string]struct{}{}
+	}
+	if _, ok := p.keys[key]; ok {
+		return false
+	}
+	p.keys[key] = struct{}{}
+	return true
+}
+
+// isBackfill returns true if the object is processed for the first time since the controller start and the latest
+// state transition of the object is older than the max event age, so notifications about the transition are stale
+func isBackfill(processed *processedObjects, obj *unstructured.Unstructured, maxEventAge time.Duration) bool {
+	if maxEventAge <= 0 || !processed.add(obj) {
+		return false
+	}
+	transitionTime, ok := k8s.LastTransitionTime(obj)
+	// the object without known transitions has not changed recently
+	return !ok || transitionTime.Before(time.Now().Add(-maxEventAge))
+}
+
+func (c *notificationController) isBackfill(app *unstructured.Unstructured) bool {
+	return isBackfill(&c.processedApps, app, c.maxEventAge)
+}
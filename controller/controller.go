@@ -7,85 +7,319 @@ import (
 	"fmt"
 	"reflect"
 	"runtime/debug"
+	"sync"
 	"time"
 
 	"github.com/argoproj-labs/argocd-notifications/expr"
+	"github.com/argoproj-labs/argocd-notifications/expr/syncwindows"
 	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/engine"
 	"github.com/argoproj-labs/argocd-notifications/pkg/services"
 	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
 	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/redact"
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+	"github.com/argoproj-labs/argocd-notifications/shared/deadletter"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
 	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
-	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+	"github.com/argoproj-labs/argocd-notifications/shared/quota"
 	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/tracing"
 
 	log "github.com/sirupsen/logrus"
+	v1core "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 	runtimeutil "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 )
 
 const (
-	resyncPeriod           = 60 * time.Second
-	notifiedHistoryMaxSize = 100
+	defaultResyncPeriod        = 60 * time.Second
+	defaultMaxRetries          = 5
+	defaultShutdownGracePeriod = 20 * time.Second
+	// queueDepthReportInterval is how often the queue depth metric is updated
+	queueDepthReportInterval = 5 * time.Second
+	// abortPersistTimeout is how long the controller waits for aborted deliveries to be recorded during the shutdown
+	abortPersistTimeout = 5 * time.Second
+	// quotaSummaryInterval is how often summaries of notifications suppressed by quotas are sent
+	quotaSummaryInterval = time.Minute
+
+	EventReasonNotificationSent    = "NotificationSent"
+	EventReasonNotificationFailed  = "NotificationFailed"
+	EventReasonNotificationUnknown = "NotificationUnknown"
 )
 
 var (
-	notifiedAnnotationKey = "notified." + subscriptions.AnnotationPrefix
+	notifiedAnnotationKey     = subscriptions.NotifiedAnnotationKey
+	acknowledgedAnnotationKey = subscriptions.AcknowledgedAnnotationKey
+	mutedAnnotationKey        = subscriptions.MutedAnnotationKey
 )
 
 type NotificationController interface {
+	// Run processes applications until the context is done. Once the context is done, Run stops taking new
+	// applications from the queue and returns after in-flight deliveries are drained
 	Run(ctx context.Context, processors int)
 	Init(ctx context.Context) error
+	// HasSynced returns true if controller informers have synced
+	HasSynced() bool
+}
+
+// Opts configures optional controller features
+type Opts func(ctrl *notificationController)
+
+// DeliveryObserver is notified about every notification delivery attempt
+type DeliveryObserver interface {
+	ObserveDelivery(dest services.Destination, err error)
+}
+
+// WithDeliveryObserver configures the observer of notification delivery results
+func WithDeliveryObserver(observer DeliveryObserver) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.deliveryObserver = observer
+	}
+}
+
+// WithDeadLetterStore configures the store that records notifications which could not be delivered
+func WithDeadLetterStore(store deadletter.Store) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.deadLetterStore = store
+	}
+}
+
+// WithHistoryRecorder configures the recorder that keeps audit records of notification delivery attempts
+func WithHistoryRecorder(recorder history.Recorder) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.historyRecorder = recorder
+	}
+}
+
+// WithEventRecorder configures the recorder used to emit Kubernetes events about notification deliveries on the application
+func WithEventRecorder(recorder record.EventRecorder) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.eventRecorder = recorder
+	}
+}
+
+// WithQuotaTracker configures the tracker that enforces notification quotas
+func WithQuotaTracker(tracker *quota.Tracker) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.quotaTracker = tracker
+	}
+}
+
+// WithShutdownGracePeriod configures how long the controller waits for in-flight deliveries during the shutdown
+func WithShutdownGracePeriod(period time.Duration) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.shutdownGracePeriod = period
+	}
+}
+
+// WithQueueRateLimiter configures the rate limiter of the applications work queue
+func WithQueueRateLimiter(rateLimiter workqueue.RateLimiter) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.queueRateLimiter = rateLimiter
+	}
+}
+
+// WithMaxRetries configures how many times the application processing is retried if it fails
+func WithMaxRetries(maxRetries int) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.maxRetries = maxRetries
+	}
+}
+
+// WithResyncPeriod configures how often all applications are re-processed
+func WithResyncPeriod(period time.Duration) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.resyncPeriod = period
+	}
+}
+
+// WithDeliveryParallelism configures how many notifications about one application are sent concurrently
+func WithDeliveryParallelism(parallelism int) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.deliveryParallelism = parallelism
+	}
+}
+
+// WithDeliveryTimeout configures how long the controller waits for the notification delivery to one destination.
+// Zero value disables the timeout.
+func WithDeliveryTimeout(timeout time.Duration) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.deliveryTimeout = timeout
+	}
+}
+
+// WithMaxEventAge configures the controller to skip notifications about state transitions that are older than the
+// specified age when the application is processed for the first time after the controller start
+func WithMaxEventAge(age time.Duration) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.maxEventAge = age
+	}
+}
+
+// WithStateLimits configures limits of the notification state stored in the application annotation
+func WithStateLimits(limits triggers.CompactOptions) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.stateLimits = limits
+	}
+}
+
+// WithAppStripFields configures dot separated application fields removed before applications are stored in the
+// informer cache. Managed fields and the last applied configuration are always removed.
+func WithAppStripFields(fields []string) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.appStripFields = fields
+	}
+}
+
+// WithAppFilter configures additional filter of processed applications. The filter is applied together with the filter from the settings
+func WithAppFilter(filter settings.AppFilter) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.appFilter = filter
+	}
+}
+
+// WithDryRun configures controller to keep notifications state in memory instead of application annotations.
+// Used together with services that don't deliver notifications to evaluate configuration without side effects.
+func WithDryRun() Opts {
+	return func(ctrl *notificationController) {
+		ctrl.dryRun = true
+		ctrl.dryRunState = map[string]string{}
+	}
+}
+
+// WithSubscriptionResources configures controller to process NotificationSubscription resources from all namespaces
+// in addition to subscriptions configured using annotations
+func WithSubscriptionResources() Opts {
+	return func(ctrl *notificationController) {
+		ctrl.subscriptionResources = true
+	}
+}
+
+// WithNamespaceSubscriptions configures controller to apply subscription annotations of the application destination namespace
+func WithNamespaceSubscriptions() Opts {
+	return func(ctrl *notificationController) {
+		ctrl.namespaceSubscriptions = true
+	}
+}
+
+// WithUpdateFilter configures controller to skip application updates that don't change fields referenced by triggers
+func WithUpdateFilter() Opts {
+	return func(ctrl *notificationController) {
+		ctrl.filterUpdates = true
+	}
+}
+
+// WithArgoCDAPI configures controller to expose the application data read from the Argo CD API server in templates
+func WithArgoCDAPI(client argocd.AppInfoClient) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.argocdAPI = client
+	}
 }
 
 func NewController(
 	client dynamic.Interface,
 	namespace string,
 	cfg settings.Config,
-	appLabelSelector string,
+	appLabelSelectors []string,
 	metricsRegistry *controllerRegistry,
+	opts ...Opts,
 ) (NotificationController, error) {
-	appClient := k8s.NewAppClient(client, namespace)
-	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	ctrl := &notificationController{
+		appClient:        k8s.NewAppClient(client, namespace),
+		namespace:        namespace,
+		cfg:              cfg,
+		metricsRegistry:  metricsRegistry,
+		queueRateLimiter: workqueue.DefaultControllerRateLimiter(),
+		maxRetries:       defaultMaxRetries,
+		resyncPeriod:     defaultResyncPeriod,
+		appStripFields:   DefaultAppStripFields,
 
-	appInformer := newInformer(appClient, appLabelSelector)
+		deliveryParallelism: defaultDeliveryParallelism,
+		deliveryTimeout:     defaultDeliveryTimeout,
+		stateLimits:         triggers.DefaultCompactOptions,
+		shutdownGracePeriod: defaultShutdownGracePeriod,
+		abortDeliveries:     make(chan struct{}),
+	}
+	for i := range opts {
+		opts[i](ctrl)
+	}
+	schedule, err := newRecheckSchedule(cfg.Triggers)
+	if err != nil {
+		return nil, err
+	}
+	ctrl.recheckSchedule = schedule
+	if ctrl.filterUpdates {
+		fields, err := newUpdateFields(cfg)
+		if err != nil {
+			return nil, err
+		}
+		ctrl.updateFields = fields
+	}
 
-	appInformer.AddEventHandler(
-		cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				key, err := cache.MetaNamespaceKeyFunc(obj)
-				if err == nil {
-					queue.Add(key)
-				}
-			},
-			UpdateFunc: func(old, new interface{}) {
-				key, err := cache.MetaNamespaceKeyFunc(new)
-				if err == nil {
-					queue.Add(key)
-				}
+	// applications with the higher priority are processed first, so alerts of production applications are not stuck
+	// behind the backlog of development applications
+	aging, err := cfg.Priorities.GetAging()
+	if err != nil {
+		return nil, err
+	}
+	queue := newPriorityQueue("app", ctrl.queueRateLimiter, ctrl.getAppPriority, aging)
+	// the API server does not support OR between label selectors, so every selector gets its own informer and the
+	// application is processed if it is loaded by any of them
+	if len(appLabelSelectors) == 0 {
+		appLabelSelectors = []string{""}
+	}
+	stripApp := newStripFunc(ctrl.appStripFields)
+	for _, selector := range appLabelSelectors {
+		selector := selector
+		appInformer := newStrippedInformer(ctrl.appClient, selector, ctrl.resyncPeriod, stripApp, newCacheStats(func(objects int, bytes int) {
+			metricsRegistry.SetInformerCacheSize("applications", selector, objects, bytes)
+		}))
+		appInformer.AddEventHandler(
+			cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					key, err := cache.MetaNamespaceKeyFunc(obj)
+					if err == nil && ctrl.isAppIncluded(obj) {
+						queue.Add(key)
+					}
+				},
+				UpdateFunc: func(old, new interface{}) {
+					key, err := cache.MetaNamespaceKeyFunc(new)
+					if err == nil && ctrl.isAppIncluded(new) && ctrl.isUpdateRelevant(old, new) {
+						queue.Add(key)
+					}
+				},
+				DeleteFunc: func(obj interface{}) {
+					if key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj); err == nil {
+						ctrl.pendingRetries.set(key, nil)
+					}
+				},
 			},
-		},
-	)
-	appProjInformer := newInformer(k8s.NewAppProjClient(client, namespace), "")
-
-	return &notificationController{
-		appClient:       appClient,
-		appInformer:     appInformer,
-		appProjInformer: appProjInformer,
-		refreshQueue:    queue,
-		cfg:             cfg,
-		metricsRegistry: metricsRegistry,
-	}, nil
+		)
+		ctrl.appInformers = append(ctrl.appInformers, appInformer)
+	}
+	ctrl.appProjInformer = newStrippedInformer(k8s.NewAppProjClient(client, namespace), "", ctrl.resyncPeriod, newStripFunc(nil), newCacheStats(func(objects int, bytes int) {
+		metricsRegistry.SetInformerCacheSize("appprojects", "", objects, bytes)
+	}))
+	if ctrl.subscriptionResources {
+		ctrl.subscriptionInformer = newInformer(client.Resource(k8s.NotificationSubscriptionResource), "", ctrl.resyncPeriod)
+	}
+	if ctrl.namespaceSubscriptions {
+		ctrl.namespaceInformer = newInformer(client.Resource(k8s.NamespaceResource), "", ctrl.resyncPeriod)
+	}
+	ctrl.refreshQueue = queue
+	return ctrl, nil
 }
 
-func newInformer(resClient dynamic.ResourceInterface, selector string) cache.SharedIndexInformer {
+func newInformer(resClient dynamic.ResourceInterface, selector string, resyncPeriod time.Duration) cache.SharedIndexInformer {
 	informer := cache.NewSharedIndexInformer(
 		&cache.ListWatch{
 			ListFunc: func(options v1.ListOptions) (object runtime.Object, err error) {
@@ -105,39 +339,165 @@ func newInformer(resClient dynamic.ResourceInterface, selector string) cache.Sha
 }
 
 type notificationController struct {
-	appClient       dynamic.ResourceInterface
-	appInformer     cache.SharedIndexInformer
+	namespace string
+	appClient dynamic.ResourceInterface
+	// appInformers has one informer per application label selector
+	appInformers    []cache.SharedIndexInformer
 	appProjInformer cache.SharedIndexInformer
-	refreshQueue    workqueue.RateLimitingInterface
-	cfg             settings.Config
-	metricsRegistry *controllerRegistry
+	// subscriptionInformer is nil unless NotificationSubscription resources are enabled
+	subscriptionInformer  cache.SharedIndexInformer
+	subscriptionResources bool
+	// namespaceInformer is nil unless namespace subscriptions are enabled
+	namespaceInformer      cache.SharedIndexInformer
+	namespaceSubscriptions bool
+	refreshQueue           workqueue.RateLimitingInterface
+	cfg                    settings.Config
+	metricsRegistry        *controllerRegistry
+	deadLetterStore        deadletter.Store
+	historyRecorder        history.Recorder
+	eventRecorder          record.EventRecorder
+	deliveryObserver       DeliveryObserver
+	quotaTracker           *quota.Tracker
+
+	queueRateLimiter workqueue.RateLimiter
+	maxRetries       int
+	resyncPeriod     time.Duration
+	appFilter        settings.AppFilter
+	appStripFields   []string
+	maxEventAge      time.Duration
+	stateLimits      triggers.CompactOptions
+	filterUpdates    bool
+	// updateFields are compared to decide if the application update must be processed; nil if updates are not filtered
+	updateFields [][]string
+	// recheckSchedule holds names of triggers that are periodically re-evaluated grouped by the interval
+	recheckSchedule map[time.Duration][]string
+	// argocdAPI is nil unless templates are enriched with the data provided by the Argo CD API server
+	argocdAPI argocd.AppInfoClient
+
+	processedApps  processedObjects
+	pendingRetries pendingRetries
+
+	deliveryParallelism int
+	deliveryTimeout     time.Duration
+	shutdownGracePeriod time.Duration
+	// abortDeliveries is closed if in-flight deliveries are not completed within the shutdown grace period
+	abortDeliveries chan struct{}
+
+	dryRun          bool
+	dryRunState     map[string]string
+	dryRunStateLock sync.Mutex
+}
+
+// getApp returns the application loaded by any of the application informers
+func (c *notificationController) getApp(key string) (interface{}, bool, error) {
+	for _, informer := range c.appInformers {
+		obj, exists, err := informer.GetIndexer().GetByKey(key)
+		if err != nil || exists {
+			return obj, exists, err
+		}
+	}
+	return nil, false, nil
+}
+
+// isAppIncluded returns true if application matches both filter configured in settings and controller filter
+func (c *notificationController) isAppIncluded(obj interface{}) bool {
+	app, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return false
+	}
+	return c.cfg.AppFilter.Matches(app) && c.appFilter.Matches(app)
 }
 
 func (c *notificationController) Init(ctx context.Context) error {
-	go c.appInformer.Run(ctx.Done())
+	synced := []cache.InformerSynced{c.appProjInformer.HasSynced}
+	for _, informer := range c.appInformers {
+		go informer.Run(ctx.Done())
+		synced = append(synced, informer.HasSynced)
+	}
 	go c.appProjInformer.Run(ctx.Done())
+	if c.subscriptionInformer != nil {
+		go c.subscriptionInformer.Run(ctx.Done())
+		synced = append(synced, c.subscriptionInformer.HasSynced)
+	}
+	if c.namespaceInformer != nil {
+		go c.namespaceInformer.Run(ctx.Done())
+		synced = append(synced, c.namespaceInformer.HasSynced)
+	}
 
-	if !cache.WaitForCacheSync(ctx.Done(), c.appInformer.HasSynced, c.appProjInformer.HasSynced) {
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
 		return errors.New("Timed out waiting for caches to sync")
 	}
 	return nil
 }
 
+func (c *notificationController) HasSynced() bool {
+	for _, informer := range c.appInformers {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return c.appProjInformer.HasSynced() &&
+		(c.subscriptionInformer == nil || c.subscriptionInformer.HasSynced()) &&
+		(c.namespaceInformer == nil || c.namespaceInformer.HasSynced())
+}
+
 func (c *notificationController) Run(ctx context.Context, processors int) {
 	defer runtimeutil.HandleCrash()
-	defer c.refreshQueue.ShutDown()
 
 	log.Warn("Controller is running.")
+	go wait.Until(func() {
+		c.metricsRegistry.SetQueueDepth(c.refreshQueue.Len())
+		c.metricsRegistry.SetMaintenance(c.cfg.Maintenance.IsActive(time.Now()))
+	}, queueDepthReportInterval, ctx.Done())
+	if c.quotaTracker != nil {
+		go wait.Until(c.sendQuotaSummaries, quotaSummaryInterval, ctx.Done())
+	}
+	for interval, triggerNames := range c.recheckSchedule {
+		go c.runRechecks(ctx, interval, triggerNames)
+	}
+	var workers sync.WaitGroup
 	for i := 0; i < processors; i++ {
-		go wait.Until(func() {
-			for c.processQueueItem() {
-			}
-		}, time.Second, ctx.Done())
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			wait.Until(func() {
+				for c.processQueueItem() {
+				}
+			}, time.Second, ctx.Done())
+		}()
 	}
 	<-ctx.Done()
+	// applications that are waiting in the queue are processed after the restart
+	c.refreshQueue.ShutDown()
+	c.drain(&workers)
 	log.Warn("Controller has stopped.")
 }
 
+// drain waits until applications that are being processed are done. Deliveries that don't complete within the
+// grace period are aborted and handled as failed deliveries, so they are recorded as dead letters and the application
+// state is persisted with the notification marked as not sent, which is retried after the restart.
+func (c *notificationController) drain(workers *sync.WaitGroup) {
+	drained := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(drained)
+	}()
+	timer := time.NewTimer(c.shutdownGracePeriod)
+	defer timer.Stop()
+	select {
+	case <-drained:
+		return
+	case <-timer.C:
+	}
+	log.Warnf("In-flight deliveries are not completed within %v, aborting", c.shutdownGracePeriod)
+	close(c.abortDeliveries)
+	select {
+	case <-drained:
+	case <-time.After(abortPersistTimeout):
+		log.Warn("Timed out waiting for aborted deliveries to be recorded")
+	}
+}
+
 func ensureAnnotations(obj *unstructured.Unstructured) {
 	if obj.GetAnnotations() == nil {
 		obj.SetAnnotations(map[string]string{})
@@ -145,15 +505,25 @@ func ensureAnnotations(obj *unstructured.Unstructured) {
 }
 
 func (c *notificationController) processApp(app *unstructured.Unstructured, logEntry *log.Entry) error {
+	ctx, span := tracing.StartSpan(context.Background(), "ProcessApp")
+	defer span.Finish()
+	span.SetAttribute("app", app.GetNamespace()+"/"+app.GetName())
+
 	refreshed := false
 	ensureAnnotations(app)
 
 	state := triggers.NewState(app.GetAnnotations()[notifiedAnnotationKey])
+	acks := triggers.NewAcknowledgments(app.GetAnnotations()[acknowledgedAnnotationKey])
+	appMute := triggers.ParseMute(app.GetAnnotations()[mutedAnnotationKey])
+	mute := c.getActiveMute(app, appMute)
+	backfill := c.isBackfill(app)
+	paused := c.cfg.Maintenance.IsActive(time.Now())
+	project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
 	// changes state of specified trigger/destination and returns if state has changed or not
 	setAlreadyNotified := func(trigger string, result triggers.ConditionResult, dest services.Destination, isNotified bool) (bool, error) {
 		changed := state.SetAlreadyNotified(trigger, result, dest, isNotified)
-		// if state changes reload application
-		if changed && !refreshed {
+		// if state changes reload application; in dry run mode the state is not persisted in the application
+		if changed && !refreshed && !c.dryRun {
 			refreshedApp, err := c.appClient.Get(context.Background(), app.GetName(), v1.GetOptions{})
 			if err != nil {
 				return false, err
@@ -168,52 +538,143 @@ func (c *notificationController) processApp(app *unstructured.Unstructured, logE
 		return changed, nil
 	}
 
-	for trigger, destinations := range c.getSubscriptions(app) {
+	policy := engine.Policy{
+		Suppress: func(trigger string, _ triggers.ConditionResult) (string, string) {
+			return suppressionReason(trigger, acks, mute, paused, backfill, c.maxEventAge)
+		},
+	}
+	if c.quotaTracker != nil {
+		policy.Allow = func(_ string, _ triggers.ConditionResult, to services.Destination) bool {
+			return c.quotaTracker.Allow(project, to, app.GetName())
+		}
+	}
+
+	// notifications are sent once all triggers are evaluated, so deliveries to all destinations run in parallel
+	var deliveries []*delivery
+	appSubscriptions := c.getSubscriptions(app)
+	syncWindows := syncwindows.NewExprs(app, c.getAppProj(app))
+	for trigger, destinations := range appSubscriptions {
 
-		res, err := c.cfg.API.RunTrigger(trigger, expr.Spawn(app, c.cfg.ArgoCDService, map[string]interface{}{"app": app.Object}))
+		triggerCtx, triggerSpan := tracing.StartSpan(ctx, "RunTrigger")
+		triggerSpan.SetAttribute("trigger", trigger)
+		res, err := c.cfg.API.RunTrigger(trigger, expr.Spawn(app, newTracedArgoCDService(triggerCtx, c.cfg.ArgoCDService), map[string]interface{}{
+			"app":         app.Object,
+			"syncwindows": syncWindows,
+		}))
+		triggerSpan.SetError(err)
+		triggerSpan.Finish()
 		if err != nil {
 			logEntry.Debugf("Failed to execute condition of trigger %s: %v", trigger, err)
+			c.metricsRegistry.IncTriggerOutcomesCounter(trigger, TriggerOutcomeError)
 		}
 		logEntry.Infof("Trigger %s result: %v", trigger, res)
 
-		for _, cr := range res {
-			if !cr.Triggered {
-				for _, to := range destinations {
-					if _, err := setAlreadyNotified(trigger, cr, to, false); err != nil {
-						return err
-					}
-				}
+		acknowledged := acks.IsAcknowledged(trigger)
+		evaluations, stateErr := engine.Evaluate(trigger, res, destinations, setAlreadyNotified, policy)
+		if stateErr != nil {
+			return stateErr
+		}
+		firing := false
+		for _, evaluation := range evaluations {
+			cr := evaluation.Result
+			c.metricsRegistry.IncTriggerEvaluationsCounter(trigger, cr.Triggered)
+			if evaluation.Outcome == engine.OutcomeNotFired {
+				c.metricsRegistry.IncTriggerOutcomesCounter(trigger, TriggerOutcomeNotFired)
 				continue
 			}
 
-			for _, to := range destinations {
-				if changed, err := setAlreadyNotified(trigger, cr, to, true); err != nil {
-					return err
-				} else if !changed {
-					logEntry.Infof("Notification about condition '%s.%s' already sent to '%v'", trigger, cr.Key, to)
-					continue // move to the next recipient
-				}
-
+			firing = true
+			c.metricsRegistry.SetTriggerLastTriggered(trigger, time.Now())
+			if evaluation.Reason != "" {
+				logEntry.Infof("Condition '%s.%s' %s, notifications are not sent", trigger, cr.Key, evaluation.Reason)
+				c.metricsRegistry.IncTriggerOutcomesCounter(trigger, evaluation.Outcome)
+				continue
+			}
+			for _, to := range evaluation.AlreadyNotified {
+				logEntry.Infof("Notification about condition '%s.%s' already sent to '%v'", trigger, cr.Key, to)
+			}
+			for _, to := range evaluation.Rejected {
+				// the notification is recorded as sent and included into the summary sent once the quota allows it
+				logEntry.Warnf("Notification about condition '%s.%s' to '%v' exceeds the quota of project '%s'", trigger, cr.Key, to, project)
+				c.metricsRegistry.IncQuotaExceededCounter(project, to.Service)
+			}
+			for _, to := range evaluation.Pending {
 				logEntry.Infof("Sending notification about condition '%s.%s' to '%v'", trigger, cr.Key, to)
-				vars := expr.Spawn(app, c.cfg.ArgoCDService, map[string]interface{}{
-					"app":     app.Object,
-					"context": legacy.InjectLegacyVar(c.cfg.Context, to.Service),
-				})
-
-				if err := c.cfg.API.Send(vars, cr.Templates, to); err != nil {
-					logEntry.Errorf("Failed to notify recipient %s defined in app %s/%s: %v",
-						to, app.GetNamespace(), app.GetName(), err)
-					_ = state.SetAlreadyNotified(trigger, cr, to, false)
-					c.metricsRegistry.IncDeliveriesCounter(trigger, to.Service, false)
-				} else {
-					logEntry.Debugf("Notification %s was sent", to.Recipient)
-					c.metricsRegistry.IncDeliveriesCounter(trigger, to.Service, true)
-				}
+				deliveries = append(deliveries, &delivery{trigger: trigger, result: cr, dest: to})
+			}
+			c.metricsRegistry.IncTriggerOutcomesCounter(trigger, evaluation.Outcome)
+		}
+		if acknowledged && !firing && err == nil {
+			logEntry.Infof("Trigger %s stopped firing, removing acknowledgment", trigger)
+			delete(acks, trigger)
+		}
+	}
+
+	c.sendAll(ctx, app, deliveries)
+	failed := map[string]PendingRetry{}
+	for _, d := range deliveries {
+		trigger, cr, to, err := d.trigger, d.result, d.dest, d.err
+		c.metricsRegistry.ObserveDeliveryDuration(trigger, to.Service, err == nil, d.duration)
+		c.recordHistory(app, trigger, cr, to, err, logEntry)
+		if c.deliveryObserver != nil {
+			c.deliveryObserver.ObserveDelivery(to, err)
+		}
+		if isOutcomeUnknown(err) {
+			// the notification service might still deliver the notification, so it stays marked as sent and is not
+			// retried, otherwise the recipient might get the duplicate
+			logEntry.Warnf("Notification to recipient %s defined in app %s/%s might not be delivered, it is not retried to avoid duplicates: %v",
+				to, app.GetNamespace(), app.GetName(), err)
+			c.metricsRegistry.IncDeliveriesCounter(trigger, to.Service, false)
+			c.metricsRegistry.IncDeliveryErrorsCounter(trigger, to.Service, services.ErrorClass(err))
+			c.addDeadLetter(app, trigger, cr, to, d.vars, err, logEntry)
+			c.emitEvent(app, v1core.EventTypeWarning, EventReasonNotificationUnknown,
+				"Notification about trigger '%s' to '%s:%s' might not be delivered: %v", trigger, to.Service, to.Recipient, err)
+		} else if err != nil {
+			failed[triggers.StateItemKey(trigger, cr, to)] = PendingRetry{
+				Trigger:   trigger,
+				Service:   to.Service,
+				Recipient: to.Recipient,
+				Error:     redact.String(err.Error()),
+				FailedAt:  time.Now(),
 			}
+			logEntry.Errorf("Failed to notify recipient %s defined in app %s/%s: %v",
+				to, app.GetNamespace(), app.GetName(), err)
+			_ = state.SetAlreadyNotified(trigger, cr, to, false)
+			c.metricsRegistry.IncDeliveriesCounter(trigger, to.Service, false)
+			c.metricsRegistry.IncDeliveryErrorsCounter(trigger, to.Service, services.ErrorClass(err))
+			var renderErr *services.RenderError
+			if errors.As(err, &renderErr) {
+				c.metricsRegistry.IncTemplateRenderErrorsCounter(renderErr.Template, renderErr.Reason)
+			}
+			c.addDeadLetter(app, trigger, cr, to, d.vars, err, logEntry)
+			c.emitEvent(app, v1core.EventTypeWarning, EventReasonNotificationFailed,
+				"Failed to send notification about trigger '%s' to '%s:%s': %v", trigger, to.Service, to.Recipient, err)
+		} else {
+			logEntry.Debugf("Notification %s was sent", to.Recipient)
+			c.metricsRegistry.IncDeliveriesCounter(trigger, to.Service, true)
+			c.removeDeadLetter(app, trigger, cr, to, logEntry)
+			c.emitEvent(app, v1core.EventTypeNormal, EventReasonNotificationSent,
+				"Notification about trigger '%s' was sent to '%s:%s'", trigger, to.Service, to.Recipient)
 		}
 	}
+	c.pendingRetries.set(app.GetNamespace()+"/"+app.GetName(), failed)
+	if len(failed) > 0 {
+		logEntry.Warnf("Failed to deliver %d of %d notifications, failed notifications are retried during the next reconciliation", len(failed), len(deliveries))
+	}
 
-	state.Truncate(notifiedHistoryMaxSize)
+	for trigger := range acks {
+		if _, ok := appSubscriptions[trigger]; !ok {
+			delete(acks, trigger)
+		}
+	}
+
+	if removed := state.Prune(c.getValidDestinations(appSubscriptions)); removed > 0 {
+		logEntry.Debugf("Removed %d stale notification state items", removed)
+	}
+	if removed := state.Compact(c.stateLimits, time.Now()); removed > 0 {
+		logEntry.Debugf("Removed %d notification state items exceeding the state limits", removed)
+		c.metricsRegistry.AddStateCompactedItems(removed)
+	}
 
 	annotations := app.GetAnnotations()
 
@@ -224,13 +685,124 @@ func (c *notificationController) processApp(app *unstructured.Unstructured, logE
 		if err != nil {
 			return err
 		}
+		c.metricsRegistry.ObserveStateSize(len(stateJson))
 		annotations[notifiedAnnotationKey] = string(stateJson)
 	}
+	if len(acks) == 0 {
+		delete(annotations, acknowledgedAnnotationKey)
+	} else {
+		annotations[acknowledgedAnnotationKey] = acks.String()
+	}
+	if _, ok := annotations[mutedAnnotationKey]; ok && !appMute.IsActive(time.Now()) {
+		logEntry.Info("Removing expired mute")
+		delete(annotations, mutedAnnotationKey)
+	}
 
 	app.SetAnnotations(annotations)
 	return nil
 }
 
+// sendQuotaSummaries notifies destinations about notifications that were not sent because of the quota
+func (c *notificationController) sendQuotaSummaries() {
+	if c.cfg.Maintenance.IsActive(time.Now()) {
+		return
+	}
+	notificationServices := c.cfg.API.GetNotificationServices()
+	for _, summary := range c.quotaTracker.Flush() {
+		svc, ok := notificationServices[summary.Destination.Service]
+		if !ok {
+			continue
+		}
+		if err := svc.Send(services.Notification{Message: summary.Message()}, summary.Destination); err != nil {
+			log.Errorf("Failed to send quota summary to %s:%s: %v", summary.Destination.Service, summary.Destination.Recipient, redact.Error(err))
+		}
+	}
+}
+
+// getValidDestinations returns subscribed destinations of triggers that are configured, so state items of removed
+// triggers and unsubscribed destinations are pruned
+func (c *notificationController) getValidDestinations(appSubscriptions pkg.Subscriptions) map[string][]services.Destination {
+	res := map[string][]services.Destination{}
+	for trigger, dests := range appSubscriptions {
+		if _, ok := c.cfg.Triggers[trigger]; ok {
+			res[trigger] = dests
+		}
+	}
+	return res
+}
+
+// addDeadLetter records notification that could not be delivered, so it can be inspected and replayed later
+func (c *notificationController) addDeadLetter(
+	app *unstructured.Unstructured,
+	trigger string,
+	cr triggers.ConditionResult,
+	dest services.Destination,
+	vars map[string]interface{},
+	sendErr error,
+	logEntry *log.Entry,
+) {
+	if c.deadLetterStore == nil {
+		return
+	}
+	entry := deadletter.Entry{
+		ID:          deadletter.EntryID(app.GetName(), trigger, cr.Templates, dest),
+		App:         app.GetName(),
+		Trigger:     trigger,
+		Templates:   cr.Templates,
+		Destination: dest,
+		Error:       redact.String(sendErr.Error()),
+		Failures:    1,
+		Timestamp:   time.Now().Unix(),
+	}
+	if notification, err := c.cfg.API.FormatNotification(vars, cr.Templates, dest); err == nil {
+		entry.PayloadHash = deadletter.PayloadHash(*notification)
+	}
+	if err := c.deadLetterStore.Add(context.Background(), entry); err != nil {
+		logEntry.Errorf("Failed to record dead letter for recipient %s: %v", dest, err)
+		return
+	}
+	c.metricsRegistry.IncDeadLettersCounter(trigger, dest.Service)
+}
+
+// emitEvent emits Kubernetes event on the application if event recorder is configured. Known secret values are
+// removed from the message since events are readable by everyone who can read the application.
+func (c *notificationController) emitEvent(app *unstructured.Unstructured, eventType string, reason string, messageFmt string, args ...interface{}) {
+	if c.eventRecorder == nil {
+		return
+	}
+	c.eventRecorder.Event(app, eventType, reason, redact.String(fmt.Sprintf(messageFmt, args...)))
+}
+
+// recordHistory records the notification delivery attempt in the notifications history
+func (c *notificationController) recordHistory(
+	app *unstructured.Unstructured, trigger string, cr triggers.ConditionResult, dest services.Destination, sendErr error, logEntry *log.Entry) {
+	if c.historyRecorder == nil {
+		return
+	}
+	err := c.historyRecorder.Record(context.Background(), history.Record{
+		App:         app.GetName(),
+		Trigger:     trigger,
+		Templates:   cr.Templates,
+		Destination: dest,
+		Error:       redact.Error(sendErr),
+		Timestamp:   time.Now(),
+	})
+	if err != nil {
+		logEntry.Warnf("Failed to record notification history for recipient %s: %v", dest, err)
+	}
+}
+
+// removeDeadLetter removes previously recorded dead letter after notification has been successfully delivered
+func (c *notificationController) removeDeadLetter(
+	app *unstructured.Unstructured, trigger string, cr triggers.ConditionResult, dest services.Destination, logEntry *log.Entry) {
+	if c.deadLetterStore == nil {
+		return
+	}
+	if err := c.deadLetterStore.Delete(context.Background(), deadletter.EntryID(app.GetName(), trigger, cr.Templates, dest)); err != nil {
+		logEntry.Warnf("Failed to remove dead letter for recipient %s: %v", dest, err)
+	}
+}
+
 func (c *notificationController) getAppProj(app *unstructured.Unstructured) *unstructured.Unstructured {
 	projName, ok, err := unstructured.NestedString(app.Object, "spec", "project")
 	if !ok || err != nil {
@@ -248,18 +820,79 @@ func (c *notificationController) getAppProj(app *unstructured.Unstructured) *uns
 	return proj
 }
 
-func (c *notificationController) getSubscriptions(app *unstructured.Unstructured) pkg.Subscriptions {
-	res := c.cfg.GetGlobalSubscriptions(app.GetLabels())
+// getActiveMute returns the active mute of the application or the application project
+func (c *notificationController) getActiveMute(app *unstructured.Unstructured, appMute *triggers.Mute) *triggers.Mute {
+	now := time.Now()
+	if appMute.IsActive(now) {
+		return appMute
+	}
+	if proj := c.getAppProj(app); proj != nil {
+		if projMute := triggers.ParseMute(proj.GetAnnotations()[mutedAnnotationKey]); projMute.IsActive(now) {
+			return projMute
+		}
+	}
+	return nil
+}
 
-	res.Merge(subscriptions.Annotations(app.GetAnnotations()).GetAll(c.cfg.DefaultTriggers...))
-	res.Merge(legacy.GetSubscriptions(app.GetAnnotations(), c.cfg.DefaultTriggers...))
+// suppressionReason returns the trigger outcome and the reason if notifications about the firing condition must not
+// be sent. Suppressed notifications are recorded as sent: acknowledged notifications are not sent after the
+// acknowledgment is removed, muted notifications are dropped rather than postponed, so subscribers don't get the burst
+// once the mute expires, notifications are not sent once the maintenance ends, and backfill conditions are caused by
+// state transitions that happened before the controller start
+func suppressionReason(trigger string, acks triggers.Acknowledgments, mute *triggers.Mute, paused bool, backfill bool, maxEventAge time.Duration) (string, string) {
+	switch {
+	case acks.IsAcknowledged(trigger):
+		return TriggerOutcomeAcknowledged, fmt.Sprintf("is acknowledged by '%s'", acks[trigger].User)
+	case mute != nil:
+		return TriggerOutcomeMuted, fmt.Sprintf("is muted by '%s' until %s", mute.User, time.Unix(mute.Until, 0).UTC().Format(time.RFC3339))
+	case paused:
+		return TriggerOutcomePaused, "is true during the maintenance"
+	case backfill:
+		return TriggerOutcomeStale, fmt.Sprintf("is caused by the state transition older than %v", maxEventAge)
+	}
+	return "", ""
+}
 
-	if proj := c.getAppProj(app); proj != nil {
-		res.Merge(subscriptions.Annotations(proj.GetAnnotations()).GetAll(c.cfg.DefaultTriggers...))
-		res.Merge(legacy.GetSubscriptions(proj.GetAnnotations(), c.cfg.DefaultTriggers...))
+func (c *notificationController) getAppDestinationNamespace(app *unstructured.Unstructured) *unstructured.Unstructured {
+	if c.namespaceInformer == nil {
+		return nil
+	}
+	namespace, ok, err := unstructured.NestedString(app.Object, "spec", "destination", "namespace")
+	if !ok || err != nil || namespace == "" {
+		return nil
 	}
+	nsObj, ok, err := c.namespaceInformer.GetIndexer().GetByKey(namespace)
+	if !ok || err != nil {
+		return nil
+	}
+	ns, ok := nsObj.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+	return ns
+}
 
-	return res.Dedup()
+func (c *notificationController) getSubscriptions(app *unstructured.Unstructured) pkg.Subscriptions {
+	sources := settings.SubscriptionSources{
+		Project:             c.getAppProj(app),
+		Namespace:           c.getAppDestinationNamespace(app),
+		ControllerNamespace: c.namespace,
+	}
+	if c.subscriptionInformer != nil {
+		for _, obj := range c.subscriptionInformer.GetStore().List() {
+			un, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			resource, err := subscriptions.ParseResource(un)
+			if err != nil {
+				log.Warnf("Failed to parse subscription: %v", err)
+				continue
+			}
+			sources.Resources = append(sources.Resources, resource)
+		}
+	}
+	return c.cfg.ResolveSubscriptions(app, sources)
 }
 
 // Checks if the application SyncStatus has been refreshed by Argo CD after an operation has completed
@@ -322,7 +955,7 @@ func (c *notificationController) processQueueItem() (processNext bool) {
 		c.refreshQueue.Done(key)
 	}()
 
-	obj, exists, err := c.appInformer.GetIndexer().GetByKey(key.(string))
+	obj, exists, err := c.getApp(key.(string))
 	if err != nil {
 		log.Errorf("Failed to get app '%s' from appInformer index: %+v", key, err)
 		return
@@ -343,41 +976,57 @@ func (c *notificationController) processQueueItem() (processNext bool) {
 		logEntry.Info("Processing skipped, sync status out of date")
 		return
 	}
+	if c.dryRun {
+		c.processDryRun(key.(string), appCopy, logEntry)
+		return
+	}
 	err = c.processApp(appCopy, logEntry)
 	if err != nil {
 		logEntry.Errorf("Failed to process: %v", err)
+		c.retry(key, logEntry)
 		return
 	}
 
 	if !isTheSame(app.GetAnnotations(), appCopy.GetAnnotations()) {
-		annotationsPatch := make(map[string]interface{})
-		for k, v := range appCopy.GetAnnotations() {
-			annotationsPatch[k] = v
-		}
-		for k := range app.GetAnnotations() {
-			if _, ok = appCopy.GetAnnotations()[k]; !ok {
-				annotationsPatch[k] = nil
-			}
-		}
-
-		patchData, err := json.Marshal(map[string]map[string]interface{}{
-			"metadata": {"annotations": annotationsPatch},
-		})
-		if err != nil {
-			logEntry.Errorf("Failed to marshal app patch: %v", err)
-			return
-		}
-		_, err = c.appClient.Patch(context.Background(), app.GetName(), types.MergePatchType, patchData, v1.PatchOptions{})
+		err = k8s.UpdateChangedAnnotations(context.Background(), c.appClient, app.GetName(), app.GetAnnotations(), appCopy.GetAnnotations())
 		if err != nil {
 			logEntry.Errorf("Failed to patch app: %v", err)
+			c.retry(key, logEntry)
 			return
 		}
 	}
+	c.refreshQueue.Forget(key)
 	logEntry.Info("Processing completed")
 
 	return
 }
 
+// processDryRun processes application using the notifications state stored in memory and does not update the application
+func (c *notificationController) processDryRun(key string, app *unstructured.Unstructured, logEntry *log.Entry) {
+	c.dryRunStateLock.Lock()
+	defer c.dryRunStateLock.Unlock()
+	ensureAnnotations(app)
+	if state, ok := c.dryRunState[key]; ok {
+		app.GetAnnotations()[notifiedAnnotationKey] = state
+	}
+	if err := c.processApp(app, logEntry); err != nil {
+		logEntry.Errorf("Failed to process: %v", err)
+		return
+	}
+	c.dryRunState[key] = app.GetAnnotations()[notifiedAnnotationKey]
+	logEntry.Info("Processing completed (dry run)")
+}
+
+// retry re-queues the application with the rate limited delay unless max retries number is reached
+func (c *notificationController) retry(key interface{}, logEntry *log.Entry) {
+	if c.refreshQueue.NumRequeues(key) < c.maxRetries {
+		c.refreshQueue.AddRateLimited(key)
+		return
+	}
+	logEntry.Warnf("Processing failed %d times, giving up until the next resync", c.maxRetries+1)
+	c.refreshQueue.Forget(key)
+}
+
 func isTheSame(appAnnotations, appCopyAnnotations map[string]string) bool {
 	if appAnnotations == nil {
 		if appCopyAnnotations == nil {
@@ -3,26 +3,39 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
 	kubetesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/argoproj-labs/argocd-notifications/pkg"
 	"github.com/argoproj-labs/argocd-notifications/pkg/mocks"
 	"github.com/argoproj-labs/argocd-notifications/pkg/services"
 	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
 	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+	"github.com/argoproj-labs/argocd-notifications/shared/deadletter"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
 	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+	"github.com/argoproj-labs/argocd-notifications/shared/quota"
 	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	"github.com/argoproj-labs/argocd-notifications/shared/unsubscribe"
 	. "github.com/argoproj-labs/argocd-notifications/testing"
 )
 
@@ -38,15 +51,15 @@ func mustToJson(val interface{}) string {
 	return string(res)
 }
 
-func newController(t *testing.T, ctx context.Context, client dynamic.Interface) (*notificationController, *mocks.MockAPI, error) {
+func newController(t *testing.T, ctx context.Context, client dynamic.Interface, opts ...Opts) (*notificationController, *mocks.MockAPI, error) {
 	mockCtrl := gomock.NewController(t)
 	go func() {
 		<-ctx.Done()
 		mockCtrl.Finish()
 	}()
 	api := mocks.NewMockAPI(mockCtrl)
-	cfg := settings.Config{Config: pkg.Config{}, API: api}
-	c, err := NewController(client, TestNamespace, cfg, "", NewMetricsRegistry())
+	cfg := settings.Config{Config: pkg.Config{Triggers: map[string][]triggers.Condition{"my-trigger": nil}}, API: api}
+	c, err := NewController(client, TestNamespace, cfg, nil, NewMetricsRegistry(), opts...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -57,6 +70,33 @@ func newController(t *testing.T, ctx context.Context, client dynamic.Interface)
 	return c.(*notificationController), api, err
 }
 
+func TestMultipleAppLabelSelectors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	var apps []runtime.Object
+	for name, team := range map[string]string{"payments": "a", "frontend": "c", "other": "d"} {
+		app := NewApp(name)
+		app.SetLabels(map[string]string{"team": team})
+		apps = append(apps, app)
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	cfg := settings.Config{API: mocks.NewMockAPI(mockCtrl)}
+	c, err := NewController(fake.NewSimpleDynamicClient(runtime.NewScheme(), apps...), TestNamespace, cfg, []string{"team=a", "team in (b,c)"}, NewMetricsRegistry())
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, c.Init(ctx)) {
+		return
+	}
+	ctrl := c.(*notificationController)
+	for name, expected := range map[string]bool{"payments": true, "frontend": true, "other": false} {
+		_, exists, err := ctrl.getApp(TestNamespace + "/" + name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, exists, name)
+	}
+}
+
 func TestSendsNotificationIfTriggered(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -84,6 +124,110 @@ func TestSendsNotificationIfTriggered(t *testing.T) {
 	assert.Equal(t, legacy.InjectLegacyVar(ctrl.cfg.Context, "mock"), receivedVars["context"])
 }
 
+func TestSendsNotificationWithUnsubscribeURL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "alice@example.com",
+	}))
+
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+	ctrl.cfg.EmailUnsubscribe = &settings.EmailUnsubscribe{URL: "https://bot.example.com/email/unsubscribe", Key: "my-key"}
+	ctrl.cfg.ServiceTypes = map[string]string{"mock": "email"}
+
+	receivedVars := map[string]interface{}{}
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+	api.EXPECT().Send(mock.MatchedBy(func(vars map[string]interface{}) bool {
+		receivedVars = vars
+		return true
+	}), []string{"test"}, services.Destination{Service: "mock", Recipient: "alice@example.com"}).Return(nil)
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+	unsubscribeURL, err := url.Parse(fmt.Sprintf("%v", receivedVars["unsubscribeURL"]))
+	if !assert.NoError(t, err) {
+		return
+	}
+	link, err := unsubscribe.Parse(unsubscribeURL.Query(), []byte("my-key"))
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@example.com", link.Recipient)
+	assert.InDelta(t, time.Now().Add(30*24*time.Hour).Unix(), link.Expires, 60)
+}
+
+func TestSendsNotificationWithoutUnsubscribeURL_NotEmail(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "general",
+	}))
+
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+	ctrl.cfg.EmailUnsubscribe = &settings.EmailUnsubscribe{URL: "https://bot.example.com/email/unsubscribe", Key: "my-key"}
+	ctrl.cfg.ServiceTypes = map[string]string{"mock": "slack"}
+
+	receivedVars := map[string]interface{}{}
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+	api.EXPECT().Send(mock.MatchedBy(func(vars map[string]interface{}) bool {
+		receivedVars = vars
+		return true
+	}), []string{"test"}, services.Destination{Service: "mock", Recipient: "general"}).Return(nil)
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+	_, ok := receivedVars["unsubscribeURL"]
+	assert.False(t, ok)
+}
+
+func TestSendsNotificationWithArgoCDAPI(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app),
+		WithArgoCDAPI(argocd.NewAPIClient(argocd.APIClientOptions{ServerURL: "https://argocd.example.com"})))
+	assert.NoError(t, err)
+
+	receivedVars := map[string]interface{}{}
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+	api.EXPECT().Send(mock.MatchedBy(func(vars map[string]interface{}) bool {
+		receivedVars = vars
+		return true
+	}), []string{"test"}, services.Destination{Service: "mock", Recipient: "recipient"}).Return(nil)
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+	if assert.IsType(t, map[string]interface{}{}, receivedVars["argocd"]) {
+		assert.Contains(t, receivedVars["argocd"], "GetResourceTree")
+	}
+}
+
+func TestSendsNotificationToDestinationGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", settings.GroupService): "team-a",
+	}))
+
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+	ctrl.cfg.DestinationGroups = settings.DestinationGroups{"team-a": {"mock:channel", "mock:team-a@example.com"}}
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, services.Destination{Service: "mock", Recipient: "channel"}).Return(nil)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, services.Destination{Service: "mock", Recipient: "team-a@example.com"}).Return(nil)
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+}
+
 func TestSendsNotificationIfProjectTriggered(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -112,6 +256,198 @@ func TestSendsNotificationIfProjectTriggered(t *testing.T) {
 	assert.Equal(t, legacy.InjectLegacyVar(ctrl.cfg.Context, "mock"), receivedVars["context"])
 }
 
+func TestSendsNotificationToSubscriptionResource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", func(app *unstructured.Unstructured) {
+		_ = unstructured.SetNestedField(app.Object, "team-a", "spec", "destination", "namespace")
+	})
+	newSubscription := func(namespace string, recipient string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       subscriptions.ResourceKind,
+			"metadata":   map[string]interface{}{"name": "team", "namespace": namespace},
+			"spec": map[string]interface{}{
+				"triggers":     []interface{}{"my-trigger"},
+				"destinations": []interface{}{map[string]interface{}{"service": "mock", "recipient": recipient}},
+			},
+		}}
+	}
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), app, newSubscription("team-a", "team-a"), newSubscription("team-b", "team-b"))
+	ctrl, api, err := newController(t, ctx, client, WithSubscriptionResources())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, services.Destination{Service: "mock", Recipient: "team-a"}).Return(nil)
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+}
+
+func TestSendsNotificationIfNamespaceSubscribed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", func(app *unstructured.Unstructured) {
+		_ = unstructured.SetNestedField(app.Object, "prod", "spec", "destination", "namespace")
+	})
+	ns := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata": map[string]interface{}{
+			"name": "prod",
+			"annotations": map[string]interface{}{
+				subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "prod-deploys",
+			},
+		},
+	}}
+
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app, ns), WithNamespaceSubscriptions())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, services.Destination{Service: "mock", Recipient: "prod-deploys"}).Return(nil)
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+}
+
+func TestRecordsDeadLetterIfDeliveryFailed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+	store := deadletter.NewConfigMapStore(k8sfake.NewSimpleClientset(), TestNamespace, 10)
+
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app), WithDeadLetterStore(store))
+	assert.NoError(t, err)
+
+	dest := services.Destination{Service: "mock", Recipient: "recipient"}
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, dest).Return(errors.New("connection refused"))
+	api.EXPECT().FormatNotification(gomock.Any(), []string{"test"}, dest).Return(&services.Notification{Message: "hello"}, nil)
+
+	err = ctrl.processApp(app, logEntry)
+	assert.NoError(t, err)
+
+	entries, err := store.List(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "test", entries[0].App)
+		assert.Equal(t, "my-trigger", entries[0].Trigger)
+		assert.Equal(t, dest, entries[0].Destination)
+		assert.Equal(t, "connection refused", entries[0].Error)
+		assert.Equal(t, deadletter.PayloadHash(services.Notification{Message: "hello"}), entries[0].PayloadHash)
+	}
+}
+
+func TestRecordsHistory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), app)
+
+	ctrl, api, err := newController(t, ctx, client, WithHistoryRecorder(history.NewRecorder(client, TestNamespace, time.Hour)))
+	assert.NoError(t, err)
+
+	dest := services.Destination{Service: "mock", Recipient: "recipient"}
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, dest).Return(nil)
+
+	err = ctrl.processApp(app, logEntry)
+	assert.NoError(t, err)
+
+	list, err := k8s.NewNotificationHistoryClient(client, TestNamespace).List(context.Background(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, list.Items, 1) {
+		assert.Equal(t, history.ResultSucceeded, list.Items[0].GetLabels()[history.ResultLabel])
+		assert.Equal(t, "test", list.Items[0].GetLabels()[history.AppLabel])
+	}
+}
+
+func TestEmitsEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient1;recipient2",
+	}))
+	recorder := record.NewFakeRecorder(10)
+
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app), WithEventRecorder(recorder))
+	assert.NoError(t, err)
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, services.Destination{Service: "mock", Recipient: "recipient1"}).Return(nil)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, services.Destination{Service: "mock", Recipient: "recipient2"}).Return(errors.New("fake error"))
+
+	err = ctrl.processApp(app, logEntry)
+	assert.NoError(t, err)
+
+	close(recorder.Events)
+	var events []string
+	for e := range recorder.Events {
+		events = append(events, e)
+	}
+	assert.ElementsMatch(t, []string{
+		"Normal NotificationSent Notification about trigger 'my-trigger' was sent to 'mock:recipient1'",
+		"Warning NotificationFailed Failed to send notification about trigger 'my-trigger' to 'mock:recipient2': fake error",
+	}, events)
+
+	retries := ctrl.pendingRetries.get(TestNamespace + "/test")
+	if assert.Len(t, retries, 1) {
+		assert.Equal(t, "recipient2", retries[0].Recipient)
+		assert.Equal(t, "fake error", retries[0].Error)
+		assert.Equal(t, 1, retries[0].Attempts)
+	}
+}
+
+func TestTriggerOutcomesMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	state := triggers.State{}
+	_ = state.SetAlreadyNotified("outcomes-trigger", triggers.ConditionResult{Key: "[0]"}, services.Destination{Service: "mock", Recipient: "recipient"}, true)
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("outcomes-trigger", "mock"): "recipient",
+		notifiedAnnotationKey: mustToJson(state),
+	}))
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+
+	api.EXPECT().RunTrigger("outcomes-trigger", gomock.Any()).Return([]triggers.ConditionResult{
+		{Key: "[0]", Triggered: true, Templates: []string{"test"}},
+		{Key: "[1]", Triggered: false, Templates: []string{"test"}},
+	}, nil)
+
+	err = ctrl.processApp(app, logEntry)
+	assert.NoError(t, err)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(triggerOutcomesCounter.WithLabelValues("outcomes-trigger", TriggerOutcomeSuppressed)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(triggerOutcomesCounter.WithLabelValues("outcomes-trigger", TriggerOutcomeNotFired)))
+	assert.Equal(t, float64(0), testutil.ToFloat64(triggerOutcomesCounter.WithLabelValues("outcomes-trigger", TriggerOutcomeFired)))
+}
+
+func TestAppFilter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, _, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme()),
+		WithAppFilter(settings.AppFilter{ExcludeProjects: []string{"sandbox"}}))
+	assert.NoError(t, err)
+	ctrl.cfg.AppFilter = settings.AppFilter{ExcludeNames: []string{"tmp-*"}}
+
+	assert.True(t, ctrl.isAppIncluded(NewApp("guestbook", WithProject("default"))))
+	assert.False(t, ctrl.isAppIncluded(NewApp("guestbook", WithProject("sandbox"))))
+	assert.False(t, ctrl.isAppIncluded(NewApp("tmp-guestbook", WithProject("default"))))
+}
+
 func TestDoesNotSendNotificationIfAnnotationPresent(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -153,6 +489,181 @@ func TestRemovesAnnotationIfNoTrigger(t *testing.T) {
 	assert.Empty(t, state)
 }
 
+func TestDoesNotSendNotificationIfAcknowledged(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	acks := triggers.Acknowledgments{}
+	acks.Acknowledge("my-trigger", "alice")
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+		acknowledgedAnnotationKey:                                  acks.String(),
+	}))
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+	assert.True(t, triggers.NewAcknowledgments(app.GetAnnotations()[acknowledgedAnnotationKey]).IsAcknowledged("my-trigger"))
+	state := triggers.NewState(app.GetAnnotations()[notifiedAnnotationKey])
+	assert.Contains(t, state, triggers.StateItemKey("my-trigger", triggers.ConditionResult{}, services.Destination{Service: "mock", Recipient: "recipient"}))
+}
+
+func TestRemovesAcknowledgmentIfNotTriggered(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	acks := triggers.Acknowledgments{}
+	acks.Acknowledge("my-trigger", "alice")
+	acks.Acknowledge("removed-trigger", "alice")
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+		acknowledgedAnnotationKey:                                  acks.String(),
+	}))
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: false}}, nil)
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+	_, ok := app.GetAnnotations()[acknowledgedAnnotationKey]
+	assert.False(t, ok)
+}
+
+func TestDoesNotSendNotificationIfMuted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+		mutedAnnotationKey: triggers.NewMute("alice", time.Hour).String(),
+	}))
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, app.GetAnnotations()[mutedAnnotationKey])
+	state := triggers.NewState(app.GetAnnotations()[notifiedAnnotationKey])
+	assert.Contains(t, state, triggers.StateItemKey("my-trigger", triggers.ConditionResult{}, services.Destination{Service: "mock", Recipient: "recipient"}))
+}
+
+func TestDoesNotSendNotificationDuringMaintenance(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+	ctrl.cfg.Maintenance = settings.Maintenance{Enabled: true}
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+	state := triggers.NewState(app.GetAnnotations()[notifiedAnnotationKey])
+	assert.Contains(t, state, triggers.StateItemKey("my-trigger", triggers.ConditionResult{}, services.Destination{Service: "mock", Recipient: "recipient"}))
+	assert.Equal(t, float64(1), testutil.ToFloat64(triggerOutcomesCounter.WithLabelValues("my-trigger", TriggerOutcomePaused)))
+}
+
+func TestDoesNotSendNotificationIfQuotaExceeded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithProject("payments"), WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+	tracker := quota.NewTracker()
+	assert.NoError(t, tracker.Configure(quota.Rules{{MaxPerHour: 1}}))
+	assert.True(t, tracker.Allow("payments", services.Destination{Service: "mock", Recipient: "other"}, "other-app"))
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app), WithQuotaTracker(tracker))
+	assert.NoError(t, err)
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+	state := triggers.NewState(app.GetAnnotations()[notifiedAnnotationKey])
+	assert.Contains(t, state, triggers.StateItemKey("my-trigger", triggers.ConditionResult{}, services.Destination{Service: "mock", Recipient: "recipient"}))
+	assert.Equal(t, float64(1), testutil.ToFloat64(quotaExceededCounter.WithLabelValues("payments", "mock")))
+}
+
+func TestRemovesExpiredMute(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+		mutedAnnotationKey: triggers.NewMute("alice", -time.Minute).String(),
+	}))
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: false}}, nil)
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+	_, ok := app.GetAnnotations()[mutedAnnotationKey]
+	assert.False(t, ok)
+}
+
+func TestPrunesStaleStateItems(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	state := triggers.State{}
+	_ = state.SetAlreadyNotified("my-trigger", triggers.ConditionResult{}, services.Destination{Service: "mock", Recipient: "recipient"}, true)
+	_ = state.SetAlreadyNotified("my-trigger", triggers.ConditionResult{}, services.Destination{Service: "mock", Recipient: "unsubscribed"}, true)
+	_ = state.SetAlreadyNotified("removed-trigger", triggers.ConditionResult{}, services.Destination{Service: "mock", Recipient: "recipient"}, true)
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+		notifiedAnnotationKey: mustToJson(state),
+	}))
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+	state = triggers.NewState(app.GetAnnotations()[notifiedAnnotationKey])
+	assert.Len(t, state, 1)
+	assert.Contains(t, state, triggers.StateItemKey("my-trigger", triggers.ConditionResult{}, services.Destination{Service: "mock", Recipient: "recipient"}))
+}
+
+func TestKeepsStateOfRecipientsWithColon(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	dest := services.Destination{Service: "mock", Recipient: "env:prod"}
+	cr := triggers.ConditionResult{Key: "[0].abc", OncePer: "rev:1", Triggered: true, Templates: []string{"test"}}
+	state := triggers.State{}
+	_ = state.SetAlreadyNotified("my-trigger", cr, dest, true)
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "env:prod",
+		notifiedAnnotationKey: mustToJson(state),
+	}))
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app))
+	assert.NoError(t, err)
+
+	// the destination is already notified, so the notification is not sent again
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{cr}, nil)
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+	state = triggers.NewState(app.GetAnnotations()[notifiedAnnotationKey])
+	assert.Contains(t, state, triggers.StateItemKey("my-trigger", cr, dest))
+}
+
 func TestUpdatedAnnotationsSavedAsPatch(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -182,13 +693,36 @@ func TestUpdatedAnnotationsSavedAsPatch(t *testing.T) {
 	case <-time.After(time.Second * 5):
 		t.Error("application was not patched")
 	case patchData := <-patchCh:
-		patch := map[string]interface{}{}
+		var patch []map[string]interface{}
 		err = json.Unmarshal(patchData, &patch)
 		assert.NoError(t, err)
-		val, ok, err := unstructured.NestedFieldNoCopy(patch, "metadata", "annotations", notifiedAnnotationKey)
-		assert.NoError(t, err)
-		assert.True(t, ok)
-		assert.Nil(t, val)
+		path := "/metadata/annotations/" + k8s.EscapeJSONPointer(notifiedAnnotationKey)
+		assert.Equal(t, []map[string]interface{}{
+			{"op": "test", "path": path, "value": mustToJson(state)},
+			{"op": "remove", "path": path},
+		}, patch)
+	}
+}
+
+func TestDryRunKeepsStateInMemory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), app)
+	ctrl, api, err := newController(t, ctx, client, WithDryRun())
+	assert.NoError(t, err)
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil).Times(2)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, services.Destination{Service: "mock", Recipient: "recipient"}).Return(nil).Times(1)
+
+	ctrl.processDryRun(TestNamespace+"/test", app.DeepCopy(), logEntry)
+	ctrl.processDryRun(TestNamespace+"/test", app.DeepCopy(), logEntry)
+
+	assert.NotEmpty(t, ctrl.dryRunState[TestNamespace+"/test"])
+	for _, action := range client.Actions() {
+		assert.Contains(t, []string{"list", "watch"}, action.GetVerb())
 	}
 }
 
@@ -316,3 +850,57 @@ func TestAnnotationIsTheSame(t *testing.T) {
 		assert.False(t, isTheSame(app1.GetAnnotations(), app2.GetAnnotations()))
 	})
 }
+
+func TestSkipsStaleNotificationsAfterStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	dest := services.Destination{Service: "mock", Recipient: "recipient"}
+	stale := NewApp("stale", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+	stale.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-2 * time.Hour)))
+	_ = unstructured.SetNestedField(stale.Object, time.Now().Add(-time.Hour).UTC().Format(time.RFC3339), "status", "operationState", "finishedAt")
+	recent := NewApp("recent", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+	recent.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-2 * time.Hour)))
+	_ = unstructured.SetNestedField(recent.Object, time.Now().UTC().Format(time.RFC3339), "status", "operationState", "finishedAt")
+
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), stale, recent), WithMaxEventAge(30*time.Minute))
+	assert.NoError(t, err)
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil).Times(2)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, dest).Return(nil).Times(1)
+
+	assert.NoError(t, ctrl.processApp(stale, logEntry))
+	assert.NoError(t, ctrl.processApp(recent, logEntry))
+
+	for _, app := range []*unstructured.Unstructured{stale, recent} {
+		state := triggers.NewState(app.GetAnnotations()[notifiedAnnotationKey])
+		assert.Contains(t, state, triggers.StateItemKey("my-trigger", triggers.ConditionResult{}, dest), app.GetName())
+	}
+	assert.True(t, ctrl.isBackfill(NewApp("other")))
+	assert.False(t, ctrl.isBackfill(stale))
+}
+
+func TestSuppressionReason(t *testing.T) {
+	acks := triggers.Acknowledgments{"my-trigger": {User: "admin"}}
+	mute := &triggers.Mute{User: "admin", Until: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC).Unix()}
+
+	outcome, reason := suppressionReason("my-trigger", acks, mute, true, true, time.Hour)
+	assert.Equal(t, TriggerOutcomeAcknowledged, outcome)
+	assert.Equal(t, "is acknowledged by 'admin'", reason)
+
+	outcome, reason = suppressionReason("other-trigger", acks, mute, true, true, time.Hour)
+	assert.Equal(t, TriggerOutcomeMuted, outcome)
+	assert.Equal(t, "is muted by 'admin' until 2021-01-01T00:00:00Z", reason)
+
+	outcome, _ = suppressionReason("other-trigger", acks, nil, true, true, time.Hour)
+	assert.Equal(t, TriggerOutcomePaused, outcome)
+
+	outcome, reason = suppressionReason("other-trigger", acks, nil, false, true, time.Hour)
+	assert.Equal(t, TriggerOutcomeStale, outcome)
+	assert.Equal(t, "is caused by the state transition older than 1h0m0s", reason)
+
+	outcome, _ = suppressionReason("other-trigger", acks, nil, false, false, time.Hour)
+	assert.Empty(t, outcome)
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/expr"
+	argocdexpr "github.com/argoproj-labs/argocd-notifications/expr/argocd"
+	"github.com/argoproj-labs/argocd-notifications/expr/syncwindows"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+	"github.com/argoproj-labs/argocd-notifications/shared/tracing"
+	"github.com/argoproj-labs/argocd-notifications/shared/unsubscribe"
+)
+
+const (
+	defaultDeliveryParallelism = 5
+	defaultDeliveryTimeout     = 5 * time.Minute
+)
+
+// errDeliveryAborted is returned if the delivery is not completed within the shutdown grace period
+var errDeliveryAborted = errors.New("delivery aborted because of the controller shutdown")
+
+// delivery is the notification about the triggered condition that is sent to one destination
+type delivery struct {
+	trigger  string
+	result   triggers.ConditionResult
+	dest     services.Destination
+	vars     map[string]interface{}
+	err      error
+	duration time.Duration
+}
+
+// deliveryTimeoutError is returned if the notification service does not respond within the delivery timeout.
+// It implements net.Error so the error is classified as timeout.
+type deliveryTimeoutError struct {
+	timeout time.Duration
+}
+
+func (e *deliveryTimeoutError) Error() string {
+	return fmt.Sprintf("delivery timed out after %v", e.timeout)
+}
+
+func (e *deliveryTimeoutError) Timeout() bool {
+	return true
+}
+
+func (e *deliveryTimeoutError) Temporary() bool {
+	return true
+}
+
+// isOutcomeUnknown returns true if the controller stopped waiting for the notification service, which might still
+// deliver the notification
+func isOutcomeUnknown(err error) bool {
+	var timeoutErr *deliveryTimeoutError
+	return errors.As(err, &timeoutErr) || errors.Is(err, errDeliveryAborted)
+}
+
+// sendAll sends notifications using the bounded number of goroutines, so the slow destination does not delay
+// notifications sent to other destinations of the application
+func (c *notificationController) sendAll(ctx context.Context, app *unstructured.Unstructured, deliveries []*delivery) {
+	parallelism := c.deliveryParallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		d := deliveries[i]
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			c.send(ctx, app, d)
+		}()
+	}
+	wg.Wait()
+}
+
+func (c *notificationController) send(ctx context.Context, app *unstructured.Unstructured, d *delivery) {
+	sendCtx, sendSpan := tracing.StartSpan(ctx, "Send")
+	sendSpan.SetAttribute("trigger", d.trigger)
+	sendSpan.SetAttribute("service", d.dest.Service)
+	sendSpan.SetAttribute("recipient", d.dest.Recipient)
+	d.vars = expr.Spawn(app, newTracedArgoCDService(sendCtx, c.cfg.ArgoCDService), map[string]interface{}{
+		"app":         app.Object,
+		"context":     legacy.InjectLegacyVar(c.cfg.Context, d.dest.Service),
+		"syncwindows": syncwindows.NewExprs(app, c.getAppProj(app)),
+	})
+	if c.argocdAPI != nil {
+		d.vars["argocd"] = argocdexpr.NewExprs(sendCtx, c.argocdAPI, app)
+	}
+	// other services don't deliver notifications to the recipient mailbox, so the link would let anyone who can read
+	// the channel unsubscribe it
+	if u := c.cfg.EmailUnsubscribe; u != nil && c.cfg.ServiceTypes[d.dest.Service] == "email" {
+		link := unsubscribe.Link{
+			App: app.GetName(), Trigger: d.trigger, Service: d.dest.Service, Recipient: d.dest.Recipient,
+			Expires: time.Now().Add(u.GetLinkExpiration()).Unix(),
+		}
+		d.vars["unsubscribeURL"] = link.URL(u.URL, []byte(u.Key))
+	}
+
+	start := time.Now()
+	d.err = c.sendWithTimeout(d)
+	d.duration = time.Since(start)
+	sendSpan.SetError(d.err)
+	sendSpan.Finish()
+}
+
+// sendWithTimeout returns the timeout error if the notification is not sent within the delivery timeout. The
+// delivery keeps running in the background until the service client gives up.
+func (c *notificationController) sendWithTimeout(d *delivery) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("recovered from panic: %v", r)
+			}
+		}()
+		done <- c.cfg.API.Send(d.vars, d.result.Templates, d.dest)
+	}()
+	var timeout <-chan time.Time
+	if c.deliveryTimeout > 0 {
+		timer := time.NewTimer(c.deliveryTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-timeout:
+		return &deliveryTimeoutError{timeout: c.deliveryTimeout}
+	case <-c.abortDeliveries:
+		return errDeliveryAborted
+	}
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestSendsNotificationsInParallel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "slow"): "recipient",
+		subscriptions.SubscribeAnnotationKey("my-trigger", "fast"): "recipient",
+	}))
+
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app), WithDeliveryParallelism(2))
+	assert.NoError(t, err)
+
+	fastSent := make(chan struct{})
+	slow := services.Destination{Service: "slow", Recipient: "recipient"}
+	fast := services.Destination{Service: "fast", Recipient: "recipient"}
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, slow).DoAndReturn(func(_ map[string]interface{}, _ []string, _ services.Destination) error {
+		// the slow destination responds only after the fast one received the notification
+		select {
+		case <-fastSent:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("fast destination is blocked by the slow one")
+		}
+	})
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, fast).DoAndReturn(func(_ map[string]interface{}, _ []string, _ services.Destination) error {
+		close(fastSent)
+		return nil
+	})
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+	state := triggers.NewState(app.GetAnnotations()[notifiedAnnotationKey])
+	assert.Contains(t, state, triggers.StateItemKey("my-trigger", triggers.ConditionResult{}, fast))
+	assert.Contains(t, state, triggers.StateItemKey("my-trigger", triggers.ConditionResult{}, slow))
+}
+
+func TestDeliveryTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "slow"): "recipient",
+	}))
+
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app), WithDeliveryTimeout(50*time.Millisecond))
+	assert.NoError(t, err)
+
+	unblock := make(chan struct{})
+	defer close(unblock)
+	slow := services.Destination{Service: "slow", Recipient: "recipient"}
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, slow).DoAndReturn(func(_ map[string]interface{}, _ []string, _ services.Destination) error {
+		<-unblock
+		return nil
+	})
+
+	err = ctrl.processApp(app, logEntry)
+
+	assert.NoError(t, err)
+	state := triggers.NewState(app.GetAnnotations()[notifiedAnnotationKey])
+	// timed out delivery might still complete, so it is not retried
+	assert.Contains(t, state, triggers.StateItemKey("my-trigger", triggers.ConditionResult{}, slow))
+}
+
+func TestDeliveryTimeoutErrorClass(t *testing.T) {
+	assert.Equal(t, services.ErrorClassTimeout, services.ErrorClass(&deliveryTimeoutError{timeout: time.Second}))
+}
+
+func TestShutdownAbortsDeliveries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := NewApp("test", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "shutdown"): "recipient",
+	}))
+
+	ctrl, api, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app), WithShutdownGracePeriod(50*time.Millisecond))
+	assert.NoError(t, err)
+
+	sending := make(chan struct{})
+	unblock := make(chan struct{})
+	defer close(unblock)
+	dest := services.Destination{Service: "shutdown", Recipient: "recipient"}
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, dest).DoAndReturn(func(_ map[string]interface{}, _ []string, _ services.Destination) error {
+		close(sending)
+		<-unblock
+		return nil
+	})
+
+	runCtx, stop := context.WithCancel(context.TODO())
+	stopped := make(chan struct{})
+	go func() {
+		ctrl.Run(runCtx, 1)
+		close(stopped)
+	}()
+	<-sending
+	stop()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "controller did not stop after the grace period")
+	}
+	// aborted delivery is recorded as not confirmed but is not retried after the restart
+	assert.Equal(t, float64(1), testutil.ToFloat64(deliveriesCounter.WithLabelValues("my-trigger", "shutdown", "false")))
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+const lastAppliedConfigAnnotationKey = "kubectl.kubernetes.io/last-applied-configuration"
+
+// DefaultAppStripFields are application fields removed in addition to managed fields and the last applied
+// configuration. Fields are not removed by default since custom templates might reference any application field, e.g.
+// iterate over status.resources
+var DefaultAppStripFields []string
+
+// newStripFunc returns function that removes managed fields, the last applied configuration and the specified
+// dot separated fields from the object before it is stored in the informer cache
+func newStripFunc(fields []string) func(obj *unstructured.Unstructured) {
+	var paths [][]string
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			paths = append(paths, strings.Split(field, "."))
+		}
+	}
+	return func(obj *unstructured.Unstructured) {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+		if annotations := obj.GetAnnotations(); annotations != nil {
+			if _, ok := annotations[lastAppliedConfigAnnotationKey]; ok {
+				delete(annotations, lastAppliedConfigAnnotationKey)
+				obj.SetAnnotations(annotations)
+			}
+		}
+		for _, path := range paths {
+			unstructured.RemoveNestedField(obj.Object, path...)
+		}
+	}
+}
+
+// cacheStats tracks the number and the approximate JSON size of objects stored in the informer cache
+type cacheStats struct {
+	lock    sync.Mutex
+	sizes   map[string]int
+	pending map[string]int
+	observe func(objects int, bytes int)
+}
+
+func newCacheStats(observe func(objects int, bytes int)) *cacheStats {
+	return &cacheStats{sizes: map[string]int{}, observe: observe}
+}
+
+// objectSize returns the approximate JSON size of the object. The object is walked rather than marshaled, so the
+// metric does not allocate the copy of every cached object
+func objectSize(obj *unstructured.Unstructured) int {
+	return valueSize(obj.Object)
+}
+
+func valueSize(val interface{}) int {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		// braces, and quotes, colon and comma of every key
+		size := 2
+		for k, item := range v {
+			size += len(k) + 4 + valueSize(item)
+		}
+		return size
+	case []interface{}:
+		size := 2
+		for _, item := range v {
+			size += valueSize(item) + 1
+		}
+		return size
+	case string:
+		return len(v) + 2
+	case bool:
+		return 5
+	case nil:
+		return 4
+	default:
+		return 8
+	}
+}
+
+func (s *cacheStats) report() {
+	bytes := 0
+	for _, size := range s.sizes {
+		bytes += size
+	}
+	s.observe(len(s.sizes), bytes)
+}
+
+// listed records the page of the list response; the cache content is replaced once the last page is received
+func (s *cacheStats) listed(list *unstructured.UnstructuredList, firstPage bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if firstPage || s.pending == nil {
+		s.pending = map[string]int{}
+	}
+	for i := range list.Items {
+		s.pending[objectKey(&list.Items[i])] = objectSize(&list.Items[i])
+	}
+	if list.GetContinue() == "" {
+		s.sizes = s.pending
+		s.pending = nil
+		s.report()
+	}
+}
+
+func (s *cacheStats) watched(eventType watch.EventType, obj *unstructured.Unstructured) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	switch eventType {
+	case watch.Added, watch.Modified:
+		s.sizes[objectKey(obj)] = objectSize(obj)
+	case watch.Deleted:
+		delete(s.sizes, objectKey(obj))
+	default:
+		return
+	}
+	s.report()
+}
+
+func objectKey(obj *unstructured.Unstructured) string {
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// newStrippedInformer returns informer that strips objects before storing them in the cache and reports cache size
+// to the specified stats
+func newStrippedInformer(resClient dynamic.ResourceInterface, selector string, resyncPeriod time.Duration, strip func(obj *unstructured.Unstructured), stats *cacheStats) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				options.LabelSelector = selector
+				list, err := resClient.List(context.Background(), options)
+				if err != nil {
+					return nil, err
+				}
+				for i := range list.Items {
+					strip(&list.Items[i])
+				}
+				stats.listed(list, options.Continue == "")
+				return list, nil
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				options.LabelSelector = selector
+				w, err := resClient.Watch(context.Background(), options)
+				if err != nil {
+					return nil, err
+				}
+				return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+					if obj, ok := in.Object.(*unstructured.Unstructured); ok {
+						strip(obj)
+						stats.watched(in.Type, obj)
+					}
+					return in, true
+				}), nil
+			},
+		},
+		&unstructured.Unstructured{},
+		resyncPeriod,
+		cache.Indexers{},
+	)
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/mocks"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func withLargeStatus(app *unstructured.Unstructured) {
+	app.SetAnnotations(map[string]string{lastAppliedConfigAnnotationKey: "{}", "foo": "bar"})
+	_ = unstructured.SetNestedField(app.Object, []interface{}{map[string]interface{}{"manager": "argocd"}}, "metadata", "managedFields")
+	_ = unstructured.SetNestedSlice(app.Object, []interface{}{map[string]interface{}{"kind": "Deployment"}}, "status", "resources")
+	_ = unstructured.SetNestedField(app.Object, "Healthy", "status", "health", "status")
+}
+
+func TestStripFunc(t *testing.T) {
+	app := NewApp("test", withLargeStatus)
+
+	newStripFunc([]string{"status.resources", " "})(app)
+
+	assert.Equal(t, map[string]string{"foo": "bar"}, app.GetAnnotations())
+	_, ok, _ := unstructured.NestedFieldNoCopy(app.Object, "metadata", "managedFields")
+	assert.False(t, ok)
+	_, ok, _ = unstructured.NestedFieldNoCopy(app.Object, "status", "resources")
+	assert.False(t, ok)
+	health, _, _ := unstructured.NestedString(app.Object, "status", "health", "status")
+	assert.Equal(t, "Healthy", health)
+}
+
+func TestObjectSize(t *testing.T) {
+	app := NewApp("test", withLargeStatus)
+	data, err := json.Marshal(app.Object)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.InDelta(t, len(data), objectSize(app), float64(len(data))/5)
+}
+
+func TestCacheStats(t *testing.T) {
+	var objects, bytes int
+	stats := newCacheStats(func(o int, b int) {
+		objects, bytes = o, b
+	})
+	first := NewApp("first")
+	second := NewApp("second")
+
+	page := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{*first}}
+	page.SetContinue("token")
+	stats.listed(page, true)
+	assert.Equal(t, 0, objects)
+
+	stats.listed(&unstructured.UnstructuredList{Items: []unstructured.Unstructured{*second}}, false)
+	assert.Equal(t, 2, objects)
+	assert.Equal(t, objectSize(first)+objectSize(second), bytes)
+
+	stats.watched(watch.Deleted, first)
+	assert.Equal(t, 1, objects)
+	assert.Equal(t, objectSize(second), bytes)
+}
+
+func TestAppInformerStripsFields(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	cfg := settings.Config{API: mocks.NewMockAPI(mockCtrl)}
+	registry := NewMetricsRegistry()
+	c, err := NewController(fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("test", withLargeStatus)), TestNamespace, cfg, nil, registry,
+		WithAppStripFields([]string{"status.resources"}))
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, c.Init(ctx)) {
+		return
+	}
+	obj, exists, err := c.(*notificationController).getApp(TestNamespace + "/test")
+	if !assert.NoError(t, err) || !assert.True(t, exists) {
+		return
+	}
+	app := obj.(*unstructured.Unstructured)
+	_, ok, _ := unstructured.NestedFieldNoCopy(app.Object, "status", "resources")
+	assert.False(t, ok)
+	assert.NotContains(t, app.GetAnnotations(), lastAppliedConfigAnnotationKey)
+	assert.Equal(t, float64(1), testutil.ToFloat64(registry.informerCacheObjectsGauge.WithLabelValues("applications", "")))
+}
@@ -2,8 +2,31 @@ package controller
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+const (
+	// TriggerOutcomeFired means the trigger condition returned true and notification was sent at least to one recipient
+	TriggerOutcomeFired = "fired"
+	// TriggerOutcomeNotFired means the trigger condition returned false
+	TriggerOutcomeNotFired = "not_fired"
+	// TriggerOutcomeSuppressed means the trigger condition returned true but all recipients have already been notified (e.g. because of oncePer)
+	TriggerOutcomeSuppressed = "suppressed"
+	// TriggerOutcomeAcknowledged means the trigger condition returned true but notifications were not sent because the trigger is acknowledged
+	TriggerOutcomeAcknowledged = "acknowledged"
+	// TriggerOutcomeMuted means the trigger condition returned true but notifications were not sent because the application or project is muted
+	TriggerOutcomeMuted = "muted"
+	// TriggerOutcomePaused means the trigger condition returned true but notifications were not sent because of the maintenance mode
+	TriggerOutcomePaused = "paused"
+	// TriggerOutcomeStale means the trigger condition returned true but notifications were not sent because the state transition happened before the controller start
+	TriggerOutcomeStale = "stale"
+	// TriggerOutcomeError means the trigger condition could not be evaluated
+	TriggerOutcomeError = "error"
 )
 
 var (
@@ -22,16 +45,234 @@ var (
 		},
 		[]string{"name", "triggered"},
 	)
+
+	deadLettersCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_dead_letters_total",
+			Help: "Number of notifications recorded as dead letters.",
+		},
+		[]string{"trigger", "service"},
+	)
+
+	deliveryDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "argocd_notifications_delivery_duration_seconds",
+			Help:    "Duration of notification delivery including template rendering and retries.",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		},
+		[]string{"trigger", "service", "succeeded"},
+	)
+
+	deliveryErrorsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_delivery_errors_total",
+			Help: "Number of failed notification deliveries by error class.",
+		},
+		[]string{"trigger", "service", "class"},
+	)
+
+	templateRenderErrorsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_template_render_errors_total",
+			Help: "Number of notification templates that could not be rendered.",
+		},
+		[]string{"template", "reason"},
+	)
+
+	triggerOutcomesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_trigger_outcomes_total",
+			Help: "Number of trigger condition evaluations by outcome.",
+		},
+		[]string{"name", "outcome"},
+	)
+
+	triggerLastTriggeredGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "argocd_notifications_trigger_last_triggered_timestamp_seconds",
+			Help: "Unix timestamp of the last time the trigger condition returned true.",
+		},
+		[]string{"name"},
+	)
+
+	stateSizeHistogram = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "argocd_notifications_state_size_bytes",
+			Help:    "Size of the notification state annotation of processed applications.",
+			Buckets: prometheus.ExponentialBuckets(256, 2, 10),
+		},
+	)
+
+	stateCompactedItemsCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_state_compacted_items_total",
+			Help: "Number of notification state items removed because of the state limits.",
+		},
+	)
+
+	quotaExceededCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_quota_exceeded_total",
+			Help: "Number of notifications that were not sent because of the notification quota.",
+		},
+		[]string{"project", "service"},
+	)
+
+	skippedUpdatesCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_app_updates_skipped_total",
+			Help: "Number of application updates skipped because they don't change fields referenced by triggers.",
+		},
+	)
+
+	informerCacheObjectsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "argocd_notifications_informer_cache_objects",
+			Help: "Number of objects stored in the informer cache.",
+		},
+		[]string{"resource", "selector"},
+	)
+
+	informerCacheBytesGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "argocd_notifications_informer_cache_bytes",
+			Help: "Approximate JSON size of objects stored in the informer cache.",
+		},
+		[]string{"resource", "selector"},
+	)
+
+	maintenanceGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "argocd_notifications_maintenance_mode",
+			Help: "Set to 1 if notification deliveries are paused by the maintenance mode.",
+		},
+	)
+
+	configDeprecationsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "argocd_notifications_config_deprecations",
+			Help: "Set to 1 for every deprecated key used by the notifications config.",
+		},
+		[]string{"api_version", "source", "key"},
+	)
+
+	queueDepthGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "argocd_notifications_queue_depth",
+			Help: "Number of applications waiting to be processed.",
+		},
+	)
 )
 
+var (
+	workqueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "workqueue_depth",
+		Help: "Current depth of workqueue.",
+	}, []string{"name"})
+
+	workqueueAdds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "workqueue_adds_total",
+		Help: "Total number of adds handled by workqueue.",
+	}, []string{"name"})
+
+	workqueueLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "workqueue_queue_duration_seconds",
+		Help:    "How long in seconds an item stays in workqueue before being requested.",
+		Buckets: prometheus.ExponentialBuckets(10e-9, 10, 10),
+	}, []string{"name"})
+
+	workqueueWorkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "workqueue_work_duration_seconds",
+		Help:    "How long in seconds processing an item from workqueue takes.",
+		Buckets: prometheus.ExponentialBuckets(10e-9, 10, 10),
+	}, []string{"name"})
+
+	workqueueUnfinishedWork = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "workqueue_unfinished_work_seconds",
+		Help: "How many seconds of work has done that is in progress and hasn't been observed by work_duration.",
+	}, []string{"name"})
+
+	workqueueLongestRunningProcessor = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "workqueue_longest_running_processor_seconds",
+		Help: "How many seconds has the longest running processor for workqueue been running.",
+	}, []string{"name"})
+
+	workqueueRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "workqueue_retries_total",
+		Help: "Total number of retries handled by workqueue.",
+	}, []string{"name"})
+)
+
+// workqueueMetricsProvider exposes metrics of the client-go work queues
+type workqueueMetricsProvider struct{}
+
+func (workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return workqueueDepth.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return workqueueAdds.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return workqueueLatency.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return workqueueWorkDuration.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workqueueUnfinishedWork.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workqueueLongestRunningProcessor.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return workqueueRetries.WithLabelValues(name)
+}
+
+func init() {
+	workqueue.SetProvider(workqueueMetricsProvider{})
+}
+
 func NewMetricsRegistry() *controllerRegistry {
 	registry := &controllerRegistry{
 		Registry:                  prometheus.NewRegistry(),
 		deliveriesCounter:         deliveriesCounter,
 		triggerEvaluationsCounter: triggerEvaluationsCounter,
+		deadLettersCounter:        deadLettersCounter,
+		deliveryDurationHistogram: deliveryDurationHistogram,
+		deliveryErrorsCounter:     deliveryErrorsCounter,
+		templateRenderErrors:      templateRenderErrorsCounter,
+		triggerOutcomesCounter:    triggerOutcomesCounter,
+		triggerLastTriggeredGauge: triggerLastTriggeredGauge,
+		queueDepthGauge:           queueDepthGauge,
+		maintenanceGauge:          maintenanceGauge,
+		configDeprecationsGauge:   configDeprecationsGauge,
+		informerCacheObjectsGauge: informerCacheObjectsGauge,
+		stateSizeHistogram:        stateSizeHistogram,
+		stateCompactedItems:       stateCompactedItemsCounter,
+		informerCacheBytesGauge:   informerCacheBytesGauge,
+		quotaExceededCounter:      quotaExceededCounter,
+		skippedUpdatesCounter:     skippedUpdatesCounter,
 	}
 	registry.MustRegister(deliveriesCounter)
 	registry.MustRegister(triggerEvaluationsCounter)
+	registry.MustRegister(deadLettersCounter)
+	registry.MustRegister(deliveryDurationHistogram)
+	registry.MustRegister(deliveryErrorsCounter)
+	registry.MustRegister(templateRenderErrorsCounter)
+	registry.MustRegister(triggerOutcomesCounter)
+	registry.MustRegister(triggerLastTriggeredGauge)
+	registry.MustRegister(queueDepthGauge, maintenanceGauge, configDeprecationsGauge)
+	registry.MustRegister(informerCacheObjectsGauge, informerCacheBytesGauge)
+	registry.MustRegister(stateSizeHistogram, stateCompactedItemsCounter)
+	registry.MustRegister(quotaExceededCounter, skippedUpdatesCounter)
+	registry.MustRegister(workqueueDepth, workqueueAdds, workqueueLatency, workqueueWorkDuration,
+		workqueueUnfinishedWork, workqueueLongestRunningProcessor, workqueueRetries)
 	return registry
 }
 
@@ -39,6 +280,21 @@ type controllerRegistry struct {
 	*prometheus.Registry
 	deliveriesCounter         *prometheus.CounterVec
 	triggerEvaluationsCounter *prometheus.CounterVec
+	deadLettersCounter        *prometheus.CounterVec
+	deliveryDurationHistogram *prometheus.HistogramVec
+	deliveryErrorsCounter     *prometheus.CounterVec
+	templateRenderErrors      *prometheus.CounterVec
+	triggerOutcomesCounter    *prometheus.CounterVec
+	triggerLastTriggeredGauge *prometheus.GaugeVec
+	queueDepthGauge           prometheus.Gauge
+	maintenanceGauge          prometheus.Gauge
+	configDeprecationsGauge   *prometheus.GaugeVec
+	informerCacheObjectsGauge *prometheus.GaugeVec
+	informerCacheBytesGauge   *prometheus.GaugeVec
+	stateSizeHistogram        prometheus.Histogram
+	stateCompactedItems       prometheus.Counter
+	quotaExceededCounter      *prometheus.CounterVec
+	skippedUpdatesCounter     prometheus.Counter
 }
 
 func (r *controllerRegistry) IncDeliveriesCounter(trigger string, service string, succeeded bool) {
@@ -48,3 +304,68 @@ func (r *controllerRegistry) IncDeliveriesCounter(trigger string, service string
 func (r *controllerRegistry) IncTriggerEvaluationsCounter(name string, triggered bool) {
 	r.triggerEvaluationsCounter.WithLabelValues(name, strconv.FormatBool(triggered)).Inc()
 }
+
+func (r *controllerRegistry) IncDeadLettersCounter(trigger string, service string) {
+	r.deadLettersCounter.WithLabelValues(trigger, service).Inc()
+}
+
+func (r *controllerRegistry) ObserveDeliveryDuration(trigger string, service string, succeeded bool, duration time.Duration) {
+	r.deliveryDurationHistogram.WithLabelValues(trigger, service, strconv.FormatBool(succeeded)).Observe(duration.Seconds())
+}
+
+func (r *controllerRegistry) IncDeliveryErrorsCounter(trigger string, service string, class string) {
+	r.deliveryErrorsCounter.WithLabelValues(trigger, service, class).Inc()
+}
+
+func (r *controllerRegistry) IncTemplateRenderErrorsCounter(template string, reason string) {
+	r.templateRenderErrors.WithLabelValues(template, reason).Inc()
+}
+
+func (r *controllerRegistry) IncTriggerOutcomesCounter(name string, outcome string) {
+	r.triggerOutcomesCounter.WithLabelValues(name, outcome).Inc()
+}
+
+func (r *controllerRegistry) SetTriggerLastTriggered(name string, t time.Time) {
+	r.triggerLastTriggeredGauge.WithLabelValues(name).Set(float64(t.Unix()))
+}
+
+func (r *controllerRegistry) SetQueueDepth(depth int) {
+	r.queueDepthGauge.Set(float64(depth))
+}
+
+func (r *controllerRegistry) SetMaintenance(active bool) {
+	if active {
+		r.maintenanceGauge.Set(1)
+	} else {
+		r.maintenanceGauge.Set(0)
+	}
+}
+
+// SetConfigDeprecations replaces the reported deprecations with deprecations of the loaded config
+func (r *controllerRegistry) SetConfigDeprecations(apiVersion string, deprecations []settings.Deprecation) {
+	r.configDeprecationsGauge.Reset()
+	for _, d := range deprecations {
+		r.configDeprecationsGauge.WithLabelValues(apiVersion, d.Source, d.Key).Set(1)
+	}
+}
+
+func (r *controllerRegistry) SetInformerCacheSize(resource string, selector string, objects int, bytes int) {
+	r.informerCacheObjectsGauge.WithLabelValues(resource, selector).Set(float64(objects))
+	r.informerCacheBytesGauge.WithLabelValues(resource, selector).Set(float64(bytes))
+}
+
+func (r *controllerRegistry) ObserveStateSize(bytes int) {
+	r.stateSizeHistogram.Observe(float64(bytes))
+}
+
+func (r *controllerRegistry) AddStateCompactedItems(count int) {
+	r.stateCompactedItems.Add(float64(count))
+}
+
+func (r *controllerRegistry) IncQuotaExceededCounter(project string, service string) {
+	r.quotaExceededCounter.WithLabelValues(project, service).Inc()
+}
+
+func (r *controllerRegistry) IncSkippedUpdatesCounter() {
+	r.skippedUpdatesCounter.Inc()
+}
@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+)
+
+type priorityQueueItem struct {
+	key interface{}
+	// rank is the time the item was added minus its priority scaled by the aging interval, so items with lower
+	// rank are returned first. Aging is disabled if rank is the negated priority
+	rank int64
+	seq  uint64
+}
+
+type priorityHeap []*priorityQueueItem
+
+func (h priorityHeap) Len() int {
+	return len(h)
+}
+
+func (h priorityHeap) Less(i, j int) bool {
+	if h[i].rank != h[j].rank {
+		return h[i].rank < h[j].rank
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h priorityHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *priorityHeap) Push(x interface{}) {
+	*h = append(*h, x.(*priorityQueueItem))
+}
+
+func (h *priorityHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// newPriorityQueue returns the rate limiting queue that returns items with the higher priority first and items
+// with the same priority in the order they were added. Waiting items gain one priority level per aging interval, so
+// items with the low priority are not starved by the stream of higher priority items; zero aging makes priorities
+// strict. Like the client-go work queue, the item is never processed by several processors concurrently and the item
+// added while it is processed is requeued once it is done.
+func newPriorityQueue(name string, rateLimiter workqueue.RateLimiter, priority func(key interface{}) int, aging time.Duration) *priorityQueue {
+	metrics := workqueueMetricsProvider{}
+	q := &priorityQueue{
+		rateLimiter:  rateLimiter,
+		priority:     priority,
+		aging:        aging,
+		now:          time.Now,
+		dirty:        map[interface{}]struct{}{},
+		processing:   map[interface{}]struct{}{},
+		addedAt:      map[interface{}]time.Time{},
+		startedAt:    map[interface{}]time.Time{},
+		depth:        metrics.NewDepthMetric(name),
+		adds:         metrics.NewAddsMetric(name),
+		latency:      metrics.NewLatencyMetric(name),
+		workDuration: metrics.NewWorkDurationMetric(name),
+		retries:      metrics.NewRetriesMetric(name),
+	}
+	q.cond = sync.NewCond(&q.lock)
+	return q
+}
+
+type priorityQueue struct {
+	rateLimiter workqueue.RateLimiter
+	priority    func(key interface{}) int
+	aging       time.Duration
+	now         func() time.Time
+
+	lock         sync.Mutex
+	cond         *sync.Cond
+	items        priorityHeap
+	seq          uint64
+	dirty        map[interface{}]struct{}
+	processing   map[interface{}]struct{}
+	addedAt      map[interface{}]time.Time
+	startedAt    map[interface{}]time.Time
+	shuttingDown bool
+
+	depth        workqueue.GaugeMetric
+	adds         workqueue.CounterMetric
+	latency      workqueue.HistogramMetric
+	workDuration workqueue.HistogramMetric
+	retries      workqueue.CounterMetric
+}
+
+func (q *priorityQueue) Add(key interface{}) {
+	// priority is computed outside of the lock since it reads informer caches
+	priority := q.priority(key)
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.shuttingDown {
+		return
+	}
+	if _, ok := q.dirty[key]; ok {
+		return
+	}
+	q.adds.Inc()
+	q.dirty[key] = struct{}{}
+	q.addedAt[key] = q.now()
+	if _, ok := q.processing[key]; ok {
+		return
+	}
+	q.push(key, priority)
+}
+
+func (q *priorityQueue) push(key interface{}, priority int) {
+	q.seq++
+	rank := -int64(priority)
+	if q.aging > 0 {
+		// the requeued item keeps the time it was added, so the time spent waiting for the processor counts too
+		addedAt, ok := q.addedAt[key]
+		if !ok {
+			addedAt = q.now()
+		}
+		rank = addedAt.UnixNano() - int64(priority)*q.aging.Nanoseconds()
+	}
+	heap.Push(&q.items, &priorityQueueItem{key: key, rank: rank, seq: q.seq})
+	q.depth.Inc()
+	q.cond.Signal()
+}
+
+func (q *priorityQueue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.items)
+}
+
+func (q *priorityQueue) Get() (interface{}, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for len(q.items) == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+	// unlike the client-go work queue, remaining items are not returned after the shutdown, so the controller stops
+	// taking new work right away
+	if q.shuttingDown {
+		return nil, true
+	}
+	item := heap.Pop(&q.items).(*priorityQueueItem)
+	q.depth.Dec()
+	now := q.now()
+	if addedAt, ok := q.addedAt[item.key]; ok {
+		q.latency.Observe(now.Sub(addedAt).Seconds())
+		delete(q.addedAt, item.key)
+	}
+	q.startedAt[item.key] = now
+	q.processing[item.key] = struct{}{}
+	delete(q.dirty, item.key)
+	return item.key, false
+}
+
+func (q *priorityQueue) Done(key interface{}) {
+	q.lock.Lock()
+	if startedAt, ok := q.startedAt[key]; ok {
+		q.workDuration.Observe(time.Since(startedAt).Seconds())
+		delete(q.startedAt, key)
+	}
+	delete(q.processing, key)
+	_, requeue := q.dirty[key]
+	q.lock.Unlock()
+	if requeue {
+		priority := q.priority(key)
+		q.lock.Lock()
+		defer q.lock.Unlock()
+		if _, processing := q.processing[key]; !processing && !q.shuttingDown {
+			q.push(key, priority)
+		}
+	}
+}
+
+func (q *priorityQueue) ShutDown() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+func (q *priorityQueue) ShuttingDown() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.shuttingDown
+}
+
+func (q *priorityQueue) AddAfter(key interface{}, duration time.Duration) {
+	if q.ShuttingDown() {
+		return
+	}
+	if duration <= 0 {
+		q.Add(key)
+		return
+	}
+	time.AfterFunc(duration, func() {
+		q.Add(key)
+	})
+}
+
+func (q *priorityQueue) AddRateLimited(key interface{}) {
+	q.retries.Inc()
+	q.AddAfter(key, q.rateLimiter.When(key))
+}
+
+func (q *priorityQueue) Forget(key interface{}) {
+	q.rateLimiter.Forget(key)
+}
+
+func (q *priorityQueue) NumRequeues(key interface{}) int {
+	return q.rateLimiter.NumRequeues(key)
+}
+
+// getAppPriority returns the priority of the application configured by the ConfigMap for the application project.
+// The priority annotation of the application might override it, but only up to the configured limit.
+func (c *notificationController) getAppPriority(key interface{}) int {
+	obj, exists, err := c.getApp(key.(string))
+	if err != nil || !exists {
+		return 0
+	}
+	app, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return 0
+	}
+	project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+	return c.cfg.Priorities.AppPriority(project, app.GetAnnotations()[subscriptions.PriorityAnnotationKey])
+}
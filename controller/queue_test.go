@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func newTestPriorityQueue(priorities map[string]int, aging time.Duration) *priorityQueue {
+	return newPriorityQueue("test", workqueue.DefaultControllerRateLimiter(), func(key interface{}) int {
+		return priorities[key.(string)]
+	}, aging)
+}
+
+func getKeys(q *priorityQueue) []string {
+	var keys []string
+	for q.Len() > 0 {
+		key, _ := q.Get()
+		keys = append(keys, key.(string))
+		q.Done(key)
+	}
+	return keys
+}
+
+func TestPriorityQueue_Order(t *testing.T) {
+	q := newTestPriorityQueue(map[string]int{"prod-1": 10, "prod-2": 10, "staging": 5}, 0)
+	for _, key := range []string{"dev-1", "prod-1", "dev-2", "staging", "prod-2"} {
+		q.Add(key)
+	}
+	q.Add("dev-1")
+	assert.Equal(t, 5, q.Len())
+
+	assert.Equal(t, []string{"prod-1", "prod-2", "staging", "dev-1", "dev-2"}, getKeys(q))
+}
+
+func TestPriorityQueue_Aging(t *testing.T) {
+	q := newTestPriorityQueue(map[string]int{"staging": 3, "prod": 10}, time.Second)
+	now := time.Now()
+	q.now = func() time.Time {
+		return now
+	}
+	q.Add("dev")
+	now = now.Add(5 * time.Second)
+	q.Add("staging")
+	q.Add("prod")
+
+	// dev waited for 5 seconds and gained 5 priority levels, so it is ahead of staging but not of prod
+	assert.Equal(t, []string{"prod", "dev", "staging"}, getKeys(q))
+}
+
+func TestPriorityQueue_RequeueProcessedItem(t *testing.T) {
+	q := newTestPriorityQueue(nil, 0)
+	q.Add("app")
+	key, _ := q.Get()
+
+	q.Add("app")
+	assert.Equal(t, 0, q.Len())
+
+	q.Done(key)
+	assert.Equal(t, 1, q.Len())
+}
+
+func TestPriorityQueue_ShutDown(t *testing.T) {
+	q := newTestPriorityQueue(nil, 0)
+	q.ShutDown()
+	q.Add("app")
+
+	_, shutdown := q.Get()
+
+	assert.True(t, shutdown)
+	assert.True(t, q.ShuttingDown())
+}
+
+func TestGetAppPriority(t *testing.T) {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &unstructured.Unstructured{}, time.Minute, cache.Indexers{})
+	for _, app := range []*unstructured.Unstructured{
+		NewApp("prod", WithProject("production")),
+		NewApp("greedy", WithProject("default"), WithAnnotations(map[string]string{subscriptions.PriorityAnnotationKey: "1000"})),
+		NewApp("idle", WithProject("production"), WithAnnotations(map[string]string{subscriptions.PriorityAnnotationKey: "-1"})),
+	} {
+		assert.NoError(t, informer.GetIndexer().Add(app))
+	}
+	ctrl := &notificationController{
+		appInformers: []cache.SharedIndexInformer{informer},
+		cfg:          settings.Config{Priorities: settings.Priorities{Projects: map[string]int{"production": 100}, MaxAppPriority: 10}},
+	}
+
+	assert.Equal(t, 100, ctrl.getAppPriority(TestNamespace+"/prod"))
+	assert.Equal(t, 10, ctrl.getAppPriority(TestNamespace+"/greedy"))
+	assert.Equal(t, -1, ctrl.getAppPriority(TestNamespace+"/idle"))
+	assert.Equal(t, 0, ctrl.getAppPriority(TestNamespace+"/missing"))
+}
@@ -0,0 +1,222 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimeutil "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/argoproj-labs/argocd-notifications/expr"
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/engine"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// ResourceType describes non-application resources processed by the resource controller
+type ResourceType struct {
+	// Name is the resource name used in logs, e.g. rollout
+	Name string
+	// Resource is the watched resource
+	Resource schema.GroupVersionResource
+	// GetVars returns resource specific template variables. The 'context' variable is added by the controller
+	GetVars func(obj *unstructured.Unstructured) map[string]interface{}
+	// GetSubscriptions optionally returns subscriptions inherited by the resource, e.g. from the owner resource
+	GetSubscriptions func(ctx context.Context, client dynamic.Interface, obj *unstructured.Unstructured) (pkg.Subscriptions, error)
+}
+
+// ResourceController is the controller of non-application resources. The controller is created once and keeps
+// running across configuration reloads; notifications are processed once the configuration is set
+type ResourceController interface {
+	NotificationController
+	// SetConfig replaces the configuration used to process resources
+	SetConfig(cfg settings.Config)
+}
+
+// NewResourceController returns controller that sends notifications about resources of the specified type subscribed
+// using annotations. Resources are watched in all namespaces if namespace is empty. Notifications about state
+// transitions older than maxEventAge are skipped when resources are processed for the first time, unless maxEventAge
+// is zero
+func NewResourceController(client dynamic.Interface, namespace string, resourceType ResourceType, resyncPeriod time.Duration, maxEventAge time.Duration) ResourceController {
+	resClient := client.Resource(resourceType.Resource).Namespace(namespace)
+	ctrl := &resourceController{
+		resourceType: resourceType,
+		client:       client,
+		informer:     newInformer(resClient, "", resyncPeriod),
+		queue:        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), resourceType.Name),
+		maxEventAge:  maxEventAge,
+		configured:   make(chan struct{}),
+	}
+	enqueue := func(obj interface{}) {
+		if key, err := cache.MetaNamespaceKeyFunc(obj); err == nil {
+			ctrl.queue.Add(key)
+		}
+	}
+	ctrl.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: enqueue,
+		UpdateFunc: func(_, new interface{}) {
+			enqueue(new)
+		},
+	})
+	return ctrl
+}
+
+type resourceController struct {
+	resourceType ResourceType
+	client       dynamic.Interface
+	informer     cache.SharedIndexInformer
+	queue        workqueue.RateLimitingInterface
+	maxEventAge  time.Duration
+	processed    processedObjects
+
+	// engine is built from the latest configuration; configured is closed once the first configuration is set
+	engine         *engine.Engine
+	engineLock     sync.RWMutex
+	configured     chan struct{}
+	configuredOnce sync.Once
+}
+
+func (c *resourceController) SetConfig(cfg settings.Config) {
+	e := engine.New(cfg.API, func(obj *unstructured.Unstructured, dest services.Destination) map[string]interface{} {
+		return c.getVars(cfg, obj, dest)
+	}, engine.WithPolicy(func(obj *unstructured.Unstructured) engine.Policy {
+		return c.getPolicy(cfg, obj)
+	}))
+	c.engineLock.Lock()
+	c.engine = e
+	c.engineLock.Unlock()
+	c.configuredOnce.Do(func() {
+		close(c.configured)
+	})
+}
+
+func (c *resourceController) getEngine() *engine.Engine {
+	c.engineLock.RLock()
+	defer c.engineLock.RUnlock()
+	return c.engine
+}
+
+func (c *resourceController) getVars(cfg settings.Config, obj *unstructured.Unstructured, dest services.Destination) map[string]interface{} {
+	vars := c.resourceType.GetVars(obj)
+	vars["context"] = legacy.InjectLegacyVar(cfg.Context, dest.Service)
+	return expr.Spawn(obj, cfg.ArgoCDService, vars)
+}
+
+// getPolicy suppresses notifications of muted resources, notifications during the maintenance and stale notifications
+// after the controller start the same way as notifications of applications
+func (c *resourceController) getPolicy(cfg settings.Config, obj *unstructured.Unstructured) engine.Policy {
+	now := time.Now()
+	mute := triggers.ParseMute(obj.GetAnnotations()[mutedAnnotationKey])
+	if !mute.IsActive(now) {
+		mute = nil
+	}
+	paused := cfg.Maintenance.IsActive(now)
+	backfill := isBackfill(&c.processed, obj, c.maxEventAge)
+	return engine.Policy{
+		Suppress: func(trigger string, _ triggers.ConditionResult) (string, string) {
+			return suppressionReason(trigger, nil, mute, paused, backfill, c.maxEventAge)
+		},
+	}
+}
+
+func (c *resourceController) Init(ctx context.Context) error {
+	go c.informer.Run(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), c.informer.HasSynced) {
+		return errors.New("Timed out waiting for caches to sync")
+	}
+	return nil
+}
+
+func (c *resourceController) HasSynced() bool {
+	return c.informer.HasSynced()
+}
+
+func (c *resourceController) Run(ctx context.Context, processors int) {
+	defer runtimeutil.HandleCrash()
+	defer c.queue.ShutDown()
+
+	select {
+	case <-c.configured:
+	case <-ctx.Done():
+		return
+	}
+	log.Warnf("%s controller is running.", c.resourceType.Name)
+	for i := 0; i < processors; i++ {
+		go wait.Until(func() {
+			for c.processQueueItem() {
+			}
+		}, time.Second, ctx.Done())
+	}
+	<-ctx.Done()
+	log.Warnf("%s controller has stopped.", c.resourceType.Name)
+}
+
+func (c *resourceController) processQueueItem() (processNext bool) {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	processNext = true
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Recovered from panic: %+v\n%s", r, debug.Stack())
+		}
+		c.queue.Done(key)
+	}()
+
+	obj, exists, err := c.informer.GetIndexer().GetByKey(key.(string))
+	if err != nil || !exists {
+		return
+	}
+	res, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	logEntry := log.WithField(c.resourceType.Name, key)
+	resCopy := res.DeepCopy()
+	var inherited pkg.Subscriptions
+	if c.resourceType.GetSubscriptions != nil {
+		if inherited, err = c.resourceType.GetSubscriptions(context.Background(), c.client, res); err != nil {
+			logEntry.Errorf("Failed to get subscriptions: %v", err)
+			c.queue.AddRateLimited(key)
+			return
+		}
+	}
+	deliveries, err := c.getEngine().Process(resCopy, inherited)
+	for _, d := range deliveries {
+		if d.Error != nil {
+			logEntry.Errorf("Failed to notify recipient %s about trigger %s: %v", d.Destination, d.Trigger, d.Error)
+		} else {
+			logEntry.Infof("Notification about trigger %s was sent to %s", d.Trigger, d.Destination)
+		}
+	}
+	if err != nil {
+		logEntry.Errorf("Failed to process: %v", err)
+		c.queue.AddRateLimited(key)
+		return
+	}
+
+	if !isTheSame(res.GetAnnotations(), resCopy.GetAnnotations()) {
+		err = k8s.UpdateChangedAnnotations(context.Background(), c.client.Resource(c.resourceType.Resource).Namespace(res.GetNamespace()),
+			res.GetName(), res.GetAnnotations(), resCopy.GetAnnotations())
+		if err != nil {
+			logEntry.Errorf("Failed to patch: %v", err)
+			c.queue.AddRateLimited(key)
+			return
+		}
+	}
+	c.queue.Forget(key)
+}
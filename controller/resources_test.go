@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/mocks"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+func TestResourceController_SendsNotification(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	api := mocks.NewMockAPI(mockCtrl)
+
+	rollout := newRollout(map[string]interface{}{"pauseConditions": []interface{}{map[string]interface{}{"reason": "CanaryPauseStep"}}})
+	rollout.SetAnnotations(map[string]string{subscriptions.SubscribeAnnotationKey("on-rollout-paused", "mock"): "recipient"})
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), rollout)
+
+	api.EXPECT().RunTrigger("on-rollout-paused", gomock.Any()).
+		DoAndReturn(func(_ string, vars map[string]interface{}) ([]triggers.ConditionResult, error) {
+			assert.Equal(t, RolloutPhasePaused, vars["rolloutStatus"].(map[string]interface{})["phase"])
+			return []triggers.ConditionResult{{Key: "[0]", Triggered: true, Templates: []string{"rollout-paused"}}}, nil
+		})
+	api.EXPECT().Send(gomock.Any(), []string{"rollout-paused"}, services.Destination{Service: "mock", Recipient: "recipient"}).Return(nil)
+
+	ctrl := NewResourceController(client, "", Rollouts, time.Minute, 0).(*resourceController)
+	ctrl.SetConfig(settings.Config{API: api})
+	assert.NoError(t, ctrl.Init(ctx))
+	assert.True(t, ctrl.processQueueItem())
+
+	updated, err := client.Resource(RolloutResource).Namespace("default").Get(ctx, "guestbook", v1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, updated.GetAnnotations()[subscriptions.NotifiedAnnotationKey])
+}
+
+func TestResourceController_SkipsFailuresBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	api := mocks.NewMockAPI(mockCtrl)
+
+	workflow := newWorkflow("Failed", nil)
+	workflow.SetAnnotations(map[string]string{subscriptions.SubscribeAnnotationKey("on-workflow-failed", "mock"): "recipient"})
+	_ = unstructured.SetNestedField(workflow.Object, time.Now().Add(-time.Hour).UTC().Format(time.RFC3339), "status", "finishedAt")
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), workflow)
+
+	api.EXPECT().RunTrigger("on-workflow-failed", gomock.Any()).
+		Return([]triggers.ConditionResult{{Key: "[0]", Triggered: true, Templates: []string{"workflow-failed"}}}, nil)
+
+	ctrl := NewResourceController(client, "", Workflows, time.Minute, 30*time.Minute).(*resourceController)
+	ctrl.SetConfig(settings.Config{API: api})
+	assert.NoError(t, ctrl.Init(ctx))
+	assert.True(t, ctrl.processQueueItem())
+
+	updated, err := client.Resource(WorkflowResource).Namespace("default").Get(ctx, "hello-world", v1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, updated.GetAnnotations()[subscriptions.NotifiedAnnotationKey])
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	RolloutPhaseHealthy     = "Healthy"
+	RolloutPhaseProgressing = "Progressing"
+	RolloutPhasePaused      = "Paused"
+	RolloutPhaseDegraded    = "Degraded"
+)
+
+var RolloutResource = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+
+// Rollouts is the Argo Rollouts resource type. Rollout is available as the 'rollout' template variable and
+// the summary of the rollout status as the 'rolloutStatus' variable
+var Rollouts = ResourceType{
+	Name:     "rollout",
+	Resource: RolloutResource,
+	GetVars: func(obj *unstructured.Unstructured) map[string]interface{} {
+		return map[string]interface{}{
+			"rollout":       obj.Object,
+			"rolloutStatus": getRolloutStatus(obj),
+		}
+	},
+}
+
+// getRolloutStatus returns the rollout phase and progress. Phase is reported by Argo Rollouts v1.0+ and inferred from
+// the rollout status for earlier versions
+func getRolloutStatus(obj *unstructured.Unstructured) map[string]interface{} {
+	aborted, _, _ := unstructured.NestedBool(obj.Object, "status", "abort")
+	pauseConditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "pauseConditions")
+	paused, _, _ := unstructured.NestedBool(obj.Object, "spec", "paused")
+	stableRS, _, _ := unstructured.NestedString(obj.Object, "status", "stableRS")
+	currentPodHash, _, _ := unstructured.NestedString(obj.Object, "status", "currentPodHash")
+	message, _, _ := unstructured.NestedString(obj.Object, "status", "message")
+	steps, _, _ := unstructured.NestedSlice(obj.Object, "spec", "strategy", "canary", "steps")
+	currentStep, _, _ := unstructured.NestedInt64(obj.Object, "status", "currentStepIndex")
+
+	phase, ok, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	if !ok || phase == "" {
+		switch {
+		case aborted:
+			phase = RolloutPhaseDegraded
+		case paused || len(pauseConditions) > 0:
+			phase = RolloutPhasePaused
+		case stableRS != "" && stableRS == currentPodHash:
+			phase = RolloutPhaseHealthy
+		default:
+			phase = RolloutPhaseProgressing
+		}
+	}
+	return map[string]interface{}{
+		"phase":       phase,
+		"aborted":     aborted,
+		"paused":      paused || len(pauseConditions) > 0,
+		"message":     message,
+		"currentStep": currentStep,
+		"stepCount":   int64(len(steps)),
+	}
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newRollout(status map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Rollout",
+		"metadata":   map[string]interface{}{"name": "guestbook", "namespace": "default"},
+		"spec": map[string]interface{}{"strategy": map[string]interface{}{"canary": map[string]interface{}{
+			"steps": []interface{}{map[string]interface{}{"setWeight": int64(20)}, map[string]interface{}{"pause": map[string]interface{}{}}},
+		}}},
+		"status": status,
+	}}
+}
+
+func TestGetRolloutStatus(t *testing.T) {
+	testCases := map[string]struct {
+		status map[string]interface{}
+		phase  string
+	}{
+		"Reported":    {status: map[string]interface{}{"phase": "Healthy", "abort": true}, phase: RolloutPhaseHealthy},
+		"Aborted":     {status: map[string]interface{}{"abort": true, "message": "metric assessed Failed"}, phase: RolloutPhaseDegraded},
+		"Paused":      {status: map[string]interface{}{"pauseConditions": []interface{}{map[string]interface{}{"reason": "CanaryPauseStep"}}}, phase: RolloutPhasePaused},
+		"Healthy":     {status: map[string]interface{}{"stableRS": "abc", "currentPodHash": "abc"}, phase: RolloutPhaseHealthy},
+		"Progressing": {status: map[string]interface{}{"stableRS": "abc", "currentPodHash": "def", "currentStepIndex": int64(1)}, phase: RolloutPhaseProgressing},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			status := getRolloutStatus(newRollout(tc.status))
+			assert.Equal(t, tc.phase, status["phase"])
+			assert.Equal(t, int64(2), status["stepCount"])
+		})
+	}
+	assert.Equal(t, int64(1), getRolloutStatus(newRollout(map[string]interface{}{"currentStepIndex": int64(1)}))["currentStep"])
+}
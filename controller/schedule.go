@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+)
+
+// newRecheckSchedule groups triggers with the evaluation interval by the interval
+func newRecheckSchedule(conditions map[string][]triggers.Condition) (map[time.Duration][]string, error) {
+	intervals, err := triggers.Intervals(conditions)
+	if err != nil {
+		return nil, err
+	}
+	res := map[time.Duration][]string{}
+	for name, interval := range intervals {
+		res[interval] = append(res[interval], name)
+	}
+	for interval := range res {
+		sort.Strings(res[interval])
+	}
+	return res, nil
+}
+
+// runRechecks re-queues applications subscribed to the triggers every interval, so conditions that depend on time,
+// e.g. the application is out of sync for more than an hour, are evaluated even if the application does not change
+func (c *notificationController) runRechecks(ctx context.Context, interval time.Duration, triggerNames []string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.enqueueSubscribed(triggerNames)
+		}
+	}
+}
+
+// enqueueSubscribed adds applications subscribed to any of the triggers to the queue and returns their number
+func (c *notificationController) enqueueSubscribed(triggerNames []string) int {
+	queued := map[string]bool{}
+	for _, informer := range c.appInformers {
+		for _, obj := range informer.GetIndexer().List() {
+			app, ok := obj.(*unstructured.Unstructured)
+			if !ok || !c.isAppIncluded(app) {
+				continue
+			}
+			key, err := cache.MetaNamespaceKeyFunc(app)
+			if err != nil || queued[key] {
+				continue
+			}
+			appSubscriptions := c.getSubscriptions(app)
+			for _, name := range triggerNames {
+				if _, ok := appSubscriptions[name]; ok {
+					queued[key] = true
+					c.refreshQueue.Add(key)
+					break
+				}
+			}
+		}
+	}
+	log.Debugf("Re-checking %d applications subscribed to triggers %v", len(queued), triggerNames)
+	return len(queued)
+}
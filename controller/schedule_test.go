@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/mocks"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestNewRecheckSchedule(t *testing.T) {
+	schedule, err := newRecheckSchedule(map[string][]triggers.Condition{
+		"on-out-of-sync": {{When: "true", Interval: "10m"}},
+		"on-degraded":    {{When: "true", Interval: "10m"}},
+		"on-sync":        {{When: "true"}},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[time.Duration][]string{10 * time.Minute: {"on-degraded", "on-out-of-sync"}}, schedule)
+	}
+}
+
+func TestEnqueueSubscribed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	cfg := settings.Config{
+		Config: pkg.Config{Triggers: map[string][]triggers.Condition{
+			"on-out-of-sync": {{When: "true", Interval: "10m"}},
+			"on-sync":        {{When: "true"}},
+		}},
+		API: mocks.NewMockAPI(mockCtrl),
+	}
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		NewApp("subscribed", WithAnnotations(map[string]string{"notifications.argoproj.io/subscribe.on-out-of-sync.slack": "my-channel"})),
+		NewApp("other", WithAnnotations(map[string]string{"notifications.argoproj.io/subscribe.on-sync.slack": "my-channel"})),
+	)
+	c, err := NewController(client, TestNamespace, cfg, nil, NewMetricsRegistry())
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, c.Init(ctx)) {
+		return
+	}
+	ctrl := c.(*notificationController)
+	assert.Equal(t, map[time.Duration][]string{10 * time.Minute: {"on-out-of-sync"}}, ctrl.recheckSchedule)
+	assert.Equal(t, 1, ctrl.enqueueSubscribed([]string{"on-out-of-sync"}))
+}
@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+)
+
+// StatePath is the path of the endpoint that serves notification states of applications
+const StatePath = "/api/state"
+
+// StateProvider returns notification states of applications processed by the controller
+type StateProvider interface {
+	GetAppStates(filter func(app *unstructured.Unstructured) bool) []AppState
+}
+
+// AppState is the decoded notification state of the application
+type AppState struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Project   string `json:"project,omitempty"`
+	// Triggers holds the state of every trigger that has notified at least one destination
+	Triggers []TriggerState `json:"triggers"`
+	// Acknowledged holds acknowledged triggers
+	Acknowledged triggers.Acknowledgments `json:"acknowledged,omitempty"`
+	// Muted is the mute of the application
+	Muted *triggers.Mute `json:"muted,omitempty"`
+	// PendingRetries holds failed deliveries that are retried during the next reconciliation of the application
+	PendingRetries []PendingRetry `json:"pendingRetries"`
+	// ProcessingRetries is the number of times processing of the application has failed and is retried
+	ProcessingRetries int `json:"processingRetries"`
+}
+
+// PendingRetry describes the failed delivery that is retried during the next reconciliation of the application
+type PendingRetry struct {
+	Trigger   string `json:"trigger"`
+	Service   string `json:"service"`
+	Recipient string `json:"recipient"`
+	// Error is the redacted error of the latest attempt
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failedAt"`
+	// Attempts is the number of consecutive failed attempts
+	Attempts int `json:"attempts"`
+}
+
+// pendingRetries holds failed deliveries of applications keyed by the application key and the state item key. The
+// state is kept in memory, so it is reset when the controller restarts or the config is reloaded
+type pendingRetries struct {
+	lock  sync.Mutex
+	items map[string]map[string]PendingRetry
+}
+
+// set replaces pending retries of the application with deliveries that have failed during the latest processing.
+// Deliveries that are not attempted anymore, e.g. because the condition stopped firing, are not pending
+func (p *pendingRetries) set(appKey string, failed map[string]PendingRetry) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	prev := p.items[appKey]
+	if len(failed) == 0 {
+		delete(p.items, appKey)
+		return
+	}
+	for itemKey, retry := range failed {
+		retry.Attempts = prev[itemKey].Attempts + 1
+		failed[itemKey] = retry
+	}
+	if p.items == nil {
+		p.items = map[string]map[string]PendingRetry{}
+	}
+	p.items[appKey] = failed
+}
+
+func (p *pendingRetries) get(appKey string) []PendingRetry {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	res := []PendingRetry{}
+	for _, retry := range p.items[appKey] {
+		res = append(res, retry)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Trigger != res[j].Trigger {
+			return res[i].Trigger < res[j].Trigger
+		}
+		if res[i].Service != res[j].Service {
+			return res[i].Service < res[j].Service
+		}
+		return res[i].Recipient < res[j].Recipient
+	})
+	return res
+}
+
+// TriggerState describes notifications sent by the trigger
+type TriggerState struct {
+	Name string `json:"name"`
+	// LastFired is the time of the most recent notification sent by the trigger
+	LastFired time.Time `json:"lastFired"`
+	// Items are the notification state items of the trigger
+	Items []StateItemInfo `json:"items"`
+}
+
+// StateItemInfo describes the notified destination
+type StateItemInfo struct {
+	OncePer      string    `json:"oncePer,omitempty"`
+	ConditionKey string    `json:"conditionKey,omitempty"`
+	Service      string    `json:"service"`
+	Recipient    string    `json:"recipient"`
+	NotifiedAt   time.Time `json:"notifiedAt"`
+}
+
+// GetAppStates returns states of applications matching the filter
+func (c *notificationController) GetAppStates(filter func(app *unstructured.Unstructured) bool) []AppState {
+	seen := map[string]bool{}
+	var res []AppState
+	for _, informer := range c.appInformers {
+		for _, obj := range informer.GetIndexer().List() {
+			app, ok := obj.(*unstructured.Unstructured)
+			if !ok || !c.isAppIncluded(app) || !filter(app) {
+				continue
+			}
+			key, err := cache.MetaNamespaceKeyFunc(app)
+			if err != nil || seen[key] {
+				continue
+			}
+			seen[key] = true
+			res = append(res, c.getAppState(key, app))
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Namespace != res[j].Namespace {
+			return res[i].Namespace < res[j].Namespace
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+func (c *notificationController) getAppState(key string, app *unstructured.Unstructured) AppState {
+	annotations := app.GetAnnotations()
+	stateVal := annotations[notifiedAnnotationKey]
+	if c.dryRun {
+		c.dryRunStateLock.Lock()
+		stateVal = c.dryRunState[key]
+		c.dryRunStateLock.Unlock()
+	}
+	res := AppState{
+		Namespace:         app.GetNamespace(),
+		Name:              app.GetName(),
+		Triggers:          []TriggerState{},
+		Muted:             triggers.ParseMute(annotations[mutedAnnotationKey]),
+		PendingRetries:    c.pendingRetries.get(key),
+		ProcessingRetries: c.refreshQueue.NumRequeues(key),
+	}
+	res.Project, _, _ = unstructured.NestedString(app.Object, "spec", "project")
+	if acks := triggers.NewAcknowledgments(annotations[acknowledgedAnnotationKey]); len(acks) > 0 {
+		res.Acknowledged = acks
+	}
+
+	byTrigger := map[string]*TriggerState{}
+	appSubscriptions := c.getSubscriptions(app)
+	for itemKey, timestamp := range triggers.NewState(stateVal) {
+		item, err := triggers.ParseSubscribedStateItemKey(itemKey, appSubscriptions)
+		if err != nil {
+			continue
+		}
+		trigger, ok := byTrigger[item.Trigger]
+		if !ok {
+			trigger = &TriggerState{Name: item.Trigger}
+			byTrigger[item.Trigger] = trigger
+		}
+		notifiedAt := time.Unix(timestamp, 0).UTC()
+		if notifiedAt.After(trigger.LastFired) {
+			trigger.LastFired = notifiedAt
+		}
+		trigger.Items = append(trigger.Items, StateItemInfo{
+			OncePer:      item.OncePer,
+			ConditionKey: item.ConditionKey,
+			Service:      item.Destination.Service,
+			Recipient:    item.Destination.Recipient,
+			NotifiedAt:   notifiedAt,
+		})
+	}
+	for _, trigger := range byTrigger {
+		sort.Slice(trigger.Items, func(i, j int) bool {
+			return trigger.Items[i].NotifiedAt.After(trigger.Items[j].NotifiedAt)
+		})
+		res.Triggers = append(res.Triggers, *trigger)
+	}
+	sort.Slice(res.Triggers, func(i, j int) bool {
+		return res.Triggers[i].Name < res.Triggers[j].Name
+	})
+	return res
+}
+
+// NewStateHandler returns the read-only handler that serves application states in JSON. The optional `app` and
+// `project` query parameters filter applications by name and project. The handler does not authenticate requests, so
+// it is served next to debug endpoints only.
+func NewStateHandler(getProvider func() StateProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET requests are supported", http.StatusMethodNotAllowed)
+			return
+		}
+		provider := getProvider()
+		if provider == nil {
+			http.Error(w, "controller is not ready", http.StatusServiceUnavailable)
+			return
+		}
+		appName, project := r.URL.Query().Get("app"), r.URL.Query().Get("project")
+		states := provider.GetAppStates(func(app *unstructured.Unstructured) bool {
+			if appName != "" && app.GetName() != appName {
+				return false
+			}
+			if project != "" {
+				if appProject, _, _ := unstructured.NestedString(app.Object, "spec", "project"); appProject != project {
+					return false
+				}
+			}
+			return true
+		})
+		if states == nil {
+			states = []AppState{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(states)
+	})
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestStateHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	dest := services.Destination{Service: "slack", Recipient: "ops"}
+	state := triggers.State{
+		triggers.StateItemKey("on-deployed", triggers.ConditionResult{OncePer: "abc"}, dest): 100,
+		triggers.StateItemKey("on-deployed", triggers.ConditionResult{OncePer: "def"}, dest): 200,
+	}
+	app := NewApp("guestbook", WithProject("default"), WithAnnotations(map[string]string{
+		notifiedAnnotationKey: mustToJson(state),
+	}))
+	other := NewApp("other", WithProject("payments"))
+	ctrl, _, err := newController(t, ctx, fake.NewSimpleDynamicClient(runtime.NewScheme(), app, other))
+	if !assert.NoError(t, err) {
+		return
+	}
+	handler := NewStateHandler(func() StateProvider {
+		return ctrl
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, StatePath+"?project=default", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var states []AppState
+	if !assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &states)) || !assert.Len(t, states, 1) {
+		return
+	}
+	assert.Equal(t, "guestbook", states[0].Name)
+	assert.Equal(t, "default", states[0].Project)
+	assert.Equal(t, []TriggerState{{
+		Name:      "on-deployed",
+		LastFired: time.Unix(200, 0).UTC(),
+		Items: []StateItemInfo{
+			{OncePer: "def", Service: "slack", Recipient: "ops", NotifiedAt: time.Unix(200, 0).UTC()},
+			{OncePer: "abc", Service: "slack", Recipient: "ops", NotifiedAt: time.Unix(100, 0).UTC()},
+		},
+	}}, states[0].Triggers)
+}
+
+func TestPendingRetries(t *testing.T) {
+	var retries pendingRetries
+	failed := func() map[string]PendingRetry {
+		return map[string]PendingRetry{
+			"a": {Trigger: "on-sync-failed", Service: "slack", Recipient: "ops"},
+			"b": {Trigger: "on-deployed", Service: "slack", Recipient: "ops"},
+		}
+	}
+	retries.set("default/guestbook", failed())
+	retries.set("default/guestbook", failed())
+
+	assert.Equal(t, []PendingRetry{
+		{Trigger: "on-deployed", Service: "slack", Recipient: "ops", Attempts: 2},
+		{Trigger: "on-sync-failed", Service: "slack", Recipient: "ops", Attempts: 2},
+	}, retries.get("default/guestbook"))
+
+	// the delivery that is not attempted during the latest processing is not pending anymore
+	retries.set("default/guestbook", map[string]PendingRetry{"a": {Trigger: "on-sync-failed", Service: "slack", Recipient: "ops"}})
+	assert.Equal(t, []PendingRetry{{Trigger: "on-sync-failed", Service: "slack", Recipient: "ops", Attempts: 3}}, retries.get("default/guestbook"))
+
+	retries.set("default/guestbook", nil)
+	assert.Empty(t, retries.get("default/guestbook"))
+}
+
+func TestStateHandler_NotReady(t *testing.T) {
+	handler := NewStateHandler(func() StateProvider {
+		return nil
+	})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, StatePath, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
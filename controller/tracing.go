@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
+
+	"github.com/argoproj-labs/argocd-notifications/expr/shared"
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+	"github.com/argoproj-labs/argocd-notifications/shared/tracing"
+)
+
+// tracedArgoCDService records repo server calls as children of the span stored in the bound context.
+// Expressions don't have access to the request context, so the span context is bound when vars are spawned.
+type tracedArgoCDService struct {
+	argocd.Service
+	ctx context.Context
+}
+
+func newTracedArgoCDService(ctx context.Context, svc argocd.Service) argocd.Service {
+	if tracing.FromContext(ctx) == nil || svc == nil {
+		return svc
+	}
+	return &tracedArgoCDService{Service: svc, ctx: ctx}
+}
+
+func (svc *tracedArgoCDService) GetCommitMetadata(_ context.Context, repoURL string, commitSHA string) (*shared.CommitMetadata, error) {
+	ctx, span := tracing.StartSpan(svc.ctx, "repo-server.GetCommitMetadata")
+	defer span.Finish()
+	span.SetAttribute("repo", repoURL)
+	span.SetAttribute("revision", commitSHA)
+	res, err := svc.Service.GetCommitMetadata(ctx, repoURL, commitSHA)
+	span.SetError(err)
+	return res, err
+}
+
+func (svc *tracedArgoCDService) GetAppDetails(_ context.Context, appSource *v1alpha1.ApplicationSource) (*shared.AppDetail, error) {
+	ctx, span := tracing.StartSpan(svc.ctx, "repo-server.GetAppDetails")
+	defer span.Finish()
+	span.SetAttribute("repo", appSource.RepoURL)
+	res, err := svc.Service.GetAppDetails(ctx, appSource)
+	span.SetError(err)
+	return res, err
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"io/ioutil"
+	"reflect"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// appControlledFields are application fields used by the controller regardless of trigger conditions: annotations
+// hold subscriptions and the notification state, labels, the project and the destination select subscriptions,
+// priorities and quotas
+var appControlledFields = [][]string{
+	{"metadata", "annotations"},
+	{"metadata", "labels"},
+	{"spec", "project"},
+	{"spec", "destination"},
+}
+
+// helperFields are application fields read by expression helpers that receive the application implicitly
+var helperFields = map[string][][]string{
+	"repo":      {{"spec", "source"}, {"spec", "sources"}, {"status", "sync"}},
+	"kustomize": {{"spec", "source", "kustomize"}, {"status", "summary", "images"}},
+}
+
+// silentLog discards messages of the sync status check performed for every filtered update
+var silentLog = func() *log.Entry {
+	logger := log.New()
+	logger.SetOutput(ioutil.Discard)
+	return log.NewEntry(logger)
+}()
+
+// newUpdateFields returns application fields compared to decide if the update must be processed or nil if any
+// update might change the trigger result
+func newUpdateFields(cfg settings.Config) ([][]string, error) {
+	fields, ok, err := triggers.ReferencedFields(cfg.Triggers, helperFields)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		log.Warn("Application updates are not filtered because some triggers reference the whole application")
+		return nil, nil
+	}
+	return append(append([][]string{}, appControlledFields...), fields...), nil
+}
+
+// isUpdateRelevant returns false if the application update does not change any field referenced by triggers, so
+// triggers would return the same result and the update doesn't have to be processed
+func (c *notificationController) isUpdateRelevant(old, new interface{}) bool {
+	if c.updateFields == nil {
+		return true
+	}
+	oldApp, ok := old.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+	newApp, ok := new.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+	// resyncs deliver the same version of the application; they retry failed notifications and re-evaluate
+	// conditions that depend on time, so they are never skipped
+	if oldApp.GetResourceVersion() == newApp.GetResourceVersion() {
+		return true
+	}
+	// the previous version was skipped until Argo CD refreshes the sync status or was not processed at all
+	if !c.isAppIncluded(oldApp) || !c.isAppSyncStatusRefreshed(oldApp, silentLog) {
+		return true
+	}
+	for _, path := range c.updateFields {
+		oldVal, _, _ := unstructured.NestedFieldNoCopy(oldApp.Object, path...)
+		newVal, _, _ := unstructured.NestedFieldNoCopy(newApp.Object, path...)
+		if !reflect.DeepEqual(oldVal, newVal) {
+			return true
+		}
+	}
+	c.metricsRegistry.IncSkippedUpdatesCounter()
+	return false
+}
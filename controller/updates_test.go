@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/mocks"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func newUpdateFilterController(t *testing.T, conditions ...triggers.Condition) *notificationController {
+	mockCtrl := gomock.NewController(t)
+	cfg := settings.Config{
+		Config: pkg.Config{Triggers: map[string][]triggers.Condition{"my-trigger": conditions}},
+		API:    mocks.NewMockAPI(mockCtrl),
+	}
+	c, err := NewController(fake.NewSimpleDynamicClient(runtime.NewScheme()), TestNamespace, cfg, nil, NewMetricsRegistry(), WithUpdateFilter())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return c.(*notificationController)
+}
+
+func newAppVersion(app *unstructured.Unstructured, resourceVersion string, update func(app *unstructured.Unstructured)) *unstructured.Unstructured {
+	res := app.DeepCopy()
+	res.SetResourceVersion(resourceVersion)
+	update(res)
+	return res
+}
+
+func TestIsUpdateRelevant(t *testing.T) {
+	ctrl := newUpdateFilterController(t, triggers.Condition{When: "app.status.sync.status == 'OutOfSync'"})
+	app := NewApp("test")
+	app.SetResourceVersion("1")
+	_ = unstructured.SetNestedField(app.Object, "Synced", "status", "sync", "status")
+
+	t.Run("UnrelatedFieldChanged", func(t *testing.T) {
+		before := testutil.ToFloat64(skippedUpdatesCounter)
+		updated := newAppVersion(app, "2", func(app *unstructured.Unstructured) {
+			_ = unstructured.SetNestedField(app.Object, "2021-01-01T00:00:00Z", "status", "reconciledAt")
+		})
+		assert.False(t, ctrl.isUpdateRelevant(app, updated))
+		assert.Equal(t, before+1, testutil.ToFloat64(skippedUpdatesCounter))
+	})
+
+	t.Run("ReferencedFieldChanged", func(t *testing.T) {
+		updated := newAppVersion(app, "2", func(app *unstructured.Unstructured) {
+			_ = unstructured.SetNestedField(app.Object, "OutOfSync", "status", "sync", "status")
+		})
+		assert.True(t, ctrl.isUpdateRelevant(app, updated))
+	})
+
+	t.Run("AnnotationsChanged", func(t *testing.T) {
+		updated := newAppVersion(app, "2", func(app *unstructured.Unstructured) {
+			app.SetAnnotations(map[string]string{"notifications.argoproj.io/subscribe.my-trigger.slack": "my-channel"})
+		})
+		assert.True(t, ctrl.isUpdateRelevant(app, updated))
+	})
+
+	t.Run("Resync", func(t *testing.T) {
+		assert.True(t, ctrl.isUpdateRelevant(app, app.DeepCopy()))
+	})
+
+	t.Run("SyncStatusNotRefreshed", func(t *testing.T) {
+		syncing := newAppVersion(app, "2", func(app *unstructured.Unstructured) {
+			_ = unstructured.SetNestedField(app.Object, map[string]interface{}{
+				"phase":      "Succeeded",
+				"finishedAt": "2021-01-01T00:00:00Z",
+			}, "status", "operationState")
+		})
+		refreshed := newAppVersion(syncing, "3", func(app *unstructured.Unstructured) {
+			_ = unstructured.SetNestedField(app.Object, "2021-01-01T00:00:10Z", "status", "reconciledAt")
+		})
+		assert.True(t, ctrl.isUpdateRelevant(syncing, refreshed))
+	})
+}
+
+func TestIsUpdateRelevant_WholeAppReferenced(t *testing.T) {
+	ctrl := newUpdateFilterController(t, triggers.Condition{When: "app != nil"})
+	app := NewApp("test")
+	app.SetResourceVersion("1")
+	updated := newAppVersion(app, "2", func(app *unstructured.Unstructured) {
+		_ = unstructured.SetNestedField(app.Object, "2021-01-01T00:00:00Z", "status", "reconciledAt")
+	})
+	assert.True(t, ctrl.isUpdateRelevant(app, updated))
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+)
+
+const (
+	WorkflowPhasePending = "Pending"
+	// cronWorkflowLabel is the label that Argo Workflows sets on workflows submitted by the CronWorkflow
+	cronWorkflowLabel = "workflows.argoproj.io/cron-workflow"
+)
+
+var (
+	WorkflowResource     = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "workflows"}
+	CronWorkflowResource = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "cronworkflows"}
+)
+
+// now is replaced in tests
+var now = time.Now
+
+// Workflows is the Argo Workflows resource type. Workflow is available as the 'workflow' template variable and
+// the summary of the workflow status as the 'workflowStatus' variable. Workflows submitted by a CronWorkflow inherit
+// subscriptions of the CronWorkflow
+var Workflows = ResourceType{
+	Name:     "workflow",
+	Resource: WorkflowResource,
+	GetVars: func(obj *unstructured.Unstructured) map[string]interface{} {
+		return map[string]interface{}{
+			"workflow":       obj.Object,
+			"workflowStatus": getWorkflowStatus(obj),
+		}
+	},
+	GetSubscriptions: getCronWorkflowSubscriptions,
+}
+
+// getWorkflowStatus returns the workflow phase and the number of seconds the workflow is pending, so triggers might
+// detect workflows stuck in the pending phase
+func getWorkflowStatus(obj *unstructured.Unstructured) map[string]interface{} {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	if phase == "" {
+		phase = WorkflowPhasePending
+	}
+	message, _, _ := unstructured.NestedString(obj.Object, "status", "message")
+	var pendingSeconds int64
+	if phase == WorkflowPhasePending {
+		pendingSeconds = int64(now().Sub(obj.GetCreationTimestamp().Time).Seconds())
+	}
+	return map[string]interface{}{
+		"phase":          phase,
+		"message":        message,
+		"pendingSeconds": pendingSeconds,
+		"cronWorkflow":   obj.GetLabels()[cronWorkflowLabel],
+	}
+}
+
+func getCronWorkflowSubscriptions(ctx context.Context, client dynamic.Interface, obj *unstructured.Unstructured) (pkg.Subscriptions, error) {
+	name := obj.GetLabels()[cronWorkflowLabel]
+	if name == "" {
+		return nil, nil
+	}
+	cronWorkflow, err := client.Resource(CronWorkflowResource).Namespace(obj.GetNamespace()).Get(ctx, name, v1.GetOptions{})
+	if apierr.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return subscriptions.Annotations(cronWorkflow.GetAnnotations()).GetAll(), nil
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+)
+
+func newWorkflow(phase string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Workflow",
+		"metadata":   map[string]interface{}{"name": "hello-world", "namespace": "default"},
+		"status":     map[string]interface{}{"phase": phase},
+	}}
+	obj.SetLabels(labels)
+	return obj
+}
+
+func TestGetWorkflowStatus(t *testing.T) {
+	defer func() {
+		now = time.Now
+	}()
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		return created.Add(10 * time.Minute)
+	}
+
+	wf := newWorkflow("", map[string]string{cronWorkflowLabel: "nightly"})
+	wf.SetCreationTimestamp(v1.NewTime(created))
+	status := getWorkflowStatus(wf)
+	assert.Equal(t, WorkflowPhasePending, status["phase"])
+	assert.Equal(t, int64(600), status["pendingSeconds"])
+	assert.Equal(t, "nightly", status["cronWorkflow"])
+
+	wf = newWorkflow("Failed", nil)
+	wf.SetCreationTimestamp(v1.NewTime(created))
+	status = getWorkflowStatus(wf)
+	assert.Equal(t, "Failed", status["phase"])
+	assert.Equal(t, int64(0), status["pendingSeconds"])
+}
+
+func TestGetCronWorkflowSubscriptions(t *testing.T) {
+	cronWorkflow := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "CronWorkflow",
+		"metadata":   map[string]interface{}{"name": "nightly", "namespace": "default"},
+	}}
+	cronWorkflow.SetAnnotations(map[string]string{subscriptions.SubscribeAnnotationKey("on-workflow-failed", "slack"): "ci"})
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), cronWorkflow)
+
+	subs, err := getCronWorkflowSubscriptions(context.Background(), client, newWorkflow("Failed", map[string]string{cronWorkflowLabel: "nightly"}))
+	assert.NoError(t, err)
+	assert.Equal(t, pkg.Subscriptions{"on-workflow-failed": {{Service: "slack", Recipient: "ci"}}}, subs)
+
+	subs, err = getCronWorkflowSubscriptions(context.Background(), client, newWorkflow("Failed", map[string]string{cronWorkflowLabel: "unknown"}))
+	assert.NoError(t, err)
+	assert.Empty(t, subs)
+
+	subs, err = getCronWorkflowSubscriptions(context.Background(), client, newWorkflow("Failed", nil))
+	assert.NoError(t, err)
+	assert.Empty(t, subs)
+}
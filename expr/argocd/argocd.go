@@ -0,0 +1,48 @@
+package argocd
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+)
+
+// defaultTailLines is the number of returned log lines if the number is not positive
+const defaultTailLines = 100
+
+func NewExprs(ctx context.Context, client argocd.AppInfoClient, app *unstructured.Unstructured) map[string]interface{} {
+	return map[string]interface{}{
+		"GetResourceTree": func() interface{} {
+			tree, err := client.GetResourceTree(ctx, app.GetName())
+			if err != nil {
+				panic(err)
+			}
+			return tree
+		},
+		"GetEvents": func() interface{} {
+			events, err := client.GetEvents(ctx, app.GetName())
+			if err != nil {
+				panic(err)
+			}
+			return events
+		},
+		"GetSyncWindows": func() interface{} {
+			windows, err := client.GetSyncWindows(ctx, app.GetName())
+			if err != nil {
+				panic(err)
+			}
+			return windows
+		},
+		"GetLogs": func(podName string, container string, tailLines int) string {
+			if tailLines <= 0 {
+				tailLines = defaultTailLines
+			}
+			logs, err := client.GetLogs(ctx, app.GetName(), podName, container, tailLines)
+			if err != nil {
+				panic(err)
+			}
+			return logs
+		},
+	}
+}
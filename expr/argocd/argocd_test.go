@@ -0,0 +1,46 @@
+package argocd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+type fakeAppInfoClient struct {
+	tailLines int
+}
+
+func (c *fakeAppInfoClient) GetResourceTree(_ context.Context, app string) (map[string]interface{}, error) {
+	return map[string]interface{}{"nodes": []interface{}{map[string]interface{}{"name": app}}}, nil
+}
+
+func (c *fakeAppInfoClient) GetEvents(_ context.Context, _ string) ([]interface{}, error) {
+	return nil, errors.New("permission denied")
+}
+
+func (c *fakeAppInfoClient) GetSyncWindows(_ context.Context, _ string) (map[string]interface{}, error) {
+	return map[string]interface{}{"canSync": false}, nil
+}
+
+func (c *fakeAppInfoClient) GetLogs(_ context.Context, _ string, podName string, container string, tailLines int) (string, error) {
+	c.tailLines = tailLines
+	return podName + "/" + container, nil
+}
+
+func TestNewExprs(t *testing.T) {
+	client := &fakeAppInfoClient{}
+	exprs := NewExprs(context.Background(), client, NewApp("guestbook"))
+
+	assert.Equal(t, map[string]interface{}{"nodes": []interface{}{map[string]interface{}{"name": "guestbook"}}},
+		exprs["GetResourceTree"].(func() interface{})())
+	assert.Equal(t, map[string]interface{}{"canSync": false}, exprs["GetSyncWindows"].(func() interface{})())
+	assert.Equal(t, "guestbook-ui-1/ui", exprs["GetLogs"].(func(string, string, int) string)("guestbook-ui-1", "ui", 0))
+	assert.Equal(t, defaultTailLines, client.tailLines)
+	assert.PanicsWithError(t, "permission denied", func() {
+		exprs["GetEvents"].(func() interface{})()
+	})
+}
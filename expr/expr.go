@@ -1,6 +1,7 @@
 package expr
 
 import (
+	"github.com/argoproj-labs/argocd-notifications/expr/kustomize"
 	"github.com/argoproj-labs/argocd-notifications/expr/repo"
 	"github.com/argoproj-labs/argocd-notifications/expr/time"
 	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
@@ -27,6 +28,7 @@ func Spawn(app *unstructured.Unstructured, argocdService argocd.Service, vars ma
 		clone[namespace] = helper
 	}
 	clone["repo"] = repo.NewExprs(argocdService, app)
+	clone["kustomize"] = kustomize.NewExprs(app)
 
 	return clone
 }
@@ -0,0 +1,68 @@
+package kustomize
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/expr/shared"
+)
+
+// parseImage parses the override in the `[<old_image_name>=]<image_name>[:<image_tag>|@<digest>]` format used by
+// `kustomize edit set image` and the Argo CD application spec
+func parseImage(override string) shared.KustomizeImage {
+	var res shared.KustomizeImage
+	ref := override
+	if parts := strings.SplitN(override, "=", 2); len(parts) == 2 {
+		res.Name, ref = parts[0], parts[1]
+	}
+	if parts := strings.SplitN(ref, "@", 2); len(parts) == 2 {
+		ref, res.Digest = parts[0], parts[1]
+	} else if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		// the colon before the last slash separates the registry port rather than the tag
+		ref, res.NewTag = ref[:i], ref[i+1:]
+	}
+	res.NewName = ref
+	if res.Name == "" {
+		res.Name = ref
+	}
+	return res
+}
+
+func getImageOverrides(app *unstructured.Unstructured) []shared.KustomizeImage {
+	images, _, _ := unstructured.NestedStringSlice(app.Object, "spec", "source", "kustomize", "images")
+	res := make([]shared.KustomizeImage, len(images))
+	for i := range images {
+		res[i] = parseImage(images[i])
+	}
+	return res
+}
+
+func getImages(app *unstructured.Unstructured) []string {
+	images, _, _ := unstructured.NestedStringSlice(app.Object, "status", "summary", "images")
+	return images
+}
+
+func imageOverridesSummary(app *unstructured.Unstructured) string {
+	overrides := getImageOverrides(app)
+	parts := make([]string, len(overrides))
+	for i := range overrides {
+		parts[i] = fmt.Sprintf("%s=%s", overrides[i].Name, overrides[i].Image())
+	}
+	return strings.Join(parts, ", ")
+}
+
+func NewExprs(app *unstructured.Unstructured) map[string]interface{} {
+	return map[string]interface{}{
+		"GetImageOverrides": func() interface{} {
+			return getImageOverrides(app)
+		},
+		"GetImages": func() interface{} {
+			return getImages(app)
+		},
+		"ImageOverridesSummary": func() string {
+			return imageOverridesSummary(app)
+		},
+	}
+}
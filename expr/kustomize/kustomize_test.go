@@ -0,0 +1,43 @@
+package kustomize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/expr/shared"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestParseImage(t *testing.T) {
+	for in, expected := range map[string]shared.KustomizeImage{
+		"nginx:1.2.3":                             {Name: "nginx", NewName: "nginx", NewTag: "1.2.3"},
+		"nginx=registry.example.com/nginx:1.2.3":  {Name: "nginx", NewName: "registry.example.com/nginx", NewTag: "1.2.3"},
+		"localhost:5000/nginx":                    {Name: "localhost:5000/nginx", NewName: "localhost:5000/nginx"},
+		"localhost:5000/nginx:1.2.3":              {Name: "localhost:5000/nginx", NewName: "localhost:5000/nginx", NewTag: "1.2.3"},
+		"nginx=nginx@sha256:24a0c4b4a4c0eb97a1aa": {Name: "nginx", NewName: "nginx", Digest: "sha256:24a0c4b4a4c0eb97a1aa"},
+	} {
+		assert.Equal(t, expected, parseImage(in), in)
+	}
+}
+
+func TestImageOverrides(t *testing.T) {
+	app := NewApp("guestbook")
+	_ = unstructured.SetNestedStringSlice(app.Object, []string{"nginx=registry.example.com/nginx:1.2.3", "redis:7"}, "spec", "source", "kustomize", "images")
+	_ = unstructured.SetNestedStringSlice(app.Object, []string{"registry.example.com/nginx:1.2.3", "redis:7"}, "status", "summary", "images")
+
+	assert.Equal(t, []shared.KustomizeImage{
+		{Name: "nginx", NewName: "registry.example.com/nginx", NewTag: "1.2.3"},
+		{Name: "redis", NewName: "redis", NewTag: "7"},
+	}, getImageOverrides(app))
+	assert.Equal(t, []string{"registry.example.com/nginx:1.2.3", "redis:7"}, getImages(app))
+	assert.Equal(t, "nginx=registry.example.com/nginx:1.2.3, redis=redis:7", imageOverridesSummary(app))
+}
+
+func TestImageOverrides_NoOverrides(t *testing.T) {
+	app := NewApp("guestbook")
+	assert.Empty(t, getImageOverrides(app))
+	assert.Empty(t, getImages(app))
+	assert.Equal(t, "", imageOverridesSummary(app))
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
@@ -45,8 +47,100 @@ func getAppDetails(app *unstructured.Unstructured, argocdService argocd.Service)
 	return appDetail, nil
 }
 
+// getSources returns sources of the multi-source application or the only source of the single source application
+func getSources(app *unstructured.Unstructured) ([]map[string]interface{}, error) {
+	sources, ok, err := unstructured.NestedSlice(app.Object, "spec", "sources")
+	if err != nil {
+		return nil, err
+	}
+	if ok && len(sources) > 0 {
+		res := make([]map[string]interface{}, len(sources))
+		for i := range sources {
+			source, ok := sources[i].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("application source %d is not an object", i)
+			}
+			res[i] = source
+		}
+		return res, nil
+	}
+	source, ok, err := unstructured.NestedMap(app.Object, "spec", "source")
+	if err != nil || !ok {
+		return nil, err
+	}
+	return []map[string]interface{}{source}, nil
+}
+
+// getSyncedRevisions returns synced revisions of application sources in the order of sources
+func getSyncedRevisions(app *unstructured.Unstructured) []string {
+	if revisions, ok, err := unstructured.NestedStringSlice(app.Object, "status", "sync", "revisions"); err == nil && ok {
+		return revisions
+	}
+	if revision, ok, err := unstructured.NestedString(app.Object, "status", "sync", "revision"); err == nil && ok {
+		return []string{revision}
+	}
+	return nil
+}
+
+// getHelmValues returns effective Helm parameters of the synced revision. The application source is rendered by the repo
+// server at the synced revision rather than the target revision, so values match the deployed manifests.
+func getHelmValues(app *unstructured.Unstructured, argocdService argocd.Service) (*shared.HelmValues, error) {
+	appSource, err := getApplicationSource(app)
+	if err != nil {
+		return nil, err
+	}
+	source := appSource.DeepCopy()
+	if revision, ok, err := unstructured.NestedString(app.Object, "status", "sync", "revision"); err == nil && ok && revision != "" {
+		source.TargetRevision = revision
+	}
+	appDetail, err := argocdService.GetAppDetails(context.Background(), source)
+	if err != nil {
+		return nil, err
+	}
+	if appDetail.Helm == nil {
+		return nil, fmt.Errorf("application source is not a Helm chart, the source type is %s", appDetail.Type)
+	}
+	res := &shared.HelmValues{
+		Revision:   source.TargetRevision,
+		ValueFiles: appDetail.Helm.ValueFiles,
+		Values:     appDetail.Helm.Values,
+	}
+	params := map[string]shared.HelmParameter{}
+	for _, p := range appDetail.Helm.Parameters {
+		if p != nil {
+			params[p.Name] = shared.HelmParameter{Name: p.Name, Value: p.Value, ForceString: p.ForceString}
+		}
+	}
+	if source.Helm != nil {
+		res.ValuesOverride = source.Helm.Values
+		for _, p := range source.Helm.Parameters {
+			override := shared.HelmParameter{Name: p.Name, Value: p.Value, ForceString: p.ForceString}
+			res.Overrides = append(res.Overrides, override)
+			params[p.Name] = override
+		}
+	}
+	for _, p := range params {
+		res.Parameters = append(res.Parameters, p)
+	}
+	sort.Slice(res.Parameters, func(i, j int) bool {
+		return res.Parameters[i].Name < res.Parameters[j].Name
+	})
+	return res, nil
+}
+
 func getCommitMetadata(commitSHA string, app *unstructured.Unstructured, argocdService argocd.Service) (*shared.CommitMetadata, error) {
-	repoURL, ok, err := unstructured.NestedString(app.Object, "spec", "source", "repoURL")
+	return getSourceCommitMetadata(0, commitSHA, app, argocdService)
+}
+
+func getSourceCommitMetadata(index int, commitSHA string, app *unstructured.Unstructured, argocdService argocd.Service) (*shared.CommitMetadata, error) {
+	sources, err := getSources(app)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(sources) {
+		panic(fmt.Errorf("application has no source with index %d", index))
+	}
+	repoURL, ok, err := unstructured.NestedString(sources[index], "repoURL")
 	if err != nil {
 		return nil, err
 	}
@@ -60,6 +154,35 @@ func getCommitMetadata(commitSHA string, app *unstructured.Unstructured, argocdS
 	return meta, nil
 }
 
+// getSourcesMetadata returns every source of the application together with the metadata of the synced commit
+func getSourcesMetadata(app *unstructured.Unstructured, argocdService argocd.Service) ([]shared.SourceMetadata, error) {
+	sources, err := getSources(app)
+	if err != nil {
+		return nil, err
+	}
+	revisions := getSyncedRevisions(app)
+	res := make([]shared.SourceMetadata, len(sources))
+	for i, source := range sources {
+		meta := shared.SourceMetadata{}
+		meta.RepoURL, _, _ = unstructured.NestedString(source, "repoURL")
+		meta.Path, _, _ = unstructured.NestedString(source, "path")
+		meta.Chart, _, _ = unstructured.NestedString(source, "chart")
+		meta.TargetRevision, _, _ = unstructured.NestedString(source, "targetRevision")
+		if i < len(revisions) {
+			meta.Revision = revisions[i]
+		}
+		// Helm repositories don't have commits
+		if meta.Chart == "" && meta.Revision != "" && meta.RepoURL != "" {
+			meta.CommitMetadata, err = argocdService.GetCommitMetadata(context.Background(), meta.RepoURL, meta.Revision)
+			if err != nil {
+				return nil, err
+			}
+		}
+		res[i] = meta
+	}
+	return res, nil
+}
+
 func fullNameByRepoURL(rawURL string) string {
 	parsed, err := giturls.Parse(rawURL)
 	if err != nil {
@@ -96,6 +219,30 @@ func NewExprs(argocdService argocd.Service, app *unstructured.Unstructured) map[
 
 			return *meta
 		},
+		"GetSourceCommitMetadata": func(index int, commitSHA string) interface{} {
+			meta, err := getSourceCommitMetadata(index, commitSHA, app, argocdService)
+			if err != nil {
+				panic(err)
+			}
+
+			return *meta
+		},
+		"GetSources": func() interface{} {
+			sources, err := getSourcesMetadata(app, argocdService)
+			if err != nil {
+				panic(err)
+			}
+
+			return sources
+		},
+		"GetHelmValues": func() interface{} {
+			values, err := getHelmValues(app, argocdService)
+			if err != nil {
+				panic(err)
+			}
+
+			return *values
+		},
 		"GetAppDetails": func() interface{} {
 			appDetails, err := getAppDetails(app, argocdService)
 			if err != nil {
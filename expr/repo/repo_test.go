@@ -4,8 +4,10 @@ import (
 	"context"
 	"testing"
 
+	"github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/argoproj-labs/argocd-notifications/expr/shared"
 	"github.com/argoproj-labs/argocd-notifications/shared/argocd/mocks"
@@ -51,3 +53,111 @@ func TestGetCommitMetadata(t *testing.T) {
 	assert.Equal(t, expectedMeta, commitMeta)
 
 }
+
+func newMultiSourceApp() *unstructured.Unstructured {
+	app := NewApp("guestbook")
+	_ = unstructured.SetNestedSlice(app.Object, []interface{}{
+		map[string]interface{}{"repoURL": "http://config-repo.git", "path": "guestbook", "targetRevision": "HEAD"},
+		map[string]interface{}{"repoURL": "https://charts.example.com", "chart": "redis", "targetRevision": "17.0.0"},
+		map[string]interface{}{"repoURL": "http://values-repo.git", "targetRevision": "main"},
+	}, "spec", "sources")
+	_ = unstructured.SetNestedStringSlice(app.Object, []string{"abc", "17.0.0", "def"}, "status", "sync", "revisions")
+	return app
+}
+
+func TestGetSourceCommitMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	argocdService := mocks.NewMockService(ctrl)
+	expectedMeta := &shared.CommitMetadata{Message: "hello"}
+	argocdService.EXPECT().GetCommitMetadata(context.Background(), "http://values-repo.git", "def").Return(expectedMeta, nil)
+	commitMeta, err := getSourceCommitMetadata(2, "def", newMultiSourceApp(), argocdService)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, expectedMeta, commitMeta)
+}
+
+func TestGetSourcesMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	argocdService := mocks.NewMockService(ctrl)
+	configMeta := &shared.CommitMetadata{Message: "update guestbook", Author: "alice"}
+	valuesMeta := &shared.CommitMetadata{Message: "bump replicas", Author: "bob"}
+	argocdService.EXPECT().GetCommitMetadata(context.Background(), "http://config-repo.git", "abc").Return(configMeta, nil)
+	argocdService.EXPECT().GetCommitMetadata(context.Background(), "http://values-repo.git", "def").Return(valuesMeta, nil)
+
+	sources, err := getSourcesMetadata(newMultiSourceApp(), argocdService)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []shared.SourceMetadata{{
+		RepoURL: "http://config-repo.git", Path: "guestbook", TargetRevision: "HEAD", Revision: "abc", CommitMetadata: configMeta,
+	}, {
+		RepoURL: "https://charts.example.com", Chart: "redis", TargetRevision: "17.0.0", Revision: "17.0.0",
+	}, {
+		RepoURL: "http://values-repo.git", TargetRevision: "main", Revision: "def", CommitMetadata: valuesMeta,
+	}}, sources)
+}
+
+func TestGetSourcesMetadata_SingleSource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	argocdService := mocks.NewMockService(ctrl)
+	expectedMeta := &shared.CommitMetadata{Message: "hello"}
+	argocdService.EXPECT().GetCommitMetadata(context.Background(), "http://myrepo-url.git", "abc").Return(expectedMeta, nil)
+	app := NewApp("guestbook", WithRepoURL("http://myrepo-url.git"))
+	_ = unstructured.SetNestedField(app.Object, "abc", "status", "sync", "revision")
+
+	sources, err := getSourcesMetadata(app, argocdService)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []shared.SourceMetadata{{RepoURL: "http://myrepo-url.git", Revision: "abc", CommitMetadata: expectedMeta}}, sources)
+}
+
+func TestGetHelmValues(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	argocdService := mocks.NewMockService(ctrl)
+	app := NewApp("guestbook", WithRepoURL("http://myrepo-url.git"))
+	_ = unstructured.SetNestedField(app.Object, "HEAD", "spec", "source", "targetRevision")
+	_ = unstructured.SetNestedSlice(app.Object, []interface{}{
+		map[string]interface{}{"name": "image.tag", "value": "1.4.2"},
+	}, "spec", "source", "helm", "parameters")
+	_ = unstructured.SetNestedField(app.Object, "abc", "status", "sync", "revision")
+
+	argocdService.EXPECT().GetAppDetails(context.Background(), gomock.Any()).DoAndReturn(func(_ context.Context, source *v1alpha1.ApplicationSource) (*shared.AppDetail, error) {
+		assert.Equal(t, "abc", source.TargetRevision)
+		return &shared.AppDetail{Type: "Helm", Helm: &shared.HelmAppSpec{
+			ValueFiles: []string{"values.yaml"},
+			Parameters: []*v1alpha1.HelmParameter{{Name: "replicaCount", Value: "1"}, {Name: "image.tag", Value: "1.0.0"}},
+		}}, nil
+	})
+
+	values, err := getHelmValues(app, argocdService)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "abc", values.Revision)
+	assert.Equal(t, []string{"values.yaml"}, values.ValueFiles)
+	assert.Equal(t, "1.4.2", values.GetParameterValueByName("image.tag"))
+	assert.Equal(t, "1", values.GetParameterValueByName("replicaCount"))
+	assert.Equal(t, "image.tag=1.4.2", values.OverridesSummary())
+}
+
+func TestGetHelmValues_NotHelm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	argocdService := mocks.NewMockService(ctrl)
+	argocdService.EXPECT().GetAppDetails(context.Background(), gomock.Any()).Return(&shared.AppDetail{Type: "Kustomize"}, nil)
+
+	_, err := getHelmValues(NewApp("guestbook", WithRepoURL("http://myrepo-url.git")), argocdService)
+	assert.Error(t, err)
+}
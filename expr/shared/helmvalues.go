@@ -0,0 +1,42 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HelmValues holds Helm parameters and values of the synced revision of the application source
+type HelmValues struct {
+	// Revision the values are loaded from
+	Revision string
+	// Value files used to render the chart
+	ValueFiles []string
+	// Values of the chart merged with the value files
+	Values string
+	// Parameters are values of the chart and value files with overrides of the application applied
+	Parameters []HelmParameter
+	// Overrides are parameters set in the application spec
+	Overrides []HelmParameter
+	// Inline values set in the application spec
+	ValuesOverride string
+}
+
+// GetParameterValueByName returns the effective value of the parameter
+func (v HelmValues) GetParameterValueByName(name string) string {
+	for i := range v.Parameters {
+		if v.Parameters[i].Name == name {
+			return v.Parameters[i].Value
+		}
+	}
+	return ""
+}
+
+// OverridesSummary returns overrides of the application in the `name=value` format separated with comma,
+// e.g. `image.tag=1.4.2, replicaCount=3`
+func (v HelmValues) OverridesSummary() string {
+	parts := make([]string, len(v.Overrides))
+	for i := range v.Overrides {
+		parts[i] = fmt.Sprintf("%s=%s", v.Overrides[i].Name, v.Overrides[i].Value)
+	}
+	return strings.Join(parts, ", ")
+}
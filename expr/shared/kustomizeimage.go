@@ -0,0 +1,25 @@
+package shared
+
+// KustomizeImage is the image override of the Kustomize application, e.g. `nginx=registry.example.com/nginx:1.2.3`
+type KustomizeImage struct {
+	// Name of the image in the manifests
+	Name string
+	// NewName replaces the image name; same as Name if the override changes only the tag or digest
+	NewName string
+	// NewTag replaces the image tag
+	NewTag string
+	// Digest replaces the image tag with the digest
+	Digest string
+}
+
+// Image returns the image reference the override resolves to, e.g. `registry.example.com/nginx:1.2.3`
+func (i KustomizeImage) Image() string {
+	switch {
+	case i.Digest != "":
+		return i.NewName + "@" + i.Digest
+	case i.NewTag != "":
+		return i.NewName + ":" + i.NewTag
+	default:
+		return i.NewName
+	}
+}
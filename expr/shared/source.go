@@ -0,0 +1,17 @@
+package shared
+
+// SourceMetadata describes the application source and the commit synced from it
+type SourceMetadata struct {
+	// Source repository URL
+	RepoURL string
+	// Path of the manifests in the repository
+	Path string
+	// Helm chart name if the source is the Helm repository
+	Chart string
+	// Revision of the source configured in the application spec
+	TargetRevision string
+	// Revision of the source the application is synced to
+	Revision string
+	// Metadata of the synced commit; nil for Helm chart sources and sources without the synced revision
+	CommitMetadata *CommitMetadata
+}
@@ -0,0 +1,15 @@
+package shared
+
+// SyncWindow is the sync window of the application project that applies to the application
+type SyncWindow struct {
+	// Kind is either `allow` or `deny`
+	Kind string
+	// Schedule is the window start time in the cron format
+	Schedule string
+	// Duration is the window length, e.g. `1h`
+	Duration string
+	// ManualSync is true if manual syncs are allowed during the window
+	ManualSync bool
+	// Active is true if the window is open
+	Active bool
+}
@@ -0,0 +1,89 @@
+package syncwindows
+
+import (
+	"github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/argoproj-labs/argocd-notifications/expr/shared"
+)
+
+// getWindows returns sync windows of the project that apply to the application or nil if the project is unknown
+func getWindows(app *unstructured.Unstructured, proj *unstructured.Unstructured) (*v1alpha1.SyncWindows, error) {
+	if proj == nil {
+		return nil, nil
+	}
+	var appProj v1alpha1.AppProject
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(proj.Object, &appProj); err != nil {
+		return nil, err
+	}
+	if !appProj.Spec.SyncWindows.HasWindows() {
+		return nil, nil
+	}
+	// windows are matched only by the application name and destination, so other fields are not converted
+	server, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "server")
+	namespace, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "namespace")
+	return appProj.Spec.SyncWindows.Matches(&v1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: app.GetName(), Namespace: app.GetNamespace()},
+		Spec: v1alpha1.ApplicationSpec{
+			Destination: v1alpha1.ApplicationDestination{Server: server, Namespace: namespace},
+		},
+	}), nil
+}
+
+func toSyncWindows(windows *v1alpha1.SyncWindows, activeOnly bool) []shared.SyncWindow {
+	res := []shared.SyncWindow{}
+	if windows == nil {
+		return res
+	}
+	for _, w := range *windows {
+		active := w.Active()
+		if activeOnly && !active {
+			continue
+		}
+		res = append(res, shared.SyncWindow{
+			Kind:       w.Kind,
+			Schedule:   w.Schedule,
+			Duration:   w.Duration,
+			ManualSync: w.ManualSync,
+			Active:     active,
+		})
+	}
+	return res
+}
+
+// canSync returns true if the sync windows allow the automated, or manual if isManual is true, sync at the moment
+func canSync(windows *v1alpha1.SyncWindows, isManual bool) bool {
+	return !windows.HasWindows() || windows.CanSync(isManual)
+}
+
+func NewExprs(app *unstructured.Unstructured, proj *unstructured.Unstructured) map[string]interface{} {
+	// the project is converted on the first call, so applications that don't use the functions don't pay for it
+	var windows *v1alpha1.SyncWindows
+	var loaded bool
+	getAppWindows := func() *v1alpha1.SyncWindows {
+		if !loaded {
+			var err error
+			if windows, err = getWindows(app, proj); err != nil {
+				panic(err)
+			}
+			loaded = true
+		}
+		return windows
+	}
+	return map[string]interface{}{
+		"GetWindows": func() interface{} {
+			return toSyncWindows(getAppWindows(), false)
+		},
+		"GetActiveWindows": func() interface{} {
+			return toSyncWindows(getAppWindows(), true)
+		},
+		"IsSyncBlocked": func() bool {
+			return !canSync(getAppWindows(), false)
+		},
+		"IsManualSyncBlocked": func() bool {
+			return !canSync(getAppWindows(), true)
+		},
+	}
+}
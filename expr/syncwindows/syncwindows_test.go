@@ -0,0 +1,46 @@
+package syncwindows
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/expr/shared"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func withSyncWindows(windows ...map[string]interface{}) func(proj *unstructured.Unstructured) {
+	return func(proj *unstructured.Unstructured) {
+		var items []interface{}
+		for i := range windows {
+			items = append(items, windows[i])
+		}
+		_ = unstructured.SetNestedSlice(proj.Object, items, "spec", "syncWindows")
+	}
+}
+
+func TestNewExprs_DenyWindow(t *testing.T) {
+	proj := NewProject("default", withSyncWindows(map[string]interface{}{
+		// the window starts every minute and lasts an hour, so it is always active
+		"kind": "deny", "schedule": "* * * * *", "duration": "1h", "applications": []interface{}{"guest*"}, "manualSync": true,
+	}, map[string]interface{}{
+		"kind": "deny", "schedule": "* * * * *", "duration": "1h", "applications": []interface{}{"other"},
+	}))
+	exprs := NewExprs(NewApp("guestbook"), proj)
+
+	assert.True(t, exprs["IsSyncBlocked"].(func() bool)())
+	assert.False(t, exprs["IsManualSyncBlocked"].(func() bool)())
+	assert.Equal(t, []shared.SyncWindow{{Kind: "deny", Schedule: "* * * * *", Duration: "1h", ManualSync: true, Active: true}},
+		exprs["GetActiveWindows"].(func() interface{})())
+}
+
+func TestNewExprs_NoWindows(t *testing.T) {
+	for _, proj := range []*unstructured.Unstructured{nil, NewProject("default")} {
+		exprs := NewExprs(NewApp("guestbook"), proj)
+
+		assert.False(t, exprs["IsSyncBlocked"].(func() bool)())
+		assert.False(t, exprs["IsManualSyncBlocked"].(func() bool)())
+		assert.Empty(t, exprs["GetWindows"].(func() interface{})())
+	}
+}
@@ -8,11 +8,17 @@ require (
 	github.com/antonmedv/expr v1.8.9
 	github.com/argoproj/argo-cd v1.8.0
 	github.com/argoproj/gitops-engine v0.2.1
+	github.com/aws/aws-sdk-go v1.33.16
+	github.com/containerd/containerd v1.3.4
+	github.com/coreos/go-oidc v2.1.0+incompatible
+	github.com/deislabs/oras v0.8.1
 	github.com/evanphx/json-patch v4.9.0+incompatible
 	github.com/ghodss/yaml v1.0.0
+	github.com/go-redis/redis/v8 v8.3.2
 	github.com/go-telegram-bot-api/telegram-bot-api v4.6.4+incompatible
 	github.com/golang/mock v1.4.4
-	github.com/grpc-ecosystem/go-grpc-middleware v1.2.0 // indirect
+	github.com/golang/protobuf v1.4.3
+	github.com/grpc-ecosystem/go-grpc-middleware v1.2.0
 	github.com/huandu/xstrings v1.3.0 // indirect
 	github.com/imdario/mergo v0.3.8 // indirect
 	github.com/mitchellh/copystructure v1.0.0 // indirect
@@ -26,7 +32,14 @@ require (
 	github.com/stretchr/testify v1.6.1
 	github.com/technoweenie/multipartstreamer v1.0.1 // indirect
 	github.com/whilp/git-urls v0.0.0-20191001220047-6db9661140c0
+	golang.org/x/net v0.0.0-20201024042810-be3efd7ff127
+	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
+	golang.org/x/sync v0.0.0-20201020160332-67f06af15bc9
+	golang.org/x/time v0.0.0-20191024005414-555d28b269f0
 	gomodules.xyz/notify v0.1.0
+	google.golang.org/grpc v1.29.1
+	gopkg.in/square/go-jose.v2 v2.2.2
+	gopkg.in/src-d/go-git.v4 v4.13.1
 	k8s.io/api v0.19.2
 	k8s.io/apimachinery v0.19.2
 	k8s.io/client-go v11.0.1-0.20190816222228-6d55c1b1f1ca+incompatible
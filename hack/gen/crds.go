@@ -0,0 +1,224 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+)
+
+const modulePath = "github.com/argoproj-labs/argocd-notifications"
+
+// crd describes the custom resource which spec schema is generated from the Go type
+type crd struct {
+	kind           string
+	plural         string
+	description    string
+	printerColumns []map[string]interface{}
+	spec           func(g *schemaGenerator) map[string]interface{}
+}
+
+var crds = []crd{{
+	kind:        "NotificationTrigger",
+	plural:      "notificationtriggers",
+	description: "NotificationTrigger defines the condition when the notification should be sent",
+	spec: func(g *schemaGenerator) map[string]interface{} {
+		condition := g.schemaOf(reflect.TypeOf(triggers.Condition{}))
+		condition["required"] = []string{"when", "send"}
+		return map[string]interface{}{
+			"type":     "object",
+			"required": []string{"conditions"},
+			"properties": map[string]interface{}{
+				"conditions": map[string]interface{}{"type": "array", "minItems": 1, "items": condition},
+			},
+		}
+	},
+}, {
+	kind:        "NotificationTemplate",
+	plural:      "notificationtemplates",
+	description: "NotificationTemplate defines the notification content",
+	spec: func(g *schemaGenerator) map[string]interface{} {
+		return g.schemaOf(reflect.TypeOf(services.Notification{}))
+	},
+}, {
+	kind:           "NotificationService",
+	plural:         "notificationservices",
+	description:    "NotificationService defines the notification service settings",
+	printerColumns: []map[string]interface{}{{"jsonPath": ".spec.type", "name": "Type", "type": "string"}},
+	spec: func(g *schemaGenerator) map[string]interface{} {
+		// service options depend on the service type, so only the type is validated
+		return map[string]interface{}{
+			"type":                                 "object",
+			"required":                             []string{"type"},
+			"x-kubernetes-preserve-unknown-fields": true,
+			"properties": map[string]interface{}{
+				"type": map[string]interface{}{"type": "string", "enum": services.ServiceTypes},
+			},
+		}
+	},
+}, {
+	kind:        subscriptions.ResourceKind,
+	plural:      "notificationsubscriptions",
+	description: "NotificationSubscription subscribes destinations to the notifications of matching applications",
+	printerColumns: []map[string]interface{}{
+		{"jsonPath": ".spec.selector", "name": "Selector", "type": "string"},
+		{"jsonPath": ".spec.project", "name": "Project", "type": "string"},
+	},
+	spec: func(g *schemaGenerator) map[string]interface{} {
+		spec := g.schemaOf(reflect.TypeOf(subscriptions.ResourceSpec{}))
+		spec["required"] = []string{"destinations"}
+		destinations := spec["properties"].(map[string]interface{})["destinations"].(map[string]interface{})
+		destinations["minItems"] = 1
+		destinations["items"].(map[string]interface{})["required"] = []string{"service"}
+		return spec
+	},
+}}
+
+func newCRDsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use: "crds",
+		Run: func(c *cobra.Command, args []string) {
+			for _, resource := range crds {
+				data, err := generateCRD(resource, ".")
+				dieOnError(err, "Failed to generate "+resource.kind+" CRD")
+				err = ioutil.WriteFile(crdPath(".", resource), data, 0644)
+				dieOnError(err, "Failed to write "+resource.kind+" CRD")
+			}
+		},
+	}
+}
+
+func crdPath(root string, resource crd) string {
+	return path.Join(root, "manifests/crds", strings.ToLower(resource.kind)+"-crd.yaml")
+}
+
+// generateCRD returns the CRD manifest; root is the repository root used to read doc comments of the spec fields
+func generateCRD(resource crd, root string) ([]byte, error) {
+	g := &schemaGenerator{root: root, docs: map[string]map[string]string{}}
+	version := map[string]interface{}{
+		"name":    "v1alpha1",
+		"served":  true,
+		"storage": true,
+		"schema": map[string]interface{}{
+			"openAPIV3Schema": map[string]interface{}{
+				"description": resource.description,
+				"type":        "object",
+				"properties": map[string]interface{}{
+					"apiVersion": map[string]interface{}{"type": "string"},
+					"kind":       map[string]interface{}{"type": "string"},
+					"metadata":   map[string]interface{}{"type": "object"},
+					"spec":       resource.spec(g),
+				},
+			},
+		},
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+	if len(resource.printerColumns) > 0 {
+		version["additionalPrinterColumns"] = resource.printerColumns
+	}
+	return yaml.Marshal(map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": resource.plural + ".argoproj.io"},
+		"spec": map[string]interface{}{
+			"group": "argoproj.io",
+			"names": map[string]interface{}{
+				"kind":     resource.kind,
+				"listKind": resource.kind + "List",
+				"plural":   resource.plural,
+				"singular": strings.ToLower(resource.kind),
+			},
+			"scope":    "Namespaced",
+			"versions": []interface{}{version},
+		},
+	})
+}
+
+// schemaGenerator builds OpenAPI v3 schemas of Go types using JSON tags and doc comments of struct fields
+type schemaGenerator struct {
+	root string
+	// docs maps the type name qualified by the package path to doc comments of the type fields
+	docs map[string]map[string]string
+	err  error
+}
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schemaOf(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		docs := g.fieldDocs(t)
+		properties := map[string]interface{}{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			schema := g.schemaOf(field.Type)
+			if doc := docs[field.Name]; doc != "" {
+				schema["description"] = doc
+			}
+			properties[name] = schema
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	}
+	return map[string]interface{}{"x-kubernetes-preserve-unknown-fields": true}
+}
+
+// fieldDocs returns doc comments of the struct fields declared in the repository package of the type
+func (g *schemaGenerator) fieldDocs(t reflect.Type) map[string]string {
+	key := t.PkgPath() + "." + t.Name()
+	if docs, ok := g.docs[key]; ok {
+		return docs
+	}
+	docs := map[string]string{}
+	g.docs[key] = docs
+	if !strings.HasPrefix(t.PkgPath(), modulePath+"/") {
+		return docs
+	}
+	pkgs, err := parser.ParseDir(token.NewFileSet(), path.Join(g.root, strings.TrimPrefix(t.PkgPath(), modulePath+"/")), nil, parser.ParseComments)
+	if err != nil {
+		g.err = err
+		return docs
+	}
+	for _, pkg := range pkgs {
+		ast.Inspect(pkg, func(node ast.Node) bool {
+			spec, ok := node.(*ast.TypeSpec)
+			if !ok || spec.Name.Name != t.Name() {
+				return true
+			}
+			if structType, ok := spec.Type.(*ast.StructType); ok {
+				for _, field := range structType.Fields.List {
+					for _, name := range field.Names {
+						docs[name.Name] = strings.Join(strings.Fields(field.Doc.Text()), " ")
+					}
+				}
+			}
+			return false
+		})
+	}
+	return docs
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCRDs_UpToDate fails if the CRD manifests are behind the Go types; run `make crds` to regenerate them
+func TestCRDs_UpToDate(t *testing.T) {
+	for _, resource := range crds {
+		expected, err := generateCRD(resource, "../..")
+		if !assert.NoError(t, err) {
+			return
+		}
+		actual, err := ioutil.ReadFile(crdPath("../..", resource))
+		if assert.NoError(t, err) {
+			assert.Equal(t, string(expected), string(actual), "%s CRD is out of date", resource.kind)
+		}
+	}
+}
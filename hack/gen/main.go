@@ -33,6 +33,7 @@ func main() {
 	}
 	command.AddCommand(newDocsCommand())
 	command.AddCommand(newCatalogCommand())
+	command.AddCommand(newCRDsCommand())
 
 	if err := command.Execute(); err != nil {
 		fmt.Println(err)
@@ -150,9 +151,14 @@ func generateBuiltInTriggersDocs(out io.Writer, triggers map[string][]triggers.C
 }
 
 func generateCommandsDocs(out io.Writer) error {
-	toolsCmd := tools.NewToolsCommand()
+	toolsCmd := tools.NewToolsCommand(tools.DefaultCLIName, tools.DefaultCLIName)
 	for _, subCommand := range toolsCmd.Commands() {
-		for _, c := range subCommand.Commands() {
+		commands := subCommand.Commands()
+		// commands without sub-commands, e.g. lint, are documented directly
+		if len(commands) == 0 {
+			commands = []*cobra.Command{subCommand}
+		}
+		for _, c := range commands {
 			var cmdDesc bytes.Buffer
 			if err := doc.GenMarkdown(c, &cmdDesc); err != nil {
 				return err
@@ -18,6 +18,7 @@ const (
 // API provides high level interface to send notifications and manage notification services
 type API interface {
 	Send(vars map[string]interface{}, templates []string, dest services.Destination) error
+	FormatNotification(vars map[string]interface{}, templates []string, dest services.Destination) (*services.Notification, error)
 	RunTrigger(triggerName string, vars map[string]interface{}) ([]triggers.ConditionResult, error)
 	AddNotificationService(name string, service services.NotificationService)
 	GetNotificationServices() map[string]services.NotificationService
@@ -46,18 +47,23 @@ func (n *api) Send(vars map[string]interface{}, templates []string, dest service
 		return fmt.Errorf("notification service '%s' is not supported", dest.Service)
 	}
 
+	notification, err := n.FormatNotification(vars, templates, dest)
+	if err != nil {
+		return err
+	}
+
+	return notificationService.Send(*notification, dest)
+}
+
+// FormatNotification renders the notification that would be sent to the specified destination
+func (n *api) FormatNotification(vars map[string]interface{}, templates []string, dest services.Destination) (*services.Notification, error) {
 	in := make(map[string]interface{})
 	for k := range vars {
 		in[k] = vars[k]
 	}
 	in[serviceTypeVarName] = dest.Service
 	in[recipientVarName] = dest.Recipient
-	notification, err := n.templatesService.FormatNotification(in, templates...)
-	if err != nil {
-		return err
-	}
-
-	return notificationService.Send(*notification, dest)
+	return n.templatesService.FormatNotification(in, templates...)
 }
 
 func (n *api) RunTrigger(triggerName string, vars map[string]interface{}) ([]triggers.ConditionResult, error) {
@@ -78,7 +84,7 @@ func NewAPI(cfg Config) (*api, error) {
 	if err != nil {
 		return nil, err
 	}
-	templatesService, err := templates.NewService(cfg.Templates)
+	templatesService, err := templates.NewServiceWithLimits(cfg.Templates, cfg.TemplateLimits)
 	if err != nil {
 		return nil, err
 	}
@@ -6,7 +6,11 @@ import (
 	"strings"
 
 	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/templates"
 	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/redact"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/signing"
 
 	"github.com/ghodss/yaml"
 	log "github.com/sirupsen/logrus"
@@ -44,25 +48,235 @@ type Config struct {
 	Services  map[string]ServiceFactory
 	Triggers  map[string][]triggers.Condition
 	Templates map[string]services.Notification
+	// TemplateLimits limits the rendering time and the size of notifications
+	TemplateLimits templates.Limits
+	// SecretValues are the values of secrets and sensitive service settings that must not appear in logs
+	SecretValues []string
+	// ServiceTypes maps service names to service types, e.g. the service configured by the service.email.ops key is
+	// named ops and has the email type
+	ServiceTypes map[string]string
 }
 
-var keyPattern = regexp.MustCompile(`[$][\w-_]+`)
+// egressPolicies are loaded from the file managed by cluster administrators rather than the config map, so the modified
+// notifications config cannot lift the restriction
+var egressPolicies map[string]httputil.EgressPolicy
+
+// SetEgressPolicies sets policies that restrict hosts services of each type are allowed to connect to. The `*` policy
+// applies to service types that are not listed, service types without a policy are not restricted
+func SetEgressPolicies(policies map[string]httputil.EgressPolicy) {
+	egressPolicies = policies
+}
+
+// KeyPattern matches references to secret keys, e.g. `$slack-token`, and external secrets, e.g. `$vault:secret/slack#token`.
+// The first group is the key or the source name and the second group is the optional key of the source prefixed with colon
+var KeyPattern = regexp.MustCompile(`[$]([\w-_]+)(:[\w-_./#{}]+)?`)
+
+// serviceDefaults holds settings configured using the global config map keys and applied to every service
+type serviceDefaults struct {
+	retry          services.RetryOptions
+	concurrency    services.ConcurrencyOptions
+	rateLimit      services.RateLimitOptions
+	circuitBreaker services.CircuitBreakerOptions
+	proxy          *httputil.ProxyOptions
+	http           *httputil.ClientOptions
+}
+
+// serviceDefaultsParsers parse global config map keys into service defaults
+var serviceDefaultsParsers = []struct {
+	key   string
+	parse func(data []byte, defaults *serviceDefaults) error
+}{{
+	key: "retry",
+	parse: func(data []byte, defaults *serviceDefaults) error {
+		var retry services.RetryOptions
+		if err := yaml.Unmarshal(data, &retry); err != nil {
+			return fmt.Errorf("failed to unmarshal retry settings: %v", err)
+		}
+		defaults.retry = defaults.retry.Merge(retry)
+		return nil
+	},
+}, {
+	key: "concurrency",
+	parse: func(data []byte, defaults *serviceDefaults) error {
+		var concurrency services.ConcurrencyOptions
+		if err := yaml.Unmarshal(data, &concurrency); err != nil {
+			return fmt.Errorf("failed to unmarshal concurrency settings: %v", err)
+		}
+		defaults.concurrency = defaults.concurrency.Merge(concurrency)
+		return nil
+	},
+}, {
+	key: "rateLimit",
+	parse: func(data []byte, defaults *serviceDefaults) error {
+		var rateLimit services.RateLimitOptions
+		if err := yaml.Unmarshal(data, &rateLimit); err != nil {
+			return fmt.Errorf("failed to unmarshal rate limit settings: %v", err)
+		}
+		defaults.rateLimit = defaults.rateLimit.Merge(rateLimit)
+		return nil
+	},
+}, {
+	key: "circuitBreaker",
+	parse: func(data []byte, defaults *serviceDefaults) error {
+		var circuitBreaker services.CircuitBreakerOptions
+		if err := yaml.Unmarshal(data, &circuitBreaker); err != nil {
+			return fmt.Errorf("failed to unmarshal circuit breaker settings: %v", err)
+		}
+		defaults.circuitBreaker = defaults.circuitBreaker.Merge(circuitBreaker)
+		return nil
+	},
+}, {
+	key: "proxy",
+	parse: func(data []byte, defaults *serviceDefaults) error {
+		defaults.proxy = &httputil.ProxyOptions{}
+		if err := yaml.Unmarshal(data, defaults.proxy); err != nil {
+			return fmt.Errorf("failed to unmarshal proxy settings: %v", err)
+		}
+		return defaults.proxy.Validate()
+	},
+}, {
+	key: "http",
+	parse: func(data []byte, defaults *serviceDefaults) error {
+		defaults.http = &httputil.ClientOptions{}
+		if err := yaml.Unmarshal(data, defaults.http); err != nil {
+			return fmt.Errorf("failed to unmarshal http client settings: %v", err)
+		}
+		if err := defaults.http.Validate(); err != nil {
+			return fmt.Errorf("invalid http client settings: %v", err)
+		}
+		return nil
+	},
+}}
+
+// GlobalServiceKeys returns the config map keys with settings that ParseConfig applies to every service
+func GlobalServiceKeys() []string {
+	keys := make([]string, len(serviceDefaultsParsers))
+	for i := range serviceDefaultsParsers {
+		keys[i] = serviceDefaultsParsers[i].key
+	}
+	return keys
+}
+
+func parseServiceDefaults(configMap *v1.ConfigMap) (*serviceDefaults, error) {
+	defaults := &serviceDefaults{
+		retry:          services.DefaultRetryOptions,
+		concurrency:    services.DefaultConcurrencyOptions,
+		rateLimit:      services.DefaultRateLimitOptions,
+		circuitBreaker: services.DefaultCircuitBreakerOptions,
+	}
+	for _, parser := range serviceDefaultsParsers {
+		if data, ok := configMap.Data[parser.key]; ok {
+			if err := parser.parse([]byte(data), defaults); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return defaults, nil
+}
+
+// SecretResolver resolves references to the values stored outside of the notifications secret. The reference
+// has the `$<source>:<key>` format, e.g. `$my-secret:slack-token` references the key of the Secret my-secret
+type SecretResolver interface {
+	Resolve(source string, key string) (string, error)
+}
 
 // replaceStringSecret checks if given string is a secret key reference ( starts with $ ) and returns corresponding value from provided map
-func replaceStringSecret(val string, secretValues map[string][]byte) string {
-	return keyPattern.ReplaceAllStringFunc(val, func(secretKey string) string {
+// or the value returned by the resolver if the reference includes the source. Unresolved references are kept as is and returned
+func replaceStringSecret(val string, secretValues map[string][]byte, resolver SecretResolver) (string, []string) {
+	var unresolved []string
+	res := KeyPattern.ReplaceAllStringFunc(val, func(ref string) string {
+		secretKey, suffix := ref, ""
+		if i := strings.Index(ref, ":"); i > 0 {
+			if resolver != nil {
+				secretVal, err := resolver.Resolve(ref[1:i], ref[i+1:])
+				if err == nil {
+					return secretVal
+				}
+				log.Debugf("failed to resolve '%s', trying key '%s' of the notifications secret: %v", ref, ref[1:i], err)
+			}
+			// reference might be followed by colon, e.g. $host:8080
+			secretKey, suffix = ref[:i], ref[i:]
+		}
 		secretVal, ok := secretValues[secretKey[1:]]
 		if !ok {
-			log.Warnf("config referenced '%s', but key does not exist in secret", val)
-			return secretKey
+			unresolved = append(unresolved, ref)
+			return ref
 		}
-		return string(secretVal)
+		return string(secretVal) + suffix
 	})
+	return res, unresolved
+}
+
+// ExternalSources is implemented by resolvers of references to external secret stores, e.g. Vault. References to
+// these stores fail ParseConfig if they are not resolved
+type ExternalSources interface {
+	IsExternalSource(source string) bool
+}
+
+func isExternalSource(resolver SecretResolver, source string) bool {
+	sources, ok := resolver.(ExternalSources)
+	return ok && sources.IsExternalSource(source)
+}
+
+// recordingResolver remembers resolved values, so they can be redacted from logs
+type recordingResolver struct {
+	resolver SecretResolver
+	values   []string
+}
+
+func (r *recordingResolver) Resolve(source string, key string) (string, error) {
+	val, err := r.resolver.Resolve(source, key)
+	if err == nil {
+		r.values = append(r.values, val)
+	}
+	return val, err
+}
+
+func (r *recordingResolver) IsExternalSource(source string) bool {
+	return isExternalSource(r.resolver, source)
+}
+
+// ResolveSecretRefs replaces references to the notifications secret keys and external secrets in the given value and
+// returns an error if any reference is not resolved, so the literal reference is never used as the secret
+func ResolveSecretRefs(val string, secret *v1.Secret, resolver SecretResolver) (string, error) {
+	res, unresolved := replaceStringSecret(val, secret.Data, resolver)
+	if len(unresolved) > 0 {
+		return "", fmt.Errorf("secret reference '%s' is not resolved", unresolved[0])
+	}
+	return res, nil
 }
 
 // ParseConfig retrieves Config from given ConfigMap and Secret
-func ParseConfig(configMap *v1.ConfigMap, secret *v1.Secret) (*Config, error) {
-	cfg := Config{map[string]ServiceFactory{}, map[string][]triggers.Condition{}, map[string]services.Notification{}}
+func ParseConfig(configMap *v1.ConfigMap, secret *v1.Secret, resolver SecretResolver) (*Config, error) {
+	cfg := Config{map[string]ServiceFactory{}, map[string][]triggers.Condition{}, map[string]services.Notification{}, templates.DefaultLimits, nil, map[string]string{}}
+	if secret != nil {
+		for _, val := range secret.Data {
+			cfg.SecretValues = append(cfg.SecretValues, string(val))
+		}
+	}
+	var recorder *recordingResolver
+	if resolver != nil {
+		recorder = &recordingResolver{resolver: resolver}
+		resolver = recorder
+	}
+	if limitsYaml, ok := configMap.Data["templateLimits"]; ok {
+		var limits templates.Limits
+		if err := yaml.Unmarshal([]byte(limitsYaml), &limits); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal template limits: %v", err)
+		}
+		if err := limits.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid template limits: %v", err)
+		}
+		cfg.TemplateLimits = cfg.TemplateLimits.Merge(limits)
+	}
+	defaults, err := parseServiceDefaults(configMap)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := configMap.Data["egress"]; ok {
+		log.Warn("The egress key of the config map is ignored: egress policies are configured using the --egress-policy-file flag")
+	}
+	egress := egressPolicies
 	for k, v := range configMap.Data {
 		parts := strings.Split(k, ".")
 		switch {
@@ -74,7 +288,20 @@ func ParseConfig(configMap *v1.ConfigMap, secret *v1.Secret) (*Config, error) {
 			}
 			cfg.Templates[name] = template
 		case strings.HasPrefix(k, "service."):
-			v = replaceStringSecret(v, secret.Data)
+			var unresolved []string
+			v, unresolved = replaceStringSecret(v, secret.Data, resolver)
+			for _, ref := range unresolved {
+				// references to external secret stores, e.g. $vault:secret/slack#token, must not be used as the
+				// literal value; other references might be secret keys followed by colon, e.g. $host:8080
+				if i := strings.Index(ref, ":"); i > 0 && isExternalSource(resolver, ref[1:i]) {
+					return nil, fmt.Errorf("failed to resolve secret reference '%s' of %s", ref, k)
+				}
+				log.Warnf("config referenced '%s', but key does not exist in secret", ref)
+			}
+			var settings interface{}
+			if err := yaml.Unmarshal([]byte(v), &settings); err == nil {
+				cfg.SecretValues = append(cfg.SecretValues, redact.SensitiveValues(settings)...)
+			}
 			name := ""
 			serviceType := ""
 			parts := strings.Split(k, ".")
@@ -87,8 +314,134 @@ func ParseConfig(configMap *v1.ConfigMap, secret *v1.Secret) (*Config, error) {
 			}
 
 			optsData := []byte(v)
+			serviceOpts := struct {
+				Retry          services.RetryOptions          `json:"retry"`
+				Concurrency    services.ConcurrencyOptions    `json:"concurrency"`
+				RateLimit      services.RateLimitOptions      `json:"rateLimit"`
+				CircuitBreaker services.CircuitBreakerOptions `json:"circuitBreaker"`
+				Proxy          *httputil.ProxyOptions         `json:"proxy"`
+				TLS            *httputil.TLSOptions           `json:"tls"`
+				HTTP           *httputil.ClientOptions        `json:"http"`
+				Signing        *signing.Options               `json:"signing"`
+			}{}
+			if err := yaml.Unmarshal(optsData, &serviceOpts); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal service %s: %v", name, err)
+			}
+			if serviceOpts.Proxy != nil {
+				if err := serviceOpts.Proxy.Validate(); err != nil {
+					return nil, fmt.Errorf("invalid proxy settings of service %s: %v", name, err)
+				}
+			} else if defaults.proxy != nil {
+				data, err := withField(optsData, "proxy", defaults.proxy)
+				if err != nil {
+					return nil, fmt.Errorf("failed to unmarshal service %s: %v", name, err)
+				}
+				optsData = data
+			}
+			if serviceOpts.HTTP != nil {
+				if err := serviceOpts.HTTP.Validate(); err != nil {
+					return nil, fmt.Errorf("invalid http client settings of service %s: %v", name, err)
+				}
+			}
+			egressPolicy, restricted := egress[serviceType]
+			if !restricted {
+				egressPolicy, restricted = egress["*"]
+			}
+			if defaults.http != nil || restricted {
+				var httpOpts httputil.ClientOptions
+				if defaults.http != nil {
+					httpOpts = *defaults.http
+				}
+				if serviceOpts.HTTP != nil {
+					httpOpts = httpOpts.Merge(*serviceOpts.HTTP)
+				}
+				if restricted {
+					httpOpts.Egress = egressPolicy
+				}
+				data, err := withField(optsData, "http", httpOpts)
+				if err != nil {
+					return nil, fmt.Errorf("failed to unmarshal service %s: %v", name, err)
+				}
+				optsData = data
+			}
+			if restricted {
+				// services that don't use HTTP, e.g. email, read the policy from the top level field
+				data, err := withField(optsData, "egress", egressPolicy)
+				if err != nil {
+					return nil, fmt.Errorf("failed to unmarshal service %s: %v", name, err)
+				}
+				optsData = data
+			}
+			if serviceOpts.TLS != nil {
+				tlsOpts := *serviceOpts.TLS
+				if tlsOpts.CABundleSecretKey != "" {
+					caBundle, ok := secret.Data[tlsOpts.CABundleSecretKey]
+					if !ok {
+						return nil, fmt.Errorf("CA bundle secret key '%s' of service %s is not found", tlsOpts.CABundleSecretKey, name)
+					}
+					tlsOpts.CABundle = strings.TrimSpace(tlsOpts.CABundle + "\n" + string(caBundle))
+					tlsOpts.CABundleSecretKey = ""
+					data, err := withField(optsData, "tls", tlsOpts)
+					if err != nil {
+						return nil, fmt.Errorf("failed to unmarshal service %s: %v", name, err)
+					}
+					optsData = data
+				}
+				if err := tlsOpts.Validate(); err != nil {
+					return nil, fmt.Errorf("invalid TLS settings of service %s: %v", name, err)
+				}
+			}
+			var signer *signing.Signer
+			if serviceOpts.Signing != nil && serviceOpts.Signing.Enabled() {
+				if !supportsSigning(serviceType) {
+					return nil, fmt.Errorf("service %s of type %s does not support signing", name, serviceType)
+				}
+				signingOpts := *serviceOpts.Signing
+				if signingOpts.PrivateKeySecretKey != "" {
+					privateKey, ok := secret.Data[signingOpts.PrivateKeySecretKey]
+					if !ok {
+						return nil, fmt.Errorf("signing private key secret key '%s' of service %s is not found", signingOpts.PrivateKeySecretKey, name)
+					}
+					signingOpts.PrivateKey = string(privateKey)
+				}
+				// the signer is created once per service, so the private key is not parsed for every notification
+				if signer, err = signing.NewSigner(signingOpts); err != nil {
+					return nil, fmt.Errorf("invalid signing settings of service %s: %v", name, err)
+				}
+			}
+			retryOpts := defaults.retry.Merge(serviceOpts.Retry)
+			// limiter and circuit breaker are created once per service so the state is shared by all service instances
+			limiter, err := services.NewConcurrencyLimiter(defaults.concurrency.Merge(serviceOpts.Concurrency))
+			if err != nil {
+				return nil, fmt.Errorf("invalid concurrency settings of service %s: %v", name, err)
+			}
+			rateLimiter, err := services.NewRateLimiter(defaults.rateLimit.Merge(serviceOpts.RateLimit))
+			if err != nil {
+				return nil, fmt.Errorf("invalid rate limit settings of service %s: %v", name, err)
+			}
+			breaker, err := services.NewCircuitBreaker(name, serviceType, defaults.circuitBreaker.Merge(serviceOpts.CircuitBreaker))
+			if err != nil {
+				return nil, fmt.Errorf("invalid circuit breaker settings of service %s: %v", name, err)
+			}
+			cfg.ServiceTypes[name] = serviceType
 			cfg.Services[name] = func() (services.NotificationService, error) {
-				return services.NewService(serviceType, optsData)
+				newService := services.NewService
+				if signer != nil {
+					newService = func(serviceType string, optsData []byte) (services.NotificationService, error) {
+						return services.NewSignedService(serviceType, optsData, signer)
+					}
+				}
+				svc, err := newService(serviceType, optsData)
+				if err != nil {
+					return nil, err
+				}
+				svc, err = services.NewRetryService(svc, serviceType, retryOpts)
+				if err != nil {
+					return nil, err
+				}
+				svc = services.NewCircuitBreakerService(svc, breaker)
+				// the delivery waits for its turn before it takes the concurrency slot
+				return services.NewRateLimitedService(services.NewConcurrencyLimitedService(svc, limiter), rateLimiter), nil
 			}
 		case strings.HasPrefix(k, "trigger."):
 			name := strings.Join(parts[1:], ".")
@@ -99,5 +452,30 @@ func ParseConfig(configMap *v1.ConfigMap, secret *v1.Secret) (*Config, error) {
 			cfg.Triggers[name] = trigger
 		}
 	}
+	if recorder != nil {
+		cfg.SecretValues = append(cfg.SecretValues, recorder.values...)
+	}
 	return &cfg, nil
 }
+
+func supportsSigning(serviceType string) bool {
+	for _, t := range services.SigningServiceTypes {
+		if t == serviceType {
+			return true
+		}
+	}
+	return false
+}
+
+// withField returns service settings with the specified field added
+func withField(optsData []byte, field string, val interface{}) ([]byte, error) {
+	var opts map[string]interface{}
+	if err := yaml.Unmarshal(optsData, &opts); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = map[string]interface{}{}
+	}
+	opts[field] = val
+	return yaml.Marshal(opts)
+}
@@ -1,10 +1,13 @@
 package pkg
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
 
+	"github.com/ghodss/yaml"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 )
@@ -17,7 +20,7 @@ func TestParseConfig_Services(t *testing.T) {
 	cfg, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
 		"service.slack": `
 token: my-token
-`}}, emptySecret)
+`}}, emptySecret, nil)
 
 	if !assert.NoError(t, err) {
 		return
@@ -26,11 +29,113 @@ token: my-token
 	assert.NotNil(t, cfg.Services["slack"])
 }
 
+func TestGlobalServiceKeys(t *testing.T) {
+	keys := GlobalServiceKeys()
+	assert.Contains(t, keys, "retry")
+	assert.Contains(t, keys, "http")
+	// every global key is parsed by ParseConfig
+	for _, key := range keys {
+		_, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{key: "invalid"}}, emptySecret, nil)
+		assert.Error(t, err, key)
+	}
+}
+
+func TestKeyPattern(t *testing.T) {
+	match := KeyPattern.FindStringSubmatch("token: $vault:secret/slack#token")
+	assert.Equal(t, []string{"$vault:secret/slack#token", "vault", ":secret/slack#token"}, match)
+}
+
+func TestParseConfig_InvalidConcurrency(t *testing.T) {
+	_, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"service.slack": `
+token: my-token
+concurrency:
+  maxConcurrent: 1
+  queueTimeout: abc
+`}}, emptySecret, nil)
+
+	assert.Error(t, err)
+}
+
+func TestParseConfig_InvalidRateLimit(t *testing.T) {
+	_, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"rateLimit": `
+rate: 5
+maxWait: abc
+`,
+		"service.slack": `
+token: my-token
+`}}, emptySecret, nil)
+
+	assert.Error(t, err)
+}
+
+func TestParseConfig_InvalidProxy(t *testing.T) {
+	_, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"proxy": `
+url: ftp://proxy
+`}}, emptySecret, nil)
+
+	assert.Error(t, err)
+}
+
+func TestParseConfig_MissingCABundleSecretKey(t *testing.T) {
+	_, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"service.webhook.mattermost": `
+url: https://mattermost.internal
+tls:
+  caBundleSecretKey: mattermost-ca
+`}}, emptySecret, nil)
+
+	assert.EqualError(t, err, "CA bundle secret key 'mattermost-ca' of service mattermost is not found")
+}
+
+func TestParseConfig_Signing(t *testing.T) {
+	_, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"service.webhook.github": `
+url: https://api.github.com
+signing:
+  privateKeySecretKey: signing-key
+`}}, emptySecret, nil)
+	assert.EqualError(t, err, "signing private key secret key 'signing-key' of service github is not found")
+
+	_, err = ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"service.slack": `
+token: my-token
+signing:
+  privateKey: my-key
+`}}, emptySecret, nil)
+	assert.EqualError(t, err, "service slack of type slack does not support signing")
+
+	_, err = ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"service.webhook.github": `
+url: https://api.github.com
+signing:
+  privateKeySecretKey: signing-key
+`}}, &v1.Secret{Data: map[string][]byte{"signing-key": []byte("not a key")}}, nil)
+	assert.EqualError(t, err, "invalid signing settings of service github: signing private key is not PEM encoded")
+}
+
+func TestWithField(t *testing.T) {
+	data, err := withField([]byte("url: https://api.github.com"), "proxy", httputil.ProxyOptions{URL: "http://proxy:3128"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	var opts services.WebhookOptions
+	err = yaml.Unmarshal(data, &opts)
+	assert.NoError(t, err)
+	assert.Equal(t, services.WebhookOptions{URL: "https://api.github.com", Proxy: httputil.ProxyOptions{URL: "http://proxy:3128"}}, opts)
+
+	data, err = withField(nil, "proxy", httputil.ProxyOptions{URL: "http://proxy:3128"})
+	assert.NoError(t, err)
+	assert.Equal(t, "proxy:\n  url: http://proxy:3128\n", string(data))
+}
+
 func TestParseConfig_Templates(t *testing.T) {
 	cfg, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
 		"template.my-template": `
 message: hello world
-`}}, emptySecret)
+`}}, emptySecret, nil)
 
 	if !assert.NoError(t, err) {
 		return
@@ -42,17 +147,167 @@ message: hello world
 }
 
 func TestReplaceStringSecret_KeyPresent(t *testing.T) {
-	val := replaceStringSecret("hello $secret-value", map[string][]byte{
+	val, unresolved := replaceStringSecret("hello $secret-value", map[string][]byte{
 		"secret-value": []byte("world"),
-	})
+	}, nil)
 
 	assert.Equal(t, "hello world", val)
+	assert.Empty(t, unresolved)
 }
 
 func TestReplaceStringSecret_KeyMissing(t *testing.T) {
-	val := replaceStringSecret("hello $secret-value", map[string][]byte{
+	val, unresolved := replaceStringSecret("hello $secret-value", map[string][]byte{
 		"another-secret-value": []byte("world"),
-	})
+	}, nil)
 
 	assert.Equal(t, "hello $secret-value", val)
+	assert.Equal(t, []string{"$secret-value"}, unresolved)
+}
+
+type mapSecretResolver map[string]map[string]string
+
+func (r mapSecretResolver) Resolve(source string, key string) (string, error) {
+	val, ok := r[source][key]
+	if !ok {
+		return "", fmt.Errorf("key %s of %s not found", key, source)
+	}
+	return val, nil
+}
+
+func (r mapSecretResolver) IsExternalSource(source string) bool {
+	return source == "vault"
+}
+
+func TestReplaceStringSecret_ExternalSecret(t *testing.T) {
+	resolver := mapSecretResolver{"slack-secret": {"token": "xoxb-123"}, "vault": {"secret/data/{{.namespace}}/slack#token": "xoxb-456"}}
+
+	val, _ := replaceStringSecret("token: $slack-secret:token", map[string][]byte{}, resolver)
+	assert.Equal(t, "token: xoxb-123", val)
+
+	val, _ = replaceStringSecret("token: $vault:secret/data/{{.namespace}}/slack#token", map[string][]byte{}, resolver)
+	assert.Equal(t, "token: xoxb-456", val)
+
+	val, _ = replaceStringSecret("url: $host:8080", map[string][]byte{"host": []byte("smtp.example.com")}, resolver)
+	assert.Equal(t, "url: smtp.example.com:8080", val)
+}
+
+func TestResolveSecretRefs(t *testing.T) {
+	secret := &v1.Secret{Data: map[string][]byte{"webhook-secret": []byte("abc")}}
+
+	val, err := ResolveSecretRefs("$webhook-secret", secret, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", val)
+
+	_, err = ResolveSecretRefs("$missing-secret", secret, nil)
+	assert.EqualError(t, err, "secret reference '$missing-secret' is not resolved")
+}
+
+func TestParseConfig_UnresolvedExternalSecret(t *testing.T) {
+	resolver := mapSecretResolver{"vault": {"secret/slack#token": "xoxb-123"}}
+
+	_, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"service.slack": `token: $vault:secret/missing#token`,
+	}}, emptySecret, resolver)
+	assert.EqualError(t, err, "failed to resolve secret reference '$vault:secret/missing#token' of service.slack")
+
+	_, err = ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"service.slack": `token: $vault:secret/slack#token`,
+	}}, emptySecret, resolver)
+	assert.NoError(t, err)
+
+	// missing keys of the notifications secret are only logged
+	_, err = ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"service.slack": `token: $slack-token`,
+	}}, emptySecret, resolver)
+	assert.NoError(t, err)
+
+	// references to Kubernetes secrets might be missing secret keys followed by colon
+	_, err = ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"service.webhook.github": `url: https://$missing/api`,
+		"service.slack":          `apiURL: http://$missing:8080`,
+	}}, emptySecret, resolver)
+	assert.NoError(t, err)
+}
+
+func TestParseConfig_MissingKeyWithPortNilResolver(t *testing.T) {
+	cfg, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"service.webhook.github": `url: http://$missing:8080`,
+	}}, emptySecret, nil)
+
+	if assert.NoError(t, err) {
+		assert.NotNil(t, cfg.Services["github"])
+	}
+}
+
+func TestParseConfig_InvalidHTTP(t *testing.T) {
+	_, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"service.webhook.github": `
+url: https://api.github.com
+http:
+  timeout: abc
+`}}, emptySecret, nil)
+
+	assert.Error(t, err)
+
+	_, err = ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"http": `
+connectTimeout: abc
+`}}, emptySecret, nil)
+
+	assert.Error(t, err)
+}
+
+func TestParseConfig_SecretValues(t *testing.T) {
+	cfg, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"service.slack": `
+token: $slack-token
+username: argocd
+`,
+		"service.webhook.github": `
+url: https://api.github.com
+headers:
+- name: Authorization
+  value: token $github:token
+`,
+		"service.webhook.hooks": `
+url: https://hooks.example.com/services/abc
+`}}, &v1.Secret{Data: map[string][]byte{"slack-token": []byte("xoxb-token")}},
+		mapSecretResolver{"github": {"token": "gh-token"}})
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Contains(t, cfg.SecretValues, "xoxb-token")
+	assert.Contains(t, cfg.SecretValues, "gh-token")
+	assert.Contains(t, cfg.SecretValues, "token gh-token")
+	assert.Contains(t, cfg.SecretValues, "https://hooks.example.com/services/abc")
+	assert.NotContains(t, cfg.SecretValues, "argocd")
+	assert.NotContains(t, cfg.SecretValues, "https://api.github.com")
+}
+
+func TestParseConfig_Egress(t *testing.T) {
+	SetEgressPolicies(map[string]httputil.EgressPolicy{"webhook": {"slack.com"}})
+	defer SetEgressPolicies(nil)
+	cfg, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		// the config map cannot lift the restriction
+		"egress": `
+webhook: [api.github.com]
+`,
+		"service.webhook.github": `
+url: https://api.github.com
+http:
+  egress: [api.github.com]
+`}}, emptySecret, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	svc, err := cfg.Services["github"]()
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = svc.Send(services.Notification{Webhook: services.WebhookNotifications{"github": {Method: "GET", Path: "/"}}},
+		services.Destination{Service: "github"})
+	assert.Equal(t, services.ErrorClassEgress, services.ErrorClass(err))
 }
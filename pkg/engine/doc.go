@@ -0,0 +1,18 @@
+/*
+Package engine allows embedding notification triggers, templates and services into other controllers.
+
+The engine is not specific to Argo CD applications: it evaluates triggers of any Kubernetes resource, sends
+notifications to the destinations subscribed using notifications.argoproj.io/subscribe.<trigger>.<service> annotations
+and keeps the notifications state in the notified.notifications.argoproj.io annotation of the resource:
+
+	api, err := engine.NewAPI(configMap, secret)
+	if err != nil {
+		return err
+	}
+	e := engine.New(api, engine.DefaultVars)
+	deliveries, err := e.Process(rollout, nil)
+	// persist updated rollout annotations
+
+The caller is responsible for watching resources and persisting annotations updated by the engine.
+*/
+package engine
@@ -0,0 +1,224 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+)
+
+// VarsProvider returns variables available in trigger conditions and templates. The destination is empty when
+// the variables are used to evaluate triggers
+type VarsProvider func(obj *unstructured.Unstructured, dest services.Destination) map[string]interface{}
+
+// DefaultVars exposes the resource as the 'resource' variable
+func DefaultVars(obj *unstructured.Unstructured, _ services.Destination) map[string]interface{} {
+	return map[string]interface{}{"resource": obj.Object}
+}
+
+// Delivery holds the result of the notification delivery attempt
+type Delivery struct {
+	Trigger     string
+	Templates   []string
+	Destination services.Destination
+	Error       error
+}
+
+// NewAPI returns API configured using triggers, templates and services from the config map and the secret
+func NewAPI(configMap *v1.ConfigMap, secret *v1.Secret) (pkg.API, error) {
+	cfg, err := pkg.ParseConfig(configMap, secret, nil)
+	if err != nil {
+		return nil, err
+	}
+	return pkg.NewAPI(*cfg)
+}
+
+const (
+	// OutcomeFired means the condition is true and at least one destination has not been notified yet
+	OutcomeFired = "fired"
+	// OutcomeNotFired means the condition is false
+	OutcomeNotFired = "not_fired"
+	// OutcomeSuppressed means the condition is true but all destinations have been already notified, e.g. because of oncePer
+	OutcomeSuppressed = "suppressed"
+)
+
+// Policy customizes how notifications of firing conditions are sent. The zero value sends all notifications
+type Policy struct {
+	// Suppress returns the outcome and the reason if notifications of the firing condition must not be sent, e.g.
+	// because the trigger is acknowledged or muted. Destinations are recorded as notified, so the notifications are
+	// not sent once the condition stops being suppressed
+	Suppress func(trigger string, result triggers.ConditionResult) (string, string)
+	// Allow returns false if the notification must not be sent, e.g. because it exceeds the quota. The notification is
+	// recorded as sent
+	Allow func(trigger string, result triggers.ConditionResult, dest services.Destination) bool
+}
+
+// StateUpdater records if the destination has been notified about the condition and returns true if the state has changed
+type StateUpdater func(trigger string, result triggers.ConditionResult, dest services.Destination, isNotified bool) (bool, error)
+
+// Evaluation describes how the condition result affects the subscribed destinations
+type Evaluation struct {
+	Result triggers.ConditionResult
+	// Outcome is OutcomeFired, OutcomeNotFired, OutcomeSuppressed or the outcome returned by Policy.Suppress
+	Outcome string
+	// Reason is the reason returned by Policy.Suppress
+	Reason string
+	// Pending holds destinations that must be notified
+	Pending []services.Destination
+	// AlreadyNotified holds destinations that have been already notified about the condition
+	AlreadyNotified []services.Destination
+	// Rejected holds destinations which notifications are not allowed by Policy.Allow
+	Rejected []services.Destination
+}
+
+// Evaluate updates the notifications state of the subscribed destinations using the trigger condition results and
+// returns destinations that must be notified about every condition. Pending destinations are recorded as notified, so
+// the caller must reset the state of the failed deliveries. Only errors returned by the state updater are returned.
+func Evaluate(trigger string, results []triggers.ConditionResult, destinations []services.Destination, setAlreadyNotified StateUpdater, policy Policy) ([]Evaluation, error) {
+	var res []Evaluation
+	for _, result := range results {
+		evaluation := Evaluation{Result: result}
+		if !result.Triggered {
+			evaluation.Outcome = OutcomeNotFired
+			for _, dest := range destinations {
+				if _, err := setAlreadyNotified(trigger, result, dest, false); err != nil {
+					return res, err
+				}
+			}
+			res = append(res, evaluation)
+			continue
+		}
+		if policy.Suppress != nil {
+			evaluation.Outcome, evaluation.Reason = policy.Suppress(trigger, result)
+		}
+		if evaluation.Outcome != "" {
+			for _, dest := range destinations {
+				if _, err := setAlreadyNotified(trigger, result, dest, true); err != nil {
+					return res, err
+				}
+			}
+			res = append(res, evaluation)
+			continue
+		}
+		for _, dest := range destinations {
+			changed, err := setAlreadyNotified(trigger, result, dest, true)
+			switch {
+			case err != nil:
+				return res, err
+			case !changed:
+				evaluation.AlreadyNotified = append(evaluation.AlreadyNotified, dest)
+			case policy.Allow != nil && !policy.Allow(trigger, result, dest):
+				evaluation.Rejected = append(evaluation.Rejected, dest)
+			default:
+				evaluation.Pending = append(evaluation.Pending, dest)
+			}
+		}
+		if len(evaluation.Pending) > 0 || len(evaluation.Rejected) > 0 {
+			evaluation.Outcome = OutcomeFired
+		} else {
+			evaluation.Outcome = OutcomeSuppressed
+		}
+		res = append(res, evaluation)
+	}
+	return res, nil
+}
+
+type Opts func(e *Engine)
+
+// WithPolicy configures how notifications of firing conditions are sent
+func WithPolicy(getPolicy func(obj *unstructured.Unstructured) Policy) Opts {
+	return func(e *Engine) {
+		e.getPolicy = getPolicy
+	}
+}
+
+// Engine evaluates triggers of the resource and sends notifications to the subscribed destinations
+type Engine struct {
+	api       pkg.API
+	getVars   VarsProvider
+	getPolicy func(obj *unstructured.Unstructured) Policy
+}
+
+// New returns engine that uses the API to evaluate triggers and send notifications
+func New(api pkg.API, getVars VarsProvider, opts ...Opts) *Engine {
+	if getVars == nil {
+		getVars = DefaultVars
+	}
+	e := &Engine{api: api, getVars: getVars}
+	for i := range opts {
+		opts[i](e)
+	}
+	return e
+}
+
+// GetSubscriptions returns subscriptions configured in the resource annotations
+func GetSubscriptions(obj *unstructured.Unstructured, defaultTriggers ...string) pkg.Subscriptions {
+	return resolveSubscriptions(obj, nil, defaultTriggers...)
+}
+
+// resolveSubscriptions merges subscriptions configured in the resource annotations with the additional subscriptions.
+// Unsubscribe annotations of the resource apply to the additional subscriptions as well
+func resolveSubscriptions(obj *unstructured.Unstructured, additional pkg.Subscriptions, defaultTriggers ...string) pkg.Subscriptions {
+	annotations := subscriptions.Annotations(obj.GetAnnotations())
+	res := annotations.GetAll(defaultTriggers...)
+	res.Merge(additional)
+	return annotations.RemoveUnsubscribed(res).Dedup()
+}
+
+// Process evaluates triggers subscribed using the resource annotations and additional subscriptions, sends notifications
+// that have not been sent yet and updates the state annotation of the resource. Returns attempted deliveries; failed
+// deliveries are not recorded in the state, so they are retried on the next call.
+func (e *Engine) Process(obj *unstructured.Unstructured, additional pkg.Subscriptions) ([]Delivery, error) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	resourceSubscriptions := resolveSubscriptions(obj, additional)
+
+	state := triggers.NewState(annotations[subscriptions.NotifiedAnnotationKey])
+	setAlreadyNotified := func(trigger string, result triggers.ConditionResult, dest services.Destination, isNotified bool) (bool, error) {
+		return state.SetAlreadyNotified(trigger, result, dest, isNotified), nil
+	}
+	policy := Policy{}
+	if e.getPolicy != nil {
+		policy = e.getPolicy(obj)
+	}
+	var deliveries []Delivery
+	for trigger, destinations := range resourceSubscriptions {
+		results, err := e.api.RunTrigger(trigger, e.getVars(obj, services.Destination{}))
+		if err != nil {
+			return deliveries, fmt.Errorf("failed to evaluate trigger %s: %v", trigger, err)
+		}
+		evaluations, _ := Evaluate(trigger, results, destinations, setAlreadyNotified, policy)
+		for _, evaluation := range evaluations {
+			result := evaluation.Result
+			for _, dest := range evaluation.Pending {
+				err := e.api.Send(e.getVars(obj, dest), result.Templates, dest)
+				if err != nil {
+					state.SetAlreadyNotified(trigger, result, dest, false)
+				}
+				deliveries = append(deliveries, Delivery{Trigger: trigger, Templates: result.Templates, Destination: dest, Error: err})
+			}
+		}
+	}
+
+	state.Compact(triggers.DefaultCompactOptions, time.Now())
+	if len(state) == 0 {
+		delete(annotations, subscriptions.NotifiedAnnotationKey)
+	} else {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return deliveries, err
+		}
+		annotations[subscriptions.NotifiedAnnotationKey] = string(data)
+	}
+	obj.SetAnnotations(annotations)
+	return deliveries, nil
+}
@@ -0,0 +1,178 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/mocks"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+)
+
+func newResource(annotations map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Rollout",
+		"metadata":   map[string]interface{}{"name": "guestbook", "namespace": "default"},
+	}}
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+func TestProcess_SendsNotificationOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := mocks.NewMockAPI(ctrl)
+	obj := newResource(map[string]string{subscriptions.SubscribeAnnotationKey("on-aborted", "slack"): "my-channel"})
+	dest := services.Destination{Service: "slack", Recipient: "my-channel"}
+
+	api.EXPECT().RunTrigger("on-aborted", map[string]interface{}{"resource": obj.Object}).
+		Return([]triggers.ConditionResult{{Key: "[0]", Triggered: true, Templates: []string{"rollout-aborted"}}}, nil).Times(2)
+	api.EXPECT().Send(gomock.Any(), []string{"rollout-aborted"}, dest).Return(nil).Times(1)
+
+	e := New(api, nil)
+	deliveries, err := e.Process(obj, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []Delivery{{Trigger: "on-aborted", Templates: []string{"rollout-aborted"}, Destination: dest}}, deliveries)
+	assert.NotEmpty(t, obj.GetAnnotations()[subscriptions.NotifiedAnnotationKey])
+
+	deliveries, err = e.Process(obj, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
+
+func TestProcess_AdditionalSubscriptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := mocks.NewMockAPI(ctrl)
+	obj := newResource(nil)
+	dest := services.Destination{Service: "slack", Recipient: "ops"}
+
+	api.EXPECT().RunTrigger("on-aborted", gomock.Any()).
+		Return([]triggers.ConditionResult{{Key: "[0]", Triggered: true, Templates: []string{"rollout-aborted"}}}, nil)
+	api.EXPECT().Send(gomock.Any(), []string{"rollout-aborted"}, dest).Return(nil)
+
+	deliveries, err := New(api, nil).Process(obj, pkg.Subscriptions{"on-aborted": []services.Destination{dest}})
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+}
+
+func TestProcess_FailedDeliveryRetried(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := mocks.NewMockAPI(ctrl)
+	obj := newResource(map[string]string{subscriptions.SubscribeAnnotationKey("on-aborted", "slack"): "my-channel"})
+
+	api.EXPECT().RunTrigger("on-aborted", gomock.Any()).
+		Return([]triggers.ConditionResult{{Key: "[0]", Triggered: true, Templates: []string{"rollout-aborted"}}}, nil).Times(2)
+	api.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("boom"))
+	api.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	e := New(api, nil)
+	deliveries, err := e.Process(obj, nil)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	assert.EqualError(t, deliveries[0].Error, "boom")
+	_, ok := obj.GetAnnotations()[subscriptions.NotifiedAnnotationKey]
+	assert.False(t, ok)
+
+	deliveries, err = e.Process(obj, nil)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	assert.NoError(t, deliveries[0].Error)
+}
+
+func TestProcess_CustomVars(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := mocks.NewMockAPI(ctrl)
+	obj := newResource(map[string]string{subscriptions.SubscribeAnnotationKey("on-aborted", "slack"): "my-channel"})
+
+	api.EXPECT().RunTrigger("on-aborted", map[string]interface{}{"rollout": obj.Object}).Return(nil, nil)
+
+	_, err := New(api, func(obj *unstructured.Unstructured, _ services.Destination) map[string]interface{} {
+		return map[string]interface{}{"rollout": obj.Object}
+	}).Process(obj, nil)
+	assert.NoError(t, err)
+}
+
+func TestEvaluate(t *testing.T) {
+	state := triggers.State{}
+	setAlreadyNotified := func(trigger string, result triggers.ConditionResult, dest services.Destination, isNotified bool) (bool, error) {
+		return state.SetAlreadyNotified(trigger, result, dest, isNotified), nil
+	}
+	slack := services.Destination{Service: "slack", Recipient: "my-channel"}
+	email := services.Destination{Service: "email", Recipient: "ops@example.com"}
+	results := []triggers.ConditionResult{
+		{Key: "[0]", Triggered: true, Templates: []string{"rollout-aborted"}},
+		{Key: "[1]", Triggered: false},
+	}
+	policy := Policy{Allow: func(_ string, _ triggers.ConditionResult, dest services.Destination) bool {
+		return dest.Service != "email"
+	}}
+
+	evaluations, err := Evaluate("on-aborted", results, []services.Destination{slack, email}, setAlreadyNotified, policy)
+	assert.NoError(t, err)
+	assert.Equal(t, []Evaluation{
+		{Result: results[0], Outcome: OutcomeFired, Pending: []services.Destination{slack}, Rejected: []services.Destination{email}},
+		{Result: results[1], Outcome: OutcomeNotFired},
+	}, evaluations)
+
+	evaluations, err = Evaluate("on-aborted", results[:1], []services.Destination{slack, email}, setAlreadyNotified, policy)
+	assert.NoError(t, err)
+	assert.Equal(t, []Evaluation{
+		{Result: results[0], Outcome: OutcomeSuppressed, AlreadyNotified: []services.Destination{slack, email}},
+	}, evaluations)
+}
+
+func TestEvaluate_Suppressed(t *testing.T) {
+	state := triggers.State{}
+	setAlreadyNotified := func(trigger string, result triggers.ConditionResult, dest services.Destination, isNotified bool) (bool, error) {
+		return state.SetAlreadyNotified(trigger, result, dest, isNotified), nil
+	}
+	dest := services.Destination{Service: "slack", Recipient: "my-channel"}
+	result := triggers.ConditionResult{Key: "[0]", Triggered: true}
+	policy := Policy{Suppress: func(_ string, _ triggers.ConditionResult) (string, string) {
+		return "muted", "is muted"
+	}}
+
+	evaluations, err := Evaluate("on-aborted", []triggers.ConditionResult{result}, []services.Destination{dest}, setAlreadyNotified, policy)
+	assert.NoError(t, err)
+	assert.Equal(t, []Evaluation{{Result: result, Outcome: "muted", Reason: "is muted"}}, evaluations)
+	// suppressed notifications are recorded as sent
+	assert.False(t, state.SetAlreadyNotified("on-aborted", result, dest, true))
+}
+
+func TestEvaluate_StateError(t *testing.T) {
+	setAlreadyNotified := func(_ string, _ triggers.ConditionResult, _ services.Destination, _ bool) (bool, error) {
+		return false, errors.New("boom")
+	}
+
+	_, err := Evaluate("on-aborted", []triggers.ConditionResult{{Key: "[0]", Triggered: true}},
+		[]services.Destination{{Service: "slack", Recipient: "my-channel"}}, setAlreadyNotified, Policy{})
+	assert.EqualError(t, err, "boom")
+}
+
+func TestProcess_WithPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := mocks.NewMockAPI(ctrl)
+	obj := newResource(map[string]string{subscriptions.SubscribeAnnotationKey("on-aborted", "slack"): "my-channel"})
+
+	api.EXPECT().RunTrigger("on-aborted", gomock.Any()).
+		Return([]triggers.ConditionResult{{Key: "[0]", Triggered: true, Templates: []string{"rollout-aborted"}}}, nil)
+
+	deliveries, err := New(api, nil, WithPolicy(func(_ *unstructured.Unstructured) Policy {
+		return Policy{Suppress: func(_ string, _ triggers.ConditionResult) (string, string) {
+			return "paused", "is true during the maintenance"
+		}}
+	})).Process(obj, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
@@ -46,6 +46,21 @@ func (mr *MockAPIMockRecorder) AddNotificationService(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddNotificationService", reflect.TypeOf((*MockAPI)(nil).AddNotificationService), arg0, arg1)
 }
 
+// FormatNotification mocks base method
+func (m *MockAPI) FormatNotification(arg0 map[string]interface{}, arg1 []string, arg2 services.Destination) (*services.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FormatNotification", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*services.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FormatNotification indicates an expected call of FormatNotification
+func (mr *MockAPIMockRecorder) FormatNotification(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FormatNotification", reflect.TypeOf((*MockAPI)(nil).FormatNotification), arg0, arg1, arg2)
+}
+
 // GetNotificationServices mocks base method
 func (m *MockAPI) GetNotificationServices() map[string]services.NotificationService {
 	m.ctrl.T.Helper()
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/signing"
+)
+
+const (
+	defaultArgoEventsSourceName = "argocd-notifications"
+	// argoEventsEventType is the type of CloudEvents published to the EventBus
+	argoEventsEventType = "argocd-notifications"
+)
+
+// ArgoEventsOptions holds settings of the connection to the JetStream based Argo Events EventBus
+type ArgoEventsOptions struct {
+	// URL is the address of the EventBus NATS service, e.g. nats://eventbus-default-js-svc.argo-events.svc:4222
+	URL string `json:"url"`
+	// Token is the client authentication token
+	Token string `json:"token"`
+	// Username and Password are the client authentication credentials
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// EventSourceName is the event source name that sensor dependencies reference. Defaults to argocd-notifications
+	EventSourceName string              `json:"eventSourceName"`
+	TLS             httputil.TLSOptions `json:"tls"`
+	// HTTP holds the client settings shared with HTTP based services. Only connectTimeout, timeout and keepAlive apply
+	HTTP httputil.ClientOptions `json:"http,omitempty"`
+	// Egress restricts hosts the service is allowed to connect to
+	Egress httputil.EgressPolicy `json:"egress,omitempty"`
+	// Signing configures the signature of the event data. Applied by NewSignedService
+	Signing signing.Options `json:"signing"`
+}
+
+// ArgoEventsNotification holds the template of the JSON event data
+type ArgoEventsNotification struct {
+	Data string `json:"data,omitempty"`
+}
+
+func (n *ArgoEventsNotification) GetTemplater(name string, f texttemplate.FuncMap) (Templater, error) {
+	data, err := texttemplate.New(name).Funcs(f).Parse(n.Data)
+	if err != nil {
+		return nil, err
+	}
+	return func(notification *Notification, vars map[string]interface{}, budget *RenderBudget) error {
+		if notification.ArgoEvents == nil {
+			notification.ArgoEvents = &ArgoEventsNotification{}
+		}
+		var dataBuf bytes.Buffer
+		if err := executeTemplate(data, &dataBuf, vars, budget); err != nil {
+			return err
+		}
+		notification.ArgoEvents.Data = dataBuf.String()
+		return nil
+	}, nil
+}
+
+func NewArgoEventsService(opts ArgoEventsOptions) NotificationService {
+	if opts.EventSourceName == "" {
+		opts.EventSourceName = defaultArgoEventsSourceName
+	}
+	return &argoEventsService{opts: opts}
+}
+
+type argoEventsService struct {
+	opts   ArgoEventsOptions
+	signer *signing.Signer
+}
+
+// cloudEvent is the JSON representation of the CloudEvent that Argo Events sensors consume from the EventBus. The
+// signature of the data is stored in extension attributes, so sensors might pass it to triggers
+type cloudEvent struct {
+	SpecVersion        string          `json:"specversion"`
+	ID                 string          `json:"id"`
+	Source             string          `json:"source"`
+	Type               string          `json:"type"`
+	Subject            string          `json:"subject"`
+	Time               string          `json:"time"`
+	DataContentType    string          `json:"datacontenttype"`
+	Data               json.RawMessage `json:"data"`
+	Signature          string          `json:"signature,omitempty"`
+	SignatureAlgorithm string          `json:"signaturealgorithm,omitempty"`
+	SignatureKeyID     string          `json:"signaturekeyid,omitempty"`
+	SignatureIssuer    string          `json:"signatureissuer,omitempty"`
+	SignatureTime      int64           `json:"signaturetime,omitempty"`
+}
+
+// newEvent returns the event with the event name set to the recipient, so sensors might depend on the specific recipient
+func (s *argoEventsService) newEvent(notification Notification, dest Destination) ([]byte, error) {
+	var data json.RawMessage
+	if notification.ArgoEvents != nil && notification.ArgoEvents.Data != "" {
+		// the data is compacted the same way json.Marshal does, so the signature matches the published data
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, []byte(notification.ArgoEvents.Data)); err != nil {
+			return nil, errors.New("argo events data must be a valid JSON")
+		}
+		data = compacted.Bytes()
+	} else {
+		var err error
+		if data, err = json.Marshal(map[string]string{"message": notification.Message}); err != nil {
+			return nil, err
+		}
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	event := cloudEvent{
+		SpecVersion:     "1.0",
+		ID:              hex.EncodeToString(id),
+		Source:          s.opts.EventSourceName,
+		Type:            argoEventsEventType,
+		Subject:         dest.Recipient,
+		Time:            time.Now().UTC().Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+	if s.signer != nil {
+		signature, err := s.signer.Sign(data)
+		if err != nil {
+			return nil, err
+		}
+		event.Signature = signature.Value
+		event.SignatureAlgorithm = signature.Algorithm
+		event.SignatureKeyID = signature.KeyID
+		event.SignatureIssuer = signature.Issuer
+		event.SignatureTime = signature.IssuedAt
+	}
+	return json.Marshal(event)
+}
+
+func (s *argoEventsService) Send(notification Notification, dest Destination) error {
+	event, err := s.newEvent(notification, dest)
+	if err != nil {
+		return err
+	}
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	// JetStream EventBus stores events of all event sources in the 'default' stream
+	subject := fmt.Sprintf("default.%s.%s", s.opts.EventSourceName, dest.Recipient)
+	if err := conn.publish(subject, event); err != nil {
+		return err
+	}
+	log.WithField("service", "argoevents").Debugf("Published event to %s", subject)
+	return nil
+}
+
+// natsConn is the minimal client of the NATS protocol that publishes messages to JetStream and waits for acknowledgments
+type natsConn struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	settings httputil.ConnSettings
+}
+
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+type natsConnect struct {
+	Verbose     bool   `json:"verbose"`
+	Pedantic    bool   `json:"pedantic"`
+	TLSRequired bool   `json:"tls_required"`
+	AuthToken   string `json:"auth_token,omitempty"`
+	User        string `json:"user,omitempty"`
+	Pass        string `json:"pass,omitempty"`
+	Name        string `json:"name"`
+	Lang        string `json:"lang"`
+	Version     string `json:"version"`
+	Protocol    int    `json:"protocol"`
+}
+
+type jetStreamAck struct {
+	Stream string `json:"stream"`
+	Seq    uint64 `json:"seq"`
+	Error  *struct {
+		Code        int    `json:"code"`
+		Description string `json:"description"`
+	} `json:"error,omitempty"`
+}
+
+func (s *argoEventsService) connect() (*natsConn, error) {
+	serverURL, err := url.Parse(s.opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	host := serverURL.Host
+	if serverURL.Port() == "" {
+		host = net.JoinHostPort(serverURL.Hostname(), "4222")
+	}
+	clientOpts := s.opts.HTTP
+	if s.opts.Egress != nil {
+		clientOpts.Egress = s.opts.Egress
+	}
+	settings := httputil.NewConnSettings(clientOpts)
+	conn, err := settings.Dial(context.Background(), "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	c := &natsConn{conn: conn, reader: bufio.NewReader(conn), settings: settings}
+	if err := c.handshake(s.opts, serverURL); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *natsConn) handshake(opts ArgoEventsOptions, serverURL *url.URL) error {
+	if err := c.conn.SetDeadline(c.settings.Deadline()); err != nil {
+		return err
+	}
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected NATS server greeting: %s", line)
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		return fmt.Errorf("failed to parse NATS server info: %v", err)
+	}
+	useTLS := info.TLSRequired || serverURL.Scheme == "tls" || opts.TLS != (httputil.TLSOptions{})
+	if useTLS {
+		tlsConfig, err := opts.TLS.ClientConfig()
+		if err != nil {
+			return err
+		}
+		tlsConfig.ServerName = serverURL.Hostname()
+		tlsConn := tls.Client(c.conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return err
+		}
+		c.conn = tlsConn
+		c.reader = bufio.NewReader(tlsConn)
+	}
+	connect, err := json.Marshal(natsConnect{
+		TLSRequired: useTLS,
+		AuthToken:   opts.Token,
+		User:        opts.Username,
+		Pass:        opts.Password,
+		Name:        "argocd-notifications",
+		Lang:        "go",
+		Protocol:    1,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.conn, "CONNECT %s\r\nPING\r\n", connect)
+	if err != nil {
+		return err
+	}
+	// server responds with PONG to confirm the connection or with -ERR if authentication has failed
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS server rejected the connection: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// publish sends the message and waits for the acknowledgment of the stream that stores the subject
+func (c *natsConn) publish(subject string, data []byte) error {
+	if err := c.conn.SetDeadline(c.settings.Deadline()); err != nil {
+		return err
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	inbox := "_INBOX." + hex.EncodeToString(id)
+	if _, err := fmt.Fprintf(c.conn, "SUB %s 1\r\nPUB %s %s %d\r\n%s\r\n", inbox, subject, inbox, len(data), data); err != nil {
+		return err
+	}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return fmt.Errorf("no acknowledgment received for the subject %s, make sure the EventBus uses JetStream: %w", subject, err)
+			}
+			return err
+		}
+		switch {
+		case line == "PING":
+			if _, err := fmt.Fprint(c.conn, "PONG\r\n"); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		case strings.HasPrefix(line, "MSG "):
+			return c.readAck(line)
+		}
+	}
+}
+
+func (c *natsConn) readAck(line string) error {
+	// MSG <subject> <sid> [reply-to] <size>
+	parts := strings.Fields(line)
+	size, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return fmt.Errorf("invalid NATS message: %s", line)
+	}
+	payload := make([]byte, size+2)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return err
+	}
+	var ack jetStreamAck
+	if err := json.Unmarshal(payload[:size], &ack); err != nil {
+		return fmt.Errorf("invalid JetStream acknowledgment: %v", err)
+	}
+	if ack.Error != nil {
+		return fmt.Errorf("JetStream rejected the event: %s (code %d)", ack.Error.Description, ack.Error.Code)
+	}
+	return nil
+}
+
+func (c *natsConn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (c *natsConn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/signing"
+)
+
+type natsMessage struct {
+	connect string
+	subject string
+	data    []byte
+}
+
+// startNATSServer starts fake NATS server that accepts single connection and responds to the published message with the given acknowledgment
+func startNATSServer(t *testing.T, ack string) (string, chan natsMessage) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	messages := make(chan natsMessage, 1)
+	go func() {
+		defer func() {
+			_ = listener.Close()
+		}()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		reader := bufio.NewReader(conn)
+		_, _ = fmt.Fprint(conn, "INFO {\"server_id\":\"test\",\"jetstream\":true}\r\n")
+		var msg natsMessage
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				msg.connect = strings.TrimPrefix(line, "CONNECT ")
+			case line == "PING":
+				_, _ = fmt.Fprint(conn, "PONG\r\n")
+			case strings.HasPrefix(line, "PUB "):
+				parts := strings.Fields(line)
+				size, _ := strconv.Atoi(parts[3])
+				data := make([]byte, size+2)
+				if _, err := io.ReadFull(reader, data); err != nil {
+					return
+				}
+				msg.subject = parts[1]
+				msg.data = data[:size]
+				messages <- msg
+				_, _ = fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", parts[2], len(ack), ack)
+			}
+		}
+	}()
+	return "nats://" + listener.Addr().String(), messages
+}
+
+func TestArgoEvents_Send(t *testing.T) {
+	url, messages := startNATSServer(t, `{"stream":"default","seq":1}`)
+	service := NewArgoEventsService(ArgoEventsOptions{URL: url, Token: "my-token"})
+
+	err := service.Send(Notification{
+		Message:    "hello",
+		ArgoEvents: &ArgoEventsNotification{Data: `{"app": "guestbook"}`},
+	}, Destination{Service: "argoevents", Recipient: "app-deployed"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	msg := <-messages
+	assert.Equal(t, "default.argocd-notifications.app-deployed", msg.subject)
+	assert.Contains(t, msg.connect, `"auth_token":"my-token"`)
+	var event map[string]interface{}
+	assert.NoError(t, json.Unmarshal(msg.data, &event))
+	assert.Equal(t, "argocd-notifications", event["source"])
+	assert.Equal(t, "app-deployed", event["subject"])
+	assert.Equal(t, map[string]interface{}{"app": "guestbook"}, event["data"])
+}
+
+func TestArgoEvents_SendSigned(t *testing.T) {
+	publicKey, key, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+	keyData, err := x509.MarshalPKCS8PrivateKey(key)
+	if !assert.NoError(t, err) {
+		return
+	}
+	signer, err := signing.NewSigner(signing.Options{PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyData})), KeyID: "my-key"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	url, messages := startNATSServer(t, `{"stream":"default","seq":1}`)
+	service, err := NewSignedService("argoevents", []byte("url: "+url), signer)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = service.Send(Notification{ArgoEvents: &ArgoEventsNotification{Data: `{"app":"guestbook"}`}}, Destination{Service: "argoevents", Recipient: "sync"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	msg := <-messages
+	var event cloudEvent
+	if !assert.NoError(t, json.Unmarshal(msg.data, &event)) {
+		return
+	}
+	assert.Equal(t, "ed25519", event.SignatureAlgorithm)
+	assert.Equal(t, "my-key", event.SignatureKeyID)
+	signature, err := base64.StdEncoding.DecodeString(event.Signature)
+	if assert.NoError(t, err) {
+		signed := fmt.Sprintf("%d.%s", event.SignatureTime, event.Data)
+		assert.True(t, ed25519.Verify(publicKey, []byte(signed), signature))
+	}
+}
+
+func TestArgoEvents_SendDefaultData(t *testing.T) {
+	url, messages := startNATSServer(t, `{"stream":"default","seq":1}`)
+	service := NewArgoEventsService(ArgoEventsOptions{URL: url, EventSourceName: "argocd"})
+
+	err := service.Send(Notification{Message: "hello"}, Destination{Service: "argoevents", Recipient: "sync"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	msg := <-messages
+	assert.Equal(t, "default.argocd.sync", msg.subject)
+	var event map[string]interface{}
+	assert.NoError(t, json.Unmarshal(msg.data, &event))
+	assert.Equal(t, map[string]interface{}{"message": "hello"}, event["data"])
+}
+
+func TestArgoEvents_SendRejected(t *testing.T) {
+	url, _ := startNATSServer(t, `{"error":{"code":503,"description":"no suitable peers"}}`)
+	service := NewArgoEventsService(ArgoEventsOptions{URL: url})
+
+	err := service.Send(Notification{Message: "hello"}, Destination{Service: "argoevents", Recipient: "sync"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no suitable peers")
+	}
+}
+
+func TestArgoEvents_SendTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	// the server accepts the connection but never sends the greeting
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer func() {
+				_ = conn.Close()
+			}()
+			time.Sleep(time.Second)
+		}
+	}()
+	service := NewArgoEventsService(ArgoEventsOptions{URL: "nats://" + listener.Addr().String(), HTTP: httputil.ClientOptions{Timeout: "50ms"}})
+
+	start := time.Now()
+	err = service.Send(Notification{Message: "hello"}, Destination{Service: "argoevents", Recipient: "sync"})
+
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestArgoEvents_InvalidData(t *testing.T) {
+	service := NewArgoEventsService(ArgoEventsOptions{URL: "nats://localhost:4222"})
+
+	err := service.Send(Notification{ArgoEvents: &ArgoEventsNotification{Data: "not a json"}}, Destination{Service: "argoevents", Recipient: "sync"})
+	assert.Error(t, err)
+}
+
+func TestGetTemplater_ArgoEvents(t *testing.T) {
+	n := Notification{
+		ArgoEvents: &ArgoEventsNotification{Data: `{"app": "{{.app}}"}`},
+	}
+	templater, err := n.GetTemplater("", template.FuncMap{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var notification Notification
+	err = templater(&notification, map[string]interface{}{"app": "guestbook"}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, `{"app": "guestbook"}`, notification.ArgoEvents.Data)
+}
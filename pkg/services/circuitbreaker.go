@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrCircuitOpen is returned without sending the notification while the service circuit breaker is open
+var ErrCircuitOpen = errors.New("notification service circuit breaker is open")
+
+// CircuitBreakerOptions holds settings of the circuit breaker that stops deliveries to the service during an outage
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive transient failures that opens the circuit. Circuit breaker is disabled if zero
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+	// Cooldown is the time the circuit stays open before a probe delivery is allowed
+	Cooldown string `json:"cooldown,omitempty"`
+}
+
+var DefaultCircuitBreakerOptions = CircuitBreakerOptions{
+	Cooldown: "1m",
+}
+
+// Merge returns copy of options with fields overridden by non empty fields of the other options
+func (o CircuitBreakerOptions) Merge(other CircuitBreakerOptions) CircuitBreakerOptions {
+	if other.FailureThreshold != 0 {
+		o.FailureThreshold = other.FailureThreshold
+	}
+	if other.Cooldown != "" {
+		o.Cooldown = other.Cooldown
+	}
+	return o
+}
+
+// CircuitBreaker tracks consecutive failures of the service. The state is shared by all instances of the service
+type CircuitBreaker struct {
+	name        string
+	serviceType string
+	threshold   int
+	cooldown    time.Duration
+	now         func() time.Time
+
+	lock     sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker returns circuit breaker of the named service or nil if circuit breaker is disabled
+func NewCircuitBreaker(name string, serviceType string, opts CircuitBreakerOptions) (*CircuitBreaker, error) {
+	if opts.FailureThreshold <= 0 {
+		return nil, nil
+	}
+	breaker := &CircuitBreaker{name: name, serviceType: serviceType, threshold: opts.FailureThreshold, now: time.Now}
+	if opts.Cooldown != "" {
+		cooldown, err := time.ParseDuration(opts.Cooldown)
+		if err != nil {
+			return nil, fmt.Errorf("invalid circuit breaker cooldown '%s': %v", opts.Cooldown, err)
+		}
+		breaker.cooldown = cooldown
+	}
+	return breaker, nil
+}
+
+func (b *CircuitBreaker) isOpen() bool {
+	return b.failures >= b.threshold
+}
+
+// allow returns true if the delivery should be attempted. Once the cooldown elapses only one probe delivery is allowed at a time
+func (b *CircuitBreaker) allow() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if !b.isOpen() {
+		return true
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *CircuitBreaker) done(err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	wasOpen := b.isOpen()
+	b.probing = false
+	// permanent errors such as invalid recipient don't indicate the service outage
+	if err == nil || !IsRetryable(b.serviceType, err) {
+		if wasOpen {
+			log.Infof("Circuit breaker of service %s is closed", b.name)
+		}
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.isOpen() {
+		if !wasOpen {
+			log.Warnf("Circuit breaker of service %s is open after %d consecutive failures, deliveries are paused for %v", b.name, b.failures, b.cooldown)
+		}
+		b.openedAt = b.now()
+	}
+}
+
+// NewCircuitBreakerService returns notification service that fails fast with ErrCircuitOpen while the circuit breaker is open
+func NewCircuitBreakerService(service NotificationService, breaker *CircuitBreaker) NotificationService {
+	if breaker == nil {
+		return service
+	}
+	return &circuitBreakerService{service: service, breaker: breaker}
+}
+
+type circuitBreakerService struct {
+	service NotificationService
+	breaker *CircuitBreaker
+}
+
+func (s *circuitBreakerService) Send(notification Notification, dest Destination) error {
+	if !s.breaker.allow() {
+		return ErrCircuitOpen
+	}
+	err := s.service.Send(notification, dest)
+	s.breaker.done(err)
+	return err
+}
+
+func (s *circuitBreakerService) Unwrap() NotificationService {
+	return s.service
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCircuitBreaker(t *testing.T, now *time.Time) *CircuitBreaker {
+	breaker, err := NewCircuitBreaker("webhook", "webhook", CircuitBreakerOptions{FailureThreshold: 2, Cooldown: "1m"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	breaker.now = func() time.Time {
+		return *now
+	}
+	return breaker
+}
+
+func TestCircuitBreakerService_OpensAfterConsecutiveFailures(t *testing.T) {
+	now := time.Now()
+	svc := &failingService{errs: []error{&HTTPError{StatusCode: 503}, &HTTPError{StatusCode: 503}}}
+	breakerSvc := NewCircuitBreakerService(svc, newTestCircuitBreaker(t, &now))
+
+	assert.Error(t, breakerSvc.Send(Notification{}, Destination{}))
+	assert.Error(t, breakerSvc.Send(Notification{}, Destination{}))
+	assert.Equal(t, ErrCircuitOpen, breakerSvc.Send(Notification{}, Destination{}))
+	assert.Equal(t, 2, svc.calls)
+
+	// probe delivery is allowed after cooldown and closes the circuit if succeeded
+	now = now.Add(time.Minute)
+	assert.NoError(t, breakerSvc.Send(Notification{}, Destination{}))
+	assert.NoError(t, breakerSvc.Send(Notification{}, Destination{}))
+	assert.Equal(t, 4, svc.calls)
+}
+
+func TestCircuitBreakerService_ReopensIfProbeFailed(t *testing.T) {
+	now := time.Now()
+	svc := &failingService{errs: []error{&HTTPError{StatusCode: 503}, &HTTPError{StatusCode: 503}, &HTTPError{StatusCode: 503}}}
+	breakerSvc := NewCircuitBreakerService(svc, newTestCircuitBreaker(t, &now))
+
+	_ = breakerSvc.Send(Notification{}, Destination{})
+	_ = breakerSvc.Send(Notification{}, Destination{})
+	now = now.Add(time.Minute)
+	assert.Error(t, breakerSvc.Send(Notification{}, Destination{}))
+	assert.Equal(t, ErrCircuitOpen, breakerSvc.Send(Notification{}, Destination{}))
+	assert.Equal(t, 3, svc.calls)
+}
+
+func TestCircuitBreakerService_IgnoresPermanentErrors(t *testing.T) {
+	now := time.Now()
+	svc := &failingService{errs: []error{errors.New("channel_not_found"), errors.New("channel_not_found"), errors.New("channel_not_found")}}
+	breakerSvc := NewCircuitBreakerService(svc, newTestCircuitBreaker(t, &now))
+
+	for i := 0; i < 3; i++ {
+		assert.EqualError(t, breakerSvc.Send(Notification{}, Destination{}), "channel_not_found")
+	}
+	assert.Equal(t, 3, svc.calls)
+}
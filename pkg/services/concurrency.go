@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrServiceBusy is returned if the notification could not be sent because all concurrent delivery slots of the service were busy
+var ErrServiceBusy = errors.New("notification service is busy: max number of concurrent deliveries reached")
+
+// ConcurrencyOptions holds settings that limit the number of notifications sent by the service at the same time
+type ConcurrencyOptions struct {
+	// MaxConcurrent is the max number of notifications sent concurrently. Number of deliveries is not limited if zero
+	MaxConcurrent int `json:"maxConcurrent,omitempty"`
+	// QueueTimeout is the max time spent waiting for a free delivery slot
+	QueueTimeout string `json:"queueTimeout,omitempty"`
+}
+
+var DefaultConcurrencyOptions = ConcurrencyOptions{
+	QueueTimeout: "10s",
+}
+
+// Merge returns copy of options with fields overridden by non empty fields of the other options
+func (o ConcurrencyOptions) Merge(other ConcurrencyOptions) ConcurrencyOptions {
+	if other.MaxConcurrent != 0 {
+		o.MaxConcurrent = other.MaxConcurrent
+	}
+	if other.QueueTimeout != "" {
+		o.QueueTimeout = other.QueueTimeout
+	}
+	return o
+}
+
+// ConcurrencyLimiter holds delivery slots shared by all instances of the notification service
+type ConcurrencyLimiter struct {
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+// NewConcurrencyLimiter returns limiter configured using specified options or nil if the concurrency is not limited
+func NewConcurrencyLimiter(opts ConcurrencyOptions) (*ConcurrencyLimiter, error) {
+	if opts.MaxConcurrent <= 0 {
+		return nil, nil
+	}
+	limiter := &ConcurrencyLimiter{slots: make(chan struct{}, opts.MaxConcurrent)}
+	if opts.QueueTimeout != "" {
+		timeout, err := time.ParseDuration(opts.QueueTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid concurrency queueTimeout '%s': %v", opts.QueueTimeout, err)
+		}
+		limiter.timeout = timeout
+	}
+	return limiter, nil
+}
+
+func (l *ConcurrencyLimiter) acquire() bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if l.timeout <= 0 {
+		return false
+	}
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (l *ConcurrencyLimiter) release() {
+	<-l.slots
+}
+
+// NewConcurrencyLimitedService returns notification service that fails fast with ErrServiceBusy instead of blocking the caller
+// if the service is already sending the max number of notifications, so the slow service cannot stall deliveries to other services
+func NewConcurrencyLimitedService(service NotificationService, limiter *ConcurrencyLimiter) NotificationService {
+	if limiter == nil {
+		return service
+	}
+	return &concurrencyLimitedService{service: service, limiter: limiter}
+}
+
+type concurrencyLimitedService struct {
+	service NotificationService
+	limiter *ConcurrencyLimiter
+}
+
+func (s *concurrencyLimitedService) Send(notification Notification, dest Destination) error {
+	if !s.limiter.acquire() {
+		return ErrServiceBusy
+	}
+	defer s.limiter.release()
+	return s.service.Send(notification, dest)
+}
+
+func (s *concurrencyLimitedService) Unwrap() NotificationService {
+	return s.service
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type blockingService struct {
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (s *blockingService) Send(_ Notification, _ Destination) error {
+	s.started <- struct{}{}
+	<-s.unblock
+	return nil
+}
+
+func TestConcurrencyLimitedService_FailsIfBusy(t *testing.T) {
+	limiter, err := NewConcurrencyLimiter(ConcurrencyOptions{MaxConcurrent: 1, QueueTimeout: "10ms"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	blocking := &blockingService{started: make(chan struct{}), unblock: make(chan struct{})}
+	svc := NewConcurrencyLimitedService(blocking, limiter)
+
+	done := make(chan error)
+	go func() {
+		done <- svc.Send(Notification{}, Destination{})
+	}()
+	<-blocking.started
+
+	// slot is shared with other instances of the same service
+	other := NewConcurrencyLimitedService(&failingService{}, limiter)
+	assert.Equal(t, ErrServiceBusy, other.Send(Notification{}, Destination{}))
+
+	close(blocking.unblock)
+	assert.NoError(t, <-done)
+	assert.NoError(t, other.Send(Notification{}, Destination{}))
+}
+
+func TestConcurrencyLimitedService_WaitsForSlot(t *testing.T) {
+	limiter, err := NewConcurrencyLimiter(ConcurrencyOptions{MaxConcurrent: 1, QueueTimeout: "5s"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	blocking := &blockingService{started: make(chan struct{}), unblock: make(chan struct{})}
+	svc := NewConcurrencyLimitedService(blocking, limiter)
+
+	go func() {
+		_ = svc.Send(Notification{}, Destination{})
+	}()
+	<-blocking.started
+	time.AfterFunc(10*time.Millisecond, func() {
+		close(blocking.unblock)
+	})
+
+	assert.NoError(t, NewConcurrencyLimitedService(&failingService{}, limiter).Send(Notification{}, Destination{}))
+}
+
+func TestNewConcurrencyLimiter_Unlimited(t *testing.T) {
+	limiter, err := NewConcurrencyLimiter(DefaultConcurrencyOptions)
+	assert.NoError(t, err)
+	assert.Nil(t, limiter)
+
+	svc := &failingService{}
+	assert.Equal(t, svc, NewConcurrencyLimitedService(svc, limiter))
+}
@@ -1,19 +1,144 @@
 package services
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/argoproj-labs/argocd-notifications/pkg/util/misc"
 )
 
+const (
+	ConsoleFormatText = "text"
+	ConsoleFormatJSON = "json"
+
+	ConsoleOutputStdout = "stdout"
+	ConsoleOutputStderr = "stderr"
+)
+
+// ConsoleOptions configures the console service that writes notifications to the standard output, standard error or
+// the file instead of sending them
+type ConsoleOptions struct {
+	// Format is either text or json. The json format writes one JSON object per line. Defaults to text
+	Format string `json:"format,omitempty"`
+	// Output is stdout, stderr or the path of the file notifications are appended to. Defaults to stdout
+	Output string `json:"output,omitempty"`
+	// IncludePayload adds service specific fields of the notification, e.g. Slack attachments or webhook bodies
+	IncludePayload bool `json:"includePayload,omitempty"`
+	// IncludeHeaders adds the time and destination of the notification
+	IncludeHeaders bool `json:"includeHeaders,omitempty"`
+}
+
+// Validate returns an error if the format is unknown
+func (o ConsoleOptions) Validate() error {
+	switch o.Format {
+	case "", ConsoleFormatText, ConsoleFormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("unknown console format '%s'; expected %s or %s", o.Format, ConsoleFormatText, ConsoleFormatJSON)
+	}
+}
+
+type consoleRecord struct {
+	Time      string        `json:"time,omitempty"`
+	Service   string        `json:"service,omitempty"`
+	Recipient string        `json:"recipient,omitempty"`
+	Message   string        `json:"message"`
+	Payload   *Notification `json:"payload,omitempty"`
+}
+
 type consoleService struct {
+	opts ConsoleOptions
+	lock sync.Mutex
+	out  io.Writer
+	now  func() time.Time
+}
+
+func (c *consoleService) writer() (io.Writer, func(), error) {
+	if c.out != nil {
+		return c.out, func() {}, nil
+	}
+	switch c.opts.Output {
+	case "", ConsoleOutputStdout:
+		return os.Stdout, func() {}, nil
+	case ConsoleOutputStderr:
+		return os.Stderr, func() {}, nil
+	}
+	// the file is opened for every notification, so it might be rotated or removed while the controller is running
+	f, err := os.OpenFile(c.opts.Output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() {
+		_ = f.Close()
+	}, nil
+}
+
+func (c *consoleService) Send(notification Notification, dest Destination) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	out, closer, err := c.writer()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	if c.opts.Format == ConsoleFormatJSON {
+		record := consoleRecord{Message: notification.Message}
+		if c.opts.IncludeHeaders {
+			record.Time = c.now().UTC().Format(time.RFC3339)
+			record.Service, record.Recipient = dest.Service, dest.Recipient
+		}
+		if c.opts.IncludePayload {
+			payload := notification
+			payload.Message = ""
+			record.Payload = &payload
+		}
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(append(data, '\n'))
+		return err
+	}
+
+	if c.opts.IncludeHeaders {
+		if _, err := fmt.Fprintf(out, "# %s notification to %s:%s\n", c.now().UTC().Format(time.RFC3339), dest.Service, dest.Recipient); err != nil {
+			return err
+		}
+	}
+	if c.opts.IncludePayload {
+		return misc.PrintFormatted(notification, "yaml", out)
+	}
+	_, err = fmt.Fprintln(out, notification.Message)
+	return err
+}
+
+// NewConsoleService returns the service that prints the whole notification in YAML format, which is useful for debugging
+func NewConsoleService(stdout io.Writer) *consoleService {
+	return &consoleService{opts: ConsoleOptions{IncludePayload: true}, out: stdout, now: time.Now}
+}
+
+// NewConfiguredConsoleService returns the console service configured using the service settings
+func NewConfiguredConsoleService(opts ConsoleOptions) *consoleService {
+	return &consoleService{opts: opts, now: time.Now}
+}
+
+type dryRunService struct {
 	stdout io.Writer
 }
 
-func (c *consoleService) Send(notification Notification, _ Destination) error {
+func (c *dryRunService) Send(notification Notification, dest Destination) error {
+	if _, err := fmt.Fprintf(c.stdout, "# dry run: notification to %s:%s\n", dest.Service, dest.Recipient); err != nil {
+		return err
+	}
 	return misc.PrintFormatted(notification, "yaml", c.stdout)
 }
 
-func NewConsoleService(stdout io.Writer) *consoleService {
-	return &consoleService{stdout}
+// NewDryRunService returns service that prints the notification and its destination instead of sending it
+func NewDryRunService(stdout io.Writer) *dryRunService {
+	return &dryRunService{stdout}
 }
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var consoleNotification = Notification{Message: "guestbook is synced", Slack: &SlackNotification{Attachments: "[]"}}
+
+func newTestConsoleService(opts ConsoleOptions) (*consoleService, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	service := NewConfiguredConsoleService(opts)
+	service.out = out
+	service.now = func() time.Time {
+		return time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	}
+	return service, out
+}
+
+func TestConsole_Text(t *testing.T) {
+	service, out := newTestConsoleService(ConsoleOptions{IncludeHeaders: true})
+
+	assert.NoError(t, service.Send(consoleNotification, Destination{Service: "console", Recipient: "audit"}))
+
+	assert.Equal(t, "# 2021-03-01T10:00:00Z notification to console:audit\nguestbook is synced\n", out.String())
+}
+
+func TestConsole_JSON(t *testing.T) {
+	service, out := newTestConsoleService(ConsoleOptions{Format: ConsoleFormatJSON, IncludeHeaders: true, IncludePayload: true})
+
+	assert.NoError(t, service.Send(consoleNotification, Destination{Service: "console", Recipient: "audit"}))
+
+	assert.Equal(t, `{"time":"2021-03-01T10:00:00Z","service":"console","recipient":"audit","message":"guestbook is synced","payload":{"slack":{"attachments":"[]"}}}`+"\n", out.String())
+}
+
+func TestConsole_File(t *testing.T) {
+	dir, err := ioutil.TempDir("", "console")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+	service := NewConfiguredConsoleService(ConsoleOptions{Output: path})
+
+	assert.NoError(t, service.Send(Notification{Message: "first"}, Destination{}))
+	assert.NoError(t, service.Send(Notification{Message: "second"}, Destination{}))
+
+	data, err := ioutil.ReadFile(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "first\nsecond\n", string(data))
+	}
+}
+
+func TestConsoleOptions_Validate(t *testing.T) {
+	assert.NoError(t, ConsoleOptions{Format: ConsoleFormatJSON}.Validate())
+	assert.Error(t, ConsoleOptions{Format: "xml"}.Validate())
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
+)
+
+const discordAPIURL = "https://discord.com/api/v10"
+
+// DiscordOptions holds settings of the Discord application that posts messages to channels
+type DiscordOptions struct {
+	// Token is the bot token of the Discord application
+	Token string `json:"token"`
+	// PublicKey is the hex encoded application public key used by Discord bot to verify interactions
+	PublicKey string                 `json:"publicKey"`
+	Proxy     httputil.ProxyOptions  `json:"proxy"`
+	HTTP      httputil.ClientOptions `json:"http"`
+	apiURL    string
+}
+
+func NewDiscordService(opts DiscordOptions) NotificationService {
+	if opts.apiURL == "" {
+		opts.apiURL = discordAPIURL
+	}
+	return &discordService{opts: opts}
+}
+
+type discordService struct {
+	opts DiscordOptions
+}
+
+// Send posts the message to the channel with the recipient id
+func (s *discordService) Send(notification Notification, dest Destination) error {
+	data, err := json.Marshal(map[string]string{"content": notification.Message})
+	if err != nil {
+		return err
+	}
+	messagesURL := fmt.Sprintf("%s/channels/%s/messages", s.opts.apiURL, url.PathEscape(dest.Recipient))
+	req, err := http.NewRequest(http.MethodPost, messagesURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bot "+s.opts.Token)
+	// requests are not logged because bot token is part of the request headers
+	client := httputil.NewClient(messagesURL, s.opts.HTTP, httputil.TLSOptions{}, s.opts.Proxy)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if !(resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			body = []byte(fmt.Sprintf("unable to read response data: %v", err))
+		}
+		return &HTTPError{URL: messagesURL, StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return nil
+}
+
+// GetPublicKey exposes application public key for discord bot
+func (s *discordService) GetPublicKey() string {
+	return s.opts.PublicKey
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscord_Send(t *testing.T) {
+	var receivedPath, receivedAuth string
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedAuth = r.Header.Get("Authorization")
+		data, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal(data, &received))
+	}))
+	defer server.Close()
+
+	service := NewDiscordService(DiscordOptions{Token: "my-token", apiURL: server.URL})
+	err := service.Send(Notification{Message: "hello"}, Destination{Service: "discord", Recipient: "1234"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "/channels/1234/messages", receivedPath)
+	assert.Equal(t, "Bot my-token", receivedAuth)
+	assert.Equal(t, map[string]string{"content": "hello"}, received)
+}
+
+func TestDiscord_SendFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	service := NewDiscordService(DiscordOptions{Token: "my-token", apiURL: server.URL})
+	err := service.Send(Notification{Message: "hello"}, Destination{Service: "discord", Recipient: "1234"})
+	assert.Error(t, err)
+}
@@ -2,10 +2,19 @@ package services
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
 	texttemplate "text/template"
+	"time"
 
+	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
 	"github.com/argoproj-labs/argocd-notifications/pkg/util/text"
-	"gomodules.xyz/notify/smtp"
 )
 
 type EmailNotification struct {
@@ -23,12 +32,12 @@ func (n *EmailNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 		return nil, err
 	}
 
-	return func(notification *Notification, vars map[string]interface{}) error {
+	return func(notification *Notification, vars map[string]interface{}, budget *RenderBudget) error {
 		if notification.Email == nil {
 			notification.Email = &EmailNotification{}
 		}
 		var emailSubjectData bytes.Buffer
-		if err := subject.Execute(&emailSubjectData, vars); err != nil {
+		if err := executeTemplate(subject, &emailSubjectData, vars, budget); err != nil {
 			return err
 		}
 
@@ -37,7 +46,7 @@ func (n *EmailNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 		}
 
 		var emailBodyData bytes.Buffer
-		if err := body.Execute(&emailBodyData, vars); err != nil {
+		if err := executeTemplate(body, &emailBodyData, vars, budget); err != nil {
 			return err
 		}
 		if val := emailBodyData.String(); val != "" {
@@ -48,6 +57,9 @@ func (n *EmailNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 	}, nil
 }
 
+// smtpsPort is the port of SMTP over implicit TLS
+const smtpsPort = 465
+
 type EmailOptions struct {
 	Host               string `json:"host"`
 	Port               int    `json:"port"`
@@ -55,6 +67,10 @@ type EmailOptions struct {
 	Username           string `json:"username"`
 	Password           string `json:"password"`
 	From               string `json:"from"`
+	// HTTP holds the client settings shared with HTTP based services. Only connectTimeout, timeout and keepAlive apply
+	HTTP httputil.ClientOptions `json:"http,omitempty"`
+	// Egress restricts hosts the service is allowed to connect to
+	Egress httputil.EgressPolicy `json:"egress,omitempty"`
 }
 
 type emailService struct {
@@ -66,18 +82,108 @@ func NewEmailService(opts EmailOptions) NotificationService {
 }
 
 func (s *emailService) Send(notification Notification, dest Destination) error {
+	if s.opts.Egress != nil {
+		if err := s.opts.Egress.Check(s.opts.Host); err != nil {
+			return err
+		}
+	}
 	subject := ""
 	body := notification.Message
 	if notification.Email != nil {
 		subject = notification.Email.Subject
 		body = text.Coalesce(notification.Email.Body, body)
 	}
-	return smtp.New(smtp.Options{
-		From:               s.opts.From,
-		Host:               s.opts.Host,
-		Port:               s.opts.Port,
-		InsecureSkipVerify: s.opts.InsecureSkipVerify,
-		Password:           s.opts.Password,
-		Username:           s.opts.Username,
-	}).WithSubject(subject).WithBody(body).To(dest.Recipient).Send()
+	message, err := s.newMessage(dest.Recipient, subject, body)
+	if err != nil {
+		return err
+	}
+	return s.sendMail(dest.Recipient, message)
+}
+
+// newMessage returns the plain text message with the quoted-printable encoded body
+func (s *emailService) newMessage(to string, subject string, body string) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, header := range [][2]string{
+		{"From", s.opts.From},
+		{"To", to},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=UTF-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	} {
+		buf.WriteString(header[0] + ": " + header[1] + "\r\n")
+	}
+	buf.WriteString("\r\n")
+	writer := quotedprintable.NewWriter(&buf)
+	if _, err := writer.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sendMail delivers the message using the connect timeout and the timeout of the client settings. The connection uses
+// implicit TLS on the port 465 and is upgraded using STARTTLS on other ports if the server supports it
+func (s *emailService) sendMail(to string, message []byte) error {
+	clientOpts := s.opts.HTTP
+	if s.opts.Egress != nil {
+		clientOpts.Egress = s.opts.Egress
+	}
+	settings := httputil.NewConnSettings(clientOpts)
+	conn, err := settings.Dial(context.Background(), "tcp", net.JoinHostPort(s.opts.Host, strconv.Itoa(s.opts.Port)))
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(settings.Deadline()); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	tlsConfig := &tls.Config{ServerName: s.opts.Host, InsecureSkipVerify: s.opts.InsecureSkipVerify}
+	if s.opts.Port == smtpsPort {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	client, err := smtp.NewClient(conn, s.opts.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	if s.opts.Port != smtpsPort {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if s.opts.Username != "" && s.opts.Password != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.opts.Username, s.opts.Password, s.opts.Host)); err != nil {
+			return err
+		}
+	}
+	from := s.opts.From
+	if addr, err := mail.ParseAddress(from); err == nil {
+		from = addr.Address
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
 }
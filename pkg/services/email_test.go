@@ -1,12 +1,120 @@
 package services
 
 import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
 	"testing"
 	"text/template"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
 )
 
+// startSMTPServer starts fake SMTP server that accepts single message and returns the host, the port and the channel
+// that receives the message data
+func startSMTPServer(t *testing.T) (string, int, chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	messages := make(chan string, 1)
+	go func() {
+		defer func() {
+			_ = listener.Close()
+		}()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		reader := bufio.NewReader(conn)
+		_, _ = fmt.Fprint(conn, "220 localhost ESMTP\r\n")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch command := strings.ToUpper(strings.Fields(line + " ")[0]); command {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				_, _ = fmt.Fprint(conn, "250 OK\r\n")
+			case "DATA":
+				_, _ = fmt.Fprint(conn, "354 Go ahead\r\n")
+				var data strings.Builder
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				messages <- data.String()
+				_, _ = fmt.Fprint(conn, "250 OK\r\n")
+			case "QUIT":
+				_, _ = fmt.Fprint(conn, "221 Bye\r\n")
+				return
+			default:
+				_, _ = fmt.Fprint(conn, "502 Not implemented\r\n")
+			}
+		}
+	}()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return host, portNum, messages
+}
+
+func TestEmail_Send(t *testing.T) {
+	host, port, messages := startSMTPServer(t)
+	service := NewEmailService(EmailOptions{Host: host, Port: port, From: "Argo CD <argocd@example.com>"})
+
+	err := service.Send(Notification{Message: "world", Email: &EmailNotification{Subject: "hello"}}, Destination{Service: "email", Recipient: "user@example.com"})
+
+	if assert.NoError(t, err) {
+		message := <-messages
+		assert.Contains(t, message, "Subject: hello\r\n")
+		assert.Contains(t, message, "To: user@example.com\r\n")
+		assert.True(t, strings.HasSuffix(message, "\r\n\r\nworld\r\n"), message)
+	}
+}
+
+func TestEmail_SendTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	// the server accepts the connection but never sends the greeting
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer func() {
+				_ = conn.Close()
+			}()
+			time.Sleep(time.Second)
+		}
+	}()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	service := NewEmailService(EmailOptions{Host: host, Port: portNum, HTTP: httputil.ClientOptions{Timeout: "50ms"}})
+
+	start := time.Now()
+	err = service.Send(Notification{Message: "hello"}, Destination{Service: "email", Recipient: "user@example.com"})
+
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
 func TestGetTemplater_Email(t *testing.T) {
 	n := Notification{
 		Email: &EmailNotification{
@@ -24,7 +132,7 @@ func TestGetTemplater_Email(t *testing.T) {
 	err = templater(&notification, map[string]interface{}{
 		"foo": "hello",
 		"bar": "world",
-	})
+	}, nil)
 
 	if !assert.NoError(t, err) {
 		return
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/slack-go/slack"
+
+	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
+)
+
+const (
+	ErrorClassTimeout     = "timeout"
+	ErrorClassAuth        = "auth"
+	ErrorClassRateLimited = "rate_limited"
+	ErrorClass4xx         = "4xx"
+	ErrorClass5xx         = "5xx"
+	ErrorClassNetwork     = "network"
+	ErrorClassCircuitOpen = "circuit_open"
+	ErrorClassRender      = "render"
+	ErrorClassEgress      = "egress_denied"
+	ErrorClassOther       = "other"
+)
+
+// slack API returns authentication failures as plain error messages
+var slackAuthErrors = map[string]bool{
+	"invalid_auth":     true,
+	"not_authed":       true,
+	"account_inactive": true,
+	"token_revoked":    true,
+	"token_expired":    true,
+}
+
+// ErrorClass returns the low cardinality category of the notification delivery error suitable for metric labels
+func ErrorClass(err error) string {
+	if errors.Is(err, ErrCircuitOpen) {
+		return ErrorClassCircuitOpen
+	}
+	var renderErr *RenderError
+	if errors.As(err, &renderErr) {
+		return ErrorClassRender
+	}
+	var egressErr *httputil.EgressDeniedError
+	if errors.As(err, &egressErr) {
+		return ErrorClassEgress
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpStatusClass(httpErr.StatusCode)
+	}
+	var rateLimitedErr *slack.RateLimitedError
+	if errors.As(err, &rateLimitedErr) || errors.Is(err, ErrServiceBusy) || errors.Is(err, ErrServiceRateLimited) {
+		return ErrorClassRateLimited
+	}
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		switch {
+		case smtpErr.Code == 530 || smtpErr.Code == 534 || smtpErr.Code == 535:
+			return ErrorClassAuth
+		case smtpErr.Code >= 500:
+			return ErrorClass5xx
+		case smtpErr.Code >= 400:
+			return ErrorClass4xx
+		}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorClassTimeout
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return ErrorClassNetwork
+	}
+	if slackAuthErrors[err.Error()] {
+		return ErrorClassAuth
+	}
+	if strings.Contains(err.Error(), "context deadline exceeded") {
+		return ErrorClassTimeout
+	}
+	return ErrorClassOther
+}
+
+func httpStatusClass(statusCode int) string {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrorClassAuth
+	case statusCode == http.StatusTooManyRequests:
+		return ErrorClassRateLimited
+	case statusCode >= 500:
+		return ErrorClass5xx
+	case statusCode >= 400:
+		return ErrorClass4xx
+	}
+	return ErrorClassOther
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestErrorClass(t *testing.T) {
+	testCases := map[string]error{
+		ErrorClassAuth:        &HTTPError{StatusCode: 401},
+		ErrorClass4xx:         fmt.Errorf("failed after 3 attempts: %w", &HTTPError{StatusCode: 404}),
+		ErrorClass5xx:         &HTTPError{StatusCode: 502},
+		ErrorClassRateLimited: &HTTPError{StatusCode: 429},
+		ErrorClassTimeout:     &net.OpError{Op: "dial", Err: timeoutError{}},
+		ErrorClassNetwork:     &net.OpError{Op: "dial", Err: errors.New("connection refused")},
+		ErrorClassCircuitOpen: ErrCircuitOpen,
+		ErrorClassEgress:      &url.Error{Op: "Post", URL: "https://example.com", Err: &httputil.EgressDeniedError{Host: "example.com"}},
+		ErrorClassOther:       errors.New("unknown"),
+	}
+	for class, err := range testCases {
+		assert.Equal(t, class, ErrorClass(err), err.Error())
+	}
+	assert.Equal(t, ErrorClassAuth, ErrorClass(errors.New("invalid_auth")))
+	assert.Equal(t, ErrorClassRateLimited, ErrorClass(ErrServiceBusy))
+	assert.Equal(t, ErrorClassRateLimited, ErrorClass(ErrServiceRateLimited))
+	assert.Equal(t, ErrorClassAuth, ErrorClass(&textproto.Error{Code: 535, Msg: "authentication failed"}))
+}
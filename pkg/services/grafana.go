@@ -16,9 +16,12 @@ import (
 )
 
 type GrafanaOptions struct {
-	ApiUrl             string `json:"apiUrl"`
-	ApiKey             string `json:"apiKey"`
-	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+	ApiUrl             string                 `json:"apiUrl"`
+	ApiKey             string                 `json:"apiKey"`
+	InsecureSkipVerify bool                   `json:"insecureSkipVerify"`
+	Proxy              httputil.ProxyOptions  `json:"proxy"`
+	TLS                httputil.TLSOptions    `json:"tls"`
+	HTTP               httputil.ClientOptions `json:"http"`
 }
 
 type grafanaService struct {
@@ -44,10 +47,10 @@ func (s *grafanaService) Send(notification Notification, dest Destination) error
 		Text:     notification.Message,
 	}
 
-	client := &http.Client{
-		Transport: httputil.NewLoggingRoundTripper(
-			httputil.NewTransport(s.opts.ApiUrl, s.opts.InsecureSkipVerify), log.WithField("service", "grafana")),
-	}
+	tlsOpts := s.opts.TLS
+	tlsOpts.InsecureSkipVerify = tlsOpts.InsecureSkipVerify || s.opts.InsecureSkipVerify
+	client := httputil.NewClient(s.opts.ApiUrl, s.opts.HTTP, tlsOpts, s.opts.Proxy)
+	client.Transport = httputil.NewLoggingRoundTripper(client.Transport, log.WithField("service", "grafana"))
 
 	jsonValue, _ := json.Marshal(ga)
 	apiUrl, err := url.Parse(s.opts.ApiUrl)
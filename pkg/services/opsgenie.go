@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"net/http"
 	texttemplate "text/template"
 
 	"github.com/opsgenie/opsgenie-go-sdk-v2/alert"
@@ -15,8 +14,11 @@ import (
 )
 
 type OpsgenieOptions struct {
-	ApiUrl  string            `json:"apiUrl"`
-	ApiKeys map[string]string `json:"apiKeys"`
+	ApiUrl  string                 `json:"apiUrl"`
+	ApiKeys map[string]string      `json:"apiKeys"`
+	Proxy   httputil.ProxyOptions  `json:"proxy"`
+	TLS     httputil.TLSOptions    `json:"tls"`
+	HTTP    httputil.ClientOptions `json:"http"`
 }
 
 type OpsgenieNotification struct {
@@ -28,12 +30,12 @@ func (n *OpsgenieNotification) GetTemplater(name string, f texttemplate.FuncMap)
 	if err != nil {
 		return nil, err
 	}
-	return func(notification *Notification, vars map[string]interface{}) error {
+	return func(notification *Notification, vars map[string]interface{}, budget *RenderBudget) error {
 		if notification.Opsgenie == nil {
 			notification.Opsgenie = &OpsgenieNotification{}
 		}
 		var descData bytes.Buffer
-		if err := desc.Execute(&descData, vars); err != nil {
+		if err := executeTemplate(desc, &descData, vars, budget); err != nil {
 			return err
 		}
 		notification.Opsgenie.Description = descData.String()
@@ -54,13 +56,12 @@ func (s *opsgenieService) Send(notification Notification, dest Destination) erro
 	if !ok {
 		return fmt.Errorf("no API key configured for recipient %s", dest.Recipient)
 	}
+	httpClient := httputil.NewClient(s.opts.ApiUrl, s.opts.HTTP, s.opts.TLS, s.opts.Proxy)
+	httpClient.Transport = httputil.NewLoggingRoundTripper(httpClient.Transport, log.WithField("service", "opsgenie"))
 	alertClient, _ := alert.NewClient(&client.Config{
 		ApiKey:         apiKey,
 		OpsGenieAPIURL: client.ApiUrl(s.opts.ApiUrl),
-		HttpClient: &http.Client{
-			Transport: httputil.NewLoggingRoundTripper(
-				httputil.NewTransport(s.opts.ApiUrl, false), log.WithField("service", "opsgenie")),
-		},
+		HttpClient:     httpClient,
 	})
 	description := ""
 	if notification.Opsgenie != nil {
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrServiceRateLimited is returned if the notification could not be sent because the service rate limit was exhausted
+var ErrServiceRateLimited = errors.New("notification service is rate limited: max number of notifications per second reached")
+
+// RateLimitOptions holds settings of the token bucket that spreads notifications sent by the service over time
+type RateLimitOptions struct {
+	// Rate is the average number of notifications sent per second. Notifications are not rate limited if zero
+	Rate float64 `json:"rate,omitempty"`
+	// Burst is the max number of notifications sent at once after the idle period. Defaults to the rate rounded up
+	Burst int `json:"burst,omitempty"`
+	// MaxWait is the max time the delivery waits for its turn
+	MaxWait string `json:"maxWait,omitempty"`
+}
+
+var DefaultRateLimitOptions = RateLimitOptions{
+	MaxWait: "1m",
+}
+
+// Merge returns copy of options with fields overridden by non empty fields of the other options
+func (o RateLimitOptions) Merge(other RateLimitOptions) RateLimitOptions {
+	if other.Rate != 0 {
+		o.Rate = other.Rate
+	}
+	if other.Burst != 0 {
+		o.Burst = other.Burst
+	}
+	if other.MaxWait != "" {
+		o.MaxWait = other.MaxWait
+	}
+	return o
+}
+
+// RateLimiter holds the token bucket shared by all instances of the notification service
+type RateLimiter struct {
+	limiter *rate.Limiter
+	maxWait time.Duration
+}
+
+// NewRateLimiter returns limiter configured using specified options or nil if the rate is not limited
+func NewRateLimiter(opts RateLimitOptions) (*RateLimiter, error) {
+	if opts.Rate <= 0 {
+		return nil, nil
+	}
+	burst := opts.Burst
+	if burst <= 0 {
+		burst = int(math.Ceil(opts.Rate))
+	}
+	limiter := &RateLimiter{limiter: rate.NewLimiter(rate.Limit(opts.Rate), burst)}
+	if opts.MaxWait != "" {
+		maxWait, err := time.ParseDuration(opts.MaxWait)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit maxWait '%s': %v", opts.MaxWait, err)
+		}
+		limiter.maxWait = maxWait
+	}
+	return limiter, nil
+}
+
+// wait blocks until the notification might be sent and returns false if it would take longer than the max wait time
+func (l *RateLimiter) wait() bool {
+	if l.maxWait <= 0 {
+		return l.limiter.Allow()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), l.maxWait)
+	defer cancel()
+	// the wait fails immediately if the reservation would exceed the deadline, so the token is not consumed
+	return l.limiter.Wait(ctx) == nil
+}
+
+// NewRateLimitedService returns notification service that delays deliveries exceeding the service rate, so mass events,
+// e.g. the cluster-wide resync, don't trip abuse detection of the provider, and fails with ErrServiceRateLimited if the
+// delivery would wait longer than the max wait time
+func NewRateLimitedService(service NotificationService, limiter *RateLimiter) NotificationService {
+	if limiter == nil {
+		return service
+	}
+	return &rateLimitedService{service: service, limiter: limiter}
+}
+
+type rateLimitedService struct {
+	service NotificationService
+	limiter *RateLimiter
+}
+
+func (s *rateLimitedService) Send(notification Notification, dest Destination) error {
+	if !s.limiter.wait() {
+		return ErrServiceRateLimited
+	}
+	return s.service.Send(notification, dest)
+}
+
+func (s *rateLimitedService) Unwrap() NotificationService {
+	return s.service
+}
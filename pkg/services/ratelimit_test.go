@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitedService_FailsIfWaitIsTooLong(t *testing.T) {
+	limiter, err := NewRateLimiter(RateLimitOptions{Rate: 1, Burst: 2, MaxWait: "10ms"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	svc := &failingService{}
+
+	// the bucket is shared with other instances of the same service
+	assert.NoError(t, NewRateLimitedService(svc, limiter).Send(Notification{}, Destination{}))
+	assert.NoError(t, NewRateLimitedService(svc, limiter).Send(Notification{}, Destination{}))
+	assert.Equal(t, ErrServiceRateLimited, NewRateLimitedService(svc, limiter).Send(Notification{}, Destination{}))
+	assert.Equal(t, 2, svc.calls)
+}
+
+func TestRateLimitedService_WaitsForToken(t *testing.T) {
+	limiter, err := NewRateLimiter(RateLimitOptions{Rate: 50, Burst: 1, MaxWait: "5s"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	svc := NewRateLimitedService(&failingService{}, limiter)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, svc.Send(Notification{}, Destination{}))
+	}
+	// the first notification is sent immediately and the others are spread by 20ms
+	assert.True(t, time.Since(start) >= 30*time.Millisecond)
+}
+
+func TestNewRateLimiter(t *testing.T) {
+	limiter, err := NewRateLimiter(DefaultRateLimitOptions)
+	assert.NoError(t, err)
+	assert.Nil(t, limiter)
+	svc := &failingService{}
+	assert.Equal(t, svc, NewRateLimitedService(svc, limiter))
+
+	limiter, err = NewRateLimiter(RateLimitOptions{Rate: 2.5})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, limiter.limiter.Burst())
+	}
+
+	_, err = NewRateLimiter(RateLimitOptions{Rate: 1, MaxWait: "abc"})
+	assert.Error(t, err)
+}
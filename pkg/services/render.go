@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	texttemplate "text/template"
+	"time"
+)
+
+const (
+	RenderErrorTimeout    = "timeout"
+	RenderErrorOutputSize = "output_size"
+	RenderErrorCalls      = "calls"
+	RenderErrorPanic      = "panic"
+	RenderErrorFailed     = "error"
+)
+
+// ErrTemplateOutputTooLarge is returned if the rendered template exceeds the output size limit
+var ErrTemplateOutputTooLarge = errors.New("template output exceeds the size limit")
+
+// RenderError is returned if the notification template cannot be rendered
+type RenderError struct {
+	Template string
+	// Reason is the low cardinality reason suitable for metric labels, one of RenderErrorTimeout,
+	// RenderErrorOutputSize, RenderErrorCalls, RenderErrorPanic and RenderErrorFailed
+	Reason string
+	Err    error
+}
+
+func (e *RenderError) Error() string {
+	return fmt.Sprintf("failed to render template '%s': %v", e.Template, e.Err)
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// RenderBudget bounds the rendering of one notification template. Text templates cannot be interrupted, so the budget
+// is checked by every template function and every write of the template output: the execution is aborted once the
+// deadline passes, the template calls too many functions or all template fragments together produce too much output.
+// The budget is not safe for concurrent use.
+type RenderBudget struct {
+	base      texttemplate.FuncMap
+	funcs     texttemplate.FuncMap
+	timeout   time.Duration
+	deadline  time.Time
+	maxCalls  int
+	calls     int
+	maxOutput int
+	output    int
+	reason    string
+	err       error
+}
+
+// NewRenderBudget returns the budget that applies limits to the specified template functions. Zero limits are not
+// enforced.
+func NewRenderBudget(funcs texttemplate.FuncMap, timeout time.Duration, maxCalls int, maxOutput int) *RenderBudget {
+	b := &RenderBudget{base: funcs, timeout: timeout, maxCalls: maxCalls, maxOutput: maxOutput}
+	if timeout > 0 {
+		b.deadline = time.Now().Add(timeout)
+	}
+	return b
+}
+
+// Err returns the low cardinality reason and the error if the budget is exhausted
+func (b *RenderBudget) Err() (string, error) {
+	return b.reason, b.err
+}
+
+func (b *RenderBudget) exhaust(reason string, err error) error {
+	if b.err == nil {
+		b.reason, b.err = reason, err
+	}
+	return b.err
+}
+
+func (b *RenderBudget) checkDeadline() error {
+	if b.err == nil && !b.deadline.IsZero() && time.Now().After(b.deadline) {
+		return b.exhaust(RenderErrorTimeout, fmt.Errorf("rendering takes longer than %v", b.timeout))
+	}
+	return b.err
+}
+
+func (b *RenderBudget) call() error {
+	if b.err != nil {
+		return b.err
+	}
+	b.calls++
+	if b.maxCalls > 0 && b.calls > b.maxCalls {
+		return b.exhaust(RenderErrorCalls, fmt.Errorf("template calls functions more than %d times", b.maxCalls))
+	}
+	return b.checkDeadline()
+}
+
+func (b *RenderBudget) write(n int) error {
+	if err := b.checkDeadline(); err != nil {
+		return err
+	}
+	b.output += n
+	if b.maxOutput > 0 && b.output > b.maxOutput {
+		return b.exhaust(RenderErrorOutputSize, fmt.Errorf("%w: rendered output exceeds %d bytes", ErrTemplateOutputTooLarge, b.maxOutput))
+	}
+	return nil
+}
+
+// wrap returns the function that checks the budget before calling the specified function. The budget error is returned
+// as the second result rather than the panic, since text/template reports errors of functions as execution errors.
+func (b *RenderBudget) wrap(fn interface{}) interface{} {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fn
+	}
+	t := v.Type()
+	switch {
+	case t.NumOut() == 1:
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+	default:
+		// text/template rejects such functions anyway
+		return fn
+	}
+	in := make([]reflect.Type, t.NumIn())
+	for i := range in {
+		in[i] = t.In(i)
+	}
+	out := []reflect.Type{t.Out(0), errorType}
+	wrapped := reflect.MakeFunc(reflect.FuncOf(in, out, t.IsVariadic()), func(args []reflect.Value) []reflect.Value {
+		if err := b.call(); err != nil {
+			return []reflect.Value{reflect.Zero(out[0]), reflect.ValueOf(&err).Elem()}
+		}
+		var res []reflect.Value
+		if t.IsVariadic() {
+			res = v.CallSlice(args)
+		} else {
+			res = v.Call(args)
+		}
+		if len(res) == 1 {
+			res = append(res, reflect.Zero(errorType))
+		}
+		return res
+	})
+	return wrapped.Interface()
+}
+
+func (b *RenderBudget) funcMap() texttemplate.FuncMap {
+	if b.funcs == nil {
+		b.funcs = texttemplate.FuncMap{}
+		for name, fn := range b.base {
+			b.funcs[name] = b.wrap(fn)
+		}
+	}
+	return b.funcs
+}
+
+// Vars returns the copy of template variables with functions, e.g. ones of the repo and argocd variables, wrapped so
+// calls of variables are subject to the budget too. The function that is already running, e.g. waits for the Argo CD
+// API, is bounded by its own timeout.
+func (b *RenderBudget) Vars(vars map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(vars))
+	for k, v := range vars {
+		if nested, ok := v.(map[string]interface{}); ok && hasFuncs(nested) {
+			wrapped := make(map[string]interface{}, len(nested))
+			for name, fn := range nested {
+				wrapped[name] = b.wrap(fn)
+			}
+			v = wrapped
+		} else {
+			v = b.wrap(v)
+		}
+		res[k] = v
+	}
+	return res
+}
+
+func hasFuncs(vars map[string]interface{}) bool {
+	for _, v := range vars {
+		if v != nil && reflect.TypeOf(v).Kind() == reflect.Func {
+			return true
+		}
+	}
+	return false
+}
+
+type budgetWriter struct {
+	w      io.Writer
+	budget *RenderBudget
+}
+
+func (w *budgetWriter) Write(p []byte) (int, error) {
+	if err := w.budget.write(len(p)); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
+
+// executeTemplate executes the template with functions and the output bounded by the budget. The template is executed
+// as is if the budget is nil.
+func executeTemplate(t *texttemplate.Template, w io.Writer, vars interface{}, budget *RenderBudget) error {
+	if budget == nil {
+		return t.Execute(w, vars)
+	}
+	if err := budget.checkDeadline(); err != nil {
+		return err
+	}
+	budgeted, err := t.Clone()
+	if err != nil {
+		return err
+	}
+	return budgeted.Funcs(budget.funcMap()).Execute(&budgetWriter{w: w, budget: budget}, vars)
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// RetryOptions holds settings of the notification delivery retries
+type RetryOptions struct {
+	// MaxAttempts is the max number of delivery attempts including the first one
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// InitialInterval is delay before the first retry
+	InitialInterval string `json:"initialInterval,omitempty"`
+	// MaxInterval limits the delay between retries
+	MaxInterval string `json:"maxInterval,omitempty"`
+	// Multiplier is the factor used to increase delay after each retry
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// Jitter is the max random fraction of the delay added to each delay
+	Jitter float64 `json:"jitter,omitempty"`
+}
+
+var DefaultRetryOptions = RetryOptions{
+	MaxAttempts:     3,
+	InitialInterval: "1s",
+	MaxInterval:     "30s",
+	Multiplier:      2,
+	Jitter:          0.2,
+}
+
+// Merge returns copy of options with fields overridden by non empty fields of the other options
+func (o RetryOptions) Merge(other RetryOptions) RetryOptions {
+	if other.MaxAttempts != 0 {
+		o.MaxAttempts = other.MaxAttempts
+	}
+	if other.InitialInterval != "" {
+		o.InitialInterval = other.InitialInterval
+	}
+	if other.MaxInterval != "" {
+		o.MaxInterval = other.MaxInterval
+	}
+	if other.Multiplier != 0 {
+		o.Multiplier = other.Multiplier
+	}
+	if other.Jitter != 0 {
+		o.Jitter = other.Jitter
+	}
+	return o
+}
+
+func (o RetryOptions) backoff() (wait.Backoff, error) {
+	backoff := wait.Backoff{Steps: o.MaxAttempts, Factor: o.Multiplier, Jitter: o.Jitter}
+	if o.InitialInterval != "" {
+		initial, err := time.ParseDuration(o.InitialInterval)
+		if err != nil {
+			return backoff, fmt.Errorf("invalid retry initialInterval '%s': %v", o.InitialInterval, err)
+		}
+		backoff.Duration = initial
+	}
+	if o.MaxInterval != "" {
+		max, err := time.ParseDuration(o.MaxInterval)
+		if err != nil {
+			return backoff, fmt.Errorf("invalid retry maxInterval '%s': %v", o.MaxInterval, err)
+		}
+		backoff.Cap = max
+	}
+	return backoff, nil
+}
+
+// HTTPError is returned when notification service responded with unsuccessful status code
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("request to %s has failed with error code %d : %s", e.URL, e.StatusCode, e.Body)
+}
+
+// retryClassifier returns true if delivery should be retried and optional delay requested by notification service
+type retryClassifier func(err error) (bool, time.Duration)
+
+var retryClassifiers = map[string]retryClassifier{
+	"slack": func(err error) (bool, time.Duration) {
+		var rateLimitedErr *slack.RateLimitedError
+		if errors.As(err, &rateLimitedErr) {
+			return true, rateLimitedErr.RetryAfter
+		}
+		return isRetryableError(err), 0
+	},
+	"email": func(err error) (bool, time.Duration) {
+		// 4xx SMTP reply codes indicate transient failures
+		var smtpErr *textproto.Error
+		if errors.As(err, &smtpErr) {
+			return smtpErr.Code >= 400 && smtpErr.Code < 500, 0
+		}
+		return isRetryableError(err), 0
+	},
+}
+
+// isRetryableError returns true for network errors and HTTP errors that are expected to be transient
+func isRetryableError(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	// connection refused, connection reset and similar errors
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// IsRetryable returns true if sending notification using the service of specified type might succeed after retry
+func IsRetryable(serviceType string, err error) bool {
+	retryable, _ := classify(serviceType, err)
+	return retryable
+}
+
+func classify(serviceType string, err error) (bool, time.Duration) {
+	if classifier, ok := retryClassifiers[serviceType]; ok {
+		return classifier(err)
+	}
+	return isRetryableError(err), 0
+}
+
+// NewRetryService returns notification service that retries failed deliveries using exponential backoff with jitter
+func NewRetryService(service NotificationService, serviceType string, opts RetryOptions) (NotificationService, error) {
+	if opts.MaxAttempts <= 1 {
+		return service, nil
+	}
+	backoff, err := opts.backoff()
+	if err != nil {
+		return nil, err
+	}
+	return &retryService{service: service, serviceType: serviceType, maxAttempts: opts.MaxAttempts, backoff: backoff, sleep: time.Sleep}, nil
+}
+
+type retryService struct {
+	service     NotificationService
+	serviceType string
+	maxAttempts int
+	backoff     wait.Backoff
+	sleep       func(time.Duration)
+}
+
+func (s *retryService) Send(notification Notification, dest Destination) error {
+	backoff := s.backoff
+	attempt := 1
+	for {
+		err := s.service.Send(notification, dest)
+		if err == nil {
+			return nil
+		}
+		retryable, retryAfter := classify(s.serviceType, err)
+		if !retryable || attempt >= s.maxAttempts {
+			if attempt > 1 {
+				return fmt.Errorf("failed after %d attempts: %w", attempt, err)
+			}
+			return err
+		}
+		delay := backoff.Step()
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		log.Warnf("Failed to send notification to %s:%s (attempt %d of %d), retrying in %v: %v",
+			dest.Service, dest.Recipient, attempt, s.maxAttempts, delay, err)
+		s.sleep(delay)
+		attempt++
+	}
+}
+
+func (s *retryService) Unwrap() NotificationService {
+	return s.service
+}
+
+// Unwrap returns the notification service wrapped by decorators such as retries
+func Unwrap(service NotificationService) NotificationService {
+	for {
+		wrapper, ok := service.(interface{ Unwrap() NotificationService })
+		if !ok {
+			return service
+		}
+		service = wrapper.Unwrap()
+	}
+}
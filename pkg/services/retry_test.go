@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"net"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingService struct {
+	errs  []error
+	calls int
+}
+
+func (s *failingService) Send(_ Notification, _ Destination) error {
+	s.calls++
+	if len(s.errs) == 0 {
+		return nil
+	}
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+	return err
+}
+
+func newTestRetryService(t *testing.T, svc NotificationService, serviceType string, delays *[]time.Duration) NotificationService {
+	retrySvc, err := NewRetryService(svc, serviceType, DefaultRetryOptions)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	retrySvc.(*retryService).backoff.Jitter = 0
+	retrySvc.(*retryService).sleep = func(d time.Duration) {
+		*delays = append(*delays, d)
+	}
+	return retrySvc
+}
+
+func TestRetryService_RetriesTransientErrors(t *testing.T) {
+	svc := &failingService{errs: []error{&HTTPError{StatusCode: 503}, &HTTPError{StatusCode: 502}}}
+	var delays []time.Duration
+
+	err := newTestRetryService(t, svc, "webhook", &delays).Send(Notification{}, Destination{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, svc.calls)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, delays)
+}
+
+func TestRetryService_GivesUpAfterMaxAttempts(t *testing.T) {
+	svc := &failingService{errs: []error{&HTTPError{StatusCode: 500}, &HTTPError{StatusCode: 500}, &HTTPError{StatusCode: 500}}}
+	var delays []time.Duration
+
+	err := newTestRetryService(t, svc, "webhook", &delays).Send(Notification{}, Destination{})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed after 3 attempts")
+	assert.Equal(t, 3, svc.calls)
+}
+
+func TestRetryService_DoesNotRetryPermanentErrors(t *testing.T) {
+	svc := &failingService{errs: []error{&HTTPError{StatusCode: 404}}}
+	var delays []time.Duration
+
+	err := newTestRetryService(t, svc, "webhook", &delays).Send(Notification{}, Destination{})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, svc.calls)
+	assert.Empty(t, delays)
+}
+
+func TestRetryService_UsesSlackRetryAfter(t *testing.T) {
+	svc := &failingService{errs: []error{&slack.RateLimitedError{RetryAfter: 10 * time.Second}}}
+	var delays []time.Duration
+
+	err := newTestRetryService(t, svc, "slack", &delays).Send(Notification{}, Destination{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{10 * time.Second}, delays)
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable("webhook", &net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+	assert.True(t, IsRetryable("webhook", &HTTPError{StatusCode: 429}))
+	assert.False(t, IsRetryable("webhook", &HTTPError{StatusCode: 401}))
+	assert.False(t, IsRetryable("slack", errors.New("channel_not_found")))
+	assert.True(t, IsRetryable("email", &textproto.Error{Code: 421, Msg: "service not available"}))
+	assert.False(t, IsRetryable("email", &textproto.Error{Code: 550, Msg: "mailbox unavailable"}))
+}
+
+func TestUnwrap(t *testing.T) {
+	svc := &failingService{}
+	retrySvc, err := NewRetryService(svc, "webhook", DefaultRetryOptions)
+	assert.NoError(t, err)
+	assert.Equal(t, svc, Unwrap(retrySvc))
+	assert.Equal(t, svc, Unwrap(svc))
+}
@@ -8,14 +8,17 @@ import (
 	texttemplate "text/template"
 
 	"github.com/ghodss/yaml"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/signing"
 )
 
 type Notification struct {
-	Message  string                `json:"message,omitempty"`
-	Email    *EmailNotification    `json:"email,omitempty"`
-	Slack    *SlackNotification    `json:"slack,omitempty"`
-	Webhook  WebhookNotifications  `json:"webhook,omitempty"`
-	Opsgenie *OpsgenieNotification `json:"opsgenie,omitempty"`
+	Message    string                  `json:"message,omitempty"`
+	Email      *EmailNotification      `json:"email,omitempty"`
+	Slack      *SlackNotification      `json:"slack,omitempty"`
+	Webhook    WebhookNotifications    `json:"webhook,omitempty"`
+	Opsgenie   *OpsgenieNotification   `json:"opsgenie,omitempty"`
+	ArgoEvents *ArgoEventsNotification `json:"argoEvents,omitempty"`
 }
 
 // Destination holds notification destination details
@@ -40,6 +43,10 @@ func (n *Notification) GetTemplater(name string, f texttemplate.FuncMap) (Templa
 		sources = append(sources, n.Opsgenie)
 	}
 
+	if n.ArgoEvents != nil {
+		sources = append(sources, n.ArgoEvents)
+	}
+
 	return n.getTemplater(name, f, sources)
 }
 
@@ -50,6 +57,31 @@ type NotificationService interface {
 	Send(notification Notification, dest Destination) error
 }
 
+// ServiceTypes holds types of the notification services supported by NewService
+var ServiceTypes = []string{"email", "slack", "grafana", "opsgenie", "webhook", "telegram", "teams", "discord", "argoevents", "console"}
+
+// SigningServiceTypes holds types of the services that sign payloads
+var SigningServiceTypes = []string{"webhook", "argoevents"}
+
+// NewSignedService is like NewService but the returned service signs payloads using the signer. The signer is created
+// by the caller, so the private key is parsed once per config rather than for every notification. Signing settings of
+// the service options are ignored
+func NewSignedService(serviceType string, optsData []byte, signer *signing.Signer) (NotificationService, error) {
+	svc, err := NewService(serviceType, optsData)
+	if err != nil {
+		return nil, err
+	}
+	switch s := svc.(type) {
+	case *webhookService:
+		s.signer = signer
+	case *argoEventsService:
+		s.signer = signer
+	default:
+		return nil, fmt.Errorf("service type '%s' does not support signing", serviceType)
+	}
+	return svc, nil
+}
+
 func NewService(serviceType string, optsData []byte) (NotificationService, error) {
 	switch serviceType {
 	case "email":
@@ -88,6 +120,33 @@ func NewService(serviceType string, optsData []byte) (NotificationService, error
 			return nil, err
 		}
 		return NewTelegramService(opts), nil
+	case "teams":
+		var opts TeamsOptions
+		if err := yaml.Unmarshal(optsData, &opts); err != nil {
+			return nil, err
+		}
+		return NewTeamsService(opts), nil
+	case "discord":
+		var opts DiscordOptions
+		if err := yaml.Unmarshal(optsData, &opts); err != nil {
+			return nil, err
+		}
+		return NewDiscordService(opts), nil
+	case "argoevents":
+		var opts ArgoEventsOptions
+		if err := yaml.Unmarshal(optsData, &opts); err != nil {
+			return nil, err
+		}
+		return NewArgoEventsService(opts), nil
+	case "console":
+		var opts ConsoleOptions
+		if err := yaml.Unmarshal(optsData, &opts); err != nil {
+			return nil, err
+		}
+		if err := opts.Validate(); err != nil {
+			return nil, err
+		}
+		return NewConfiguredConsoleService(opts), nil
 	default:
 		return nil, fmt.Errorf("service type '%s' is not supported", serviceType)
 	}
@@ -118,9 +177,9 @@ func (n *Notification) getTemplater(name string, f texttemplate.FuncMap, sources
 		return nil, err
 	}
 
-	templaters := []Templater{func(notification *Notification, vars map[string]interface{}) error {
+	templaters := []Templater{func(notification *Notification, vars map[string]interface{}, budget *RenderBudget) error {
 		var messageData bytes.Buffer
-		if err := message.Execute(&messageData, vars); err != nil {
+		if err := executeTemplate(message, &messageData, vars, budget); err != nil {
 			return err
 		}
 		if val := messageData.String(); val != "" {
@@ -138,9 +197,9 @@ func (n *Notification) getTemplater(name string, f texttemplate.FuncMap, sources
 		templaters = append(templaters, t)
 	}
 
-	return func(notification *Notification, vars map[string]interface{}) error {
+	return func(notification *Notification, vars map[string]interface{}, budget *RenderBudget) error {
 		for _, t := range templaters {
-			if err := t(notification, vars); err != nil {
+			if err := t(notification, vars, budget); err != nil {
 				return err
 			}
 		}
@@ -148,7 +207,9 @@ func (n *Notification) getTemplater(name string, f texttemplate.FuncMap, sources
 	}, nil
 }
 
-type Templater func(notification *Notification, vars map[string]interface{}) error
+// Templater renders the notification using the template variables and the budget that bounds the rendering, the
+// rendering is not bounded if the budget is nil
+type Templater func(notification *Notification, vars map[string]interface{}, budget *RenderBudget) error
 
 type TemplaterSource interface {
 	GetTemplater(name string, f texttemplate.FuncMap) (Templater, error)
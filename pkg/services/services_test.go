@@ -19,7 +19,7 @@ func TestGetTemplater(t *testing.T) {
 
 	err = templater(&notification, map[string]interface{}{
 		"foo": "hello",
-	})
+	}, nil)
 
 	if !assert.NoError(t, err) {
 		return
@@ -27,3 +27,14 @@ func TestGetTemplater(t *testing.T) {
 
 	assert.Equal(t, "hello", notification.Message)
 }
+
+func TestNewService_ServiceTypes(t *testing.T) {
+	for _, serviceType := range ServiceTypes {
+		_, err := NewService(serviceType, []byte("{}"))
+		if err != nil {
+			assert.NotContains(t, err.Error(), "is not supported", serviceType)
+		}
+	}
+	_, err := NewService("unknown", []byte("{}"))
+	assert.EqualError(t, err, "service type 'unknown' is not supported")
+}
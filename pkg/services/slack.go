@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 	"regexp"
 	texttemplate "text/template"
@@ -30,18 +29,18 @@ func (n *SlackNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 	if err != nil {
 		return nil, err
 	}
-	return func(notification *Notification, vars map[string]interface{}) error {
+	return func(notification *Notification, vars map[string]interface{}, budget *RenderBudget) error {
 		if notification.Slack == nil {
 			notification.Slack = &SlackNotification{}
 		}
 		var slackAttachmentsData bytes.Buffer
-		if err := slackAttachments.Execute(&slackAttachmentsData, vars); err != nil {
+		if err := executeTemplate(slackAttachments, &slackAttachmentsData, vars, budget); err != nil {
 			return err
 		}
 
 		notification.Slack.Attachments = slackAttachmentsData.String()
 		var slackBlocksData bytes.Buffer
-		if err := slackBlocks.Execute(&slackBlocksData, vars); err != nil {
+		if err := executeTemplate(slackBlocks, &slackBlocksData, vars, budget); err != nil {
 			return err
 		}
 		notification.Slack.Blocks = slackBlocksData.String()
@@ -50,13 +49,17 @@ func (n *SlackNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 }
 
 type SlackOptions struct {
-	Username           string   `json:"username"`
-	Icon               string   `json:"icon"`
-	Token              string   `json:"token"`
-	SigningSecret      string   `json:"signingSecret"`
-	Channels           []string `json:"channels"`
-	InsecureSkipVerify bool     `json:"insecureSkipVerify"`
-	ApiURL             string   `json:"apiURL"`
+	Username           string                 `json:"username"`
+	Icon               string                 `json:"icon"`
+	Token              string                 `json:"token"`
+	SigningSecret      string                 `json:"signingSecret"`
+	CommandToken       string                 `json:"commandToken"`
+	Channels           []string               `json:"channels"`
+	InsecureSkipVerify bool                   `json:"insecureSkipVerify"`
+	ApiURL             string                 `json:"apiURL"`
+	Proxy              httputil.ProxyOptions  `json:"proxy"`
+	TLS                httputil.TLSOptions    `json:"tls"`
+	HTTP               httputil.ClientOptions `json:"http"`
 }
 
 type slackService struct {
@@ -74,10 +77,10 @@ func (s *slackService) Send(notification Notification, dest Destination) error {
 	if s.opts.ApiURL != "" {
 		apiURL = s.opts.ApiURL
 	}
-	transport := httputil.NewTransport(apiURL, s.opts.InsecureSkipVerify)
-	client := &http.Client{
-		Transport: httputil.NewLoggingRoundTripper(transport, log.WithField("service", "slack")),
-	}
+	tlsOpts := s.opts.TLS
+	tlsOpts.InsecureSkipVerify = tlsOpts.InsecureSkipVerify || s.opts.InsecureSkipVerify
+	client := httputil.NewClient(apiURL, s.opts.HTTP, tlsOpts, s.opts.Proxy)
+	client.Transport = httputil.NewLoggingRoundTripper(client.Transport, log.WithField("service", "slack"))
 	sl := slack.New(s.opts.Token, slack.OptionHTTPClient(client), slack.OptionAPIURL(apiURL))
 	msgOptions := []slack.MsgOption{slack.MsgOptionText(notification.Message, false)}
 	if s.opts.Username != "" {
@@ -119,6 +122,11 @@ func (s *slackService) GetSigningSecret() string {
 	return s.opts.SigningSecret
 }
 
+// GetCommandToken exposes token of Mattermost slash commands for the bot
+func (s *slackService) GetCommandToken() string {
+	return s.opts.CommandToken
+}
+
 func isValidIconURL(iconURL string) bool {
 	_, err := url.ParseRequestURI(iconURL)
 	if err != nil {
@@ -38,7 +38,7 @@ func TestGetTemplater_Slack(t *testing.T) {
 	err = templater(&notification, map[string]interface{}{
 		"foo": "hello",
 		"bar": "world",
-	})
+	}, nil)
 
 	if !assert.NoError(t, err) {
 		return
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	log "github.com/sirupsen/logrus"
+
+	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
+)
+
+// TeamsOptions holds Microsoft Teams incoming webhook URLs of the channels and the optional credentials of the Teams bot
+type TeamsOptions struct {
+	// RecipientURLs maps the channel name used in subscriptions to the channel incoming webhook URL
+	RecipientURLs map[string]string `json:"recipientUrls"`
+	// AppID and AppPassword are the Azure Bot registration credentials used by the Teams bot
+	AppID       string                 `json:"appId"`
+	AppPassword string                 `json:"appPassword"`
+	HTTP        httputil.ClientOptions `json:"http"`
+}
+
+func NewTeamsService(opts TeamsOptions) NotificationService {
+	return &teamsService{opts: opts}
+}
+
+type teamsService struct {
+	opts TeamsOptions
+}
+
+type teamsMessageCard struct {
+	Type    string `json:"@type"`
+	Context string `json:"@context"`
+	Text    string `json:"text"`
+}
+
+func (s *teamsService) Send(notification Notification, dest Destination) error {
+	webhookURL, ok := s.opts.RecipientURLs[dest.Recipient]
+	if !ok {
+		return fmt.Errorf("no teams webhook configured for recipient %s", dest.Recipient)
+	}
+	data, err := json.Marshal(teamsMessageCard{
+		Type:    "MessageCard",
+		Context: "https://schema.org/extensions",
+		Text:    notification.Message,
+	})
+	if err != nil {
+		return err
+	}
+	// requests are not logged because webhook URL includes the access token
+	client := httputil.NewClient(webhookURL, s.opts.HTTP, httputil.TLSOptions{}, httputil.ProxyOptions{})
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if !(resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			body = []byte(fmt.Sprintf("unable to read response data: %v", err))
+		}
+		return &HTTPError{URL: "teams webhook", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	log.WithField("service", dest.Service).Debugf("Posted message to the channel %s", dest.Recipient)
+	return nil
+}
+
+// GetBotCredentials exposes Azure Bot credentials for teams bot
+func (s *teamsService) GetBotCredentials() (string, string) {
+	return s.opts.AppID, s.opts.AppPassword
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeams_Send(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal(data, &received))
+		_, _ = w.Write([]byte("1"))
+	}))
+	defer server.Close()
+
+	service := NewTeamsService(TeamsOptions{RecipientURLs: map[string]string{"general": server.URL}})
+	err := service.Send(Notification{Message: "hello"}, Destination{Service: "teams", Recipient: "general"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "MessageCard", received["@type"])
+	assert.Equal(t, "hello", received["text"])
+}
+
+func TestTeams_UnknownRecipient(t *testing.T) {
+	service := NewTeamsService(TeamsOptions{RecipientURLs: map[string]string{}})
+	err := service.Send(Notification{Message: "hello"}, Destination{Service: "teams", Recipient: "general"})
+	assert.EqualError(t, err, "no teams webhook configured for recipient general")
+}
@@ -1,9 +1,19 @@
 package services
 
-import tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+import (
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+
+	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
+)
 
 type TelegramOptions struct {
-	Token string `json:"token"`
+	Token string                 `json:"token"`
+	Proxy httputil.ProxyOptions  `json:"proxy"`
+	HTTP  httputil.ClientOptions `json:"http"`
+	// WebhookSecret is the secret token that Telegram sends with every update delivered to the bot webhook
+	WebhookSecret string `json:"webhookSecret"`
 }
 
 func NewTelegramService(opts TelegramOptions) NotificationService {
@@ -15,10 +25,24 @@ type telegramService struct {
 }
 
 func (s telegramService) Send(notification Notification, dest Destination) error {
-	bot, err := tgbotapi.NewBotAPI(s.opts.Token)
+	// requests are not logged because bot token is part of the request URL
+	client := httputil.NewClient(tgbotapi.APIEndpoint, s.opts.HTTP, httputil.TLSOptions{}, s.opts.Proxy)
+	bot, err := tgbotapi.NewBotAPIWithClient(s.opts.Token, client)
 	if err != nil {
 		return err
 	}
-	_, err = bot.Send(tgbotapi.NewMessageToChannel("@"+dest.Recipient, notification.Message))
+	// recipient is either the public channel username or the numeric chat id, e.g. the id of the group subscribed using bot
+	var msg tgbotapi.MessageConfig
+	if chatID, err := strconv.ParseInt(dest.Recipient, 10, 64); err == nil {
+		msg = tgbotapi.NewMessage(chatID, notification.Message)
+	} else {
+		msg = tgbotapi.NewMessageToChannel("@"+dest.Recipient, notification.Message)
+	}
+	_, err = bot.Send(msg)
 	return err
 }
+
+// GetWebhookSecret exposes webhook secret for telegram bot
+func (s telegramService) GetWebhookSecret() string {
+	return s.opts.WebhookSecret
+}
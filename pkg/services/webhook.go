@@ -12,6 +12,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/signing"
 )
 
 type WebhookNotification struct {
@@ -41,18 +42,18 @@ func (n WebhookNotifications) GetTemplater(name string, f texttemplate.FuncMap)
 		}
 		webhooks[k] = compiledWebhookTemplate{body: body, method: v.Method, path: path}
 	}
-	return func(notification *Notification, vars map[string]interface{}) error {
+	return func(notification *Notification, vars map[string]interface{}, budget *RenderBudget) error {
 		for k, v := range webhooks {
 			if notification.Webhook == nil {
 				notification.Webhook = map[string]WebhookNotification{}
 			}
 			var body bytes.Buffer
-			err := webhooks[k].body.Execute(&body, vars)
+			err := executeTemplate(webhooks[k].body, &body, vars, budget)
 			if err != nil {
 				return err
 			}
 			var path bytes.Buffer
-			err = webhooks[k].path.Execute(&path, vars)
+			err = executeTemplate(webhooks[k].path, &path, vars, budget)
 			if err != nil {
 				return err
 			}
@@ -77,9 +78,14 @@ type BasicAuth struct {
 }
 
 type WebhookOptions struct {
-	URL       string     `json:"url"`
-	Headers   []Header   `json:"headers"`
-	BasicAuth *BasicAuth `json:"basicAuth"`
+	URL       string                 `json:"url"`
+	Headers   []Header               `json:"headers"`
+	BasicAuth *BasicAuth             `json:"basicAuth"`
+	Proxy     httputil.ProxyOptions  `json:"proxy"`
+	TLS       httputil.TLSOptions    `json:"tls"`
+	HTTP      httputil.ClientOptions `json:"http"`
+	// Signing configures the signature of the request body. Applied by NewSignedService
+	Signing signing.Options `json:"signing"`
 }
 
 func NewWebhookService(opts WebhookOptions) NotificationService {
@@ -87,7 +93,8 @@ func NewWebhookService(opts WebhookOptions) NotificationService {
 }
 
 type webhookService struct {
-	opts WebhookOptions
+	opts   WebhookOptions
+	signer *signing.Signer
 }
 
 func (s webhookService) Send(notification Notification, dest Destination) error {
@@ -117,11 +124,18 @@ func (s webhookService) Send(notification Notification, dest Destination) error
 	if s.opts.BasicAuth != nil {
 		req.SetBasicAuth(s.opts.BasicAuth.Username, s.opts.BasicAuth.Password)
 	}
-
-	client := http.Client{
-		Transport: httputil.NewLoggingRoundTripper(
-			httputil.NewTransport(url, false), log.WithField("service", dest.Service)),
+	if s.signer != nil {
+		headers, err := s.signer.Headers([]byte(body))
+		if err != nil {
+			return err
+		}
+		for name, val := range headers {
+			req.Header.Set(name, val)
+		}
 	}
+
+	client := httputil.NewClient(url, s.opts.HTTP, s.opts.TLS, s.opts.Proxy)
+	client.Transport = httputil.NewLoggingRoundTripper(client.Transport, log.WithField("service", dest.Service))
 	resp, err := client.Do(req)
 	if err != nil {
 		return err
@@ -131,7 +145,7 @@ func (s webhookService) Send(notification Notification, dest Destination) error
 		if err != nil {
 			data = []byte(fmt.Sprintf("unable to read response data: %v", err))
 		}
-		return fmt.Errorf("request to %s has failed with error code %d : %s", url, resp.StatusCode, string(data))
+		return &HTTPError{URL: url, StatusCode: resp.StatusCode, Body: string(data)}
 	}
 	return nil
 }
@@ -1,6 +1,11 @@
 package services
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -9,6 +14,8 @@ import (
 	"text/template"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/signing"
 )
 
 func TestWebhook_SuccessfullySendsNotification(t *testing.T) {
@@ -40,6 +47,49 @@ func TestWebhook_SuccessfullySendsNotification(t *testing.T) {
 	assert.Contains(t, receivedHeaders.Get("Authorization"), "Basic")
 }
 
+func TestWebhook_SignsBody(t *testing.T) {
+	publicKey, key, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+	keyData, err := x509.MarshalPKCS8PrivateKey(key)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var receivedHeaders http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		receivedHeaders = request.Header
+	}))
+	defer server.Close()
+
+	signer, err := signing.NewSigner(signing.Options{
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyData})),
+		KeyID:      "my-key",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	service, err := NewSignedService("webhook", []byte("url: "+server.URL), signer)
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = service.Send(Notification{
+		Webhook: map[string]WebhookNotification{
+			"test": {Body: "hello world", Method: http.MethodPost},
+		},
+	}, Destination{Recipient: "test", Service: "test"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "my-key", receivedHeaders.Get(signing.KeyIDHeader))
+	signature, err := base64.StdEncoding.DecodeString(receivedHeaders.Get(signing.SignatureHeader))
+	if assert.NoError(t, err) {
+		signed := receivedHeaders.Get(signing.TimestampHeader) + ".hello world"
+		assert.True(t, ed25519.Verify(publicKey, []byte(signed), signature))
+	}
+}
+
 func TestWebhook_SubPath(t *testing.T) {
 	var receivedPath string
 	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
@@ -88,7 +138,7 @@ func TestGetTemplater_Webhook(t *testing.T) {
 	err = templater(&notification, map[string]interface{}{
 		"foo": "hello",
 		"bar": "world",
-	})
+	}, nil)
 
 	if !assert.NoError(t, err) {
 		return
@@ -10,6 +10,15 @@ import (
 
 const (
 	AnnotationPrefix = "notifications.argoproj.io"
+	// NotifiedAnnotationKey is the annotation that stores notifications state of the application
+	NotifiedAnnotationKey = "notified." + AnnotationPrefix
+	// AcknowledgedAnnotationKey is the annotation that stores acknowledged triggers of the application
+	AcknowledgedAnnotationKey = "acknowledged." + AnnotationPrefix
+	// MutedAnnotationKey is the annotation that stores the temporary suppression window of the application or project notifications
+	MutedAnnotationKey = "muted." + AnnotationPrefix
+	// PriorityAnnotationKey is the annotation with the integer processing priority of the application. The value is
+	// capped by the priorities configured in the ConfigMap
+	PriorityAnnotationKey = "priority." + AnnotationPrefix
 )
 
 func parseRecipients(v string) []string {
@@ -27,10 +36,18 @@ func SubscribeAnnotationKey(trigger string, service string) string {
 	return fmt.Sprintf("%s/subscribe.%s.%s", AnnotationPrefix, trigger, service)
 }
 
+// UnsubscribeAnnotationKey returns the key of annotation that opts out from the notifications of the trigger sent using the service
+func UnsubscribeAnnotationKey(trigger string, service string) string {
+	return fmt.Sprintf("%s/unsubscribe.%s.%s", AnnotationPrefix, trigger, service)
+}
+
 type Annotations map[string]string
 
 func (a Annotations) iterate(callback func(trigger string, service string, recipients []string, key string)) {
-	prefix := AnnotationPrefix + "/subscribe."
+	a.iterateWithPrefix(AnnotationPrefix+"/subscribe.", callback)
+}
+
+func (a Annotations) iterateWithPrefix(prefix string, callback func(trigger string, service string, recipients []string, key string)) {
 	for k, v := range a {
 		if !strings.HasPrefix(k, prefix) {
 			continue
@@ -55,7 +72,21 @@ func (a Annotations) iterate(callback func(trigger string, service string, recip
 }
 
 func (a Annotations) Subscribe(trigger string, service string, recipients ...string) {
-	annotationKey := SubscribeAnnotationKey(trigger, service)
+	a.addRecipients(SubscribeAnnotationKey(trigger, service), recipients)
+}
+
+// OptOut adds the recipient to the unsubscribe annotation, so the recipient stops receiving notifications of the trigger
+// even if it is subscribed using the project, namespace or default subscriptions
+func (a Annotations) OptOut(trigger string, service string, recipient string) {
+	annotationKey := UnsubscribeAnnotationKey(trigger, service)
+	// the annotation with empty value already opts out all recipients
+	if v, ok := a[annotationKey]; ok && strings.TrimSpace(v) == "" {
+		return
+	}
+	a.addRecipients(annotationKey, []string{recipient})
+}
+
+func (a Annotations) addRecipients(annotationKey string, recipients []string) {
 	r := parseRecipients(a[annotationKey])
 	set := map[string]bool{}
 	for _, recipient := range r {
@@ -79,7 +110,7 @@ func (a Annotations) Unsubscribe(trigger string, service string, recipient strin
 			if r[i] == recipient {
 				updatedRecipients := append(r[:i], r[i+1:]...)
 				if len(updatedRecipients) > 0 {
-					a[k] = strings.Join(updatedRecipients, "")
+					a[k] = strings.Join(updatedRecipients, ";")
 				} else {
 					delete(a, k)
 				}
@@ -123,3 +154,43 @@ func (a Annotations) GetAll(defaultTriggers ...string) pkg.Subscriptions {
 	})
 	return subscriptions
 }
+
+// RemoveUnsubscribed removes destinations that are opted out using unsubscribe annotations. The annotation without trigger
+// opts out from all triggers and the annotation with empty value opts out from all recipients of the service.
+func (a Annotations) RemoveUnsubscribed(subscriptions pkg.Subscriptions) pkg.Subscriptions {
+	type exclusion struct {
+		trigger   string
+		service   string
+		recipient string
+		any       bool
+	}
+	var exclusions []exclusion
+	a.iterateWithPrefix(AnnotationPrefix+"/unsubscribe.", func(trigger string, service string, recipients []string, k string) {
+		if strings.TrimSpace(a[k]) == "" {
+			exclusions = append(exclusions, exclusion{trigger: trigger, service: service, any: true})
+			return
+		}
+		for _, recipient := range recipients {
+			exclusions = append(exclusions, exclusion{trigger: trigger, service: service, recipient: recipient})
+		}
+	})
+	if len(exclusions) == 0 {
+		return subscriptions
+	}
+	res := pkg.Subscriptions{}
+	for trigger, destinations := range subscriptions {
+		for _, dest := range destinations {
+			excluded := false
+			for _, e := range exclusions {
+				if (e.trigger == "" || e.trigger == trigger) && e.service == dest.Service && (e.any || e.recipient == dest.Recipient) {
+					excluded = true
+					break
+				}
+			}
+			if !excluded {
+				res[trigger] = append(res[trigger], dest)
+			}
+		}
+	}
+	return res
+}
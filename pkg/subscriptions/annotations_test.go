@@ -96,4 +96,65 @@ func TestUnsubscribe(t *testing.T) {
 	a.Unsubscribe("my-trigger", "slack", "my-channel2")
 	_, ok := a["notifications.argoproj.io/subscribe.my-trigger.slack"]
 	assert.False(t, ok)
+
+	a = Annotations(map[string]string{
+		"notifications.argoproj.io/subscribe.my-trigger.slack": "my-channel1;my-channel2;my-channel3",
+	})
+	a.Unsubscribe("my-trigger", "slack", "my-channel1")
+	assert.Equal(t, "my-channel2;my-channel3", a["notifications.argoproj.io/subscribe.my-trigger.slack"])
+}
+
+func TestOptOut(t *testing.T) {
+	a := Annotations(map[string]string{
+		"notifications.argoproj.io/unsubscribe.my-trigger.email": "alice@example.com",
+		"notifications.argoproj.io/unsubscribe.my-trigger.slack": "",
+	})
+	a.OptOut("my-trigger", "email", "bob@example.com")
+	a.OptOut("my-trigger", "email", "bob@example.com")
+	a.OptOut("my-trigger", "slack", "my-channel")
+
+	assert.Equal(t, "alice@example.com;bob@example.com", a["notifications.argoproj.io/unsubscribe.my-trigger.email"])
+	assert.Equal(t, "", a["notifications.argoproj.io/unsubscribe.my-trigger.slack"])
+}
+
+func TestRemoveUnsubscribed(t *testing.T) {
+	subscriptions := pkg.Subscriptions{
+		"on-sync-status-unknown": []services.Destination{{Service: "slack", Recipient: "channel"}, {Service: "slack", Recipient: "other"}},
+		"on-sync-failed":         []services.Destination{{Service: "slack", Recipient: "channel"}, {Service: "email", Recipient: "team@example.com"}},
+	}
+
+	tests := map[string]struct {
+		annotations map[string]string
+		expected    pkg.Subscriptions
+	}{
+		"Recipient": {
+			annotations: map[string]string{UnsubscribeAnnotationKey("on-sync-status-unknown", "slack"): "channel"},
+			expected: pkg.Subscriptions{
+				"on-sync-status-unknown": []services.Destination{{Service: "slack", Recipient: "other"}},
+				"on-sync-failed":         []services.Destination{{Service: "slack", Recipient: "channel"}, {Service: "email", Recipient: "team@example.com"}},
+			},
+		},
+		"AllRecipients": {
+			annotations: map[string]string{UnsubscribeAnnotationKey("on-sync-status-unknown", "slack"): ""},
+			expected: pkg.Subscriptions{
+				"on-sync-failed": []services.Destination{{Service: "slack", Recipient: "channel"}, {Service: "email", Recipient: "team@example.com"}},
+			},
+		},
+		"AllTriggers": {
+			annotations: map[string]string{"notifications.argoproj.io/unsubscribe.slack": "channel"},
+			expected: pkg.Subscriptions{
+				"on-sync-status-unknown": []services.Destination{{Service: "slack", Recipient: "other"}},
+				"on-sync-failed":         []services.Destination{{Service: "email", Recipient: "team@example.com"}},
+			},
+		},
+		"NoAnnotations": {
+			annotations: map[string]string{},
+			expected:    subscriptions,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Annotations(test.annotations).RemoveUnsubscribed(subscriptions))
+		})
+	}
 }
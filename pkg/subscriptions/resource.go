@@ -0,0 +1,96 @@
+package subscriptions
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+)
+
+const ResourceKind = "NotificationSubscription"
+
+// ResourceSpec is the spec of the NotificationSubscription resource
+type ResourceSpec struct {
+	// Selector is the label selector of subscribed applications
+	Selector string `json:"selector,omitempty"`
+	// Project limits subscribed applications to the specified project
+	Project string `json:"project,omitempty"`
+	// Applications limits subscribed applications to the specified names
+	Applications []string `json:"applications,omitempty"`
+	// Triggers holds list of subscribed triggers. Default triggers are used if empty
+	Triggers []string `json:"triggers,omitempty"`
+	// Destinations holds list of services and recipients that receive notifications
+	Destinations []services.Destination `json:"destinations"`
+}
+
+// Resource is the parsed NotificationSubscription resource
+type Resource struct {
+	Namespace string
+	Name      string
+	Spec      ResourceSpec
+
+	selector labels.Selector
+}
+
+// ParseResource parses NotificationSubscription resource
+func ParseResource(obj *unstructured.Unstructured) (*Resource, error) {
+	res := Resource{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	if spec, ok := obj.Object["spec"].(map[string]interface{}); ok {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(spec, &res.Spec); err != nil {
+			return nil, fmt.Errorf("invalid spec of subscription %s/%s: %v", res.Namespace, res.Name, err)
+		}
+	}
+	selector, err := labels.Parse(res.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector of subscription %s/%s: %v", res.Namespace, res.Name, err)
+	}
+	res.selector = selector
+	return &res, nil
+}
+
+// Matches returns true if the application is subscribed. Subscriptions created outside of the controller namespace
+// match only applications that are deployed to the namespace of the subscription.
+func (r *Resource) Matches(app *unstructured.Unstructured, controllerNamespace string) bool {
+	if r.Namespace != controllerNamespace {
+		destNamespace, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "namespace")
+		if destNamespace != r.Namespace {
+			return false
+		}
+	}
+	if r.Spec.Project != "" {
+		project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+		if project != r.Spec.Project {
+			return false
+		}
+	}
+	if len(r.Spec.Applications) > 0 {
+		found := false
+		for _, name := range r.Spec.Applications {
+			if name == app.GetName() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return r.selector.Matches(labels.Set(app.GetLabels()))
+}
+
+// GetAll returns subscriptions of the resource
+func (r *Resource) GetAll(defaultTriggers ...string) pkg.Subscriptions {
+	subscriptions := pkg.Subscriptions{}
+	triggers := r.Spec.Triggers
+	if len(triggers) == 0 {
+		triggers = defaultTriggers
+	}
+	for _, trigger := range triggers {
+		subscriptions[trigger] = append(subscriptions[trigger], r.Spec.Destinations...)
+	}
+	return subscriptions
+}
@@ -0,0 +1,79 @@
+package subscriptions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+)
+
+func newApp(name string, project string, destNamespace string, labels map[string]string) *unstructured.Unstructured {
+	app := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"project":     project,
+			"destination": map[string]interface{}{"namespace": destNamespace},
+		},
+	}}
+	app.SetName(name)
+	app.SetLabels(labels)
+	return app
+}
+
+func newResource(namespace string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetKind(ResourceKind)
+	obj.SetName("my-subscription")
+	obj.SetNamespace(namespace)
+	return obj
+}
+
+func TestResource_Matches(t *testing.T) {
+	resource, err := ParseResource(newResource("team-a", map[string]interface{}{
+		"selector": "tier=frontend",
+		"project":  "team-a",
+	}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, resource.Matches(newApp("guestbook", "team-a", "team-a", map[string]string{"tier": "frontend"}), "argocd"))
+	assert.False(t, resource.Matches(newApp("guestbook", "team-a", "team-a", map[string]string{"tier": "backend"}), "argocd"))
+	assert.False(t, resource.Matches(newApp("guestbook", "team-b", "team-a", map[string]string{"tier": "frontend"}), "argocd"))
+	// subscription from another namespace cannot subscribe to applications that are deployed elsewhere
+	assert.False(t, resource.Matches(newApp("guestbook", "team-a", "team-b", map[string]string{"tier": "frontend"}), "argocd"))
+	assert.True(t, resource.Matches(newApp("guestbook", "team-a", "team-b", map[string]string{"tier": "frontend"}), "team-a"))
+}
+
+func TestResource_MatchesApplications(t *testing.T) {
+	resource, err := ParseResource(newResource("argocd", map[string]interface{}{
+		"applications": []interface{}{"guestbook"},
+	}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, resource.Matches(newApp("guestbook", "default", "default", nil), "argocd"))
+	assert.False(t, resource.Matches(newApp("other", "default", "default", nil), "argocd"))
+}
+
+func TestResource_GetAll(t *testing.T) {
+	resource, err := ParseResource(newResource("team-a", map[string]interface{}{
+		"destinations": []interface{}{map[string]interface{}{"service": "slack", "recipient": "team-a"}},
+	}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, pkg.Subscriptions{
+		"on-sync-failed":     []services.Destination{{Service: "slack", Recipient: "team-a"}},
+		"on-health-degraded": []services.Destination{{Service: "slack", Recipient: "team-a"}},
+	}, resource.GetAll("on-sync-failed", "on-health-degraded"))
+}
+
+func TestParseResource_InvalidSelector(t *testing.T) {
+	_, err := ParseResource(newResource("team-a", map[string]interface{}{"selector": "a in ("}))
+	assert.Error(t, err)
+}
@@ -1,7 +1,11 @@
 package templates
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	texttemplate "text/template"
+	"time"
 
 	"github.com/Masterminds/sprig"
 
@@ -12,16 +16,81 @@ type Service interface {
 	FormatNotification(vars map[string]interface{}, templates ...string) (*services.Notification, error)
 }
 
+// Limits holds limits of the notification rendering
+type Limits struct {
+	// Timeout is the max time of rendering one template
+	Timeout string `json:"timeout,omitempty"`
+	// MaxOutputSize is the max size of the rendered template in bytes
+	MaxOutputSize int `json:"maxOutputSize,omitempty"`
+	// MaxFunctionCalls is the max number of function calls made by one template
+	MaxFunctionCalls int `json:"maxFunctionCalls,omitempty"`
+}
+
+// DefaultLimits are used if limits are not configured
+var DefaultLimits = Limits{Timeout: "10s", MaxOutputSize: 1024 * 1024, MaxFunctionCalls: 100000}
+
+// Merge returns limits with fields overridden by non empty fields of the specified limits
+func (l Limits) Merge(other Limits) Limits {
+	if other.Timeout != "" {
+		l.Timeout = other.Timeout
+	}
+	if other.MaxOutputSize != 0 {
+		l.MaxOutputSize = other.MaxOutputSize
+	}
+	if other.MaxFunctionCalls != 0 {
+		l.MaxFunctionCalls = other.MaxFunctionCalls
+	}
+	return l
+}
+
+// Validate returns an error if the timeout cannot be parsed or the output size or the number of calls is negative
+func (l Limits) Validate() error {
+	if l.Timeout != "" {
+		if _, err := time.ParseDuration(l.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout '%s': %v", l.Timeout, err)
+		}
+	}
+	if l.MaxOutputSize < 0 {
+		return errors.New("maxOutputSize must not be negative")
+	}
+	if l.MaxFunctionCalls < 0 {
+		return errors.New("maxFunctionCalls must not be negative")
+	}
+	return nil
+}
+
 type service struct {
-	templaters map[string]services.Templater
+	templaters       map[string]services.Templater
+	funcs            texttemplate.FuncMap
+	timeout          time.Duration
+	maxOutputSize    int
+	maxFunctionCalls int
 }
 
 func NewService(templates map[string]services.Notification) (*service, error) {
+	return NewServiceWithLimits(templates, DefaultLimits)
+}
+
+// NewServiceWithLimits returns the service that aborts rendering of templates exceeding the specified limits.
+// Missing limits are taken from DefaultLimits.
+func NewServiceWithLimits(templates map[string]services.Notification, limits Limits) (*service, error) {
+	limits = DefaultLimits.Merge(limits)
+	if err := limits.Validate(); err != nil {
+		return nil, err
+	}
+	timeout, _ := time.ParseDuration(limits.Timeout)
+
 	f := sprig.TxtFuncMap()
 	delete(f, "env")
 	delete(f, "expandenv")
 
-	svc := &service{templaters: map[string]services.Templater{}}
+	svc := &service{
+		templaters:       map[string]services.Templater{},
+		funcs:            f,
+		timeout:          timeout,
+		maxOutputSize:    limits.MaxOutputSize,
+		maxFunctionCalls: limits.MaxFunctionCalls,
+	}
 	for name, cfg := range templates {
 		templater, err := cfg.GetTemplater(name, f)
 		if err != nil {
@@ -40,9 +109,50 @@ func (s *service) FormatNotification(vars map[string]interface{}, templates ...s
 			return nil, fmt.Errorf("template '%s' is not supported", templateName)
 		}
 
-		if err := templater(&notification, vars); err != nil {
+		if err := s.render(templateName, templater, &notification, vars); err != nil {
 			return nil, err
 		}
 	}
 	return &notification, nil
 }
+
+// render executes the templater with the budget that aborts the execution once the template exceeds limits, so the
+// rendering does not outlive the caller. The templater works with the deep copy of the notification, so the
+// notification is not modified if the rendering fails or panics.
+func (s *service) render(name string, templater services.Templater, notification *services.Notification, vars map[string]interface{}) (err error) {
+	res, err := copyNotification(notification)
+	if err != nil {
+		return &services.RenderError{Template: name, Reason: services.RenderErrorFailed, Err: err}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = &services.RenderError{Template: name, Reason: services.RenderErrorPanic, Err: fmt.Errorf("recovered from panic: %v", r)}
+		}
+	}()
+
+	budget := services.NewRenderBudget(s.funcs, s.timeout, s.maxFunctionCalls, s.maxOutputSize)
+	if err := templater(res, budget.Vars(vars), budget); err != nil {
+		if reason, budgetErr := budget.Err(); budgetErr != nil {
+			return &services.RenderError{Template: name, Reason: reason, Err: budgetErr}
+		}
+		var renderErr *services.RenderError
+		if errors.As(err, &renderErr) {
+			return err
+		}
+		return &services.RenderError{Template: name, Reason: services.RenderErrorFailed, Err: err}
+	}
+	*notification = *res
+	return nil
+}
+
+func copyNotification(notification *services.Notification) (*services.Notification, error) {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return nil, err
+	}
+	var res services.Notification
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -1,7 +1,9 @@
 package templates
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -29,3 +31,110 @@ func TestFormat_Message(t *testing.T) {
 
 	assert.Equal(t, "hello", notification.Message)
 }
+
+func TestFormat_RenderErrors(t *testing.T) {
+	svc, err := NewServiceWithLimits(map[string]services.Notification{
+		"large":   {Message: `{{range until 100}}{{$.foo}}{{end}}`},
+		"huge":    {Message: `{{range until 2000000}}{{$.foo}}{{end}}`},
+		"invalid": {Message: "{{.foo.bar}}"},
+	}, Limits{MaxOutputSize: 1000})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for template, reason := range map[string]string{
+		"large":   services.RenderErrorOutputSize,
+		"huge":    services.RenderErrorOutputSize,
+		"invalid": services.RenderErrorFailed,
+	} {
+		_, err := svc.FormatNotification(map[string]interface{}{"foo": "hello world"}, template)
+
+		var renderErr *services.RenderError
+		if assert.True(t, errors.As(err, &renderErr), template) {
+			assert.Equal(t, reason, renderErr.Reason, template)
+			assert.Equal(t, template, renderErr.Template)
+		}
+	}
+}
+
+func TestFormat_RenderPanic(t *testing.T) {
+	svc := &service{templaters: map[string]services.Templater{
+		"panic": func(notification *services.Notification, vars map[string]interface{}, budget *services.RenderBudget) error {
+			panic("boom")
+		},
+	}}
+
+	_, err := svc.FormatNotification(map[string]interface{}{}, "panic")
+
+	var renderErr *services.RenderError
+	if assert.True(t, errors.As(err, &renderErr)) {
+		assert.Equal(t, services.RenderErrorPanic, renderErr.Reason)
+	}
+}
+
+func TestFormat_RenderTimeout(t *testing.T) {
+	svc, err := NewServiceWithLimits(map[string]services.Notification{
+		"slow": {Message: "{{call .wait}}{{call .wait}}"},
+	}, Limits{Timeout: "10ms"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	calls := 0
+
+	_, err = svc.FormatNotification(map[string]interface{}{"wait": func() string {
+		calls++
+		time.Sleep(20 * time.Millisecond)
+		return ""
+	}}, "slow")
+
+	var renderErr *services.RenderError
+	if assert.True(t, errors.As(err, &renderErr)) {
+		assert.Equal(t, services.RenderErrorTimeout, renderErr.Reason)
+	}
+	assert.Equal(t, services.ErrorClassRender, services.ErrorClass(err))
+	assert.Equal(t, 1, calls)
+}
+
+func TestFormat_RenderTooManyCalls(t *testing.T) {
+	svc, err := NewServiceWithLimits(map[string]services.Notification{
+		"loop": {Message: `{{range until 1000}}{{upper "a"}}{{end}}`},
+	}, Limits{MaxFunctionCalls: 100})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = svc.FormatNotification(map[string]interface{}{}, "loop")
+
+	var renderErr *services.RenderError
+	if assert.True(t, errors.As(err, &renderErr)) {
+		assert.Equal(t, services.RenderErrorCalls, renderErr.Reason)
+	}
+}
+
+func TestFormat_DoesNotModifyNotificationOnError(t *testing.T) {
+	svc, err := NewService(map[string]services.Notification{
+		"slack":   {Slack: &services.SlackNotification{Attachments: "{{.foo}}"}},
+		"invalid": {Slack: &services.SlackNotification{Attachments: "changed", Blocks: "{{.foo.bar}}"}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	notification := services.Notification{Slack: &services.SlackNotification{Attachments: "original"}}
+
+	err = svc.render("invalid", svc.templaters["invalid"], &notification, map[string]interface{}{"foo": "hello"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "original", notification.Slack.Attachments)
+
+	err = svc.render("slack", svc.templaters["slack"], &notification, map[string]interface{}{"foo": "hello"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", notification.Slack.Attachments)
+}
+
+func TestLimits_Validate(t *testing.T) {
+	assert.NoError(t, DefaultLimits.Validate())
+	assert.Error(t, Limits{Timeout: "abc"}.Validate())
+	assert.Error(t, Limits{MaxOutputSize: -1}.Validate())
+	assert.Error(t, Limits{MaxFunctionCalls: -1}.Validate())
+}
@@ -0,0 +1,49 @@
+package triggers
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Acknowledgment holds the information about the user who has acknowledged the firing trigger
+type Acknowledgment struct {
+	User      string `json:"user,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Acknowledgments holds acknowledged triggers. Notifications of the acknowledged trigger are not sent until the trigger stops firing
+type Acknowledgments map[string]Acknowledgment
+
+// Acknowledge marks the trigger as acknowledged by the specified user
+func (a Acknowledgments) Acknowledge(trigger string, user string) {
+	a[trigger] = Acknowledgment{User: user, Timestamp: time.Now().Unix()}
+}
+
+// IsAcknowledged returns true if the trigger is acknowledged
+func (a Acknowledgments) IsAcknowledged(trigger string) bool {
+	_, ok := a[trigger]
+	return ok
+}
+
+// String returns the JSON representation of acknowledgments or an empty string if there are no acknowledgments
+func (a Acknowledgments) String() string {
+	if len(a) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func NewAcknowledgments(val string) Acknowledgments {
+	res := Acknowledgments{}
+	if val == "" {
+		return res
+	}
+	if err := json.Unmarshal([]byte(val), &res); err != nil {
+		return Acknowledgments{}
+	}
+	return res
+}
@@ -0,0 +1,24 @@
+package triggers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcknowledgments(t *testing.T) {
+	acks := NewAcknowledgments("")
+	assert.False(t, acks.IsAcknowledged("on-sync-failed"))
+	assert.Equal(t, "", acks.String())
+
+	acks.Acknowledge("on-sync-failed", "alice")
+
+	assert.True(t, acks.IsAcknowledged("on-sync-failed"))
+	parsed := NewAcknowledgments(acks.String())
+	assert.Equal(t, "alice", parsed["on-sync-failed"].User)
+	assert.NotZero(t, parsed["on-sync-failed"].Timestamp)
+}
+
+func TestNewAcknowledgments_Invalid(t *testing.T) {
+	assert.Equal(t, Acknowledgments{}, NewAcknowledgments("not a json"))
+}
@@ -0,0 +1,125 @@
+package triggers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/parser"
+)
+
+// ReferencedFields returns paths of the application fields referenced by conditions and oncePer settings of the
+// triggers, e.g. [status sync status] for the `app.status.sync.status == 'OutOfSync'` condition. The second value
+// is false if any condition references the whole application, so the change of any field might change the result.
+// The helperFields map holds fields read by helpers that receive the application implicitly, e.g. fields read by the
+// `repo` functions, and are referenced by any condition that uses the helper.
+func ReferencedFields(triggers map[string][]Condition, helperFields map[string][][]string) ([][]string, bool, error) {
+	paths := map[string][]string{}
+	for _, t := range triggers {
+		for _, condition := range t {
+			tree, err := parser.Parse(condition.When)
+			if err != nil {
+				return nil, false, err
+			}
+			v := &fieldsVisitor{helperFields: helperFields}
+			ast.Walk(&tree.Node, v)
+			if v.wholeApp {
+				return nil, false, nil
+			}
+			for _, path := range v.paths {
+				paths[strings.Join(path, ".")] = path
+			}
+			if condition.OncePer != "" {
+				parts := strings.Split(condition.OncePer, ".")
+				if parts[0] != "app" {
+					continue
+				}
+				if len(parts) == 1 {
+					return nil, false, nil
+				}
+				paths[strings.Join(parts[1:], ".")] = parts[1:]
+			}
+		}
+	}
+	var keys []string
+	for key := range paths {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	res := make([][]string, len(keys))
+	for i := range keys {
+		res[i] = paths[keys[i]]
+	}
+	return res, true, nil
+}
+
+// fieldsVisitor collects the longest application field paths of the expression: `app.status.sync.status` is
+// collected as one path rather than also as `app.status` and `app.status.sync`
+type fieldsVisitor struct {
+	helperFields map[string][][]string
+	parents      []ast.Node
+	paths        [][]string
+	wholeApp     bool
+}
+
+func (v *fieldsVisitor) Enter(node *ast.Node) {
+	if identifier, ok := (*node).(*ast.IdentifierNode); ok {
+		v.paths = append(v.paths, v.helperFields[identifier.Value]...)
+	}
+	if len(v.parents) == 0 || !isAppFieldOf(v.parents[len(v.parents)-1], *node) {
+		if path, ok := appFieldPath(*node); ok {
+			if len(path) == 0 {
+				v.wholeApp = true
+			} else {
+				v.paths = append(v.paths, path)
+			}
+		}
+	}
+	v.parents = append(v.parents, *node)
+}
+
+func (v *fieldsVisitor) Exit(_ *ast.Node) {
+	v.parents = v.parents[:len(v.parents)-1]
+}
+
+// isAppFieldOf returns true if the parent node is the application field that is accessed through the child node
+func isAppFieldOf(parent ast.Node, child ast.Node) bool {
+	var accessed ast.Node
+	switch n := parent.(type) {
+	case *ast.PropertyNode:
+		accessed = n.Node
+	case *ast.IndexNode:
+		accessed = n.Node
+	default:
+		return false
+	}
+	if accessed != child {
+		return false
+	}
+	_, ok := appFieldPath(parent)
+	return ok
+}
+
+// appFieldPath returns the path of the application field accessed by the node. Only property access and indexes with
+// constant string keys are supported, e.g. indexes of the list items stop the path at the list field
+func appFieldPath(node ast.Node) ([]string, bool) {
+	switch n := node.(type) {
+	case *ast.IdentifierNode:
+		return []string{}, n.Value == "app"
+	case *ast.PropertyNode:
+		return appendAppFieldPath(n.Node, n.Property)
+	case *ast.IndexNode:
+		if key, ok := n.Index.(*ast.StringNode); ok {
+			return appendAppFieldPath(n.Node, key.Value)
+		}
+	}
+	return nil, false
+}
+
+func appendAppFieldPath(node ast.Node, field string) ([]string, bool) {
+	path, ok := appFieldPath(node)
+	if !ok {
+		return nil, false
+	}
+	return append(append([]string{}, path...), field), true
+}
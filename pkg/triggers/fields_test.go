@@ -0,0 +1,58 @@
+package triggers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferencedFields(t *testing.T) {
+	fields, ok, err := ReferencedFields(map[string][]Condition{
+		"on-sync-status-unknown": {{
+			When: "app.status.sync.status == 'Unknown'",
+		}},
+		"on-sync-succeeded": {{
+			When:    "app.status.operationState.phase in ['Succeeded'] and app.metadata.labels['team'] == 'payments'",
+			OncePer: "app.status.sync.revision",
+		}},
+		"on-degraded": {{
+			When: "app.status.history[0].revision != '' and time.Now().Sub(time.Parse(app.status.operationState.finishedAt)).Minutes() > 5",
+		}},
+		"on-new-commit": {{
+			When: "repo.GetCommitMetadata(app.status.sync.revision).Author != 'bot'",
+		}},
+	}, map[string][][]string{"repo": {{"spec", "source"}}})
+	if !assert.NoError(t, err) || !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, [][]string{
+		{"metadata", "labels", "team"},
+		{"spec", "source"},
+		{"status", "history"},
+		{"status", "operationState", "finishedAt"},
+		{"status", "operationState", "phase"},
+		{"status", "sync", "revision"},
+		{"status", "sync", "status"},
+	}, fields)
+}
+
+func TestReferencedFields_WholeApp(t *testing.T) {
+	_, ok, err := ReferencedFields(map[string][]Condition{
+		"on-created": {{When: "app != nil"}},
+	}, nil)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = ReferencedFields(map[string][]Condition{
+		"on-sync": {{When: "true", OncePer: "app"}},
+	}, nil)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestReferencedFields_InvalidCondition(t *testing.T) {
+	_, _, err := ReferencedFields(map[string][]Condition{
+		"on-sync": {{When: "app.status.sync.status =="}},
+	}, nil)
+	assert.Error(t, err)
+}
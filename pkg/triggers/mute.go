@@ -0,0 +1,46 @@
+package triggers
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Mute holds the temporary suppression window of the application or project notifications
+type Mute struct {
+	User  string `json:"user,omitempty"`
+	Until int64  `json:"until"`
+}
+
+// IsActive returns true if notifications are still muted at the specified time
+func (m *Mute) IsActive(now time.Time) bool {
+	return m != nil && now.Unix() < m.Until
+}
+
+// String returns the JSON representation of the mute or an empty string if there is no mute
+func (m *Mute) String() string {
+	if m == nil {
+		return ""
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// NewMute returns the mute that suppresses notifications for the specified duration
+func NewMute(user string, duration time.Duration) *Mute {
+	return &Mute{User: user, Until: time.Now().Add(duration).Unix()}
+}
+
+// ParseMute returns nil if the value is empty or invalid
+func ParseMute(val string) *Mute {
+	if val == "" {
+		return nil
+	}
+	var res Mute
+	if err := json.Unmarshal([]byte(val), &res); err != nil {
+		return nil
+	}
+	return &res
+}
@@ -0,0 +1,23 @@
+package triggers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMute(t *testing.T) {
+	mute := NewMute("alice", time.Hour)
+
+	parsed := ParseMute(mute.String())
+	assert.Equal(t, mute, parsed)
+	assert.True(t, parsed.IsActive(time.Now()))
+	assert.False(t, parsed.IsActive(time.Now().Add(2*time.Hour)))
+}
+
+func TestParseMute_Invalid(t *testing.T) {
+	assert.Nil(t, ParseMute(""))
+	assert.Nil(t, ParseMute("not a json"))
+	assert.False(t, ParseMute("").IsActive(time.Now()))
+}
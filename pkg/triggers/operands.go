@@ -0,0 +1,126 @@
+package triggers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/parser"
+)
+
+// Operands returns the top level operands of the `or` operator of the condition or, if there are none, of the `and`
+// operator, so the result of every operand might be evaluated separately. Operands are formatted as expressions, e.g.
+// [app.status.health.status == "Healthy" app.status.sync.status == "Synced"] for the
+// `app.status.health.status == 'Healthy' and app.status.sync.status == 'Synced'` condition
+func Operands(when string) ([]string, error) {
+	tree, err := parser.Parse(when)
+	if err != nil {
+		return nil, err
+	}
+	for _, operators := range [][]string{{"or", "||"}, {"and", "&&"}} {
+		if nodes := splitBinary(tree.Node, operators); len(nodes) > 1 {
+			res := make([]string, len(nodes))
+			for i := range nodes {
+				res[i] = formatNode(nodes[i])
+			}
+			return res, nil
+		}
+	}
+	return []string{formatNode(tree.Node)}, nil
+}
+
+// splitBinary returns operands of the chain of binary operators, e.g. [a b c] for `a or b or c`
+func splitBinary(node ast.Node, operators []string) []ast.Node {
+	binary, ok := node.(*ast.BinaryNode)
+	if !ok || !containsString(operators, binary.Operator) {
+		return []ast.Node{node}
+	}
+	return append(splitBinary(binary.Left, operators), splitBinary(binary.Right, operators)...)
+}
+
+func containsString(items []string, item string) bool {
+	for i := range items {
+		if items[i] == item {
+			return true
+		}
+	}
+	return false
+}
+
+// formatNode formats the expression node. Nested binary and conditional expressions are wrapped in parentheses, so the
+// result evaluates to the same value regardless of the operators precedence
+func formatNode(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.NilNode:
+		return "nil"
+	case *ast.IdentifierNode:
+		return n.Value
+	case *ast.IntegerNode:
+		return strconv.Itoa(n.Value)
+	case *ast.FloatNode:
+		return strconv.FormatFloat(n.Value, 'g', -1, 64)
+	case *ast.BoolNode:
+		return strconv.FormatBool(n.Value)
+	case *ast.StringNode:
+		return strconv.Quote(n.Value)
+	case *ast.UnaryNode:
+		if n.Operator == "not" {
+			return "not " + formatOperand(n.Node)
+		}
+		return n.Operator + formatOperand(n.Node)
+	case *ast.BinaryNode:
+		return formatOperand(n.Left) + " " + n.Operator + " " + formatOperand(n.Right)
+	case *ast.MatchesNode:
+		return formatOperand(n.Left) + " matches " + formatOperand(n.Right)
+	case *ast.PropertyNode:
+		return formatOperand(n.Node) + "." + n.Property
+	case *ast.IndexNode:
+		return formatOperand(n.Node) + "[" + formatNode(n.Index) + "]"
+	case *ast.SliceNode:
+		var from, to string
+		if n.From != nil {
+			from = formatNode(n.From)
+		}
+		if n.To != nil {
+			to = formatNode(n.To)
+		}
+		return formatOperand(n.Node) + "[" + from + ":" + to + "]"
+	case *ast.MethodNode:
+		return formatOperand(n.Node) + "." + n.Method + "(" + formatNodes(n.Arguments) + ")"
+	case *ast.FunctionNode:
+		return n.Name + "(" + formatNodes(n.Arguments) + ")"
+	case *ast.BuiltinNode:
+		return n.Name + "(" + formatNodes(n.Arguments) + ")"
+	case *ast.ClosureNode:
+		return "{" + formatNode(n.Node) + "}"
+	case *ast.PointerNode:
+		return "#"
+	case *ast.ConditionalNode:
+		return formatOperand(n.Cond) + " ? " + formatOperand(n.Exp1) + " : " + formatOperand(n.Exp2)
+	case *ast.ArrayNode:
+		return "[" + formatNodes(n.Nodes) + "]"
+	case *ast.MapNode:
+		return "{" + formatNodes(n.Pairs) + "}"
+	case *ast.PairNode:
+		return formatNode(n.Key) + ": " + formatNode(n.Value)
+	}
+	return fmt.Sprintf("%v", node)
+}
+
+// formatOperand formats the operand of the operator or the accessed node and wraps it in parentheses if necessary
+func formatOperand(node ast.Node) string {
+	switch node.(type) {
+	case *ast.BinaryNode, *ast.MatchesNode, *ast.ConditionalNode, *ast.UnaryNode:
+		return "(" + formatNode(node) + ")"
+	}
+	return formatNode(node)
+}
+
+func formatNodes(nodes []ast.Node) string {
+	res := make([]string, len(nodes))
+	for i := range nodes {
+		res[i] = formatNode(nodes[i])
+	}
+	return strings.Join(res, ", ")
+}
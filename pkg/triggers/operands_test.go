@@ -0,0 +1,52 @@
+package triggers
+
+import (
+	"testing"
+
+	"github.com/antonmedv/expr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperands(t *testing.T) {
+	testCases := map[string][]string{
+		"a == 'x || y' || b and c":       {`a == "x || y"`, "b and c"},
+		"a && (b or c) and d in ['and']": {"a", "(b or c)", `d in ["and"]`},
+		" a == 1 ":                       {"a == 1"},
+		"app.status.sync.status == 'Synced' and time.Now().Sub(time.Parse(app.status.operationState.finishedAt)).Minutes() < 5": {
+			`app.status.sync.status == "Synced"`,
+			"time.Now().Sub(time.Parse(app.status.operationState.finishedAt)).Minutes() < 5",
+		},
+		"app.metadata.annotations['team'] == 'payments' or not (app.spec.project in ['default'])": {
+			`app.metadata.annotations["team"] == "payments"`,
+			`not (app.spec.project in ["default"])`,
+		},
+	}
+	for when, expected := range testCases {
+		t.Run(when, func(t *testing.T) {
+			operands, err := Operands(when)
+			if assert.NoError(t, err) {
+				assert.Equal(t, expected, operands)
+			}
+		})
+	}
+}
+
+func TestOperands_EvaluatesToSameValue(t *testing.T) {
+	env := map[string]interface{}{"a": 1, "b": 2, "items": []int{1, 2, 3}}
+	for _, when := range []string{"a + b * 2 == 5 and a < b", "(a > 0 ? b : a) == 2 or all(items, {# > 0})"} {
+		operands, err := Operands(when)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		for _, operand := range operands {
+			val, err := expr.Eval(operand, env)
+			assert.NoError(t, err, operand)
+			assert.Equal(t, true, val, operand)
+		}
+	}
+}
+
+func TestOperands_InvalidExpression(t *testing.T) {
+	_, err := Operands("a ==")
+	assert.Error(t, err)
+}
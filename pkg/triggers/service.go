@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
@@ -18,6 +19,41 @@ type Condition struct {
 	When        string   `json:"when,omitempty"`
 	Description string   `json:"description,omitempty"`
 	Send        []string `json:"send,omitempty"`
+	// Interval is how often the condition is re-evaluated even if the application has not changed, e.g. 10m
+	Interval string `json:"interval,omitempty"`
+}
+
+// GetInterval returns the parsed evaluation interval or zero if the interval is not specified
+func (c Condition) GetInterval() (time.Duration, error) {
+	if c.Interval == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(c.Interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval '%s': %v", c.Interval, err)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("invalid interval '%s': must be positive", c.Interval)
+	}
+	return interval, nil
+}
+
+// Intervals returns evaluation intervals of triggers that have conditions with the interval. The shortest interval
+// is used if conditions of the trigger have different intervals.
+func Intervals(triggers map[string][]Condition) (map[string]time.Duration, error) {
+	res := map[string]time.Duration{}
+	for name, t := range triggers {
+		for _, condition := range t {
+			interval, err := condition.GetInterval()
+			if err != nil {
+				return nil, fmt.Errorf("trigger '%s': %v", name, err)
+			}
+			if current, ok := res[name]; interval > 0 && (!ok || interval < current) {
+				res[name] = interval
+			}
+		}
+	}
+	return res, nil
 }
 
 type ConditionResult struct {
@@ -45,6 +81,9 @@ func NewService(triggers map[string][]Condition) (*service, error) {
 			if err != nil {
 				return nil, err
 			}
+			if _, err := condition.GetInterval(); err != nil {
+				return nil, err
+			}
 			svc.compiledConditions[condition.When] = prog
 		}
 	}
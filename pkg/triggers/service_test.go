@@ -3,6 +3,7 @@ package triggers
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -100,3 +101,23 @@ func TestRun_OncePerSet(t *testing.T) {
 		}}, res)
 	})
 }
+
+func TestNewService_InvalidInterval(t *testing.T) {
+	_, err := NewService(map[string][]Condition{
+		"my-trigger": {{When: "true", Interval: "often"}},
+	})
+	assert.Error(t, err)
+}
+
+func TestIntervals(t *testing.T) {
+	intervals, err := Intervals(map[string][]Condition{
+		"on-out-of-sync": {{When: "true", Interval: "1h"}, {When: "false", Interval: "10m"}},
+		"on-sync":        {{When: "true"}},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]time.Duration{"on-out-of-sync": 10 * time.Minute}, intervals)
+	}
+
+	_, err = Intervals(map[string][]Condition{"on-sync": {{When: "true", Interval: "-1m"}}})
+	assert.Error(t, err)
+}
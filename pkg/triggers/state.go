@@ -3,7 +3,9 @@ package triggers
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/argoproj-labs/argocd-notifications/pkg/services"
@@ -17,6 +19,75 @@ func StateItemKey(trigger string, conditionResult ConditionResult, dest services
 	return key
 }
 
+// StateItem holds the information encoded in the state item key
+type StateItem struct {
+	OncePer      string
+	Trigger      string
+	ConditionKey string
+	Destination  services.Destination
+}
+
+// ParseSubscribedStateItemKey parses the key using MatchStateItemKey if it belongs to any of the subscribed trigger
+// destinations and using ParseStateItemKey otherwise
+func ParseSubscribedStateItemKey(key string, destinations map[string][]services.Destination) (*StateItem, error) {
+	for trigger, dests := range destinations {
+		if item, ok := MatchStateItemKey(key, trigger, dests); ok {
+			return item, nil
+		}
+	}
+	return ParseStateItemKey(key)
+}
+
+// conditionKeyPattern matches condition keys produced by the trigger service, e.g. [0].y7b5sbwa2Q329JYH755peeq-fBs
+var conditionKeyPattern = regexp.MustCompile(`^\[\d+\]`)
+
+// ParseStateItemKey parses the key produced by StateItemKey. Both the oncePer value and the recipient might contain
+// ':', so the key is split around the condition key, e.g. [0].abc; keys with legacy condition keys are split assuming
+// the recipient does not contain ':'. Use MatchStateItemKey if the trigger and destinations are known
+func ParseStateItemKey(key string) (*StateItem, error) {
+	parts := strings.Split(key, ":")
+	n := len(parts)
+	if n < 4 {
+		return nil, fmt.Errorf("invalid state item key '%s'", key)
+	}
+	condIndex := n - 3
+	for i := 1; i <= n-3; i++ {
+		if conditionKeyPattern.MatchString(parts[i]) {
+			condIndex = i
+			break
+		}
+	}
+	return &StateItem{
+		OncePer:      strings.Join(parts[:condIndex-1], ":"),
+		Trigger:      parts[condIndex-1],
+		ConditionKey: parts[condIndex],
+		Destination:  services.Destination{Service: parts[condIndex+1], Recipient: strings.Join(parts[condIndex+2:], ":")},
+	}, nil
+}
+
+// MatchStateItemKey parses the key produced by StateItemKey if it belongs to the trigger and one of the destinations.
+// The destination is matched as the key suffix rather than split off, so recipients that contain ':' are supported
+func MatchStateItemKey(key string, trigger string, dests []services.Destination) (*StateItem, bool) {
+	for _, dest := range dests {
+		suffix := ":" + dest.Service + ":" + dest.Recipient
+		if !strings.HasSuffix(key, suffix) {
+			continue
+		}
+		parts := strings.Split(strings.TrimSuffix(key, suffix), ":")
+		n := len(parts)
+		if n < 2 || parts[n-2] != trigger {
+			continue
+		}
+		return &StateItem{
+			OncePer:      strings.Join(parts[:n-2], ":"),
+			Trigger:      trigger,
+			ConditionKey: parts[n-1],
+			Destination:  dest,
+		}, true
+	}
+	return nil, false
+}
+
 // State track notification triggers state (already notified/not notified)
 type State map[string]int64
 
@@ -38,6 +109,121 @@ func (s State) Truncate(maxSize int) {
 	}
 }
 
+// CompactOptions holds limits of the notification state stored in the annotation
+type CompactOptions struct {
+	// MaxItems is the max number of items; oldest items are removed first
+	MaxItems int
+	// MaxItemsPerTrigger is the max number of oncePer items of one trigger, zero value means no limit
+	MaxItemsPerTrigger int
+	// TTL is how long oncePer items are kept, zero value means forever
+	TTL time.Duration
+	// MaxBytes is the max size of the JSON serialized state, zero value means no limit
+	MaxBytes int
+}
+
+// DefaultCompactOptions keep the state well below the 256KB limit of the total annotations size
+var DefaultCompactOptions = CompactOptions{MaxItems: 100, MaxBytes: 64 * 1024}
+
+type stateEntry struct {
+	key       string
+	timestamp int64
+	item      *StateItem
+}
+
+// sortedEntries returns state entries starting from the oldest
+func (s State) sortedEntries() []stateEntry {
+	var entries []stateEntry
+	for k, v := range s {
+		item, _ := ParseStateItemKey(k)
+		entries = append(entries, stateEntry{key: k, timestamp: v, item: item})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].timestamp != entries[j].timestamp {
+			return entries[i].timestamp < entries[j].timestamp
+		}
+		return entries[i].key < entries[j].key
+	})
+	return entries
+}
+
+// Size returns the size of the JSON serialized state
+func (s State) Size() int {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// Compact removes expired oncePer items, the oldest oncePer items of triggers with too many items and then the
+// oldest items until the state satisfies the size limits. Returns number of removed items.
+// TTL and per trigger limits don't apply to items without oncePer: such items are removed anyway once the condition
+// becomes false, while removing them earlier would cause duplicate notifications.
+func (s State) Compact(opts CompactOptions, now time.Time) int {
+	before := len(s)
+	entries := s.sortedEntries()
+	if opts.TTL > 0 {
+		expiresBefore := now.Add(-opts.TTL).Unix()
+		for _, entry := range entries {
+			if entry.item != nil && entry.item.OncePer != "" && entry.timestamp < expiresBefore {
+				delete(s, entry.key)
+			}
+		}
+	}
+	if opts.MaxItemsPerTrigger > 0 {
+		counts := map[string]int{}
+		// iterate starting from the newest entry, so the newest items of every trigger are kept
+		for i := len(entries) - 1; i >= 0; i-- {
+			entry := entries[i]
+			if _, ok := s[entry.key]; !ok || entry.item == nil || entry.item.OncePer == "" {
+				continue
+			}
+			counts[entry.item.Trigger]++
+			if counts[entry.item.Trigger] > opts.MaxItemsPerTrigger {
+				delete(s, entry.key)
+			}
+		}
+	}
+	if opts.MaxItems > 0 {
+		s.Truncate(opts.MaxItems)
+	}
+	if opts.MaxBytes > 0 {
+		size := s.Size()
+		for _, entry := range entries {
+			if size <= opts.MaxBytes {
+				break
+			}
+			if _, ok := s[entry.key]; !ok {
+				continue
+			}
+			delete(s, entry.key)
+			size = s.Size()
+		}
+	}
+	return before - len(s)
+}
+
+// Prune removes items that don't belong to any of the trigger destinations, e.g. items of removed triggers or
+// unsubscribed destinations, and returns number of removed items. Destinations map trigger names to subscribed
+// destinations
+func (s State) Prune(destinations map[string][]services.Destination) int {
+	removed := 0
+	for key := range s {
+		valid := false
+		for trigger, dests := range destinations {
+			if _, ok := MatchStateItemKey(key, trigger, dests); ok {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			delete(s, key)
+			removed++
+		}
+	}
+	return removed
+}
+
 // SetAlreadyNotified set the state of given trigger/destination and return if state has been changed
 func (s State) SetAlreadyNotified(trigger string, result ConditionResult, dest services.Destination, isNotified bool) bool {
 	key := StateItemKey(trigger, result, dest)
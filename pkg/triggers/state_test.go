@@ -1,8 +1,10 @@
 package triggers
 
 import (
+	"fmt"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/argoproj-labs/argocd-notifications/pkg/services"
 
@@ -54,3 +56,102 @@ func TestSetAlreadyNotified_OncePerItem(t *testing.T) {
 	_, ok = state["abc:app-synced:0:slack:my-channel"]
 	assert.True(t, ok)
 }
+
+func TestParseStateItemKey(t *testing.T) {
+	dest := services.Destination{Service: "slack", Recipient: "my-channel"}
+	item, err := ParseStateItemKey(StateItemKey("app-synced", ConditionResult{OncePer: "a:b", Key: "[0].abc"}, dest))
+
+	assert.NoError(t, err)
+	assert.Equal(t, StateItem{OncePer: "a:b", Trigger: "app-synced", ConditionKey: "[0].abc", Destination: dest}, *item)
+
+	_, err = ParseStateItemKey("invalid")
+	assert.Error(t, err)
+
+	dest = services.Destination{Service: "grafana", Recipient: "env:prod"}
+	item, err = ParseStateItemKey(StateItemKey("app-synced", ConditionResult{OncePer: "a:b", Key: "[0].abc"}, dest))
+	assert.NoError(t, err)
+	assert.Equal(t, StateItem{OncePer: "a:b", Trigger: "app-synced", ConditionKey: "[0].abc", Destination: dest}, *item)
+}
+
+func TestMatchStateItemKey(t *testing.T) {
+	dest := services.Destination{Service: "grafana", Recipient: "env:prod"}
+	key := StateItemKey("app-synced", ConditionResult{OncePer: "a:b", Key: "0"}, dest)
+
+	item, ok := MatchStateItemKey(key, "app-synced", []services.Destination{{Service: "slack", Recipient: "prod"}, dest})
+	assert.True(t, ok)
+	assert.Equal(t, StateItem{OncePer: "a:b", Trigger: "app-synced", ConditionKey: "0", Destination: dest}, *item)
+
+	_, ok = MatchStateItemKey(key, "app-deployed", []services.Destination{dest})
+	assert.False(t, ok)
+	_, ok = MatchStateItemKey(key, "app-synced", []services.Destination{{Service: "grafana", Recipient: "prod"}})
+	assert.False(t, ok)
+}
+
+func TestPrune(t *testing.T) {
+	state := State{
+		"app-synced:0:slack:my-channel":  1,
+		"app-synced:0:slack:old-channel": 1,
+		"app-removed:0:slack:my-channel": 1,
+		"invalid":                        1,
+	}
+
+	removed := state.Prune(map[string][]services.Destination{
+		"app-synced": {{Service: "slack", Recipient: "my-channel"}},
+	})
+
+	assert.Equal(t, 3, removed)
+	assert.Equal(t, State{"app-synced:0:slack:my-channel": 1}, state)
+}
+
+func TestPrune_RecipientWithColon(t *testing.T) {
+	dest := services.Destination{Service: "grafana", Recipient: "env:prod"}
+	state := State{}
+	_ = state.SetAlreadyNotified("app-synced", ConditionResult{Key: "[0].abc", OncePer: "rev:1"}, dest, true)
+
+	removed := state.Prune(map[string][]services.Destination{"app-synced": {dest}})
+
+	assert.Equal(t, 0, removed)
+	assert.Len(t, state, 1)
+}
+
+func TestNotificationState_Compact(t *testing.T) {
+	now := time.Unix(10000, 0)
+	state := State{
+		"rev-1:on-deployed:0:slack:my-channel": 1000,
+		"rev-2:on-deployed:0:slack:my-channel": 9000,
+		"rev-3:on-deployed:0:slack:my-channel": 9500,
+		"on-sync-failed:0:slack:my-channel":    1000,
+	}
+
+	removed := state.Compact(CompactOptions{TTL: time.Hour}, now)
+
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, State{
+		"rev-2:on-deployed:0:slack:my-channel": 9000,
+		"rev-3:on-deployed:0:slack:my-channel": 9500,
+		"on-sync-failed:0:slack:my-channel":    1000,
+	}, state)
+
+	removed = state.Compact(CompactOptions{MaxItemsPerTrigger: 1}, now)
+
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, State{
+		"rev-3:on-deployed:0:slack:my-channel": 9500,
+		"on-sync-failed:0:slack:my-channel":    1000,
+	}, state)
+}
+
+func TestNotificationState_CompactMaxBytes(t *testing.T) {
+	state := State{}
+	for i := 0; i < 10; i++ {
+		state[fmt.Sprintf("rev-%d:on-deployed:0:slack:my-channel", i)] = int64(i)
+	}
+	maxBytes := state.Size() / 2
+
+	removed := state.Compact(CompactOptions{MaxBytes: maxBytes}, time.Now())
+
+	assert.True(t, removed > 0)
+	assert.True(t, state.Size() <= maxBytes)
+	_, ok := state["rev-9:on-deployed:0:slack:my-channel"]
+	assert.True(t, ok)
+}
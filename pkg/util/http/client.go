@@ -0,0 +1,260 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/argoproj/argo-cd/util/cert"
+)
+
+// ClientOptions holds settings of the HTTP client used to connect to the notification service
+type ClientOptions struct {
+	// ConnectTimeout is the maximum time to establish the connection
+	ConnectTimeout string `json:"connectTimeout,omitempty"`
+	// Timeout is the maximum time of the request including reading the response body
+	Timeout string `json:"timeout,omitempty"`
+	// KeepAlive is the interval between keep-alive probes of the active connection. Negative value disables probes
+	KeepAlive string `json:"keepAlive,omitempty"`
+	// IdleConnTimeout is the maximum time the idle connection is kept in the pool
+	IdleConnTimeout string `json:"idleConnTimeout,omitempty"`
+	// MaxIdleConns is the maximum number of idle connections in the pool
+	MaxIdleConns int `json:"maxIdleConns,omitempty"`
+	// MaxIdleConnsPerHost is the maximum number of idle connections to the same host
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost,omitempty"`
+	// Egress restricts hosts the client is allowed to connect to. All hosts are allowed if not specified
+	Egress EgressPolicy `json:"egress,omitempty"`
+}
+
+// DefaultClientOptions are used if client settings are not specified, so that the hung endpoint
+// does not block the notification delivery indefinitely
+var DefaultClientOptions = ClientOptions{
+	ConnectTimeout:      "10s",
+	Timeout:             "30s",
+	KeepAlive:           "30s",
+	IdleConnTimeout:     "90s",
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 10,
+}
+
+// Merge returns options with fields overridden by non empty fields of the specified options
+func (o ClientOptions) Merge(other ClientOptions) ClientOptions {
+	if other.ConnectTimeout != "" {
+		o.ConnectTimeout = other.ConnectTimeout
+	}
+	if other.Timeout != "" {
+		o.Timeout = other.Timeout
+	}
+	if other.KeepAlive != "" {
+		o.KeepAlive = other.KeepAlive
+	}
+	if other.IdleConnTimeout != "" {
+		o.IdleConnTimeout = other.IdleConnTimeout
+	}
+	if other.MaxIdleConns != 0 {
+		o.MaxIdleConns = other.MaxIdleConns
+	}
+	if other.MaxIdleConnsPerHost != 0 {
+		o.MaxIdleConnsPerHost = other.MaxIdleConnsPerHost
+	}
+	if other.Egress != nil {
+		o.Egress = other.Egress
+	}
+	return o
+}
+
+// Validate returns an error if durations cannot be parsed or limits are negative
+func (o ClientOptions) Validate() error {
+	_, err := o.parse()
+	return err
+}
+
+type clientSettings struct {
+	connectTimeout  time.Duration
+	timeout         time.Duration
+	keepAlive       time.Duration
+	idleConnTimeout time.Duration
+}
+
+func (o ClientOptions) parse() (*clientSettings, error) {
+	var res clientSettings
+	for _, field := range []struct {
+		name string
+		val  string
+		dest *time.Duration
+	}{
+		{"connectTimeout", o.ConnectTimeout, &res.connectTimeout},
+		{"timeout", o.Timeout, &res.timeout},
+		{"keepAlive", o.KeepAlive, &res.keepAlive},
+		{"idleConnTimeout", o.IdleConnTimeout, &res.idleConnTimeout},
+	} {
+		if field.val == "" {
+			continue
+		}
+		d, err := time.ParseDuration(field.val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s '%s': %v", field.name, field.val, err)
+		}
+		*field.dest = d
+	}
+	if o.MaxIdleConns < 0 {
+		return nil, fmt.Errorf("maxIdleConns must not be negative")
+	}
+	if o.MaxIdleConnsPerHost < 0 {
+		return nil, fmt.Errorf("maxIdleConnsPerHost must not be negative")
+	}
+	if o.Egress != nil {
+		if err := o.Egress.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid egress: %v", err)
+		}
+	}
+	return &res, nil
+}
+
+// unusedTransportTTL is the time after which the transport that has not sent requests is removed from the pool, so
+// transports of outdated settings are released after configuration reloads
+const unusedTransportTTL = 10 * time.Minute
+
+var (
+	transportsLock sync.Mutex
+	// transports are shared by all clients with the same settings, so connections are reused across deliveries
+	transports = map[string]*pooledTransport{}
+)
+
+// pooledTransport records the time of the last request, so unused transports might be evicted from the pool
+type pooledTransport struct {
+	// lastUsed is the first field, so it is 64-bit aligned for atomic operations
+	lastUsed int64
+	*http.Transport
+}
+
+func (t *pooledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.StoreInt64(&t.lastUsed, time.Now().UnixNano())
+	return t.Transport.RoundTrip(req)
+}
+
+func (t *pooledTransport) unusedSince(since time.Time) bool {
+	return atomic.LoadInt64(&t.lastUsed) < since.UnixNano()
+}
+
+// NewClient returns the HTTP client with the pooled transport. Missing client settings are taken from DefaultClientOptions.
+// The client rejects requests to hosts and connections to addresses which are not allowed by the egress policy.
+func NewClient(rawURL string, clientOpts ClientOptions, tlsOpts TLSOptions, proxy ProxyOptions) *http.Client {
+	clientOpts, settings := withDefaults(clientOpts)
+	pooled := getPooledTransport(rawURL, clientOpts, settings, tlsOpts, proxy)
+	var transport http.RoundTripper = pooled
+	if clientOpts.Egress != nil {
+		transport = NewEgressRoundTripper(transport, clientOpts.Egress, pooled.Proxy)
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   settings.timeout,
+	}
+}
+
+// withDefaults merges options with DefaultClientOptions. Invalid options are replaced with defaults except the egress
+// policy, so the misconfigured client is still restricted
+func withDefaults(clientOpts ClientOptions) (ClientOptions, *clientSettings) {
+	egress := clientOpts.Egress
+	clientOpts = DefaultClientOptions.Merge(clientOpts)
+	settings, err := clientOpts.parse()
+	if err != nil {
+		settings, _ = DefaultClientOptions.parse()
+		clientOpts = DefaultClientOptions
+		clientOpts.Egress = egress
+	}
+	return clientOpts, settings
+}
+
+// ConnSettings holds client settings of the services that use protocols other than HTTP, e.g. SMTP and NATS
+type ConnSettings struct {
+	// Dial connects to the server using the connect timeout, keep-alive and egress settings
+	Dial func(ctx context.Context, network, address string) (net.Conn, error)
+	// Timeout is the maximum time of the exchange with the server after the connection is established
+	Timeout time.Duration
+}
+
+// NewConnSettings returns connection settings of the non HTTP client. Missing settings are taken from
+// DefaultClientOptions; pool settings are not applicable and ignored
+func NewConnSettings(clientOpts ClientOptions) ConnSettings {
+	clientOpts, settings := withDefaults(clientOpts)
+	dialer := &net.Dialer{Timeout: settings.connectTimeout, KeepAlive: settings.keepAlive}
+	dial := dialer.DialContext
+	if clientOpts.Egress != nil {
+		dial = clientOpts.Egress.Dialer(dialer)
+	}
+	return ConnSettings{Dial: dial, Timeout: settings.timeout}
+}
+
+// Deadline returns the deadline of the exchange that starts now or zero time if the exchange is not limited
+func (s ConnSettings) Deadline() time.Time {
+	if s.Timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(s.Timeout)
+}
+
+// transportKey returns the hash of the settings that identifies the shared transport
+func transportKey(host string, clientOpts ClientOptions, tlsOpts TLSOptions, proxy ProxyOptions, serverCertificatePem []string) string {
+	data, _ := json.Marshal(struct {
+		Host         string        `json:"host"`
+		Client       ClientOptions `json:"client"`
+		TLS          TLSOptions    `json:"tls"`
+		Proxy        ProxyOptions  `json:"proxy"`
+		Certificates []string      `json:"certificates"`
+	}{host, clientOpts, tlsOpts, proxy, serverCertificatePem})
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func getPooledTransport(rawURL string, clientOpts ClientOptions, settings *clientSettings, tlsOpts TLSOptions, proxy ProxyOptions) *pooledTransport {
+	host := ""
+	var serverCertificatePem []string
+	if parsedURL, err := url.Parse(rawURL); err == nil {
+		host = parsedURL.Host
+		// certificates configured in Argo CD are part of the key, so the updated certificate is used by new deliveries
+		serverCertificatePem, _ = cert.GetCertificateForConnect(parsedURL.Host)
+	}
+	key := transportKey(host, clientOpts, tlsOpts, proxy, serverCertificatePem)
+
+	transportsLock.Lock()
+	defer transportsLock.Unlock()
+	now := time.Now()
+	evictUnusedTransports(now.Add(-unusedTransportTTL))
+	if transport, ok := transports[key]; ok {
+		return transport
+	}
+	transport := NewTransport(rawURL, tlsOpts, proxy)
+	dialer := &net.Dialer{Timeout: settings.connectTimeout, KeepAlive: settings.keepAlive}
+	transport.DialContext = dialer.DialContext
+	if clientOpts.Egress != nil {
+		// the policy is part of the key, so transports that check connected addresses are not shared with other clients
+		transport.DialContext = clientOpts.Egress.Dialer(dialer)
+	}
+	transport.IdleConnTimeout = settings.idleConnTimeout
+	transport.MaxIdleConns = clientOpts.MaxIdleConns
+	transport.MaxIdleConnsPerHost = clientOpts.MaxIdleConnsPerHost
+	transport.TLSHandshakeTimeout = settings.connectTimeout
+	pooled := &pooledTransport{lastUsed: now.UnixNano(), Transport: transport}
+	transports[key] = pooled
+	return pooled
+}
+
+// evictUnusedTransports removes transports that have not sent requests since the specified time and closes their idle
+// connections. Clients that still reference the removed transport keep working but no longer share it with new clients
+func evictUnusedTransports(since time.Time) {
+	for key, transport := range transports {
+		if transport.unusedSince(since) {
+			transport.CloseIdleConnections()
+			delete(transports, key)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientOptions_Merge(t *testing.T) {
+	opts := DefaultClientOptions.Merge(ClientOptions{Timeout: "5s", MaxIdleConnsPerHost: 2})
+
+	assert.Equal(t, "5s", opts.Timeout)
+	assert.Equal(t, 2, opts.MaxIdleConnsPerHost)
+	assert.Equal(t, DefaultClientOptions.ConnectTimeout, opts.ConnectTimeout)
+	assert.Equal(t, DefaultClientOptions.MaxIdleConns, opts.MaxIdleConns)
+}
+
+func TestClientOptions_Validate(t *testing.T) {
+	assert.NoError(t, ClientOptions{}.Validate())
+	assert.NoError(t, DefaultClientOptions.Validate())
+	assert.Error(t, ClientOptions{Timeout: "abc"}.Validate())
+	assert.Error(t, ClientOptions{MaxIdleConns: -1}.Validate())
+}
+
+func TestNewClient_ReusesTransport(t *testing.T) {
+	first := NewClient("https://hooks.example.com/a", ClientOptions{}, TLSOptions{}, ProxyOptions{})
+	second := NewClient("https://hooks.example.com/b", ClientOptions{}, TLSOptions{}, ProxyOptions{})
+	other := NewClient("https://hooks.example.com/a", ClientOptions{MaxIdleConns: 5}, TLSOptions{}, ProxyOptions{})
+
+	assert.Equal(t, 30*time.Second, first.Timeout)
+	assert.True(t, first.Transport == second.Transport)
+	assert.False(t, first.Transport == other.Transport)
+}
+
+func TestNewClient_Timeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client := NewClient(server.URL, ClientOptions{Timeout: "50ms"}, TLSOptions{}, ProxyOptions{NoProxy: "*"})
+	_, err := client.Get(server.URL)
+
+	assert.Error(t, err)
+}
+
+func TestNewClient_EvictsUnusedTransports(t *testing.T) {
+	client := NewClient("https://hooks.example.com/unused", ClientOptions{MaxIdleConns: 7}, TLSOptions{}, ProxyOptions{})
+	used := NewClient("https://hooks.example.com/used", ClientOptions{MaxIdleConns: 8}, TLSOptions{}, ProxyOptions{})
+	unused := client.Transport.(*pooledTransport)
+	atomic.StoreInt64(&unused.lastUsed, time.Now().Add(-2*unusedTransportTTL).UnixNano())
+
+	next := NewClient("https://hooks.example.com/unused", ClientOptions{MaxIdleConns: 7}, TLSOptions{}, ProxyOptions{})
+	again := NewClient("https://hooks.example.com/used", ClientOptions{MaxIdleConns: 8}, TLSOptions{}, ProxyOptions{})
+
+	assert.False(t, next.Transport == client.Transport)
+	assert.True(t, again.Transport == used.Transport)
+}
+
+func TestTransportKey_Stable(t *testing.T) {
+	opts := ClientOptions{Timeout: "5s", Egress: EgressPolicy{"*.example.com"}}
+	key := transportKey("hooks.example.com", opts, TLSOptions{MinVersion: "1.2"}, ProxyOptions{}, nil)
+
+	assert.Equal(t, key, transportKey("hooks.example.com", opts, TLSOptions{MinVersion: "1.2"}, ProxyOptions{}, nil))
+	assert.NotEqual(t, key, transportKey("hooks.example.com", opts, TLSOptions{MinVersion: "1.3"}, ProxyOptions{}, nil))
+	assert.Len(t, key, 64)
+}
+
+func TestNewConnSettings(t *testing.T) {
+	settings := NewConnSettings(ClientOptions{Timeout: "5s"})
+	assert.Equal(t, 5*time.Second, settings.Timeout)
+	assert.False(t, settings.Deadline().IsZero())
+
+	settings = NewConnSettings(ClientOptions{Timeout: "invalid"})
+	assert.Equal(t, 30*time.Second, settings.Timeout)
+}
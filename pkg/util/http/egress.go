@@ -0,0 +1,188 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"github.com/ghodss/yaml"
+)
+
+// EgressPolicy lists hostnames and CIDRs the notification service is allowed to connect to. Hostnames might start
+// with the `*.` wildcard that matches any subdomain, e.g. `*.slack.com`.
+type EgressPolicy []string
+
+// EgressDeniedError is returned if the host is not allowed by the egress policy
+type EgressDeniedError struct {
+	Host string
+}
+
+func (e *EgressDeniedError) Error() string {
+	return fmt.Sprintf("connection to %s is not allowed by the egress policy", e.Host)
+}
+
+// LoadEgressPolicies reads policies of service types from the YAML file. The `*` key holds the policy of service
+// types that are not listed.
+func LoadEgressPolicies(path string) (map[string]EgressPolicy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var policies map[string]EgressPolicy
+	if err := yaml.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal egress policies: %v", err)
+	}
+	for serviceType, policy := range policies {
+		if err := policy.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid egress policy of %s: %v", serviceType, err)
+		}
+	}
+	return policies, nil
+}
+
+// Validate returns an error if the policy is empty or includes malformed entries
+func (p EgressPolicy) Validate() error {
+	if len(p) == 0 {
+		return errors.New("egress policy must include at least one hostname or CIDR")
+	}
+	for _, entry := range p {
+		switch {
+		case strings.Contains(entry, "/"):
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return fmt.Errorf("invalid CIDR '%s': %v", entry, err)
+			}
+		case entry == "" || strings.ContainsAny(entry, ":@ ") || strings.Contains(strings.TrimPrefix(entry, "*."), "*"):
+			return fmt.Errorf("invalid hostname '%s'", entry)
+		}
+	}
+	return nil
+}
+
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+func (p EgressPolicy) networks() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, entry := range p {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+// allowsHostname returns true if the hostname is listed or is the IP address that belongs to the allowed CIDRs
+func (p EgressPolicy) allowsHostname(host string) bool {
+	host = normalizeHost(host)
+	if ip := net.ParseIP(host); ip != nil {
+		return p.allowsIP(ip)
+	}
+	for _, entry := range p {
+		if strings.Contains(entry, "/") {
+			continue
+		}
+		entry = strings.ToLower(entry)
+		if host == entry || strings.HasPrefix(entry, "*.") && strings.HasSuffix(host, entry[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p EgressPolicy) allowsIP(ip net.IP) bool {
+	for _, network := range p.networks() {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns an error if the policy does not allow connections to the host. The host might include the port.
+// Hostnames are not resolved, so hostnames that are not listed are rejected even if they belong to the allowed CIDRs:
+// use Dialer to check addresses the connection is established with.
+func (p EgressPolicy) Check(host string) error {
+	if !p.allowsHostname(host) {
+		return &EgressDeniedError{Host: normalizeHost(host)}
+	}
+	return nil
+}
+
+// Dialer returns the dial function that rejects connections which are not allowed by the policy. Connections to
+// listed hostnames are allowed. Connections to other hostnames are allowed only if the address the connection is
+// established with belongs to the allowed CIDRs, so the check cannot be bypassed by the DNS record that changes
+// between the check and the connection.
+func (p EgressPolicy) Dialer(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if p.allowsHostname(addr) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		host := normalizeHost(addr)
+		if len(p.networks()) == 0 {
+			return nil, &EgressDeniedError{Host: host}
+		}
+		restricted := *dialer
+		restricted.Control = func(network, address string, c syscall.RawConn) error {
+			if dialer.Control != nil {
+				if err := dialer.Control(network, address, c); err != nil {
+					return err
+				}
+			}
+			ip := net.ParseIP(normalizeHost(address))
+			if ip == nil || !p.allowsIP(ip) {
+				return &EgressDeniedError{Host: host}
+			}
+			return nil
+		}
+		return restricted.DialContext(ctx, network, addr)
+	}
+}
+
+// NewEgressRoundTripper returns the round tripper that rejects requests to hosts that are not allowed by the policy.
+// Requests are checked before the proxy is applied, so redirects and proxied requests are checked as well. Proxied
+// requests must target listed hostnames since addresses of proxied hosts are resolved by the proxy. Requests that are
+// not proxied are checked again by the transport dialer, see Dialer.
+func NewEgressRoundTripper(roundTripper http.RoundTripper, policy EgressPolicy, proxy func(*http.Request) (*url.URL, error)) http.RoundTripper {
+	return &egressRoundTripper{roundTripper: roundTripper, policy: policy, proxy: proxy}
+}
+
+type egressRoundTripper struct {
+	roundTripper http.RoundTripper
+	policy       EgressPolicy
+	proxy        func(*http.Request) (*url.URL, error)
+}
+
+func (rt *egressRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := rt.check(req); err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	return rt.roundTripper.RoundTrip(req)
+}
+
+func (rt *egressRoundTripper) check(req *http.Request) error {
+	if rt.policy.allowsHostname(req.URL.Host) {
+		return nil
+	}
+	if rt.proxy != nil {
+		if proxyURL, err := rt.proxy(req); err != nil || proxyURL != nil {
+			return &EgressDeniedError{Host: normalizeHost(req.URL.Host)}
+		}
+	}
+	if len(rt.policy.networks()) == 0 {
+		return &EgressDeniedError{Host: normalizeHost(req.URL.Host)}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEgressPolicy_Validate(t *testing.T) {
+	assert.NoError(t, EgressPolicy{"slack.com", "*.example.com", "10.0.0.0/8"}.Validate())
+	assert.Error(t, EgressPolicy{}.Validate())
+	assert.Error(t, EgressPolicy{"10.0.0.0/33"}.Validate())
+	assert.Error(t, EgressPolicy{"https://slack.com"}.Validate())
+	assert.Error(t, EgressPolicy{"hooks.*.com"}.Validate())
+}
+
+func TestEgressPolicy_Check(t *testing.T) {
+	policy := EgressPolicy{"slack.com", "*.example.com", "10.0.0.0/8"}
+
+	assert.NoError(t, policy.Check("slack.com"))
+	assert.NoError(t, policy.Check("SLACK.com:443"))
+	assert.NoError(t, policy.Check("hooks.example.com"))
+	assert.NoError(t, policy.Check("10.1.2.3:8080"))
+
+	// hostnames are not resolved, so hostnames that are not listed are rejected
+	for _, host := range []string{"example.com", "evil.com", "slack.com.evil.com", "jenkins.internal", "192.168.0.1"} {
+		var egressErr *EgressDeniedError
+		assert.True(t, errors.As(policy.Check(host), &egressErr), host)
+	}
+}
+
+func TestEgressPolicy_Dialer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	addr := net.JoinHostPort("localhost", port)
+
+	for _, policy := range []EgressPolicy{{"localhost"}, {"127.0.0.0/8"}} {
+		conn, err := policy.Dialer(&net.Dialer{})(context.Background(), "tcp4", addr)
+		if assert.NoError(t, err, policy) {
+			_ = conn.Close()
+		}
+	}
+
+	// the connected address is checked, so the hostname resolved to the not allowed address is rejected
+	for _, policy := range []EgressPolicy{{"slack.com"}, {"10.0.0.0/8"}} {
+		_, err = policy.Dialer(&net.Dialer{})(context.Background(), "tcp4", addr)
+		var egressErr *EgressDeniedError
+		assert.True(t, errors.As(err, &egressErr), policy)
+	}
+}
+
+func TestLoadEgressPolicies(t *testing.T) {
+	file, err := ioutil.TempFile("", "egress")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+	_, err = file.WriteString(`
+slack: [slack.com]
+"*": [10.0.0.0/8]
+`)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	policies, err := LoadEgressPolicies(file.Name())
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]EgressPolicy{"slack": {"slack.com"}, "*": {"10.0.0.0/8"}}, policies)
+	}
+
+	assert.NoError(t, ioutil.WriteFile(file.Name(), []byte("slack: []"), 0600))
+	_, err = LoadEgressPolicies(file.Name())
+	assert.Error(t, err)
+}
+
+func TestNewClient_Egress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, ClientOptions{Egress: EgressPolicy{"127.0.0.0/8"}}, TLSOptions{}, ProxyOptions{NoProxy: "*"})
+	resp, err := client.Get(server.URL)
+	if assert.NoError(t, err) {
+		_ = resp.Body.Close()
+	}
+
+	client = NewClient(server.URL, ClientOptions{Egress: EgressPolicy{"slack.com"}}, TLSOptions{}, ProxyOptions{NoProxy: "*"})
+	_, err = client.Get(server.URL)
+	var egressErr *EgressDeniedError
+	assert.True(t, errors.As(err, &egressErr))
+}
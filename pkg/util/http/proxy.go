@@ -0,0 +1,54 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxyOptions holds settings of the proxy used to connect to the notification service
+type ProxyOptions struct {
+	// URL is the HTTP, HTTPS or SOCKS5 proxy URL. Proxy configured using environment variables is used if empty
+	URL string `json:"url,omitempty"`
+	// NoProxy is the comma separated list of hosts, domains and CIDRs that are accessed directly. Use '*' to disable proxy
+	NoProxy string `json:"noProxy,omitempty"`
+}
+
+// Validate returns an error if proxy URL is invalid
+func (o ProxyOptions) Validate() error {
+	if o.URL == "" {
+		return nil
+	}
+	proxyURL, err := url.Parse(o.URL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy url '%s': %v", o.URL, err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+		return nil
+	default:
+		return fmt.Errorf("invalid proxy url '%s': scheme must be one of http, https, socks5", o.URL)
+	}
+}
+
+// ProxyFunc returns function that selects proxy for the request. Settings not specified in options are taken from
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+func (o ProxyOptions) ProxyFunc() func(*http.Request) (*url.URL, error) {
+	if o.URL == "" && o.NoProxy == "" {
+		return http.ProxyFromEnvironment
+	}
+	cfg := httpproxy.FromEnvironment()
+	if o.URL != "" {
+		cfg.HTTPProxy = o.URL
+		cfg.HTTPSProxy = o.URL
+	}
+	if o.NoProxy != "" {
+		cfg.NoProxy = o.NoProxy
+	}
+	proxyFunc := cfg.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+}
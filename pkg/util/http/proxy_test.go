@@ -0,0 +1,34 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyOptions_ProxyFunc(t *testing.T) {
+	proxyFunc := ProxyOptions{URL: "http://proxy:3128", NoProxy: "internal.example.com"}.ProxyFunc()
+
+	req, err := http.NewRequest(http.MethodGet, "https://slack.com/api", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	proxyURL, err := proxyFunc(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://proxy:3128", proxyURL.String())
+
+	req, err = http.NewRequest(http.MethodGet, "https://internal.example.com/hooks", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	proxyURL, err = proxyFunc(req)
+	assert.NoError(t, err)
+	assert.Nil(t, proxyURL)
+}
+
+func TestProxyOptions_Validate(t *testing.T) {
+	assert.NoError(t, ProxyOptions{}.Validate())
+	assert.NoError(t, ProxyOptions{URL: "socks5://proxy:1080"}.Validate())
+	assert.Error(t, ProxyOptions{URL: "ftp://proxy"}.Validate())
+}
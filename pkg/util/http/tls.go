@@ -0,0 +1,79 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSOptions holds TLS settings of the connection to the notification service
+type TLSOptions struct {
+	// CABundle is the PEM encoded bundle of certificates trusted in addition to the system certificates
+	CABundle string `json:"caBundle,omitempty"`
+	// CABundleFile is the path to the PEM encoded bundle of trusted certificates, e.g. mounted from the config map
+	CABundleFile string `json:"caBundleFile,omitempty"`
+	// CABundleSecretKey is the key of the notifications secret that holds PEM encoded bundle of trusted certificates
+	CABundleSecretKey string `json:"caBundleSecretKey,omitempty"`
+	// MinVersion is the minimum TLS version, one of 1.0, 1.1, 1.2, 1.3
+	MinVersion string `json:"minVersion,omitempty"`
+	// InsecureSkipVerify disables verification of the server certificate
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// Validate returns an error if the TLS settings are invalid or the CA bundle cannot be loaded
+func (o TLSOptions) Validate() error {
+	_, err := o.tlsConfig()
+	return err
+}
+
+// ClientConfig returns TLS configuration of non HTTP connections, e.g. connections to message brokers
+func (o TLSOptions) ClientConfig() (*tls.Config, error) {
+	return o.tlsConfig()
+}
+
+func (o TLSOptions) isEmpty() bool {
+	return o == TLSOptions{}
+}
+
+func (o TLSOptions) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify}
+	if o.MinVersion != "" {
+		version, ok := tlsVersions[o.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid TLS minVersion '%s': must be one of 1.0, 1.1, 1.2, 1.3", o.MinVersion)
+		}
+		cfg.MinVersion = version
+	}
+	var bundles [][]byte
+	if o.CABundle != "" {
+		bundles = append(bundles, []byte(o.CABundle))
+	}
+	if o.CABundleFile != "" {
+		data, err := ioutil.ReadFile(o.CABundleFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %v", err)
+		}
+		bundles = append(bundles, data)
+	}
+	if len(bundles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		for _, bundle := range bundles {
+			if !pool.AppendCertsFromPEM(bundle) {
+				return nil, fmt.Errorf("CA bundle does not contain valid PEM encoded certificates")
+			}
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
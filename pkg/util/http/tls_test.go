@@ -0,0 +1,36 @@
+package http
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTransport_CABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	caBundle := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	client := &http.Client{Transport: NewTransport(server.URL, TLSOptions{}, ProxyOptions{NoProxy: "*"})}
+	_, err := client.Get(server.URL)
+	assert.Error(t, err)
+
+	client = &http.Client{Transport: NewTransport(server.URL, TLSOptions{CABundle: caBundle}, ProxyOptions{NoProxy: "*"})}
+	resp, err := client.Get(server.URL)
+	if assert.NoError(t, err) {
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestTLSOptions_Validate(t *testing.T) {
+	assert.NoError(t, TLSOptions{MinVersion: "1.2"}.Validate())
+	assert.Error(t, TLSOptions{MinVersion: "1.4"}.Validate())
+	assert.Error(t, TLSOptions{CABundle: "not a certificate"}.Validate())
+	assert.Error(t, TLSOptions{CABundleFile: "/non/existing/file"}.Validate())
+}
@@ -6,29 +6,40 @@ import (
 	"net/url"
 
 	"github.com/argoproj/argo-cd/util/cert"
+	log "github.com/sirupsen/logrus"
 )
 
-func NewTransport(rawURL string, insecureSkipVerify bool) *http.Transport {
+func NewTransport(rawURL string, tlsOpts TLSOptions, proxy ProxyOptions) *http.Transport {
 	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
+		Proxy: proxy.ProxyFunc(),
 	}
-	if insecureSkipVerify {
+	if tlsOpts.InsecureSkipVerify {
 		transport.TLSClientConfig = &tls.Config{
 			InsecureSkipVerify: true,
 		}
-	} else {
-		parsedURL, err := url.Parse(rawURL)
+		return transport
+	}
+	if !tlsOpts.isEmpty() {
+		tlsConfig, err := tlsOpts.tlsConfig()
 		if err != nil {
-			return transport
+			log.Warnf("Failed to configure TLS of the connection to %s, using default settings: %v", rawURL, err)
+		} else {
+			transport.TLSClientConfig = tlsConfig
 		}
-		serverCertificatePem, err := cert.GetCertificateForConnect(parsedURL.Host)
-		if err != nil {
-			return transport
-		} else if len(serverCertificatePem) > 0 {
-			transport.TLSClientConfig = &tls.Config{
-				RootCAs: cert.GetCertPoolFromPEMData(serverCertificatePem),
-			}
+	}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return transport
+	}
+	serverCertificatePem, err := cert.GetCertificateForConnect(parsedURL.Host)
+	if err != nil {
+		return transport
+	} else if len(serverCertificatePem) > 0 {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
 		}
+		// certificates configured in Argo CD for the server host replace other trusted certificates
+		transport.TLSClientConfig.RootCAs = cert.GetCertPoolFromPEMData(serverCertificatePem)
 	}
 	return transport
 }
@@ -0,0 +1,208 @@
+package redact
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Placeholder replaces redacted values
+const Placeholder = "******"
+
+// minValueLength is the min length of the redacted value; shorter values such as ports or booleans would garble
+// log messages without protecting anything
+const minValueLength = 6
+
+// sensitiveKeys are the substrings of service settings keys which values are redacted
+var sensitiveKeys = []string{"token", "password", "secret", "apikey", "webhook", "credentials", "recipienturls", "authorization"}
+
+// Redactor replaces known secret values in strings
+type Redactor struct {
+	lock     sync.RWMutex
+	replacer *strings.Replacer
+}
+
+// New returns redactor of the specified values
+func New(values ...string) *Redactor {
+	r := &Redactor{}
+	r.SetValues(values)
+	return r
+}
+
+// SetValues replaces values redacted by the redactor
+func (r *Redactor) SetValues(values []string) {
+	unique := map[string]bool{}
+	for _, val := range values {
+		if len(val) < minValueLength {
+			continue
+		}
+		unique[val] = true
+		// values embedded into JSON documents are escaped
+		if encoded, err := json.Marshal(val); err == nil {
+			unique[strings.Trim(string(encoded), `"`)] = true
+		}
+		if escaped := url.QueryEscape(val); escaped != val {
+			unique[escaped] = true
+		}
+	}
+	sorted := make([]string, 0, len(unique))
+	for val := range unique {
+		sorted = append(sorted, val)
+	}
+	// replace longer values first so values that contain other values are fully redacted
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	var replacer *strings.Replacer
+	if len(sorted) > 0 {
+		pairs := make([]string, 0, len(sorted)*2)
+		for _, val := range sorted {
+			pairs = append(pairs, val, Placeholder)
+		}
+		replacer = strings.NewReplacer(pairs...)
+	}
+	r.lock.Lock()
+	r.replacer = replacer
+	r.lock.Unlock()
+}
+
+// String returns the string with all known secret values replaced by the placeholder
+func (r *Redactor) String(s string) string {
+	r.lock.RLock()
+	replacer := r.replacer
+	r.lock.RUnlock()
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+// Error returns the error which message does not include known secret values. The original error is returned
+// if the message has nothing to redact.
+func (r *Redactor) Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	if redacted := r.String(msg); redacted != msg {
+		return &redactedError{msg: redacted, err: err}
+	}
+	return err
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// Default is the redactor used by the package level functions and the log hook
+var Default = New()
+
+// SetValues replaces values redacted by the default redactor
+func SetValues(values []string) {
+	Default.SetValues(values)
+}
+
+// String redacts known secret values using the default redactor
+func String(s string) string {
+	return Default.String(s)
+}
+
+// Error redacts known secret values from the error message using the default redactor
+func Error(err error) error {
+	return Default.Error(err)
+}
+
+// NewHook returns logrus hook that redacts messages and string or error fields of log entries
+func NewHook(r *Redactor) log.Hook {
+	return &hook{redactor: r}
+}
+
+type hook struct {
+	redactor *Redactor
+}
+
+func (h *hook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *hook) Fire(entry *log.Entry) error {
+	entry.Message = h.redactor.String(entry.Message)
+	for k, v := range entry.Data {
+		switch val := v.(type) {
+		case string:
+			entry.Data[k] = h.redactor.String(val)
+		case error:
+			entry.Data[k] = h.redactor.Error(val)
+		}
+	}
+	return nil
+}
+
+// SensitiveValues returns values of the sensitive settings such as tokens, passwords and webhook URLs found in
+// the parsed YAML or JSON document. URLs are sensitive if they include credentials, a path or a query since
+// webhook URLs often embed tokens.
+func SensitiveValues(settings interface{}) []string {
+	var res []string
+	var visit func(key string, val interface{})
+	visit = func(key string, val interface{}) {
+		switch v := val.(type) {
+		case map[string]interface{}:
+			// headers are configured as name and value pairs
+			if name, ok := v["name"].(string); ok && isSensitiveKey(name) {
+				if val, ok := v["value"].(string); ok {
+					res = append(res, val)
+				}
+			}
+			for k, item := range v {
+				visit(k, item)
+			}
+		case []interface{}:
+			for _, item := range v {
+				visit(key, item)
+			}
+		case string:
+			if isSensitiveKey(key) || isSensitiveURL(v) {
+				res = append(res, v)
+			}
+		}
+	}
+	visit("", settings)
+	return res
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSensitiveURL(val string) bool {
+	if !strings.Contains(val, "://") {
+		return false
+	}
+	u, err := url.Parse(val)
+	if err != nil {
+		return false
+	}
+	return u.User != nil || u.RawQuery != "" || strings.Trim(u.Path, "/") != ""
+}
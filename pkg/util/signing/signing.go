@@ -0,0 +1,219 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// FormatDetached attaches the base64 encoded signature of the `<iat>.<payload>` string, where iat is the Unix
+	// time of the signature. ECDSA signatures are ASN.1 encoded, so the signed string can be verified using
+	// `cosign verify-blob`
+	FormatDetached = "detached"
+	// FormatJWS attaches the JWS with the detached payload (RFC 7515, appendix F)
+	FormatJWS = "jws"
+
+	SignatureHeader = "X-Argocd-Notifications-Signature"
+	AlgorithmHeader = "X-Argocd-Notifications-Signature-Algorithm"
+	KeyIDHeader     = "X-Argocd-Notifications-Key-Id"
+	IssuerHeader    = "X-Argocd-Notifications-Issuer"
+	TimestampHeader = "X-Argocd-Notifications-Signature-Timestamp"
+	JWSHeader       = "X-Argocd-Notifications-JWS"
+)
+
+// Options holds settings of the payload signature
+type Options struct {
+	// PrivateKey is the PEM encoded PKCS #8 ed25519 or ECDSA P-256 private key
+	PrivateKey string `json:"privateKey,omitempty"`
+	// PrivateKeySecretKey is the key of the notifications secret with the PEM encoded private key
+	PrivateKeySecretKey string `json:"privateKeySecretKey,omitempty"`
+	// KeyID identifies the key, so receivers can pick the verification key
+	KeyID string `json:"keyId,omitempty"`
+	// Format is either detached or jws. Defaults to detached
+	Format string `json:"format,omitempty"`
+	// Issuer identifies the sender. Defaults to the host name, i.e. the controller pod name
+	Issuer string `json:"issuer,omitempty"`
+}
+
+// Enabled returns true if the private key is configured
+func (o Options) Enabled() bool {
+	return o.PrivateKey != "" || o.PrivateKeySecretKey != ""
+}
+
+// Validate returns an error if the private key cannot be parsed or the format is unknown
+func (o Options) Validate() error {
+	_, err := NewSigner(o)
+	return err
+}
+
+// Signer signs notification payloads
+type Signer struct {
+	key       crypto.Signer
+	algorithm string
+	opts      Options
+	now       func() time.Time
+}
+
+// NewSigner returns the signer that uses the configured private key
+func NewSigner(opts Options) (*Signer, error) {
+	switch opts.Format {
+	case "":
+		opts.Format = FormatDetached
+	case FormatDetached, FormatJWS:
+	default:
+		return nil, fmt.Errorf("unknown signature format '%s'; expected %s or %s", opts.Format, FormatDetached, FormatJWS)
+	}
+	if opts.PrivateKey == "" {
+		return nil, errors.New("signing private key is not specified")
+	}
+	block, _ := pem.Decode([]byte(opts.PrivateKey))
+	if block == nil {
+		return nil, errors.New("signing private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing private key: %v", err)
+	}
+	s := &Signer{opts: opts, now: time.Now}
+	switch key := parsed.(type) {
+	case ed25519.PrivateKey:
+		s.key, s.algorithm = key, "ed25519"
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 ECDSA signing keys are supported")
+		}
+		s.key, s.algorithm = key, "ecdsa-sha256"
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T; expected ed25519 or ECDSA P-256 key", parsed)
+	}
+	if s.opts.Issuer == "" {
+		s.opts.Issuer, _ = os.Hostname()
+	}
+	return s, nil
+}
+
+func (s *Signer) sign(data []byte) ([]byte, error) {
+	if _, ok := s.key.(ed25519.PrivateKey); ok {
+		return s.key.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(data)
+	return s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// Signature holds the signature of the payload and the attributes receivers need to verify it
+type Signature struct {
+	// Format is either detached or jws
+	Format string
+	// Value is the base64 encoded signature of the detached format or the compact JWS of the jws format
+	Value string
+	// Algorithm is either ed25519 or ecdsa-sha256. Empty for the jws format, which protected header holds the algorithm
+	Algorithm string
+	KeyID     string
+	Issuer    string
+	// IssuedAt is the Unix time of the signature. It is signed together with the payload, so receivers can reject
+	// replayed payloads
+	IssuedAt int64
+}
+
+// Sign returns the signature of the payload
+func (s *Signer) Sign(payload []byte) (*Signature, error) {
+	res := &Signature{Format: s.opts.Format, KeyID: s.opts.KeyID, Issuer: s.opts.Issuer, IssuedAt: s.now().Unix()}
+	if s.opts.Format == FormatJWS {
+		jws, err := s.jws(payload, res.IssuedAt)
+		if err != nil {
+			return nil, err
+		}
+		res.Value = jws
+		return res, nil
+	}
+	signature, err := s.sign([]byte(fmt.Sprintf("%d.%s", res.IssuedAt, payload)))
+	if err != nil {
+		return nil, err
+	}
+	res.Value = base64.StdEncoding.EncodeToString(signature)
+	res.Algorithm = s.algorithm
+	return res, nil
+}
+
+// Headers returns HTTP headers with the signature of the payload
+func (s *Signer) Headers(payload []byte) (map[string]string, error) {
+	signature, err := s.Sign(payload)
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{}
+	if signature.KeyID != "" {
+		headers[KeyIDHeader] = signature.KeyID
+	}
+	if signature.Issuer != "" {
+		headers[IssuerHeader] = signature.Issuer
+	}
+	if signature.Format == FormatJWS {
+		headers[JWSHeader] = signature.Value
+		return headers, nil
+	}
+	headers[SignatureHeader] = signature.Value
+	headers[AlgorithmHeader] = signature.Algorithm
+	headers[TimestampHeader] = strconv.FormatInt(signature.IssuedAt, 10)
+	return headers, nil
+}
+
+type jwsHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+}
+
+// jws returns the compact JWS serialization with the detached payload
+func (s *Signer) jws(payload []byte, issuedAt int64) (string, error) {
+	header := jwsHeader{Algorithm: "EdDSA", KeyID: s.opts.KeyID, Issuer: s.opts.Issuer, IssuedAt: issuedAt}
+	if _, ok := s.key.(*ecdsa.PrivateKey); ok {
+		header.Algorithm = "ES256"
+	}
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	protected := base64.RawURLEncoding.EncodeToString(headerData)
+	signature, err := s.sign([]byte(protected + "." + base64.RawURLEncoding.EncodeToString(payload)))
+	if err != nil {
+		return "", err
+	}
+	if header.Algorithm == "ES256" {
+		// JWS uses the fixed size concatenation of R and S instead of ASN.1
+		if signature, err = rawECDSASignature(signature); err != nil {
+			return "", err
+		}
+	}
+	return protected + ".." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func rawECDSASignature(der []byte) ([]byte, error) {
+	var sig struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, err
+	}
+	res := make([]byte, 64)
+	r, s := sig.R.Bytes(), sig.S.Bytes()
+	copy(res[32-len(r):32], r)
+	copy(res[64-len(s):], s)
+	return res, nil
+}
@@ -0,0 +1,144 @@
+package signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeKey(t *testing.T, key interface{}) string {
+	data, err := x509.MarshalPKCS8PrivateKey(key)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: data}))
+}
+
+func TestOptions_Validate(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, Options{PrivateKey: encodeKey(t, key)}.Validate())
+	assert.Error(t, Options{PrivateKey: encodeKey(t, key), Format: "pgp"}.Validate())
+	assert.Error(t, Options{PrivateKey: "abc"}.Validate())
+	assert.Error(t, Options{}.Validate())
+}
+
+func TestSigner_Detached(t *testing.T) {
+	publicKey, key, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+	signer, err := NewSigner(Options{PrivateKey: encodeKey(t, key), KeyID: "prod", Issuer: "controller-0"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	signer.now = func() time.Time {
+		return time.Unix(100, 0)
+	}
+
+	headers, err := signer.Headers([]byte(`{"app":"guestbook"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "ed25519", headers[AlgorithmHeader])
+	assert.Equal(t, "prod", headers[KeyIDHeader])
+	assert.Equal(t, "controller-0", headers[IssuerHeader])
+	assert.Equal(t, "100", headers[TimestampHeader])
+	signature, err := base64.StdEncoding.DecodeString(headers[SignatureHeader])
+	if assert.NoError(t, err) {
+		assert.True(t, ed25519.Verify(publicKey, []byte(`100.{"app":"guestbook"}`), signature))
+		assert.False(t, ed25519.Verify(publicKey, []byte(`101.{"app":"guestbook"}`), signature))
+	}
+}
+
+func TestSigner_DetachedECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+	signer, err := NewSigner(Options{PrivateKey: encodeKey(t, key)})
+	if !assert.NoError(t, err) {
+		return
+	}
+	signer.now = func() time.Time {
+		return time.Unix(100, 0)
+	}
+
+	headers, err := signer.Headers([]byte("hello"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "ecdsa-sha256", headers[AlgorithmHeader])
+	signature, err := base64.StdEncoding.DecodeString(headers[SignatureHeader])
+	if !assert.NoError(t, err) {
+		return
+	}
+	r, s, err := parseASN1(signature)
+	if assert.NoError(t, err) {
+		digest := sha256.Sum256([]byte("100.hello"))
+		assert.True(t, ecdsa.Verify(&key.PublicKey, digest[:], r, s))
+	}
+}
+
+func TestSigner_JWS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+	signer, err := NewSigner(Options{PrivateKey: encodeKey(t, key), Format: FormatJWS, KeyID: "prod", Issuer: "controller-0"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	signer.now = func() time.Time {
+		return time.Unix(100, 0)
+	}
+
+	headers, err := signer.Headers([]byte("hello"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	parts := strings.Split(headers[JWSHeader], ".")
+	if !assert.Len(t, parts, 3) {
+		return
+	}
+	assert.Empty(t, parts[1])
+	headerData, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if !assert.NoError(t, err) {
+		return
+	}
+	var header jwsHeader
+	assert.NoError(t, json.Unmarshal(headerData, &header))
+	assert.Equal(t, jwsHeader{Algorithm: "ES256", KeyID: "prod", Issuer: "controller-0", IssuedAt: 100}, header)
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if assert.NoError(t, err) && assert.Len(t, signature, 64) {
+		digest := sha256.Sum256([]byte(parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte("hello"))))
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		assert.True(t, ecdsa.Verify(&key.PublicKey, digest[:], r, s))
+	}
+}
+
+func parseASN1(der []byte) (*big.Int, *big.Int, error) {
+	raw, err := rawECDSASignature(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return new(big.Int).SetBytes(raw[:32]), new(big.Int).SetBytes(raw[32:]), nil
+}
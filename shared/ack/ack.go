@@ -0,0 +1,21 @@
+package ack
+
+import (
+	"context"
+
+	"k8s.io/client-go/dynamic"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+)
+
+// Acknowledge marks the trigger of the application as acknowledged by the specified user, so the controller stops
+// sending notifications of the trigger until the trigger condition returns false
+func Acknowledge(ctx context.Context, appClient dynamic.ResourceInterface, app string, trigger string, user string) error {
+	return k8s.UpdateAnnotations(ctx, appClient, app, func(annotations map[string]string) {
+		acks := triggers.NewAcknowledgments(annotations[subscriptions.AcknowledgedAnnotationKey])
+		acks.Acknowledge(trigger, user)
+		annotations[subscriptions.AcknowledgedAnnotationKey] = acks.String()
+	})
+}
@@ -0,0 +1,44 @@
+package ack
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestAcknowledge(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("guestbook", WithAnnotations(map[string]string{
+		subscriptions.AcknowledgedAnnotationKey: `{"on-health-degraded":{"user":"bob","timestamp":1}}`,
+	})))
+	appClient := k8s.NewAppClient(client, TestNamespace)
+
+	err := Acknowledge(context.Background(), appClient, "guestbook", "on-sync-failed", "alice")
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	app, err := appClient.Get(context.Background(), "guestbook", metav1.GetOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	acks := triggers.NewAcknowledgments(app.GetAnnotations()[subscriptions.AcknowledgedAnnotationKey])
+	assert.Equal(t, "alice", acks["on-sync-failed"].User)
+	assert.Equal(t, "bob", acks["on-health-degraded"].User)
+}
+
+func TestAcknowledge_AppNotFound(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+
+	err := Acknowledge(context.Background(), k8s.NewAppClient(client, TestNamespace), "guestbook", "on-sync-failed", "alice")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+const maxRequestSize = 3 * 1024 * 1024
+
+// NewConfigValidator returns handler of the validating admission webhook requests that rejects notifications config map
+// and config fragments with invalid triggers or templates or unsupported keys. Other config maps are always allowed.
+func NewConfigValidator(getSecret func() (*v1.Secret, error)) http.Handler {
+	return &configValidator{getSecret: getSecret}
+}
+
+type configValidator struct {
+	getSecret func() (*v1.Secret, error)
+}
+
+func (v *configValidator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	review := admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(data, &review); err != nil || review.Request == nil {
+		http.Error(w, "invalid admission review request", http.StatusBadRequest)
+		return
+	}
+
+	response := &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+	if err := v.validate(review.Request); err != nil {
+		response.Allowed = false
+		response.Result = &metav1.Status{Status: metav1.StatusFailure, Message: err.Error(), Reason: metav1.StatusReasonInvalid}
+	}
+	review.Request = nil
+	review.Response = response
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		log.Warnf("Failed to write admission review response: %v", err)
+	}
+}
+
+func (v *configValidator) validate(req *admissionv1.AdmissionRequest) error {
+	if req.Operation == admissionv1.Delete || len(req.Object.Raw) == 0 {
+		return nil
+	}
+	var configMap v1.ConfigMap
+	if err := json.Unmarshal(req.Object.Raw, &configMap); err != nil {
+		return fmt.Errorf("failed to parse config map: %v", err)
+	}
+	if configMap.Name != k8s.ConfigMapName && !settings.IsConfigFragment(&configMap) {
+		return nil
+	}
+	if settings.IsConfigFragment(&configMap) {
+		if err := settings.ValidateConfigFragment(&configMap); err != nil {
+			return fmt.Errorf("invalid config fragment %s: %v", configMap.Name, err)
+		}
+	}
+
+	secret, err := v.getSecret()
+	if err != nil {
+		// secret is not required to validate syntax, so missing secret should not block config changes
+		log.Warnf("Failed to get notifications secret, validating config map without secret: %v", err)
+		secret = &v1.Secret{}
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	// referenced files are validated once the controller downloads them
+	withoutRefs, err := settings.ExcludeConfigRefs(&configMap)
+	if err != nil {
+		return fmt.Errorf("invalid notifications config map %s: %v", configMap.Name, err)
+	}
+	if _, err := settings.NewConfig(withoutRefs, secret, nil, nil, legacy.ApplyLegacyConfig); err != nil {
+		return fmt.Errorf("invalid notifications config map %s: %v", configMap.Name, err)
+	}
+	return nil
+}
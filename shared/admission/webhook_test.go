@@ -0,0 +1,119 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+)
+
+func review(t *testing.T, handler http.Handler, configMap *v1.ConfigMap) *admissionv1.AdmissionResponse {
+	data, err := json.Marshal(configMap)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	body, err := json.Marshal(admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "123",
+			Operation: admissionv1.Update,
+			Object:    runtime.RawExtension{Raw: data},
+		},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+	if !assert.Equal(t, http.StatusOK, w.Code) {
+		t.FailNow()
+	}
+	var res admissionv1.AdmissionReview
+	if !assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res)) {
+		t.FailNow()
+	}
+	assert.Equal(t, "123", string(res.Response.UID))
+	return res.Response
+}
+
+func newSecret() (*v1.Secret, error) {
+	return &v1.Secret{Data: map[string][]byte{"slack-token": []byte("abc")}}, nil
+}
+
+func TestConfigValidator_Valid(t *testing.T) {
+	res := review(t, NewConfigValidator(newSecret), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: k8s.ConfigMapName},
+		Data: map[string]string{
+			"trigger.on-sync-failed":   `[{when: "app.status.operationState.phase == 'Failed'", send: [app-sync-failed]}]`,
+			"template.app-sync-failed": `message: "{{.app.metadata.name}} sync failed"`,
+			"service.slack":            `token: $slack-token`,
+		},
+	})
+	assert.True(t, res.Allowed)
+}
+
+func TestConfigValidator_ConfigRefs(t *testing.T) {
+	res := review(t, NewConfigValidator(newSecret), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: k8s.ConfigMapName},
+		Data: map[string]string{
+			"trigger.on-sync-failed": `$ref: https://example.com/triggers/on-sync-failed.yaml`,
+		},
+	})
+	assert.True(t, res.Allowed)
+
+	res = review(t, NewConfigValidator(newSecret), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: k8s.ConfigMapName},
+		Data: map[string]string{
+			"trigger.on-sync-failed": `$ref: http://example.com/triggers/on-sync-failed.yaml`,
+		},
+	})
+	assert.False(t, res.Allowed)
+}
+
+func TestConfigValidator_InvalidTrigger(t *testing.T) {
+	res := review(t, NewConfigValidator(newSecret), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: k8s.ConfigMapName},
+		Data: map[string]string{
+			"trigger.on-sync-failed": `[{when: "app.status.operationState.phase ==", send: [app-sync-failed]}]`,
+		},
+	})
+	assert.False(t, res.Allowed)
+	assert.Contains(t, res.Result.Message, "invalid notifications config map")
+}
+
+func TestConfigValidator_InvalidFragmentTemplate(t *testing.T) {
+	res := review(t, NewConfigValidator(newSecret), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{k8s.ConfigFragmentLabel: "true"}},
+		Data:       map[string]string{"template.broken": `message: "{{.app.metadata.name"`},
+	})
+	assert.False(t, res.Allowed)
+}
+
+func TestConfigValidator_FragmentService(t *testing.T) {
+	res := review(t, NewConfigValidator(newSecret), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{k8s.ConfigFragmentLabel: "true"}},
+		Data:       map[string]string{"service.webhook.team-a": `url: https://example.com`},
+	})
+	assert.False(t, res.Allowed)
+	assert.Contains(t, res.Result.Message, "unsupported key 'service.webhook.team-a'")
+}
+
+func TestConfigValidator_IgnoresOtherConfigMaps(t *testing.T) {
+	res := review(t, NewConfigValidator(func() (*v1.Secret, error) {
+		return nil, errors.New("not found")
+	}), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		Data:       map[string]string{"trigger.broken": `[{when: "==="}]`},
+	})
+	assert.True(t, res.Allowed)
+}
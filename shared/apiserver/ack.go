@@ -0,0 +1,74 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/ack"
+)
+
+// AcknowledgePath is the path of the endpoint that acknowledges firing triggers
+const AcknowledgePath = "/api/v1/acknowledge"
+
+// AcknowledgeRequest is the body of the acknowledge request
+type AcknowledgeRequest struct {
+	// App is the name of the application in the controller namespace
+	App string `json:"app"`
+	// Trigger is the name of the firing trigger
+	Trigger string `json:"trigger"`
+	// User is the name of the user who has acknowledged the trigger
+	User string `json:"user,omitempty"`
+}
+
+func (req AcknowledgeRequest) validate() error {
+	if req.App == "" {
+		return errors.New("app must be specified")
+	}
+	if req.Trigger == "" {
+		return errors.New("trigger must be specified")
+	}
+	return nil
+}
+
+func (s *server) acknowledge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cfg := s.getConfig()
+	if cfg == nil {
+		http.Error(w, "configuration is not loaded yet", http.StatusServiceUnavailable)
+		return
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req AcknowledgeRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, ok := cfg.Triggers[req.Trigger]; !ok {
+		http.Error(w, fmt.Sprintf("trigger '%s' is not configured", req.Trigger), http.StatusBadRequest)
+		return
+	}
+	if err := ack.Acknowledge(r.Context(), s.appClient, req.App, req.Trigger, req.User); apierr.IsNotFound(err) {
+		http.Error(w, fmt.Sprintf("application '%s' not found", req.App), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, fmt.Sprintf("failed to acknowledge trigger: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
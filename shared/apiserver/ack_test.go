@@ -0,0 +1,70 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func newAckServer(patches *[]map[string]interface{}, objects ...runtime.Object) *server {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+	AddPatchCollectorReactor(client, patches)
+	cfg := &settings.Config{Triggers: map[string][]triggers.Condition{
+		"on-sync-failed": {{When: "app.status.operationState.phase == 'Failed'", Send: []string{"app-sync-failed"}}},
+	}}
+	return NewServer(k8s.NewAppClient(client, TestNamespace), nil, func() *settings.Config {
+		return cfg
+	})
+}
+
+func acknowledge(s http.Handler, req AcknowledgeRequest) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, AcknowledgePath, bytes.NewReader(data)))
+	return w
+}
+
+func TestAcknowledge(t *testing.T) {
+	var patches []map[string]interface{}
+	s := newAckServer(&patches, NewApp("guestbook"))
+
+	w := acknowledge(s, AcknowledgeRequest{App: "guestbook", Trigger: "on-sync-failed", User: "alice"})
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	if assert.Len(t, patches, 1) {
+		val, _, _ := unstructured.NestedString(patches[0], "metadata", "annotations", subscriptions.AcknowledgedAnnotationKey)
+		assert.Equal(t, "alice", triggers.NewAcknowledgments(val)["on-sync-failed"].User)
+	}
+}
+
+func TestAcknowledge_UnknownTrigger(t *testing.T) {
+	var patches []map[string]interface{}
+	s := newAckServer(&patches, NewApp("guestbook"))
+
+	w := acknowledge(s, AcknowledgeRequest{App: "guestbook", Trigger: "on-unknown"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, patches)
+}
+
+func TestAcknowledge_AppNotFound(t *testing.T) {
+	var patches []map[string]interface{}
+	s := newAckServer(&patches)
+
+	w := acknowledge(s, AcknowledgeRequest{App: "guestbook", Trigger: "on-sync-failed"})
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
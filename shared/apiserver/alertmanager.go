@@ -0,0 +1,184 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/expr"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/redact"
+	"github.com/argoproj-labs/argocd-notifications/shared/deadletter"
+	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// AlertmanagerPath is the path of the endpoint that receives Alertmanager webhook notifications
+const AlertmanagerPath = "/api/v1/alertmanager"
+
+// AlertmanagerOptions configures routing of the Alertmanager alerts
+type AlertmanagerOptions struct {
+	// AppLabel is the alert label that holds the application name
+	AppLabel string
+	// Trigger is the name of the trigger used in subscriptions to the application alerts
+	Trigger string
+	// Templates holds names of the templates used to generate the alert notification
+	Templates []string
+}
+
+// Alert is the single alert of the Alertmanager webhook payload
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     string            `json:"startsAt"`
+	EndsAt       string            `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// AlertmanagerMessage is the payload of the Alertmanager webhook
+type AlertmanagerMessage struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+// WithAlertmanagerReceiver enables the endpoint that routes Alertmanager alerts to subscribers of the application
+// referenced by the alert label
+func WithAlertmanagerReceiver(opts AlertmanagerOptions) Opts {
+	return func(s *server) {
+		s.alertmanagerOpts = opts
+		s.mux.HandleFunc(AlertmanagerPath, s.receiveAlerts)
+	}
+}
+
+func (a Alert) toVars() map[string]interface{} {
+	return map[string]interface{}{
+		"status":       a.Status,
+		"labels":       a.Labels,
+		"annotations":  a.Annotations,
+		"startsAt":     a.StartsAt,
+		"endsAt":       a.EndsAt,
+		"generatorURL": a.GeneratorURL,
+		"fingerprint":  a.Fingerprint,
+	}
+}
+
+func (s *server) receiveAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cfg := s.getConfig()
+	if cfg == nil {
+		http.Error(w, "configuration is not loaded yet", http.StatusServiceUnavailable)
+		return
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var msg AlertmanagerMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		http.Error(w, fmt.Sprintf("invalid alertmanager message: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// applications are loaded before any notification is sent, so the request fails without partial deliveries if
+	// applications cannot be loaded
+	apps := map[string]*unstructured.Unstructured{}
+	for _, alert := range msg.Alerts {
+		appName := alert.Labels[s.alertmanagerOpts.AppLabel]
+		if appName == "" {
+			log.Debugf("Alert %s has no %s label, skipping", alert.Fingerprint, s.alertmanagerOpts.AppLabel)
+			continue
+		}
+		if _, ok := apps[appName]; ok {
+			continue
+		}
+		app, err := s.appClient.Get(r.Context(), appName, metav1.GetOptions{})
+		if apierr.IsNotFound(err) {
+			log.Warnf("Application %s referenced by alert %s not found, skipping", appName, alert.Fingerprint)
+			apps[appName] = nil
+			continue
+		} else if err != nil {
+			http.Error(w, fmt.Sprintf("failed to get application '%s': %v", appName, err), http.StatusInternalServerError)
+			return
+		}
+		apps[appName] = app
+	}
+
+	// the response status is 200 even if some notifications are not delivered: Alertmanager re-sends the whole group on
+	// errors, so the delivered notifications would be duplicated. Failures are reported in the response body, dead
+	// letters and metrics instead
+	res := NotifyResponse{}
+	for _, alert := range msg.Alerts {
+		app := apps[alert.Labels[s.alertmanagerOpts.AppLabel]]
+		if app == nil {
+			continue
+		}
+		for _, dest := range s.getSubscriptions(r.Context(), cfg, app)[s.alertmanagerOpts.Trigger] {
+			alertVars := alert.toVars()
+			vars := expr.Spawn(app, cfg.ArgoCDService, map[string]interface{}{
+				"app":     app.Object,
+				"alert":   alertVars,
+				"context": legacy.InjectLegacyVar(cfg.Context, dest.Service),
+			})
+			result := DeliveryResult{Recipient: fmt.Sprintf("%s:%s", dest.Service, dest.Recipient)}
+			err := cfg.API.Send(vars, s.alertmanagerOpts.Templates, dest)
+			s.metrics.IncAlertDeliveriesCounter(dest.Service, err == nil)
+			if err != nil {
+				log.Errorf("Failed to notify %s about alert %s: %v", dest, alert.Fingerprint, err)
+				result.Error = err.Error()
+				res.Failed++
+				s.recordAlertDeadLetter(r.Context(), app, alert, alertVars, dest, err)
+			}
+			res.Results = append(res.Results, result)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Warnf("Failed to write alertmanager response: %v", err)
+	}
+}
+
+// recordAlertDeadLetter records the notification about the alert that could not be delivered. Repeated failures of the
+// same alert are recorded once
+func (s *server) recordAlertDeadLetter(ctx context.Context, app *unstructured.Unstructured, alert Alert, alertVars map[string]interface{}, dest services.Destination, sendErr error) {
+	if s.deadLetterStore == nil {
+		return
+	}
+	entry := deadletter.Entry{
+		ID:          deadletter.EntryID(app.GetName()+"/"+alert.Fingerprint, s.alertmanagerOpts.Trigger, s.alertmanagerOpts.Templates, dest),
+		App:         app.GetName(),
+		Trigger:     s.alertmanagerOpts.Trigger,
+		Templates:   s.alertmanagerOpts.Templates,
+		Destination: dest,
+		Vars:        map[string]interface{}{"alert": alertVars},
+		Error:       redact.String(sendErr.Error()),
+		Failures:    1,
+		Timestamp:   time.Now().Unix(),
+	}
+	if err := s.deadLetterStore.Add(ctx, entry); err != nil {
+		log.Errorf("Failed to record dead letter for recipient %s: %v", dest, err)
+		return
+	}
+	s.metrics.IncAlertDeadLettersCounter(dest.Service)
+}
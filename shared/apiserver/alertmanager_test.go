@@ -0,0 +1,128 @@
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/mocks"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/shared/deadletter"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestReceiveAlerts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := mocks.NewMockAPI(ctrl)
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		NewApp("guestbook", WithProject("default"), WithAnnotations(map[string]string{
+			subscriptions.SubscribeAnnotationKey("on-alert", "slack"):       "app-team",
+			subscriptions.SubscribeAnnotationKey("on-sync-failed", "slack"): "deploys",
+		})),
+		NewProject("default", WithAnnotations(map[string]string{
+			subscriptions.SubscribeAnnotationKey("on-alert", "slack"): "ops",
+		})))
+	cfg := &settings.Config{API: api}
+	s := NewServer(k8s.NewAppClient(client, TestNamespace), k8s.NewAppProjClient(client, TestNamespace), func() *settings.Config {
+		return cfg
+	}, WithAlertmanagerReceiver(AlertmanagerOptions{
+		AppLabel: "argocd_application", Trigger: "on-alert", Templates: []string{"alertmanager-alert"},
+	}))
+
+	for _, recipient := range []string{"app-team", "ops"} {
+		api.EXPECT().Send(gomock.Any(), []string{"alertmanager-alert"}, services.Destination{Service: "slack", Recipient: recipient}).
+			DoAndReturn(func(vars map[string]interface{}, _ []string, _ services.Destination) error {
+				alert := vars["alert"].(map[string]interface{})
+				assert.Equal(t, "firing", alert["status"])
+				assert.Equal(t, "HighErrorRate", alert["labels"].(map[string]string)["alertname"])
+				assert.NotNil(t, vars["app"])
+				return nil
+			})
+	}
+
+	data, _ := json.Marshal(AlertmanagerMessage{Status: "firing", Alerts: []Alert{{
+		Status: "firing",
+		Labels: map[string]string{"alertname": "HighErrorRate", "argocd_application": "guestbook"},
+	}, {
+		Status: "firing",
+		Labels: map[string]string{"alertname": "NodeDown"},
+	}, {
+		Status: "firing",
+		Labels: map[string]string{"alertname": "HighErrorRate", "argocd_application": "unknown"},
+	}}})
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, AlertmanagerPath, bytes.NewReader(data)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var res NotifyResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Len(t, res.Results, 2)
+}
+
+func TestReceiveAlerts_PartialFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := mocks.NewMockAPI(ctrl)
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		NewApp("guestbook", WithAnnotations(map[string]string{
+			subscriptions.SubscribeAnnotationKey("on-alert", "slack"): "app-team",
+			subscriptions.SubscribeAnnotationKey("on-alert", "email"): "ops@example.com",
+		})))
+	store := deadletter.NewConfigMapStore(k8sfake.NewSimpleClientset(), TestNamespace, 10)
+	cfg := &settings.Config{API: api}
+	s := NewServer(k8s.NewAppClient(client, TestNamespace), nil, func() *settings.Config {
+		return cfg
+	}, WithDeadLetterStore(store), WithAlertmanagerReceiver(AlertmanagerOptions{
+		AppLabel: "argocd_application", Trigger: "on-alert", Templates: []string{"alertmanager-alert"},
+	}))
+
+	api.EXPECT().Send(gomock.Any(), []string{"alertmanager-alert"}, services.Destination{Service: "slack", Recipient: "app-team"}).Return(nil)
+	api.EXPECT().Send(gomock.Any(), []string{"alertmanager-alert"}, services.Destination{Service: "email", Recipient: "ops@example.com"}).
+		Return(errors.New("connection refused"))
+
+	data, _ := json.Marshal(AlertmanagerMessage{Status: "firing", Alerts: []Alert{{
+		Status:      "firing",
+		Fingerprint: "abc",
+		Labels:      map[string]string{"alertname": "HighErrorRate", "argocd_application": "guestbook"},
+	}}})
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, AlertmanagerPath, bytes.NewReader(data)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var res NotifyResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Len(t, res.Results, 2)
+	assert.Equal(t, 1, res.Failed)
+
+	entries, err := store.List(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "guestbook", entries[0].App)
+		assert.Equal(t, services.Destination{Service: "email", Recipient: "ops@example.com"}, entries[0].Destination)
+		assert.Equal(t, "HighErrorRate", entries[0].Vars["alert"].(map[string]interface{})["labels"].(map[string]interface{})["alertname"])
+	}
+}
+
+func TestReceiveAlerts_Disabled(t *testing.T) {
+	s := NewServer(nil, nil, func() *settings.Config {
+		return &settings.Config{}
+	})
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, AlertmanagerPath, bytes.NewReader([]byte("{}"))))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,119 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewGRPCServer returns gRPC server that serves NotificationService using the same configuration as the REST API
+func (s *server) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	grpcServer := grpc.NewServer(opts...)
+	RegisterNotificationServiceServer(grpcServer, &grpcService{server: s})
+	return grpcServer
+}
+
+type grpcService struct {
+	server *server
+}
+
+func toGRPCError(err error) error {
+	reqErr, ok := err.(*requestError)
+	if !ok {
+		return status.Error(codes.Internal, err.Error())
+	}
+	switch reqErr.status {
+	case http.StatusBadRequest:
+		return status.Error(codes.InvalidArgument, reqErr.message)
+	case http.StatusNotFound:
+		return status.Error(codes.NotFound, reqErr.message)
+	case http.StatusServiceUnavailable:
+		return status.Error(codes.Unavailable, reqErr.message)
+	default:
+		return status.Error(codes.Internal, reqErr.message)
+	}
+}
+
+func toRawContext(ctx map[string]string) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	res := map[string]interface{}{}
+	for k, v := range ctx {
+		res[k] = v
+	}
+	return res
+}
+
+func (g *grpcService) Send(ctx context.Context, req *SendRequest) (*SendResponse, error) {
+	res, err := g.server.send(ctx, NotifyRequest{
+		App:        req.App,
+		Context:    toRawContext(req.Context),
+		Templates:  req.Templates,
+		Recipients: req.Recipients,
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	out := &SendResponse{}
+	for _, result := range res.Results {
+		out.Results = append(out.Results, &GRPCDeliveryResult{Recipient: result.Recipient, Error: result.Error})
+	}
+	return out, nil
+}
+
+func (g *grpcService) ListTriggers(_ context.Context, _ *ListTriggersRequest) (*ListTriggersResponse, error) {
+	cfg := g.server.getConfig()
+	if cfg == nil {
+		return nil, status.Error(codes.Unavailable, "configuration is not loaded yet")
+	}
+	res := &ListTriggersResponse{}
+	for name, conditions := range cfg.Triggers {
+		trigger := &Trigger{Name: name}
+		for _, c := range conditions {
+			trigger.Conditions = append(trigger.Conditions, &Condition{When: c.When, Send: c.Send, Description: c.Description, OncePer: c.OncePer})
+		}
+		res.Triggers = append(res.Triggers, trigger)
+	}
+	sort.Slice(res.Triggers, func(i, j int) bool {
+		return res.Triggers[i].Name < res.Triggers[j].Name
+	})
+	return res, nil
+}
+
+func (g *grpcService) RenderTemplate(ctx context.Context, req *RenderTemplateRequest) (*RenderTemplateResponse, error) {
+	cfg := g.server.getConfig()
+	if cfg == nil {
+		return nil, status.Error(codes.Unavailable, "configuration is not loaded yet")
+	}
+	if req.Template == "" {
+		return nil, status.Error(codes.InvalidArgument, "template must be specified")
+	}
+	recipient := req.Recipient
+	if recipient == "" {
+		recipient = "console"
+	}
+	dest, err := parseDestination(recipient)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	rawContext := toRawContext(req.Context)
+	app, err := g.server.getApp(ctx, req.App, rawContext)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	notification, err := cfg.API.FormatNotification(getVars(cfg, app, req.App != "", rawContext, dest), []string{req.Template}, dest)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &RenderTemplateResponse{Message: notification.Message, Notification: string(data)}, nil
+}
@@ -0,0 +1,97 @@
+package apiserver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/mocks"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func newGRPCClient(t *testing.T, api pkg.API) (NotificationServiceClient, func()) {
+	s := newServer(api, NewApp("guestbook"))
+	s.getConfig().Triggers = map[string][]triggers.Condition{
+		"on-sync-failed": {{When: "app.status.operationState.phase == 'Failed'", Send: []string{"app-sync-failed"}}},
+	}
+	grpcServer := s.NewGRPCServer()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return NewNotificationServiceClient(conn), func() {
+		_ = conn.Close()
+		grpcServer.Stop()
+	}
+}
+
+func TestGRPC_Send(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := mocks.NewMockAPI(ctrl)
+	api.EXPECT().Send(gomock.Any(), []string{"app-deployed"}, services.Destination{Service: "slack", Recipient: "ci"}).Return(nil)
+	client, closer := newGRPCClient(t, api)
+	defer closer()
+
+	res, err := client.Send(context.Background(), &SendRequest{App: "guestbook", Templates: []string{"app-deployed"}, Recipients: []string{"slack:ci"}})
+
+	assert.NoError(t, err)
+	assert.Len(t, res.Results, 1)
+	assert.Equal(t, "slack:ci", res.Results[0].Recipient)
+	assert.Empty(t, res.Results[0].Error)
+}
+
+func TestGRPC_SendInvalidRequest(t *testing.T) {
+	client, closer := newGRPCClient(t, nil)
+	defer closer()
+
+	_, err := client.Send(context.Background(), &SendRequest{App: "guestbook"})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPC_ListTriggers(t *testing.T) {
+	client, closer := newGRPCClient(t, nil)
+	defer closer()
+
+	res, err := client.ListTriggers(context.Background(), &ListTriggersRequest{})
+
+	assert.NoError(t, err)
+	assert.Len(t, res.Triggers, 1)
+	assert.Equal(t, "on-sync-failed", res.Triggers[0].Name)
+	assert.Equal(t, []string{"app-sync-failed"}, res.Triggers[0].Conditions[0].Send)
+}
+
+func TestGRPC_RenderTemplate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := mocks.NewMockAPI(ctrl)
+	api.EXPECT().FormatNotification(gomock.Any(), []string{"build-finished"}, services.Destination{Service: "console"}).
+		DoAndReturn(func(vars map[string]interface{}, _ []string, _ services.Destination) (*services.Notification, error) {
+			return &services.Notification{Message: "Build " + vars["build"].(string)}, nil
+		})
+	client, closer := newGRPCClient(t, api)
+	defer closer()
+
+	res, err := client.RenderTemplate(context.Background(), &RenderTemplateRequest{Template: "build-finished", Context: map[string]string{"build": "123"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Build 123", res.Message)
+	assert.Contains(t, res.Notification, `"message":"Build 123"`)
+}
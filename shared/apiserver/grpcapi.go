@@ -0,0 +1,185 @@
+package apiserver
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+// Messages and service descriptor of the gRPC API defined in notifications.proto. Messages are serialized using struct
+// tags, so fields must be kept in sync with the proto file.
+
+type SendRequest struct {
+	App        string            `protobuf:"bytes,1,opt,name=app,proto3" json:"app,omitempty"`
+	Context    map[string]string `protobuf:"bytes,2,rep,name=context,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3" json:"context,omitempty"`
+	Templates  []string          `protobuf:"bytes,3,rep,name=templates,proto3" json:"templates,omitempty"`
+	Recipients []string          `protobuf:"bytes,4,rep,name=recipients,proto3" json:"recipients,omitempty"`
+}
+
+func (m *SendRequest) Reset()         { *m = SendRequest{} }
+func (m *SendRequest) String() string { return proto.CompactTextString(m) }
+func (*SendRequest) ProtoMessage()    {}
+
+type GRPCDeliveryResult struct {
+	Recipient string `protobuf:"bytes,1,opt,name=recipient,proto3" json:"recipient,omitempty"`
+	Error     string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *GRPCDeliveryResult) Reset()         { *m = GRPCDeliveryResult{} }
+func (m *GRPCDeliveryResult) String() string { return proto.CompactTextString(m) }
+func (*GRPCDeliveryResult) ProtoMessage()    {}
+
+type SendResponse struct {
+	Results []*GRPCDeliveryResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+}
+
+func (m *SendResponse) Reset()         { *m = SendResponse{} }
+func (m *SendResponse) String() string { return proto.CompactTextString(m) }
+func (*SendResponse) ProtoMessage()    {}
+
+type ListTriggersRequest struct {
+}
+
+func (m *ListTriggersRequest) Reset()         { *m = ListTriggersRequest{} }
+func (m *ListTriggersRequest) String() string { return proto.CompactTextString(m) }
+func (*ListTriggersRequest) ProtoMessage()    {}
+
+type Condition struct {
+	When        string   `protobuf:"bytes,1,opt,name=when,proto3" json:"when,omitempty"`
+	Send        []string `protobuf:"bytes,2,rep,name=send,proto3" json:"send,omitempty"`
+	Description string   `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	OncePer     string   `protobuf:"bytes,4,opt,name=once_per,json=oncePer,proto3" json:"once_per,omitempty"`
+}
+
+func (m *Condition) Reset()         { *m = Condition{} }
+func (m *Condition) String() string { return proto.CompactTextString(m) }
+func (*Condition) ProtoMessage()    {}
+
+type Trigger struct {
+	Name       string       `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Conditions []*Condition `protobuf:"bytes,2,rep,name=conditions,proto3" json:"conditions,omitempty"`
+}
+
+func (m *Trigger) Reset()         { *m = Trigger{} }
+func (m *Trigger) String() string { return proto.CompactTextString(m) }
+func (*Trigger) ProtoMessage()    {}
+
+type ListTriggersResponse struct {
+	Triggers []*Trigger `protobuf:"bytes,1,rep,name=triggers,proto3" json:"triggers,omitempty"`
+}
+
+func (m *ListTriggersResponse) Reset()         { *m = ListTriggersResponse{} }
+func (m *ListTriggersResponse) String() string { return proto.CompactTextString(m) }
+func (*ListTriggersResponse) ProtoMessage()    {}
+
+type RenderTemplateRequest struct {
+	Template  string            `protobuf:"bytes,1,opt,name=template,proto3" json:"template,omitempty"`
+	App       string            `protobuf:"bytes,2,opt,name=app,proto3" json:"app,omitempty"`
+	Context   map[string]string `protobuf:"bytes,3,rep,name=context,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3" json:"context,omitempty"`
+	Recipient string            `protobuf:"bytes,4,opt,name=recipient,proto3" json:"recipient,omitempty"`
+}
+
+func (m *RenderTemplateRequest) Reset()         { *m = RenderTemplateRequest{} }
+func (m *RenderTemplateRequest) String() string { return proto.CompactTextString(m) }
+func (*RenderTemplateRequest) ProtoMessage()    {}
+
+type RenderTemplateResponse struct {
+	Message      string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Notification string `protobuf:"bytes,2,opt,name=notification,proto3" json:"notification,omitempty"`
+}
+
+func (m *RenderTemplateResponse) Reset()         { *m = RenderTemplateResponse{} }
+func (m *RenderTemplateResponse) String() string { return proto.CompactTextString(m) }
+func (*RenderTemplateResponse) ProtoMessage()    {}
+
+// NotificationServiceServer is the server API of the NotificationService
+type NotificationServiceServer interface {
+	Send(context.Context, *SendRequest) (*SendResponse, error)
+	ListTriggers(context.Context, *ListTriggersRequest) (*ListTriggersResponse, error)
+	RenderTemplate(context.Context, *RenderTemplateRequest) (*RenderTemplateResponse, error)
+}
+
+// NotificationServiceClient is the client API of the NotificationService
+type NotificationServiceClient interface {
+	Send(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*SendResponse, error)
+	ListTriggers(ctx context.Context, in *ListTriggersRequest, opts ...grpc.CallOption) (*ListTriggersResponse, error)
+	RenderTemplate(ctx context.Context, in *RenderTemplateRequest, opts ...grpc.CallOption) (*RenderTemplateResponse, error)
+}
+
+const notificationServiceName = "notifications.v1.NotificationService"
+
+type notificationServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewNotificationServiceClient returns client of the NotificationService
+func NewNotificationServiceClient(cc *grpc.ClientConn) NotificationServiceClient {
+	return &notificationServiceClient{cc: cc}
+}
+
+func (c *notificationServiceClient) Send(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*SendResponse, error) {
+	out := new(SendResponse)
+	if err := c.cc.Invoke(ctx, "/"+notificationServiceName+"/Send", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) ListTriggers(ctx context.Context, in *ListTriggersRequest, opts ...grpc.CallOption) (*ListTriggersResponse, error) {
+	out := new(ListTriggersResponse)
+	if err := c.cc.Invoke(ctx, "/"+notificationServiceName+"/ListTriggers", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) RenderTemplate(ctx context.Context, in *RenderTemplateRequest, opts ...grpc.CallOption) (*RenderTemplateResponse, error) {
+	out := new(RenderTemplateResponse)
+	if err := c.cc.Invoke(ctx, "/"+notificationServiceName+"/RenderTemplate", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RegisterNotificationServiceServer registers the service implementation in the gRPC server
+func RegisterNotificationServiceServer(s *grpc.Server, srv NotificationServiceServer) {
+	s.RegisterService(&notificationServiceDesc, srv)
+}
+
+func unaryHandler(method string, newRequest func() interface{}, call func(srv NotificationServiceServer, ctx context.Context, req interface{}) (interface{}, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := newRequest()
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return call(srv.(NotificationServiceServer), ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + notificationServiceName + "/" + method}
+			return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return call(srv.(NotificationServiceServer), ctx, req)
+			})
+		},
+	}
+}
+
+var notificationServiceDesc = grpc.ServiceDesc{
+	ServiceName: notificationServiceName,
+	HandlerType: (*NotificationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryHandler("Send", func() interface{} { return new(SendRequest) }, func(srv NotificationServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.Send(ctx, req.(*SendRequest))
+		}),
+		unaryHandler("ListTriggers", func() interface{} { return new(ListTriggersRequest) }, func(srv NotificationServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.ListTriggers(ctx, req.(*ListTriggersRequest))
+		}),
+		unaryHandler("RenderTemplate", func() interface{} { return new(RenderTemplateRequest) }, func(srv NotificationServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.RenderTemplate(ctx, req.(*RenderTemplateRequest))
+		}),
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "notifications.proto",
+}
@@ -0,0 +1,135 @@
+package apiserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/antonmedv/expr"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	notificationsexpr "github.com/argoproj-labs/argocd-notifications/expr"
+	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+)
+
+const (
+	// InboundWebhookPathPrefix is the path prefix of the inbound webhook endpoints, followed by the webhook name
+	InboundWebhookPathPrefix = "/api/v1/webhooks/"
+	// SignatureHeader holds HMAC-SHA256 signature of the request body in the sha256=<hex> format
+	SignatureHeader = "X-Signature-256"
+	// gitHubSignatureHeader allows receiving GitHub webhooks without a proxy
+	gitHubSignatureHeader = "X-Hub-Signature-256"
+)
+
+func verifySignature(body []byte, secret string, r *http.Request) bool {
+	signature := r.Header.Get(SignatureHeader)
+	if signature == "" {
+		signature = r.Header.Get(gitHubSignatureHeader)
+	}
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// receiveEvent evaluates the trigger of the inbound webhook for every application that matches the webhook app selector
+// and sends triggered notifications to the application subscribers. Events are not deduplicated: every received event
+// produces notifications
+func (s *server) receiveEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cfg := s.getConfig()
+	if cfg == nil {
+		http.Error(w, "configuration is not loaded yet", http.StatusServiceUnavailable)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, InboundWebhookPathPrefix)
+	webhook, ok := cfg.GetInboundWebhook(name)
+	if !ok {
+		http.Error(w, fmt.Sprintf("inbound webhook '%s' is not configured", name), http.StatusNotFound)
+		return
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !verifySignature(data, webhook.Secret, r) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	var event interface{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		http.Error(w, fmt.Sprintf("invalid event: %v", err), http.StatusBadRequest)
+		return
+	}
+	selector, err := webhook.CompileSelector()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	apps, err := s.appClient.List(r.Context(), metav1.ListOptions{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to list applications: %v", err), http.StatusInternalServerError)
+		return
+	}
+	res := NotifyResponse{}
+	status := http.StatusOK
+	for i := range apps.Items {
+		app := &apps.Items[i]
+		if selector != nil {
+			matches, err := expr.Run(selector, map[string]interface{}{"app": app.Object, "event": event})
+			if err != nil {
+				log.Debugf("Inbound webhook %s selector failed on app %s: %v", name, app.GetName(), err)
+				continue
+			}
+			if ok, _ := matches.(bool); !ok {
+				continue
+			}
+		}
+		destinations := s.getSubscriptions(r.Context(), cfg, app)[webhook.Trigger]
+		if len(destinations) == 0 {
+			continue
+		}
+		vars := map[string]interface{}{"app": app.Object, "event": event}
+		results, err := cfg.API.RunTrigger(webhook.Trigger, notificationsexpr.Spawn(app, cfg.ArgoCDService, vars))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to run trigger %s: %v", webhook.Trigger, err), http.StatusInternalServerError)
+			return
+		}
+		for _, result := range results {
+			if !result.Triggered {
+				continue
+			}
+			for _, dest := range destinations {
+				vars["context"] = legacy.InjectLegacyVar(cfg.Context, dest.Service)
+				deliveryResult := DeliveryResult{Recipient: fmt.Sprintf("%s:%s", dest.Service, dest.Recipient)}
+				if err := cfg.API.Send(notificationsexpr.Spawn(app, cfg.ArgoCDService, vars), result.Templates, dest); err != nil {
+					log.Errorf("Failed to notify %s about %s event: %v", deliveryResult.Recipient, name, err)
+					deliveryResult.Error = err.Error()
+					res.Failed++
+					status = http.StatusBadGateway
+				}
+				res.Results = append(res.Results, deliveryResult)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Warnf("Failed to write inbound webhook response: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package apiserver
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/mocks"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newInboundServer(api *mocks.MockAPI) http.Handler {
+	withImage := func(image string) func(app *unstructured.Unstructured) {
+		return func(app *unstructured.Unstructured) {
+			_ = unstructured.SetNestedField(app.Object, image, "spec", "source", "repoURL")
+		}
+	}
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		NewApp("guestbook", withImage("guestbook"), WithAnnotations(map[string]string{
+			subscriptions.SubscribeAnnotationKey("on-image-built", "slack"): "guestbook-team",
+		})),
+		NewApp("billing", withImage("billing"), WithAnnotations(map[string]string{
+			subscriptions.SubscribeAnnotationKey("on-image-built", "slack"): "billing-team",
+		})))
+	cfg := &settings.Config{API: api, InboundWebhooks: []settings.InboundWebhook{{
+		Name:        "image-build",
+		Secret:      "my-secret",
+		Trigger:     "on-image-built",
+		AppSelector: "app.spec.source.repoURL == event.image",
+	}}}
+	return NewServer(k8s.NewAppClient(client, TestNamespace), k8s.NewAppProjClient(client, TestNamespace), func() *settings.Config {
+		return cfg
+	})
+}
+
+func TestReceiveEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := mocks.NewMockAPI(ctrl)
+	api.EXPECT().RunTrigger("on-image-built", gomock.Any()).
+		DoAndReturn(func(_ string, vars map[string]interface{}) ([]triggers.ConditionResult, error) {
+			assert.Equal(t, map[string]interface{}{"image": "guestbook"}, vars["event"])
+			return []triggers.ConditionResult{{Triggered: true, Templates: []string{"image-built"}}}, nil
+		})
+	api.EXPECT().Send(gomock.Any(), []string{"image-built"}, services.Destination{Service: "slack", Recipient: "guestbook-team"}).Return(nil)
+
+	body := []byte(`{"image": "guestbook"}`)
+	req := httptest.NewRequest(http.MethodPost, InboundWebhookPathPrefix+"image-build", bytes.NewReader(body))
+	req.Header.Set(SignatureHeader, sign(body, "my-secret"))
+	w := httptest.NewRecorder()
+	newInboundServer(api).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestReceiveEvent_InvalidSignature(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	body := []byte(`{"image": "guestbook"}`)
+	req := httptest.NewRequest(http.MethodPost, InboundWebhookPathPrefix+"image-build", bytes.NewReader(body))
+	req.Header.Set(SignatureHeader, sign(body, "wrong-secret"))
+	w := httptest.NewRecorder()
+	newInboundServer(mocks.NewMockAPI(ctrl)).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestReceiveEvent_UnknownWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := httptest.NewRequest(http.MethodPost, InboundWebhookPathPrefix+"jira", bytes.NewReader([]byte("{}")))
+	w := httptest.NewRecorder()
+	newInboundServer(mocks.NewMockAPI(ctrl)).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,62 @@
+package apiserver
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	alertDeliveriesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_alertmanager_deliveries_total",
+			Help: "Number of notifications about Alertmanager alerts.",
+		},
+		[]string{"service", "succeeded"},
+	)
+
+	alertDeadLettersCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "argocd_notifications_alertmanager_dead_letters_total",
+			Help: "Number of notifications about Alertmanager alerts recorded as dead letters.",
+		},
+		[]string{"service"},
+	)
+)
+
+// MetricsRegistry records API server metrics
+type MetricsRegistry interface {
+	IncAlertDeliveriesCounter(service string, succeeded bool)
+	IncAlertDeadLettersCounter(service string)
+}
+
+func NewMetricsRegistry() *apiServerRegistry {
+	registry := &apiServerRegistry{
+		Registry:                prometheus.NewRegistry(),
+		alertDeliveriesCounter:  alertDeliveriesCounter,
+		alertDeadLettersCounter: alertDeadLettersCounter,
+	}
+	registry.MustRegister(alertDeliveriesCounter)
+	registry.MustRegister(alertDeadLettersCounter)
+	return registry
+}
+
+type apiServerRegistry struct {
+	*prometheus.Registry
+	alertDeliveriesCounter  *prometheus.CounterVec
+	alertDeadLettersCounter *prometheus.CounterVec
+}
+
+func (r *apiServerRegistry) IncAlertDeliveriesCounter(service string, succeeded bool) {
+	r.alertDeliveriesCounter.WithLabelValues(service, strconv.FormatBool(succeeded)).Inc()
+}
+
+func (r *apiServerRegistry) IncAlertDeadLettersCounter(service string) {
+	r.alertDeadLettersCounter.WithLabelValues(service).Inc()
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) IncAlertDeliveriesCounter(string, bool) {}
+
+func (noopMetrics) IncAlertDeadLettersCounter(string) {}
@@ -0,0 +1,264 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/argoproj-labs/argocd-notifications/expr"
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/shared/deadletter"
+	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+const (
+	// NotifyPath is the path of the endpoint that sends notifications
+	NotifyPath     = "/api/v1/notify"
+	maxRequestSize = 1024 * 1024
+)
+
+// NotifyRequest is the body of the notify request. Either the application name or the context must be specified
+type NotifyRequest struct {
+	// App is the name of the application in the controller namespace that is available as the 'app' template variable
+	App string `json:"app,omitempty"`
+	// Context holds raw template variables used instead of the application
+	Context map[string]interface{} `json:"context,omitempty"`
+	// Templates holds names of the templates used to generate the notification
+	Templates []string `json:"templates"`
+	// Recipients holds list of destinations in the <service>:<recipient> format
+	Recipients []string `json:"recipients"`
+}
+
+// DeliveryResult is the result of the notification delivery to the recipient
+type DeliveryResult struct {
+	Recipient string `json:"recipient"`
+	Error     string `json:"error,omitempty"`
+}
+
+// NotifyResponse is the body of the notify response
+type NotifyResponse struct {
+	Results []DeliveryResult `json:"results"`
+	// Failed is the number of notifications that have not been delivered
+	Failed int `json:"failed,omitempty"`
+}
+
+type Opts func(s *server)
+
+// WithDeadLetterStore records notifications about Alertmanager alerts that could not be delivered, so they might be
+// replayed using the deadletter command
+func WithDeadLetterStore(store deadletter.Store) Opts {
+	return func(s *server) {
+		s.deadLetterStore = store
+	}
+}
+
+// WithMetrics enables recording of the API server metrics
+func WithMetrics(metrics MetricsRegistry) Opts {
+	return func(s *server) {
+		s.metrics = metrics
+	}
+}
+
+// NewServer returns handler that sends notifications using the configured services and templates.
+// The getConfig function returns nil until the configuration is loaded
+func NewServer(appClient dynamic.ResourceInterface, appProjClient dynamic.ResourceInterface, getConfig func() *settings.Config, opts ...Opts) *server {
+	s := &server{appClient: appClient, appProjClient: appProjClient, getConfig: getConfig, mux: http.NewServeMux(), metrics: noopMetrics{}}
+	s.mux.HandleFunc(NotifyPath, s.notify)
+	s.mux.HandleFunc(InboundWebhookPathPrefix, s.receiveEvent)
+	s.mux.HandleFunc(AcknowledgePath, s.acknowledge)
+	s.mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	for i := range opts {
+		opts[i](s)
+	}
+	return s
+}
+
+type server struct {
+	appClient        dynamic.ResourceInterface
+	appProjClient    dynamic.ResourceInterface
+	alertmanagerOpts AlertmanagerOptions
+	getConfig        func() *settings.Config
+	mux              *http.ServeMux
+	deadLetterStore  deadletter.Store
+	metrics          MetricsRegistry
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// getSubscriptions returns destinations subscribed to the application by the same sources as the controller uses,
+// except namespace and NotificationSubscription resources that are not watched by the API server
+func (s *server) getSubscriptions(ctx context.Context, cfg *settings.Config, app *unstructured.Unstructured) pkg.Subscriptions {
+	var sources settings.SubscriptionSources
+	if projName, ok, _ := unstructured.NestedString(app.Object, "spec", "project"); ok && s.appProjClient != nil {
+		if proj, err := s.appProjClient.Get(ctx, projName, metav1.GetOptions{}); err == nil {
+			sources.Project = proj
+		} else if !apierr.IsNotFound(err) {
+			log.Warnf("Failed to get project %s: %v", projName, err)
+		}
+	}
+	return cfg.ResolveSubscriptions(app, sources)
+}
+
+func parseDestination(recipient string) (services.Destination, error) {
+	parts := strings.SplitN(recipient, ":", 2)
+	if parts[0] == "" {
+		return services.Destination{}, fmt.Errorf("invalid recipient '%s'", recipient)
+	}
+	dest := services.Destination{Service: parts[0]}
+	if len(parts) > 1 {
+		dest.Recipient = parts[1]
+	}
+	return dest, nil
+}
+
+func (req NotifyRequest) validate() error {
+	if req.App == "" && req.Context == nil {
+		return errors.New("either app or context must be specified")
+	}
+	if req.App != "" && req.Context != nil {
+		return errors.New("app and context cannot be specified at the same time")
+	}
+	if len(req.Templates) == 0 {
+		return errors.New("at least one template must be specified")
+	}
+	if len(req.Recipients) == 0 {
+		return errors.New("at least one recipient must be specified")
+	}
+	return nil
+}
+
+// requestError is returned if the request cannot be processed and holds the corresponding HTTP status
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+func newRequestError(status int, format string, args ...interface{}) error {
+	return &requestError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if reqErr, ok := err.(*requestError); ok {
+		status = reqErr.status
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// getApp returns the application with the specified name or the application passed in the raw context, so templates
+// written for applications keep working
+func (s *server) getApp(ctx context.Context, name string, rawContext map[string]interface{}) (*unstructured.Unstructured, error) {
+	if name == "" {
+		app := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		if obj, ok := rawContext["app"].(map[string]interface{}); ok {
+			app.Object = obj
+		}
+		return app, nil
+	}
+	app, err := s.appClient.Get(ctx, name, metav1.GetOptions{})
+	if apierr.IsNotFound(err) {
+		return nil, newRequestError(http.StatusNotFound, "application '%s' not found", name)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get application '%s': %v", name, err)
+	}
+	return app, nil
+}
+
+func getVars(cfg *settings.Config, app *unstructured.Unstructured, useApp bool, rawContext map[string]interface{}, dest services.Destination) map[string]interface{} {
+	vars := map[string]interface{}{"context": legacy.InjectLegacyVar(cfg.Context, dest.Service)}
+	if useApp {
+		vars["app"] = app.Object
+	} else {
+		for k, v := range rawContext {
+			vars[k] = v
+		}
+	}
+	return expr.Spawn(app, cfg.ArgoCDService, vars)
+}
+
+// send sends notifications requested by the notify request and returns delivery results. Returns an error if request is invalid
+func (s *server) send(ctx context.Context, req NotifyRequest) (*NotifyResponse, error) {
+	cfg := s.getConfig()
+	if cfg == nil {
+		return nil, newRequestError(http.StatusServiceUnavailable, "configuration is not loaded yet")
+	}
+	if err := req.validate(); err != nil {
+		return nil, newRequestError(http.StatusBadRequest, err.Error())
+	}
+	var destinations []services.Destination
+	for _, recipient := range req.Recipients {
+		dest, err := parseDestination(recipient)
+		if err != nil {
+			return nil, newRequestError(http.StatusBadRequest, err.Error())
+		}
+		destinations = append(destinations, dest)
+	}
+	app, err := s.getApp(ctx, req.App, req.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &NotifyResponse{}
+	for i, dest := range destinations {
+		result := DeliveryResult{Recipient: req.Recipients[i]}
+		if err := cfg.API.Send(getVars(cfg, app, req.App != "", req.Context, dest), req.Templates, dest); err != nil {
+			log.Errorf("Failed to notify %s: %v", req.Recipients[i], err)
+			result.Error = err.Error()
+			res.Failed++
+		}
+		res.Results = append(res.Results, result)
+	}
+	return res, nil
+}
+
+func (s *server) notify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req NotifyRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	res, err := s.send(r.Context(), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if res.Failed > 0 {
+		status = http.StatusBadGateway
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Warnf("Failed to write notify response: %v", err)
+	}
+}
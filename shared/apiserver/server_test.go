@@ -0,0 +1,114 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/mocks"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func newServer(api pkg.API, objects ...runtime.Object) *server {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+	cfg := &settings.Config{API: api, Context: map[string]string{"argocdUrl": "https://argocd.example.com"}}
+	return NewServer(k8s.NewAppClient(client, TestNamespace), k8s.NewAppProjClient(client, TestNamespace), func() *settings.Config {
+		return cfg
+	})
+}
+
+func notify(s http.Handler, req interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, NotifyPath, bytes.NewReader(data)))
+	return w
+}
+
+func TestNotify_App(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := mocks.NewMockAPI(ctrl)
+	app := NewApp("guestbook")
+	api.EXPECT().Send(gomock.Any(), []string{"app-deployed"}, services.Destination{Service: "slack", Recipient: "my-channel"}).
+		DoAndReturn(func(vars map[string]interface{}, _ []string, _ services.Destination) error {
+			assert.Equal(t, app.Object, vars["app"])
+			assert.Equal(t, "https://argocd.example.com", vars["context"].(map[string]string)["argocdUrl"])
+			return nil
+		})
+
+	w := notify(newServer(api, app), NotifyRequest{App: "guestbook", Templates: []string{"app-deployed"}, Recipients: []string{"slack:my-channel"}})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var res NotifyResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, []DeliveryResult{{Recipient: "slack:my-channel"}}, res.Results)
+}
+
+func TestNotify_RawContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := mocks.NewMockAPI(ctrl)
+	api.EXPECT().Send(gomock.Any(), []string{"build-finished"}, services.Destination{Service: "slack", Recipient: "ci"}).
+		DoAndReturn(func(vars map[string]interface{}, _ []string, _ services.Destination) error {
+			assert.Equal(t, "123", vars["build"])
+			return nil
+		})
+
+	w := notify(newServer(api), NotifyRequest{Context: map[string]interface{}{"build": "123"}, Templates: []string{"build-finished"}, Recipients: []string{"slack:ci"}})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestNotify_DeliveryFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := mocks.NewMockAPI(ctrl)
+	api.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("boom"))
+
+	w := notify(newServer(api, NewApp("guestbook")), NotifyRequest{App: "guestbook", Templates: []string{"app-deployed"}, Recipients: []string{"slack:ci"}})
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	var res NotifyResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, []DeliveryResult{{Recipient: "slack:ci", Error: "boom"}}, res.Results)
+}
+
+func TestNotify_AppNotFound(t *testing.T) {
+	w := notify(newServer(nil), NotifyRequest{App: "guestbook", Templates: []string{"app-deployed"}, Recipients: []string{"slack:ci"}})
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestNotify_InvalidRequest(t *testing.T) {
+	s := newServer(nil)
+	for _, req := range []NotifyRequest{
+		{Templates: []string{"app-deployed"}, Recipients: []string{"slack:ci"}},
+		{App: "guestbook", Context: map[string]interface{}{"build": "123"}, Templates: []string{"app-deployed"}, Recipients: []string{"slack:ci"}},
+		{App: "guestbook", Recipients: []string{"slack:ci"}},
+		{App: "guestbook", Templates: []string{"app-deployed"}},
+		{App: "guestbook", Templates: []string{"app-deployed"}, Recipients: []string{":ci"}},
+	} {
+		assert.Equal(t, http.StatusBadRequest, notify(s, req).Code)
+	}
+}
+
+func TestNotify_ConfigNotLoaded(t *testing.T) {
+	s := NewServer(nil, nil, func() *settings.Config {
+		return nil
+	})
+	w := notify(s, NotifyRequest{App: "guestbook"})
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,216 @@
+package argocd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIClient performs application operations using the Argo CD API server
+type APIClient interface {
+	// Sync starts the sync of the application to the target revision
+	Sync(ctx context.Context, app string) error
+	// Refresh forces the comparison of the application with the target revision
+	Refresh(ctx context.Context, app string) error
+	// Rollback syncs the application to the previously deployed revision
+	Rollback(ctx context.Context, app string) error
+}
+
+// AppInfoClient reads application data that is not stored in the application resource using the Argo CD API server
+type AppInfoClient interface {
+	// GetResourceTree returns the tree of the application resources including the resources created by controllers
+	GetResourceTree(ctx context.Context, app string) (map[string]interface{}, error)
+	// GetEvents returns Kubernetes events of the application
+	GetEvents(ctx context.Context, app string) ([]interface{}, error)
+	// GetSyncWindows returns sync windows assigned to the application and the currently active windows
+	GetSyncWindows(ctx context.Context, app string) (map[string]interface{}, error)
+	// GetLogs returns the last lines of the container logs of the application pod
+	GetLogs(ctx context.Context, app string, podName string, container string, tailLines int) (string, error)
+}
+
+// APIClientOptions holds settings of the Argo CD API server connection
+type APIClientOptions struct {
+	// ServerURL is the Argo CD API server URL, e.g. https://argocd-server.argocd.svc
+	ServerURL string
+	// AuthTokenFile is the path to the file with the Argo CD account token. The file is read on every request, so the token might be rotated
+	AuthTokenFile string
+	// Insecure disables the server certificate verification
+	Insecure bool
+}
+
+func NewAPIClient(opts APIClientOptions) *apiClient {
+	serverURL := opts.ServerURL
+	if !strings.HasPrefix(serverURL, "http://") && !strings.HasPrefix(serverURL, "https://") {
+		serverURL = "https://" + serverURL
+	}
+	return &apiClient{
+		serverURL:     strings.TrimSuffix(serverURL, "/"),
+		authTokenFile: opts.AuthTokenFile,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: &tls.Config{InsecureSkipVerify: opts.Insecure}},
+		},
+	}
+}
+
+type apiClient struct {
+	serverURL     string
+	authTokenFile string
+	client        *http.Client
+}
+
+func (c *apiClient) request(ctx context.Context, method string, path string, body interface{}) ([]byte, error) {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(method, c.serverURL+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if c.authTokenFile != "" {
+		token, err := ioutil.ReadFile(c.authTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Argo CD auth token: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Argo CD API returns gRPC gateway errors with the human readable message
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(data, &apiErr); err == nil && apiErr.Message != "" {
+			return nil, errors.New(apiErr.Message)
+		}
+		return nil, fmt.Errorf("Argo CD API responded with %d: %s", resp.StatusCode, string(data))
+	}
+	return data, nil
+}
+
+func (c *apiClient) do(ctx context.Context, method string, path string, body interface{}, res interface{}) error {
+	data, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if res != nil {
+		return json.Unmarshal(data, res)
+	}
+	return nil
+}
+
+func appPath(app string) string {
+	return "/api/v1/applications/" + url.PathEscape(app)
+}
+
+func (c *apiClient) Sync(ctx context.Context, app string) error {
+	return c.do(ctx, http.MethodPost, appPath(app)+"/sync", map[string]string{"name": app}, nil)
+}
+
+func (c *apiClient) Refresh(ctx context.Context, app string) error {
+	return c.do(ctx, http.MethodGet, appPath(app)+"?refresh=normal", nil, nil)
+}
+
+func (c *apiClient) Rollback(ctx context.Context, app string) error {
+	var res struct {
+		Status struct {
+			History []struct {
+				ID int64 `json:"id"`
+			} `json:"history"`
+		} `json:"status"`
+	}
+	if err := c.do(ctx, http.MethodGet, appPath(app), nil, &res); err != nil {
+		return err
+	}
+	// the last history item is the currently deployed revision
+	history := res.Status.History
+	if len(history) < 2 {
+		return fmt.Errorf("application %s has no previously deployed revisions", app)
+	}
+	return c.do(ctx, http.MethodPost, appPath(app)+"/rollback", map[string]interface{}{"name": app, "id": history[len(history)-2].ID}, nil)
+}
+
+func (c *apiClient) GetResourceTree(ctx context.Context, app string) (map[string]interface{}, error) {
+	var res map[string]interface{}
+	if err := c.do(ctx, http.MethodGet, appPath(app)+"/resource-tree", nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *apiClient) GetEvents(ctx context.Context, app string) ([]interface{}, error) {
+	var res struct {
+		Items []interface{} `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, appPath(app)+"/events", nil, &res); err != nil {
+		return nil, err
+	}
+	return res.Items, nil
+}
+
+func (c *apiClient) GetSyncWindows(ctx context.Context, app string) (map[string]interface{}, error) {
+	var res map[string]interface{}
+	if err := c.do(ctx, http.MethodGet, appPath(app)+"/syncwindows", nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *apiClient) GetLogs(ctx context.Context, app string, podName string, container string, tailLines int) (string, error) {
+	query := url.Values{"follow": {"false"}, "tailLines": {strconv.Itoa(tailLines)}}
+	if container != "" {
+		query.Set("container", container)
+	}
+	data, err := c.request(ctx, http.MethodGet, appPath(app)+"/pods/"+url.PathEscape(podName)+"/logs?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	// logs are streamed as JSON entries separated by new lines
+	var lines []string
+	for _, entry := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(entry)) == 0 {
+			continue
+		}
+		var logEntry struct {
+			Result struct {
+				Content string `json:"content"`
+			} `json:"result"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(entry, &logEntry); err != nil {
+			return "", err
+		}
+		if logEntry.Error != nil {
+			return "", errors.New(logEntry.Error.Message)
+		}
+		lines = append(lines, logEntry.Result.Content)
+	}
+	return strings.Join(lines, "\n"), nil
+}
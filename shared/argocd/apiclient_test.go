@@ -0,0 +1,119 @@
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAPIClient(t *testing.T, handler http.HandlerFunc) (*apiClient, func()) {
+	server := httptest.NewServer(handler)
+	tokenFile, err := ioutil.TempFile("", "token")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, _ = tokenFile.WriteString("my-token\n")
+	_ = tokenFile.Close()
+	return NewAPIClient(APIClientOptions{ServerURL: server.URL, AuthTokenFile: tokenFile.Name()}), func() {
+		server.Close()
+		_ = os.Remove(tokenFile.Name())
+	}
+}
+
+func TestAPIClient_Sync(t *testing.T) {
+	var path, authorization string
+	client, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		authorization = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte("{}"))
+	})
+	defer cleanup()
+
+	err := client.Sync(context.Background(), "guestbook")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/v1/applications/guestbook/sync", path)
+	assert.Equal(t, "Bearer my-token", authorization)
+}
+
+func TestAPIClient_Rollback(t *testing.T) {
+	var rollbackRequest map[string]interface{}
+	client, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/applications/guestbook":
+			_, _ = w.Write([]byte(`{"status": {"history": [{"id": 1}, {"id": 2}, {"id": 3}]}}`))
+		case "/api/v1/applications/guestbook/rollback":
+			_ = json.NewDecoder(r.Body).Decode(&rollbackRequest)
+			_, _ = w.Write([]byte("{}"))
+		}
+	})
+	defer cleanup()
+
+	err := client.Rollback(context.Background(), "guestbook")
+
+	assert.NoError(t, err)
+	assert.Equal(t, float64(2), rollbackRequest["id"])
+}
+
+func TestAPIClient_Error(t *testing.T) {
+	client, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error": "permission denied", "message": "permission denied"}`))
+	})
+	defer cleanup()
+
+	err := client.Refresh(context.Background(), "guestbook")
+
+	assert.EqualError(t, err, "permission denied")
+}
+
+func TestAPIClient_GetEvents(t *testing.T) {
+	var path string
+	client, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_, _ = w.Write([]byte(`{"metadata": {}, "items": [{"reason": "ResourceUpdated"}]}`))
+	})
+	defer cleanup()
+
+	events, err := client.GetEvents(context.Background(), "guestbook")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/v1/applications/guestbook/events", path)
+	assert.Equal(t, []interface{}{map[string]interface{}{"reason": "ResourceUpdated"}}, events)
+}
+
+func TestAPIClient_GetLogs(t *testing.T) {
+	var path, query string
+	client, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		query = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{"result": {"content": "starting"}}
+{"result": {"content": "listening on :8080"}}
+`))
+	})
+	defer cleanup()
+
+	logs, err := client.GetLogs(context.Background(), "guestbook", "guestbook-ui-1", "ui", 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/v1/applications/guestbook/pods/guestbook-ui-1/logs", path)
+	assert.Equal(t, "container=ui&follow=false&tailLines=10", query)
+	assert.Equal(t, "starting\nlistening on :8080", logs)
+}
+
+func TestAPIClient_GetLogsError(t *testing.T) {
+	client, cleanup := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"error": {"message": "pods \"guestbook-ui-1\" not found"}}`))
+	})
+	defer cleanup()
+
+	_, err := client.GetLogs(context.Background(), "guestbook", "guestbook-ui-1", "", 10)
+
+	assert.EqualError(t, err, `pods "guestbook-ui-1" not found`)
+}
@@ -0,0 +1,160 @@
+package argocd
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/argoproj-labs/argocd-notifications/expr/shared"
+)
+
+const (
+	CacheResultHit  = "hit"
+	CacheResultMiss = "miss"
+)
+
+var cacheRequestsCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "argocd_notifications_repo_server_cache_requests_total",
+		Help: "Number of repo server requests served from the cache or forwarded to the repo server.",
+	},
+	[]string{"method", "result"},
+)
+
+// RegisterCacheMetrics registers repo server cache metrics in the specified registry
+func RegisterCacheMetrics(registerer prometheus.Registerer) {
+	registerer.MustRegister(cacheRequestsCounter)
+}
+
+// Cache stores JSON serialized repo server responses
+type Cache interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, data []byte) error
+}
+
+type memoryCacheEntry struct {
+	key       string
+	data      []byte
+	expiresAt time.Time
+}
+
+// NewMemoryCache returns the LRU cache that keeps up to size entries for the specified ttl
+func NewMemoryCache(size int, ttl time.Duration) *memoryCache {
+	return &memoryCache{size: size, ttl: ttl, entries: list.New(), index: map[string]*list.Element{}, now: time.Now}
+}
+
+type memoryCache struct {
+	size    int
+	ttl     time.Duration
+	now     func() time.Time
+	lock    sync.Mutex
+	entries *list.List
+	index   map[string]*list.Element
+}
+
+func (c *memoryCache) Get(key string) ([]byte, bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	item, ok := c.index[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := item.Value.(*memoryCacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.entries.Remove(item)
+		delete(c.index, key)
+		return nil, false, nil
+	}
+	c.entries.MoveToFront(item)
+	return entry.data, true, nil
+}
+
+func (c *memoryCache) Set(key string, data []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	expiresAt := c.now().Add(c.ttl)
+	if item, ok := c.index[key]; ok {
+		entry := item.Value.(*memoryCacheEntry)
+		entry.data = data
+		entry.expiresAt = expiresAt
+		c.entries.MoveToFront(item)
+		return nil
+	}
+	c.index[key] = c.entries.PushFront(&memoryCacheEntry{key: key, data: data, expiresAt: expiresAt})
+	for c.entries.Len() > c.size {
+		oldest := c.entries.Back()
+		c.entries.Remove(oldest)
+		delete(c.index, oldest.Value.(*memoryCacheEntry).key)
+	}
+	return nil
+}
+
+// NewCachedService returns the service that caches repo server responses. Cache errors are logged and the request is
+// forwarded to the repo server, so the cache outage does not break notifications
+func NewCachedService(svc Service, cache Cache) *cachedService {
+	return &cachedService{Service: svc, cache: cache}
+}
+
+type cachedService struct {
+	Service
+	cache Cache
+}
+
+func (svc *cachedService) get(method string, key string, val interface{}, load func() (interface{}, error)) error {
+	key = fmt.Sprintf("%s|%s", method, key)
+	if data, ok, err := svc.cache.Get(key); err != nil {
+		log.Warnf("Failed to get %s from repo server cache: %v", method, err)
+	} else if ok {
+		if err := json.Unmarshal(data, val); err == nil {
+			cacheRequestsCounter.WithLabelValues(method, CacheResultHit).Inc()
+			return nil
+		}
+	}
+	cacheRequestsCounter.WithLabelValues(method, CacheResultMiss).Inc()
+	res, err := load()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	if err := svc.cache.Set(key, data); err != nil {
+		log.Warnf("Failed to store %s in repo server cache: %v", method, err)
+	}
+	return json.Unmarshal(data, val)
+}
+
+func (svc *cachedService) GetCommitMetadata(ctx context.Context, repoURL string, commitSHA string) (*shared.CommitMetadata, error) {
+	var res shared.CommitMetadata
+	err := svc.get("GetCommitMetadata", repoURL+"|"+commitSHA, &res, func() (interface{}, error) {
+		return svc.Service.GetCommitMetadata(ctx, repoURL, commitSHA)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (svc *cachedService) GetAppDetails(ctx context.Context, appSource *v1alpha1.ApplicationSource) (*shared.AppDetail, error) {
+	// the source includes the repo URL, the revision and all parameters that affect the application details
+	source, err := json.Marshal(appSource)
+	if err != nil {
+		return nil, err
+	}
+	var res shared.AppDetail
+	err = svc.get("GetAppDetails", string(source), &res, func() (interface{}, error) {
+		return svc.Service.GetAppDetails(ctx, appSource)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
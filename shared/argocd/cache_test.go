@@ -0,0 +1,81 @@
+package argocd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/expr/shared"
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd/mocks"
+)
+
+func TestMemoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewMemoryCache(2, time.Hour)
+	assert.NoError(t, cache.Set("a", []byte("1")))
+	assert.NoError(t, cache.Set("b", []byte("2")))
+	_, ok, _ := cache.Get("a")
+	assert.True(t, ok)
+	assert.NoError(t, cache.Set("c", []byte("3")))
+
+	_, ok, _ = cache.Get("b")
+	assert.False(t, ok)
+	data, ok, _ := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", string(data))
+	_, ok, _ = cache.Get("c")
+	assert.True(t, ok)
+}
+
+func TestMemoryCache_Expires(t *testing.T) {
+	cache := NewMemoryCache(10, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	assert.NoError(t, cache.Set("a", []byte("1")))
+
+	cache.now = func() time.Time { return now.Add(2 * time.Minute) }
+	_, ok, _ := cache.Get("a")
+	assert.False(t, ok)
+}
+
+func TestCachedService_GetCommitMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	svc := mocks.NewMockService(ctrl)
+	svc.EXPECT().GetCommitMetadata(gomock.Any(), "https://github.com/argoproj/argo-cd.git", "abc").
+		Return(&shared.CommitMetadata{Message: "fix bug", Author: "alice"}, nil).Times(1)
+
+	cached := NewCachedService(svc, NewMemoryCache(10, time.Hour))
+	for i := 0; i < 2; i++ {
+		metadata, err := cached.GetCommitMetadata(context.Background(), "https://github.com/argoproj/argo-cd.git", "abc")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "fix bug", metadata.Message)
+			assert.Equal(t, "alice", metadata.Author)
+		}
+	}
+}
+
+func TestCachedService_ErrorsAreNotCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	svc := mocks.NewMockService(ctrl)
+	source := &v1alpha1.ApplicationSource{RepoURL: "https://github.com/argoproj/argo-cd.git", TargetRevision: "HEAD"}
+	svc.EXPECT().GetAppDetails(gomock.Any(), source).Return(nil, errors.New("repo server is not available")).Times(1)
+	svc.EXPECT().GetAppDetails(gomock.Any(), source).Return(&shared.AppDetail{Type: "Helm"}, nil).Times(1)
+
+	cached := NewCachedService(svc, NewMemoryCache(10, time.Hour))
+	_, err := cached.GetAppDetails(context.Background(), source)
+	assert.Error(t, err)
+	details, err := cached.GetAppDetails(context.Background(), source)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Helm", details.Type)
+	}
+	details, err = cached.GetAppDetails(context.Background(), source)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Helm", details.Type)
+	}
+}
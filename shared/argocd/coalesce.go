@@ -0,0 +1,49 @@
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/argoproj-labs/argocd-notifications/expr/shared"
+)
+
+// NewCoalescingService returns the service that shares one repo server round trip between concurrent requests of the
+// same data, e.g. when a monorepo push syncs many applications at the same revision
+func NewCoalescingService(svc Service) *coalescingService {
+	return &coalescingService{Service: svc}
+}
+
+type coalescingService struct {
+	Service
+	group singleflight.Group
+}
+
+func (svc *coalescingService) GetCommitMetadata(ctx context.Context, repoURL string, commitSHA string) (*shared.CommitMetadata, error) {
+	res, err, _ := svc.group.Do("GetCommitMetadata|"+repoURL+"|"+commitSHA, func() (interface{}, error) {
+		return svc.Service.GetCommitMetadata(ctx, repoURL, commitSHA)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// every caller gets its own copy, so the shared response cannot be modified by other callers
+	metadata := *res.(*shared.CommitMetadata)
+	return &metadata, nil
+}
+
+func (svc *coalescingService) GetAppDetails(ctx context.Context, appSource *v1alpha1.ApplicationSource) (*shared.AppDetail, error) {
+	source, err := json.Marshal(appSource)
+	if err != nil {
+		return nil, err
+	}
+	res, err, _ := svc.group.Do("GetAppDetails|"+string(source), func() (interface{}, error) {
+		return svc.Service.GetAppDetails(ctx, appSource)
+	})
+	if err != nil {
+		return nil, err
+	}
+	details := *res.(*shared.AppDetail)
+	return &details, nil
+}
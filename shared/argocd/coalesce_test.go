@@ -0,0 +1,47 @@
+package argocd
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/expr/shared"
+)
+
+type slowService struct {
+	Service
+	calls int32
+}
+
+func (svc *slowService) GetCommitMetadata(ctx context.Context, repoURL string, commitSHA string) (*shared.CommitMetadata, error) {
+	atomic.AddInt32(&svc.calls, 1)
+	time.Sleep(100 * time.Millisecond)
+	return &shared.CommitMetadata{Message: commitSHA}, nil
+}
+
+func TestCoalescingService_GetCommitMetadata(t *testing.T) {
+	svc := &slowService{}
+	coalescing := NewCoalescingService(svc)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			metadata, err := coalescing.GetCommitMetadata(context.Background(), "https://github.com/argoproj/argo-cd.git", "abc")
+			if assert.NoError(t, err) {
+				assert.Equal(t, "abc", metadata.Message)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&svc.calls))
+
+	_, err := coalescing.GetCommitMetadata(context.Background(), "https://github.com/argoproj/argo-cd.git", "def")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&svc.calls))
+}
@@ -0,0 +1,73 @@
+package argocd
+
+import (
+	"context"
+
+	"github.com/argoproj/argo-cd/util/rbac"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// RBACConfigMapName is the name of the config map with Argo CD RBAC policies
+	RBACConfigMapName = "argocd-rbac-cm"
+
+	ResourceApplications = "applications"
+	ResourceProjects     = "projects"
+
+	ActionGet    = "get"
+	ActionSync   = "sync"
+	ActionUpdate = "update"
+)
+
+// builtinPolicy mirrors the built-in Argo CD roles which are available even if the RBAC config map is empty. The
+// policy is not read from the Argo CD assets, which are bundled with the Argo CD server binary
+const builtinPolicy = `
+p, role:readonly, applications, get, */*, allow
+p, role:readonly, certificates, get, *, allow
+p, role:readonly, clusters, get, *, allow
+p, role:readonly, repositories, get, *, allow
+p, role:readonly, projects, get, *, allow
+p, role:readonly, accounts, get, *, allow
+p, role:readonly, gpgkeys, get, *, allow
+p, role:admin, *, *, *, allow
+g, role:admin, role:readonly
+g, admin, role:admin
+`
+
+// Enforcer checks if the subject is allowed to perform the action on the Argo CD resource
+type Enforcer interface {
+	Enforce(ctx context.Context, subject string, resource string, action string, object string) (bool, error)
+}
+
+// NewRBACEnforcer returns the Argo CD RBAC enforcer that evaluates policies from the Argo CD RBAC config map. The
+// config map is watched until the context is done, so policy changes are applied without restart
+func NewRBACEnforcer(ctx context.Context, clientset kubernetes.Interface, namespace string) *rbacEnforcer {
+	enforcer := rbac.NewEnforcer(clientset, namespace, RBACConfigMapName, nil)
+	if err := enforcer.SetBuiltinPolicy(builtinPolicy); err != nil {
+		// the built-in policy is a constant, so the error means the bug
+		panic(err)
+	}
+	go func() {
+		// policies of the config map are not applied until it is loaded, so every action is denied during the start
+		if err := enforcer.RunPolicyLoader(ctx, func(_ *v1.ConfigMap) error {
+			log.Info("Argo CD RBAC policy is updated")
+			return nil
+		}); err != nil {
+			log.Errorf("Failed to watch Argo CD RBAC config map: %v", err)
+		}
+	}()
+	return &rbacEnforcer{enforcer: enforcer}
+}
+
+type rbacEnforcer struct {
+	enforcer *rbac.Enforcer
+}
+
+func (e *rbacEnforcer) Enforce(_ context.Context, subject string, resource string, action string, object string) (bool, error) {
+	if subject == "" {
+		return false, nil
+	}
+	return e.enforcer.Enforce(subject, resource, action, object), nil
+}
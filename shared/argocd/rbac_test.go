@@ -0,0 +1,50 @@
+package argocd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRBACEnforcer_Enforce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clientset := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: RBACConfigMapName, Namespace: "argocd"},
+		Data: map[string]string{
+			"policy.csv": `
+p, role:team-a, applications, sync, team-a/*, allow
+p, role:team-a, applications, sync, team-a/prod-*, deny
+g, alice, role:team-a
+`,
+			"policy.default": "role:readonly",
+		},
+	})
+	enforcer := NewRBACEnforcer(ctx, clientset, "argocd")
+
+	assert.Eventually(t, func() bool {
+		allowed, _ := enforcer.Enforce(ctx, "alice", ResourceApplications, ActionSync, "team-a/guestbook")
+		return allowed
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for _, testCase := range []struct {
+		subject string
+		action  string
+		object  string
+		allowed bool
+	}{
+		{"alice", ActionSync, "team-a/prod-guestbook", false},
+		{"bob", ActionSync, "team-a/guestbook", false},
+		{"bob", ActionGet, "team-a/guestbook", true},
+		{"", ActionGet, "team-a/guestbook", false},
+	} {
+		allowed, err := enforcer.Enforce(ctx, testCase.subject, ResourceApplications, testCase.action, testCase.object)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.allowed, allowed, testCase)
+	}
+}
@@ -0,0 +1,104 @@
+package argocd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	redisKeyPrefix   = "argocd-notifications|"
+	redisDialTimeout = 5 * time.Second
+	redisIOTimeout   = 3 * time.Second
+)
+
+// RedisOptions holds settings of the Redis connection
+type RedisOptions struct {
+	// Address is the Redis address, e.g. argocd-redis:6379
+	Address string
+	// Password is the optional Redis password
+	Password string
+	// TLS enables TLS connections to Redis
+	TLS bool
+	// InsecureSkipVerify disables verification of the Redis server certificate
+	InsecureSkipVerify bool
+	// CACertificate is the optional path to the PEM file with certificates used to verify the Redis server certificate
+	CACertificate string
+	// PoolSize is the max number of connections. Defaults to 10 connections per CPU
+	PoolSize int
+}
+
+func (o RedisOptions) tlsConfig() (*tls.Config, error) {
+	if !o.TLS {
+		return nil, nil
+	}
+	cfg := &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify}
+	if o.CACertificate != "" {
+		data, err := ioutil.ReadFile(o.CACertificate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Redis CA certificate: %v", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", o.CACertificate)
+		}
+	}
+	return cfg, nil
+}
+
+// NewRedisClient returns the Redis client with the connection pool configured using the specified options
+func NewRedisClient(opts RedisOptions) (*redis.Client, error) {
+	tlsConfig, err := opts.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	return redis.NewClient(&redis.Options{
+		Addr:         opts.Address,
+		Password:     opts.Password,
+		TLSConfig:    tlsConfig,
+		PoolSize:     opts.PoolSize,
+		DialTimeout:  redisDialTimeout,
+		ReadTimeout:  redisIOTimeout,
+		WriteTimeout: redisIOTimeout,
+	}), nil
+}
+
+// NewRedisCache returns the cache that stores entries in Redis, so the cache is shared by all controller replicas
+func NewRedisCache(opts RedisOptions, ttl time.Duration) (*redisCache, error) {
+	client, err := NewRedisClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &redisCache{client: client, ttl: ttl}, nil
+}
+
+type redisCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func (c *redisCache) Get(key string) ([]byte, bool, error) {
+	res, err := c.client.Get(context.Background(), redisKeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return res, true, nil
+}
+
+func (c *redisCache) Set(key string, data []byte) error {
+	return c.client.Set(context.Background(), redisKeyPrefix+key, data, c.ttl).Err()
+}
+
+// Close closes connections of the pool
+func (c *redisCache) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,137 @@
+package argocd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startFakeRedis starts the server that supports AUTH, GET and SET commands of the Redis protocol
+func startFakeRedis(t *testing.T, password string) (string, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var lock sync.Mutex
+	data := map[string]string{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer func() {
+					_ = conn.Close()
+				}()
+				reader := bufio.NewReader(conn)
+				authenticated := password == ""
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+					var args []string
+					for i := 0; i < count; i++ {
+						sizeLine, err := reader.ReadString('\n')
+						if err != nil || len(sizeLine) < 2 {
+							return
+						}
+						size, _ := strconv.Atoi(strings.TrimSpace(sizeLine[1:]))
+						arg := make([]byte, size+2)
+						_, _ = io.ReadFull(reader, arg)
+						args = append(args, string(arg[:size]))
+					}
+					if len(args) == 0 {
+						return
+					}
+					lock.Lock()
+					switch command := strings.ToUpper(args[0]); {
+					case command == "AUTH" && args[len(args)-1] == password:
+						authenticated = true
+						_, _ = conn.Write([]byte("+OK\r\n"))
+					case !authenticated:
+						_, _ = conn.Write([]byte("-NOAUTH Authentication required.\r\n"))
+					case command == "SET":
+						data[args[1]] = args[2]
+						_, _ = conn.Write([]byte("+OK\r\n"))
+					case command == "GET":
+						if val, ok := data[args[1]]; ok {
+							_, _ = conn.Write([]byte(fmt.Sprintf("$%d\r\n%s\r\n", len(val), val)))
+						} else {
+							_, _ = conn.Write([]byte("$-1\r\n"))
+						}
+					default:
+						_, _ = conn.Write([]byte("-ERR unknown command\r\n"))
+					}
+					lock.Unlock()
+				}
+			}(conn)
+		}
+	}()
+	return listener.Addr().String(), func() {
+		_ = listener.Close()
+	}
+}
+
+func TestRedisCache(t *testing.T) {
+	address, stop := startFakeRedis(t, "my-password")
+	defer stop()
+
+	cache, err := NewRedisCache(RedisOptions{Address: address, Password: "my-password"}, time.Minute)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = cache.Close()
+	}()
+	_, ok, err := cache.Get("a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, cache.Set("a", []byte(`{"message":"multi\r\nline"}`)))
+	data, ok, err := cache.Get("a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, `{"message":"multi\r\nline"}`, string(data))
+}
+
+func TestRedisCache_InvalidPassword(t *testing.T) {
+	address, stop := startFakeRedis(t, "my-password")
+	defer stop()
+
+	cache, err := NewRedisCache(RedisOptions{Address: address, Password: "wrong"}, time.Minute)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = cache.Close()
+	}()
+	_, _, err = cache.Get("a")
+	assert.Error(t, err)
+}
+
+func TestRedisCache_InvalidCACertificate(t *testing.T) {
+	file, err := ioutil.TempFile("", "redis-ca")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+	_ = file.Close()
+
+	_, err = NewRedisCache(RedisOptions{Address: "localhost:6379", TLS: true, CACertificate: file.Name()}, time.Minute)
+	assert.Error(t, err)
+}
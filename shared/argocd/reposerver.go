@@ -0,0 +1,134 @@
+package argocd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/argoproj/argo-cd/reposerver/apiclient"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	grpc_retry "github.com/grpc-ecosystem/go-grpc-middleware/retry"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	repoServerTimeoutSeconds = 5
+	// repoServerMaxMessageSize matches the max message size of the Argo CD repo server
+	repoServerMaxMessageSize = 100 * 1024 * 1024
+)
+
+// RepoServerOptions holds settings of the connection to the Argo CD repo server
+type RepoServerOptions struct {
+	// Address is the repo server address, e.g. argocd-repo-server:8081
+	Address string
+	// Plaintext disables TLS
+	Plaintext bool
+	// StrictTLS enables verification of the repo server certificate
+	StrictTLS bool
+	// CAFile is the path to the CA bundle used to verify the repo server certificate. System roots are used if not specified
+	CAFile string
+	// ClientCertFile and ClientKeyFile are paths to the client certificate and key presented to the repo server
+	ClientCertFile string
+	ClientKeyFile  string
+}
+
+// AddRepoServerFlags adds flags of the repo server connection to the command
+func AddRepoServerFlags(cmd *cobra.Command, opts *RepoServerOptions) {
+	cmd.Flags().StringVar(&opts.Address, "argocd-repo-server", "argocd-repo-server:8081", "Argo CD repo server address")
+	cmd.Flags().BoolVar(&opts.Plaintext, "argocd-repo-server-plaintext", true, "Use plaintext connection to the Argo CD repo server. Set to false if the repo server uses TLS.")
+	cmd.Flags().BoolVar(&opts.StrictTLS, "argocd-repo-server-strict-tls", false, "Verify the Argo CD repo server TLS certificate.")
+	cmd.Flags().StringVar(&opts.CAFile, "argocd-repo-server-ca", "", "Path to the CA bundle used to verify the Argo CD repo server certificate. Requires strict TLS.")
+	cmd.Flags().StringVar(&opts.ClientCertFile, "argocd-repo-server-client-cert", "", "Path to the client certificate presented to the Argo CD repo server.")
+	cmd.Flags().StringVar(&opts.ClientKeyFile, "argocd-repo-server-client-key", "", "Path to the client private key.")
+}
+
+// Validate returns an error if options are inconsistent
+func (o RepoServerOptions) Validate() error {
+	if (o.ClientCertFile == "") != (o.ClientKeyFile == "") {
+		return errors.New("both repo server client certificate and key must be specified")
+	}
+	if o.Plaintext && (o.StrictTLS || o.CAFile != "" || o.ClientCertFile != "") {
+		return errors.New("repo server TLS settings require --argocd-repo-server-plaintext=false")
+	}
+	if o.CAFile != "" && !o.StrictTLS {
+		return errors.New("repo server CA requires --argocd-repo-server-strict-tls")
+	}
+	return nil
+}
+
+// TLSConfig returns TLS configuration of the repo server connection. The client certificate is loaded on every
+// handshake, so rotated certificates are picked up without restart.
+func (o RepoServerOptions) TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: !o.StrictTLS}
+	if o.CAFile != "" {
+		caData, err := ioutil.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no certificates found in %s", o.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if o.ClientCertFile != "" {
+		if _, err := tls.LoadX509KeyPair(o.ClientCertFile, o.ClientKeyFile); err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = func(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(o.ClientCertFile, o.ClientKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		}
+	}
+	return tlsConfig, nil
+}
+
+// newRepoServerClient returns the repo server client that uses the same retry settings as the Argo CD clientset
+func newRepoServerClient(opts RepoServerOptions) (io.Closer, apiclient.RepoServerServiceClient, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if opts.Plaintext {
+		return apiclient.NewRepoServerClientset(opts.Address, repoServerTimeoutSeconds).NewRepoServerClient()
+	}
+	tlsConfig, err := opts.TLSConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	retryOpts := []grpc_retry.CallOption{
+		grpc_retry.WithMax(3),
+		grpc_retry.WithBackoff(grpc_retry.BackoffLinear(1000 * time.Millisecond)),
+	}
+	conn, err := grpc.Dial(opts.Address,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithStreamInterceptor(grpc_retry.StreamClientInterceptor(retryOpts...)),
+		grpc.WithUnaryInterceptor(grpc_middleware.ChainUnaryClient(
+			grpc_retry.UnaryClientInterceptor(retryOpts...),
+			withTimeout(repoServerTimeoutSeconds*time.Second),
+		)),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(repoServerMaxMessageSize), grpc.MaxCallSendMsgSize(repoServerMaxMessageSize)),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, apiclient.NewRepoServerServiceClient(conn), nil
+}
+
+// withTimeout returns interceptor that limits the duration of every unary call
+func withTimeout(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,56 @@
+package argocd
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoServerOptions_Validate(t *testing.T) {
+	assert.NoError(t, RepoServerOptions{Plaintext: true}.Validate())
+	assert.NoError(t, RepoServerOptions{StrictTLS: true, CAFile: "ca.crt", ClientCertFile: "tls.crt", ClientKeyFile: "tls.key"}.Validate())
+	assert.Error(t, RepoServerOptions{Plaintext: true, StrictTLS: true}.Validate())
+	assert.Error(t, RepoServerOptions{ClientCertFile: "tls.crt"}.Validate())
+	assert.Error(t, RepoServerOptions{CAFile: "ca.crt"}.Validate())
+}
+
+func TestRepoServerOptions_TLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "reposerver")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	caFile := filepath.Join(dir, "ca.crt")
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if !assert.NoError(t, ioutil.WriteFile(caFile, caData, 0600)) {
+		return
+	}
+
+	tlsConfig, err := RepoServerOptions{}.TLSConfig()
+	if assert.NoError(t, err) {
+		assert.True(t, tlsConfig.InsecureSkipVerify)
+		assert.Nil(t, tlsConfig.RootCAs)
+	}
+
+	tlsConfig, err = RepoServerOptions{StrictTLS: true, CAFile: caFile}.TLSConfig()
+	if assert.NoError(t, err) {
+		assert.False(t, tlsConfig.InsecureSkipVerify)
+		assert.NotNil(t, tlsConfig.RootCAs)
+	}
+
+	_, err = RepoServerOptions{StrictTLS: true, CAFile: filepath.Join(dir, "missing.crt")}.TLSConfig()
+	assert.Error(t, err)
+
+	_, err = RepoServerOptions{ClientCertFile: caFile, ClientKeyFile: filepath.Join(dir, "missing.key")}.TLSConfig()
+	assert.Error(t, err)
+}
@@ -19,11 +19,10 @@ type Service interface {
 	GetAppDetails(ctx context.Context, appSource *v1alpha1.ApplicationSource) (*shared.AppDetail, error)
 }
 
-func NewArgoCDService(clientset kubernetes.Interface, namespace string, repoServerAddress string) (*argoCDService, error) {
+func NewArgoCDService(clientset kubernetes.Interface, namespace string, repoServer RepoServerOptions) (*argoCDService, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	settingsMgr := settings.NewSettingsManager(ctx, clientset, namespace)
-	repoClientset := apiclient.NewRepoServerClientset(repoServerAddress, 5)
-	closer, repoClient, err := repoClientset.NewRepoServerClient()
+	closer, repoClient, err := newRepoServerClient(repoServer)
 	if err != nil {
 		cancel()
 		return nil, err
@@ -0,0 +1,97 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// requestTimeout is the max time of the request to the AWS API
+const requestTimeout = 30 * time.Second
+
+// NewSession returns the AWS session that uses the default credentials chain: AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY
+// environment variables, the role of the service account (IRSA) configured using AWS_ROLE_ARN and
+// AWS_WEB_IDENTITY_TOKEN_FILE variables, shared config files and the instance role. Region defaults to AWS_REGION
+// environment variable
+func NewSession(region string) (*session.Session, error) {
+	cfg := awssdk.NewConfig()
+	if region != "" {
+		cfg = cfg.WithRegion(region)
+	}
+	return session.NewSessionWithOptions(session.Options{Config: *cfg, SharedConfigState: session.SharedConfigEnable})
+}
+
+// splitField splits reference in the `<name>#<field>` format
+func splitField(key string) (string, string) {
+	if i := strings.LastIndex(key, "#"); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return key, ""
+}
+
+// NewSecretsManagerProvider returns provider of AWS Secrets Manager secrets. References have the `<secret-id>#<field>` format;
+// the field is optional and selects the value of the JSON secret
+func NewSecretsManagerProvider(sess client.ConfigProvider) *secretsManagerProvider {
+	return &secretsManagerProvider{api: secretsmanager.New(sess)}
+}
+
+type secretsManagerProvider struct {
+	api secretsmanageriface.SecretsManagerAPI
+}
+
+func (p *secretsManagerProvider) GetSecret(key string) (string, error) {
+	secretID, field := splitField(key)
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	output, err := p.api.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{SecretId: awssdk.String(secretID)})
+	if err != nil {
+		return "", err
+	}
+	if field == "" {
+		return awssdk.StringValue(output.SecretString), nil
+	}
+	values := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(awssdk.StringValue(output.SecretString)), &values); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object: %v", secretID, err)
+	}
+	val, ok := values[field]
+	if !ok {
+		return "", fmt.Errorf("secret %s does not have field %s", secretID, field)
+	}
+	if str, ok := val.(string); ok {
+		return str, nil
+	}
+	return fmt.Sprintf("%v", val), nil
+}
+
+// NewParameterStoreProvider returns provider of AWS Systems Manager Parameter Store parameters. SecureString parameters are decrypted
+func NewParameterStoreProvider(sess client.ConfigProvider) *parameterStoreProvider {
+	return &parameterStoreProvider{api: ssm.New(sess)}
+}
+
+type parameterStoreProvider struct {
+	api ssmiface.SSMAPI
+}
+
+func (p *parameterStoreProvider) GetSecret(key string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	output, err := p.api.GetParameterWithContext(ctx, &ssm.GetParameterInput{Name: awssdk.String(key), WithDecryption: awssdk.Bool(true)})
+	if err != nil {
+		return "", err
+	}
+	if output.Parameter == nil {
+		return "", fmt.Errorf("parameter %s is not found", key)
+	}
+	return awssdk.StringValue(output.Parameter.Value), nil
+}
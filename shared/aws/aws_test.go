@@ -0,0 +1,73 @@
+package aws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSession(t *testing.T, server *httptest.Server) *session.Session {
+	sess, err := session.NewSession(awssdk.NewConfig().
+		WithRegion("us-east-1").
+		WithEndpoint(server.URL).
+		WithCredentials(credentials.NewStaticCredentials("AKIDEXAMPLE", "secret", "session")))
+	assert.NoError(t, err)
+	return sess
+}
+
+func TestSecretsManagerProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+		var input map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		if input["SecretId"] == "/argocd/slack" {
+			_, _ = w.Write([]byte(`{"SecretString": "{\"token\": \"xoxb-123\"}"}`))
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type": "ResourceNotFoundException", "message": "Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer server.Close()
+	provider := NewSecretsManagerProvider(newTestSession(t, server))
+
+	val, err := provider.GetSecret("/argocd/slack#token")
+	assert.NoError(t, err)
+	assert.Equal(t, "xoxb-123", val)
+
+	val, err = provider.GetSecret("/argocd/slack")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"token": "xoxb-123"}`, val)
+
+	_, err = provider.GetSecret("/argocd/slack#missing")
+	assert.Error(t, err)
+
+	_, err = provider.GetSecret("/argocd/missing")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ResourceNotFoundException")
+}
+
+func TestParameterStoreProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "AmazonSSM.GetParameter", r.Header.Get("X-Amz-Target"))
+		var input map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+		assert.Equal(t, map[string]interface{}{"Name": "/argocd/smtp-password", "WithDecryption": true}, input)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		_, _ = w.Write([]byte(`{"Parameter": {"Value": "password"}}`))
+	}))
+	defer server.Close()
+
+	val, err := NewParameterStoreProvider(newTestSession(t, server)).GetSecret("/argocd/smtp-password")
+	assert.NoError(t, err)
+	assert.Equal(t, "password", val)
+}
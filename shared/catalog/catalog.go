@@ -0,0 +1,362 @@
+package catalog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/remotes/docker"
+	orascontent "github.com/deislabs/oras/pkg/content"
+	"github.com/deislabs/oras/pkg/oras"
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ociScheme = "oci://"
+	// maxSize is the max size of the catalog file downloaded over HTTPS
+	maxSize = 10 << 20
+)
+
+var (
+	digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	// keyPrefixes holds prefixes of the keys catalogs might define; services are not supported since they hold credentials
+	keyPrefixes = []string{"trigger.", "template."}
+)
+
+// Source references the catalog of triggers and templates
+type Source struct {
+	// URL is either the HTTPS URL of the catalog file, e.g. the raw file URL in the Git repository, or the OCI artifact
+	// reference in the oci://<registry>/<repository>[:<tag>] format
+	URL string `json:"url"`
+	// Digest is the sha256 digest of the catalog file or of the OCI artifact manifest
+	Digest string `json:"digest"`
+}
+
+// Validate returns an error if the URL is not supported or the catalog is not pinned by the digest
+func (s Source) Validate() error {
+	if !strings.HasPrefix(s.URL, "https://") && !strings.HasPrefix(s.URL, ociScheme) {
+		return fmt.Errorf("catalog url '%s' must start with https:// or %s", s.URL, ociScheme)
+	}
+	if !digestPattern.MatchString(s.Digest) {
+		return fmt.Errorf("catalog %s must be pinned by the digest in the sha256:<hex> format", s.URL)
+	}
+	if strings.HasPrefix(s.URL, ociScheme) {
+		if _, _, err := parseReference(s.URL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseSources parses and validates the YAML list of catalogs
+func ParseSources(val string) ([]Source, error) {
+	var sources []Source
+	if err := yaml.Unmarshal([]byte(val), &sources); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal catalogs: %v", err)
+	}
+	for _, source := range sources {
+		if err := source.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return sources, nil
+}
+
+// ErrPending is returned by LoadCached if some catalogs are still being downloaded
+var ErrPending = errors.New("catalogs are being downloaded")
+
+// Loader downloads catalogs. The catalog content is pinned by the digest, so every catalog is downloaded only once.
+type Loader struct {
+	client  *http.Client
+	lock    sync.Mutex
+	cache   map[Source]map[string]string
+	errors  map[Source]error
+	pending map[Source]bool
+}
+
+func NewLoader() *Loader {
+	return &Loader{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		cache:   map[Source]map[string]string{},
+		errors:  map[Source]error{},
+		pending: map[Source]bool{},
+	}
+}
+
+// Load returns triggers and templates of the catalogs and downloads catalogs that are not cached yet. If several
+// catalogs define the same key, the catalog that is earlier in the list takes precedence.
+func (l *Loader) Load(ctx context.Context, sources []Source) (map[string]string, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.evict(sources)
+	for _, source := range sources {
+		if _, ok := l.cache[source]; ok {
+			continue
+		}
+		data, err := l.download(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		l.cache[source] = data
+		delete(l.errors, source)
+	}
+	return l.merge(sources), nil
+}
+
+// LoadCached returns triggers and templates of the cached catalogs without accessing the network. Catalogs that are
+// not cached yet are downloaded in the background using the given context and ErrPending is returned, so the caller
+// keeps the previous settings until onLoaded is called. Catalogs that failed to download are retried by Retry.
+func (l *Loader) LoadCached(ctx context.Context, sources []Source, onLoaded func()) (map[string]string, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.evict(sources)
+	pending := false
+	for _, source := range sources {
+		if _, ok := l.cache[source]; ok {
+			continue
+		}
+		if err, ok := l.errors[source]; ok {
+			return nil, err
+		}
+		pending = true
+		if !l.pending[source] {
+			l.pending[source] = true
+			go l.loadInBackground(ctx, source, onLoaded)
+		}
+	}
+	if pending {
+		return nil, ErrPending
+	}
+	return l.merge(sources), nil
+}
+
+func (l *Loader) loadInBackground(ctx context.Context, source Source, onLoaded func()) {
+	data, err := l.download(ctx, source)
+	l.lock.Lock()
+	delete(l.pending, source)
+	if err != nil {
+		l.errors[source] = err
+	} else {
+		l.cache[source] = data
+	}
+	l.lock.Unlock()
+	if onLoaded != nil {
+		onLoaded()
+	}
+}
+
+// Retry downloads again catalogs that failed to download and returns true if any catalog is loaded. Catalogs are
+// downloaded without holding the lock, so LoadCached is never blocked by the network.
+func (l *Loader) Retry(ctx context.Context) bool {
+	l.lock.Lock()
+	var sources []Source
+	for source := range l.errors {
+		sources = append(sources, source)
+	}
+	l.lock.Unlock()
+
+	loaded := false
+	for _, source := range sources {
+		data, err := l.download(ctx, source)
+		l.lock.Lock()
+		// the catalog might be removed from the config while it is being downloaded
+		if _, ok := l.errors[source]; ok {
+			if err != nil {
+				l.errors[source] = err
+			} else {
+				delete(l.errors, source)
+				l.cache[source] = data
+				loaded = true
+			}
+		}
+		l.lock.Unlock()
+	}
+	return loaded
+}
+
+// evict removes catalogs that are no longer referenced by the config, so they are not kept in memory
+func (l *Loader) evict(sources []Source) {
+	used := map[Source]bool{}
+	for _, source := range sources {
+		used[source] = true
+	}
+	for source := range l.cache {
+		if !used[source] {
+			delete(l.cache, source)
+		}
+	}
+	for source := range l.errors {
+		if !used[source] {
+			delete(l.errors, source)
+		}
+	}
+}
+
+func (l *Loader) merge(sources []Source) map[string]string {
+	res := map[string]string{}
+	owners := map[string]string{}
+	for _, source := range sources {
+		for k, v := range l.cache[source] {
+			if owner, ok := owners[k]; ok {
+				log.Warnf("Key '%s' of catalog %s is ignored because it is already defined in catalog %s", k, source.URL, owner)
+				continue
+			}
+			res[k] = v
+			owners[k] = source.URL
+		}
+	}
+	return res
+}
+
+func (l *Loader) download(ctx context.Context, source Source) (map[string]string, error) {
+	content, err := l.fetch(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load catalog %s: %v", source.URL, err)
+	}
+	data, err := parseCatalog(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse catalog %s: %v", source.URL, err)
+	}
+	log.Infof("Loaded %d triggers and templates from catalog %s", len(data), source.URL)
+	return data, nil
+}
+
+// Download returns the content of the file served over HTTPS or stored in the Git repository without verifying the digest
+func (l *Loader) Download(ctx context.Context, rawURL string) ([]byte, error) {
+	if strings.HasPrefix(rawURL, GitScheme) {
+		return downloadGitFile(ctx, rawURL)
+	}
+	return l.get(ctx, rawURL)
+}
+
+// parseCatalog returns triggers and templates of the catalog file, which is either the ConfigMap manifest, like
+// catalog/install.yaml of this repository, or the map of keys
+func parseCatalog(content []byte) (map[string]string, error) {
+	var configMap struct {
+		Kind string            `json:"kind"`
+		Data map[string]string `json:"data"`
+	}
+	data := map[string]string{}
+	if err := yaml.Unmarshal(content, &configMap); err == nil && configMap.Kind == "ConfigMap" {
+		data = configMap.Data
+	} else if err := yaml.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("catalog must be the ConfigMap manifest or the map of triggers and templates: %v", err)
+	}
+	res := map[string]string{}
+	for k, v := range data {
+		if !hasKeyPrefix(k) {
+			log.Warnf("Catalog key '%s' is ignored; only triggers and templates are allowed", k)
+			continue
+		}
+		res[k] = v
+	}
+	return res, nil
+}
+
+func hasKeyPrefix(key string) bool {
+	for _, prefix := range keyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func verifyDigest(content []byte, digest string) error {
+	sum := sha256.Sum256(content)
+	if actual := "sha256:" + hex.EncodeToString(sum[:]); actual != digest {
+		return fmt.Errorf("digest mismatch: expected %s, got %s", digest, actual)
+	}
+	return nil
+}
+
+func (l *Loader) fetch(ctx context.Context, source Source) ([]byte, error) {
+	if strings.HasPrefix(source.URL, ociScheme) {
+		return l.fetchArtifact(ctx, source)
+	}
+	content, err := l.get(ctx, source.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyDigest(content, source.Digest); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// parseReference splits the oci://<registry>/<repository>[:<tag>] reference. The tag is informational only since the
+// artifact is pulled by the pinned digest.
+func parseReference(ref string) (string, string, error) {
+	ref = strings.TrimPrefix(ref, ociScheme)
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	slash := strings.Index(ref, "/")
+	if slash <= 0 || slash == len(ref)-1 {
+		return "", "", fmt.Errorf("invalid OCI reference '%s%s'; expected %s<registry>/<repository>[:<tag>]", ociScheme, ref, ociScheme)
+	}
+	registry, repository := ref[:slash], ref[slash+1:]
+	if i := strings.LastIndex(repository, ":"); i >= 0 {
+		repository = repository[:i]
+	}
+	return registry, repository, nil
+}
+
+// fetchArtifact pulls the OCI artifact by the pinned digest and returns the catalog file stored as the only named
+// layer of the artifact, e.g. pushed using `oras push <registry>/<repository>:<tag> catalog.yaml`
+func (l *Loader) fetchArtifact(ctx context.Context, source Source) ([]byte, error) {
+	registry, repository, err := parseReference(source.URL)
+	if err != nil {
+		return nil, err
+	}
+	resolver := docker.NewResolver(docker.ResolverOptions{Client: l.client})
+	store := orascontent.NewMemoryStore()
+	_, layers, err := oras.Pull(ctx, resolver, fmt.Sprintf("%s/%s@%s", registry, repository, source.Digest), store)
+	if err != nil {
+		return nil, err
+	}
+	if len(layers) != 1 {
+		return nil, fmt.Errorf("artifact must have exactly one layer with the catalog file, got %d", len(layers))
+	}
+	_, content, ok := store.Get(layers[0])
+	if !ok {
+		return nil, fmt.Errorf("layer %s of the artifact is not pulled", layers[0].Digest)
+	}
+	return content, nil
+}
+
+// get returns the response body of the GET request
+func (l *Loader) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned %s", rawURL, resp.Status)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxSize {
+		return nil, fmt.Errorf("GET %s returned more than %d bytes", rawURL, maxSize)
+	}
+	return body, nil
+}
@@ -0,0 +1,232 @@
+package catalog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const catalogYaml = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: argocd-notifications-cm
+data:
+  trigger.on-deployed: |
+    - when: app.status.operationState.phase in ['Succeeded']
+      send: [app-deployed]
+  template.app-deployed: |
+    message: Application {{.app.metadata.name}} is deployed
+  service.slack: |
+    token: abc
+`
+
+func digestOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestParseSources(t *testing.T) {
+	sources, err := ParseSources(fmt.Sprintf(`
+- url: https://example.com/catalog.yaml
+  digest: %s
+- url: oci://ghcr.io/my-org/catalog:v1
+  digest: %s`, digestOf("a"), digestOf("b")))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Source{
+			{URL: "https://example.com/catalog.yaml", Digest: digestOf("a")},
+			{URL: "oci://ghcr.io/my-org/catalog:v1", Digest: digestOf("b")},
+		}, sources)
+	}
+
+	_, err = ParseSources(`[{url: "https://example.com/catalog.yaml"}]`)
+	assert.Error(t, err)
+	_, err = ParseSources(fmt.Sprintf(`[{url: "http://example.com/catalog.yaml", digest: %s}]`, digestOf("a")))
+	assert.Error(t, err)
+	_, err = ParseSources(fmt.Sprintf(`[{url: "oci://catalog", digest: %s}]`, digestOf("a")))
+	assert.Error(t, err)
+}
+
+func TestLoad_HTTPS(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(catalogYaml))
+	}))
+	defer server.Close()
+	loader := NewLoader()
+	loader.client = server.Client()
+
+	source := Source{URL: server.URL + "/catalog/install.yaml", Digest: digestOf(catalogYaml)}
+	for i := 0; i < 2; i++ {
+		data, err := loader.Load(context.Background(), []Source{source})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, map[string]string{
+			"trigger.on-deployed":   "- when: app.status.operationState.phase in ['Succeeded']\n  send: [app-deployed]\n",
+			"template.app-deployed": "message: Application {{.app.metadata.name}} is deployed\n",
+		}, data)
+	}
+	assert.Equal(t, 1, requests)
+}
+
+func TestLoad_DigestMismatch(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(catalogYaml))
+	}))
+	defer server.Close()
+	loader := NewLoader()
+	loader.client = server.Client()
+
+	_, err := loader.Load(context.Background(), []Source{{URL: server.URL + "/catalog.yaml", Digest: digestOf("other")}})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "digest mismatch")
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "template.app-deployed: from %s\n", strings.TrimPrefix(r.URL.Path, "/"))
+	}))
+	defer server.Close()
+	loader := NewLoader()
+	loader.client = server.Client()
+
+	data, err := loader.Load(context.Background(), []Source{
+		{URL: server.URL + "/first", Digest: digestOf("template.app-deployed: from first\n")},
+		{URL: server.URL + "/second", Digest: digestOf("template.app-deployed: from second\n")},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{"template.app-deployed": "from first"}, data)
+	}
+}
+
+func TestLoad_OCI(t *testing.T) {
+	layerDigest := digestOf(catalogYaml)
+	manifest := fmt.Sprintf(`{"schemaVersion": 2, "config": {"mediaType": "application/vnd.unknown.config.v1+json", "digest": "%s", "size": 2}, `+
+		`"layers": [{"mediaType": "application/vnd.oci.image.layer.v1.tar", "digest": "%s", "size": %d, `+
+		`"annotations": {"org.opencontainers.image.title": "catalog.yaml"}}]}`, digestOf("{}"), layerDigest, len(catalogYaml))
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			assert.Equal(t, "repository:my-org/catalog:pull", r.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token": "abc"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:my-org/catalog:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body, mediaType, digest string
+		switch r.URL.Path {
+		case "/v2/my-org/catalog/manifests/" + digestOf(manifest):
+			body, mediaType, digest = manifest, "application/vnd.oci.image.manifest.v1+json", digestOf(manifest)
+		case "/v2/my-org/catalog/blobs/" + layerDigest:
+			body, mediaType, digest = catalogYaml, "application/octet-stream", layerDigest
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", mediaType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Header().Set("Docker-Content-Digest", digest)
+		if r.Method != http.MethodHead {
+			_, _ = w.Write([]byte(body))
+		}
+	}))
+	defer server.Close()
+	loader := NewLoader()
+	loader.client = server.Client()
+
+	ref := "oci://" + strings.TrimPrefix(server.URL, "https://") + "/my-org/catalog:v1"
+	data, err := loader.Load(context.Background(), []Source{{URL: ref, Digest: digestOf(manifest)}})
+	if assert.NoError(t, err) {
+		assert.Len(t, data, 2)
+		assert.Contains(t, data, "trigger.on-deployed")
+	}
+}
+
+func TestLoadCached(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte(catalogYaml))
+	}))
+	defer server.Close()
+	loader := NewLoader()
+	loader.client = server.Client()
+
+	loaded := make(chan struct{}, 1)
+	source := Source{URL: server.URL + "/catalog.yaml", Digest: digestOf(catalogYaml)}
+	_, err := loader.LoadCached(context.Background(), []Source{source}, func() {
+		loaded <- struct{}{}
+	})
+	assert.Equal(t, ErrPending, err)
+
+	close(release)
+	<-loaded
+	data, err := loader.LoadCached(context.Background(), []Source{source}, nil)
+	if assert.NoError(t, err) {
+		assert.Len(t, data, 2)
+	}
+}
+
+func TestLoadCached_Retry(t *testing.T) {
+	failing := int32(1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(catalogYaml))
+	}))
+	defer server.Close()
+	loader := NewLoader()
+	loader.client = server.Client()
+
+	loaded := make(chan struct{}, 1)
+	source := Source{URL: server.URL + "/catalog.yaml", Digest: digestOf(catalogYaml)}
+	_, err := loader.LoadCached(context.Background(), []Source{source}, func() {
+		loaded <- struct{}{}
+	})
+	assert.Equal(t, ErrPending, err)
+	<-loaded
+	_, err = loader.LoadCached(context.Background(), []Source{source}, nil)
+	assert.Error(t, err)
+
+	atomic.StoreInt32(&failing, 0)
+	assert.True(t, loader.Retry(context.Background()))
+	data, err := loader.LoadCached(context.Background(), []Source{source}, nil)
+	if assert.NoError(t, err) {
+		assert.Len(t, data, 2)
+	}
+}
+
+func TestParseGitURL(t *testing.T) {
+	repoURL, filePath, ref, err := ParseGitURL("git+https://github.com/my-org/config.git//triggers/on-deployed.yaml?ref=v1.2.0")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "https://github.com/my-org/config.git", repoURL)
+		assert.Equal(t, "triggers/on-deployed.yaml", filePath)
+		assert.Equal(t, "v1.2.0", ref)
+	}
+
+	_, _, ref, err = ParseGitURL("git+https://github.com/my-org/config.git//on-deployed.yaml")
+	assert.NoError(t, err)
+	assert.Empty(t, ref)
+
+	_, _, _, err = ParseGitURL("git+https://github.com/my-org/config.git/on-deployed.yaml")
+	assert.Error(t, err)
+
+	_, _, _, err = ParseGitURL("git+ssh://github.com/my-org/config.git//on-deployed.yaml")
+	assert.Error(t, err)
+}
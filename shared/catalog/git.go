@@ -0,0 +1,95 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+// GitScheme is the prefix of URLs that reference files in Git repositories
+const GitScheme = "git+https://"
+
+// ParseGitURL splits the git+https://<host>/<repository>//<path>[?ref=<branch or tag>] URL into the repository URL, the
+// file path and the branch or tag. The default branch is used if the ref is not specified.
+func ParseGitURL(rawURL string) (string, string, string, error) {
+	u, err := url.Parse(strings.TrimPrefix(rawURL, "git+"))
+	if err != nil || !strings.HasPrefix(rawURL, GitScheme) {
+		return "", "", "", fmt.Errorf("invalid git url '%s'", rawURL)
+	}
+	ref := u.Query().Get("ref")
+	u.RawQuery = ""
+	parts := strings.SplitN(u.Path, "//", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", fmt.Errorf("git url '%s' must reference the file in the %s<host>/<repository>//<path>[?ref=<branch or tag>] format", rawURL, GitScheme)
+	}
+	u.Path = parts[0]
+	return u.String(), parts[1], ref, nil
+}
+
+// downloadGitFile returns the content of the file at the head of the branch or tag. Only the head commit is fetched and the
+// repository is not checked out to the disk.
+func downloadGitFile(ctx context.Context, rawURL string) ([]byte, error) {
+	repoURL, filePath, ref, err := ParseGitURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	refNames := []plumbing.ReferenceName{plumbing.HEAD}
+	if ref != "" {
+		refNames = []plumbing.ReferenceName{plumbing.NewTagReferenceName(ref), plumbing.NewBranchReferenceName(ref)}
+	}
+	var repo *git.Repository
+	for _, refName := range refNames {
+		opts := &git.CloneOptions{URL: repoURL, Depth: 1, NoCheckout: true, Tags: git.NoTags}
+		if refName != plumbing.HEAD {
+			opts.ReferenceName = refName
+			opts.SingleBranch = true
+		}
+		if repo, err = git.CloneContext(ctx, memory.NewStorage(), nil, opts); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", repoURL, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		// annotated tags reference the tag object rather than the commit
+		tag, tagErr := repo.TagObject(head.Hash())
+		if tagErr != nil {
+			return nil, err
+		}
+		if commit, err = tag.Commit(); err != nil {
+			return nil, err
+		}
+	}
+	return readGitFile(commit, filePath)
+}
+
+func readGitFile(commit *object.Commit, filePath string) ([]byte, error) {
+	file, err := commit.File(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s in commit %s: %v", filePath, commit.Hash, err)
+	}
+	if file.Size > maxSize {
+		return nil, fmt.Errorf("file %s is larger than %d bytes", filePath, maxSize)
+	}
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	return ioutil.ReadAll(reader)
+}
@@ -0,0 +1,177 @@
+package deadletter
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+)
+
+const (
+	ConfigMapName = "argocd-notifications-dead-letters"
+)
+
+// Entry holds information about notification that could not be delivered
+type Entry struct {
+	ID          string               `json:"id"`
+	App         string               `json:"app"`
+	Trigger     string               `json:"trigger"`
+	Templates   []string             `json:"templates"`
+	Destination services.Destination `json:"destination"`
+	// Vars holds template variables of the notification besides the application, e.g. the alert of notifications
+	// sent by the API server Alertmanager receiver
+	Vars        map[string]interface{} `json:"vars,omitempty"`
+	Error       string                 `json:"error"`
+	PayloadHash string                 `json:"payloadHash,omitempty"`
+	Failures    int                    `json:"failures"`
+	Timestamp   int64                  `json:"timestamp"`
+}
+
+// EntryID returns stable identifier of the notification, so repeated failures of the same notification are recorded once
+func EntryID(app string, trigger string, templates []string, dest services.Destination) string {
+	h := sha1.New()
+	_, _ = h.Write([]byte(strings.Join([]string{app, trigger, strings.Join(templates, ","), dest.Service, dest.Recipient}, ":")))
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// PayloadHash returns hash of the rendered notification
+func PayloadHash(notification services.Notification) string {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return ""
+	}
+	h := sha1.New()
+	_, _ = h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Store persists notifications that could not be delivered
+type Store interface {
+	Add(ctx context.Context, entry Entry) error
+	Get(ctx context.Context, id string) (*Entry, error)
+	List(ctx context.Context) ([]Entry, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// NewConfigMapStore returns store that keeps up to maxSize entries in the argocd-notifications-dead-letters ConfigMap
+func NewConfigMapStore(clientset kubernetes.Interface, namespace string, maxSize int) *configMapStore {
+	return &configMapStore{clientset: clientset, namespace: namespace, maxSize: maxSize}
+}
+
+type configMapStore struct {
+	clientset kubernetes.Interface
+	namespace string
+	maxSize   int
+}
+
+// getConfigMap returns dead letters ConfigMap and flag that indicates if ConfigMap already exists
+func (s *configMapStore) getConfigMap(ctx context.Context) (*v1.ConfigMap, bool, error) {
+	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, ConfigMapName, metav1.GetOptions{})
+	if apierr.IsNotFound(err) {
+		return &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: s.namespace}}, false, nil
+	}
+	return cm, err == nil, err
+}
+
+func (s *configMapStore) saveConfigMap(ctx context.Context, cm *v1.ConfigMap, exists bool) error {
+	var err error
+	if exists {
+		_, err = s.clientset.CoreV1().ConfigMaps(s.namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	} else {
+		_, err = s.clientset.CoreV1().ConfigMaps(s.namespace).Create(ctx, cm, metav1.CreateOptions{})
+	}
+	return err
+}
+
+func parseEntries(cm *v1.ConfigMap) []Entry {
+	var entries []Entry
+	for k, v := range cm.Data {
+		entry := Entry{}
+		if err := json.Unmarshal([]byte(v), &entry); err != nil {
+			continue
+		}
+		entry.ID = k
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Timestamp > entries[j].Timestamp
+	})
+	return entries
+}
+
+func (s *configMapStore) Add(ctx context.Context, entry Entry) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, exists, err := s.getConfigMap(ctx)
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		if existing, ok := cm.Data[entry.ID]; ok {
+			prev := Entry{}
+			if err := json.Unmarshal([]byte(existing), &prev); err == nil {
+				entry.Failures += prev.Failures
+			}
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		cm.Data[entry.ID] = string(data)
+
+		// drop the oldest entries to keep ConfigMap size bounded
+		if entries := parseEntries(cm); len(entries) > s.maxSize {
+			for _, e := range entries[s.maxSize:] {
+				delete(cm.Data, e.ID)
+			}
+		}
+		return s.saveConfigMap(ctx, cm, exists)
+	})
+}
+
+func (s *configMapStore) Get(ctx context.Context, id string) (*Entry, error) {
+	entries, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].ID == id {
+			return &entries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("dead letter '%s' not found", id)
+}
+
+func (s *configMapStore) List(ctx context.Context) ([]Entry, error) {
+	cm, _, err := s.getConfigMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseEntries(cm), nil
+}
+
+func (s *configMapStore) Delete(ctx context.Context, id string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, exists, err := s.getConfigMap(ctx)
+		if err != nil {
+			return err
+		}
+		if _, ok := cm.Data[id]; !ok {
+			return nil
+		}
+		delete(cm.Data, id)
+		return s.saveConfigMap(ctx, cm, exists)
+	})
+}
@@ -0,0 +1,78 @@
+package deadletter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func newEntry(app string, timestamp int64) Entry {
+	dest := services.Destination{Service: "slack", Recipient: "my-channel"}
+	return Entry{
+		ID:          EntryID(app, "on-sync-failed", []string{"app-sync-failed"}, dest),
+		App:         app,
+		Trigger:     "on-sync-failed",
+		Templates:   []string{"app-sync-failed"},
+		Destination: dest,
+		Error:       "connection refused",
+		Failures:    1,
+		Timestamp:   timestamp,
+	}
+}
+
+func TestConfigMapStore_AddAndList(t *testing.T) {
+	store := NewConfigMapStore(fake.NewSimpleClientset(), TestNamespace, 10)
+
+	err := store.Add(context.Background(), newEntry("guestbook", 1))
+	assert.NoError(t, err)
+
+	entries, err := store.List(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "guestbook", entries[0].App)
+	assert.Equal(t, "connection refused", entries[0].Error)
+}
+
+func TestConfigMapStore_AddSameNotificationIncrementsFailures(t *testing.T) {
+	store := NewConfigMapStore(fake.NewSimpleClientset(), TestNamespace, 10)
+
+	assert.NoError(t, store.Add(context.Background(), newEntry("guestbook", 1)))
+	assert.NoError(t, store.Add(context.Background(), newEntry("guestbook", 2)))
+
+	entries, err := store.List(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, 2, entries[0].Failures)
+	assert.Equal(t, int64(2), entries[0].Timestamp)
+}
+
+func TestConfigMapStore_DropsOldestEntries(t *testing.T) {
+	store := NewConfigMapStore(fake.NewSimpleClientset(), TestNamespace, 2)
+
+	assert.NoError(t, store.Add(context.Background(), newEntry("app1", 1)))
+	assert.NoError(t, store.Add(context.Background(), newEntry("app2", 2)))
+	assert.NoError(t, store.Add(context.Background(), newEntry("app3", 3)))
+
+	entries, err := store.List(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "app3", entries[0].App)
+		assert.Equal(t, "app2", entries[1].App)
+	}
+}
+
+func TestConfigMapStore_Delete(t *testing.T) {
+	store := NewConfigMapStore(fake.NewSimpleClientset(), TestNamespace, 10)
+	entry := newEntry("guestbook", 1)
+	assert.NoError(t, store.Add(context.Background(), entry))
+
+	assert.NoError(t, store.Delete(context.Background(), entry.ID))
+
+	_, err := store.Get(context.Background(), entry.ID)
+	assert.Error(t, err)
+}
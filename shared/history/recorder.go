@@ -0,0 +1,164 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+)
+
+const (
+	Kind = "NotificationHistory"
+
+	AppLabel     = "notifications.argoproj.io/app"
+	TriggerLabel = "notifications.argoproj.io/trigger"
+	ServiceLabel = "notifications.argoproj.io/service"
+	ResultLabel  = "notifications.argoproj.io/result"
+
+	ResultSucceeded = "Succeeded"
+	ResultFailed    = "Failed"
+
+	// maxNamePrefixLength leaves room for the random suffix in the 253 chars long resource name
+	maxNamePrefixLength = 240
+	// cleanupPageSize limits the number of records loaded into memory at once during the cleanup
+	cleanupPageSize = 500
+)
+
+// Record holds information about single notification delivery attempt
+type Record struct {
+	App         string
+	Trigger     string
+	Templates   []string
+	Destination services.Destination
+	Error       error
+	Timestamp   time.Time
+}
+
+// Recorder persists notification delivery attempts
+type Recorder interface {
+	Record(ctx context.Context, record Record) error
+	// Cleanup removes records that are older than the configured TTL
+	Cleanup(ctx context.Context) error
+}
+
+// NewRecorder returns recorder that stores delivery attempts as NotificationHistory resources which are kept for the specified ttl
+func NewRecorder(client dynamic.Interface, namespace string, ttl time.Duration) *recorder {
+	return &recorder{client: k8s.NewNotificationHistoryClient(client, namespace), ttl: ttl, now: time.Now}
+}
+
+type recorder struct {
+	client dynamic.ResourceInterface
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func (r *recorder) Record(ctx context.Context, record Record) error {
+	result := ResultSucceeded
+	errMessage := ""
+	if record.Error != nil {
+		result = ResultFailed
+		errMessage = record.Error.Error()
+	}
+	if record.Timestamp.IsZero() {
+		record.Timestamp = r.now()
+	}
+	name := record.App
+	if len(name) > maxNamePrefixLength {
+		name = name[:maxNamePrefixLength]
+	}
+	templates := make([]interface{}, len(record.Templates))
+	for i := range record.Templates {
+		templates[i] = record.Templates[i]
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       Kind,
+		"metadata": map[string]interface{}{
+			"name": fmt.Sprintf("%s-%s", name, rand.String(5)),
+		},
+		"spec": map[string]interface{}{
+			"app":       record.App,
+			"trigger":   record.Trigger,
+			"templates": templates,
+			"destination": map[string]interface{}{
+				"service":   record.Destination.Service,
+				"recipient": record.Destination.Recipient,
+			},
+			"result":    result,
+			"error":     errMessage,
+			"timestamp": record.Timestamp.UTC().Format(time.RFC3339),
+		},
+	}}
+	labels := map[string]string{}
+	for k, v := range map[string]string{
+		AppLabel:     record.App,
+		TriggerLabel: record.Trigger,
+		ServiceLabel: record.Destination.Service,
+		ResultLabel:  result,
+	} {
+		// labels are used only for filtering so values that cannot be used as label are skipped
+		if len(validation.IsValidLabelValue(v)) == 0 {
+			labels[k] = v
+		}
+	}
+	obj.SetLabels(labels)
+	_, err := r.client.Create(ctx, obj, metav1.CreateOptions{})
+	return err
+}
+
+func (r *recorder) Cleanup(ctx context.Context) error {
+	expireBefore := r.now().Add(-r.ttl)
+	var errs []error
+	opts := metav1.ListOptions{Limit: cleanupPageSize}
+	for {
+		list, err := r.client.List(ctx, opts)
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+		for _, item := range list.Items {
+			if !recordTimestamp(item).Before(expireBefore) {
+				continue
+			}
+			// keep removing other records so a single failure does not block the cleanup
+			if err := r.client.Delete(ctx, item.GetName(), metav1.DeleteOptions{}); err != nil && !apierr.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to delete %s: %v", item.GetName(), err))
+			}
+		}
+		if opts.Continue = list.GetContinue(); opts.Continue == "" {
+			break
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// Run periodically removes expired records until context is canceled
+func Run(ctx context.Context, recorder Recorder, interval time.Duration) {
+	wait.Until(func() {
+		if err := recorder.Cleanup(ctx); err != nil {
+			log.Warnf("Failed to remove expired notification history records: %v", err)
+		}
+	}, interval, ctx.Done())
+}
+
+func recordTimestamp(obj unstructured.Unstructured) time.Time {
+	if ts, ok, err := unstructured.NestedString(obj.Object, "spec", "timestamp"); ok && err == nil {
+		if t, err := time.Parse(time.RFC3339, ts); err == nil {
+			return t
+		}
+	}
+	return obj.GetCreationTimestamp().Time
+}
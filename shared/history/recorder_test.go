@@ -0,0 +1,111 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func newRecord(name string, timestamp time.Time) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       Kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": TestNamespace,
+		},
+		"spec": map[string]interface{}{
+			"timestamp": timestamp.UTC().Format(time.RFC3339),
+		},
+	}}
+}
+
+func TestRecord(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	recorder := NewRecorder(client, TestNamespace, time.Hour)
+
+	err := recorder.Record(context.Background(), Record{
+		App:         "guestbook",
+		Trigger:     "on-sync-failed",
+		Templates:   []string{"app-sync-failed"},
+		Destination: services.Destination{Service: "slack", Recipient: "my-channel"},
+		Error:       errors.New("channel not found"),
+	})
+	assert.NoError(t, err)
+
+	list, err := k8s.NewNotificationHistoryClient(client, TestNamespace).List(context.Background(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	if !assert.Len(t, list.Items, 1) {
+		return
+	}
+	item := list.Items[0]
+	assert.Equal(t, map[string]string{
+		AppLabel:     "guestbook",
+		TriggerLabel: "on-sync-failed",
+		ServiceLabel: "slack",
+		ResultLabel:  ResultFailed,
+	}, item.GetLabels())
+	assert.Equal(t, map[string]interface{}{"service": "slack", "recipient": "my-channel"}, item.Object["spec"].(map[string]interface{})["destination"])
+	result, _, _ := unstructured.NestedString(item.Object, "spec", "error")
+	assert.Equal(t, "channel not found", result)
+}
+
+func TestCleanup(t *testing.T) {
+	now := time.Now()
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newRecord("old", now.Add(-2*time.Hour)),
+		newRecord("new", now.Add(-time.Minute)))
+	recorder := NewRecorder(client, TestNamespace, time.Hour)
+
+	err := recorder.Cleanup(context.Background())
+	assert.NoError(t, err)
+
+	list, err := k8s.NewNotificationHistoryClient(client, TestNamespace).List(context.Background(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, list.Items, 1) {
+		assert.Equal(t, "new", list.Items[0].GetName())
+	}
+}
+
+func TestCleanup_DeleteErrors(t *testing.T) {
+	now := time.Now()
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newRecord("broken", now.Add(-2*time.Hour)),
+		newRecord("deleted", now.Add(-2*time.Hour)),
+		newRecord("old", now.Add(-2*time.Hour)))
+	client.PrependReactor("delete", "*", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		switch action.(kubetesting.DeleteAction).GetName() {
+		case "broken":
+			return true, nil, errors.New("forbidden")
+		case "deleted":
+			return true, nil, apierr.NewNotFound(schema.GroupResource{Resource: "notificationhistories"}, "deleted")
+		}
+		return false, nil, nil
+	})
+	recorder := NewRecorder(client, TestNamespace, time.Hour)
+
+	err := recorder.Cleanup(context.Background())
+	assert.EqualError(t, err, "failed to delete broken: forbidden")
+
+	list, err := k8s.NewNotificationHistoryClient(client, TestNamespace).List(context.Background(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	var names []string
+	for _, item := range list.Items {
+		names = append(names, item.GetName())
+	}
+	assert.ElementsMatch(t, []string{"broken", "deleted"}, names)
+}
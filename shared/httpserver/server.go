@@ -0,0 +1,166 @@
+package httpserver
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Options holds TLS and authentication settings of the HTTP server
+type Options struct {
+	// TLSCertFile and TLSKeyFile are paths to the server certificate and key. Server uses plain HTTP if not specified
+	TLSCertFile string
+	TLSKeyFile  string
+	// ClientCAFile is the path to the CA bundle used to verify client certificates
+	ClientCAFile string
+	// BearerTokenFile is the path to the file with the token expected in the Authorization header
+	BearerTokenFile string
+	// PublicPaths are served without authentication, e.g. health checks used by kubelet. Paths that end with slash match all subpaths
+	PublicPaths []string
+}
+
+// AddFlags adds TLS and authentication flags with the specified prefix to the command
+func AddFlags(cmd *cobra.Command, prefix string, opts *Options) {
+	cmd.Flags().StringVar(&opts.TLSCertFile, prefix+"-tls-cert", "", "Path to the TLS certificate. Enables HTTPS if specified together with the key.")
+	cmd.Flags().StringVar(&opts.TLSKeyFile, prefix+"-tls-key", "", "Path to the TLS private key.")
+	cmd.Flags().StringVar(&opts.ClientCAFile, prefix+"-client-ca", "", "Path to the CA bundle used to verify client certificates. Requires TLS.")
+	cmd.Flags().StringVar(&opts.BearerTokenFile, prefix+"-bearer-token-file", "", "Path to the file with the bearer token that clients must provide.")
+}
+
+func (o Options) tlsEnabled() bool {
+	return o.TLSCertFile != "" && o.TLSKeyFile != ""
+}
+
+// Validate returns an error if options are inconsistent
+func (o Options) Validate() error {
+	if (o.TLSCertFile == "") != (o.TLSKeyFile == "") {
+		return errors.New("both TLS certificate and key must be specified")
+	}
+	if o.ClientCAFile != "" && !o.tlsEnabled() {
+		return errors.New("client certificate verification requires TLS certificate and key")
+	}
+	return nil
+}
+
+func (o Options) isPublic(path string) bool {
+	for _, p := range o.PublicPaths {
+		if path == p || strings.HasSuffix(p, "/") && strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithAuth wraps the handler with the authentication configured in options
+func (o Options) WithAuth(handler http.Handler) http.Handler {
+	if o.BearerTokenFile == "" && o.ClientCAFile == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !o.isPublic(r.URL.Path) {
+			if o.ClientCAFile != "" && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+				http.Error(w, "client certificate required", http.StatusUnauthorized)
+				return
+			}
+			if o.BearerTokenFile != "" {
+				if err := o.checkBearerToken(r); err != nil {
+					http.Error(w, err.Error(), http.StatusUnauthorized)
+					return
+				}
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func (o Options) checkBearerToken(r *http.Request) error {
+	// token is read on every request so rotated secrets don't require restart
+	expected, err := ioutil.ReadFile(o.BearerTokenFile)
+	if err != nil {
+		return errors.New("failed to read bearer token")
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(strings.TrimSpace(string(expected)))) != 1 {
+		return errors.New("invalid bearer token")
+	}
+	return nil
+}
+
+// ListenAndServe serves the handler on the specified address using HTTPS if the TLS certificate is configured
+func (o Options) ListenAndServe(addr string, handler http.Handler) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	server := &http.Server{Addr: addr, Handler: o.WithAuth(handler)}
+	if !o.tlsEnabled() {
+		return server.ListenAndServe()
+	}
+	tlsConfig, err := o.TLSConfig()
+	if err != nil {
+		return err
+	}
+	server.TLSConfig = tlsConfig
+	return server.ListenAndServeTLS("", "")
+}
+
+// TLSConfig returns TLS configuration that reloads rotated certificates and verifies client certificates if the CA is configured
+func (o Options) TLSConfig() (*tls.Config, error) {
+	loader := &certLoader{certFile: o.TLSCertFile, keyFile: o.TLSKeyFile}
+	if _, err := loader.GetCertificate(nil); err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: loader.GetCertificate}
+	if o.ClientCAFile != "" {
+		caData, err := ioutil.ReadFile(o.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no certificates found in %s", o.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		// health checks are served without client certificates, so certificates are enforced by the handler
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
+
+// certLoader reloads the certificate when files are updated, e.g. when the mounted secret is rotated
+type certLoader struct {
+	certFile string
+	keyFile  string
+
+	lock    sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func (l *certLoader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	info, err := os.Stat(l.certFile)
+	if err != nil {
+		return nil, err
+	}
+	if l.cert != nil && !info.ModTime().After(l.modTime) {
+		return l.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	l.cert = &cert
+	l.modTime = info.ModTime()
+	return l.cert, nil
+}
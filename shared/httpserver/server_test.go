@@ -0,0 +1,65 @@
+package httpserver
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAuth_BearerToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpserver")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	tokenFile := filepath.Join(dir, "token")
+	assert.NoError(t, ioutil.WriteFile(tokenFile, []byte("my-token\n"), 0600))
+
+	handler := Options{BearerTokenFile: tokenFile, PublicPaths: []string{"/healthz"}}.WithAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	testCases := []struct {
+		path   string
+		token  string
+		status int
+	}{
+		{path: "/metrics", status: http.StatusUnauthorized},
+		{path: "/metrics", token: "wrong-token", status: http.StatusUnauthorized},
+		{path: "/metrics", token: "my-token", status: http.StatusOK},
+		{path: "/healthz", status: http.StatusOK},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, tc.status, rec.Code, "%s with token '%s'", tc.path, tc.token)
+	}
+}
+
+func TestWithAuth_ClientCertificateRequired(t *testing.T) {
+	handler := Options{ClientCAFile: "ca.crt"}.WithAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestValidate(t *testing.T) {
+	assert.Error(t, Options{TLSCertFile: "tls.crt"}.Validate())
+	assert.Error(t, Options{ClientCAFile: "ca.crt"}.Validate())
+	assert.NoError(t, Options{TLSCertFile: "tls.crt", TLSKeyFile: "tls.key", ClientCAFile: "ca.crt"}.Validate())
+}
@@ -16,3 +16,22 @@ func NewAppProjClient(client dynamic.Interface, namespace string) dynamic.Resour
 	resClient := client.Resource(appResource).Namespace(namespace)
 	return resClient
 }
+
+func NewNotificationHistoryClient(client dynamic.Interface, namespace string) dynamic.ResourceInterface {
+	historyResource := schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "notificationhistories"}
+	resClient := client.Resource(historyResource).Namespace(namespace)
+	return resClient
+}
+
+var (
+	NotificationTriggerResource  = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "notificationtriggers"}
+	NotificationTemplateResource = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "notificationtemplates"}
+	NotificationServiceResource  = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "notificationservices"}
+
+	// ConfigResources holds resources that might be used to configure triggers, templates and services instead of the config map
+	ConfigResources = []schema.GroupVersionResource{NotificationTriggerResource, NotificationTemplateResource, NotificationServiceResource}
+)
+
+var NotificationSubscriptionResource = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "notificationsubscriptions"}
+
+var NamespaceResource = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
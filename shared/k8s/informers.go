@@ -1,10 +1,16 @@
 package k8s
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	corev1 "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
@@ -13,6 +19,8 @@ import (
 const (
 	ConfigMapName = "argocd-notifications-cm"
 	SecretName    = "argocd-notifications-secret"
+	// ConfigFragmentLabel marks config maps that hold additional triggers, templates and services
+	ConfigFragmentLabel = "argocd-notifications.argoproj.io/config-fragment"
 
 	settingsResyncDuration = 3 * time.Minute
 )
@@ -28,3 +36,26 @@ func NewConfigMapInformer(clientset kubernetes.Interface, namespace string) cach
 		options.FieldSelector = fmt.Sprintf("metadata.name=%s", ConfigMapName)
 	})
 }
+
+func NewConfigFragmentInformer(clientset kubernetes.Interface, namespace string) cache.SharedIndexInformer {
+	return corev1.NewFilteredConfigMapInformer(clientset, namespace, settingsResyncDuration, cache.Indexers{}, func(options *metav1.ListOptions) {
+		options.LabelSelector = fmt.Sprintf("%s=true", ConfigFragmentLabel)
+	})
+}
+
+func NewConfigResourceInformer(client dynamic.Interface, namespace string, resource schema.GroupVersionResource) cache.SharedIndexInformer {
+	resClient := client.Resource(resource).Namespace(namespace)
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return resClient.List(context.Background(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return resClient.Watch(context.Background(), options)
+			},
+		},
+		&unstructured.Unstructured{},
+		settingsResyncDuration,
+		cache.Indexers{},
+	)
+}
@@ -0,0 +1,147 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+type jsonPatchOperation struct {
+	Op    string  `json:"op"`
+	Path  string  `json:"path"`
+	Value *string `json:"value,omitempty"`
+}
+
+// EscapeJSONPointer escapes the string so it can be used as the JSON pointer reference token (RFC 6901)
+func EscapeJSONPointer(val string) string {
+	return strings.Replace(strings.Replace(val, "~", "~0", -1), "/", "~1", -1)
+}
+
+// NewAnnotationsPatch returns patch that changes only modified annotations. JSON patch verifies that
+// modified annotations still have the original values, so concurrent changes cause a conflict instead of being overwritten.
+// Merge patch is used if the application has no annotations because JSON patch cannot add a key to the missing object.
+func NewAnnotationsPatch(before map[string]string, after map[string]string) (types.PatchType, []byte, error) {
+	var changed []string
+	for k, v := range after {
+		if prev, ok := before[k]; !ok || prev != v {
+			changed = append(changed, k)
+		}
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+
+	if len(before) == 0 {
+		annotations := map[string]interface{}{}
+		for _, k := range changed {
+			annotations[k] = after[k]
+		}
+		data, err := json.Marshal(map[string]map[string]interface{}{
+			"metadata": {"annotations": annotations},
+		})
+		return types.MergePatchType, data, err
+	}
+
+	var ops []jsonPatchOperation
+	for _, k := range changed {
+		path := "/metadata/annotations/" + EscapeJSONPointer(k)
+		prev, existed := before[k]
+		val, exists := after[k]
+		switch {
+		case !existed:
+			ops = append(ops, jsonPatchOperation{Op: "add", Path: path, Value: &val})
+		case exists:
+			ops = append(ops,
+				jsonPatchOperation{Op: "test", Path: path, Value: &prev},
+				jsonPatchOperation{Op: "replace", Path: path, Value: &val})
+		default:
+			ops = append(ops,
+				jsonPatchOperation{Op: "test", Path: path, Value: &prev},
+				jsonPatchOperation{Op: "remove", Path: path})
+		}
+	}
+	data, err := json.Marshal(ops)
+	return types.JSONPatchType, data, err
+}
+
+// isPatchConflict returns true if the resource has been modified concurrently. API server rejects JSON patch with the
+// failed test operation as unprocessable entity and merge patch with the outdated resource version as conflict
+func isPatchConflict(err error) bool {
+	return apierr.IsConflict(err) || apierr.IsInvalid(err)
+}
+
+// UpdateAnnotations applies the update function to annotations of the resource and patches modified annotations. The
+// update is re-applied to the latest resource version if the resource has been modified concurrently, so concurrent
+// updates made by multiple replicas are not lost. The resource is not patched if the update does not change annotations
+func UpdateAnnotations(ctx context.Context, client dynamic.ResourceInterface, name string, update func(annotations map[string]string)) error {
+	return retry.OnError(retry.DefaultRetry, isPatchConflict, func() error {
+		obj, err := client.Get(ctx, name, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		before := obj.GetAnnotations()
+		after := map[string]string{}
+		for k, v := range before {
+			after[k] = v
+		}
+		update(after)
+		if annotationsEqual(before, after) {
+			return nil
+		}
+		patchType, data, err := NewAnnotationsPatch(before, after)
+		if err != nil {
+			return err
+		}
+		if patchType == types.MergePatchType && obj.GetResourceVersion() != "" {
+			// merge patch that adds the first annotations is guarded by the resource version
+			data, err = json.Marshal(map[string]map[string]interface{}{
+				"metadata": {"resourceVersion": obj.GetResourceVersion(), "annotations": after},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		_, err = client.Patch(ctx, name, patchType, data, v1.PatchOptions{})
+		return err
+	})
+}
+
+// UpdateChangedAnnotations applies annotations that differ between before and after, e.g. the informer copy of the
+// resource and the processed copy, to the latest resource version. The informer copy might be stale, so the patch built
+// from it would fail or drop changes made by other clients, e.g. the bot, since the cache was updated.
+func UpdateChangedAnnotations(ctx context.Context, client dynamic.ResourceInterface, name string, before map[string]string, after map[string]string) error {
+	return UpdateAnnotations(ctx, client, name, func(annotations map[string]string) {
+		for k, v := range after {
+			if prev, ok := before[k]; !ok || prev != v {
+				annotations[k] = v
+			}
+		}
+		for k := range before {
+			if _, ok := after[k]; !ok {
+				delete(annotations, k)
+			}
+		}
+	})
+}
+
+func annotationsEqual(left map[string]string, right map[string]string) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for k, v := range left {
+		if val, ok := right[k]; !ok || val != v {
+			return false
+		}
+	}
+	return true
+}
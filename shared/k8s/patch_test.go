@@ -0,0 +1,102 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestNewAnnotationsPatch(t *testing.T) {
+	patchType, data, err := NewAnnotationsPatch(map[string]string{"a/b": "1", "c": "2", "d": "3"}, map[string]string{"a/b": "2", "d": "3", "e": ""})
+	assert.NoError(t, err)
+	assert.Equal(t, types.JSONPatchType, patchType)
+	assert.JSONEq(t, `[
+		{"op": "test", "path": "/metadata/annotations/a~1b", "value": "1"},
+		{"op": "replace", "path": "/metadata/annotations/a~1b", "value": "2"},
+		{"op": "test", "path": "/metadata/annotations/c", "value": "2"},
+		{"op": "remove", "path": "/metadata/annotations/c"},
+		{"op": "add", "path": "/metadata/annotations/e", "value": ""}
+	]`, string(data))
+
+	patchType, data, err = NewAnnotationsPatch(nil, map[string]string{"a": "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, types.MergePatchType, patchType)
+	assert.JSONEq(t, `{"metadata": {"annotations": {"a": "1"}}}`, string(data))
+}
+
+func TestUpdateAnnotations(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithAnnotations(map[string]string{"a": "1", "b": "2"})))
+	appClient := NewAppClient(client, TestNamespace)
+
+	err := UpdateAnnotations(context.Background(), appClient, "foo", func(annotations map[string]string) {
+		annotations["a"] = "3"
+		delete(annotations, "b")
+	})
+	assert.NoError(t, err)
+
+	app, err := appClient.Get(context.Background(), "foo", v1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "3"}, app.GetAnnotations())
+}
+
+func TestUpdateAnnotations_NoChanges(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithAnnotations(map[string]string{"a": "1"})))
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+
+	err := UpdateAnnotations(context.Background(), NewAppClient(client, TestNamespace), "foo", func(annotations map[string]string) {
+		annotations["a"] = "1"
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, patches)
+}
+
+func TestUpdateAnnotations_RetriesConflict(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithAnnotations(map[string]string{"a": "1"})))
+	conflicts := 0
+	client.PrependReactor("patch", "*", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		if conflicts == 0 {
+			conflicts++
+			return true, nil, apierr.NewConflict(schema.GroupResource{Resource: "applications"}, "foo", errors.New("the object has been modified"))
+		}
+		return false, nil, nil
+	})
+	appClient := NewAppClient(client, TestNamespace)
+	updates := 0
+
+	err := UpdateAnnotations(context.Background(), appClient, "foo", func(annotations map[string]string) {
+		updates++
+		annotations["b"] = "2"
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updates)
+
+	app, err := appClient.Get(context.Background(), "foo", v1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, app.GetAnnotations())
+}
+
+func TestUpdateChangedAnnotations(t *testing.T) {
+	// the annotation b has been added by another client since the informer copy has been cached
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("foo", WithAnnotations(map[string]string{"a": "1", "b": "2", "c": "3"})))
+	appClient := NewAppClient(client, TestNamespace)
+
+	err := UpdateChangedAnnotations(context.Background(), appClient, "foo",
+		map[string]string{"a": "1", "c": "3"}, map[string]string{"a": "2", "d": "4"})
+	assert.NoError(t, err)
+
+	app, err := appClient.Get(context.Background(), "foo", v1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "2", "b": "2", "d": "4"}, app.GetAnnotations())
+}
@@ -0,0 +1,48 @@
+package k8s
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// LastTransitionTime returns the time of the latest resource state change: the resource creation, the start or the end
+// of the last application operation or of the workflow, the last application deployment or the last condition change
+func LastTransitionTime(obj *unstructured.Unstructured) (time.Time, bool) {
+	var timestamps []string
+	for _, path := range [][]string{
+		{"metadata", "creationTimestamp"},
+		{"status", "operationState", "startedAt"},
+		{"status", "operationState", "finishedAt"},
+		{"status", "startedAt"},
+		{"status", "finishedAt"},
+	} {
+		if val, ok, _ := unstructured.NestedString(obj.Object, path...); ok {
+			timestamps = append(timestamps, val)
+		}
+	}
+	if history, ok, _ := unstructured.NestedSlice(obj.Object, "status", "history"); ok && len(history) > 0 {
+		if entry, ok := history[len(history)-1].(map[string]interface{}); ok {
+			if val, ok := entry["deployedAt"].(string); ok {
+				timestamps = append(timestamps, val)
+			}
+		}
+	}
+	if conditions, ok, _ := unstructured.NestedSlice(obj.Object, "status", "conditions"); ok {
+		for _, item := range conditions {
+			if condition, ok := item.(map[string]interface{}); ok {
+				if val, ok := condition["lastTransitionTime"].(string); ok {
+					timestamps = append(timestamps, val)
+				}
+			}
+		}
+	}
+
+	var res time.Time
+	for _, val := range timestamps {
+		if t, err := time.Parse(time.RFC3339, val); err == nil && t.After(res) {
+			res = t
+		}
+	}
+	return res, !res.IsZero()
+}
@@ -0,0 +1,42 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestLastTransitionTime(t *testing.T) {
+	finishedAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	app := NewApp("guestbook", WithSyncOperationStartAt(finishedAt.Add(-time.Minute)), WithSyncOperationFinishedAt(finishedAt))
+
+	transitionTime, ok := LastTransitionTime(app)
+
+	assert.True(t, ok)
+	assert.True(t, finishedAt.Equal(transitionTime))
+}
+
+func TestLastTransitionTime_NoTransitions(t *testing.T) {
+	_, ok := LastTransitionTime(NewApp("guestbook"))
+
+	assert.False(t, ok)
+}
+
+func TestLastTransitionTime_Workflow(t *testing.T) {
+	finishedAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	workflow := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"startedAt":  finishedAt.Add(-time.Minute).Format(time.RFC3339),
+			"finishedAt": finishedAt.Format(time.RFC3339),
+		},
+	}}
+
+	transitionTime, ok := LastTransitionTime(workflow)
+
+	assert.True(t, ok)
+	assert.True(t, finishedAt.Equal(transitionTime))
+}
@@ -0,0 +1,245 @@
+package legacy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+// sensitiveServiceFields are the legacy service fields moved to the secret during the migration
+var sensitiveServiceFields = []string{"password", "token", "apiKey", "signingSecret"}
+
+// MigrateConfig converts the deprecated 'config.yaml' config map key and 'notifiers.yaml' secret key into the current
+// format and sets the current schema version. Returns copies of the config map and secret and descriptions of the
+// performed changes
+func MigrateConfig(cm *v1.ConfigMap, secret *v1.Secret) (*v1.ConfigMap, *v1.Secret, []string, error) {
+	cm = cm.DeepCopy()
+	secret = secret.DeepCopy()
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	// manifest files usually use 'stringData' that is merged into 'data' by the API server
+	for k, v := range secret.StringData {
+		secret.Data[k] = []byte(v)
+	}
+	secret.StringData = nil
+	var changes []string
+	if configData, ok := cm.Data["config.yaml"]; ok {
+		configChanges, err := migrateConfigYAML(cm.Data, configData)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to migrate 'config.yaml': %v", err)
+		}
+		changes = append(changes, configChanges...)
+	}
+	if notifiersData, ok := secret.Data["notifiers.yaml"]; ok {
+		servicesChanges, err := migrateNotifiersYAML(cm.Data, secret.Data, notifiersData)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to migrate 'notifiers.yaml': %v", err)
+		}
+		changes = append(changes, servicesChanges...)
+	}
+	if len(changes) > 0 && cm.Data[settings.APIVersionKey] != settings.APIVersionV2 {
+		// the migrated config has no deprecated keys, so it is safe to opt into the current schema
+		cm.Data[settings.APIVersionKey] = settings.APIVersionV2
+		changes = append(changes, fmt.Sprintf("'%s' is set to '%s'", settings.APIVersionKey, settings.APIVersionV2))
+	}
+	return cm, secret, changes, nil
+}
+
+func marshalValue(val interface{}) (string, error) {
+	data, err := yaml.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// migrateConfigYAML applies the legacy config on top of the config map keys the same way as ApplyLegacyConfig does
+func migrateConfigYAML(data map[string]string, configData string) ([]string, error) {
+	legacyCfg := legacyConfig{}
+	if err := yaml.Unmarshal([]byte(configData), &legacyCfg); err != nil {
+		return nil, err
+	}
+	cfg := &settings.Config{
+		Config:  pkg.Config{Templates: map[string]services.Notification{}, Triggers: map[string][]triggers.Condition{}},
+		Context: map[string]string{},
+	}
+	for _, template := range legacyCfg.Templates {
+		if val, ok := data["template."+template.Name]; ok {
+			var notification services.Notification
+			if err := yaml.Unmarshal([]byte(val), &notification); err != nil {
+				return nil, err
+			}
+			cfg.Templates[template.Name] = notification
+		}
+	}
+	for _, trigger := range legacyCfg.Triggers {
+		if val, ok := data["trigger."+trigger.Name]; ok {
+			var conditions []triggers.Condition
+			if err := yaml.Unmarshal([]byte(val), &conditions); err != nil {
+				return nil, err
+			}
+			cfg.Triggers[trigger.Name] = conditions
+		}
+	}
+	for key, val := range map[string]interface{}{"context": &cfg.Context, "subscriptions": &cfg.Subscriptions, "defaultTriggers": &cfg.DefaultTriggers} {
+		if existing, ok := data[key]; ok {
+			if err := yaml.Unmarshal([]byte(existing), val); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := legacyCfg.merge(cfg); err != nil {
+		return nil, err
+	}
+
+	var changes []string
+	for _, template := range legacyCfg.Templates {
+		val, err := marshalValue(cfg.Templates[template.Name])
+		if err != nil {
+			return nil, err
+		}
+		data["template."+template.Name] = val
+		changes = append(changes, fmt.Sprintf("template '%s' is moved to 'template.%s' key", template.Name, template.Name))
+	}
+	for _, trigger := range legacyCfg.Triggers {
+		val, err := marshalValue(cfg.Triggers[trigger.Name])
+		if err != nil {
+			return nil, err
+		}
+		data["trigger."+trigger.Name] = val
+		changes = append(changes, fmt.Sprintf("trigger '%s' is moved to 'trigger.%s' key", trigger.Name, trigger.Name))
+	}
+	if len(legacyCfg.Context) > 0 {
+		val, err := marshalValue(cfg.Context)
+		if err != nil {
+			return nil, err
+		}
+		data["context"] = val
+		changes = append(changes, "context is moved to 'context' key")
+	}
+	if len(legacyCfg.Subscriptions) > 0 {
+		val, err := marshalValue(cfg.Subscriptions)
+		if err != nil {
+			return nil, err
+		}
+		data["subscriptions"] = val
+		changes = append(changes, "subscriptions are moved to 'subscriptions' key")
+	}
+	if len(cfg.DefaultTriggers) > 0 {
+		val, err := marshalValue(cfg.DefaultTriggers)
+		if err != nil {
+			return nil, err
+		}
+		data["defaultTriggers"] = val
+		changes = append(changes, "enabled triggers are moved to 'defaultTriggers' key")
+	}
+	delete(data, "config.yaml")
+	return changes, nil
+}
+
+// toSecretKey converts the service field name into the secret key name, e.g. 'slack' and 'signingSecret' into 'slack-signing-secret'
+func toSecretKey(service string, field string) string {
+	var res strings.Builder
+	res.WriteString(service + "-")
+	for _, ch := range field {
+		if ch >= 'A' && ch <= 'Z' {
+			res.WriteString("-")
+			ch = ch - 'A' + 'a'
+		}
+		res.WriteRune(ch)
+	}
+	return res.String()
+}
+
+// moveSensitiveFields replaces sensitive values of the service options with references to the secret keys
+func moveSensitiveFields(service string, opts map[string]interface{}, secretData map[string][]byte) []string {
+	var changes []string
+	move := func(fields map[string]interface{}, field string, prefix string) {
+		val, ok := fields[field].(string)
+		if !ok || val == "" || strings.HasPrefix(val, "$") {
+			return
+		}
+		path, keyField := field, field
+		if prefix != "" {
+			path, keyField = prefix+"."+field, prefix+strings.ToUpper(field[:1])+field[1:]
+		}
+		key := toSecretKey(service, keyField)
+		secretData[key] = []byte(val)
+		fields[field] = "$" + key
+		changes = append(changes, fmt.Sprintf("service '%s' field '%s' is moved to '%s' secret key", service, path, key))
+	}
+	for _, field := range sensitiveServiceFields {
+		move(opts, field, "")
+	}
+	if basicAuth, ok := opts["basicAuth"].(map[string]interface{}); ok {
+		move(basicAuth, "password", "basicAuth")
+	}
+	return changes
+}
+
+// migrateNotifiersYAML converts services of the legacy secret key into 'service.<type>(.<name>)' config map keys
+func migrateNotifiersYAML(data map[string]string, secretData map[string][]byte, notifiersData []byte) ([]string, error) {
+	var legacyServices map[string]interface{}
+	if err := yaml.Unmarshal(notifiersData, &legacyServices); err != nil {
+		return nil, err
+	}
+	var serviceTypes []string
+	for serviceType := range legacyServices {
+		serviceTypes = append(serviceTypes, serviceType)
+	}
+	sort.Strings(serviceTypes)
+
+	var changes []string
+	addService := func(key string, name string, opts map[string]interface{}) error {
+		changes = append(changes, moveSensitiveFields(name, opts, secretData)...)
+		val, err := marshalValue(opts)
+		if err != nil {
+			return err
+		}
+		data[key] = val
+		changes = append(changes, fmt.Sprintf("service '%s' is moved to '%s' key", name, key))
+		return nil
+	}
+	for _, serviceType := range serviceTypes {
+		switch opts := legacyServices[serviceType].(type) {
+		case nil:
+			continue
+		case map[string]interface{}:
+			if err := addService("service."+serviceType, serviceType, opts); err != nil {
+				return nil, err
+			}
+		case []interface{}:
+			// webhooks are configured using the list of named options
+			for _, item := range opts {
+				webhook, ok := item.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("invalid '%s' service options", serviceType)
+				}
+				name, _ := webhook["name"].(string)
+				if name == "" {
+					return nil, fmt.Errorf("'%s' service name is not specified", serviceType)
+				}
+				delete(webhook, "name")
+				if err := addService(fmt.Sprintf("service.%s.%s", serviceType, name), name, webhook); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid '%s' service options", serviceType)
+		}
+	}
+	delete(secretData, "notifiers.yaml")
+	return changes, nil
+}
@@ -0,0 +1,74 @@
+package legacy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	cm := &v1.ConfigMap{Data: map[string]string{
+		"config.yaml": `
+triggers:
+- name: on-sync-status-unknown
+  condition: app.status.sync.status == 'Unknown'
+  template: app-sync-status
+  enabled: true
+templates:
+- name: app-sync-status
+  title: Application {{.app.metadata.name}} sync status is {{.app.status.sync.status}}
+  body: Application {{.app.metadata.name}} sync is {{.app.status.sync.status}}
+context:
+  argocdUrl: https://argocd.example.com
+`,
+	}}
+	secret := &v1.Secret{Data: map[string][]byte{
+		"notifiers.yaml": []byte(`
+slack:
+  token: abc
+webhook:
+- name: github
+  url: https://api.github.com
+  basicAuth:
+    username: bot
+    password: $github-password
+`),
+	}}
+
+	migratedCM, migratedSecret, changes, err := MigrateConfig(cm, secret)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[string]string{
+		"apiVersion": "v2",
+		"trigger.on-sync-status-unknown": `- send:
+  - app-sync-status
+  when: app.status.sync.status == 'Unknown'
+`,
+		"template.app-sync-status": `email:
+  subject: Application {{.app.metadata.name}} sync status is {{.app.status.sync.status}}
+message: Application {{.app.metadata.name}} sync is {{.app.status.sync.status}}
+`,
+		"context":         "argocdUrl: https://argocd.example.com\n",
+		"defaultTriggers": "- on-sync-status-unknown\n",
+		"service.slack":   "token: $slack-token\n",
+		"service.webhook.github": `basicAuth:
+  password: $github-password
+  username: bot
+url: https://api.github.com
+`,
+	}, migratedCM.Data)
+	assert.Equal(t, map[string][]byte{"slack-token": []byte("abc")}, migratedSecret.Data)
+	assert.Contains(t, changes, "service 'slack' field 'token' is moved to 'slack-token' secret key")
+	assert.Contains(t, changes, "trigger 'on-sync-status-unknown' is moved to 'trigger.on-sync-status-unknown' key")
+
+	// the original objects are not modified
+	assert.Contains(t, cm.Data, "config.yaml")
+	assert.Contains(t, secret.Data, "notifiers.yaml")
+}
+
+func TestToSecretKey(t *testing.T) {
+	assert.Equal(t, "slack-signing-secret", toSecretKey("slack", "signingSecret"))
+	assert.Equal(t, "email-password", toSecretKey("email", "password"))
+}
@@ -11,7 +11,6 @@ import (
 	jsonpatch "github.com/evanphx/json-patch"
 
 	"github.com/ghodss/yaml"
-	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 
 	"github.com/argoproj-labs/argocd-notifications/pkg"
@@ -161,7 +160,6 @@ func (c *legacyServicesConfig) merge(cfg *pkg.Config) {
 // ApplyLegacyConfig settings specified using deprecated config map and secret keys
 func ApplyLegacyConfig(cfg *settings.Config, cm *v1.ConfigMap, secret *v1.Secret) error {
 	if notifiersData, ok := secret.Data["notifiers.yaml"]; ok && len(notifiersData) > 0 {
-		log.Warn("Key 'notifiers.yaml' in Secret is deprecated, please migrate to new settings")
 		legacyServices := &legacyServicesConfig{}
 		err := yaml.Unmarshal(notifiersData, legacyServices)
 		if err != nil {
@@ -171,7 +169,6 @@ func ApplyLegacyConfig(cfg *settings.Config, cm *v1.ConfigMap, secret *v1.Secret
 	}
 
 	if configData, ok := cm.Data["config.yaml"]; ok && configData != "" {
-		log.Warn("Key 'config.yaml' in ConfigMap is deprecated, please migrate to new settings")
 		legacyCfg := &legacyConfig{}
 		err := yaml.Unmarshal([]byte(configData), legacyCfg)
 		if err != nil {
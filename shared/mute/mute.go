@@ -0,0 +1,39 @@
+package mute
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+)
+
+// Mute suppresses notifications of the application or project for the specified duration. The suppression window is
+// stored in the resource annotation, so it survives controller restarts
+func Mute(ctx context.Context, client dynamic.ResourceInterface, name string, duration time.Duration, user string) error {
+	val := triggers.NewMute(user, duration).String()
+	return patchMute(ctx, client, name, &val)
+}
+
+// Unmute removes the suppression window of the application or project
+func Unmute(ctx context.Context, client dynamic.ResourceInterface, name string) error {
+	return patchMute(ctx, client, name, nil)
+}
+
+func patchMute(ctx context.Context, client dynamic.ResourceInterface, name string, val *string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]*string{subscriptions.MutedAnnotationKey: val},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
@@ -0,0 +1,46 @@
+package mute
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func TestMute(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("guestbook"))
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+
+	err := Mute(context.Background(), k8s.NewAppClient(client, TestNamespace), "guestbook", time.Hour, "alice")
+
+	if !assert.NoError(t, err) || !assert.Len(t, patches, 1) {
+		return
+	}
+	annotations := patches[0]["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	mute := triggers.ParseMute(annotations[subscriptions.MutedAnnotationKey].(string))
+	assert.Equal(t, "alice", mute.User)
+	assert.True(t, mute.IsActive(time.Now().Add(59*time.Minute)))
+}
+
+func TestUnmute(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), NewApp("guestbook"))
+	var patches []map[string]interface{}
+	AddPatchCollectorReactor(client, &patches)
+
+	err := Unmute(context.Background(), k8s.NewAppClient(client, TestNamespace), "guestbook")
+
+	if !assert.NoError(t, err) || !assert.Len(t, patches, 1) {
+		return
+	}
+	annotations := patches[0]["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	assert.Nil(t, annotations[subscriptions.MutedAnnotationKey])
+}
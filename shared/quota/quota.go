@@ -0,0 +1,238 @@
+package quota
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+)
+
+const (
+	// PerProject counts notifications of all applications of the project
+	PerProject = "project"
+	// PerDestination counts notifications sent to the same destination
+	PerDestination = "destination"
+
+	window = time.Hour
+	// maxSummaryApps limits the number of application names listed in the summary
+	maxSummaryApps = 10
+)
+
+// Rule limits the number of notifications sent during one hour
+type Rule struct {
+	// Projects are glob patterns of Argo CD projects the rule applies to; the rule applies to all projects if empty
+	Projects []string `json:"projects,omitempty"`
+	// Services are names of notification services the rule applies to; the rule applies to all services if empty
+	Services []string `json:"services,omitempty"`
+	// Per is either project or destination and defines how notifications are counted. Defaults to project
+	Per string `json:"per,omitempty"`
+	// MaxPerHour is the max number of notifications sent during one hour
+	MaxPerHour int `json:"maxPerHour"`
+}
+
+// Rules holds quota rules. The notification is sent only if all matching rules allow it
+type Rules []Rule
+
+// Validate returns an error if any of rules is invalid
+func (r Rules) Validate() error {
+	for i, rule := range r {
+		if rule.MaxPerHour <= 0 {
+			return fmt.Errorf("quota %d: maxPerHour must be positive", i)
+		}
+		if rule.Per != "" && rule.Per != PerProject && rule.Per != PerDestination {
+			return fmt.Errorf("quota %d: per must be either %s or %s", i, PerProject, PerDestination)
+		}
+		for _, pattern := range rule.Projects {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("quota %d: invalid project pattern '%s': %v", i, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Matching returns rules that apply to notifications of the project applications sent to the destination
+func (r Rules) Matching(project string, dest services.Destination) Rules {
+	var res Rules
+	for _, rule := range r {
+		if rule.matches(project, dest) {
+			res = append(res, rule)
+		}
+	}
+	return res
+}
+
+func (r Rule) matches(project string, dest services.Destination) bool {
+	if len(r.Services) > 0 && !contains(r.Services, dest.Service) {
+		return false
+	}
+	if len(r.Projects) == 0 {
+		return true
+	}
+	for _, pattern := range r.Projects {
+		if ok, _ := filepath.Match(pattern, project); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) key(index int, project string, dest services.Destination) string {
+	if r.Per == PerDestination {
+		return fmt.Sprintf("%d/%s:%s", index, dest.Service, dest.Recipient)
+	}
+	return fmt.Sprintf("%d/%s", index, project)
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary describes notifications that were not sent because of the quota
+type Summary struct {
+	Project     string
+	Destination services.Destination
+	Suppressed  int
+	Apps        []string
+}
+
+// Message returns the human readable summary
+func (s Summary) Message() string {
+	apps := s.Apps
+	more := ""
+	if len(apps) > maxSummaryApps {
+		more = fmt.Sprintf(" and %d more", len(apps)-maxSummaryApps)
+		apps = apps[:maxSummaryApps]
+	}
+	return fmt.Sprintf("[argocd-notifications] %d notifications about applications of project %s were not sent because of the notification quota: %s%s",
+		s.Suppressed, s.Project, strings.Join(apps, ", "), more)
+}
+
+func overflowKey(project string, dest services.Destination) string {
+	return project + "/" + dest.Service + ":" + dest.Recipient
+}
+
+type overflow struct {
+	project    string
+	dest       services.Destination
+	suppressed int
+	apps       map[string]bool
+}
+
+// Tracker counts sent notifications and collects notifications that exceed quotas. Counters are kept in memory,
+// so every controller replica enforces quotas independently.
+type Tracker struct {
+	lock     sync.Mutex
+	rules    Rules
+	sent     map[string][]time.Time
+	overflow map[string]*overflow
+	now      func() time.Time
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{sent: map[string][]time.Time{}, overflow: map[string]*overflow{}, now: time.Now}
+}
+
+// Configure updates quota rules. Counters are preserved unless rules have changed
+func (t *Tracker) Configure(rules Rules) error {
+	if err := rules.Validate(); err != nil {
+		return err
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !reflect.DeepEqual(t.rules, rules) {
+		t.sent = map[string][]time.Time{}
+	}
+	t.rules = rules
+	return nil
+}
+
+// hasCapacity returns keys of matching rules if all of them allow one more notification
+func (t *Tracker) hasCapacity(project string, dest services.Destination, now time.Time) ([]string, bool) {
+	var keys []string
+	for i, rule := range t.rules {
+		if !rule.matches(project, dest) {
+			continue
+		}
+		key := rule.key(i, project, dest)
+		items := t.sent[key]
+		start := 0
+		for start < len(items) && now.Sub(items[start]) >= window {
+			start++
+		}
+		items = items[start:]
+		if len(items) == 0 {
+			delete(t.sent, key)
+		} else {
+			t.sent[key] = items
+		}
+		if len(items) >= rule.MaxPerHour {
+			return nil, false
+		}
+		keys = append(keys, key)
+	}
+	return keys, true
+}
+
+// Allow returns true and counts the notification if it is allowed by all matching rules. Otherwise, the notification
+// is added to the overflow summary of the destination.
+func (t *Tracker) Allow(project string, dest services.Destination, app string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	now := t.now()
+	keys, ok := t.hasCapacity(project, dest, now)
+	if !ok {
+		key := overflowKey(project, dest)
+		item, exists := t.overflow[key]
+		if !exists {
+			item = &overflow{project: project, dest: dest, apps: map[string]bool{}}
+			t.overflow[key] = item
+		}
+		item.suppressed++
+		item.apps[app] = true
+		return false
+	}
+	for _, key := range keys {
+		t.sent[key] = append(t.sent[key], now)
+	}
+	return true
+}
+
+// Flush returns summaries of suppressed notifications for destinations that are within the quota again. Every
+// summary is counted as one notification.
+func (t *Tracker) Flush() []Summary {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	now := t.now()
+	var res []Summary
+	for key, item := range t.overflow {
+		keys, ok := t.hasCapacity(item.project, item.dest, now)
+		if !ok {
+			continue
+		}
+		for _, k := range keys {
+			t.sent[k] = append(t.sent[k], now)
+		}
+		summary := Summary{Project: item.project, Destination: item.dest, Suppressed: item.suppressed}
+		for app := range item.apps {
+			summary.Apps = append(summary.Apps, app)
+		}
+		sort.Strings(summary.Apps)
+		res = append(res, summary)
+		delete(t.overflow, key)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return overflowKey(res[i].Project, res[i].Destination) < overflowKey(res[j].Project, res[j].Destination)
+	})
+	return res
+}
@@ -0,0 +1,97 @@
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+)
+
+var (
+	paymentsSlack = services.Destination{Service: "slack", Recipient: "payments"}
+	paymentsEmail = services.Destination{Service: "email", Recipient: "payments@example.com"}
+)
+
+func newTestTracker(t *testing.T, rules Rules) (*Tracker, *time.Time) {
+	tracker := NewTracker()
+	now := time.Now()
+	tracker.now = func() time.Time {
+		return now
+	}
+	if !assert.NoError(t, tracker.Configure(rules)) {
+		t.FailNow()
+	}
+	return tracker, &now
+}
+
+func TestRules_Validate(t *testing.T) {
+	assert.NoError(t, Rules{{MaxPerHour: 10, Projects: []string{"team-*"}, Per: PerDestination}}.Validate())
+	assert.Error(t, Rules{{}}.Validate())
+	assert.Error(t, Rules{{MaxPerHour: 10, Per: "app"}}.Validate())
+	assert.Error(t, Rules{{MaxPerHour: 10, Projects: []string{"team-["}}}.Validate())
+}
+
+func TestRules_Matching(t *testing.T) {
+	rules := Rules{
+		{MaxPerHour: 10, Projects: []string{"team-*"}},
+		{MaxPerHour: 5, Services: []string{"email"}},
+		{MaxPerHour: 1, Projects: []string{"other"}},
+	}
+
+	assert.Equal(t, Rules{rules[0]}, rules.Matching("team-payments", paymentsSlack))
+	assert.Equal(t, Rules{rules[0], rules[1]}, rules.Matching("team-payments", paymentsEmail))
+	assert.Empty(t, rules.Matching("default", paymentsSlack))
+}
+
+func TestTracker_PerProject(t *testing.T) {
+	tracker, now := newTestTracker(t, Rules{{MaxPerHour: 2, Projects: []string{"payments"}}})
+
+	assert.True(t, tracker.Allow("payments", paymentsSlack, "app-1"))
+	assert.True(t, tracker.Allow("payments", paymentsEmail, "app-2"))
+	assert.False(t, tracker.Allow("payments", paymentsSlack, "app-1"))
+	assert.False(t, tracker.Allow("payments", paymentsSlack, "app-3"))
+	// other projects are not limited
+	assert.True(t, tracker.Allow("frontend", paymentsSlack, "app-4"))
+	assert.Empty(t, tracker.Flush())
+
+	*now = now.Add(time.Hour)
+	summaries := tracker.Flush()
+	assert.Equal(t, []Summary{{Project: "payments", Destination: paymentsSlack, Suppressed: 2, Apps: []string{"app-1", "app-3"}}}, summaries)
+	assert.Equal(t, "[argocd-notifications] 2 notifications about applications of project payments were not sent because of the notification quota: app-1, app-3",
+		summaries[0].Message())
+	// the summary counts as one notification
+	assert.True(t, tracker.Allow("payments", paymentsSlack, "app-1"))
+	assert.False(t, tracker.Allow("payments", paymentsSlack, "app-1"))
+}
+
+func TestTracker_PerDestination(t *testing.T) {
+	tracker, _ := newTestTracker(t, Rules{{MaxPerHour: 1, Services: []string{"slack"}, Per: PerDestination}})
+
+	assert.True(t, tracker.Allow("payments", paymentsSlack, "app-1"))
+	assert.False(t, tracker.Allow("frontend", paymentsSlack, "app-2"))
+	assert.True(t, tracker.Allow("payments", services.Destination{Service: "slack", Recipient: "frontend"}, "app-1"))
+	assert.True(t, tracker.Allow("payments", paymentsEmail, "app-1"))
+}
+
+func TestTracker_Configure(t *testing.T) {
+	rules := Rules{{MaxPerHour: 1}}
+	tracker, _ := newTestTracker(t, rules)
+	assert.True(t, tracker.Allow("payments", paymentsSlack, "app-1"))
+
+	// counters are preserved if rules are the same
+	assert.NoError(t, tracker.Configure(Rules{{MaxPerHour: 1}}))
+	assert.False(t, tracker.Allow("payments", paymentsSlack, "app-1"))
+
+	assert.NoError(t, tracker.Configure(Rules{{MaxPerHour: 2}}))
+	assert.True(t, tracker.Allow("payments", paymentsSlack, "app-1"))
+}
+
+func TestSummary_MessageTruncatesApps(t *testing.T) {
+	summary := Summary{Project: "payments", Suppressed: 12}
+	for i := 0; i < 12; i++ {
+		summary.Apps = append(summary.Apps, string(rune('a'+i)))
+	}
+	assert.Contains(t, summary.Message(), "a, b, c, d, e, f, g, h, i, j and 2 more")
+}
@@ -0,0 +1,198 @@
+package selfmonitoring
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+)
+
+const (
+	EventConfigError            = "config-error"
+	EventDeliveryErrorRate      = "delivery-error-rate"
+	EventDestinationUnreachable = "destination-unreachable"
+)
+
+const (
+	defaultErrorRateThreshold = 0.5
+	defaultMinDeliveries      = 10
+	defaultWindow             = "10m"
+	defaultRepeatInterval     = "1h"
+	messagePrefix             = "[argocd-notifications] "
+	// maxDeliveriesPerService limits memory used to track deliveries of the busy service
+	maxDeliveriesPerService = 10000
+)
+
+// Options holds settings of the notifications about the controller itself
+type Options struct {
+	// Recipients holds destinations in <service>:<recipient> format that receive notifications about the controller problems
+	Recipients []string `json:"recipients,omitempty"`
+	// ErrorRateThreshold is the fraction of failed deliveries of the service within the window that triggers the notification
+	ErrorRateThreshold float64 `json:"errorRateThreshold,omitempty"`
+	// MinDeliveries is the min number of deliveries of the service within the window required to evaluate the error rate
+	MinDeliveries int `json:"minDeliveries,omitempty"`
+	// Window is the duration of the window used to calculate the error rate
+	Window string `json:"window,omitempty"`
+	// RepeatInterval is the min interval between notifications about the same problem
+	RepeatInterval string `json:"repeatInterval,omitempty"`
+}
+
+type parsedOptions struct {
+	destinations       []services.Destination
+	errorRateThreshold float64
+	minDeliveries      int
+	window             time.Duration
+	repeatInterval     time.Duration
+}
+
+func (o Options) parse() (*parsedOptions, error) {
+	res := parsedOptions{errorRateThreshold: o.ErrorRateThreshold, minDeliveries: o.MinDeliveries}
+	if res.errorRateThreshold == 0 {
+		res.errorRateThreshold = defaultErrorRateThreshold
+	}
+	if res.errorRateThreshold < 0 || res.errorRateThreshold > 1 {
+		return nil, errors.New("errorRateThreshold must be between 0 and 1")
+	}
+	if res.minDeliveries <= 0 {
+		res.minDeliveries = defaultMinDeliveries
+	}
+	window, repeatInterval := o.Window, o.RepeatInterval
+	if window == "" {
+		window = defaultWindow
+	}
+	if repeatInterval == "" {
+		repeatInterval = defaultRepeatInterval
+	}
+	var err error
+	if res.window, err = time.ParseDuration(window); err != nil {
+		return nil, fmt.Errorf("invalid window: %v", err)
+	}
+	if res.repeatInterval, err = time.ParseDuration(repeatInterval); err != nil {
+		return nil, fmt.Errorf("invalid repeatInterval: %v", err)
+	}
+	for _, recipient := range o.Recipients {
+		parts := strings.SplitN(recipient, ":", 2)
+		dest := services.Destination{Service: parts[0]}
+		if len(parts) > 1 {
+			dest.Recipient = parts[1]
+		}
+		res.destinations = append(res.destinations, dest)
+	}
+	return &res, nil
+}
+
+// Validate returns an error if options are invalid
+func (o Options) Validate() error {
+	_, err := o.parse()
+	return err
+}
+
+type delivery struct {
+	timestamp time.Time
+	failed    bool
+}
+
+// Monitor sends notifications about configuration errors and failing notification services to the configured recipients
+type Monitor struct {
+	lock       sync.Mutex
+	api        pkg.API
+	opts       *parsedOptions
+	deliveries map[string][]delivery
+	lastSent   map[string]time.Time
+	now        func() time.Time
+}
+
+func NewMonitor() *Monitor {
+	return &Monitor{deliveries: map[string][]delivery{}, lastSent: map[string]time.Time{}, now: time.Now}
+}
+
+// Configure updates the API used to send notifications and monitoring settings. Monitoring is disabled if no recipients are configured
+func (m *Monitor) Configure(api pkg.API, opts Options) error {
+	parsed, err := opts.parse()
+	if err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.api = api
+	m.opts = parsed
+	return nil
+}
+
+// ReportConfigError notifies about the configuration that cannot be loaded. The last valid configuration is used to send the notification
+func (m *Monitor) ReportConfigError(err error) {
+	m.notify(EventConfigError, fmt.Sprintf("failed to load notifications configuration: %v", err))
+}
+
+// ObserveDelivery records the notification delivery result and notifies if the destination is unreachable or if the
+// error rate of the service exceeds the threshold
+func (m *Monitor) ObserveDelivery(dest services.Destination, err error) {
+	m.lock.Lock()
+	if m.opts == nil || len(m.opts.destinations) == 0 {
+		m.lock.Unlock()
+		return
+	}
+	now := m.now()
+	items := append(m.deliveries[dest.Service], delivery{timestamp: now, failed: err != nil})
+	start := 0
+	for start < len(items) && (now.Sub(items[start].timestamp) > m.opts.window || len(items)-start > maxDeliveriesPerService) {
+		start++
+	}
+	items = items[start:]
+	m.deliveries[dest.Service] = items
+	failed := 0
+	for _, item := range items {
+		if item.failed {
+			failed++
+		}
+	}
+	errorRateExceeded := len(items) >= m.opts.minDeliveries && float64(failed)/float64(len(items)) >= m.opts.errorRateThreshold
+	window := m.opts.window
+	m.lock.Unlock()
+
+	if err != nil {
+		if class := services.ErrorClass(err); class == services.ErrorClassNetwork || class == services.ErrorClassTimeout {
+			m.notify(EventDestinationUnreachable+"/"+dest.Service+"/"+dest.Recipient,
+				fmt.Sprintf("destination %s of service %s is unreachable: %v", dest.Recipient, dest.Service, err))
+		}
+	}
+	if errorRateExceeded {
+		m.notify(EventDeliveryErrorRate+"/"+dest.Service,
+			fmt.Sprintf("%d of %d notifications sent using service %s during last %v have failed", failed, len(items), dest.Service, window))
+	}
+}
+
+func (m *Monitor) notify(key string, message string) {
+	m.lock.Lock()
+	if m.api == nil || m.opts == nil || len(m.opts.destinations) == 0 {
+		m.lock.Unlock()
+		return
+	}
+	now := m.now()
+	if last, ok := m.lastSent[key]; ok && now.Sub(last) < m.opts.repeatInterval {
+		m.lock.Unlock()
+		return
+	}
+	m.lastSent[key] = now
+	api, destinations := m.api, m.opts.destinations
+	m.lock.Unlock()
+
+	notificationServices := api.GetNotificationServices()
+	for _, dest := range destinations {
+		svc, ok := notificationServices[dest.Service]
+		if !ok {
+			log.Warnf("Self-monitoring notification service '%s' is not configured", dest.Service)
+			continue
+		}
+		// the delivery is sent directly to the service and is not observed to avoid notifying about own failures
+		if err := svc.Send(services.Notification{Message: messagePrefix + message}, dest); err != nil {
+			log.Warnf("Failed to send self-monitoring notification to %v: %v", dest, err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package selfmonitoring
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/mocks"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	servicemocks "github.com/argoproj-labs/argocd-notifications/pkg/services/mocks"
+)
+
+var opsDestination = services.Destination{Service: "slack", Recipient: "ops"}
+
+func newTestMonitor(t *testing.T, opts Options) (*Monitor, *servicemocks.MockNotificationService, *gomock.Controller) {
+	ctrl := gomock.NewController(t)
+	api := mocks.NewMockAPI(ctrl)
+	svc := servicemocks.NewMockNotificationService(ctrl)
+	api.EXPECT().GetNotificationServices().Return(map[string]services.NotificationService{"slack": svc}).AnyTimes()
+	monitor := NewMonitor()
+	if !assert.NoError(t, monitor.Configure(api, opts)) {
+		t.FailNow()
+	}
+	return monitor, svc, ctrl
+}
+
+func TestReportConfigError(t *testing.T) {
+	monitor, svc, ctrl := newTestMonitor(t, Options{Recipients: []string{"slack:ops"}})
+	defer ctrl.Finish()
+
+	svc.EXPECT().Send(services.Notification{Message: "[argocd-notifications] failed to load notifications configuration: bad trigger"}, opsDestination).Return(nil)
+
+	monitor.ReportConfigError(errors.New("bad trigger"))
+	// repeated notification is suppressed until repeat interval passes
+	monitor.ReportConfigError(errors.New("bad trigger"))
+}
+
+func TestObserveDelivery_ErrorRate(t *testing.T) {
+	monitor, svc, ctrl := newTestMonitor(t, Options{Recipients: []string{"slack:ops"}, MinDeliveries: 4, ErrorRateThreshold: 0.5})
+	defer ctrl.Finish()
+	now := time.Now()
+	monitor.now = func() time.Time {
+		return now
+	}
+
+	dest := services.Destination{Service: "email", Recipient: "team@example.com"}
+	monitor.ObserveDelivery(dest, nil)
+	monitor.ObserveDelivery(dest, nil)
+	monitor.ObserveDelivery(dest, errors.New("fail"))
+
+	svc.EXPECT().Send(services.Notification{Message: "[argocd-notifications] 2 of 4 notifications sent using service email during last 10m0s have failed"}, opsDestination).Return(nil)
+	monitor.ObserveDelivery(dest, errors.New("fail"))
+
+	// deliveries outside of the window are forgotten
+	now = now.Add(2 * time.Hour)
+	monitor.ObserveDelivery(dest, errors.New("fail"))
+}
+
+func TestObserveDelivery_Unreachable(t *testing.T) {
+	monitor, svc, ctrl := newTestMonitor(t, Options{Recipients: []string{"slack:ops"}})
+	defer ctrl.Finish()
+
+	svc.EXPECT().Send(gomock.Any(), opsDestination).Return(nil)
+
+	monitor.ObserveDelivery(services.Destination{Service: "webhook", Recipient: "github"}, &net.OpError{Op: "dial", Err: errors.New("connection refused")})
+}
+
+func TestObserveDelivery_Disabled(t *testing.T) {
+	monitor, _, ctrl := newTestMonitor(t, Options{})
+	defer ctrl.Finish()
+
+	monitor.ObserveDelivery(services.Destination{Service: "webhook", Recipient: "github"}, &net.OpError{Op: "dial", Err: errors.New("connection refused")})
+}
+
+func TestOptions_Validate(t *testing.T) {
+	assert.NoError(t, Options{}.Validate())
+	assert.Error(t, Options{Window: "abc"}.Validate())
+	assert.Error(t, Options{ErrorRateThreshold: 2}.Validate())
+}
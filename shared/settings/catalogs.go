@@ -0,0 +1,39 @@
+package settings
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/catalog"
+)
+
+// CatalogsKey is the config map key with the list of catalogs that provide triggers and templates
+const CatalogsKey = "catalogs"
+
+// catalogsFragmentName is the name of the config fragment with triggers and templates of all catalogs
+const catalogsFragmentName = "catalogs"
+
+// LoadCatalogs returns the config fragment with triggers and templates of catalogs referenced in the config map or nil
+// if the config map does not reference catalogs. Catalogs are never downloaded while the config is loaded: catalogs that
+// are not cached yet are downloaded in the background, catalog.ErrPending is returned and onLoaded is called once the
+// download completes
+func LoadCatalogs(ctx context.Context, loader *catalog.Loader, configMap *v1.ConfigMap, onLoaded func()) (*v1.ConfigMap, error) {
+	val, ok := configMap.Data[CatalogsKey]
+	if !ok {
+		return nil, nil
+	}
+	sources, err := catalog.ParseSources(val)
+	if err != nil {
+		return nil, err
+	}
+	data, err := loader.LoadCached(ctx, sources, onLoaded)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: catalogsFragmentName, Namespace: configMap.Namespace},
+		Data:       data,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package settings
+
+import (
+	"encoding/json"
+
+	"github.com/ghodss/yaml"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/triggers"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/redact"
+)
+
+// effectiveConfig is the representation of the controller configuration that is safe to print
+type effectiveConfig struct {
+	Triggers        map[string][]triggers.Condition  `json:"triggers"`
+	Templates       map[string]services.Notification `json:"templates"`
+	Services        map[string]interface{}           `json:"services"`
+	Context         map[string]string                `json:"context"`
+	Subscriptions   DefaultSubscriptions             `json:"subscriptions"`
+	DefaultTriggers []string                         `json:"defaultTriggers"`
+}
+
+// RedactedConfig returns JSON representation of the effective configuration with all secret values replaced by asterisks
+func RedactedConfig(cfg Config, configMap *v1.ConfigMap, secret *v1.Secret) ([]byte, error) {
+	res := effectiveConfig{
+		Triggers:        cfg.Triggers,
+		Templates:       cfg.Templates,
+		Services:        map[string]interface{}{},
+		Context:         cfg.Context,
+		Subscriptions:   cfg.Subscriptions,
+		DefaultTriggers: cfg.DefaultTriggers,
+	}
+	for name := range cfg.Services {
+		var serviceSettings interface{}
+		if configMap != nil {
+			if val, ok := configMap.Data["service."+name]; ok {
+				if err := yaml.Unmarshal([]byte(val), &serviceSettings); err != nil {
+					serviceSettings = val
+				}
+			}
+		}
+		res.Services[name] = serviceSettings
+	}
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	values := cfg.SecretValues
+	if secret != nil {
+		for _, val := range secret.Data {
+			values = append(values, string(val))
+		}
+	}
+	return []byte(redact.New(values...).String(string(data))), nil
+}
@@ -0,0 +1,36 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestRedactedConfig(t *testing.T) {
+	configMap := &v1.ConfigMap{
+		Data: map[string]string{
+			"service.slack": `{token: $slack-token, username: argocd}`,
+			"context":       `{argocdUrl: "https://argocd.example.com", apiKey: "my-inline-token"}`,
+		},
+	}
+	secret := &v1.Secret{Data: map[string][]byte{
+		"slack-token":  []byte("xoxb-secret"),
+		"inline-token": []byte("my-inline-token"),
+	}}
+	cfg, err := NewConfig(configMap, secret, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	data, err := RedactedConfig(*cfg, configMap, secret)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	output := string(data)
+	assert.NotContains(t, output, "xoxb-secret")
+	assert.NotContains(t, output, "my-inline-token")
+	assert.Contains(t, output, `"apiKey": "******"`)
+	assert.Contains(t, output, `"username": "argocd"`)
+}
@@ -0,0 +1,99 @@
+package settings
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// AppFilter limits the set of applications processed by the controller
+type AppFilter struct {
+	// Projects holds glob patterns of the included application projects. All projects are included if empty
+	Projects []string `json:"projects,omitempty"`
+	// ExcludeProjects holds glob patterns of the excluded application projects
+	ExcludeProjects []string `json:"excludeProjects,omitempty"`
+	// Namespaces holds glob patterns of the included application destination namespaces. All namespaces are included if empty
+	Namespaces []string `json:"namespaces,omitempty"`
+	// ExcludeNamespaces holds glob patterns of the excluded application destination namespaces
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+	// Names holds glob patterns of the included application names. All applications are included if empty
+	Names []string `json:"names,omitempty"`
+	// ExcludeNames holds glob patterns of the excluded application names
+	ExcludeNames []string `json:"excludeNames,omitempty"`
+	// LabelSelector is the label selector application must match
+	LabelSelector string `json:"labelSelector,omitempty"`
+	// FieldSelector is the selector of application fields in a dot notation, e.g. spec.destination.server=https://kubernetes.default.svc
+	FieldSelector string `json:"fieldSelector,omitempty"`
+}
+
+// Validate returns an error if filter has invalid patterns or selectors
+func (f AppFilter) Validate() error {
+	for _, patterns := range [][]string{f.Projects, f.ExcludeProjects, f.Namespaces, f.ExcludeNamespaces, f.Names, f.ExcludeNames} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+			}
+		}
+	}
+	if _, err := labels.Parse(f.LabelSelector); err != nil {
+		return fmt.Errorf("invalid label selector '%s': %v", f.LabelSelector, err)
+	}
+	if _, err := fields.ParseSelector(f.FieldSelector); err != nil {
+		return fmt.Errorf("invalid field selector '%s': %v", f.FieldSelector, err)
+	}
+	return nil
+}
+
+func matchesAny(patterns []string, val string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, val); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func included(include []string, exclude []string, val string) bool {
+	if len(include) > 0 && !matchesAny(include, val) {
+		return false
+	}
+	return !matchesAny(exclude, val)
+}
+
+// Matches returns true if application passes the filter
+func (f AppFilter) Matches(app *unstructured.Unstructured) bool {
+	project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+	namespace, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "namespace")
+	if !included(f.Projects, f.ExcludeProjects, project) ||
+		!included(f.Namespaces, f.ExcludeNamespaces, namespace) ||
+		!included(f.Names, f.ExcludeNames, app.GetName()) {
+		return false
+	}
+	if f.LabelSelector != "" {
+		selector, err := labels.Parse(f.LabelSelector)
+		if err != nil || !selector.Matches(labels.Set(app.GetLabels())) {
+			return false
+		}
+	}
+	if f.FieldSelector != "" {
+		selector, err := fields.ParseSelector(f.FieldSelector)
+		if err != nil {
+			return false
+		}
+		values := fields.Set{}
+		for _, requirement := range selector.Requirements() {
+			val, ok, err := unstructured.NestedFieldNoCopy(app.Object, strings.Split(requirement.Field, ".")...)
+			if ok && err == nil {
+				values[requirement.Field] = fmt.Sprintf("%v", val)
+			}
+		}
+		if !selector.Matches(values) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func withDestinationNamespace(namespace string) func(app *unstructured.Unstructured) {
+	return func(app *unstructured.Unstructured) {
+		_ = unstructured.SetNestedField(app.Object, namespace, "spec", "destination", "namespace")
+	}
+}
+
+func withLabels(labels map[string]string) func(app *unstructured.Unstructured) {
+	return func(app *unstructured.Unstructured) {
+		app.SetLabels(labels)
+	}
+}
+
+func TestAppFilter_Matches(t *testing.T) {
+	sandboxApp := NewApp("sandbox-guestbook", WithProject("sandbox"), withDestinationNamespace("sandbox-1"))
+	prodApp := NewApp("guestbook", WithProject("default"), withDestinationNamespace("prod"), withLabels(map[string]string{"team": "a"}))
+
+	testCases := []struct {
+		name   string
+		filter AppFilter
+		app    *unstructured.Unstructured
+		match  bool
+	}{
+		{"empty filter", AppFilter{}, sandboxApp, true},
+		{"excluded project", AppFilter{ExcludeProjects: []string{"sandbox"}}, sandboxApp, false},
+		{"included project", AppFilter{Projects: []string{"def*"}}, prodApp, true},
+		{"not included project", AppFilter{Projects: []string{"def*"}}, sandboxApp, false},
+		{"excluded namespace", AppFilter{ExcludeNamespaces: []string{"sandbox-*"}}, sandboxApp, false},
+		{"included namespace", AppFilter{Namespaces: []string{"prod"}}, prodApp, true},
+		{"excluded name", AppFilter{ExcludeNames: []string{"sandbox-*"}}, sandboxApp, false},
+		{"included name", AppFilter{Names: []string{"guest*"}}, prodApp, true},
+		{"label selector", AppFilter{LabelSelector: "team=a"}, prodApp, true},
+		{"label selector mismatch", AppFilter{LabelSelector: "team=a"}, sandboxApp, false},
+		{"field selector", AppFilter{FieldSelector: "spec.destination.namespace=prod"}, prodApp, true},
+		{"field selector mismatch", AppFilter{FieldSelector: "spec.destination.namespace!=prod"}, prodApp, false},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.match, tc.filter.Matches(tc.app), tc.name)
+	}
+}
+
+func TestNewConfig_AppFilter(t *testing.T) {
+	cfg, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"appFilter": `
+excludeProjects: [sandbox]
+fieldSelector: spec.destination.namespace!=tmp`,
+		},
+	}, emptySecret, nil, nil)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, AppFilter{ExcludeProjects: []string{"sandbox"}, FieldSelector: "spec.destination.namespace!=tmp"}, cfg.AppFilter)
+}
+
+func TestNewConfig_InvalidAppFilter(t *testing.T) {
+	_, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{"appFilter": `labelSelector: "a in ("`},
+	}, emptySecret, nil, nil)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package settings
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+)
+
+// fragmentKeyPrefixes holds prefixes of the keys that might be defined in config fragments. Services are not allowed,
+// since the service of the labeled config map might send values of argocd-notifications-secret to any endpoint
+var fragmentKeyPrefixes = []string{"trigger.", "template."}
+
+func isFragmentKey(fragment *v1.ConfigMap, key string) bool {
+	if strings.HasPrefix(key, "service.") {
+		return isResourceFragment(fragment)
+	}
+	for _, prefix := range fragmentKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isResourceFragment returns true if the fragment is converted from the NotificationService or another config
+// resource, which defines services since access to resources is controlled by RBAC of the CRD
+func isResourceFragment(fragment *v1.ConfigMap) bool {
+	return strings.Contains(fragment.Name, "/")
+}
+
+// IsConfigFragment returns true if config map is labeled as the config fragment
+func IsConfigFragment(configMap *v1.ConfigMap) bool {
+	return configMap.Name != k8s.ConfigMapName && configMap.Labels[k8s.ConfigFragmentLabel] == "true"
+}
+
+// ValidateConfigFragment returns an error if the fragment has keys that are ignored when fragments are merged
+func ValidateConfigFragment(fragment *v1.ConfigMap) error {
+	for k := range fragment.Data {
+		if !isFragmentKey(fragment, k) {
+			return fmt.Errorf("unsupported key '%s': config fragments might define only triggers and templates", k)
+		}
+	}
+	return nil
+}
+
+// MergeConfigFragments returns copy of the config map extended with triggers and templates defined in the fragments and
+// services defined in config resources.
+// Fragments are applied in order of names, so keys defined in the main config map and in fragments with lower names take precedence.
+func MergeConfigFragments(configMap *v1.ConfigMap, fragments []*v1.ConfigMap) *v1.ConfigMap {
+	if len(fragments) == 0 {
+		return configMap
+	}
+	sorted := make([]*v1.ConfigMap, len(fragments))
+	copy(sorted, fragments)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	res := configMap.DeepCopy()
+	if res.Data == nil {
+		res.Data = map[string]string{}
+	}
+	owners := map[string]string{}
+	for k := range res.Data {
+		owners[k] = configMap.Name
+	}
+	for _, fragment := range sorted {
+		for k, v := range fragment.Data {
+			if !isFragmentKey(fragment, k) {
+				log.Warnf("Config fragment %s has unsupported key '%s'; only triggers and templates are allowed", fragment.Name, k)
+				continue
+			}
+			if owner, ok := owners[k]; ok {
+				log.Warnf("Key '%s' of config fragment %s is ignored because it is already defined in %s", k, fragment.Name, owner)
+				continue
+			}
+			res.Data[k] = v
+			owners[k] = fragment.Name
+		}
+	}
+	return res
+}
@@ -0,0 +1,50 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+)
+
+func newFragment(name string, data map[string]string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{k8s.ConfigFragmentLabel: "true"}},
+		Data:       data,
+	}
+}
+
+func TestMergeConfigFragments(t *testing.T) {
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: k8s.ConfigMapName},
+		Data: map[string]string{
+			"template.shared": "main",
+			"defaultTriggers": "[on-sync-failed]",
+		},
+	}
+
+	merged := MergeConfigFragments(configMap, []*v1.ConfigMap{
+		newFragment("team-b", map[string]string{"template.team": "b", "template.team-b": "b"}),
+		newFragment("team-a", map[string]string{"template.team": "a", "template.shared": "a", "defaultTriggers": "[]",
+			"service.webhook.team-a": "url: https://example.com"}),
+		{ObjectMeta: metav1.ObjectMeta{Name: "notificationservice/team-c"}, Data: map[string]string{"service.webhook.team-c": "url: https://example.com"}},
+	})
+
+	assert.Equal(t, map[string]string{
+		"template.shared":        "main",
+		"template.team":          "a",
+		"template.team-b":        "b",
+		"defaultTriggers":        "[on-sync-failed]",
+		"service.webhook.team-c": "url: https://example.com",
+	}, merged.Data)
+	assert.Len(t, configMap.Data, 2)
+}
+
+func TestIsConfigFragment(t *testing.T) {
+	assert.True(t, IsConfigFragment(newFragment("team-a", nil)))
+	assert.False(t, IsConfigFragment(newFragment(k8s.ConfigMapName, nil)))
+	assert.False(t, IsConfigFragment(&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}))
+}
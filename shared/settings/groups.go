@@ -0,0 +1,71 @@
+package settings
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+)
+
+// GroupService is the service name that references the destination group in subscriptions, e.g. group:team-payments
+const GroupService = "group"
+
+// DestinationGroups maps the logical group name, e.g. the team, to destinations in the service:recipient format
+type DestinationGroups map[string][]string
+
+func parseGroupDestination(val string) (services.Destination, error) {
+	parts := strings.SplitN(val, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return services.Destination{}, fmt.Errorf("destination '%s' must have the service:recipient format", val)
+	}
+	return services.Destination{Service: parts[0], Recipient: parts[1]}, nil
+}
+
+// withTarget returns copy of the referencing destination that targets the service and recipient of the referenced
+// destination, so other destination fields are preserved when groups and routes are expanded
+func withTarget(dest services.Destination, target services.Destination) services.Destination {
+	dest.Service = target.Service
+	dest.Recipient = target.Recipient
+	return dest
+}
+
+// Validate returns an error if the destination has invalid format or references another group
+func (g DestinationGroups) Validate() error {
+	for name, destinations := range g {
+		for _, val := range destinations {
+			dest, err := parseGroupDestination(val)
+			if err != nil {
+				return fmt.Errorf("destination group %s: %v", name, err)
+			}
+			if dest.Service == GroupService {
+				return fmt.Errorf("destination group %s: nested group %s is not supported", name, dest.Recipient)
+			}
+		}
+	}
+	return nil
+}
+
+// Expand replaces references to destination groups with group destinations. References to unknown groups are kept, so
+// the delivery fails and the misconfiguration is reported
+func (g DestinationGroups) Expand(subscriptions pkg.Subscriptions) pkg.Subscriptions {
+	if len(g) == 0 {
+		return subscriptions
+	}
+	res := pkg.Subscriptions{}
+	for trigger, destinations := range subscriptions {
+		for _, dest := range destinations {
+			group, ok := g[dest.Recipient]
+			if dest.Service != GroupService || !ok {
+				res[trigger] = append(res[trigger], dest)
+				continue
+			}
+			for _, val := range group {
+				// groups are validated when the config is parsed
+				groupDest, _ := parseGroupDestination(val)
+				res[trigger] = append(res[trigger], withTarget(dest, groupDest))
+			}
+		}
+	}
+	return res
+}
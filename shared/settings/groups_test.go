@@ -0,0 +1,35 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+)
+
+func TestDestinationGroups_Expand(t *testing.T) {
+	groups := DestinationGroups{"team-payments": {"slack:payments", "email:payments@example.com"}}
+
+	res := groups.Expand(pkg.Subscriptions{
+		"on-sync-failed": {{Service: "group", Recipient: "team-payments"}, {Service: "slack", Recipient: "ops"}},
+		"on-deployed":    {{Service: "group", Recipient: "team-unknown"}},
+	})
+
+	assert.Equal(t, pkg.Subscriptions{
+		"on-sync-failed": {
+			{Service: "slack", Recipient: "payments"},
+			{Service: "email", Recipient: "payments@example.com"},
+			{Service: "slack", Recipient: "ops"},
+		},
+		"on-deployed": {{Service: "group", Recipient: "team-unknown"}},
+	}, res)
+}
+
+func TestDestinationGroups_Validate(t *testing.T) {
+	assert.NoError(t, DestinationGroups{"team-payments": {"pagerduty:my-service-key"}}.Validate())
+	assert.EqualError(t, DestinationGroups{"team-payments": {"payments"}}.Validate(),
+		"destination group team-payments: destination 'payments' must have the service:recipient format")
+	assert.EqualError(t, DestinationGroups{"team-payments": {"group:team-ops"}}.Validate(),
+		"destination group team-payments: nested group team-ops is not supported")
+}
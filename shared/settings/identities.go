@@ -0,0 +1,40 @@
+package settings
+
+import "fmt"
+
+// BotIdentity maps the chat user to Argo CD RBAC subjects, e.g. the local account name, the SSO user email or SSO groups
+type BotIdentity struct {
+	// Service is the name of the notification service the user belongs to. Matches all services if empty
+	Service string `json:"service,omitempty"`
+	// UserID is the immutable chat user ID: the Azure AD object ID in Teams, the user ID in Slack, Discord and Telegram.
+	// User names are not supported because the user might change the own name
+	UserID string `json:"userID"`
+	// Subjects are Argo CD RBAC subjects of the user. The user is allowed to perform the action if at least one subject is allowed
+	Subjects []string `json:"subjects"`
+}
+
+type BotIdentities []BotIdentity
+
+// Validate returns an error if the identity has no user ID, e.g. is configured using the user name
+func (identities BotIdentities) Validate() error {
+	for i, identity := range identities {
+		if identity.UserID == "" {
+			return fmt.Errorf("bot identity %d: userID is required", i)
+		}
+	}
+	return nil
+}
+
+// Resolve returns Argo CD RBAC subjects of the chat user or nil if the user is not mapped, so unknown users are not
+// allowed to perform any action
+func (identities BotIdentities) Resolve(service string, userID string) []string {
+	if userID == "" {
+		return nil
+	}
+	for _, identity := range identities {
+		if identity.UserID == userID && (identity.Service == "" || identity.Service == service) {
+			return identity.Subjects
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/vm"
+)
+
+// InboundWebhook maps events received by the inbound webhook endpoint to the notification trigger of matching applications
+type InboundWebhook struct {
+	// Name is the webhook name used in the endpoint path
+	Name string `json:"name"`
+	// Secret is the key used to verify HMAC-SHA256 signature of the request body. Might reference the notifications secret key, e.g. $jira-webhook-secret
+	Secret string `json:"secret"`
+	// Trigger is the notification trigger evaluated for every matching application
+	Trigger string `json:"trigger"`
+	// AppSelector is the expression that selects applications using 'app' and 'event' variables. All applications match if empty
+	AppSelector string `json:"appSelector,omitempty"`
+}
+
+// Validate returns an error if webhook settings are incomplete or the selector cannot be compiled
+func (w InboundWebhook) Validate() error {
+	if w.Name == "" {
+		return errors.New("inbound webhook name must be specified")
+	}
+	if w.Secret == "" {
+		return fmt.Errorf("inbound webhook %s: secret must be specified", w.Name)
+	}
+	if w.Trigger == "" {
+		return fmt.Errorf("inbound webhook %s: trigger must be specified", w.Name)
+	}
+	if _, err := w.CompileSelector(); err != nil {
+		return fmt.Errorf("inbound webhook %s: invalid app selector: %v", w.Name, err)
+	}
+	return nil
+}
+
+// CompileSelector returns compiled application selector or nil if selector is empty
+func (w InboundWebhook) CompileSelector() (*vm.Program, error) {
+	if w.AppSelector == "" {
+		return nil, nil
+	}
+	return expr.Compile(w.AppSelector)
+}
+
+// GetInboundWebhook returns the inbound webhook settings with the specified name
+func (cfg Config) GetInboundWebhook(name string) (InboundWebhook, bool) {
+	for _, w := range cfg.InboundWebhooks {
+		if w.Name == name {
+			return w, true
+		}
+	}
+	return InboundWebhook{}, false
+}
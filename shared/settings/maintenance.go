@@ -0,0 +1,41 @@
+package settings
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+)
+
+// MaintenanceAnnotationKey is the config map annotation that pauses all notification deliveries. The value is either
+// `true` or the RFC 3339 time when the maintenance ends
+var MaintenanceAnnotationKey = "maintenance." + subscriptions.AnnotationPrefix
+
+// Maintenance pauses deliveries of all notifications, e.g. during planned Argo CD upgrades
+type Maintenance struct {
+	// Enabled is true if the maintenance annotation is set
+	Enabled bool
+	// Until is the time when the maintenance ends; zero means maintenance lasts until the annotation is removed
+	Until time.Time
+}
+
+// ParseMaintenance parses the maintenance annotation value
+func ParseMaintenance(val string) (Maintenance, error) {
+	if val == "" {
+		return Maintenance{}, nil
+	}
+	if enabled, err := strconv.ParseBool(val); err == nil {
+		return Maintenance{Enabled: enabled}, nil
+	}
+	until, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return Maintenance{}, fmt.Errorf("invalid %s annotation '%s': expected true, false or RFC 3339 time", MaintenanceAnnotationKey, val)
+	}
+	return Maintenance{Enabled: true, Until: until}, nil
+}
+
+// IsActive returns true if deliveries are paused at the specified time
+func (m Maintenance) IsActive(now time.Time) bool {
+	return m.Enabled && (m.Until.IsZero() || now.Before(m.Until))
+}
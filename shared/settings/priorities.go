@@ -0,0 +1,82 @@
+package settings
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPriorityAging is the wait time after which the queued application gains one priority level
+	DefaultPriorityAging = 10 * time.Second
+	// maxPriority bounds priorities, so the priority scaled by the aging interval does not overflow
+	maxPriority = 1000000
+)
+
+// Priorities configures the order in which the controller processes queued applications. Priorities are configured
+// centrally, so owners of applications cannot move their applications ahead of everyone else
+type Priorities struct {
+	// Projects maps project names to the priority of the project applications. Applications of other projects have
+	// the zero priority
+	Projects map[string]int `json:"projects,omitempty"`
+	// MaxAppPriority caps priorities set using the application annotation. The annotation might raise the priority up
+	// to the greater of the project priority and MaxAppPriority, so by default owners might only lower the priority
+	MaxAppPriority int `json:"maxAppPriority,omitempty"`
+	// Aging is the wait time after which the queued application gains one priority level, so applications with the low
+	// priority are not starved. Defaults to 10s, zero value disables aging
+	Aging string `json:"aging,omitempty"`
+}
+
+// Validate returns an error if the aging interval cannot be parsed or priorities are out of bounds
+func (p Priorities) Validate() error {
+	if _, err := p.GetAging(); err != nil {
+		return err
+	}
+	for project, priority := range p.Projects {
+		if priority > maxPriority || priority < -maxPriority {
+			return fmt.Errorf("priority of project '%s' must be between %d and %d", project, -maxPriority, maxPriority)
+		}
+	}
+	if p.MaxAppPriority > maxPriority || p.MaxAppPriority < -maxPriority {
+		return fmt.Errorf("maxAppPriority must be between %d and %d", -maxPriority, maxPriority)
+	}
+	return nil
+}
+
+// GetAging returns the aging interval
+func (p Priorities) GetAging() (time.Duration, error) {
+	if p.Aging == "" {
+		return DefaultPriorityAging, nil
+	}
+	aging, err := time.ParseDuration(p.Aging)
+	if err != nil {
+		return 0, fmt.Errorf("invalid aging '%s': %v", p.Aging, err)
+	}
+	if aging < 0 {
+		return 0, fmt.Errorf("aging must not be negative")
+	}
+	return aging, nil
+}
+
+// AppPriority returns the priority of the application of the project. The priority annotation value of the
+// application, if valid, overrides the project priority but is capped at the greater of the project priority and
+// MaxAppPriority
+func (p Priorities) AppPriority(project string, annotation string) int {
+	priority := p.Projects[project]
+	appPriority, err := strconv.Atoi(strings.TrimSpace(annotation))
+	if annotation == "" || err != nil {
+		return priority
+	}
+	limit := p.MaxAppPriority
+	if priority > limit {
+		limit = priority
+	}
+	if appPriority > limit {
+		return limit
+	}
+	if appPriority < -maxPriority {
+		return -maxPriority
+	}
+	return appPriority
+}
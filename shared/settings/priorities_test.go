@@ -0,0 +1,34 @@
+package settings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorities_AppPriority(t *testing.T) {
+	priorities := Priorities{Projects: map[string]int{"production": 100}, MaxAppPriority: 10}
+
+	assert.Equal(t, 100, priorities.AppPriority("production", ""))
+	assert.Equal(t, 0, priorities.AppPriority("default", ""))
+	assert.Equal(t, 100, priorities.AppPriority("production", "1000"))
+	assert.Equal(t, 50, priorities.AppPriority("production", " 50 "))
+	assert.Equal(t, 10, priorities.AppPriority("default", "1000"))
+	assert.Equal(t, -5, priorities.AppPriority("default", "-5"))
+	assert.Equal(t, 0, priorities.AppPriority("default", "high"))
+}
+
+func TestPriorities_Validate(t *testing.T) {
+	assert.NoError(t, Priorities{}.Validate())
+	assert.Error(t, Priorities{Aging: "soon"}.Validate())
+	assert.Error(t, Priorities{Aging: "-1s"}.Validate())
+	assert.Error(t, Priorities{Projects: map[string]int{"production": 1 << 30}}.Validate())
+
+	aging, err := Priorities{}.GetAging()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPriorityAging, aging)
+	aging, err = Priorities{Aging: "0s"}.GetAging()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), aging)
+}
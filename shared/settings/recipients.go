@@ -0,0 +1,81 @@
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/Masterminds/sprig"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+)
+
+var recipientFuncs = func() texttemplate.FuncMap {
+	f := sprig.TxtFuncMap()
+	delete(f, "env")
+	delete(f, "expandenv")
+	return f
+}()
+
+// isRecipientTemplate returns true if the recipient is rendered using the application, e.g.
+// email:{{.app.metadata.labels.team}}@example.com
+func isRecipientTemplate(recipient string) bool {
+	return strings.Contains(recipient, "{{")
+}
+
+func hasRecipientTemplates(subscriptions pkg.Subscriptions) bool {
+	for _, destinations := range subscriptions {
+		for _, dest := range destinations {
+			if isRecipientTemplate(dest.Recipient) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func renderRecipient(recipient string, vars map[string]interface{}) (string, error) {
+	tmpl, err := texttemplate.New(recipient).Funcs(recipientFuncs).Option("missingkey=error").Parse(recipient)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// renderRecipients replaces recipient templates with recipients rendered using the application labels, annotations
+// and other fields. Destinations are dropped if the template fails, e.g. references the missing label, or renders an
+// empty recipient, so applications without the recipient metadata are not notified
+func renderRecipients(app *unstructured.Unstructured, subscriptions pkg.Subscriptions) pkg.Subscriptions {
+	if !hasRecipientTemplates(subscriptions) {
+		return subscriptions
+	}
+	res := pkg.Subscriptions{}
+	vars := map[string]interface{}{"app": app.Object}
+	for trigger, destinations := range subscriptions {
+		for _, dest := range destinations {
+			if !isRecipientTemplate(dest.Recipient) {
+				res[trigger] = append(res[trigger], dest)
+				continue
+			}
+			recipient, err := renderRecipient(dest.Recipient, vars)
+			if err == nil && recipient == "" {
+				err = errors.New("recipient is empty")
+			}
+			if err != nil {
+				log.Warnf("Failed to render recipient '%s' of the %s service for application %s: %v",
+					dest.Recipient, dest.Service, app.GetName(), err)
+				continue
+			}
+			dest.Recipient = recipient
+			res[trigger] = append(res[trigger], dest)
+		}
+	}
+	return res
+}
@@ -0,0 +1,40 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+)
+
+func TestRenderRecipients(t *testing.T) {
+	app := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":        "guestbook",
+			"labels":      map[string]interface{}{"team": "payments"},
+			"annotations": map[string]interface{}{"oncall-channel": "payments-oncall"},
+		},
+	}}
+
+	res := renderRecipients(app, pkg.Subscriptions{
+		"on-sync-failed": {
+			{Service: "email", Recipient: "{{.app.metadata.labels.team}}@example.com"},
+			{Service: "slack", Recipient: `{{index .app.metadata.annotations "oncall-channel"}}`},
+			{Service: "slack", Recipient: "ops"},
+		},
+		"on-deployed": {
+			{Service: "email", Recipient: "{{.app.metadata.labels.owner}}@example.com"},
+			{Service: "slack", Recipient: `{{index .app.metadata.annotations "notify-channel" | default ""}}`},
+		},
+	})
+
+	assert.Equal(t, pkg.Subscriptions{
+		"on-sync-failed": {
+			{Service: "email", Recipient: "payments@example.com"},
+			{Service: "slack", Recipient: "payments-oncall"},
+			{Service: "slack", Recipient: "ops"},
+		},
+	}, res)
+}
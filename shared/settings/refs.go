@@ -0,0 +1,267 @@
+package settings
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj-labs/argocd-notifications/shared/catalog"
+)
+
+// refRefreshInterval is how often referenced files that are not pinned by the checksum are downloaded again
+const refRefreshInterval = 5 * time.Minute
+
+var (
+	// refKeyPrefixes holds prefixes of the config map keys that might reference remote files. Services can't reference
+	// remote files since they might reference secrets, so the remote file could send credentials to any URL
+	refKeyPrefixes = []string{"template.", "trigger."}
+	sha256Pattern  = regexp.MustCompile(`^[a-f0-9]{64}$`)
+	// errRefsPending is returned if the referenced files are being downloaded
+	errRefsPending = errors.New("referenced files are being downloaded")
+)
+
+// configRef references the remote file with the value of the config map key
+type configRef struct {
+	Ref string `json:"$ref"`
+	// SHA256 is the optional hex encoded checksum of the file; pinned files are never downloaded again
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// parseConfigRef returns the remote file reference if the value is the YAML object with the `$ref` field
+func parseConfigRef(val string) (*configRef, bool, error) {
+	if !strings.Contains(val, "$ref") {
+		return nil, false, nil
+	}
+	var fields map[string]interface{}
+	// triggers are lists and can't be references
+	if err := yaml.Unmarshal([]byte(val), &fields); err != nil {
+		return nil, false, nil
+	}
+	if _, ok := fields["$ref"]; !ok {
+		return nil, false, nil
+	}
+	for field := range fields {
+		if field != "$ref" && field != "sha256" {
+			return nil, false, fmt.Errorf("unsupported field '%s' of the $ref value; only $ref and sha256 are allowed", field)
+		}
+	}
+	var ref configRef
+	if err := yaml.Unmarshal([]byte(val), &ref); err != nil {
+		return nil, false, err
+	}
+	switch {
+	case strings.HasPrefix(ref.Ref, catalog.GitScheme):
+		if _, _, _, err := catalog.ParseGitURL(ref.Ref); err != nil {
+			return nil, false, err
+		}
+	case !strings.HasPrefix(ref.Ref, "https://"):
+		return nil, false, fmt.Errorf("$ref '%s' must start with https:// or %s", ref.Ref, catalog.GitScheme)
+	}
+	if ref.SHA256 != "" && !sha256Pattern.MatchString(ref.SHA256) {
+		return nil, false, fmt.Errorf("sha256 of $ref '%s' must be the hex encoded checksum", ref.Ref)
+	}
+	return &ref, true, nil
+}
+
+// parseKeyRef returns the remote file reference of the config map key and fails if the key can't reference remote files
+func parseKeyRef(key string, val string) (*configRef, bool, error) {
+	if strings.HasPrefix(key, "service.") {
+		if _, ok, err := parseConfigRef(val); ok || err != nil {
+			return nil, false, errors.New("$ref is not supported for services since they might reference secrets")
+		}
+		return nil, false, nil
+	}
+	for _, prefix := range refKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return parseConfigRef(val)
+		}
+	}
+	return nil, false, nil
+}
+
+// ExcludeConfigRefs returns copy of the config map without keys that reference remote files. Returns an error if any
+// reference is invalid. Used to validate the config map without downloading referenced files.
+func ExcludeConfigRefs(configMap *v1.ConfigMap) (*v1.ConfigMap, error) {
+	res := configMap.DeepCopy()
+	for k, v := range configMap.Data {
+		if _, ok, err := parseKeyRef(k, v); err != nil {
+			return nil, fmt.Errorf("key '%s': %v", k, err)
+		} else if ok {
+			delete(res.Data, k)
+		}
+	}
+	return res, nil
+}
+
+// resolveConfigRefs returns copy of the config map with references replaced with the file content returned by get
+func resolveConfigRefs(configMap *v1.ConfigMap, get func(ref configRef) ([]byte, error)) (*v1.ConfigMap, error) {
+	res := configMap
+	for k, v := range configMap.Data {
+		ref, ok, err := parseKeyRef(k, v)
+		if err != nil {
+			return nil, fmt.Errorf("key '%s': %v", k, err)
+		}
+		if !ok {
+			continue
+		}
+		content, err := get(*ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load $ref '%s' of key '%s': %v", ref.Ref, k, err)
+		}
+		if res == configMap {
+			res = configMap.DeepCopy()
+		}
+		res.Data[k] = string(content)
+	}
+	return res, nil
+}
+
+// ResolveConfigRefs returns copy of the config map with references replaced with the content of the remote files.
+// Files are downloaded synchronously.
+func ResolveConfigRefs(ctx context.Context, configMap *v1.ConfigMap) (*v1.ConfigMap, error) {
+	loader := newRefLoader(catalog.NewLoader().Download, nil)
+	return resolveConfigRefs(configMap, func(ref configRef) ([]byte, error) {
+		return loader.fetch(ctx, ref)
+	})
+}
+
+// refLoader downloads and caches files referenced by config map keys. Files are downloaded in the background, so
+// the network is never accessed while the settings are being parsed.
+type refLoader struct {
+	download func(ctx context.Context, rawURL string) ([]byte, error)
+	// onLoaded is called when the file requested by Resolve is downloaded or fails to download
+	onLoaded func()
+	lock     sync.Mutex
+	files    map[configRef][]byte
+	errors   map[configRef]error
+	pending  map[configRef]bool
+}
+
+func newRefLoader(download func(ctx context.Context, rawURL string) ([]byte, error), onLoaded func()) *refLoader {
+	return &refLoader{
+		download: download,
+		onLoaded: onLoaded,
+		files:    map[configRef][]byte{},
+		errors:   map[configRef]error{},
+		pending:  map[configRef]bool{},
+	}
+}
+
+// Resolve returns copy of the config map with references replaced with the cached content of the remote files. Files
+// that are not cached yet are downloaded in the background using the given context and errRefsPending is returned, so
+// the caller keeps the previous settings until onLoaded is called. Files that failed to download are retried by Refresh.
+func (l *refLoader) Resolve(ctx context.Context, configMap *v1.ConfigMap) (*v1.ConfigMap, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	used := map[configRef]bool{}
+	pending := false
+	res, err := resolveConfigRefs(configMap, func(ref configRef) ([]byte, error) {
+		used[ref] = true
+		if content, ok := l.files[ref]; ok {
+			return content, nil
+		}
+		if err, ok := l.errors[ref]; ok {
+			return nil, err
+		}
+		pending = true
+		if !l.pending[ref] {
+			l.pending[ref] = true
+			go l.load(ctx, ref)
+		}
+		return nil, nil
+	})
+	// files that are no longer referenced are not refreshed and kept in memory
+	for ref := range l.files {
+		if !used[ref] {
+			delete(l.files, ref)
+		}
+	}
+	for ref := range l.errors {
+		if !used[ref] {
+			delete(l.errors, ref)
+		}
+	}
+	if err == nil && pending {
+		err = errRefsPending
+	}
+	return res, err
+}
+
+func (l *refLoader) load(ctx context.Context, ref configRef) {
+	content, err := l.fetch(ctx, ref)
+	l.lock.Lock()
+	delete(l.pending, ref)
+	if err != nil {
+		l.errors[ref] = err
+	} else {
+		l.files[ref] = content
+	}
+	l.lock.Unlock()
+	if l.onLoaded != nil {
+		l.onLoaded()
+	}
+}
+
+func (l *refLoader) fetch(ctx context.Context, ref configRef) ([]byte, error) {
+	content, err := l.download(ctx, ref.Ref)
+	if err != nil {
+		return nil, err
+	}
+	if ref.SHA256 != "" {
+		sum := sha256.Sum256(content)
+		if actual := hex.EncodeToString(sum[:]); actual != ref.SHA256 {
+			return nil, fmt.Errorf("checksum mismatch: expected %s, got %s", ref.SHA256, actual)
+		}
+	}
+	return content, nil
+}
+
+// Refresh downloads again files that are not pinned by the checksum as well as files that failed to download and
+// returns true if any file has changed. Files are downloaded without holding the lock, so Resolve is never blocked by
+// the network, and the cache is updated once the file is downloaded. Files that fail to download keep the cached content.
+func (l *refLoader) Refresh(ctx context.Context) bool {
+	l.lock.Lock()
+	var refs []configRef
+	for ref := range l.files {
+		if ref.SHA256 == "" {
+			refs = append(refs, ref)
+		}
+	}
+	for ref := range l.errors {
+		refs = append(refs, ref)
+	}
+	l.lock.Unlock()
+
+	changed := false
+	for _, ref := range refs {
+		content, err := l.fetch(ctx, ref)
+		l.lock.Lock()
+		if err != nil {
+			if _, ok := l.errors[ref]; ok {
+				l.errors[ref] = err
+			} else {
+				log.Warnf("Failed to refresh $ref '%s', using the cached content: %v", ref.Ref, err)
+			}
+		} else if _, ok := l.errors[ref]; ok {
+			delete(l.errors, ref)
+			l.files[ref] = content
+			changed = true
+		} else if cached, ok := l.files[ref]; ok && !bytes.Equal(cached, content) {
+			l.files[ref] = content
+			changed = true
+		}
+		l.lock.Unlock()
+	}
+	return changed
+}
@@ -0,0 +1,129 @@
+package settings
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+const remoteTemplate = "message: Application {{.app.metadata.name}} is deployed\n"
+
+func sha256Of(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParseConfigRef(t *testing.T) {
+	ref, ok, err := parseConfigRef("$ref: https://example.com/templates/app-deployed.yaml\nsha256: " + sha256Of("a"))
+	if assert.NoError(t, err) && assert.True(t, ok) {
+		assert.Equal(t, configRef{Ref: "https://example.com/templates/app-deployed.yaml", SHA256: sha256Of("a")}, *ref)
+	}
+
+	_, ok, err = parseConfigRef("- when: app.status.sync.status == 'Unknown'\n  send: [app-sync-status]")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = parseConfigRef("message: use $ref in the message")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = parseConfigRef("$ref: http://example.com/template.yaml")
+	assert.Error(t, err)
+
+	_, _, err = parseConfigRef("$ref: https://example.com/template.yaml\nmessage: hello")
+	assert.Error(t, err)
+
+	ref, ok, err = parseConfigRef("$ref: git+https://github.com/my-org/config.git//templates/app-deployed.yaml?ref=v1.2.0")
+	if assert.NoError(t, err) && assert.True(t, ok) {
+		assert.Equal(t, "git+https://github.com/my-org/config.git//templates/app-deployed.yaml?ref=v1.2.0", ref.Ref)
+	}
+
+	_, _, err = parseConfigRef("$ref: git+https://github.com/my-org/config.git")
+	assert.Error(t, err)
+}
+
+func TestRefLoader_Resolve(t *testing.T) {
+	downloads := 0
+	content := remoteTemplate
+	loaded := make(chan bool, 1)
+	loader := newRefLoader(func(_ context.Context, rawURL string) ([]byte, error) {
+		downloads++
+		if rawURL != "https://example.com/app-deployed.yaml" {
+			return nil, errors.New("not found")
+		}
+		return []byte(content), nil
+	}, func() {
+		loaded <- true
+	})
+	configMap := &v1.ConfigMap{Data: map[string]string{
+		"template.app-deployed": "$ref: https://example.com/app-deployed.yaml",
+		"template.app-synced":   "message: synced",
+	}}
+
+	_, err := loader.Resolve(context.Background(), configMap)
+	assert.Equal(t, errRefsPending, err)
+	<-loaded
+
+	resolved, err := loader.Resolve(context.Background(), configMap)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, remoteTemplate, resolved.Data["template.app-deployed"])
+	assert.Equal(t, "message: synced", resolved.Data["template.app-synced"])
+	assert.Equal(t, "$ref: https://example.com/app-deployed.yaml", configMap.Data["template.app-deployed"])
+	assert.Equal(t, 1, downloads)
+
+	assert.False(t, loader.Refresh(context.Background()))
+	content = "message: changed\n"
+	assert.True(t, loader.Refresh(context.Background()))
+	resolved, err = loader.Resolve(context.Background(), configMap)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "message: changed\n", resolved.Data["template.app-deployed"])
+	}
+}
+
+func TestRefLoader_ChecksumMismatch(t *testing.T) {
+	loaded := make(chan bool, 1)
+	loader := newRefLoader(func(_ context.Context, _ string) ([]byte, error) {
+		return []byte(remoteTemplate), nil
+	}, func() {
+		loaded <- true
+	})
+	configMap := &v1.ConfigMap{Data: map[string]string{
+		"template.app-deployed": "$ref: https://example.com/app-deployed.yaml\nsha256: " + sha256Of("other"),
+	}}
+	_, err := loader.Resolve(context.Background(), configMap)
+	assert.Equal(t, errRefsPending, err)
+	<-loaded
+
+	_, err = loader.Resolve(context.Background(), configMap)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "checksum mismatch")
+	}
+}
+
+func TestRefLoader_ServiceRef(t *testing.T) {
+	loader := newRefLoader(func(_ context.Context, _ string) ([]byte, error) {
+		return nil, errors.New("unexpected download")
+	}, nil)
+	_, err := loader.Resolve(context.Background(), &v1.ConfigMap{Data: map[string]string{
+		"service.slack": "$ref: https://example.com/slack.yaml",
+	}})
+	assert.EqualError(t, err, "key 'service.slack': $ref is not supported for services since they might reference secrets")
+}
+
+func TestExcludeConfigRefs(t *testing.T) {
+	configMap, err := ExcludeConfigRefs(&v1.ConfigMap{Data: map[string]string{
+		"trigger.on-deployed": "$ref: https://example.com/on-deployed.yaml",
+		"template.app-synced": "message: synced",
+		"subscriptions":       "[]",
+	}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{"template.app-synced": "message: synced", "subscriptions": "[]"}, configMap.Data)
+	}
+}
@@ -0,0 +1,123 @@
+package settings
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/pkg/util/text"
+)
+
+const (
+	// SubscriptionSourceApp marks subscriptions configured using the application annotations
+	SubscriptionSourceApp = "application"
+	// SubscriptionSourceDefault marks default subscriptions configured in the config map
+	SubscriptionSourceDefault = "default"
+	// SubscriptionSourceProject marks subscriptions configured using the project annotations
+	SubscriptionSourceProject = "project"
+	// SubscriptionSourceNamespace marks subscriptions configured using the destination namespace annotations
+	SubscriptionSourceNamespace = "namespace"
+	// SubscriptionSourceResource marks subscriptions configured using NotificationSubscription resources
+	SubscriptionSourceResource = "resource"
+
+	legacyRecipientsAnnotationKey = "recipients.argocd-notifications.argoproj.io"
+)
+
+// SubscriptionSources holds resources that subscribe destinations to the application in addition to the application
+// annotations and the default subscriptions. Sources that are not available are left empty
+type SubscriptionSources struct {
+	// Project is the application project
+	Project *unstructured.Unstructured
+	// Namespace is the application destination namespace and is set only if namespace subscriptions are enabled
+	Namespace *unstructured.Unstructured
+	// Resources holds NotificationSubscription resources; only resources that match the application are applied
+	Resources []*subscriptions.Resource
+	// ControllerNamespace is the namespace used to match NotificationSubscription resources
+	ControllerNamespace string
+}
+
+// SourceSubscriptions holds subscriptions configured by one source
+type SourceSubscriptions struct {
+	Source        string
+	Subscriptions pkg.Subscriptions
+}
+
+// GetLegacySubscriptions returns subscriptions configured using the deprecated recipients annotations
+func GetLegacySubscriptions(annotations map[string]string, defaultTriggers ...string) pkg.Subscriptions {
+	res := pkg.Subscriptions{}
+	for k, v := range annotations {
+		if !strings.HasSuffix(k, legacyRecipientsAnnotationKey) {
+			continue
+		}
+
+		var triggerNames []string
+		triggerName := strings.TrimRight(k[0:len(k)-len(legacyRecipientsAnnotationKey)], ".")
+		if triggerName == "" {
+			triggerNames = defaultTriggers
+		} else {
+			triggerNames = []string{triggerName}
+		}
+
+		for _, recipient := range text.SplitRemoveEmpty(v, ",") {
+			if recipient = strings.TrimSpace(recipient); recipient != "" {
+				parts := strings.Split(recipient, ":")
+				dest := services.Destination{Service: parts[0]}
+				if len(parts) > 1 {
+					dest.Recipient = parts[1]
+				}
+				for _, name := range triggerNames {
+					res[name] = append(res[name], dest)
+				}
+			}
+		}
+	}
+	return res
+}
+
+func (cfg Config) getAnnotationSubscriptions(obj *unstructured.Unstructured) pkg.Subscriptions {
+	res := subscriptions.Annotations(obj.GetAnnotations()).GetAll(cfg.DefaultTriggers...)
+	res.Merge(GetLegacySubscriptions(obj.GetAnnotations(), cfg.DefaultTriggers...))
+	return res
+}
+
+// CollectSubscriptions returns subscriptions of the application grouped by the source, starting with the application
+// annotations, followed by the default, project, namespace and resource subscriptions. Destination groups and cluster
+// routes are expanded and recipient templates are rendered, but unsubscribe annotations are not applied
+func (cfg Config) CollectSubscriptions(app *unstructured.Unstructured, sources SubscriptionSources) []SourceSubscriptions {
+	res := []SourceSubscriptions{
+		{SubscriptionSourceApp, cfg.getAnnotationSubscriptions(app)},
+		{SubscriptionSourceDefault, cfg.GetGlobalSubscriptions(app)},
+	}
+	if sources.Project != nil {
+		res = append(res, SourceSubscriptions{SubscriptionSourceProject, cfg.getAnnotationSubscriptions(sources.Project)})
+	}
+	if sources.Namespace != nil {
+		res = append(res, SourceSubscriptions{SubscriptionSourceNamespace, subscriptions.Annotations(sources.Namespace.GetAnnotations()).GetAll(cfg.DefaultTriggers...)})
+	}
+	resourceSubs := pkg.Subscriptions{}
+	for _, resource := range sources.Resources {
+		if resource.Matches(app, sources.ControllerNamespace) {
+			resourceSubs.Merge(resource.GetAll(cfg.DefaultTriggers...))
+		}
+	}
+	if len(resourceSubs) > 0 {
+		res = append(res, SourceSubscriptions{SubscriptionSourceResource, resourceSubs})
+	}
+	for i := range res {
+		res[i].Subscriptions = cfg.ExpandDestinations(app, res[i].Subscriptions)
+	}
+	return res
+}
+
+// ResolveSubscriptions returns destinations subscribed to the application by all sources. Destinations opted out using
+// unsubscribe annotations of the application are removed
+func (cfg Config) ResolveSubscriptions(app *unstructured.Unstructured, sources SubscriptionSources) pkg.Subscriptions {
+	res := pkg.Subscriptions{}
+	for _, source := range cfg.CollectSubscriptions(app, sources) {
+		res.Merge(source.Subscriptions)
+	}
+	return subscriptions.Annotations(app.GetAnnotations()).RemoveUnsubscribed(res).Dedup()
+}
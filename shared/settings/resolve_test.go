@@ -0,0 +1,86 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func newSubscriptionResource(t *testing.T, namespace string, spec map[string]interface{}) *subscriptions.Resource {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetName("my-subscription")
+	obj.SetNamespace(namespace)
+	resource, err := subscriptions.ParseResource(obj)
+	assert.NoError(t, err)
+	return resource
+}
+
+func TestResolveSubscriptions(t *testing.T) {
+	cfg := Config{
+		DefaultTriggers:   []string{"on-sync-failed"},
+		Subscriptions:     DefaultSubscriptions{{Recipients: []string{"slack:defaults"}, Triggers: []string{"on-deployed"}}},
+		DestinationGroups: DestinationGroups{"team-payments": {"slack:payments", "email:payments@example.com"}},
+	}
+	app := NewApp("guestbook", WithProject("payments"), withDestinationNamespace("payments"), WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("", "slack"):   "app",
+		"recipients.argocd-notifications.argoproj.io":       "slack:legacy",
+		subscriptions.UnsubscribeAnnotationKey("", "email"): "payments@example.com",
+	}))
+	proj := NewProject("payments", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("on-deployed", "group"): "team-payments",
+	}))
+	ns := &unstructured.Unstructured{}
+	ns.SetName("payments")
+	ns.SetAnnotations(map[string]string{subscriptions.SubscribeAnnotationKey("", "slack"): "namespace"})
+
+	res := cfg.ResolveSubscriptions(app, SubscriptionSources{
+		Project:   proj,
+		Namespace: ns,
+		Resources: []*subscriptions.Resource{
+			newSubscriptionResource(t, "argocd", map[string]interface{}{
+				"project":      "payments",
+				"destinations": []interface{}{map[string]interface{}{"service": "slack", "recipient": "resource"}},
+			}),
+			newSubscriptionResource(t, "argocd", map[string]interface{}{
+				"project":      "other",
+				"destinations": []interface{}{map[string]interface{}{"service": "slack", "recipient": "other"}},
+			}),
+		},
+		ControllerNamespace: "argocd",
+	})
+
+	assert.ElementsMatch(t, []services.Destination{
+		{Service: "slack", Recipient: "app"},
+		{Service: "slack", Recipient: "legacy"},
+		{Service: "slack", Recipient: "namespace"},
+		{Service: "slack", Recipient: "resource"},
+	}, res["on-sync-failed"])
+	assert.ElementsMatch(t, []services.Destination{
+		{Service: "slack", Recipient: "defaults"},
+		{Service: "slack", Recipient: "payments"},
+	}, res["on-deployed"])
+}
+
+func TestCollectSubscriptions_Sources(t *testing.T) {
+	cfg := Config{Subscriptions: DefaultSubscriptions{{Recipients: []string{"slack:defaults"}, Triggers: []string{"on-deployed"}}}}
+	app := NewApp("guestbook", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("on-deployed", "slack"): "app",
+	}))
+	proj := NewProject("default", WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("on-deployed", "slack"): "proj",
+	}))
+
+	res := cfg.CollectSubscriptions(app, SubscriptionSources{Project: proj})
+
+	assert.Equal(t, []SourceSubscriptions{
+		{SubscriptionSourceApp, pkg.Subscriptions{"on-deployed": {{Service: "slack", Recipient: "app"}}}},
+		{SubscriptionSourceDefault, pkg.Subscriptions{"on-deployed": {{Service: "slack", Recipient: "defaults"}}}},
+		{SubscriptionSourceProject, pkg.Subscriptions{"on-deployed": {{Service: "slack", Recipient: "proj"}}}},
+	}, res)
+}
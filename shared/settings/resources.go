@@ -0,0 +1,61 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	TriggerKind  = "NotificationTrigger"
+	TemplateKind = "NotificationTemplate"
+	ServiceKind  = "NotificationService"
+)
+
+// ResourceToConfigFragment converts NotificationTrigger, NotificationTemplate or NotificationService resource into
+// the config fragment with the same keys that are used in the config map
+func ResourceToConfigFragment(obj *unstructured.Unstructured) (*v1.ConfigMap, error) {
+	name := obj.GetName()
+	spec, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec of %s %s: %v", obj.GetKind(), name, err)
+	}
+	var key string
+	var value interface{} = spec
+	switch obj.GetKind() {
+	case TriggerKind:
+		key = "trigger." + name
+		value = spec["conditions"]
+	case TemplateKind:
+		key = "template." + name
+	case ServiceKind:
+		serviceType, _ := spec["type"].(string)
+		if serviceType == "" {
+			return nil, fmt.Errorf("service %s does not specify type", name)
+		}
+		delete(spec, "type")
+		key = "service." + serviceType
+		if serviceType != name {
+			key = key + "." + name
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kind %s", obj.GetKind())
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ResourceFragmentName(obj)},
+		Data:       map[string]string{key: string(data)},
+	}, nil
+}
+
+// ResourceFragmentName returns name of the config fragment that represents the resource, e.g. notificationtrigger/on-sync-failed
+func ResourceFragmentName(obj *unstructured.Unstructured) string {
+	return strings.ToLower(obj.GetKind()) + "/" + obj.GetName()
+}
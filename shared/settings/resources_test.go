@@ -0,0 +1,102 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newResource(t *testing.T, data string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	if !assert.NoError(t, yaml.Unmarshal([]byte(data), &obj.Object)) {
+		t.FailNow()
+	}
+	return obj
+}
+
+func TestResourceToConfigFragment(t *testing.T) {
+	resources := []string{`
+apiVersion: argoproj.io/v1alpha1
+kind: NotificationTrigger
+metadata:
+  name: on-sync-failed
+spec:
+  conditions:
+  - when: app.status.operationState.phase in ['Error', 'Failed']
+    send: [app-sync-failed]
+`, `
+apiVersion: argoproj.io/v1alpha1
+kind: NotificationTemplate
+metadata:
+  name: app-sync-failed
+spec:
+  message: Application {{.app.metadata.name}} sync is failed
+`, `
+apiVersion: argoproj.io/v1alpha1
+kind: NotificationService
+metadata:
+  name: slack
+spec:
+  type: slack
+  token: $slack-token
+`, `
+apiVersion: argoproj.io/v1alpha1
+kind: NotificationService
+metadata:
+  name: mattermost
+spec:
+  type: slack
+  apiURL: https://mattermost.example.com/api
+`}
+	var fragments []*v1.ConfigMap
+	for _, data := range resources {
+		fragment, err := ResourceToConfigFragment(newResource(t, data))
+		if !assert.NoError(t, err) {
+			return
+		}
+		fragments = append(fragments, fragment)
+	}
+
+	cfg, err := NewConfig(MergeConfigFragments(&v1.ConfigMap{}, fragments), emptySecret, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Contains(t, cfg.Triggers, "on-sync-failed")
+	assert.Equal(t, []string{"app-sync-failed"}, cfg.Triggers["on-sync-failed"][0].Send)
+	assert.Equal(t, "Application {{.app.metadata.name}} sync is failed", cfg.Templates["app-sync-failed"].Message)
+	assert.Contains(t, cfg.Services, "slack")
+	assert.Contains(t, cfg.Services, "mattermost")
+}
+
+func TestResourceToConfigFragment_ServiceKey(t *testing.T) {
+	fragment, err := ResourceToConfigFragment(newResource(t, `
+apiVersion: argoproj.io/v1alpha1
+kind: NotificationService
+metadata:
+  name: mattermost
+spec:
+  type: slack
+  apiURL: https://mattermost.example.com/api
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "notificationservice/mattermost", fragment.Name)
+	assert.Equal(t, map[string]string{"service.slack.mattermost": `{"apiURL":"https://mattermost.example.com/api"}`}, fragment.Data)
+}
+
+func TestResourceToConfigFragment_MissingServiceType(t *testing.T) {
+	_, err := ResourceToConfigFragment(newResource(t, `
+apiVersion: argoproj.io/v1alpha1
+kind: NotificationService
+metadata:
+  name: slack
+spec:
+  token: abc
+`))
+	assert.Error(t, err)
+}
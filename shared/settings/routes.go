@@ -0,0 +1,86 @@
+package settings
+
+import (
+	"fmt"
+	"path"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+)
+
+// ClusterRouteService is the service name that references the cluster route in subscriptions, e.g. cluster:alerts
+const ClusterRouteService = "cluster"
+
+// ClusterRoute holds destinations of applications deployed to the matching clusters
+type ClusterRoute struct {
+	// Clusters holds glob patterns matched against the destination cluster name or server URL of the application
+	Clusters []string `json:"clusters,omitempty"`
+	// Destinations holds destinations in the service:recipient format
+	Destinations []string `json:"destinations,omitempty"`
+}
+
+// ClusterRoutes maps the route name to rules that select destinations by the application destination cluster. Rules
+// are checked in order and the first matching rule is used
+type ClusterRoutes map[string][]ClusterRoute
+
+// Validate returns an error if the cluster pattern or the destination has invalid format or references another route
+func (r ClusterRoutes) Validate() error {
+	for name, rules := range r {
+		for i, rule := range rules {
+			for _, pattern := range rule.Clusters {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("cluster route %s: rule %d: invalid cluster pattern '%s': %v", name, i, pattern, err)
+				}
+			}
+			for _, val := range rule.Destinations {
+				dest, err := parseGroupDestination(val)
+				if err != nil {
+					return fmt.Errorf("cluster route %s: rule %d: %v", name, i, err)
+				}
+				if dest.Service == ClusterRouteService {
+					return fmt.Errorf("cluster route %s: rule %d: nested route %s is not supported", name, i, dest.Recipient)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// matches returns true if any cluster pattern matches the destination cluster name or server of the application
+func (r ClusterRoute) matches(app *unstructured.Unstructured) bool {
+	name, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "name")
+	server, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "server")
+	return name != "" && matchesAny(r.Clusters, name) || server != "" && matchesAny(r.Clusters, server)
+}
+
+// Expand replaces references to cluster routes with destinations of the first rule that matches the application
+// destination cluster. References are dropped if no rule matches, so the route might ignore some clusters, and
+// references to unknown routes are kept, so the delivery fails and the misconfiguration is reported
+func (r ClusterRoutes) Expand(app *unstructured.Unstructured, subscriptions pkg.Subscriptions) pkg.Subscriptions {
+	if len(r) == 0 {
+		return subscriptions
+	}
+	res := pkg.Subscriptions{}
+	for trigger, destinations := range subscriptions {
+		for _, dest := range destinations {
+			rules, ok := r[dest.Recipient]
+			if dest.Service != ClusterRouteService || !ok {
+				res[trigger] = append(res[trigger], dest)
+				continue
+			}
+			for _, rule := range rules {
+				if !rule.matches(app) {
+					continue
+				}
+				for _, val := range rule.Destinations {
+					// routes are validated when the config is parsed
+					routeDest, _ := parseGroupDestination(val)
+					res[trigger] = append(res[trigger], withTarget(dest, routeDest))
+				}
+				break
+			}
+		}
+	}
+	return res
+}
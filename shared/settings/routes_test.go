@@ -0,0 +1,61 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+)
+
+func newClusterApp(name string, server string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"destination": map[string]interface{}{"name": name, "server": server},
+		},
+	}}
+}
+
+func TestClusterRoutes_Expand(t *testing.T) {
+	routes := ClusterRoutes{"alerts": {{
+		Clusters:     []string{"prod-*"},
+		Destinations: []string{"slack:prod-alerts", "group:team-oncall"},
+	}, {
+		Clusters:     []string{"https://staging.example.com"},
+		Destinations: []string{"slack:staging"},
+	}}}
+	subs := pkg.Subscriptions{
+		"on-sync-failed": {{Service: "cluster", Recipient: "alerts"}, {Service: "slack", Recipient: "ops"}},
+		"on-deployed":    {{Service: "cluster", Recipient: "unknown"}},
+	}
+
+	assert.Equal(t, pkg.Subscriptions{
+		"on-sync-failed": {
+			{Service: "slack", Recipient: "prod-alerts"},
+			{Service: "group", Recipient: "team-oncall"},
+			{Service: "slack", Recipient: "ops"},
+		},
+		"on-deployed": {{Service: "cluster", Recipient: "unknown"}},
+	}, routes.Expand(newClusterApp("prod-eu", ""), subs))
+
+	assert.Equal(t, pkg.Subscriptions{
+		"on-sync-failed": {{Service: "slack", Recipient: "staging"}, {Service: "slack", Recipient: "ops"}},
+		"on-deployed":    {{Service: "cluster", Recipient: "unknown"}},
+	}, routes.Expand(newClusterApp("", "https://staging.example.com"), subs))
+
+	assert.Equal(t, pkg.Subscriptions{
+		"on-sync-failed": {{Service: "slack", Recipient: "ops"}},
+		"on-deployed":    {{Service: "cluster", Recipient: "unknown"}},
+	}, routes.Expand(newClusterApp("dev", ""), subs))
+}
+
+func TestClusterRoutes_Validate(t *testing.T) {
+	assert.NoError(t, ClusterRoutes{"alerts": {{Clusters: []string{"prod-*"}, Destinations: []string{"group:team-oncall"}}}}.Validate())
+	assert.EqualError(t, ClusterRoutes{"alerts": {{Clusters: []string{"prod-["}}}}.Validate(),
+		"cluster route alerts: rule 0: invalid cluster pattern 'prod-[': syntax error in pattern")
+	assert.EqualError(t, ClusterRoutes{"alerts": {{Destinations: []string{"prod-alerts"}}}}.Validate(),
+		"cluster route alerts: rule 0: destination 'prod-alerts' must have the service:recipient format")
+	assert.EqualError(t, ClusterRoutes{"alerts": {{Destinations: []string{"cluster:other"}}}}.Validate(),
+		"cluster route alerts: rule 0: nested route other is not supported")
+}
@@ -0,0 +1,65 @@
+package settings
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// APIVersionKey is the config map key with the version of the config schema
+	APIVersionKey = "apiVersion"
+	// APIVersionV1 is the previous schema version: deprecated keys are supported and reported as warnings
+	APIVersionV1 = "v1"
+	// APIVersionV2 is the current schema version: deprecated keys are rejected
+	APIVersionV2 = "v2"
+)
+
+// Deprecation describes the config map or secret key that is not supported by the current schema version
+type Deprecation struct {
+	// Source is either ConfigMap or Secret
+	Source string
+	Key    string
+	// Replacement describes the settings that should be used instead
+	Replacement string
+}
+
+func (d Deprecation) String() string {
+	return fmt.Sprintf("key '%s' of the %s is deprecated, use %s instead", d.Key, d.Source, d.Replacement)
+}
+
+var deprecations = []Deprecation{
+	{Source: "ConfigMap", Key: "config.yaml", Replacement: "trigger.<name>, template.<name>, context and subscriptions keys"},
+	{Source: "Secret", Key: "notifiers.yaml", Replacement: "service.<type> keys of the ConfigMap"},
+}
+
+// CheckSchema returns the schema version of the config map and deprecated keys used in the config map and secret.
+// Returns an error if the version is unknown or the current version config uses deprecated keys. Config maps
+// without the version are treated as the previous version, so existing installations keep working.
+func CheckSchema(configMap *v1.ConfigMap, secret *v1.Secret) (string, []Deprecation, error) {
+	version, ok := configMap.Data[APIVersionKey]
+	if !ok {
+		version = APIVersionV1
+	}
+	if version != APIVersionV1 && version != APIVersionV2 {
+		return "", nil, fmt.Errorf("unsupported config %s '%s'; supported versions are %s and %s", APIVersionKey, version, APIVersionV1, APIVersionV2)
+	}
+	var found []Deprecation
+	for _, d := range deprecations {
+		var used bool
+		switch d.Source {
+		case "ConfigMap":
+			used = configMap.Data[d.Key] != ""
+		case "Secret":
+			used = secret != nil && len(secret.Data[d.Key]) > 0
+		}
+		if !used {
+			continue
+		}
+		if version == APIVersionV2 {
+			return "", nil, fmt.Errorf("config %s %s does not support deprecated keys: %s", APIVersionKey, version, d)
+		}
+		found = append(found, d)
+	}
+	return version, found, nil
+}
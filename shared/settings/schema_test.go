@@ -0,0 +1,35 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestCheckSchema(t *testing.T) {
+	legacySecret := &v1.Secret{Data: map[string][]byte{"notifiers.yaml": []byte("slack: {}")}}
+
+	version, deprecations, err := CheckSchema(&v1.ConfigMap{}, emptySecret)
+	assert.NoError(t, err)
+	assert.Equal(t, APIVersionV1, version)
+	assert.Empty(t, deprecations)
+
+	version, deprecations, err = CheckSchema(&v1.ConfigMap{Data: map[string]string{"config.yaml": "triggers: []"}}, legacySecret)
+	assert.NoError(t, err)
+	assert.Equal(t, APIVersionV1, version)
+	if assert.Len(t, deprecations, 2) {
+		assert.Equal(t, "config.yaml", deprecations[0].Key)
+		assert.Equal(t, "notifiers.yaml", deprecations[1].Key)
+	}
+
+	version, _, err = CheckSchema(&v1.ConfigMap{Data: map[string]string{APIVersionKey: APIVersionV2}}, emptySecret)
+	assert.NoError(t, err)
+	assert.Equal(t, APIVersionV2, version)
+
+	_, _, err = CheckSchema(&v1.ConfigMap{Data: map[string]string{APIVersionKey: APIVersionV2}}, legacySecret)
+	assert.EqualError(t, err, "config apiVersion v2 does not support deprecated keys: key 'notifiers.yaml' of the Secret is deprecated, use service.<type> keys of the ConfigMap instead")
+
+	_, _, err = CheckSchema(&v1.ConfigMap{Data: map[string]string{APIVersionKey: "v3"}}, emptySecret)
+	assert.Error(t, err)
+}
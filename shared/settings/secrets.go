@@ -0,0 +1,49 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+)
+
+// SecretProvider returns values stored in the external secret store
+type SecretProvider interface {
+	GetSecret(key string) (string, error)
+}
+
+// NewSecretResolver returns resolver of `$<secret-name>:<key>` references to the Secrets in the specified namespace.
+// References with the source that matches the provider name are resolved by the provider, e.g. `$vault:secret/data/slack#token`
+func NewSecretResolver(clientset kubernetes.Interface, namespace string, providers map[string]SecretProvider) pkg.SecretResolver {
+	return &secretResolver{clientset: clientset, namespace: namespace, providers: providers}
+}
+
+type secretResolver struct {
+	clientset kubernetes.Interface
+	namespace string
+	providers map[string]SecretProvider
+}
+
+// IsExternalSource returns true if references with the source are resolved by the provider
+func (r *secretResolver) IsExternalSource(source string) bool {
+	_, ok := r.providers[source]
+	return ok
+}
+
+func (r *secretResolver) Resolve(source string, key string) (string, error) {
+	if provider, ok := r.providers[source]; ok {
+		return provider.GetSecret(key)
+	}
+	secret, err := r.clientset.CoreV1().Secrets(r.namespace).Get(context.Background(), source, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	val, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s does not have key %s", source, key)
+	}
+	return string(val), nil
+}
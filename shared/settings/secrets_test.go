@@ -0,0 +1,42 @@
+package settings
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type staticProvider map[string]string
+
+func (p staticProvider) GetSecret(key string) (string, error) {
+	val, ok := p[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found", key)
+	}
+	return val, nil
+}
+
+func TestSecretResolver(t *testing.T) {
+	resolver := NewSecretResolver(fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "slack-secret", Namespace: "default"},
+		Data:       map[string][]byte{"token": []byte("xoxb-123")},
+	}), "default", map[string]SecretProvider{"vault": staticProvider{"secret/data/slack#token": "xoxb-456"}})
+
+	val, err := resolver.Resolve("slack-secret", "token")
+	assert.NoError(t, err)
+	assert.Equal(t, "xoxb-123", val)
+
+	_, err = resolver.Resolve("slack-secret", "missing")
+	assert.Error(t, err)
+
+	_, err = resolver.Resolve("missing-secret", "token")
+	assert.Error(t, err)
+
+	val, err = resolver.Resolve("vault", "secret/data/slack#token")
+	assert.NoError(t, err)
+	assert.Equal(t, "xoxb-456", val)
+}
@@ -10,33 +10,65 @@ import (
 	"github.com/ghodss/yaml"
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/argoproj-labs/argocd-notifications/pkg"
 	"github.com/argoproj-labs/argocd-notifications/pkg/services"
 	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+	"github.com/argoproj-labs/argocd-notifications/shared/catalog"
 	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/quota"
+	"github.com/argoproj-labs/argocd-notifications/shared/selfmonitoring"
 )
 
 type Config struct {
 	pkg.Config
 
+	// APIVersion is the version of the config schema
+	APIVersion string
+	// Deprecations holds deprecated keys used by the config
+	Deprecations []Deprecation
+
 	// Context holds list of configured key value pairs available in notification templates
 	Context map[string]string
 	// Subscriptions holds list of default application subscriptions
 	Subscriptions DefaultSubscriptions
 	// DefaultTriggers holds list of triggers that is used by default if subscriber don't specify trigger
 	DefaultTriggers []string
+	// AppFilter limits the set of applications processed by the controller
+	AppFilter AppFilter
+	// SelfMonitoring configures notifications about the controller problems
+	SelfMonitoring selfmonitoring.Options
+	// Maintenance pauses all notification deliveries
+	Maintenance Maintenance
+	// Quotas limit the number of notifications sent per project or destination
+	Quotas quota.Rules
+	// Priorities configure the order in which queued applications are processed
+	Priorities Priorities
+	// Catalogs reference bundles of triggers and templates loaded in addition to the config map
+	Catalogs []catalog.Source
+	// InboundWebhooks holds settings of the inbound webhooks served by the API server
+	InboundWebhooks []InboundWebhook
+	// BotIdentities maps bot users to Argo CD RBAC subjects
+	BotIdentities BotIdentities
+	// DestinationGroups maps logical group names to destinations, so subscriptions might reference the group
+	DestinationGroups DestinationGroups
+	// ClusterRoutes maps route names to destinations selected by the application destination cluster
+	ClusterRoutes ClusterRoutes
+	// EmailUnsubscribe enables signed unsubscribe links in notification templates
+	EmailUnsubscribe *EmailUnsubscribe
 	// ArgoCDService encapsulates methods provided by Argo CD
 	ArgoCDService argocd.Service
 	// API allows sending notifications
 	API pkg.API
 }
 
-// Returns list of recipients for the specified trigger
-func (cfg Config) GetGlobalSubscriptions(labels map[string]string) pkg.Subscriptions {
+// Returns list of recipients of the default subscriptions that match the application
+func (cfg Config) GetGlobalSubscriptions(app *unstructured.Unstructured) pkg.Subscriptions {
 	subscriptions := pkg.Subscriptions{}
 	for _, s := range cfg.Subscriptions {
 		triggers := s.Triggers
@@ -44,9 +76,9 @@ func (cfg Config) GetGlobalSubscriptions(labels map[string]string) pkg.Subscript
 			triggers = cfg.DefaultTriggers
 		}
 		for _, trigger := range triggers {
-			if s.MatchesTrigger(trigger) && s.Selector.Matches(fields.Set(labels)) {
+			if s.MatchesTrigger(trigger) && s.MatchesApp(app) {
 				for _, recipient := range s.Recipients {
-					parts := strings.Split(recipient, ":")
+					parts := strings.SplitN(recipient, ":", 2)
 					dest := services.Destination{Service: parts[0]}
 					if len(parts) > 1 {
 						dest.Recipient = parts[1]
@@ -59,18 +91,34 @@ func (cfg Config) GetGlobalSubscriptions(labels map[string]string) pkg.Subscript
 	return subscriptions
 }
 
+// ExpandDestinations replaces references to cluster routes and destination groups with the referenced destinations and
+// renders recipient templates
+func (cfg Config) ExpandDestinations(app *unstructured.Unstructured, subscriptions pkg.Subscriptions) pkg.Subscriptions {
+	return renderRecipients(app, cfg.DestinationGroups.Expand(cfg.ClusterRoutes.Expand(app, subscriptions)))
+}
+
 type CfgOpts = func(*Config, *v1.ConfigMap, *v1.Secret) error
 
-// NewConfig retrieves configured templates and triggers from the provided config map
-func NewConfig(configMap *v1.ConfigMap, secret *v1.Secret, argocdService argocd.Service, opts ...CfgOpts) (*Config, error) {
+// NewConfig retrieves configured templates and triggers from the provided config map. Secret resolver is optional and
+// used to resolve references to values stored outside of the provided secret
+func NewConfig(configMap *v1.ConfigMap, secret *v1.Secret, resolver pkg.SecretResolver, argocdService argocd.Service, opts ...CfgOpts) (*Config, error) {
+	apiVersion, deprecations, err := CheckSchema(configMap, secret)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deprecations {
+		log.Warnf("Config %s %s: %s", APIVersionKey, apiVersion, d)
+	}
 	// read all the keys in format of templates.%s and triggers.%s
 	// to create config
-	c, err := pkg.ParseConfig(configMap, secret)
+	c, err := pkg.ParseConfig(configMap, secret, resolver)
 	if err != nil {
 		return nil, err
 	}
 	cfg := Config{
-		Config: *c,
+		Config:       *c,
+		APIVersion:   apiVersion,
+		Deprecations: deprecations,
 		Context: map[string]string{
 			"argocdUrl": "https://localhost:4000",
 		},
@@ -83,6 +131,14 @@ func NewConfig(configMap *v1.ConfigMap, secret *v1.Secret, argocdService argocd.
 		}
 	}
 
+	if subscriptionYaml, ok := configMap.Data["defaultSubscriptions"]; ok {
+		var defaultSubscriptions DefaultSubscriptions
+		if err := yaml.Unmarshal([]byte(subscriptionYaml), &defaultSubscriptions); err != nil {
+			return nil, err
+		}
+		cfg.Subscriptions = append(cfg.Subscriptions, defaultSubscriptions...)
+	}
+
 	if contextYaml, ok := configMap.Data["context"]; ok {
 		if err := yaml.Unmarshal([]byte(contextYaml), &cfg.Context); err != nil {
 			return nil, err
@@ -95,12 +151,118 @@ func NewConfig(configMap *v1.ConfigMap, secret *v1.Secret, argocdService argocd.
 		}
 	}
 
+	if appFilterYaml, ok := configMap.Data["appFilter"]; ok {
+		if err := yaml.Unmarshal([]byte(appFilterYaml), &cfg.AppFilter); err != nil {
+			return nil, err
+		}
+		if err := cfg.AppFilter.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if selfMonitoringYaml, ok := configMap.Data["selfMonitoring"]; ok {
+		if err := yaml.Unmarshal([]byte(selfMonitoringYaml), &cfg.SelfMonitoring); err != nil {
+			return nil, err
+		}
+		if err := cfg.SelfMonitoring.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid self-monitoring settings: %v", err)
+		}
+	}
+
+	if cfg.Maintenance, err = ParseMaintenance(configMap.Annotations[MaintenanceAnnotationKey]); err != nil {
+		return nil, err
+	}
+
+	if quotasYaml, ok := configMap.Data["quotas"]; ok {
+		if err := yaml.Unmarshal([]byte(quotasYaml), &cfg.Quotas); err != nil {
+			return nil, err
+		}
+		if err := cfg.Quotas.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid quotas: %v", err)
+		}
+	}
+
+	if prioritiesYaml, ok := configMap.Data["priorities"]; ok {
+		if err := yaml.Unmarshal([]byte(prioritiesYaml), &cfg.Priorities); err != nil {
+			return nil, err
+		}
+		if err := cfg.Priorities.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid priorities: %v", err)
+		}
+	}
+
+	if catalogsYaml, ok := configMap.Data[CatalogsKey]; ok {
+		if cfg.Catalogs, err = catalog.ParseSources(catalogsYaml); err != nil {
+			return nil, err
+		}
+	}
+
+	if botIdentitiesYaml, ok := configMap.Data["botIdentities"]; ok {
+		if err := yaml.Unmarshal([]byte(botIdentitiesYaml), &cfg.BotIdentities); err != nil {
+			return nil, err
+		}
+		if err := cfg.BotIdentities.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if destinationGroupsYaml, ok := configMap.Data["destinationGroups"]; ok {
+		if err := yaml.Unmarshal([]byte(destinationGroupsYaml), &cfg.DestinationGroups); err != nil {
+			return nil, err
+		}
+		if err := cfg.DestinationGroups.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if clusterRoutesYaml, ok := configMap.Data["clusterRoutes"]; ok {
+		if err := yaml.Unmarshal([]byte(clusterRoutesYaml), &cfg.ClusterRoutes); err != nil {
+			return nil, err
+		}
+		if err := cfg.ClusterRoutes.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if emailUnsubscribeYaml, ok := configMap.Data["emailUnsubscribe"]; ok {
+		var emailUnsubscribe EmailUnsubscribe
+		if err := yaml.Unmarshal([]byte(emailUnsubscribeYaml), &emailUnsubscribe); err != nil {
+			return nil, err
+		}
+		if emailUnsubscribe.Key, err = pkg.ResolveSecretRefs(emailUnsubscribe.Key, secret, resolver); err != nil {
+			return nil, fmt.Errorf("invalid email unsubscribe settings: %v", err)
+		}
+		if err := emailUnsubscribe.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid email unsubscribe settings: %v", err)
+		}
+		cfg.EmailUnsubscribe = &emailUnsubscribe
+		cfg.SecretValues = append(cfg.SecretValues, emailUnsubscribe.Key)
+	}
+
 	for _, fn := range opts {
 		if err := fn(&cfg, configMap, secret); err != nil {
 			return nil, err
 		}
 	}
 
+	if inboundWebhooksYaml, ok := configMap.Data["inboundWebhooks"]; ok {
+		if err := yaml.Unmarshal([]byte(inboundWebhooksYaml), &cfg.InboundWebhooks); err != nil {
+			return nil, err
+		}
+		for i, w := range cfg.InboundWebhooks {
+			if err := w.Validate(); err != nil {
+				return nil, err
+			}
+			if _, ok := cfg.Triggers[w.Trigger]; !ok {
+				return nil, fmt.Errorf("inbound webhook %s: trigger '%s' is not configured", w.Name, w.Trigger)
+			}
+			if cfg.InboundWebhooks[i].Secret, err = pkg.ResolveSecretRefs(w.Secret, secret, resolver); err != nil {
+				return nil, fmt.Errorf("inbound webhook %s: %v", w.Name, err)
+			}
+			cfg.SecretValues = append(cfg.SecretValues, cfg.InboundWebhooks[i].Secret)
+		}
+	}
+
 	if cfg.API, err = pkg.NewAPI(*c); err != nil {
 		return nil, err
 	} else {
@@ -112,42 +274,101 @@ func WatchConfig(
 	ctx context.Context,
 	argocdService argocd.Service,
 	clientset kubernetes.Interface,
+	dynamicClient dynamic.Interface,
 	namespace string,
-	callback func(Config) error, opts ...CfgOpts,
+	resolver pkg.SecretResolver,
+	callback func(Config) error,
+	onError func(error),
+	opts ...CfgOpts,
 ) error {
 	var secret *v1.Secret
 	var configMap *v1.ConfigMap
+	fragments := map[string]*v1.ConfigMap{}
+	catalogLoader := catalog.NewLoader()
 	lock := &sync.Mutex{}
-	onNewConfigMapAndSecret := func(newSecret *v1.Secret, newConfigMap *v1.ConfigMap) {
+	var onChanged func(update func())
+	// referenced files and catalogs are downloaded in the background and settings are parsed again once the download
+	// completes
+	reload := func() {
+		onChanged(func() {})
+	}
+	refs := newRefLoader(catalogLoader.Download, reload)
+	onChanged = func(update func()) {
 		lock.Lock()
 		defer lock.Unlock()
-		if newSecret != nil {
-			secret = newSecret
-		}
-		if newConfigMap != nil {
-			configMap = newConfigMap
-		}
+		update()
 
 		if secret != nil && configMap != nil {
-			if cfg, err := NewConfig(configMap, secret, argocdService, opts...); err == nil {
+			var fragmentsList []*v1.ConfigMap
+			for _, fragment := range fragments {
+				fragmentsList = append(fragmentsList, fragment)
+			}
+			// triggers and templates of the config map and fragments take precedence over catalogs
+			merged := MergeConfigFragments(configMap, fragmentsList)
+			catalogs, err := LoadCatalogs(ctx, catalogLoader, configMap, reload)
+			if err == catalog.ErrPending {
+				log.Info("Waiting for catalogs to download")
+				return
+			}
+			if err == nil && catalogs != nil {
+				merged = MergeConfigFragments(merged, []*v1.ConfigMap{catalogs})
+			}
+			if err == nil {
+				merged, err = refs.Resolve(ctx, merged)
+				if err == errRefsPending {
+					log.Info("Waiting for referenced config files to download")
+					return
+				}
+			}
+			var cfg *Config
+			if err == nil {
+				cfg, err = NewConfig(merged, secret, resolver, argocdService, opts...)
+			}
+			if err == nil {
 				if err = callback(*cfg); err != nil {
 					log.Warnf("Failed to apply new settings: %v", err)
 				}
 			} else {
 				log.Warnf("Failed to parse new settings: %v", err)
+				if onError != nil {
+					onError(err)
+				}
 			}
 		}
 	}
 
 	onConfigMapChanged := func(newObj interface{}) {
-		if cm, ok := newObj.(*v1.ConfigMap); ok {
-			onNewConfigMapAndSecret(nil, cm)
+		if cm, ok := newObj.(*v1.ConfigMap); ok && cm.Name == k8s.ConfigMapName {
+			onChanged(func() {
+				configMap = cm
+			})
 		}
 	}
 
 	onSecretChanged := func(newObj interface{}) {
 		if s, ok := newObj.(*v1.Secret); ok {
-			onNewConfigMapAndSecret(s, nil)
+			onChanged(func() {
+				secret = s
+			})
+		}
+	}
+
+	onFragmentChanged := func(newObj interface{}) {
+		if cm, ok := newObj.(*v1.ConfigMap); ok && IsConfigFragment(cm) {
+			onChanged(func() {
+				fragments[cm.Name] = cm
+			})
+		}
+	}
+
+	onFragmentDeleted := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		if cm, ok := obj.(*v1.ConfigMap); ok {
+			onChanged(func() {
+				delete(fragments, cm.Name)
+			})
 		}
 	}
 
@@ -162,6 +383,17 @@ func WatchConfig(
 		},
 	})
 
+	fragmentInformer := k8s.NewConfigFragmentInformer(clientset, namespace)
+	fragmentInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			onFragmentChanged(newObj)
+		},
+		AddFunc: func(obj interface{}) {
+			onFragmentChanged(obj)
+		},
+		DeleteFunc: onFragmentDeleted,
+	})
+
 	secretInformer := k8s.NewSecretInformer(clientset, namespace)
 	secretInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(oldObj, newObj interface{}) {
@@ -174,8 +406,59 @@ func WatchConfig(
 	})
 	go secretInformer.Run(ctx.Done())
 	go cmInformer.Run(ctx.Done())
+	go fragmentInformer.Run(ctx.Done())
+	go wait.Until(func() {
+		if refs.Refresh(ctx) {
+			log.Info("Referenced config files have changed, reloading settings")
+			onChanged(func() {})
+		}
+		if catalogLoader.Retry(ctx) {
+			log.Info("Catalogs that failed to download are loaded, reloading settings")
+			onChanged(func() {})
+		}
+	}, refRefreshInterval, ctx.Done())
+	synced := []cache.InformerSynced{cmInformer.HasSynced, secretInformer.HasSynced, fragmentInformer.HasSynced}
+
+	// triggers, templates and services resources are watched only if dynamic client is provided since CRDs are optional
+	if dynamicClient != nil {
+		onResourceChanged := func(newObj interface{}) {
+			if obj, ok := newObj.(*unstructured.Unstructured); ok {
+				fragment, err := ResourceToConfigFragment(obj)
+				onChanged(func() {
+					if err != nil {
+						log.Warnf("Failed to parse %s: %v", ResourceFragmentName(obj), err)
+						delete(fragments, ResourceFragmentName(obj))
+					} else {
+						fragments[fragment.Name] = fragment
+					}
+				})
+			}
+		}
+		onResourceDeleted := func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if un, ok := obj.(*unstructured.Unstructured); ok {
+				onChanged(func() {
+					delete(fragments, ResourceFragmentName(un))
+				})
+			}
+		}
+		for _, resource := range k8s.ConfigResources {
+			informer := k8s.NewConfigResourceInformer(dynamicClient, namespace, resource)
+			informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					onResourceChanged(newObj)
+				},
+				AddFunc:    onResourceChanged,
+				DeleteFunc: onResourceDeleted,
+			})
+			go informer.Run(ctx.Done())
+			synced = append(synced, informer.HasSynced)
+		}
+	}
 
-	if !cache.WaitForCacheSync(ctx.Done(), cmInformer.HasSynced, secretInformer.HasSynced) {
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
 		return errors.New("timed out waiting for caches to sync")
 	}
 	var missingWarn []string
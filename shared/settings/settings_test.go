@@ -3,14 +3,20 @@ package settings
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
 	"github.com/argoproj-labs/argocd-notifications/shared/argocd/mocks"
+	"github.com/argoproj-labs/argocd-notifications/shared/catalog"
 	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/quota"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes/fake"
 )
@@ -27,7 +33,7 @@ func TestNewConfig_Subscriptions(t *testing.T) {
   triggers:
   - my-trigger2`,
 		},
-	}, emptySecret, nil)
+	}, emptySecret, nil, nil)
 
 	if !assert.NoError(t, err) {
 		return
@@ -42,12 +48,136 @@ func TestNewConfig_Subscriptions(t *testing.T) {
 	}), cfg.Subscriptions)
 }
 
+func TestNewConfig_BotIdentities(t *testing.T) {
+	cfg, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"botIdentities": `
+- service: slack
+  userID: U1234
+  subjects: [alice@example.com, my-org:devops]`,
+		},
+	}, emptySecret, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []string{"alice@example.com", "my-org:devops"}, cfg.BotIdentities.Resolve("slack", "U1234"))
+	assert.Nil(t, cfg.BotIdentities.Resolve("teams", "U1234"))
+	assert.Nil(t, cfg.BotIdentities.Resolve("slack", "U5678"))
+	assert.Nil(t, cfg.BotIdentities.Resolve("slack", ""))
+}
+
+func TestNewConfig_BotIdentitiesWithoutUserID(t *testing.T) {
+	_, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"botIdentities": `
+- service: slack
+  user: alice
+  subjects: [alice@example.com]`,
+		},
+	}, emptySecret, nil, nil)
+	assert.EqualError(t, err, "bot identity 0: userID is required")
+}
+
+func TestNewConfig_Quotas(t *testing.T) {
+	cfg, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"quotas": `
+- projects: [team-*]
+  services: [slack]
+  per: destination
+  maxPerHour: 30`,
+		},
+	}, emptySecret, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, quota.Rules{{Projects: []string{"team-*"}, Services: []string{"slack"}, Per: quota.PerDestination, MaxPerHour: 30}}, cfg.Quotas)
+
+	_, err = NewConfig(&v1.ConfigMap{Data: map[string]string{"quotas": "- per: project"}}, emptySecret, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestNewConfig_Catalogs(t *testing.T) {
+	digest := "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	cfg, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"catalogs": `
+- url: oci://ghcr.io/my-org/notifications-catalog:v1
+  digest: ` + digest,
+		},
+	}, emptySecret, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []catalog.Source{{URL: "oci://ghcr.io/my-org/notifications-catalog:v1", Digest: digest}}, cfg.Catalogs)
+
+	_, err = NewConfig(&v1.ConfigMap{Data: map[string]string{"catalogs": "- url: https://example.com/catalog.yaml"}}, emptySecret, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestNewConfig_Maintenance(t *testing.T) {
+	cfg, err := NewConfig(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{MaintenanceAnnotationKey: "2020-10-14T18:00:00Z"}},
+	}, emptySecret, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, cfg.Maintenance.IsActive(time.Date(2020, 10, 14, 17, 0, 0, 0, time.UTC)))
+	assert.False(t, cfg.Maintenance.IsActive(time.Date(2020, 10, 14, 18, 0, 0, 0, time.UTC)))
+
+	cfg, err = NewConfig(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{MaintenanceAnnotationKey: "true"}},
+	}, emptySecret, nil, nil)
+	if assert.NoError(t, err) {
+		assert.True(t, cfg.Maintenance.IsActive(time.Now()))
+	}
+
+	_, err = NewConfig(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{MaintenanceAnnotationKey: "tomorrow"}},
+	}, emptySecret, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestNewConfig_DefaultSubscriptions(t *testing.T) {
+	cfg, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"defaultSubscriptions": `
+- recipients: [slack:prod-deploys]
+  triggers: [on-deployed]
+  projects: [prod-*]
+  namespaces: [prod]`,
+		},
+	}, emptySecret, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	newApp := func(project string, namespace string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{"project": project, "destination": map[string]interface{}{"namespace": namespace}},
+		}}
+	}
+	assert.Equal(t, pkg.Subscriptions{
+		"on-deployed": []services.Destination{{Service: "slack", Recipient: "prod-deploys"}},
+	}, cfg.GetGlobalSubscriptions(newApp("prod-eu", "prod")))
+	assert.Empty(t, cfg.GetGlobalSubscriptions(newApp("staging", "prod")))
+	assert.Empty(t, cfg.GetGlobalSubscriptions(newApp("prod-eu", "staging")))
+}
+
+func TestNewConfig_InvalidDefaultSubscriptions(t *testing.T) {
+	_, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{"defaultSubscriptions": `[{recipients: [slack:test], projects: ["[a-"]}]`},
+	}, emptySecret, nil, nil)
+	assert.Error(t, err)
+}
+
 func TestNewSettings_Context(t *testing.T) {
 	cfg, err := NewConfig(&v1.ConfigMap{
 		Data: map[string]string{
 			"context": `{hello: world}`,
 		},
-	}, emptySecret, nil)
+	}, emptySecret, nil, nil)
 
 	if !assert.NoError(t, err) {
 		return
@@ -60,7 +190,7 @@ func TestNewSettings_DefaultTriggers(t *testing.T) {
 		Data: map[string]string{
 			"defaultTriggers": `[trigger1, trigger2]`,
 		},
-	}, emptySecret, nil)
+	}, emptySecret, nil, nil)
 
 	if !assert.NoError(t, err) {
 		return
@@ -68,6 +198,126 @@ func TestNewSettings_DefaultTriggers(t *testing.T) {
 	assert.Equal(t, []string{"trigger1", "trigger2"}, cfg.DefaultTriggers)
 }
 
+func TestNewConfig_InboundWebhooks(t *testing.T) {
+	cfg, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"trigger.on-image-built": `[{when: "true", send: [image-built]}]`,
+			"inboundWebhooks": `
+- name: image-build
+  secret: $image-build-secret
+  trigger: on-image-built
+  appSelector: app.metadata.name == event.app`,
+		},
+	}, &v1.Secret{Data: map[string][]byte{"image-build-secret": []byte("my-secret")}}, nil, nil)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []InboundWebhook{{
+		Name: "image-build", Secret: "my-secret", Trigger: "on-image-built", AppSelector: "app.metadata.name == event.app",
+	}}, cfg.InboundWebhooks)
+}
+
+func TestNewConfig_InboundWebhookUnknownTrigger(t *testing.T) {
+	_, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"inboundWebhooks": `[{name: image-build, secret: abc, trigger: on-image-built}]`,
+		},
+	}, emptySecret, nil, nil)
+
+	assert.EqualError(t, err, "inbound webhook image-build: trigger 'on-image-built' is not configured")
+}
+
+func TestNewConfig_InboundWebhookUnresolvedSecret(t *testing.T) {
+	_, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"trigger.on-image-built": `[{when: "true", send: [image-built]}]`,
+			"inboundWebhooks":        `[{name: image-build, secret: $image-build-secret, trigger: on-image-built}]`,
+		},
+	}, emptySecret, nil, nil)
+
+	assert.EqualError(t, err, "inbound webhook image-build: secret reference '$image-build-secret' is not resolved")
+}
+
+func TestNewConfig_EmailUnsubscribe(t *testing.T) {
+	cfg, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"emailUnsubscribe": `{url: "https://bot.example.com/email/unsubscribe", key: $email-unsubscribe-key}`,
+		},
+	}, &v1.Secret{Data: map[string][]byte{"email-unsubscribe-key": []byte("my-key")}}, nil, nil)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, &EmailUnsubscribe{URL: "https://bot.example.com/email/unsubscribe", Key: "my-key"}, cfg.EmailUnsubscribe)
+}
+
+func TestNewConfig_EmailUnsubscribeWithoutKey(t *testing.T) {
+	_, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"emailUnsubscribe": `{url: "https://bot.example.com/email/unsubscribe"}`,
+		},
+	}, emptySecret, nil, nil)
+
+	assert.EqualError(t, err, "invalid email unsubscribe settings: email unsubscribe key must be specified")
+
+	_, err = NewConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"emailUnsubscribe": `{url: "https://bot.example.com/email/unsubscribe", key: $email-unsubscribe-key}`,
+		},
+	}, emptySecret, nil, nil)
+
+	assert.EqualError(t, err, "invalid email unsubscribe settings: secret reference '$email-unsubscribe-key' is not resolved")
+}
+
+func TestNewConfig_DestinationGroups(t *testing.T) {
+	cfg, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"destinationGroups": `
+team-payments:
+- slack:payments
+- email:payments@example.com`,
+		},
+	}, emptySecret, nil, nil)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, DestinationGroups{"team-payments": {"slack:payments", "email:payments@example.com"}}, cfg.DestinationGroups)
+	assert.Equal(t, []services.Destination{{Service: "slack", Recipient: "payments"}, {Service: "email", Recipient: "payments@example.com"}},
+		cfg.DestinationGroups.Expand(pkg.Subscriptions{"on-sync-failed": {{Service: GroupService, Recipient: "team-payments"}}})["on-sync-failed"])
+}
+
+func TestNewConfig_ClusterRoutes(t *testing.T) {
+	cfg, err := NewConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"destinationGroups": `
+team-oncall:
+- slack:oncall
+- email:oncall@example.com`,
+			"clusterRoutes": `
+alerts:
+- clusters: [prod-*]
+  destinations: [group:team-oncall]
+- clusters: ['*']
+  destinations: [slack:staging]`,
+		},
+	}, emptySecret, nil, nil)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, ClusterRoutes{"alerts": {
+		{Clusters: []string{"prod-*"}, Destinations: []string{"group:team-oncall"}},
+		{Clusters: []string{"*"}, Destinations: []string{"slack:staging"}},
+	}}, cfg.ClusterRoutes)
+	subs := pkg.Subscriptions{"on-sync-failed": {{Service: ClusterRouteService, Recipient: "alerts"}}}
+	assert.Equal(t, []services.Destination{{Service: "slack", Recipient: "oncall"}, {Service: "email", Recipient: "oncall@example.com"}},
+		cfg.ExpandDestinations(newClusterApp("prod-eu", ""), subs)["on-sync-failed"])
+	assert.Equal(t, []services.Destination{{Service: "slack", Recipient: "staging"}},
+		cfg.ExpandDestinations(newClusterApp("staging", ""), subs)["on-sync-failed"])
+}
+
 func TestWatchConfig(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -96,10 +346,10 @@ argocdUrl: https://myargocd.com
 	argocdService := mocks.NewMockService(ctrl)
 	clientset := fake.NewSimpleClientset(configMap, secret)
 	cfgCn := make(chan Config)
-	err := WatchConfig(ctx, argocdService, clientset, "default", func(cfg Config) error {
+	err := WatchConfig(ctx, argocdService, clientset, nil, "default", nil, func(cfg Config) error {
 		cfgCn <- cfg
 		return nil
-	})
+	}, nil)
 
 	if !assert.NoError(t, err) {
 		return
@@ -109,3 +359,49 @@ argocdUrl: https://myargocd.com
 
 	assert.Equal(t, "https://myargocd.com", parsedCfg.Context["argocdUrl"])
 }
+
+func TestWatchConfig_Fragments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: k8s.ConfigMapName, Namespace: "default"},
+		Data:       map[string]string{"template.main": `message: main`},
+	}
+	fragment := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "team-a-notifications",
+			Namespace: "default",
+			Labels:    map[string]string{k8s.ConfigFragmentLabel: "true"},
+		},
+		Data: map[string]string{"template.team-a": `message: team-a`},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: k8s.SecretName, Namespace: "default"},
+		Data:       map[string][]byte{},
+	}
+
+	clientset := fake.NewSimpleClientset(configMap, fragment, secret)
+	cfgCn := make(chan Config, 10)
+	err := WatchConfig(ctx, mocks.NewMockService(ctrl), clientset, nil, "default", nil, func(cfg Config) error {
+		cfgCn <- cfg
+		return nil
+	}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for {
+		select {
+		case cfg := <-cfgCn:
+			if _, ok := cfg.Templates["team-a"]; ok {
+				assert.Contains(t, cfg.Templates, "main")
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("config with fragment templates is not received")
+		}
+	}
+}
@@ -2,7 +2,10 @@ package settings
 
 import (
 	"encoding/json"
+	"fmt"
+	"path"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 )
 
@@ -10,6 +13,8 @@ type rawSubscription struct {
 	Recipients []string `json:"recipients"`
 	Triggers   []string `json:"triggers"`
 	Selector   string   `json:"selector"`
+	Projects   []string `json:"projects,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
 }
 
 // DefaultSubscription holds recipients that receives notification by default.
@@ -20,6 +25,20 @@ type DefaultSubscription struct {
 	Triggers []string
 	// Options label selector that limits applied applications
 	Selector labels.Selector
+	// Optional glob patterns of application projects
+	Projects []string
+	// Optional glob patterns of application destination namespaces
+	Namespaces []string
+}
+
+// MatchesApp returns true if the application matches subscription selector, projects and namespaces
+func (s *DefaultSubscription) MatchesApp(app *unstructured.Unstructured) bool {
+	if s.Selector != nil && !s.Selector.Matches(labels.Set(app.GetLabels())) {
+		return false
+	}
+	project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+	namespace, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "namespace")
+	return included(s.Projects, nil, project) && included(s.Namespaces, nil, namespace)
 }
 
 func (s *DefaultSubscription) MatchesTrigger(trigger string) bool {
@@ -41,6 +60,13 @@ func (s *DefaultSubscription) UnmarshalJSON(data []byte) error {
 	}
 	s.Triggers = raw.Triggers
 	s.Recipients = raw.Recipients
+	for _, pattern := range append(raw.Projects, raw.Namespaces...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+		}
+	}
+	s.Projects = raw.Projects
+	s.Namespaces = raw.Namespaces
 	selector, err := labels.Parse(raw.Selector)
 	if err != nil {
 		return err
@@ -53,6 +79,8 @@ func (s *DefaultSubscription) MarshalJSON() ([]byte, error) {
 	raw := rawSubscription{
 		Triggers:   s.Triggers,
 		Recipients: s.Recipients,
+		Projects:   s.Projects,
+		Namespaces: s.Namespaces,
 	}
 	if s.Selector != nil {
 		raw.Selector = s.Selector.String()
@@ -0,0 +1,48 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// defaultLinkExpiration is how long unsubscribe links are valid if the expiration is not configured
+const defaultLinkExpiration = 30 * 24 * time.Hour
+
+// EmailUnsubscribe configures signed links that allow notification recipients to unsubscribe themselves
+type EmailUnsubscribe struct {
+	// URL is the external URL of the bot unsubscribe endpoint, e.g. https://argocd-notifications-bot.example.com/email/unsubscribe
+	URL string `json:"url"`
+	// Key signs unsubscribe links. Might reference the notifications secret key, e.g. $email-unsubscribe-key
+	Key string `json:"key"`
+	// LinkExpiration is how long the link is valid after the notification is sent, e.g. 168h. Defaults to 30 days
+	LinkExpiration string `json:"linkExpiration,omitempty"`
+}
+
+// Validate returns an error if the URL or key is missing
+func (u EmailUnsubscribe) Validate() error {
+	if u.URL == "" {
+		return errors.New("email unsubscribe URL must be specified")
+	}
+	if _, err := url.Parse(u.URL); err != nil {
+		return err
+	}
+	if u.Key == "" {
+		return errors.New("email unsubscribe key must be specified")
+	}
+	if u.LinkExpiration != "" {
+		if expiration, err := time.ParseDuration(u.LinkExpiration); err != nil || expiration <= 0 {
+			return fmt.Errorf("invalid link expiration '%s'", u.LinkExpiration)
+		}
+	}
+	return nil
+}
+
+// GetLinkExpiration returns how long the link is valid after the notification is sent
+func (u EmailUnsubscribe) GetLinkExpiration() time.Duration {
+	if expiration, err := time.ParseDuration(u.LinkExpiration); err == nil && expiration > 0 {
+		return expiration
+	}
+	return defaultLinkExpiration
+}
@@ -0,0 +1,209 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultBatchSize     = 512
+	defaultFlushInterval = 5 * time.Second
+	// maxQueueSize limits number of spans kept in memory if the collector is not available
+	maxQueueSize = 4096
+
+	spanKindInternal = 1
+	statusCodeOK     = 1
+	statusCodeError  = 2
+)
+
+// NewOTLPExporter returns exporter that sends spans to the OpenTelemetry collector using OTLP/HTTP protocol with JSON encoding
+func NewOTLPExporter(endpoint string, serviceName string) *otlpExporter {
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		endpoint = "http://" + endpoint
+	}
+	e := &otlpExporter{
+		url:         strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		batchSize:   defaultBatchSize,
+		flush:       make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+	e.wg.Add(1)
+	go e.run(defaultFlushInterval)
+	return e
+}
+
+type otlpExporter struct {
+	url         string
+	serviceName string
+	client      *http.Client
+	batchSize   int
+
+	lock     sync.Mutex
+	spans    []*Span
+	flush    chan struct{}
+	done     chan struct{}
+	shutdown sync.Once
+	wg       sync.WaitGroup
+}
+
+func (e *otlpExporter) Export(span *Span) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if len(e.spans) >= maxQueueSize {
+		return
+	}
+	e.spans = append(e.spans, span)
+	if len(e.spans) >= e.batchSize {
+		select {
+		case e.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Shutdown sends remaining spans and stops the exporter
+func (e *otlpExporter) Shutdown() {
+	e.shutdown.Do(func() {
+		close(e.done)
+		e.wg.Wait()
+		e.send()
+	})
+}
+
+func (e *otlpExporter) run(interval time.Duration) {
+	defer e.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			e.send()
+		case <-e.flush:
+			e.send()
+		case <-e.done:
+			return
+		}
+	}
+}
+
+func (e *otlpExporter) send() {
+	e.lock.Lock()
+	spans := e.spans
+	e.spans = nil
+	e.lock.Unlock()
+	if len(spans) == 0 {
+		return
+	}
+	data, err := json.Marshal(e.newRequest(spans))
+	if err != nil {
+		log.Warnf("Failed to serialize spans: %v", err)
+		return
+	}
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.Warnf("Failed to export %d spans: %v", len(spans), err)
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		log.Warnf("Failed to export %d spans: collector responded with %d: %s", len(spans), resp.StatusCode, string(body))
+	}
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            status     `json:"status"`
+}
+
+type scopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type resourceSpans struct {
+	Resource struct {
+		Attributes []keyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+func toKeyValues(attributes map[string]string) []keyValue {
+	var res []keyValue
+	for k, v := range attributes {
+		res = append(res, keyValue{Key: k, Value: anyValue{StringValue: v}})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+	return res
+}
+
+func (e *otlpExporter) newRequest(spans []*Span) exportRequest {
+	scope := scopeSpans{}
+	scope.Scope.Name = "github.com/argoproj-labs/argocd-notifications"
+	for _, s := range spans {
+		s.lock.Lock()
+		span := otlpSpan{
+			TraceID:           s.TraceID.String(),
+			SpanID:            s.SpanID.String(),
+			Name:              s.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        toKeyValues(s.Attributes),
+			Status:            status{Code: statusCodeOK},
+		}
+		if !s.ParentSpanID.IsEmpty() {
+			span.ParentSpanID = s.ParentSpanID.String()
+		}
+		if s.Err != nil {
+			span.Status = status{Code: statusCodeError, Message: s.Err.Error()}
+		}
+		s.lock.Unlock()
+		scope.Spans = append(scope.Spans, span)
+	}
+	res := resourceSpans{ScopeSpans: []scopeSpans{scope}}
+	res.Resource.Attributes = toKeyValues(map[string]string{"service.name": e.serviceName})
+	return exportRequest{ResourceSpans: []resourceSpans{res}}
+}
@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartSpan_NoExporter(t *testing.T) {
+	SetExporter(nil)
+
+	ctx, span := StartSpan(context.Background(), "test")
+
+	assert.Nil(t, span)
+	assert.Nil(t, FromContext(ctx))
+	// methods of nil span should not panic
+	span.SetAttribute("foo", "bar")
+	span.SetError(errors.New("fake"))
+	span.Finish()
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var requests []exportRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		data, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		req := exportRequest{}
+		assert.NoError(t, json.Unmarshal(data, &req))
+		requests = append(requests, req)
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(server.URL, "test-service")
+	SetExporter(exporter)
+	defer SetExporter(nil)
+
+	ctx, parent := StartSpan(context.Background(), "parent")
+	_, child := StartSpan(ctx, "child")
+	child.SetAttribute("service", "slack")
+	child.SetError(errors.New("channel not found"))
+	child.Finish()
+	parent.Finish()
+	exporter.Shutdown()
+
+	if !assert.Len(t, requests, 1) {
+		return
+	}
+	res := requests[0].ResourceSpans[0]
+	assert.Equal(t, []keyValue{{Key: "service.name", Value: anyValue{StringValue: "test-service"}}}, res.Resource.Attributes)
+	spans := res.ScopeSpans[0].Spans
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, parent.SpanID.String(), spans[0].ParentSpanID)
+	assert.Equal(t, parent.TraceID.String(), spans[0].TraceID)
+	assert.Equal(t, status{Code: statusCodeError, Message: "channel not found"}, spans[0].Status)
+	assert.Equal(t, []keyValue{{Key: "service", Value: anyValue{StringValue: "slack"}}}, spans[0].Attributes)
+	assert.Equal(t, "parent", spans[1].Name)
+	assert.Empty(t, spans[1].ParentSpanID)
+}
@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+type contextKey struct{}
+
+// Exporter sends finished spans to the tracing backend
+type Exporter interface {
+	Export(span *Span)
+}
+
+var (
+	exporter     Exporter
+	exporterLock sync.RWMutex
+)
+
+// SetExporter configures the exporter used by all spans. Spans are not recorded if no exporter is configured
+func SetExporter(e Exporter) {
+	exporterLock.Lock()
+	defer exporterLock.Unlock()
+	exporter = e
+}
+
+func getExporter() Exporter {
+	exporterLock.RLock()
+	defer exporterLock.RUnlock()
+	return exporter
+}
+
+type TraceID [16]byte
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+type SpanID [8]byte
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id SpanID) IsEmpty() bool {
+	return id == SpanID{}
+}
+
+// Span represents the single operation within the trace. Methods of nil span are no-op
+type Span struct {
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Name         string
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]string
+	Err          error
+
+	exporter Exporter
+	lock     sync.Mutex
+}
+
+// StartSpan starts new span which is a child of the span stored in the given context
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	e := getExporter()
+	if e == nil {
+		return ctx, nil
+	}
+	span := &Span{Name: name, Start: time.Now(), Attributes: map[string]string{}, exporter: e}
+	if parent := FromContext(ctx); parent != nil {
+		span.TraceID = parent.TraceID
+		span.ParentSpanID = parent.SpanID
+	} else {
+		_, _ = rand.Read(span.TraceID[:])
+	}
+	_, _ = rand.Read(span.SpanID[:])
+	return context.WithValue(ctx, contextKey{}, span), span
+}
+
+// FromContext returns the span stored in the context or nil
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(contextKey{}).(*Span)
+	return span
+}
+
+// SetAttribute sets the span attribute
+func (s *Span) SetAttribute(key string, value string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Attributes[key] = value
+}
+
+// SetError marks span as failed if error is not nil
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Err = err
+}
+
+// Finish completes the span and passes it to the exporter
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.End = time.Now()
+	s.lock.Unlock()
+	s.exporter.Export(s)
+}
@@ -0,0 +1,79 @@
+package unsubscribe
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	appParam       = "app"
+	triggerParam   = "trigger"
+	serviceParam   = "service"
+	recipientParam = "recipient"
+	expiresParam   = "exp"
+	signatureParam = "sig"
+)
+
+// Link identifies the notification recipient that might unsubscribe itself from the application trigger
+type Link struct {
+	App       string
+	Trigger   string
+	Service   string
+	Recipient string
+	// Expires is the Unix time after which the link is rejected
+	Expires int64
+}
+
+func (l Link) sign(key []byte) string {
+	// fields are JSON encoded to keep the signed payload unambiguous if values contain separators
+	payload, _ := json.Marshal([]string{l.App, l.Trigger, l.Service, l.Recipient, strconv.FormatInt(l.Expires, 10)})
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// URL returns the base URL with the link parameters and signature in the query
+func (l Link) URL(baseURL string, key []byte) string {
+	query := url.Values{
+		appParam:       {l.App},
+		triggerParam:   {l.Trigger},
+		serviceParam:   {l.Service},
+		recipientParam: {l.Recipient},
+		expiresParam:   {strconv.FormatInt(l.Expires, 10)},
+		signatureParam: {l.sign(key)},
+	}
+	separator := "?"
+	if strings.Contains(baseURL, "?") {
+		separator = "&"
+	}
+	return baseURL + separator + query.Encode()
+}
+
+// Parse returns the link with the valid signature from the URL query. Links that have expired are rejected
+func Parse(query url.Values, key []byte) (Link, error) {
+	link := Link{
+		App:       query.Get(appParam),
+		Trigger:   query.Get(triggerParam),
+		Service:   query.Get(serviceParam),
+		Recipient: query.Get(recipientParam),
+	}
+	expires, err := strconv.ParseInt(query.Get(expiresParam), 10, 64)
+	if link.App == "" || link.Trigger == "" || link.Service == "" || link.Recipient == "" || err != nil {
+		return Link{}, errors.New("unsubscribe link is incomplete")
+	}
+	link.Expires = expires
+	if !hmac.Equal([]byte(query.Get(signatureParam)), []byte(link.sign(key))) {
+		return Link{}, errors.New("invalid unsubscribe link signature")
+	}
+	if time.Now().Unix() > link.Expires {
+		return Link{}, errors.New("unsubscribe link has expired")
+	}
+	return link, nil
+}
@@ -0,0 +1,59 @@
+package unsubscribe
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testKey = []byte("my-key")
+
+func parseURL(t *testing.T, rawURL string) url.Values {
+	u, err := url.Parse(rawURL)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return u.Query()
+}
+
+func TestURL_RoundTrip(t *testing.T) {
+	link := Link{App: "guestbook", Trigger: "on-sync-failed", Service: "email", Recipient: "alice@example.com", Expires: time.Now().Add(time.Hour).Unix()}
+
+	rawURL := link.URL("https://bot.example.com/email/unsubscribe", testKey)
+	assert.Contains(t, rawURL, "https://bot.example.com/email/unsubscribe?")
+
+	parsed, err := Parse(parseURL(t, rawURL), testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, link, parsed)
+}
+
+func TestParse_InvalidSignature(t *testing.T) {
+	link := Link{App: "guestbook", Trigger: "on-sync-failed", Service: "email", Recipient: "alice@example.com", Expires: time.Now().Add(time.Hour).Unix()}
+	query := parseURL(t, link.URL("https://bot.example.com/email/unsubscribe", testKey))
+
+	query.Set(recipientParam, "bob@example.com")
+	_, err := Parse(query, testKey)
+	assert.EqualError(t, err, "invalid unsubscribe link signature")
+
+	_, err = Parse(parseURL(t, link.URL("https://bot.example.com/email/unsubscribe", []byte("other-key"))), testKey)
+	assert.EqualError(t, err, "invalid unsubscribe link signature")
+
+	query = parseURL(t, link.URL("https://bot.example.com/email/unsubscribe", testKey))
+	query.Set(expiresParam, "4102444800")
+	_, err = Parse(query, testKey)
+	assert.EqualError(t, err, "invalid unsubscribe link signature")
+}
+
+func TestParse_Expired(t *testing.T) {
+	link := Link{App: "guestbook", Trigger: "on-sync-failed", Service: "email", Recipient: "alice@example.com", Expires: time.Now().Add(-time.Minute).Unix()}
+
+	_, err := Parse(parseURL(t, link.URL("https://bot.example.com/email/unsubscribe", testKey)), testKey)
+	assert.EqualError(t, err, "unsubscribe link has expired")
+}
+
+func TestParse_Incomplete(t *testing.T) {
+	_, err := Parse(url.Values{appParam: {"guestbook"}}, testKey)
+	assert.EqualError(t, err, "unsubscribe link is incomplete")
+}
@@ -0,0 +1,269 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	httputil "github.com/argoproj-labs/argocd-notifications/pkg/util/http"
+)
+
+const (
+	defaultAuthPath  = "kubernetes"
+	defaultTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	defaultField     = "value"
+	// minRenewInterval prevents hammering Vault if the token has a very short TTL
+	minRenewInterval = 10 * time.Second
+)
+
+// Options holds settings of the connection to Vault
+type Options struct {
+	// Address is the Vault server address, e.g. https://vault.vault.svc:8200
+	Address string
+	// Role is the Vault role used to log in with the Kubernetes auth method
+	Role string
+	// AuthPath is the mount path of the Kubernetes auth method
+	AuthPath string
+	// TokenFile is the path to the service account token used to log in
+	TokenFile string
+	// Namespace is the Vault Enterprise namespace
+	Namespace string
+	// TLS holds TLS settings of the connection to Vault
+	TLS httputil.TLSOptions
+	// PathVars are available in secret path templates, e.g. $vault:secret/data/{{.namespace}}/slack#token
+	PathVars map[string]string
+}
+
+// NewClient returns Vault client that logs in using the Kubernetes auth method and reads secrets from the KV secrets engine.
+// References have the `<path>#<field>` format, the field defaults to `value`. github.com/hashicorp/vault/api is not a
+// dependency of the module, so the client calls the small subset of the Vault HTTP API directly: the Kubernetes login,
+// the token renewal and KV reads.
+func NewClient(opts Options) *client {
+	if opts.AuthPath == "" {
+		opts.AuthPath = defaultAuthPath
+	}
+	if opts.TokenFile == "" {
+		opts.TokenFile = defaultTokenFile
+	}
+	return &client{
+		opts:       opts,
+		httpClient: &http.Client{Timeout: 30 * time.Second, Transport: httputil.NewTransport(opts.Address, opts.TLS, httputil.ProxyOptions{})},
+		now:        time.Now,
+	}
+}
+
+type client struct {
+	opts       Options
+	httpClient *http.Client
+	now        func() time.Time
+
+	lock      sync.Mutex
+	token     string
+	expiresAt time.Time
+	renewable bool
+}
+
+type authResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+type secretResponse struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+func (c *client) do(method string, path string, token string, body interface{}, result interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.opts.Address, "/")+"/v1/"+strings.TrimPrefix(path, "/"), reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.opts.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.opts.Namespace)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// response body is not included because it might contain sensitive data
+		return fmt.Errorf("vault request %s %s has failed with error code %d", method, path, resp.StatusCode)
+	}
+	return json.Unmarshal(data, result)
+}
+
+func (c *client) setToken(resp authResponse) {
+	c.token = resp.Auth.ClientToken
+	c.renewable = resp.Auth.Renewable
+	c.expiresAt = time.Time{}
+	if resp.Auth.LeaseDuration > 0 {
+		c.expiresAt = c.now().Add(time.Duration(resp.Auth.LeaseDuration) * time.Second)
+	}
+}
+
+func (c *client) login() error {
+	jwt, err := ioutil.ReadFile(c.opts.TokenFile)
+	if err != nil {
+		return fmt.Errorf("failed to read service account token: %v", err)
+	}
+	var resp authResponse
+	err = c.do(http.MethodPost, "auth/"+strings.Trim(c.opts.AuthPath, "/")+"/login", "", map[string]string{
+		"role": c.opts.Role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	}, &resp)
+	if err != nil {
+		return err
+	}
+	if resp.Auth.ClientToken == "" {
+		return errors.New("vault login response does not have client token")
+	}
+	c.setToken(resp)
+	return nil
+}
+
+// getToken returns the valid token and logs in if the token is missing or expired
+func (c *client) getToken() (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.token == "" || (!c.expiresAt.IsZero() && !c.now().Before(c.expiresAt)) {
+		if err := c.login(); err != nil {
+			return "", err
+		}
+	}
+	return c.token, nil
+}
+
+// nextRenewal returns delay before the token renewal or zero if the token does not expire. Must be called with lock held
+func (c *client) nextRenewal() time.Duration {
+	if c.token == "" {
+		return minRenewInterval
+	}
+	if c.expiresAt.IsZero() {
+		return 0
+	}
+	// renew when two thirds of the lease duration has passed
+	interval := c.expiresAt.Sub(c.now()) * 2 / 3
+	if interval < minRenewInterval {
+		interval = minRenewInterval
+	}
+	return interval
+}
+
+// renew extends the token lease or logs in again if the token cannot be renewed and returns the delay before the next renewal
+func (c *client) renew() time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var err error
+	if c.token != "" && c.renewable {
+		var resp authResponse
+		if err = c.do(http.MethodPost, "auth/token/renew-self", c.token, map[string]string{}, &resp); err == nil {
+			c.setToken(resp)
+			return c.nextRenewal()
+		}
+		log.Warnf("Failed to renew vault token, logging in again: %v", err)
+	}
+	if err = c.login(); err != nil {
+		log.Warnf("Failed to log in to vault: %v", err)
+	}
+	return c.nextRenewal()
+}
+
+// Run keeps the token valid by renewing it before the lease expires
+func (c *client) Run(ctx context.Context) {
+	if _, err := c.getToken(); err != nil {
+		log.Warnf("Failed to log in to vault: %v", err)
+	}
+	c.lock.Lock()
+	interval := c.nextRenewal()
+	c.lock.Unlock()
+	for interval > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		interval = c.renew()
+	}
+}
+
+func (c *client) renderPath(path string) (string, error) {
+	if !strings.Contains(path, "{{") {
+		return path, nil
+	}
+	tmpl, err := texttemplate.New(path).Option("missingkey=error").Parse(path)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, c.opts.PathVars); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// GetSecret returns the field of the secret referenced using `<path>#<field>` format
+func (c *client) GetSecret(key string) (string, error) {
+	path, field := key, defaultField
+	if i := strings.LastIndex(key, "#"); i >= 0 {
+		path, field = key[:i], key[i+1:]
+	}
+	path, err := c.renderPath(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid vault secret path '%s': %v", key, err)
+	}
+	token, err := c.getToken()
+	if err != nil {
+		return "", err
+	}
+	var resp secretResponse
+	if err := c.do(http.MethodGet, path, token, nil, &resp); err != nil {
+		return "", err
+	}
+	data := resp.Data
+	// KV version 2 secrets engine wraps the secret data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	val, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("vault secret %s does not have field %s", path, field)
+	}
+	if str, ok := val.(string); ok {
+		return str, nil
+	}
+	return fmt.Sprintf("%v", val), nil
+}
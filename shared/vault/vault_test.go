@@ -0,0 +1,102 @@
+package vault
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T, logins *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/kubernetes/login":
+			var body map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, map[string]string{"role": "argocd-notifications", "jwt": "my-jwt"}, body)
+			*logins++
+			_, _ = w.Write([]byte(`{"auth": {"client_token": "my-token", "lease_duration": 3600, "renewable": true}}`))
+		case "/v1/auth/token/renew-self":
+			assert.Equal(t, "my-token", r.Header.Get("X-Vault-Token"))
+			_, _ = w.Write([]byte(`{"auth": {"client_token": "my-token", "lease_duration": 3600, "renewable": true}}`))
+		case "/v1/secret/data/default/slack":
+			if r.Header.Get("X-Vault-Token") != "my-token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data": {"data": {"token": "xoxb-123"}, "metadata": {"version": 1}}}`))
+		case "/v1/kv/smtp":
+			_, _ = w.Write([]byte(`{"data": {"value": "password"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newTestClient(t *testing.T, address string) (*client, func()) {
+	tokenFile, err := ioutil.TempFile("", "token")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, _ = tokenFile.WriteString("my-jwt\n")
+	_ = tokenFile.Close()
+	c := NewClient(Options{
+		Address:   address,
+		Role:      "argocd-notifications",
+		TokenFile: tokenFile.Name(),
+		PathVars:  map[string]string{"namespace": "default"},
+	})
+	return c, func() {
+		_ = os.Remove(tokenFile.Name())
+	}
+}
+
+func TestGetSecret(t *testing.T) {
+	logins := 0
+	server := newTestServer(t, &logins)
+	defer server.Close()
+	c, closer := newTestClient(t, server.URL)
+	defer closer()
+
+	val, err := c.GetSecret("secret/data/{{.namespace}}/slack#token")
+	assert.NoError(t, err)
+	assert.Equal(t, "xoxb-123", val)
+
+	val, err = c.GetSecret("kv/smtp")
+	assert.NoError(t, err)
+	assert.Equal(t, "password", val)
+
+	_, err = c.GetSecret("secret/data/default/slack#missing")
+	assert.Error(t, err)
+
+	assert.Equal(t, 1, logins)
+}
+
+func TestRenew(t *testing.T) {
+	logins := 0
+	server := newTestServer(t, &logins)
+	defer server.Close()
+	c, closer := newTestClient(t, server.URL)
+	defer closer()
+	now := time.Now()
+	c.now = func() time.Time {
+		return now
+	}
+
+	_, err := c.getToken()
+	assert.NoError(t, err)
+
+	assert.Equal(t, 40*time.Minute, c.renew())
+	assert.Equal(t, 1, logins)
+
+	// expired token is replaced using the new login
+	now = now.Add(2 * time.Hour)
+	_, err = c.getToken()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, logins)
+}
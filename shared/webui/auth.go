@@ -0,0 +1,355 @@
+package webui
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc"
+	"golang.org/x/oauth2"
+)
+
+const (
+	loginPath    = PathPrefix + "auth/login"
+	callbackPath = PathPrefix + "auth/callback"
+	logoutPath   = PathPrefix + "auth/logout"
+
+	sessionCookieName = "argocd-notifications-session"
+	stateCookieName   = "argocd-notifications-state"
+
+	defaultSessionTTL = 12 * time.Hour
+	// loginTimeout is how long the user might stay on the Dex sign in page
+	loginTimeout = 10 * time.Minute
+)
+
+// DexOptions holds settings of the OpenID Connect client registered in Argo CD's Dex
+type DexOptions struct {
+	// IssuerURL is the Dex issuer, e.g. https://argocd.example.com/api/dex
+	IssuerURL string
+	ClientID  string
+	// ClientSecretFile is the path to the file with the client secret. The file is read on every sign in, so the secret might be rotated
+	ClientSecretFile string
+	// RedirectURL is the external URL of the callback endpoint, e.g. https://notifications.example.com/ui/auth/callback
+	RedirectURL string
+	// RootCAFile is the path to PEM encoded certificates that verify the Dex certificate in addition to the system certificates
+	RootCAFile string
+	// SessionKey signs session cookies. All replicas must use the same key
+	SessionKey []byte
+	// SessionTTL is the max time the user stays signed in. The session never outlives the ID token. Defaults to 12 hours
+	SessionTTL time.Duration
+}
+
+// User is the user signed in using Dex
+type User struct {
+	Email   string   `json:"email,omitempty"`
+	Subject string   `json:"sub"`
+	Groups  []string `json:"groups,omitempty"`
+	Expiry  int64    `json:"exp"`
+}
+
+// Name returns the user email or the subject if email is not available
+func (u *User) Name() string {
+	if u.Email != "" {
+		return u.Email
+	}
+	return u.Subject
+}
+
+// Subjects returns Argo CD RBAC subjects of the user
+func (u *User) Subjects() []string {
+	return append([]string{u.Name()}, u.Groups...)
+}
+
+// loginState is kept in the signed cookie between the redirect to Dex and the callback
+type loginState struct {
+	State string `json:"state"`
+	Nonce string `json:"nonce"`
+	// Verifier is the PKCE code verifier (RFC 7636)
+	Verifier string `json:"verifier"`
+}
+
+// Authenticator signs users in using the OpenID Connect authorization code flow with PKCE and keeps the session in the
+// signed cookie
+type Authenticator struct {
+	opts   DexOptions
+	client *http.Client
+	now    func() time.Time
+
+	lock     sync.Mutex
+	provider *oidc.Provider
+}
+
+func NewAuthenticator(opts DexOptions) (*Authenticator, error) {
+	if opts.IssuerURL == "" || opts.ClientID == "" || opts.RedirectURL == "" {
+		return nil, errors.New("Dex issuer URL, client ID and redirect URL must be specified")
+	}
+	if opts.SessionKey == nil {
+		opts.SessionKey = make([]byte, 32)
+		if _, err := rand.Read(opts.SessionKey); err != nil {
+			return nil, err
+		}
+	}
+	if opts.SessionTTL == 0 {
+		opts.SessionTTL = defaultSessionTTL
+	}
+	tlsConfig := &tls.Config{}
+	if opts.RootCAFile != "" {
+		data, err := ioutil.ReadFile(opts.RootCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Dex root CA: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.RootCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return &Authenticator{
+		opts: opts,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig},
+		},
+		now: time.Now,
+	}, nil
+}
+
+func (a *Authenticator) register(mux *http.ServeMux) {
+	mux.HandleFunc(loginPath, a.login)
+	mux.HandleFunc(callbackPath, a.callback)
+	mux.HandleFunc(logoutPath, a.logout)
+}
+
+// clientContext returns the context that makes the OpenID Connect and OAuth2 libraries use the Dex HTTP client
+func (a *Authenticator) clientContext(ctx context.Context) context.Context {
+	return oidc.ClientContext(ctx, a.client)
+}
+
+// getProvider discovers Dex endpoints and keys on the first sign in, so the server starts even if Dex is not available
+func (a *Authenticator) getProvider() (*oidc.Provider, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.provider != nil {
+		return a.provider, nil
+	}
+	// the key set uses the provider context to fetch keys, so it must outlive the request
+	provider, err := oidc.NewProvider(a.clientContext(context.Background()), a.opts.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Dex metadata: %v", err)
+	}
+	a.provider = provider
+	return a.provider, nil
+}
+
+func (a *Authenticator) oauth2Config(provider *oidc.Provider, clientSecret string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     a.opts.ClientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  a.opts.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       []string{oidc.ScopeOpenID, "email", "profile", "groups"},
+	}
+}
+
+func (a *Authenticator) sign(payload string) string {
+	mac := hmac.New(sha256.New, a.opts.SessionKey)
+	_, _ = mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// encode returns the signed cookie value that holds the JSON encoded value
+func (a *Authenticator) encode(val interface{}) (string, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + a.sign(payload), nil
+}
+
+// decode verifies the signature of the cookie value and decodes it into the given value
+func (a *Authenticator) decode(cookie string, val interface{}) error {
+	parts := strings.Split(cookie, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(a.sign(parts[0]))) {
+		return errors.New("invalid signature")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, val)
+}
+
+func (a *Authenticator) newSession(user User) (string, error) {
+	return a.encode(user)
+}
+
+// User returns the user of the valid session cookie
+func (a *Authenticator) User(r *http.Request) (*User, error) {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return nil, errors.New("not signed in")
+	}
+	var user User
+	if err := a.decode(cookie.Value, &user); err != nil {
+		return nil, errors.New("invalid session")
+	}
+	if a.now().Unix() > user.Expiry {
+		return nil, errors.New("session expired")
+	}
+	return &user, nil
+}
+
+func (a *Authenticator) secureCookies() bool {
+	return strings.HasPrefix(a.opts.RedirectURL, "https://")
+}
+
+func randomString() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func newLoginState() (*loginState, error) {
+	var state loginState
+	for _, field := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		val, err := randomString()
+		if err != nil {
+			return nil, err
+		}
+		*field = val
+	}
+	return &state, nil
+}
+
+// codeChallenge returns the S256 PKCE code challenge of the verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (a *Authenticator) login(w http.ResponseWriter, r *http.Request) {
+	provider, err := a.getProvider()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	state, err := newLoginState()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	cookie, err := a.encode(state)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name: stateCookieName, Value: cookie, Path: PathPrefix,
+		MaxAge: int(loginTimeout.Seconds()), HttpOnly: true, Secure: a.secureCookies(), SameSite: http.SameSiteLaxMode,
+	})
+	authURL := a.oauth2Config(provider, "").AuthCodeURL(state.State,
+		oidc.Nonce(state.Nonce),
+		oauth2.SetAuthURLParam("code_challenge", codeChallenge(state.Verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (a *Authenticator) callback(w http.ResponseWriter, r *http.Request) {
+	var state loginState
+	cookie, err := r.Cookie(stateCookieName)
+	if err != nil || a.decode(cookie.Value, &state) != nil ||
+		subtle.ConstantTimeCompare([]byte(state.State), []byte(r.URL.Query().Get("state"))) != 1 {
+		http.Error(w, "invalid sign in state", http.StatusBadRequest)
+		return
+	}
+	if errMessage := r.URL.Query().Get("error"); errMessage != "" {
+		http.Error(w, fmt.Sprintf("sign in failed: %s %s", errMessage, r.URL.Query().Get("error_description")), http.StatusUnauthorized)
+		return
+	}
+	user, err := a.exchange(r.Context(), r.URL.Query().Get("code"), state)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("sign in failed: %v", err), http.StatusUnauthorized)
+		return
+	}
+	session, err := a.newSession(*user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: stateCookieName, Path: PathPrefix, MaxAge: -1})
+	http.SetCookie(w, &http.Cookie{
+		Name: sessionCookieName, Value: session, Path: PathPrefix,
+		Expires: time.Unix(user.Expiry, 0), HttpOnly: true, Secure: a.secureCookies(), SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, PathPrefix, http.StatusFound)
+}
+
+func (a *Authenticator) logout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: sessionCookieName, Path: PathPrefix, MaxAge: -1})
+	_, _ = w.Write([]byte("signed out"))
+}
+
+// exchange exchanges the authorization code to the ID token and verifies the token signature, issuer, audience, expiry
+// and nonce. The session expires together with the ID token if the token expires earlier than the session TTL
+func (a *Authenticator) exchange(ctx context.Context, code string, state loginState) (*User, error) {
+	provider, err := a.getProvider()
+	if err != nil {
+		return nil, err
+	}
+	clientSecret := ""
+	if a.opts.ClientSecretFile != "" {
+		data, err := ioutil.ReadFile(a.opts.ClientSecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Dex client secret: %v", err)
+		}
+		clientSecret = strings.TrimSpace(string(data))
+	}
+	ctx = a.clientContext(ctx)
+	token, err := a.oauth2Config(provider, clientSecret).Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", state.Verifier))
+	if err != nil {
+		return nil, err
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("Dex token response has no ID token")
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: a.opts.ClientID, Now: a.now}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %v", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(state.Nonce)) != 1 {
+		return nil, errors.New("invalid ID token: nonce does not match")
+	}
+	var claims struct {
+		Email  string   `json:"email"`
+		Groups []string `json:"groups"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("invalid ID token: %v", err)
+	}
+	expiry := a.now().Add(a.opts.SessionTTL)
+	if idToken.Expiry.Before(expiry) {
+		expiry = idToken.Expiry
+	}
+	return &User{Email: claims.Email, Subject: idToken.Subject, Groups: claims.Groups, Expiry: expiry.Unix()}, nil
+}
@@ -0,0 +1,163 @@
+package webui
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// fakeDex issues the ID token with the specified claims for any authorization code and verifies the PKCE code verifier
+type fakeDex struct {
+	*httptest.Server
+	claims    map[string]interface{}
+	nonce     string
+	challenge string
+}
+
+func startDex(t *testing.T, claims map[string]interface{}) *fakeDex {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key, KeyID: "test"}}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	dex := &fakeDex{claims: claims}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer": dex.URL, "authorization_endpoint": dex.URL + "/auth", "token_endpoint": dex.URL + "/token",
+			"jwks_uri": dex.URL + "/keys", "id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "my-code", r.Form.Get("code"))
+		assert.Equal(t, dex.challenge, codeChallenge(r.Form.Get("code_verifier")))
+		if dex.claims["iss"] == nil {
+			dex.claims["iss"] = dex.URL
+		}
+		if dex.claims["nonce"] == nil {
+			dex.claims["nonce"] = dex.nonce
+		}
+		payload, _ := json.Marshal(dex.claims)
+		token, err := signer.Sign(payload)
+		if !assert.NoError(t, err) {
+			return
+		}
+		idToken, _ := token.CompactSerialize()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "abc", "token_type": "bearer", "id_token": idToken})
+	})
+	dex.Server = httptest.NewServer(mux)
+	return dex
+}
+
+func newTestAuthenticator(t *testing.T, issuer string) *Authenticator {
+	auth, err := NewAuthenticator(DexOptions{IssuerURL: issuer, ClientID: "argocd-notifications", RedirectURL: "https://example.com/ui/auth/callback"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return auth
+}
+
+func signIn(auth *Authenticator, dex *fakeDex) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	auth.register(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, loginPath, nil))
+	location, _ := url.Parse(w.Header().Get("Location"))
+	state := location.Query().Get("state")
+	dex.nonce = location.Query().Get("nonce")
+	dex.challenge = location.Query().Get("code_challenge")
+
+	r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("%s?code=my-code&state=%s", callbackPath, state), nil)
+	for _, cookie := range w.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+func TestSignIn(t *testing.T) {
+	dex := startDex(t, map[string]interface{}{
+		"aud": "argocd-notifications", "exp": time.Now().Add(time.Hour).Unix(),
+		"sub": "123", "email": "alice@example.com", "groups": []string{"ops"},
+	})
+	defer dex.Close()
+	auth := newTestAuthenticator(t, dex.URL)
+
+	w := signIn(auth, dex)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	r := httptest.NewRequest(http.MethodGet, PathPrefix, nil)
+	for _, cookie := range w.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+	user, err := auth.User(r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "alice@example.com", user.Name())
+		assert.Equal(t, []string{"alice@example.com", "ops"}, user.Subjects())
+		// the session does not outlive the ID token
+		assert.InDelta(t, time.Now().Add(time.Hour).Unix(), user.Expiry, 5)
+	}
+}
+
+func TestSignIn_WrongAudience(t *testing.T) {
+	dex := startDex(t, map[string]interface{}{"aud": []string{"argo-cd"}, "exp": time.Now().Add(time.Hour).Unix(), "sub": "123"})
+	defer dex.Close()
+
+	w := signIn(newTestAuthenticator(t, dex.URL), dex)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid ID token")
+}
+
+func TestSignIn_WrongNonce(t *testing.T) {
+	dex := startDex(t, map[string]interface{}{
+		"aud": "argocd-notifications", "exp": time.Now().Add(time.Hour).Unix(), "sub": "123", "nonce": "replayed",
+	})
+	defer dex.Close()
+
+	w := signIn(newTestAuthenticator(t, dex.URL), dex)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "nonce does not match")
+}
+
+func TestSignIn_InvalidState(t *testing.T) {
+	auth := newTestAuthenticator(t, "https://argocd.example.com/api/dex")
+	mux := http.NewServeMux()
+	auth.register(mux)
+	w := httptest.NewRecorder()
+
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, callbackPath+"?code=my-code&state=123", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUser_Expired(t *testing.T) {
+	auth := newTestAuthenticator(t, "https://argocd.example.com/api/dex")
+	session, err := auth.newSession(User{Subject: "123", Expiry: time.Now().Add(-time.Minute).Unix()})
+	assert.NoError(t, err)
+	r := httptest.NewRequest(http.MethodGet, PathPrefix, nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookieName, Value: session})
+
+	_, err = auth.User(r)
+
+	assert.EqualError(t, err, "session expired")
+}
@@ -0,0 +1,142 @@
+package webui
+
+// indexHTML is the single page UI that uses the JSON endpoints of the server. It has no external dependencies, so the
+// UI works in air-gapped installations
+const indexHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Argo CD Notifications</title>
+<style>
+body { font-family: sans-serif; margin: 0; color: #333; }
+header { background: #0dadea; color: #fff; padding: 10px 20px; display: flex; justify-content: space-between; }
+header a { color: #fff; }
+main { display: flex; }
+section { padding: 10px 20px; }
+#apps { width: 30%; border-right: 1px solid #ddd; }
+#details { flex: 1; }
+li { cursor: pointer; padding: 2px 0; }
+li.selected { font-weight: bold; }
+table { border-collapse: collapse; width: 100%; }
+td, th { border-bottom: 1px solid #eee; padding: 4px; text-align: left; }
+pre { background: #f5f5f5; padding: 10px; white-space: pre-wrap; }
+.Failed { color: #c00; }
+</style>
+</head>
+<body>
+<header><span>Argo CD Notifications</span><span><span id="user"></span> | <a href="auth/logout">Sign out</a></span></header>
+<main>
+<section id="apps"><h3>Applications</h3><input id="filter" placeholder="Filter"><ul id="app-list"></ul></section>
+<section id="details" hidden>
+<h3 id="app-name"></h3>
+<h4>Subscriptions</h4>
+<table><thead><tr><th>Trigger</th><th>Recipient</th><th></th></tr></thead><tbody id="subscriptions"></tbody></table>
+<p><input id="new-trigger" placeholder="trigger, e.g. on-sync-failed"> <input id="new-recipient" placeholder="service:recipient"> <button id="subscribe">Subscribe</button></p>
+<h4>Template preview</h4>
+<p><select id="templates"></select> <input id="preview-recipient" placeholder="service:recipient (optional)"> <button id="preview">Preview</button></p>
+<pre id="preview-result" hidden></pre>
+<h4>Recent deliveries</h4>
+<table><thead><tr><th>Time</th><th>Trigger</th><th>Recipient</th><th>Result</th></tr></thead><tbody id="history"></tbody></table>
+</section>
+</main>
+<script>
+var apps = [], selected = null;
+
+function request(method, path, body) {
+  return fetch(path, {method: method, credentials: 'same-origin', headers: {'Content-Type': 'application/json'}, body: body && JSON.stringify(body)})
+    .then(function (res) {
+      if (res.status === 401) { window.location = 'auth/login'; }
+      if (!res.ok) { return res.text().then(function (text) { throw new Error(text); }); }
+      return res.status === 204 ? null : res.json();
+    });
+}
+
+function cell(row, text, className) {
+  var td = row.insertCell();
+  td.textContent = text;
+  if (className) { td.className = className; }
+  return td;
+}
+
+function renderApps() {
+  var list = document.getElementById('app-list'), filter = document.getElementById('filter').value;
+  list.innerHTML = '';
+  apps.filter(function (app) { return app.name.indexOf(filter) >= 0; }).forEach(function (app) {
+    var li = document.createElement('li');
+    li.textContent = app.project + '/' + app.name;
+    li.className = selected && selected.name === app.name ? 'selected' : '';
+    li.onclick = function () { select(app.name); };
+    list.appendChild(li);
+  });
+}
+
+function toggle(trigger, recipient, subscribed) {
+  request('POST', 'api/apps/' + encodeURIComponent(selected.name) + '/subscriptions', {trigger: trigger, recipient: recipient, subscribed: subscribed})
+    .then(loadApps).then(function () { select(selected.name); }).catch(function (err) { alert(err.message); });
+}
+
+function select(name) {
+  selected = apps.filter(function (app) { return app.name === name; })[0];
+  renderApps();
+  document.getElementById('details').hidden = false;
+  document.getElementById('app-name').textContent = selected.project + '/' + selected.name;
+  var subscriptions = document.getElementById('subscriptions');
+  subscriptions.innerHTML = '';
+  (selected.subscriptions || []).forEach(function (sub) {
+    var row = subscriptions.insertRow();
+    cell(row, sub.trigger);
+    cell(row, sub.recipient);
+    var actions = cell(row, sub.editable ? '' : 'inherited');
+    if (sub.editable) {
+      var button = document.createElement('button');
+      button.textContent = 'Unsubscribe';
+      button.onclick = function () { toggle(sub.trigger, sub.recipient, false); };
+      actions.appendChild(button);
+    }
+  });
+  request('GET', 'api/history?app=' + encodeURIComponent(name)).then(function (records) {
+    var history = document.getElementById('history');
+    history.innerHTML = '';
+    records.forEach(function (record) {
+      var row = history.insertRow();
+      cell(row, record.timestamp);
+      cell(row, record.trigger);
+      cell(row, record.service + ':' + record.recipient);
+      cell(row, record.result + (record.error ? ': ' + record.error : ''), record.result);
+    });
+  });
+}
+
+function loadApps() {
+  return request('GET', 'api/apps').then(function (res) { apps = res; renderApps(); });
+}
+
+document.getElementById('filter').oninput = renderApps;
+document.getElementById('subscribe').onclick = function () {
+  toggle(document.getElementById('new-trigger').value, document.getElementById('new-recipient').value, true);
+};
+document.getElementById('preview').onclick = function () {
+  var query = '?template=' + encodeURIComponent(document.getElementById('templates').value) +
+    '&recipient=' + encodeURIComponent(document.getElementById('preview-recipient').value);
+  var result = document.getElementById('preview-result');
+  request('GET', 'api/apps/' + encodeURIComponent(selected.name) + '/preview' + query).then(function (notification) {
+    result.textContent = JSON.stringify(notification, null, 2);
+  }).catch(function (err) {
+    result.textContent = err.message;
+  }).then(function () { result.hidden = false; });
+};
+
+request('GET', 'api/userinfo').then(function (user) { document.getElementById('user').textContent = user.email || user.sub; });
+request('GET', 'api/templates').then(function (templates) {
+  var select = document.getElementById('templates');
+  templates.forEach(function (name) {
+    var option = document.createElement('option');
+    option.value = option.textContent = name;
+    select.appendChild(option);
+  });
+});
+loadApps();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,397 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/argoproj-labs/argocd-notifications/expr"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/shared/argocd"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/legacy"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+)
+
+const (
+	// PathPrefix is the path prefix of the web UI and its endpoints
+	PathPrefix = "/ui/"
+
+	appsPath      = PathPrefix + "api/apps"
+	templatesPath = PathPrefix + "api/templates"
+	historyPath   = PathPrefix + "api/history"
+	userInfoPath  = PathPrefix + "api/userinfo"
+
+	maxRequestSize = 64 * 1024
+	// maxHistoryRecords limits the number of delivery history records returned to the UI
+	maxHistoryRecords = 100
+)
+
+// Subscription is the recipient of the application trigger notifications
+type Subscription struct {
+	Trigger string `json:"trigger"`
+	// Recipient has the <service>:<recipient> format
+	Recipient string `json:"recipient"`
+	// Editable is true if the subscription is configured using the application annotation and might be toggled in the UI
+	Editable bool `json:"editable"`
+}
+
+// App is the application with its effective subscriptions
+type App struct {
+	Name          string         `json:"name"`
+	Project       string         `json:"project"`
+	Subscriptions []Subscription `json:"subscriptions"`
+}
+
+// UpdateSubscriptionRequest is the body of the request that subscribes or unsubscribes the recipient
+type UpdateSubscriptionRequest struct {
+	Trigger    string `json:"trigger"`
+	Recipient  string `json:"recipient"`
+	Subscribed bool   `json:"subscribed"`
+}
+
+// HistoryRecord is the notification delivery attempt
+type HistoryRecord struct {
+	App       string `json:"app"`
+	Trigger   string `json:"trigger"`
+	Service   string `json:"service"`
+	Recipient string `json:"recipient"`
+	Result    string `json:"result"`
+	Error     string `json:"error,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
+
+type Opts func(s *server)
+
+// WithRBAC enables the check that the signed in user is allowed to update the application before toggling subscriptions.
+// The user email and groups are used as Argo CD RBAC subjects
+func WithRBAC(enforcer argocd.Enforcer) Opts {
+	return func(s *server) {
+		s.enforcer = enforcer
+	}
+}
+
+// NewServer returns handler that serves the web UI. All UI endpoints require the user signed in using the authenticator.
+// The getConfig function returns nil until the configuration is loaded
+func NewServer(dynamicClient dynamic.Interface, namespace string, getConfig func() *settings.Config, auth *Authenticator, opts ...Opts) *server {
+	s := &server{
+		appClient:     k8s.NewAppClient(dynamicClient, namespace),
+		appProjClient: k8s.NewAppProjClient(dynamicClient, namespace),
+		historyClient: k8s.NewNotificationHistoryClient(dynamicClient, namespace),
+		getConfig:     getConfig,
+		auth:          auth,
+		mux:           http.NewServeMux(),
+	}
+	s.mux.HandleFunc(PathPrefix, s.index)
+	s.mux.HandleFunc(appsPath, s.listApps)
+	s.mux.HandleFunc(appsPath+"/", s.app)
+	s.mux.HandleFunc(templatesPath, s.listTemplates)
+	s.mux.HandleFunc(historyPath, s.listHistory)
+	s.mux.HandleFunc(userInfoPath, s.userInfo)
+	auth.register(s.mux)
+	for i := range opts {
+		opts[i](s)
+	}
+	return s
+}
+
+type server struct {
+	appClient     dynamic.ResourceInterface
+	appProjClient dynamic.ResourceInterface
+	historyClient dynamic.ResourceInterface
+	getConfig     func() *settings.Config
+	auth          *Authenticator
+	enforcer      argocd.Enforcer
+	mux           *http.ServeMux
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, val interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(val); err != nil {
+		log.Warnf("Failed to write UI response: %v", err)
+	}
+}
+
+// authenticated returns the signed in user or responds with 401 if the session is missing or expired
+func (s *server) authenticated(w http.ResponseWriter, r *http.Request) (*User, bool) {
+	user, err := s.auth.User(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+	return user, true
+}
+
+func (s *server) config(w http.ResponseWriter) (*settings.Config, bool) {
+	cfg := s.getConfig()
+	if cfg == nil {
+		http.Error(w, "configuration is not loaded yet", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return cfg, true
+}
+
+func (s *server) index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != PathPrefix {
+		http.NotFound(w, r)
+		return
+	}
+	if _, err := s.auth.User(r); err != nil {
+		http.Redirect(w, r, loginPath, http.StatusFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(indexHTML))
+}
+
+func (s *server) userInfo(w http.ResponseWriter, r *http.Request) {
+	if user, ok := s.authenticated(w, r); ok {
+		writeJSON(w, user)
+	}
+}
+
+// getSubscriptions returns effective subscriptions of the application. Only subscriptions configured using application
+// annotations are editable because project and default subscriptions are shared with other applications
+func (s *server) getSubscriptions(ctx context.Context, cfg *settings.Config, app unstructured.Unstructured) []Subscription {
+	own := subscriptions.Annotations(app.GetAnnotations()).GetAll(cfg.DefaultTriggers...)
+	var sources settings.SubscriptionSources
+	if projName, ok, _ := unstructured.NestedString(app.Object, "spec", "project"); ok {
+		if proj, err := s.appProjClient.Get(ctx, projName, metav1.GetOptions{}); err == nil {
+			sources.Project = proj
+		} else if !apierr.IsNotFound(err) {
+			log.Warnf("Failed to get project %s: %v", projName, err)
+		}
+	}
+	all := cfg.ResolveSubscriptions(&app, sources)
+
+	var res []Subscription
+	for trigger, destinations := range all {
+		for _, dest := range destinations {
+			editable := false
+			for _, ownDest := range own[trigger] {
+				editable = editable || ownDest == dest
+			}
+			res = append(res, Subscription{Trigger: trigger, Recipient: dest.Service + ":" + dest.Recipient, Editable: editable})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Trigger != res[j].Trigger {
+			return res[i].Trigger < res[j].Trigger
+		}
+		return res[i].Recipient < res[j].Recipient
+	})
+	return res
+}
+
+func (s *server) listApps(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authenticated(w, r); !ok {
+		return
+	}
+	cfg, ok := s.config(w)
+	if !ok {
+		return
+	}
+	list, err := s.appClient.List(r.Context(), metav1.ListOptions{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	apps := []App{}
+	for _, item := range list.Items {
+		project, _, _ := unstructured.NestedString(item.Object, "spec", "project")
+		apps = append(apps, App{Name: item.GetName(), Project: project, Subscriptions: s.getSubscriptions(r.Context(), cfg, item)})
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].Name < apps[j].Name
+	})
+	writeJSON(w, apps)
+}
+
+// app serves /ui/api/apps/<name>/subscriptions and /ui/api/apps/<name>/preview endpoints
+func (s *server) app(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.authenticated(w, r)
+	if !ok {
+		return
+	}
+	cfg, ok := s.config(w)
+	if !ok {
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, appsPath+"/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	app, err := s.appClient.Get(r.Context(), parts[0], metav1.GetOptions{})
+	if apierr.IsNotFound(err) {
+		http.Error(w, fmt.Sprintf("application '%s' not found", parts[0]), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	switch {
+	case parts[1] == "subscriptions" && r.Method == http.MethodPost:
+		s.updateSubscription(w, r, user, app)
+	case parts[1] == "preview" && r.Method == http.MethodGet:
+		s.preview(w, r, cfg, app)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func parseRecipient(recipient string) (services.Destination, error) {
+	parts := strings.SplitN(recipient, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return services.Destination{}, fmt.Errorf("recipient must have the <service>:<recipient> format, got '%s'", recipient)
+	}
+	return services.Destination{Service: parts[0], Recipient: parts[1]}, nil
+}
+
+func (s *server) updateSubscription(w http.ResponseWriter, r *http.Request, user *User, app *unstructured.Unstructured) {
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req UpdateSubscriptionRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	dest, err := parseRecipient(req.Recipient)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.enforcer != nil {
+		project, _, _ := unstructured.NestedString(app.Object, "spec", "project")
+		allowed, err := s.isAllowed(r.Context(), user, project+"/"+app.GetName())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			http.Error(w, fmt.Sprintf("user %s is not allowed to update application %s", user.Name(), app.GetName()), http.StatusForbidden)
+			return
+		}
+	}
+	err = k8s.UpdateAnnotations(r.Context(), s.appClient, app.GetName(), func(annotations map[string]string) {
+		if req.Subscribed {
+			subscriptions.Annotations(annotations).Subscribe(req.Trigger, dest.Service, dest.Recipient)
+		} else {
+			subscriptions.Annotations(annotations).Unsubscribe(req.Trigger, dest.Service, dest.Recipient)
+		}
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.WithFields(log.Fields{"user": user.Name(), "app": app.GetName(), "trigger": req.Trigger, "recipient": req.Recipient, "subscribed": req.Subscribed}).
+		Info("Subscription updated using web UI")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) isAllowed(ctx context.Context, user *User, object string) (bool, error) {
+	for _, subject := range user.Subjects() {
+		allowed, err := s.enforcer.Enforce(ctx, subject, argocd.ResourceApplications, argocd.ActionUpdate, object)
+		if err != nil || allowed {
+			return allowed, err
+		}
+	}
+	return false, nil
+}
+
+// preview renders the template against the live application without sending the notification
+func (s *server) preview(w http.ResponseWriter, r *http.Request, cfg *settings.Config, app *unstructured.Unstructured) {
+	template := r.URL.Query().Get("template")
+	if _, ok := cfg.Templates[template]; !ok {
+		http.Error(w, fmt.Sprintf("template '%s' not found", template), http.StatusBadRequest)
+		return
+	}
+	dest := services.Destination{Service: "console"}
+	if recipient := r.URL.Query().Get("recipient"); recipient != "" {
+		var err error
+		if dest, err = parseRecipient(recipient); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	vars := expr.Spawn(app, cfg.ArgoCDService, map[string]interface{}{
+		"app":     app.Object,
+		"context": legacy.InjectLegacyVar(cfg.Context, dest.Service),
+	})
+	notification, err := cfg.API.FormatNotification(vars, []string{template}, dest)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to render template: %v", err), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, notification)
+}
+
+func (s *server) listTemplates(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authenticated(w, r); !ok {
+		return
+	}
+	cfg, ok := s.config(w)
+	if !ok {
+		return
+	}
+	names := []string{}
+	for name := range cfg.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	writeJSON(w, names)
+}
+
+func (s *server) listHistory(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authenticated(w, r); !ok {
+		return
+	}
+	opts := metav1.ListOptions{}
+	if app := r.URL.Query().Get("app"); app != "" {
+		opts.LabelSelector = history.AppLabel + "=" + app
+	}
+	list, err := s.historyClient.List(r.Context(), opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	records := []HistoryRecord{}
+	for _, item := range list.Items {
+		record := HistoryRecord{}
+		record.App, _, _ = unstructured.NestedString(item.Object, "spec", "app")
+		record.Trigger, _, _ = unstructured.NestedString(item.Object, "spec", "trigger")
+		record.Service, _, _ = unstructured.NestedString(item.Object, "spec", "destination", "service")
+		record.Recipient, _, _ = unstructured.NestedString(item.Object, "spec", "destination", "recipient")
+		record.Result, _, _ = unstructured.NestedString(item.Object, "spec", "result")
+		record.Error, _, _ = unstructured.NestedString(item.Object, "spec", "error")
+		record.Timestamp, _, _ = unstructured.NestedString(item.Object, "spec", "timestamp")
+		records = append(records, record)
+	}
+	// RFC3339 timestamps in UTC are sorted lexicographically
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Timestamp > records[j].Timestamp
+	})
+	if len(records) > maxHistoryRecords {
+		records = records[:maxHistoryRecords]
+	}
+	writeJSON(w, records)
+}
@@ -0,0 +1,173 @@
+package webui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/argoproj-labs/argocd-notifications/pkg"
+	"github.com/argoproj-labs/argocd-notifications/pkg/mocks"
+	"github.com/argoproj-labs/argocd-notifications/pkg/services"
+	"github.com/argoproj-labs/argocd-notifications/pkg/subscriptions"
+	"github.com/argoproj-labs/argocd-notifications/shared/history"
+	"github.com/argoproj-labs/argocd-notifications/shared/k8s"
+	"github.com/argoproj-labs/argocd-notifications/shared/settings"
+	. "github.com/argoproj-labs/argocd-notifications/testing"
+)
+
+func newTestServer(t *testing.T, cfg *settings.Config, objects ...runtime.Object) (*server, *fake.FakeDynamicClient, string) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+	auth, err := NewAuthenticator(DexOptions{IssuerURL: "https://argocd.example.com/api/dex", ClientID: "argocd-notifications", RedirectURL: "https://example.com/ui/auth/callback"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	session, err := auth.newSession(User{Email: "alice@example.com", Groups: []string{"ops"}, Expiry: time.Now().Add(time.Hour).Unix()})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return NewServer(client, TestNamespace, func() *settings.Config {
+		return cfg
+	}, auth), client, session
+}
+
+func do(s http.Handler, session string, method string, path string, body interface{}) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	r := httptest.NewRequest(method, path, bytes.NewReader(data))
+	if session != "" {
+		r.AddCookie(&http.Cookie{Name: sessionCookieName, Value: session})
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestListApps(t *testing.T) {
+	cfg := &settings.Config{Subscriptions: settings.DefaultSubscriptions{{Recipients: []string{"slack:ops"}, Triggers: []string{"on-sync-failed"}}}}
+	s, _, session := newTestServer(t, cfg, NewApp("guestbook", WithProject("prod"), WithAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("on-deployed", "slack"): "dev",
+	})))
+
+	w := do(s, session, http.MethodGet, appsPath, nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var apps []App
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &apps))
+	assert.Equal(t, []App{{Name: "guestbook", Project: "prod", Subscriptions: []Subscription{
+		{Trigger: "on-deployed", Recipient: "slack:dev", Editable: true},
+		{Trigger: "on-sync-failed", Recipient: "slack:ops"},
+	}}}, apps)
+}
+
+func TestListApps_Unauthenticated(t *testing.T) {
+	s, _, _ := newTestServer(t, &settings.Config{}, NewApp("guestbook"))
+
+	w := do(s, "", http.MethodGet, appsPath, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = do(s, "invalid.session", http.MethodGet, appsPath, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = do(s, "", http.MethodGet, PathPrefix, nil)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, loginPath, w.Header().Get("Location"))
+}
+
+func TestUpdateSubscription(t *testing.T) {
+	s, client, session := newTestServer(t, &settings.Config{}, NewApp("guestbook"))
+
+	w := do(s, session, http.MethodPost, appsPath+"/guestbook/subscriptions", UpdateSubscriptionRequest{Trigger: "on-deployed", Recipient: "slack:dev", Subscribed: true})
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	app, err := k8s.NewAppClient(client, TestNamespace).Get(context.Background(), "guestbook", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", app.GetAnnotations()[subscriptions.SubscribeAnnotationKey("on-deployed", "slack")])
+}
+
+type fakeEnforcer map[string]bool
+
+func (e fakeEnforcer) Enforce(_ context.Context, subject string, _ string, _ string, object string) (bool, error) {
+	return e[subject+" "+object], nil
+}
+
+func TestUpdateSubscription_RBAC(t *testing.T) {
+	s, _, session := newTestServer(t, &settings.Config{}, NewApp("guestbook", WithProject("prod")))
+
+	WithRBAC(fakeEnforcer{"ops prod/guestbook": true})(s)
+	w := do(s, session, http.MethodPost, appsPath+"/guestbook/subscriptions", UpdateSubscriptionRequest{Recipient: "slack:dev", Subscribed: true})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	WithRBAC(fakeEnforcer{})(s)
+	w = do(s, session, http.MethodPost, appsPath+"/guestbook/subscriptions", UpdateSubscriptionRequest{Recipient: "slack:dev", Subscribed: false})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestUpdateSubscription_InvalidRecipient(t *testing.T) {
+	s, _, session := newTestServer(t, &settings.Config{}, NewApp("guestbook"))
+
+	w := do(s, session, http.MethodPost, appsPath+"/guestbook/subscriptions", UpdateSubscriptionRequest{Recipient: "slack", Subscribed: true})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPreview(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	api := mocks.NewMockAPI(ctrl)
+	app := NewApp("guestbook")
+	api.EXPECT().FormatNotification(gomock.Any(), []string{"app-deployed"}, services.Destination{Service: "slack", Recipient: "dev"}).
+		DoAndReturn(func(vars map[string]interface{}, _ []string, _ services.Destination) (*services.Notification, error) {
+			assert.Equal(t, app.Object, vars["app"])
+			return &services.Notification{Message: "deployed"}, nil
+		})
+	cfg := &settings.Config{API: api, Config: pkg.Config{Templates: map[string]services.Notification{"app-deployed": {}}}}
+	s, _, session := newTestServer(t, cfg, app)
+
+	w := do(s, session, http.MethodGet, appsPath+"/guestbook/preview?template=app-deployed&recipient=slack:dev", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var notification services.Notification
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &notification))
+	assert.Equal(t, "deployed", notification.Message)
+
+	w = do(s, session, http.MethodGet, appsPath+"/guestbook/preview?template=unknown", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListHistory(t *testing.T) {
+	s, client, session := newTestServer(t, &settings.Config{})
+	recorder := history.NewRecorder(client, TestNamespace, time.Hour)
+	now := time.Now()
+	for _, record := range []history.Record{
+		{App: "guestbook", Trigger: "on-deployed", Destination: services.Destination{Service: "slack", Recipient: "dev"}, Timestamp: now.Add(-time.Hour)},
+		{App: "guestbook", Trigger: "on-sync-failed", Destination: services.Destination{Service: "slack", Recipient: "ops"}, Error: errors.New("channel not found"), Timestamp: now},
+		{App: "other", Trigger: "on-deployed", Destination: services.Destination{Service: "slack", Recipient: "dev"}, Timestamp: now},
+	} {
+		assert.NoError(t, recorder.Record(context.Background(), record))
+	}
+
+	w := do(s, session, http.MethodGet, historyPath+"?app=guestbook", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var records []HistoryRecord
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &records))
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "on-sync-failed", records[0].Trigger)
+		assert.Equal(t, history.ResultFailed, records[0].Result)
+		assert.Equal(t, "channel not found", records[0].Error)
+		assert.Equal(t, "on-deployed", records[1].Trigger)
+	}
+}